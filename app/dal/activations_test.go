@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryActivations(t *testing.T) {
+	testActivationStore(t, dal.NewMemoryActivations(), dal.NewMemoryLicenses())
+}
+
+func TestPostgreSQLActivations(t *testing.T) {
+	db := newTestDB(t)
+	testActivationStore(t, dal.NewPostgreSQLActivations(db), dal.NewPostgreSQLLicenses(db))
+}
+
+// testActivationStore checks the behavior every ports.ActivationStore must
+// have. Activations are of licenses created in licenses.
+func testActivationStore(t *testing.T, store ports.ActivationStore, licenses ports.LicenseStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var licenseIDs []int
+
+	for i, key := range []string{"AAAAA-AAAAA-AAAAA-AAAA1", "AAAAA-AAAAA-AAAAA-AAAA2"} {
+		l, err := licenses.CreateLicense(ctx, domain.License{
+			ID:           0,
+			Key:          key,
+			OrderID:      i + 1,
+			Line:         0,
+			Seat:         0,
+			CustomerID:   i + 1,
+			ProductID:    1,
+			Version:      "1.2.0",
+			Edition:      domain.EditionStandard,
+			Issued:       now,
+			Expires:      time.Time{},
+			Revoked:      time.Time{},
+			RevokeReason: "",
+			UpgradedFrom: 0,
+		})
+		assert.NoError(t, err)
+
+		licenseIDs = append(licenseIDs, l.ID)
+	}
+
+	activate := func(license int, machine string, at time.Time, limit int) (domain.Activation, error) {
+		return store.CreateActivation(ctx, domain.Activation{
+			ID:               0,
+			LicenseID:        licenseIDs[license],
+			CustomerID:       license + 1,
+			ProductID:        1,
+			Machine:          machine,
+			MachineName:      "Studio " + machine,
+			Activated:        at,
+			LastSeen:         at,
+			Deactivated:      time.Time{},
+			DeactivateReason: "",
+		}, limit)
+	}
+
+	first, err := activate(0, "machine-0000000001", now, 2)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID, 0)
+
+	// A license is active once per machine, and on at most limit machines.
+	_, err = activate(0, first.Machine, now, 2)
+	assert.Error(t, err, ports.ErrConflict)
+
+	second, err := activate(0, "machine-0000000002", now.Add(time.Hour), 2)
+	assert.NoError(t, err)
+
+	_, err = activate(0, "machine-0000000003", now, 2)
+	assert.Error(t, err, ports.ErrConflict)
+
+	// Licenses of other customers and unlimited licenses are unaffected.
+	other, err := activate(1, first.Machine, now, 0)
+	assert.NoError(t, err)
+
+	got, err := store.Activation(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Machine, first.Machine)
+	assert.Equal(t, got.MachineName, "Studio machine-0000000001")
+	assert.True(t, got.Activated.Equal(now))
+	assert.True(t, got.Active())
+
+	_, err = store.Activation(ctx, 1000)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	got, err = store.MachineActivation(ctx, licenseIDs[0], second.Machine)
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, second.ID)
+
+	_, err = store.MachineActivation(ctx, licenseIDs[0], "machine-0000000003")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	assert.NoError(t, store.TouchActivation(ctx, first.ID, now.Add(2*time.Hour)))
+
+	got, err = store.Activation(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.True(t, got.LastSeen.Equal(now.Add(2*time.Hour)))
+
+	assert.Error(t, store.TouchActivation(ctx, 1000, now), ports.ErrNotFound)
+
+	// Deactivating frees a seat, and keeps the first deactivation.
+	assert.NoError(t, store.DeactivateActivation(ctx, first.ID, now.Add(3*time.Hour), "replaced"))
+	assert.NoError(t, store.DeactivateActivation(ctx, first.ID, now.Add(4*time.Hour), "again"))
+	assert.Error(t, store.DeactivateActivation(ctx, 1000, now, ""), ports.ErrNotFound)
+
+	got, err = store.Activation(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.Active())
+	assert.True(t, got.Deactivated.Equal(now.Add(3*time.Hour)))
+	assert.Equal(t, got.DeactivateReason, "replaced")
+
+	assert.Error(t, store.TouchActivation(ctx, first.ID, now), ports.ErrNotFound)
+
+	_, err = store.MachineActivation(ctx, licenseIDs[0], first.Machine)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	third, err := activate(0, "machine-0000000003", now.Add(4*time.Hour), 2)
+	assert.NoError(t, err)
+
+	// Deactivated machines may be activated again.
+	assert.NoError(t, store.DeactivateActivation(ctx, third.ID, now.Add(5*time.Hour), "moved"))
+
+	again, err := activate(0, first.Machine, now.Add(5*time.Hour), 2)
+	assert.NoError(t, err)
+
+	all, err := store.LicenseActivations(ctx, licenseIDs[0])
+	assert.NoError(t, err)
+	assert.Equal(t, len(all), 4)
+	assert.Equal(t, all[0].ID, first.ID)
+	assert.Equal(t, all[3].ID, again.ID)
+
+	active, err := store.CustomerActivations(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(active), 2)
+	assert.Equal(t, active[0].ID, second.ID)
+	assert.Equal(t, active[1].ID, again.ID)
+
+	// The first license was activated on three machines, one of them
+	// twice, and is active on two.
+	counts, err := store.ActivationCounts(ctx, now, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(counts), 2)
+	assert.Equal(t, counts[0], domain.ActivationCount{
+		LicenseID:  licenseIDs[0],
+		CustomerID: 1,
+		ProductID:  1,
+		Active:     2,
+		Machines:   3,
+	})
+	assert.Equal(t, counts[1].LicenseID, other.LicenseID)
+	assert.Equal(t, counts[1].Machines, 1)
+
+	counts, err = store.ActivationCounts(ctx, now, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(counts), 1)
+
+	counts, err = store.ActivationCounts(ctx, now, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(counts), 1)
+
+	// Activations before the period only count while active.
+	counts, err = store.ActivationCounts(ctx, now.Add(90*time.Minute), 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(counts), 2)
+	assert.Equal(t, counts[0].Active, 2)
+	assert.Equal(t, counts[0].Machines, 2)
+	assert.Equal(t, counts[1].Active, 1)
+	assert.Equal(t, counts[1].Machines, 0)
+
+	// Activations not seen since are expired.
+	expired, err := store.ExpireActivations(ctx, now.Add(90*time.Minute), now.Add(6*time.Hour), "inactive")
+	assert.NoError(t, err)
+	assert.Equal(t, expired, 2)
+
+	got, err = store.Activation(ctx, second.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.Active())
+	assert.Equal(t, got.DeactivateReason, "inactive")
+
+	got, err = store.Activation(ctx, again.ID)
+	assert.NoError(t, err)
+	assert.True(t, got.Active())
+}
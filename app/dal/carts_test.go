@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryCarts(t *testing.T) {
+	testCartStore(t, dal.NewMemoryCarts())
+}
+
+func TestPostgreSQLCarts(t *testing.T) {
+	testCartStore(t, dal.NewPostgreSQLCarts(newTestDB(t)))
+}
+
+// testCartStore checks the behavior every ports.CartStore must have.
+func testCartStore(t *testing.T, store ports.CartStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newCart := func(id string, customerID int, expires time.Time) domain.Cart {
+		return domain.Cart{
+			ID:         id,
+			CustomerID: customerID,
+			Lines: []domain.CartLine{
+				{ProductID: 2, Quantity: 1, Gift: false},
+				{ProductID: 1, Quantity: 3, Gift: true},
+			},
+			Created: now,
+			Updated: now,
+			Expires: expires,
+		}
+	}
+
+	assert.NoError(t, store.SaveCart(ctx, newCart("guest", 0, now.Add(time.Hour))))
+	assert.NoError(t, store.SaveCart(ctx, newCart("other-guest", 0, now.Add(time.Hour))))
+	assert.NoError(t, store.SaveCart(ctx, newCart("customer", 1, now.Add(2*time.Hour))))
+
+	err := store.SaveCart(ctx, newCart("second", 1, now.Add(time.Hour)))
+	assert.Error(t, err, ports.ErrConflict)
+
+	got, err := store.CartByID(ctx, "guest")
+	assert.NoError(t, err)
+	assert.Equal(t, got.CustomerID, 0)
+	assert.Equal(t, len(got.Lines), 2)
+	assert.Equal(t, got.Lines[0], domain.CartLine{ProductID: 2, Quantity: 1, Gift: false})
+	assert.Equal(t, got.Lines[1], domain.CartLine{ProductID: 1, Quantity: 3, Gift: true})
+	assert.True(t, got.Created.Equal(now))
+
+	got, err = store.CartByCustomer(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, "customer")
+
+	_, err = store.CartByCustomer(ctx, 2)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	// Saving replaces the lines.
+	updated := newCart("customer", 1, now.Add(3*time.Hour))
+	updated.Lines = updated.Lines[1:]
+	updated.Updated = now.Add(time.Minute)
+	assert.NoError(t, store.SaveCart(ctx, updated))
+
+	got, err = store.CartByID(ctx, "customer")
+	assert.NoError(t, err)
+	assert.Equal(t, len(got.Lines), 1)
+	assert.True(t, got.Created.Equal(now))
+	assert.True(t, got.Updated.Equal(now.Add(time.Minute)))
+	assert.True(t, got.Expires.Equal(now.Add(3*time.Hour)))
+
+	// A guest cart can be handed to a customer.
+	assert.NoError(t, store.SaveCart(ctx, newCart("other-guest", 2, now.Add(time.Hour))))
+
+	got, err = store.CartByCustomer(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, "other-guest")
+
+	assert.NoError(t, store.DeleteCart(ctx, "customer"))
+	assert.Error(t, store.DeleteCart(ctx, "customer"), ports.ErrNotFound)
+
+	_, err = store.CartByCustomer(ctx, 1)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	n, err := store.DeleteExpiredCarts(ctx, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, n, 2)
+
+	_, err = store.CartByID(ctx, "guest")
+	assert.Error(t, err, ports.ErrNotFound)
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryCustomers(t *testing.T) {
+	testCustomerRepository(t, dal.NewMemoryCustomers())
+}
+
+func TestPostgreSQLCustomers(t *testing.T) {
+	testCustomerRepository(t, dal.NewPostgreSQLCustomers(newTestDB(t)))
+}
+
+// testCustomerRepository checks the behavior every ports.CustomerRepository
+// must have.
+func testCustomerRepository(t *testing.T, store ports.CustomerRepository) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newCustomer := func(email string) domain.Customer {
+		return domain.Customer{
+			ID:                0,
+			Email:             email,
+			HashedPassword:    []byte("hash-" + email),
+			Created:           now,
+			Verified:          false,
+			PaymentCustomerID: "",
+		}
+	}
+
+	first, err := store.CreateCustomer(ctx, newCustomer("first@example.com"))
+	assert.NoError(t, err)
+	assert.True(t, first.ID > 0)
+
+	second, err := store.CreateCustomer(ctx, newCustomer("second@example.com"))
+	assert.NoError(t, err)
+	assert.True(t, second.ID > first.ID)
+
+	_, err = store.CreateCustomer(ctx, newCustomer("first@example.com"))
+	assert.Error(t, err, ports.ErrConflict)
+
+	c, err := store.CustomerByEmail(ctx, "first@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, first.ID)
+	assert.Equal(t, string(c.HashedPassword), "hash-first@example.com")
+	assert.True(t, c.Created.Equal(now))
+	assert.False(t, c.Verified)
+
+	_, err = store.CustomerByEmail(ctx, "nobody@example.com")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	assert.NoError(t, store.MarkCustomerVerified(ctx, first.ID))
+	assert.NoError(t, store.UpdateCustomerPassword(ctx, first.ID, []byte("rehashed")))
+	assert.NoError(t, store.SetPaymentCustomerID(ctx, first.ID, "cus_1"))
+
+	c, err = store.CustomerByID(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, c.Email, "first@example.com")
+	assert.True(t, c.Verified)
+	assert.Equal(t, string(c.HashedPassword), "rehashed")
+	assert.Equal(t, c.PaymentCustomerID, "cus_1")
+
+	// The first key reserved is kept.
+	key, err := store.ReservePaymentCustomerKey(ctx, first.ID, "customer-a")
+	assert.NoError(t, err)
+	assert.Equal(t, key, "customer-a")
+
+	key, err = store.ReservePaymentCustomerKey(ctx, first.ID, "customer-b")
+	assert.NoError(t, err)
+	assert.Equal(t, key, "customer-a")
+
+	const unknown = 1000
+
+	_, err = store.CustomerByID(ctx, unknown)
+	assert.Error(t, err, ports.ErrNotFound)
+	assert.Error(t, store.MarkCustomerVerified(ctx, unknown), ports.ErrNotFound)
+	assert.Error(t, store.UpdateCustomerPassword(ctx, unknown, []byte("hash")), ports.ErrNotFound)
+	assert.Error(t, store.SetPaymentCustomerID(ctx, unknown, "cus_2"), ports.ErrNotFound)
+
+	_, err = store.ReservePaymentCustomerKey(ctx, unknown, "customer-c")
+	assert.Error(t, err, ports.ErrNotFound)
+}
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryDevices(t *testing.T) {
+	testDeviceStore(t, dal.NewMemoryDevices())
+}
+
+func TestPostgreSQLDevices(t *testing.T) {
+	testDeviceStore(t, dal.NewPostgreSQLDevices(newTestDB(t)))
+}
+
+func newDevice(id string, customerID int, lastSeen time.Time) domain.Device {
+	return domain.Device{
+		ID:               id,
+		CustomerID:       customerID,
+		ClientID:         "microwave",
+		Name:             "Microwave",
+		Scopes:           []string{"licenses", "profile"},
+		RefreshTokenHash: []byte("refresh-" + id),
+		AccessTokenHash:  []byte("access-" + id),
+		AccessExpires:    lastSeen.Add(time.Hour),
+		Created:          lastSeen,
+		LastSeen:         lastSeen,
+		Expires:          lastSeen.Add(24 * time.Hour),
+	}
+}
+
+// testDeviceStore checks the behavior every ports.DeviceStore must have.
+func testDeviceStore(t *testing.T, store ports.DeviceStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	a := domain.DeviceAuthorization{
+		DeviceCodeHash: []byte("device-code"),
+		UserCode:       "BCDFGHJK",
+		ClientID:       "microwave",
+		DeviceName:     "Studio Mac",
+		Scopes:         []string{"licenses", "profile"},
+		Created:        now,
+		Expires:        now.Add(10 * time.Minute),
+		Interval:       5 * time.Second,
+		LastPolled:     time.Time{},
+		Status:         domain.DeviceAuthorizationPending,
+		CustomerID:     0,
+	}
+
+	err := store.CreateDeviceAuthorization(ctx, a)
+	assert.NoError(t, err)
+
+	dup := a
+	dup.DeviceCodeHash = []byte("other-device-code")
+	err = store.CreateDeviceAuthorization(ctx, dup)
+	assert.Error(t, err, ports.ErrConflict)
+
+	got, err := store.DeviceAuthorizationByUserCode(ctx, "BCDFGHJK")
+	assert.NoError(t, err)
+	assert.Equal(t, got.DeviceName, "Studio Mac")
+	assert.Equal(t, len(got.Scopes), 2)
+	assert.Equal(t, got.Interval, 5*time.Second)
+	assert.True(t, got.LastPolled.IsZero())
+	assert.Equal(t, got.Status, domain.DeviceAuthorizationPending)
+
+	_, err = store.DeviceAuthorizationByUserCode(ctx, "XXXXXXXX")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	err = store.PollDeviceAuthorization(ctx, a.DeviceCodeHash, now.Add(time.Second), 10*time.Second)
+	assert.NoError(t, err)
+
+	got, err = store.DeviceAuthorizationByDeviceCode(ctx, a.DeviceCodeHash)
+	assert.NoError(t, err)
+	assert.True(t, got.LastPolled.Equal(now.Add(time.Second)))
+	assert.Equal(t, got.Interval, 10*time.Second)
+
+	err = store.DecideDeviceAuthorization(ctx, "BCDFGHJK", 7, domain.DeviceAuthorizationApproved)
+	assert.NoError(t, err)
+
+	// Only pending authorizations can be decided.
+	err = store.DecideDeviceAuthorization(ctx, "BCDFGHJK", 8, domain.DeviceAuthorizationDenied)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	got, err = store.DeviceAuthorizationByDeviceCode(ctx, a.DeviceCodeHash)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.DeviceAuthorizationApproved)
+	assert.Equal(t, got.CustomerID, 7)
+
+	err = store.DeleteDeviceAuthorization(ctx, a.DeviceCodeHash)
+	assert.NoError(t, err)
+
+	err = store.DeleteDeviceAuthorization(ctx, a.DeviceCodeHash)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	err = store.CreateDeviceAuthorization(ctx, dup)
+	assert.NoError(t, err)
+
+	n, err := store.DeleteExpiredDeviceAuthorizations(ctx, now.Add(10*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+
+	err = store.CreateDevice(ctx, newDevice("a", 7, now))
+	assert.NoError(t, err)
+
+	err = store.CreateDevice(ctx, newDevice("b", 7, now.Add(time.Minute)))
+	assert.NoError(t, err)
+
+	err = store.CreateDevice(ctx, newDevice("c", 8, now))
+	assert.NoError(t, err)
+
+	err = store.CreateDevice(ctx, newDevice("a", 7, now))
+	assert.Error(t, err, ports.ErrConflict)
+
+	d, err := store.DeviceByRefreshToken(ctx, []byte("refresh-a"))
+	assert.NoError(t, err)
+	assert.Equal(t, d.ID, "a")
+	assert.Equal(t, d.Name, "Microwave")
+	assert.Equal(t, len(d.Scopes), 2)
+
+	d, err = store.DeviceByAccessToken(ctx, []byte("access-a"))
+	assert.NoError(t, err)
+	assert.Equal(t, d.ID, "a")
+
+	rotated := d
+	rotated.RefreshTokenHash = []byte("refresh-a2")
+	rotated.AccessTokenHash = []byte("access-a2")
+	rotated.LastSeen = now.Add(2 * time.Minute)
+
+	err = store.RotateDeviceTokens(ctx, []byte("refresh-a"), rotated)
+	assert.NoError(t, err)
+
+	// A refresh token only rotates once.
+	err = store.RotateDeviceTokens(ctx, []byte("refresh-a"), rotated)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	_, err = store.DeviceByAccessToken(ctx, []byte("access-a"))
+	assert.Error(t, err, ports.ErrNotFound)
+
+	devices, err := store.CustomerDevices(ctx, 7, now)
+	assert.NoError(t, err)
+	assert.Equal(t, len(devices), 2)
+	assert.Equal(t, devices[0].ID, "a")
+
+	err = store.DeleteDevice(ctx, "b")
+	assert.NoError(t, err)
+
+	err = store.DeleteDevice(ctx, "b")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	n, err = store.DeleteExpiredDevices(ctx, now.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, n, 2)
+
+	// Deleting the devices of a customer also drops the authorizations
+	// they approved, and leaves those of others.
+	for _, d := range []domain.Device{newDevice("d", 7, now), newDevice("e", 7, now), newDevice("f", 8, now)} {
+		err = store.CreateDevice(ctx, d)
+		assert.NoError(t, err)
+	}
+
+	err = store.CreateDeviceAuthorization(ctx, a)
+	assert.NoError(t, err)
+
+	err = store.DecideDeviceAuthorization(ctx, "BCDFGHJK", 7, domain.DeviceAuthorizationApproved)
+	assert.NoError(t, err)
+
+	n, err = store.DeleteCustomerDevices(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, n, 2)
+
+	_, err = store.DeviceAuthorizationByDeviceCode(ctx, a.DeviceCodeHash)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	devices, err = store.CustomerDevices(ctx, 8, now)
+	assert.NoError(t, err)
+	assert.Equal(t, len(devices), 1)
+}
@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryLicenses(t *testing.T) {
+	testLicenseStore(t, dal.NewMemoryLicenses())
+}
+
+func TestPostgreSQLLicenses(t *testing.T) {
+	testLicenseStore(t, dal.NewPostgreSQLLicenses(newTestDB(t)))
+}
+
+// testLicenseStore checks the behavior every ports.LicenseStore must have.
+func testLicenseStore(t *testing.T, store ports.LicenseStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	create := func(key string, orderID, line, seat, customerID, productID int, expires time.Time) (domain.License, error) {
+		return store.CreateLicense(ctx, domain.License{
+			ID:           0,
+			Key:          key,
+			OrderID:      orderID,
+			Line:         line,
+			Seat:         seat,
+			CustomerID:   customerID,
+			ProductID:    productID,
+			Version:      "1.2.0",
+			Edition:      domain.EditionStandard,
+			Issued:       now,
+			Expires:      expires,
+			Revoked:      time.Time{},
+			RevokeReason: "",
+			UpgradedFrom: 0,
+		})
+	}
+
+	first, err := create("AAAAA-AAAAA-AAAAA-AAAA1", 1, 0, 0, 1, 1, time.Time{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID, 0)
+
+	second, err := create("AAAAA-AAAAA-AAAAA-AAAA2", 1, 1, 0, 1, 2, now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	_, err = create("AAAAA-AAAAA-AAAAA-AAAA3", 2, 0, 0, 2, 1, time.Time{})
+	assert.NoError(t, err)
+
+	// A line has a license for each of its seats.
+	_, err = create("AAAAA-AAAAA-AAAAA-AAAA7", 2, 0, 1, 2, 1, time.Time{})
+	assert.NoError(t, err)
+
+	// Keys are unique, and so are the licenses of a seat of a line.
+	_, err = create(first.Key, 3, 0, 0, 1, 1, time.Time{})
+	assert.Error(t, err, ports.ErrConflict)
+
+	_, err = create("AAAAA-AAAAA-AAAAA-AAAA4", 1, 0, 0, 1, 1, time.Time{})
+	assert.Error(t, err, ports.ErrConflict)
+
+	got, err := store.License(ctx, second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got, second)
+
+	got, err = store.LicenseByKey(ctx, first.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, first.ID)
+	assert.True(t, got.Expires.IsZero())
+	assert.True(t, got.Revoked.IsZero())
+
+	_, err = store.License(ctx, 1000)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	_, err = store.LicenseByKey(ctx, "AAAAA-AAAAA-AAAAA-AAAA5")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	licenses, err := store.OrderLicenses(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 2)
+	assert.Equal(t, licenses[0].ID, first.ID)
+
+	licenses, err = store.CustomerLicenses(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 2)
+	assert.Equal(t, licenses[1].Seat, 1)
+
+	licenses, err = store.CustomerLicenses(ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 0)
+
+	// Revoking again keeps the first revocation.
+	assert.NoError(t, store.RevokeLicense(ctx, first.ID, now.Add(time.Minute), "refunded"))
+	assert.NoError(t, store.RevokeLicense(ctx, first.ID, now.Add(time.Hour), "again"))
+	assert.Error(t, store.RevokeLicense(ctx, 1000, now, "missing"), ports.ErrNotFound)
+
+	got, err = store.License(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.True(t, got.Revoked.Equal(now.Add(time.Minute)))
+	assert.Equal(t, got.RevokeReason, "refunded")
+
+	// Upgraded licenses record the license they were upgraded from.
+	upgraded, err := store.CreateLicense(ctx, domain.License{
+		ID:           0,
+		Key:          "AAAAA-AAAAA-AAAAA-AAAA6",
+		OrderID:      4,
+		Line:         0,
+		Seat:         0,
+		CustomerID:   1,
+		ProductID:    3,
+		Version:      "2.0.0",
+		Edition:      domain.EditionStandard,
+		Issued:       now,
+		Expires:      time.Time{},
+		Revoked:      time.Time{},
+		RevokeReason: "",
+		UpgradedFrom: second.ID,
+	})
+	assert.NoError(t, err)
+
+	got, err = store.License(ctx, upgraded.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Version, "2.0.0")
+	assert.Equal(t, got.UpgradedFrom, second.ID)
+
+	testLicenseTrials(t, store, now)
+}
+
+// testLicenseTrials checks the trials of a ports.LicenseStore, for
+// customers 10 and up and products 10 and up.
+func testLicenseTrials(t *testing.T, store ports.LicenseStore, now time.Time) {
+	t.Helper()
+
+	ctx := t.Context()
+
+	trial := func(key string, customerID, productID int, machine string) (domain.License, error) {
+		return store.CreateTrial(ctx, domain.License{
+			ID:           0,
+			Key:          key,
+			OrderID:      0,
+			Line:         0,
+			Seat:         0,
+			CustomerID:   customerID,
+			ProductID:    productID,
+			Version:      "1.2.0",
+			Edition:      domain.EditionTrial,
+			Issued:       now,
+			Expires:      now.Add(14 * 24 * time.Hour),
+			Revoked:      time.Time{},
+			RevokeReason: "",
+			UpgradedFrom: 0,
+		}, machine)
+	}
+
+	first, err := trial("BBBBB-BBBBB-BBBBB-BBBB1", 10, 10, "studio-fingerprint-1")
+	assert.NoError(t, err)
+	assert.True(t, first.Trial())
+
+	// Trials of other products and customers have no order in common.
+	_, err = trial("BBBBB-BBBBB-BBBBB-BBBB2", 10, 11, "studio-fingerprint-1")
+	assert.NoError(t, err)
+
+	_, err = trial("BBBBB-BBBBB-BBBBB-BBBB3", 11, 11, "laptop-fingerprint-2")
+	assert.NoError(t, err)
+
+	// Customers and machines try a product once.
+	_, err = trial("BBBBB-BBBBB-BBBBB-BBBB4", 10, 10, "laptop-fingerprint-2")
+	assert.Error(t, err, ports.ErrConflict)
+
+	_, err = trial("BBBBB-BBBBB-BBBBB-BBBB4", 12, 10, "studio-fingerprint-1")
+	assert.Error(t, err, ports.ErrConflict)
+
+	got, err := store.License(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got, first)
+
+	// Abandoned trials are revoked, and may be started again.
+	abandoned, err := trial("BBBBB-BBBBB-BBBBB-BBBB7", 12, 12, "studio-fingerprint-1")
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.AbandonTrial(ctx, abandoned.ID, now, "activation failed"))
+
+	got, err = store.License(ctx, abandoned.ID)
+	assert.NoError(t, err)
+	assert.True(t, got.Revoked.Equal(now))
+	assert.Equal(t, got.RevokeReason, "activation failed")
+
+	_, err = trial("BBBBB-BBBBB-BBBBB-BBBB8", 12, 12, "studio-fingerprint-1")
+	assert.NoError(t, err)
+
+	// Buying a trial converts it in place.
+	converted := first
+	converted.OrderID = 20
+	converted.Version = "1.3.0"
+	converted.Edition = domain.EditionStandard
+	converted.Issued = now.Add(time.Hour)
+	converted.Expires = time.Time{}
+
+	assert.NoError(t, store.ConvertTrial(ctx, converted))
+	assert.Error(t, store.ConvertTrial(ctx, converted), ports.ErrNotFound)
+	assert.Error(t, store.AbandonTrial(ctx, converted.ID, now, "activation failed"), ports.ErrNotFound)
+
+	got, err = store.LicenseByKey(ctx, first.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, got, converted)
+
+	licenses, err := store.OrderLicenses(ctx, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 1)
+
+	// A seat of an order line has one license.
+	_, err = store.CreateLicense(ctx, domain.License{
+		ID:           0,
+		Key:          "BBBBB-BBBBB-BBBBB-BBBB5",
+		OrderID:      21,
+		Line:         0,
+		Seat:         0,
+		CustomerID:   11,
+		ProductID:    11,
+		Version:      "1.2.0",
+		Edition:      domain.EditionStandard,
+		Issued:       now,
+		Expires:      time.Time{},
+		Revoked:      time.Time{},
+		RevokeReason: "",
+		UpgradedFrom: 0,
+	})
+	assert.NoError(t, err)
+
+	licenses, err = store.CustomerLicenses(ctx, 11)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 2)
+
+	bought := licenses[0]
+	bought.OrderID = 21
+	bought.Edition = domain.EditionStandard
+
+	assert.Error(t, store.ConvertTrial(ctx, bought), ports.ErrConflict)
+
+	// Converted trials still count.
+	_, err = trial("BBBBB-BBBBB-BBBBB-BBBB6", 10, 10, "others-fingerprint-3")
+	assert.Error(t, err, ports.ErrConflict)
+}
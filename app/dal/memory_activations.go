@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryActivations is an in-memory ports.ActivationStore. Its content is
+// lost when the process exits, so it is meant for development and tests.
+type MemoryActivations struct {
+	mu sync.RWMutex
+
+	// activations are ordered by ID, the index of an activation plus one.
+	activations []domain.Activation
+}
+
+func NewMemoryActivations() *MemoryActivations {
+	return &MemoryActivations{
+		mu:          sync.RWMutex{},
+		activations: make([]domain.Activation, 0),
+	}
+}
+
+func (m *MemoryActivations) CreateActivation(
+	_ context.Context,
+	a domain.Activation,
+	limit int,
+) (domain.Activation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	active := 0
+
+	for _, existing := range m.activations {
+		if existing.LicenseID != a.LicenseID || !existing.Active() {
+			continue
+		}
+
+		if existing.Machine == a.Machine {
+			return domain.Activation{}, ports.ErrConflict
+		}
+
+		active++
+	}
+
+	if limit > 0 && active >= limit {
+		return domain.Activation{}, ports.ErrConflict
+	}
+
+	a.ID = len(m.activations) + 1
+	m.activations = append(m.activations, a)
+
+	return a, nil
+}
+
+func (m *MemoryActivations) Activation(_ context.Context, id int) (domain.Activation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if id <= 0 || id > len(m.activations) {
+		return domain.Activation{}, ports.ErrNotFound
+	}
+
+	return m.activations[id-1], nil
+}
+
+func (m *MemoryActivations) MachineActivation(
+	_ context.Context,
+	licenseID int,
+	machine string,
+) (domain.Activation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, a := range m.activations {
+		if a.LicenseID == licenseID && a.Machine == machine && a.Active() {
+			return a, nil
+		}
+	}
+
+	return domain.Activation{}, ports.ErrNotFound
+}
+
+func (m *MemoryActivations) LicenseActivations(_ context.Context, licenseID int) ([]domain.Activation, error) {
+	return m.list(func(a domain.Activation) bool { return a.LicenseID == licenseID }), nil
+}
+
+func (m *MemoryActivations) CustomerActivations(_ context.Context, customerID int) ([]domain.Activation, error) {
+	return m.list(func(a domain.Activation) bool { return a.CustomerID == customerID && a.Active() }), nil
+}
+
+func (m *MemoryActivations) TouchActivation(_ context.Context, id int, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id <= 0 || id > len(m.activations) || !m.activations[id-1].Active() {
+		return ports.ErrNotFound
+	}
+
+	m.activations[id-1].LastSeen = at
+
+	return nil
+}
+
+func (m *MemoryActivations) DeactivateActivation(_ context.Context, id int, at time.Time, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id <= 0 || id > len(m.activations) {
+		return ports.ErrNotFound
+	}
+
+	a := &m.activations[id-1]
+	if a.Active() {
+		a.Deactivated, a.DeactivateReason = at, reason
+	}
+
+	return nil
+}
+
+func (m *MemoryActivations) ExpireActivations(
+	_ context.Context,
+	seenBefore, at time.Time,
+	reason string,
+) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expired := 0
+
+	for i := range m.activations {
+		a := &m.activations[i]
+		if a.Active() && a.LastSeen.Before(seenBefore) {
+			a.Deactivated, a.DeactivateReason = at, reason
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+func (m *MemoryActivations) ActivationCounts(
+	_ context.Context,
+	since time.Time,
+	atLeast, limit int,
+) ([]domain.ActivationCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[int]*domain.ActivationCount)
+	machines := make(map[int]map[string]bool)
+
+	for _, a := range m.activations {
+		if a.Activated.Before(since) && !a.Active() {
+			continue
+		}
+
+		c, ok := counts[a.LicenseID]
+		if !ok {
+			c = &domain.ActivationCount{
+				LicenseID:  a.LicenseID,
+				CustomerID: a.CustomerID,
+				ProductID:  a.ProductID,
+				Active:     0,
+				Machines:   0,
+			}
+			counts[a.LicenseID] = c
+			machines[a.LicenseID] = make(map[string]bool)
+		}
+
+		if a.Active() {
+			c.Active++
+		}
+
+		if !a.Activated.Before(since) && !machines[a.LicenseID][a.Machine] {
+			machines[a.LicenseID][a.Machine] = true
+			c.Machines++
+		}
+	}
+
+	list := make([]domain.ActivationCount, 0, len(counts))
+
+	for _, c := range counts {
+		if c.Machines >= atLeast {
+			list = append(list, *c)
+		}
+	}
+
+	slices.SortFunc(list, func(a, b domain.ActivationCount) int {
+		return cmp.Or(cmp.Compare(b.Machines, a.Machines), cmp.Compare(a.LicenseID, b.LicenseID))
+	})
+
+	if len(list) > limit {
+		list = list[:limit]
+	}
+
+	return list, nil
+}
+
+// list returns the activations matching match, oldest first.
+func (m *MemoryActivations) list(match func(domain.Activation) bool) []domain.Activation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	activations := make([]domain.Activation, 0)
+
+	for _, a := range m.activations {
+		if match(a) {
+			activations = append(activations, a)
+		}
+	}
+
+	return activations
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// MemoryAuditLog is an in-memory ports.AuditLog. Its content is lost when the
+// process exits, so it is meant for development and tests.
+type MemoryAuditLog struct {
+	mu     sync.Mutex
+	events []domain.AuditEvent
+}
+
+func NewMemoryAuditLog() *MemoryAuditLog {
+	return &MemoryAuditLog{
+		mu:     sync.Mutex{},
+		events: nil,
+	}
+}
+
+func (m *MemoryAuditLog) RecordAudit(_ context.Context, e domain.AuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, e)
+
+	return nil
+}
+
+// Events returns the recorded events, oldest first.
+func (m *MemoryAuditLog) Events() []domain.AuditEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.events)
+}
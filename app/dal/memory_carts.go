@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryCarts is an in-memory ports.CartStore. Its content is lost when the
+// process exits, which empties every cart.
+type MemoryCarts struct {
+	mu         sync.RWMutex
+	byID       map[string]domain.Cart
+	byCustomer map[int]string
+}
+
+func NewMemoryCarts() *MemoryCarts {
+	return &MemoryCarts{
+		mu:         sync.RWMutex{},
+		byID:       make(map[string]domain.Cart),
+		byCustomer: make(map[int]string),
+	}
+}
+
+func (m *MemoryCarts) SaveCart(_ context.Context, c domain.Cart) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c.CustomerID != 0 {
+		id, ok := m.byCustomer[c.CustomerID]
+		if ok && id != c.ID {
+			return ports.ErrConflict
+		}
+	}
+
+	old, ok := m.byID[c.ID]
+	if ok && old.CustomerID != 0 {
+		delete(m.byCustomer, old.CustomerID)
+	}
+
+	c.Lines = slices.Clone(c.Lines)
+	m.byID[c.ID] = c
+
+	if c.CustomerID != 0 {
+		m.byCustomer[c.CustomerID] = c.ID
+	}
+
+	return nil
+}
+
+func (m *MemoryCarts) CartByID(_ context.Context, id string) (domain.Cart, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.byID[id]
+	if !ok {
+		return domain.Cart{}, ports.ErrNotFound
+	}
+
+	c.Lines = slices.Clone(c.Lines)
+
+	return c, nil
+}
+
+func (m *MemoryCarts) CartByCustomer(ctx context.Context, customerID int) (domain.Cart, error) {
+	m.mu.RLock()
+	id, ok := m.byCustomer[customerID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return domain.Cart{}, ports.ErrNotFound
+	}
+
+	return m.CartByID(ctx, id)
+}
+
+func (m *MemoryCarts) DeleteCart(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.byID[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	m.delete(c)
+
+	return nil
+}
+
+func (m *MemoryCarts) DeleteExpiredCarts(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, c := range m.byID {
+		if !c.Expires.After(now) {
+			m.delete(c)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// delete removes c. The caller must hold the lock.
+func (m *MemoryCarts) delete(c domain.Cart) {
+	delete(m.byID, c.ID)
+
+	if c.CustomerID != 0 {
+		delete(m.byCustomer, c.CustomerID)
+	}
+}
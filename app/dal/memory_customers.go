@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryCustomers is an in-memory ports.CustomerRepository. Its content is
+// lost when the process exits, so it is meant for development and tests.
+type MemoryCustomers struct {
+	mu      sync.RWMutex
+	lastID  int
+	byID    map[int]domain.Customer
+	byEmail map[string]int
+
+	// paymentKeys are the idempotency keys of creating customers at the
+	// payment provider.
+	paymentKeys map[int]string
+}
+
+func NewMemoryCustomers() *MemoryCustomers {
+	return &MemoryCustomers{
+		mu:      sync.RWMutex{},
+		lastID:  0,
+		byID:    make(map[int]domain.Customer),
+		byEmail: make(map[string]int),
+
+		paymentKeys: make(map[int]string),
+	}
+}
+
+func (m *MemoryCustomers) CreateCustomer(_ context.Context, c domain.Customer) (domain.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.byEmail[c.Email]
+	if ok {
+		return domain.Customer{}, ports.ErrConflict
+	}
+
+	m.lastID++
+	c.ID = m.lastID
+	c.HashedPassword = slices.Clone(c.HashedPassword)
+
+	m.byID[c.ID] = c
+	m.byEmail[c.Email] = c.ID
+
+	return c, nil
+}
+
+func (m *MemoryCustomers) CustomerByEmail(_ context.Context, email string) (domain.Customer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.byEmail[email]
+	if !ok {
+		return domain.Customer{}, ports.ErrNotFound
+	}
+
+	c := m.byID[id]
+	c.HashedPassword = slices.Clone(c.HashedPassword)
+
+	return c, nil
+}
+
+func (m *MemoryCustomers) CustomerByID(_ context.Context, id int) (domain.Customer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.byID[id]
+	if !ok {
+		return domain.Customer{}, ports.ErrNotFound
+	}
+
+	c.HashedPassword = slices.Clone(c.HashedPassword)
+
+	return c, nil
+}
+
+func (m *MemoryCustomers) MarkCustomerVerified(_ context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.byID[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	c.Verified = true
+	m.byID[id] = c
+
+	return nil
+}
+
+func (m *MemoryCustomers) UpdateCustomerPassword(_ context.Context, id int, hashedPassword []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.byID[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	c.HashedPassword = slices.Clone(hashedPassword)
+	m.byID[id] = c
+
+	return nil
+}
+
+func (m *MemoryCustomers) SetPaymentCustomerID(_ context.Context, id int, paymentCustomerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.byID[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	c.PaymentCustomerID = paymentCustomerID
+	m.byID[id] = c
+
+	return nil
+}
+
+func (m *MemoryCustomers) ReservePaymentCustomerKey(_ context.Context, id int, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.byID[id]
+	if !ok {
+		return "", ports.ErrNotFound
+	}
+
+	reserved, ok := m.paymentKeys[id]
+	if ok {
+		return reserved, nil
+	}
+
+	m.paymentKeys[id] = key
+
+	return key, nil
+}
@@ -0,0 +1,305 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryDevices is an in-memory ports.DeviceStore. Its content is lost when
+// the process exits, which signs every device out.
+type MemoryDevices struct {
+	mu             sync.RWMutex
+	authorizations map[string]domain.DeviceAuthorization
+	devices        map[string]domain.Device
+}
+
+func NewMemoryDevices() *MemoryDevices {
+	return &MemoryDevices{
+		mu:             sync.RWMutex{},
+		authorizations: make(map[string]domain.DeviceAuthorization),
+		devices:        make(map[string]domain.Device),
+	}
+}
+
+func (m *MemoryDevices) CreateDeviceAuthorization(_ context.Context, a domain.DeviceAuthorization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.authorizations[string(a.DeviceCodeHash)]
+	if ok {
+		return ports.ErrConflict
+	}
+
+	for _, other := range m.authorizations {
+		if other.UserCode == a.UserCode {
+			return ports.ErrConflict
+		}
+	}
+
+	m.authorizations[string(a.DeviceCodeHash)] = cloneDeviceAuthorization(a)
+
+	return nil
+}
+
+func (m *MemoryDevices) DeviceAuthorizationByUserCode(
+	_ context.Context,
+	userCode string,
+) (domain.DeviceAuthorization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, a := range m.authorizations {
+		if a.UserCode == userCode {
+			return cloneDeviceAuthorization(a), nil
+		}
+	}
+
+	return domain.DeviceAuthorization{}, ports.ErrNotFound
+}
+
+func (m *MemoryDevices) DeviceAuthorizationByDeviceCode(
+	_ context.Context,
+	deviceCodeHash []byte,
+) (domain.DeviceAuthorization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.authorizations[string(deviceCodeHash)]
+	if !ok {
+		return domain.DeviceAuthorization{}, ports.ErrNotFound
+	}
+
+	return cloneDeviceAuthorization(a), nil
+}
+
+func (m *MemoryDevices) PollDeviceAuthorization(
+	_ context.Context,
+	deviceCodeHash []byte,
+	at time.Time,
+	interval time.Duration,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.authorizations[string(deviceCodeHash)]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	a.LastPolled = at
+	a.Interval = interval
+	m.authorizations[string(deviceCodeHash)] = a
+
+	return nil
+}
+
+func (m *MemoryDevices) DecideDeviceAuthorization(
+	_ context.Context,
+	userCode string,
+	customerID int,
+	status domain.DeviceAuthorizationStatus,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, a := range m.authorizations {
+		if a.UserCode != userCode || a.Status != domain.DeviceAuthorizationPending {
+			continue
+		}
+
+		a.CustomerID = customerID
+		a.Status = status
+		m.authorizations[key] = a
+
+		return nil
+	}
+
+	return ports.ErrNotFound
+}
+
+func (m *MemoryDevices) DeleteDeviceAuthorization(_ context.Context, deviceCodeHash []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.authorizations[string(deviceCodeHash)]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	delete(m.authorizations, string(deviceCodeHash))
+
+	return nil
+}
+
+func (m *MemoryDevices) DeleteExpiredDeviceAuthorizations(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for key, a := range m.authorizations {
+		if !now.Before(a.Expires) {
+			delete(m.authorizations, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (m *MemoryDevices) CreateDevice(_ context.Context, d domain.Device) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.devices[d.ID]
+	if ok {
+		return ports.ErrConflict
+	}
+
+	for _, other := range m.devices {
+		if bytes.Equal(other.RefreshTokenHash, d.RefreshTokenHash) ||
+			bytes.Equal(other.AccessTokenHash, d.AccessTokenHash) {
+			return ports.ErrConflict
+		}
+	}
+
+	m.devices[d.ID] = cloneDevice(d)
+
+	return nil
+}
+
+func (m *MemoryDevices) DeviceByRefreshToken(_ context.Context, refreshTokenHash []byte) (domain.Device, error) {
+	return m.find(func(d domain.Device) bool {
+		return bytes.Equal(d.RefreshTokenHash, refreshTokenHash)
+	})
+}
+
+func (m *MemoryDevices) DeviceByAccessToken(_ context.Context, accessTokenHash []byte) (domain.Device, error) {
+	return m.find(func(d domain.Device) bool {
+		return bytes.Equal(d.AccessTokenHash, accessTokenHash)
+	})
+}
+
+func (m *MemoryDevices) RotateDeviceTokens(_ context.Context, oldRefreshTokenHash []byte, d domain.Device) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.devices[d.ID]
+	if !ok || !bytes.Equal(current.RefreshTokenHash, oldRefreshTokenHash) {
+		return ports.ErrNotFound
+	}
+
+	current.RefreshTokenHash = slices.Clone(d.RefreshTokenHash)
+	current.AccessTokenHash = slices.Clone(d.AccessTokenHash)
+	current.AccessExpires = d.AccessExpires
+	current.LastSeen = d.LastSeen
+	current.Expires = d.Expires
+	m.devices[d.ID] = current
+
+	return nil
+}
+
+func (m *MemoryDevices) CustomerDevices(_ context.Context, customerID int, now time.Time) ([]domain.Device, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var devices []domain.Device
+	for _, d := range m.devices {
+		if d.CustomerID == customerID && now.Before(d.Expires) {
+			devices = append(devices, cloneDevice(d))
+		}
+	}
+
+	slices.SortFunc(devices, func(a, b domain.Device) int {
+		return b.LastSeen.Compare(a.LastSeen)
+	})
+
+	return devices, nil
+}
+
+func (m *MemoryDevices) DeleteDevice(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.devices[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	delete(m.devices, id)
+
+	return nil
+}
+
+func (m *MemoryDevices) DeleteCustomerDevices(_ context.Context, customerID int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, a := range m.authorizations {
+		if a.CustomerID == customerID && a.Status == domain.DeviceAuthorizationApproved {
+			delete(m.authorizations, key)
+		}
+	}
+
+	n := 0
+	for id, d := range m.devices {
+		if d.CustomerID == customerID {
+			delete(m.devices, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (m *MemoryDevices) DeleteExpiredDevices(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for id, d := range m.devices {
+		if !now.Before(d.Expires) {
+			delete(m.devices, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (m *MemoryDevices) find(match func(domain.Device) bool) (domain.Device, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, d := range m.devices {
+		if match(d) {
+			return cloneDevice(d), nil
+		}
+	}
+
+	return domain.Device{}, ports.ErrNotFound
+}
+
+func cloneDeviceAuthorization(a domain.DeviceAuthorization) domain.DeviceAuthorization {
+	a.DeviceCodeHash = slices.Clone(a.DeviceCodeHash)
+	a.Scopes = slices.Clone(a.Scopes)
+
+	return a
+}
+
+func cloneDevice(d domain.Device) domain.Device {
+	d.Scopes = slices.Clone(d.Scopes)
+	d.RefreshTokenHash = slices.Clone(d.RefreshTokenHash)
+	d.AccessTokenHash = slices.Clone(d.AccessTokenHash)
+
+	return d
+}
@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryLicenses is an in-memory ports.LicenseStore. Its content is lost
+// when the process exits, so it is meant for development and tests.
+type MemoryLicenses struct {
+	mu sync.RWMutex
+
+	// licenses are ordered by ID, the index of a license plus one.
+	licenses []domain.License
+
+	// trials are the trials ever started, converted or not.
+	trials []memoryTrial
+}
+
+// memoryTrial is the license and product of a trial, and the customer and
+// machine that started it.
+type memoryTrial struct {
+	licenseID  int
+	customerID int
+	productID  int
+	machine    string
+}
+
+func NewMemoryLicenses() *MemoryLicenses {
+	return &MemoryLicenses{
+		mu:       sync.RWMutex{},
+		licenses: make([]domain.License, 0),
+		trials:   make([]memoryTrial, 0),
+	}
+}
+
+func (m *MemoryLicenses) CreateLicense(_ context.Context, l domain.License) (domain.License, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.conflicts(l) {
+		return domain.License{}, ports.ErrConflict
+	}
+
+	l.ID = len(m.licenses) + 1
+	m.licenses = append(m.licenses, l)
+
+	return l, nil
+}
+
+func (m *MemoryLicenses) CreateTrial(_ context.Context, l domain.License, machine string) (domain.License, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.conflicts(l) {
+		return domain.License{}, ports.ErrConflict
+	}
+
+	for _, t := range m.trials {
+		if t.productID == l.ProductID && (t.customerID == l.CustomerID || t.machine == machine) {
+			return domain.License{}, ports.ErrConflict
+		}
+	}
+
+	l.ID = len(m.licenses) + 1
+	m.licenses = append(m.licenses, l)
+	m.trials = append(m.trials, memoryTrial{
+		licenseID:  l.ID,
+		customerID: l.CustomerID,
+		productID:  l.ProductID,
+		machine:    machine,
+	})
+
+	return l, nil
+}
+
+func (m *MemoryLicenses) AbandonTrial(_ context.Context, id int, at time.Time, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id <= 0 || id > len(m.licenses) || !m.licenses[id-1].Trial() {
+		return ports.ErrNotFound
+	}
+
+	l := &m.licenses[id-1]
+	if l.Revoked.IsZero() {
+		l.Revoked, l.RevokeReason = at, reason
+	}
+
+	m.trials = slices.DeleteFunc(m.trials, func(t memoryTrial) bool { return t.licenseID == id })
+
+	return nil
+}
+
+func (m *MemoryLicenses) ConvertTrial(_ context.Context, l domain.License) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l.ID <= 0 || l.ID > len(m.licenses) || !m.licenses[l.ID-1].Trial() {
+		return ports.ErrNotFound
+	}
+
+	trial := &m.licenses[l.ID-1]
+
+	if m.conflicts(l) {
+		return ports.ErrConflict
+	}
+
+	trial.OrderID, trial.Line, trial.Seat = l.OrderID, l.Line, l.Seat
+	trial.Edition, trial.Issued, trial.Expires = l.Edition, l.Issued, l.Expires
+	trial.Version, trial.UpgradedFrom = l.Version, l.UpgradedFrom
+
+	return nil
+}
+
+func (m *MemoryLicenses) License(_ context.Context, id int) (domain.License, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if id <= 0 || id > len(m.licenses) {
+		return domain.License{}, ports.ErrNotFound
+	}
+
+	return m.licenses[id-1], nil
+}
+
+func (m *MemoryLicenses) LicenseByKey(_ context.Context, key string) (domain.License, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, l := range m.licenses {
+		if l.Key == key {
+			return l, nil
+		}
+	}
+
+	return domain.License{}, ports.ErrNotFound
+}
+
+func (m *MemoryLicenses) OrderLicenses(_ context.Context, orderID int) ([]domain.License, error) {
+	return m.list(func(l domain.License) bool { return l.OrderID == orderID }), nil
+}
+
+func (m *MemoryLicenses) CustomerLicenses(_ context.Context, customerID int) ([]domain.License, error) {
+	return m.list(func(l domain.License) bool { return l.CustomerID == customerID }), nil
+}
+
+func (m *MemoryLicenses) RevokeLicense(_ context.Context, id int, at time.Time, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id <= 0 || id > len(m.licenses) {
+		return ports.ErrNotFound
+	}
+
+	l := &m.licenses[id-1]
+	if l.Revoked.IsZero() {
+		l.Revoked, l.RevokeReason = at, reason
+	}
+
+	return nil
+}
+
+// conflicts reports whether another license has the key of l, or was
+// issued for the same order and product.
+func (m *MemoryLicenses) conflicts(l domain.License) bool {
+	for _, existing := range m.licenses {
+		if existing.ID == l.ID {
+			continue
+		}
+
+		if existing.Key == l.Key ||
+			l.OrderID != 0 && existing.OrderID == l.OrderID && existing.Line == l.Line && existing.Seat == l.Seat {
+			return true
+		}
+	}
+
+	return false
+}
+
+// list returns the licenses matching match, oldest first.
+func (m *MemoryLicenses) list(match func(domain.License) bool) []domain.License {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	licenses := make([]domain.License, 0)
+
+	for _, l := range m.licenses {
+		if match(l) {
+			licenses = append(licenses, l)
+		}
+	}
+
+	return licenses
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLoginFailures is an in-memory ports.LoginFailures. Counts are local
+// to the process, so instances behind a load balancer each count their own
+// share of the failures.
+type MemoryLoginFailures struct {
+	mu       sync.Mutex
+	failures map[string]loginFailures
+}
+
+type loginFailures struct {
+	count int
+	last  time.Time
+}
+
+func NewMemoryLoginFailures() *MemoryLoginFailures {
+	return &MemoryLoginFailures{
+		mu:       sync.Mutex{},
+		failures: make(map[string]loginFailures),
+	}
+}
+
+func (m *MemoryLoginFailures) LoginFailures(_ context.Context, key string) (int, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f := m.failures[key]
+
+	return f.count, f.last, nil
+}
+
+func (m *MemoryLoginFailures) RecordLoginFailure(_ context.Context, key string, at time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f := m.failures[key]
+	f.count++
+	f.last = at
+	m.failures[key] = f
+
+	return f.count, nil
+}
+
+func (m *MemoryLoginFailures) ForgiveLoginFailure(_ context.Context, key string, at, previous time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.failures[key]
+	if !ok {
+		return nil
+	}
+
+	f.count--
+	if f.count <= 0 {
+		delete(m.failures, key)
+		return nil
+	}
+
+	if f.last.Equal(at) {
+		f.last = previous
+	}
+
+	m.failures[key] = f
+
+	return nil
+}
+
+func (m *MemoryLoginFailures) ResetLoginFailures(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.failures, key)
+
+	return nil
+}
+
+func (m *MemoryLoginFailures) ForgetLoginFailures(_ context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for key, f := range m.failures {
+		if f.last.Before(before) {
+			delete(m.failures, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
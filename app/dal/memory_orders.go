@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryOrders is an in-memory ports.OrderStore. Its content is lost when
+// the process exits, so it is meant for development and tests.
+type MemoryOrders struct {
+	mu          sync.RWMutex
+	orders      map[int]domain.Order
+	transitions map[int][]domain.OrderTransition
+	nextID      int
+}
+
+func NewMemoryOrders() *MemoryOrders {
+	return &MemoryOrders{
+		mu:          sync.RWMutex{},
+		orders:      make(map[int]domain.Order),
+		transitions: make(map[int][]domain.OrderTransition),
+		nextID:      1,
+	}
+}
+
+func (m *MemoryOrders) CreateOrder(
+	_ context.Context,
+	o domain.Order,
+	t domain.OrderTransition,
+) (domain.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.orders {
+		if existing.CheckoutKey == o.CheckoutKey {
+			return domain.Order{}, ports.ErrConflict
+		}
+	}
+
+	o.ID = m.nextID
+	m.nextID++
+
+	stored := o
+	stored.Lines = slices.Clone(o.Lines)
+	m.orders[o.ID] = stored
+
+	t.OrderID = o.ID
+	m.transitions[o.ID] = []domain.OrderTransition{t}
+
+	return o, nil
+}
+
+func (m *MemoryOrders) Order(_ context.Context, id int) (domain.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	o, ok := m.orders[id]
+	if !ok {
+		return domain.Order{}, ports.ErrNotFound
+	}
+
+	o.Lines = slices.Clone(o.Lines)
+
+	return o, nil
+}
+
+func (m *MemoryOrders) OrderByCheckoutKey(_ context.Context, key string) (domain.Order, error) {
+	return m.find(func(o domain.Order) bool { return o.CheckoutKey == key })
+}
+
+func (m *MemoryOrders) OrderByCheckout(_ context.Context, checkoutID string) (domain.Order, error) {
+	return m.find(func(o domain.Order) bool { return checkoutID != "" && o.CheckoutID == checkoutID })
+}
+
+func (m *MemoryOrders) SetOrderCheckout(_ context.Context, id int, checkoutID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	o.CheckoutID = checkoutID
+	m.orders[id] = o
+
+	return nil
+}
+
+func (m *MemoryOrders) OrderByPayment(_ context.Context, paymentID string) (domain.Order, error) {
+	return m.find(func(o domain.Order) bool { return paymentID != "" && o.PaymentID == paymentID })
+}
+
+func (m *MemoryOrders) CustomerOrders(_ context.Context, customerID int) ([]domain.Order, error) {
+	return m.list(func(o domain.Order) bool { return o.CustomerID == customerID }, -1), nil
+}
+
+func (m *MemoryOrders) Orders(_ context.Context, status domain.OrderStatus, limit int) ([]domain.Order, error) {
+	return m.list(func(o domain.Order) bool { return o.Status == status }, limit), nil
+}
+
+func (m *MemoryOrders) TransitionOrder(_ context.Context, o domain.Order, t domain.OrderTransition) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.orders[o.ID]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	if stored.Status != t.From {
+		return ports.ErrConflict
+	}
+
+	stored.Status = o.Status
+	stored.PaymentID = o.PaymentID
+	stored.Refunded = o.Refunded
+	stored.Updated = o.Updated
+	m.orders[o.ID] = stored
+
+	t.OrderID = o.ID
+	m.transitions[o.ID] = append(m.transitions[o.ID], t)
+
+	return nil
+}
+
+func (m *MemoryOrders) OrderTransitions(_ context.Context, id int) ([]domain.OrderTransition, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return slices.Clone(m.transitions[id]), nil
+}
+
+// find returns the order matching match.
+func (m *MemoryOrders) find(match func(domain.Order) bool) (domain.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, o := range m.orders {
+		if match(o) {
+			o.Lines = slices.Clone(o.Lines)
+			return o, nil
+		}
+	}
+
+	return domain.Order{}, ports.ErrNotFound
+}
+
+// list returns up to limit orders matching match, most recent first, or
+// every one with a negative limit.
+func (m *MemoryOrders) list(match func(domain.Order) bool, limit int) []domain.Order {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders := make([]domain.Order, 0)
+
+	for _, o := range m.orders {
+		if match(o) {
+			o.Lines = slices.Clone(o.Lines)
+			orders = append(orders, o)
+		}
+	}
+
+	slices.SortFunc(orders, func(a, b domain.Order) int {
+		return cmp.Or(b.Created.Compare(a.Created), cmp.Compare(b.ID, a.ID))
+	})
+
+	if limit >= 0 {
+		orders = orders[:min(len(orders), limit)]
+	}
+
+	return orders
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"crypto/subtle"
+	"slices"
+	"sync"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryPasswordResets is an in-memory ports.PasswordResetStore.
+type MemoryPasswordResets struct {
+	mu           sync.Mutex
+	byCustomerID map[int]domain.PasswordReset
+}
+
+func NewMemoryPasswordResets() *MemoryPasswordResets {
+	return &MemoryPasswordResets{
+		mu:           sync.Mutex{},
+		byCustomerID: make(map[int]domain.PasswordReset),
+	}
+}
+
+func (m *MemoryPasswordResets) SavePasswordReset(_ context.Context, r domain.PasswordReset) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r.TokenHash = slices.Clone(r.TokenHash)
+	m.byCustomerID[r.CustomerID] = r
+
+	return nil
+}
+
+func (m *MemoryPasswordResets) PasswordReset(_ context.Context, customerID int) (domain.PasswordReset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.byCustomerID[customerID]
+	if !ok {
+		return domain.PasswordReset{}, ports.ErrNotFound
+	}
+
+	r.TokenHash = slices.Clone(r.TokenHash)
+
+	return r, nil
+}
+
+func (m *MemoryPasswordResets) ConsumePasswordReset(_ context.Context, tokenHash []byte) (domain.PasswordReset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, r := range m.byCustomerID {
+		if subtle.ConstantTimeCompare(r.TokenHash, tokenHash) == 1 {
+			delete(m.byCustomerID, id)
+			return r, nil
+		}
+	}
+
+	return domain.PasswordReset{}, ports.ErrNotFound
+}
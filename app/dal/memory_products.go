@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryProducts is an in-memory ports.ProductRepository.
+type MemoryProducts struct {
+	mu     sync.RWMutex
+	lastID int
+	byID   map[int]domain.Product
+	bySlug map[string]int
+}
+
+func NewMemoryProducts() *MemoryProducts {
+	return &MemoryProducts{
+		mu:     sync.RWMutex{},
+		lastID: 0,
+		byID:   make(map[int]domain.Product),
+		bySlug: make(map[string]int),
+	}
+}
+
+func (m *MemoryProducts) CreateProduct(_ context.Context, p domain.Product) (domain.Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.bySlug[p.Slug]
+	if ok {
+		return domain.Product{}, ports.ErrConflict
+	}
+
+	m.lastID++
+	p.ID = m.lastID
+	p.Media = slices.Clone(p.Media)
+	p.Prices = slices.Clone(p.Prices)
+
+	m.byID[p.ID] = p
+	m.bySlug[p.Slug] = p.ID
+
+	return p, nil
+}
+
+func (m *MemoryProducts) UpdateProduct(_ context.Context, p domain.Product) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.byID[p.ID]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	id, ok := m.bySlug[p.Slug]
+	if ok && id != p.ID {
+		return ports.ErrConflict
+	}
+
+	p.Media = slices.Clone(p.Media)
+	p.Prices = slices.Clone(p.Prices)
+
+	delete(m.bySlug, old.Slug)
+	m.byID[p.ID] = p
+	m.bySlug[p.Slug] = p.ID
+
+	return nil
+}
+
+func (m *MemoryProducts) DeleteProduct(_ context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.byID[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	delete(m.byID, id)
+	delete(m.bySlug, p.Slug)
+
+	return nil
+}
+
+func (m *MemoryProducts) ProductByID(_ context.Context, id int) (domain.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.byID[id]
+	if !ok {
+		return domain.Product{}, ports.ErrNotFound
+	}
+
+	p.Media = slices.Clone(p.Media)
+	p.Prices = slices.Clone(p.Prices)
+
+	return p, nil
+}
+
+func (m *MemoryProducts) ProductBySlug(ctx context.Context, slug string) (domain.Product, error) {
+	m.mu.RLock()
+	id, ok := m.bySlug[slug]
+	m.mu.RUnlock()
+
+	if !ok {
+		return domain.Product{}, ports.ErrNotFound
+	}
+
+	return m.ProductByID(ctx, id)
+}
+
+func (m *MemoryProducts) Products(_ context.Context, filter ports.ProductFilter) ([]domain.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var products []domain.Product
+
+	for _, p := range m.byID {
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, p.Type) {
+			continue
+		}
+
+		if filter.PublishedOnly && !p.Published {
+			continue
+		}
+
+		p.Media = slices.Clone(p.Media)
+		p.Prices = slices.Clone(p.Prices)
+		products = append(products, p)
+	}
+
+	slices.SortFunc(products, func(a, b domain.Product) int {
+		return cmp.Or(cmp.Compare(a.Position, b.Position), cmp.Compare(a.ID, b.ID))
+	})
+
+	return products, nil
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRequestCounts is an in-memory ports.RequestCounts. Like
+// MemoryLoginFailures, counts are local to the process.
+type MemoryRequestCounts struct {
+	mu       sync.Mutex
+	requests map[string]requestCount
+}
+
+type requestCount struct {
+	count int
+	last  time.Time
+}
+
+func NewMemoryRequestCounts() *MemoryRequestCounts {
+	return &MemoryRequestCounts{
+		mu:       sync.Mutex{},
+		requests: make(map[string]requestCount),
+	}
+}
+
+func (m *MemoryRequestCounts) CountRequest(
+	_ context.Context,
+	key string,
+	at time.Time,
+	window time.Duration,
+) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := m.requests[key]
+	if at.Sub(r.last) >= window {
+		r.count = 0
+	}
+
+	r.count++
+	r.last = at
+	m.requests[key] = r
+
+	return r.count, nil
+}
+
+func (m *MemoryRequestCounts) ForgetRequests(_ context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for key, r := range m.requests {
+		if r.last.Before(before) {
+			delete(m.requests, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
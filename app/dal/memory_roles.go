@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryRoles is an in-memory ports.RoleStore.
+type MemoryRoles struct {
+	mu     sync.RWMutex
+	roles  map[domain.Role]domain.RoleDefinition
+	grants map[int]map[domain.Role]time.Time
+}
+
+func NewMemoryRoles() *MemoryRoles {
+	return &MemoryRoles{
+		mu:     sync.RWMutex{},
+		roles:  make(map[domain.Role]domain.RoleDefinition),
+		grants: make(map[int]map[domain.Role]time.Time),
+	}
+}
+
+func (m *MemoryRoles) SaveRole(_ context.Context, r domain.RoleDefinition) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r.Permissions = slices.Clone(r.Permissions)
+	m.roles[r.Name] = r
+
+	return nil
+}
+
+func (m *MemoryRoles) Role(_ context.Context, name domain.Role) (domain.RoleDefinition, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.roles[name]
+	if !ok {
+		return domain.RoleDefinition{}, ports.ErrNotFound
+	}
+
+	r.Permissions = slices.Clone(r.Permissions)
+
+	return r, nil
+}
+
+func (m *MemoryRoles) Roles(_ context.Context) ([]domain.RoleDefinition, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	roles := make([]domain.RoleDefinition, 0, len(m.roles))
+	for _, name := range slices.Sorted(maps.Keys(m.roles)) {
+		r := m.roles[name]
+		r.Permissions = slices.Clone(r.Permissions)
+		roles = append(roles, r)
+	}
+
+	return roles, nil
+}
+
+func (m *MemoryRoles) DeleteRole(_ context.Context, name domain.Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.roles[name]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	delete(m.roles, name)
+
+	for _, roles := range m.grants {
+		delete(roles, name)
+	}
+
+	return nil
+}
+
+func (m *MemoryRoles) GrantRole(_ context.Context, customerID int, role domain.Role, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	roles, ok := m.grants[customerID]
+	if !ok {
+		roles = make(map[domain.Role]time.Time)
+		m.grants[customerID] = roles
+	}
+
+	_, ok = roles[role]
+	if ok {
+		return ports.ErrConflict
+	}
+
+	roles[role] = at
+
+	return nil
+}
+
+func (m *MemoryRoles) RevokeRole(_ context.Context, customerID int, role domain.Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.grants[customerID][role]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	delete(m.grants[customerID], role)
+
+	return nil
+}
+
+func (m *MemoryRoles) CustomerRoles(_ context.Context, customerID int) ([]domain.Role, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(m.grants[customerID])), nil
+}
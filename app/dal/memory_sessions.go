@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemorySessions is an in-memory ports.SessionStore. Its content is lost
+// when the process exits, which logs every customer out.
+type MemorySessions struct {
+	mu     sync.RWMutex
+	byID   map[string]domain.Session
+	byHash map[string]string
+}
+
+func NewMemorySessions() *MemorySessions {
+	return &MemorySessions{
+		mu:     sync.RWMutex{},
+		byID:   make(map[string]domain.Session),
+		byHash: make(map[string]string),
+	}
+}
+
+func (m *MemorySessions) CreateSession(_ context.Context, s domain.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.byID[s.ID]
+	if ok {
+		return ports.ErrConflict
+	}
+
+	_, ok = m.byHash[string(s.TokenHash)]
+	if ok {
+		return ports.ErrConflict
+	}
+
+	s.TokenHash = slices.Clone(s.TokenHash)
+
+	m.byID[s.ID] = s
+	m.byHash[string(s.TokenHash)] = s.ID
+
+	return nil
+}
+
+func (m *MemorySessions) SessionByTokenHash(_ context.Context, tokenHash []byte) (domain.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.byHash[string(tokenHash)]
+	if !ok {
+		return domain.Session{}, ports.ErrNotFound
+	}
+
+	return cloneSession(m.byID[id]), nil
+}
+
+func (m *MemorySessions) TouchSession(_ context.Context, id string, lastSeen, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.byID[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	s.LastSeen = lastSeen
+	s.Expires = expires
+	m.byID[id] = s
+
+	return nil
+}
+
+func (m *MemorySessions) StepUpSession(_ context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.byID[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	s.SteppedUp = at
+	m.byID[id] = s
+
+	return nil
+}
+
+func (m *MemorySessions) CustomerSessions(_ context.Context, customerID int, now time.Time) ([]domain.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var sessions []domain.Session
+	for _, s := range m.byID {
+		if s.CustomerID == customerID && !sessionExpired(s, now) {
+			sessions = append(sessions, cloneSession(s))
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b domain.Session) int {
+		return b.LastSeen.Compare(a.LastSeen)
+	})
+
+	return sessions, nil
+}
+
+func (m *MemorySessions) DeleteSession(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.byID[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	m.delete(s)
+
+	return nil
+}
+
+func (m *MemorySessions) DeleteCustomerSessions(_ context.Context, customerID int, except ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, s := range m.byID {
+		if s.CustomerID == customerID && !slices.Contains(except, s.ID) {
+			m.delete(s)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (m *MemorySessions) DeleteExpiredSessions(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, s := range m.byID {
+		if sessionExpired(s, now) {
+			m.delete(s)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// delete removes s. The caller must hold the lock.
+func (m *MemorySessions) delete(s domain.Session) {
+	delete(m.byID, s.ID)
+	delete(m.byHash, string(s.TokenHash))
+}
+
+func sessionExpired(s domain.Session, now time.Time) bool {
+	return !now.Before(s.Expires) || !now.Before(s.AbsoluteExpires)
+}
+
+func cloneSession(s domain.Session) domain.Session {
+	s.TokenHash = slices.Clone(s.TokenHash)
+	return s
+}
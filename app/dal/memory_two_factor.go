@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryTwoFactor is an in-memory ports.TwoFactorStore.
+type MemoryTwoFactor struct {
+	mu            sync.Mutex
+	totps         map[int]domain.TOTP
+	recoveryCodes map[int]map[string]struct{}
+}
+
+func NewMemoryTwoFactor() *MemoryTwoFactor {
+	return &MemoryTwoFactor{
+		mu:            sync.Mutex{},
+		totps:         make(map[int]domain.TOTP),
+		recoveryCodes: make(map[int]map[string]struct{}),
+	}
+}
+
+func (m *MemoryTwoFactor) SaveTOTP(_ context.Context, t domain.TOTP) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t.SealedSecret = slices.Clone(t.SealedSecret)
+	m.totps[t.CustomerID] = t
+
+	return nil
+}
+
+func (m *MemoryTwoFactor) TOTP(_ context.Context, customerID int) (domain.TOTP, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.totps[customerID]
+	if !ok {
+		return domain.TOTP{}, ports.ErrNotFound
+	}
+
+	t.SealedSecret = slices.Clone(t.SealedSecret)
+
+	return t, nil
+}
+
+func (m *MemoryTwoFactor) AdvanceTOTPCounter(_ context.Context, customerID int, counter int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.totps[customerID]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	if counter <= t.LastCounter {
+		return ports.ErrConflict
+	}
+
+	t.LastCounter = counter
+	m.totps[customerID] = t
+
+	return nil
+}
+
+func (m *MemoryTwoFactor) DeleteTOTP(_ context.Context, customerID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.totps, customerID)
+	delete(m.recoveryCodes, customerID)
+
+	return nil
+}
+
+func (m *MemoryTwoFactor) ReplaceRecoveryCodes(_ context.Context, customerID int, hashes [][]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	codes := make(map[string]struct{}, len(hashes))
+	for _, h := range hashes {
+		codes[string(h)] = struct{}{}
+	}
+
+	m.recoveryCodes[customerID] = codes
+
+	return nil
+}
+
+func (m *MemoryTwoFactor) ConsumeRecoveryCode(_ context.Context, customerID int, hash []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	codes := m.recoveryCodes[customerID]
+
+	_, ok := codes[string(hash)]
+	if !ok {
+		return 0, ports.ErrNotFound
+	}
+
+	delete(codes, string(hash))
+
+	return len(codes), nil
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryUpgradeRules is an in-memory ports.UpgradeRuleStore. Its content is
+// lost when the process exits, so it is meant for development and tests.
+type MemoryUpgradeRules struct {
+	mu     sync.RWMutex
+	lastID int
+	rules  map[int]domain.UpgradeRule
+}
+
+func NewMemoryUpgradeRules() *MemoryUpgradeRules {
+	return &MemoryUpgradeRules{
+		mu:     sync.RWMutex{},
+		lastID: 0,
+		rules:  make(map[int]domain.UpgradeRule),
+	}
+}
+
+func (m *MemoryUpgradeRules) CreateUpgradeRule(_ context.Context, r domain.UpgradeRule) (domain.UpgradeRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	r.ID = m.lastID
+	r.Prices = slices.Clone(r.Prices)
+	m.rules[r.ID] = r
+
+	return r, nil
+}
+
+func (m *MemoryUpgradeRules) UpgradeRules(_ context.Context) ([]domain.UpgradeRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rules := slices.SortedFunc(maps.Values(m.rules), func(a, b domain.UpgradeRule) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	for i := range rules {
+		rules[i].Prices = slices.Clone(rules[i].Prices)
+	}
+
+	return rules, nil
+}
+
+func (m *MemoryUpgradeRules) DeleteUpgradeRule(_ context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.rules[id]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	delete(m.rules, id)
+
+	return nil
+}
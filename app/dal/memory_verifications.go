@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"crypto/subtle"
+	"slices"
+	"sync"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryVerifications is an in-memory ports.VerificationStore.
+type MemoryVerifications struct {
+	mu           sync.Mutex
+	byCustomerID map[int]domain.Verification
+}
+
+func NewMemoryVerifications() *MemoryVerifications {
+	return &MemoryVerifications{
+		mu:           sync.Mutex{},
+		byCustomerID: make(map[int]domain.Verification),
+	}
+}
+
+func (m *MemoryVerifications) SaveVerification(_ context.Context, v domain.Verification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v.NonceHash = slices.Clone(v.NonceHash)
+	m.byCustomerID[v.CustomerID] = v
+
+	return nil
+}
+
+func (m *MemoryVerifications) Verification(_ context.Context, customerID int) (domain.Verification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.byCustomerID[customerID]
+	if !ok {
+		return domain.Verification{}, ports.ErrNotFound
+	}
+
+	v.NonceHash = slices.Clone(v.NonceHash)
+
+	return v, nil
+}
+
+func (m *MemoryVerifications) ConsumeVerification(
+	_ context.Context,
+	customerID int,
+	nonceHash []byte,
+) (domain.Verification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.byCustomerID[customerID]
+	if !ok || subtle.ConstantTimeCompare(v.NonceHash, nonceHash) != 1 {
+		return domain.Verification{}, ports.ErrNotFound
+	}
+
+	delete(m.byCustomerID, customerID)
+
+	return v, nil
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// MemoryWebhookInbox is an in-memory ports.WebhookInbox. Its content is
+// lost when the process exits, with the events not processed yet.
+type MemoryWebhookInbox struct {
+	mu     sync.Mutex
+	events map[string]domain.WebhookEvent
+}
+
+func NewMemoryWebhookInbox() *MemoryWebhookInbox {
+	return &MemoryWebhookInbox{
+		mu:     sync.Mutex{},
+		events: make(map[string]domain.WebhookEvent),
+	}
+}
+
+func (m *MemoryWebhookInbox) SaveWebhookEvent(_ context.Context, e domain.WebhookEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.events[e.ID]
+	if ok {
+		return ports.ErrConflict
+	}
+
+	e.Payload = slices.Clone(e.Payload)
+	m.events[e.ID] = e
+
+	return nil
+}
+
+func (m *MemoryWebhookInbox) ClaimWebhookEvents(
+	_ context.Context,
+	now, until time.Time,
+	limit int,
+) ([]domain.WebhookEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := make([]domain.WebhookEvent, 0)
+
+	for _, e := range m.events {
+		if e.Status == domain.WebhookPending && !e.NextAttempt.After(now) {
+			due = append(due, e)
+		}
+	}
+
+	slices.SortFunc(due, func(a, b domain.WebhookEvent) int {
+		return cmp.Or(a.Received.Compare(b.Received), cmp.Compare(a.ID, b.ID))
+	})
+
+	due = due[:min(len(due), limit)]
+
+	for i, e := range due {
+		e.NextAttempt = until
+		m.events[e.ID] = e
+
+		due[i].NextAttempt = until
+		due[i].Payload = slices.Clone(e.Payload)
+	}
+
+	return due, nil
+}
+
+func (m *MemoryWebhookInbox) UpdateWebhookEvent(_ context.Context, e domain.WebhookEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.events[e.ID]
+	if !ok {
+		return ports.ErrNotFound
+	}
+
+	e.Payload = slices.Clone(e.Payload)
+	m.events[e.ID] = e
+
+	return nil
+}
+
+func (m *MemoryWebhookInbox) WebhookEvent(_ context.Context, id string) (domain.WebhookEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.events[id]
+	if !ok {
+		return domain.WebhookEvent{}, ports.ErrNotFound
+	}
+
+	e.Payload = slices.Clone(e.Payload)
+
+	return e, nil
+}
+
+func (m *MemoryWebhookInbox) WebhookEvents(
+	_ context.Context,
+	status domain.WebhookStatus,
+	limit int,
+) ([]domain.WebhookEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := make([]domain.WebhookEvent, 0)
+
+	for _, e := range m.events {
+		if e.Status == status {
+			e.Payload = slices.Clone(e.Payload)
+			events = append(events, e)
+		}
+	}
+
+	slices.SortFunc(events, func(a, b domain.WebhookEvent) int {
+		return cmp.Or(b.Received.Compare(a.Received), cmp.Compare(b.ID, a.ID))
+	})
+
+	return events[:min(len(events), limit)], nil
+}
+
+func (m *MemoryWebhookInbox) DeleteProcessedWebhookEvents(_ context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+
+	for id, e := range m.events {
+		if e.Status == domain.WebhookProcessed && e.Processed.Before(before) {
+			delete(m.events, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrate applies the pending migrations of the PostgreSQL schema, in the
+// order of their file names. Each migration runs in its own transaction and
+// is recorded in the schema_migration table, so applied migrations are never
+// run twice.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migration (
+			name TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create migration table: %w", err)
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		err = migrate(ctx, db, name)
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckSchema returns ErrSchemaMismatch when the migrations applied to the
+// schema are not those of this build, without applying any. Tools that work
+// on the database of a running app check it instead of migrating it, which
+// is left to the app.
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT name FROM schema_migration ORDER BY name`)
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	var applied []string
+
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+		if err != nil {
+			return fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+
+		applied = append(applied, name)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	slices.Sort(names)
+
+	if !slices.Equal(applied, names) {
+		return fmt.Errorf("%w: %d migrations applied, %d known", ErrSchemaMismatch, len(applied), len(names))
+	}
+
+	return nil
+}
+
+func migrate(ctx context.Context, db *sql.DB, name string) error {
+	script, err := migrations.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Serialize concurrent migrations, such as when several instances start
+	// at once.
+	_, err = tx.ExecContext(ctx, `LOCK TABLE schema_migration IN EXCLUSIVE MODE`)
+	if err != nil {
+		return err
+	}
+
+	var applied bool
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM schema_migration WHERE name = $1)`,
+		name,
+	).Scan(&applied)
+	if err != nil {
+		return err
+	}
+
+	if applied {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, string(script))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migration (name) VALUES ($1)`, name)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type MigrationError string
+
+func (e MigrationError) Error() string {
+	return string(e)
+}
+
+const ErrSchemaMismatch MigrationError = "database schema does not match this build"
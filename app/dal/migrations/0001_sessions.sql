@@ -0,0 +1,19 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+CREATE TABLE customer_session (
+    id TEXT PRIMARY KEY,
+    token_hash BYTEA NOT NULL UNIQUE,
+    customer_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    absolute_expires_at TIMESTAMPTZ NOT NULL,
+    user_agent TEXT NOT NULL,
+    ip TEXT NOT NULL
+);
+
+CREATE INDEX customer_session_customer_id_idx ON customer_session (customer_id);
+
+CREATE INDEX customer_session_expires_at_idx ON customer_session (expires_at);
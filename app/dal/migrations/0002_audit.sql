@@ -0,0 +1,14 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+CREATE TABLE audit_event (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    detail TEXT NOT NULL
+);
+
+CREATE INDEX audit_event_subject_idx ON audit_event (subject, occurred_at);
@@ -0,0 +1,10 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+CREATE TABLE email_verification (
+    customer_id BIGINT PRIMARY KEY,
+    nonce_hash BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,19 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+ALTER TABLE customer_session ADD COLUMN stepped_up_at TIMESTAMPTZ;
+
+CREATE TABLE customer_totp (
+    customer_id BIGINT PRIMARY KEY,
+    sealed_secret BYTEA NOT NULL,
+    confirmed BOOLEAN NOT NULL,
+    last_counter BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE recovery_code (
+    customer_id BIGINT NOT NULL,
+    code_hash BYTEA NOT NULL,
+    PRIMARY KEY (customer_id, code_hash)
+);
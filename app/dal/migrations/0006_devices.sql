@@ -0,0 +1,39 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Scopes are kept space separated, as in OAuth 2.0.
+
+CREATE TABLE device_authorization (
+    device_code_hash BYTEA PRIMARY KEY,
+    user_code TEXT NOT NULL UNIQUE,
+    client_id TEXT NOT NULL,
+    device_name TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    interval_seconds INTEGER NOT NULL,
+    last_polled_at TIMESTAMPTZ,
+    status TEXT NOT NULL,
+    customer_id BIGINT NOT NULL
+);
+
+CREATE INDEX device_authorization_expires_at_idx ON device_authorization (expires_at);
+
+CREATE TABLE customer_device (
+    id TEXT PRIMARY KEY,
+    customer_id BIGINT NOT NULL,
+    client_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    refresh_token_hash BYTEA NOT NULL UNIQUE,
+    access_token_hash BYTEA NOT NULL UNIQUE,
+    access_expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX customer_device_customer_id_idx ON customer_device (customer_id);
+
+CREATE INDEX customer_device_expires_at_idx ON customer_device (expires_at);
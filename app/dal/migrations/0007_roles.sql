@@ -0,0 +1,22 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Permissions are kept space separated, like the scopes of devices. Grants
+-- of built-in roles name no row of custom_role, so role is not a foreign
+-- key.
+
+CREATE TABLE custom_role (
+    name TEXT PRIMARY KEY,
+    permissions TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE customer_role (
+    customer_id BIGINT NOT NULL,
+    role TEXT NOT NULL,
+    granted_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (customer_id, role)
+);
+
+CREATE INDEX customer_role_role_idx ON customer_role (role);
@@ -0,0 +1,24 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Types are the values of domain.ProductType. Media are kept as a JSON array
+-- of {kind, url, alt} objects, since they are only ever read with their
+-- product.
+
+CREATE TABLE product (
+    id BIGSERIAL PRIMARY KEY,
+    type SMALLINT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    media JSONB NOT NULL,
+    published BOOLEAN NOT NULL,
+    position INTEGER NOT NULL,
+    stripe_price_id TEXT NOT NULL,
+    stripe_product_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX product_position_idx ON product (position, id);
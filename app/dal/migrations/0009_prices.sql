@@ -0,0 +1,14 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Amounts are in the minor units of their currency. Region is a country
+-- code, or "default" for the price of every other country.
+
+CREATE TABLE product_price (
+    product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+    currency TEXT NOT NULL,
+    region TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    PRIMARY KEY (product_id, currency, region)
+);
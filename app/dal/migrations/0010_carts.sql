@@ -0,0 +1,26 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Guest carts have no customer. Lines do not reference the product table:
+-- carts outlive the products deleted from the catalog, whose lines are then
+-- shown as unavailable.
+
+CREATE TABLE cart (
+    id TEXT PRIMARY KEY,
+    customer_id BIGINT UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX cart_expires_at_idx ON cart (expires_at);
+
+CREATE TABLE cart_line (
+    cart_id TEXT NOT NULL REFERENCES cart (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    product_id BIGINT NOT NULL,
+    quantity INTEGER NOT NULL,
+    gift BOOLEAN NOT NULL,
+    PRIMARY KEY (cart_id, product_id, gift)
+);
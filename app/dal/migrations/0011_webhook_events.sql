@@ -0,0 +1,21 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- The inbox of the webhook events of the payment provider. The payload is
+-- kept as received, byte for byte.
+
+CREATE TABLE webhook_event (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    last_error TEXT NOT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    processed_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_event_due_idx ON webhook_event (status, next_attempt_at);
+CREATE INDEX webhook_event_received_at_idx ON webhook_event (received_at);
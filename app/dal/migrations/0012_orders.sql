@@ -0,0 +1,52 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- "order" is a reserved word, hence customer_order. Amounts are in the
+-- minor units of the currency of their order. Lines do not reference the
+-- product table: they are snapshots of products as they were bought, and
+-- outlive them.
+
+ALTER TABLE product ADD COLUMN version TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE customer_order (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL,
+    status TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    total BIGINT NOT NULL,
+    refunded BIGINT NOT NULL,
+    checkout_id TEXT NOT NULL UNIQUE,
+    payment_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX customer_order_customer_id_idx ON customer_order (customer_id, created_at);
+CREATE INDEX customer_order_status_idx ON customer_order (status, created_at);
+CREATE INDEX customer_order_payment_id_idx ON customer_order (payment_id) WHERE payment_id <> '';
+
+CREATE TABLE order_line (
+    order_id BIGINT NOT NULL REFERENCES customer_order (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    product_id BIGINT NOT NULL,
+    product_type SMALLINT NOT NULL,
+    name TEXT NOT NULL,
+    version TEXT NOT NULL,
+    unit_amount BIGINT NOT NULL,
+    quantity INTEGER NOT NULL,
+    gift BOOLEAN NOT NULL,
+    PRIMARY KEY (order_id, position)
+);
+
+CREATE TABLE order_transition (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES customer_order (id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX order_transition_order_id_idx ON order_transition (order_id, id);
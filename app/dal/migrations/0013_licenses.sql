@@ -0,0 +1,22 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Licenses outlive the orders they were issued for, which are never
+-- deleted anyway, so they do not reference them.
+
+CREATE TABLE license (
+    id BIGSERIAL PRIMARY KEY,
+    key TEXT NOT NULL UNIQUE,
+    order_id BIGINT NOT NULL,
+    customer_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    edition TEXT NOT NULL,
+    issued_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    revoke_reason TEXT NOT NULL DEFAULT '',
+    UNIQUE (order_id, product_id)
+);
+
+CREATE INDEX license_customer_id_idx ON license (customer_id, id);
@@ -0,0 +1,33 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- A machine limit of zero means no limit.
+
+ALTER TABLE product ADD COLUMN machine_limit INTEGER NOT NULL DEFAULT 0;
+
+-- A license is active at most once per machine, but may be activated on a
+-- machine again once deactivated there.
+
+CREATE TABLE activation (
+    id BIGSERIAL PRIMARY KEY,
+    license_id BIGINT NOT NULL REFERENCES license (id),
+    customer_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    machine TEXT NOT NULL,
+    machine_name TEXT NOT NULL DEFAULT '',
+    activated_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    deactivated_at TIMESTAMPTZ,
+    deactivate_reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX activation_machine_idx ON activation (license_id, machine) WHERE deactivated_at IS NULL;
+
+CREATE INDEX activation_license_id_idx ON activation (license_id, id);
+
+CREATE INDEX activation_customer_id_idx ON activation (customer_id, id) WHERE deactivated_at IS NULL;
+
+CREATE INDEX activation_last_seen_at_idx ON activation (last_seen_at) WHERE deactivated_at IS NULL;
+
+CREATE INDEX activation_activated_at_idx ON activation (activated_at);
@@ -0,0 +1,19 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Trials have no order until they are bought.
+
+ALTER TABLE license ALTER COLUMN order_id DROP NOT NULL;
+
+-- Trials are kept once converted, so that no customer or machine tries a
+-- product twice.
+
+CREATE TABLE license_trial (
+    license_id BIGINT PRIMARY KEY REFERENCES license (id),
+    customer_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    machine TEXT NOT NULL,
+    UNIQUE (customer_id, product_id),
+    UNIQUE (product_id, machine)
+);
@@ -0,0 +1,43 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- An empty from_version matches every version. Rules have either prices,
+-- kept like those of products, or a percentage off.
+
+CREATE TABLE upgrade_rule (
+    id BIGSERIAL PRIMARY KEY,
+    from_product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+    from_version TEXT NOT NULL,
+    to_product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+    percent_off INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX upgrade_rule_to_product_id_idx ON upgrade_rule (to_product_id);
+
+CREATE TABLE upgrade_rule_price (
+    rule_id BIGINT NOT NULL REFERENCES upgrade_rule (id) ON DELETE CASCADE,
+    currency TEXT NOT NULL,
+    region TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    PRIMARY KEY (rule_id, currency, region)
+);
+
+-- Lines bought as upgrades keep the license they upgrade from, which their
+-- license then records as its lineage.
+
+ALTER TABLE order_line ADD COLUMN upgrade_from BIGINT;
+
+ALTER TABLE license ADD COLUMN version TEXT NOT NULL DEFAULT '';
+ALTER TABLE license ADD COLUMN upgraded_from BIGINT REFERENCES license (id);
+
+CREATE INDEX license_upgraded_from_idx ON license (upgraded_from) WHERE upgraded_from IS NOT NULL;
+
+-- Licenses issued before had the version of their order line.
+
+UPDATE license SET version = order_line.version
+FROM order_line
+WHERE order_line.order_id = license.order_id
+    AND order_line.product_id = license.product_id
+    AND NOT order_line.gift;
@@ -0,0 +1,20 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Emails are stored normalized, so the unique constraint makes one account
+-- per address. Sessions, roles, orders and licenses were keyed by the IDs
+-- of customers kept in memory before this table existed, so they have no
+-- foreign key to it.
+
+CREATE TABLE customer (
+    id BIGSERIAL PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    hashed_password BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT false,
+    payment_customer_id TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX customer_payment_customer_id_idx ON customer (payment_customer_id)
+    WHERE payment_customer_id <> '';
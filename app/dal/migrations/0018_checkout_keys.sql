@@ -0,0 +1,24 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Customers are created at the payment provider with a random idempotency
+-- key, recorded before the first attempt so that retries and racing
+-- checkouts reuse it.
+
+ALTER TABLE customer ADD COLUMN payment_customer_key TEXT NOT NULL DEFAULT '';
+
+-- Orders are placed before their checkout session is opened, keyed by the
+-- idempotency key of the checkout, and get the ID of the session once it
+-- is. Orders placed before had their session already, whose ID serves as
+-- their key.
+
+ALTER TABLE customer_order ADD COLUMN checkout_key TEXT;
+
+UPDATE customer_order SET checkout_key = checkout_id;
+
+ALTER TABLE customer_order ALTER COLUMN checkout_key SET NOT NULL;
+ALTER TABLE customer_order ADD CONSTRAINT customer_order_checkout_key_key UNIQUE (checkout_key);
+ALTER TABLE customer_order DROP CONSTRAINT customer_order_checkout_id_key;
+
+CREATE UNIQUE INDEX customer_order_checkout_id_idx ON customer_order (checkout_id) WHERE checkout_id <> '';
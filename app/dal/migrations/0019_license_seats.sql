@@ -0,0 +1,21 @@
+-- SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+--
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Orders get a license for every unit of their plugin lines, such as the
+-- copies of a plugin bought as gifts, so licenses are keyed by the line and
+-- seat they were issued for rather than by product.
+
+ALTER TABLE license ADD COLUMN line INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE license ADD COLUMN seat INTEGER NOT NULL DEFAULT 0;
+
+-- Licenses issued before were issued for the first line of their product.
+
+UPDATE license SET line = COALESCE((
+    SELECT MIN(order_line.position) FROM order_line
+    WHERE order_line.order_id = license.order_id AND order_line.product_id = license.product_id
+), 0)
+WHERE order_id IS NOT NULL;
+
+ALTER TABLE license DROP CONSTRAINT license_order_id_product_id_key;
+ALTER TABLE license ADD CONSTRAINT license_order_id_line_seat_key UNIQUE (order_id, line, seat);
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryOrders(t *testing.T) {
+	testOrderStore(t, dal.NewMemoryOrders())
+}
+
+func TestPostgreSQLOrders(t *testing.T) {
+	testOrderStore(t, dal.NewPostgreSQLOrders(newTestDB(t)))
+}
+
+// testOrderStore checks the behavior every ports.OrderStore must have.
+func testOrderStore(t *testing.T, store ports.OrderStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	usd := func(amount int64) domain.Money { return domain.Money{Amount: amount, Currency: domain.CurrencyUSD} }
+
+	create := func(customerID int, checkoutID string, created time.Time) (domain.Order, error) {
+		return store.CreateOrder(ctx, domain.Order{
+			ID:         0,
+			CustomerID: customerID,
+			Status:     domain.OrderPending,
+			Lines: []domain.OrderLine{
+				{
+					ProductID:   2,
+					ProductType: domain.ProductPlugin,
+					Name:        "Microwave",
+					Version:     "1.2.0",
+					UnitPrice:   usd(4900),
+					Quantity:    1,
+					Gift:        false,
+					UpgradeFrom: 7,
+				},
+				{
+					ProductID:   1,
+					ProductType: domain.ProductMerchandise,
+					Name:        "Shirt",
+					Version:     "",
+					UnitPrice:   usd(2500),
+					Quantity:    2,
+					Gift:        true,
+					UpgradeFrom: 0,
+				},
+			},
+			Total:       usd(9900),
+			Refunded:    usd(0),
+			CheckoutKey: "checkout-" + checkoutID,
+			CheckoutID:  checkoutID,
+			PaymentID:   "",
+			Created:     created,
+			Updated:     created,
+		}, domain.OrderTransition{
+			OrderID: 0,
+			From:    "",
+			To:      domain.OrderPending,
+			Actor:   "customer 1",
+			Reason:  "checked out",
+			Time:    created,
+		})
+	}
+
+	first, err := create(1, "cs_1", now)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID, 0)
+
+	_, err = create(1, "cs_1", now)
+	assert.Error(t, err, ports.ErrConflict)
+
+	second, err := create(1, "cs_2", now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	_, err = create(2, "cs_3", now)
+	assert.NoError(t, err)
+
+	got, err := store.Order(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.CheckoutID, "cs_1")
+	assert.Equal(t, got.Total, usd(9900))
+	assert.Equal(t, got.Refunded, usd(0))
+	assert.Equal(t, len(got.Lines), 2)
+	assert.Equal(t, got.Lines[0].Version, "1.2.0")
+	assert.Equal(t, got.Lines[0].UpgradeFrom, 7)
+	assert.Equal(t, got.Lines[1].UpgradeFrom, 0)
+	assert.Equal(t, got.Lines[1].UnitPrice, usd(2500))
+	assert.True(t, got.Lines[1].Gift)
+	assert.True(t, got.Created.Equal(now))
+
+	_, err = store.Order(ctx, 1000)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	got, err = store.OrderByCheckout(ctx, "cs_2")
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, second.ID)
+
+	_, err = store.OrderByCheckout(ctx, "cs_4")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	got, err = store.OrderByCheckoutKey(ctx, "checkout-cs_2")
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, second.ID)
+
+	_, err = store.OrderByCheckoutKey(ctx, "checkout-cs_4")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	// Orders are placed before their session is opened, and get it later.
+	placed, err := create(2, "", now)
+	assert.NoError(t, err)
+
+	_, err = store.OrderByCheckout(ctx, "")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	assert.NoError(t, store.SetOrderCheckout(ctx, placed.ID, "cs_5"))
+	assert.Error(t, store.SetOrderCheckout(ctx, 1000, "cs_6"), ports.ErrNotFound)
+
+	got, err = store.OrderByCheckout(ctx, "cs_5")
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, placed.ID)
+	assert.Equal(t, got.CheckoutKey, "checkout-")
+
+	// Orders without a payment are not found by payment.
+	_, err = store.OrderByPayment(ctx, "")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	orders, err := store.CustomerOrders(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), 2)
+	assert.Equal(t, orders[0].ID, second.ID)
+	assert.Equal(t, len(orders[1].Lines), 2)
+
+	// Transitions are saved along with the order, from the status it is in.
+	paid := first
+	transition, err := paid.Transition(domain.OrderPaid, "payments", "checkout completed", now.Add(time.Minute))
+	assert.NoError(t, err)
+
+	paid.PaymentID = "pi_1"
+
+	assert.NoError(t, store.TransitionOrder(ctx, paid, transition))
+	assert.Error(t, store.TransitionOrder(ctx, paid, transition), ports.ErrConflict)
+
+	missing := paid
+	missing.ID = 1000
+	assert.Error(t, store.TransitionOrder(ctx, missing, transition), ports.ErrNotFound)
+
+	got, err = store.OrderByPayment(ctx, "pi_1")
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, first.ID)
+	assert.Equal(t, got.Status, domain.OrderPaid)
+	assert.True(t, got.Updated.Equal(now.Add(time.Minute)))
+
+	history, err := store.OrderTransitions(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(history), 2)
+	assert.Equal(t, history[0].To, domain.OrderPending)
+	assert.Equal(t, history[0].OrderID, first.ID)
+	assert.Equal(t, history[1].From, domain.OrderPending)
+	assert.Equal(t, history[1].Actor, "payments")
+	assert.True(t, history[1].Time.Equal(now.Add(time.Minute)))
+
+	orders, err = store.Orders(ctx, domain.OrderPending, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), 1)
+	assert.Equal(t, orders[0].ID, second.ID)
+
+	orders, err = store.Orders(ctx, domain.OrderPaid, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), 1)
+}
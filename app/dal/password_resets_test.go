@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryPasswordResets(t *testing.T) {
+	testPasswordResetStore(t, dal.NewMemoryPasswordResets())
+}
+
+func TestPostgreSQLPasswordResets(t *testing.T) {
+	testPasswordResetStore(t, dal.NewPostgreSQLPasswordResets(newTestDB(t)))
+}
+
+func testPasswordResetStore(t *testing.T, store ports.PasswordResetStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := store.PasswordReset(ctx, 1)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	for _, r := range []domain.PasswordReset{
+		{CustomerID: 1, TokenHash: []byte("first"), Created: now, Expires: now.Add(time.Hour)},
+		{CustomerID: 1, TokenHash: []byte("second"), Created: now.Add(time.Minute), Expires: now.Add(time.Hour)},
+		{CustomerID: 2, TokenHash: []byte("other"), Created: now, Expires: now.Add(time.Hour)},
+	} {
+		err = store.SavePasswordReset(ctx, r)
+		assert.NoError(t, err)
+	}
+
+	r, err := store.PasswordReset(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, string(r.TokenHash), "second")
+
+	// The replaced reset cannot be consumed.
+	_, err = store.ConsumePasswordReset(ctx, []byte("first"))
+	assert.Error(t, err, ports.ErrNotFound)
+
+	r, err = store.ConsumePasswordReset(ctx, []byte("second"))
+	assert.NoError(t, err)
+	assert.Equal(t, r.CustomerID, 1)
+
+	_, err = store.ConsumePasswordReset(ctx, []byte("second"))
+	assert.Error(t, err, ports.ErrNotFound)
+
+	r, err = store.PasswordReset(ctx, 2)
+	assert.NoError(t, err)
+	assert.True(t, r.Created.Equal(now))
+}
@@ -0,0 +1,297 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLActivations is a ports.ActivationStore backed by the
+// activation table. The schema is created by Migrate.
+type PostgreSQLActivations struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLActivations(db *sql.DB) *PostgreSQLActivations {
+	return &PostgreSQLActivations{
+		db: db,
+	}
+}
+
+const activationColumns = `id, license_id, customer_id, product_id, machine, machine_name, activated_at,
+	last_seen_at, deactivated_at, deactivate_reason`
+
+func (p *PostgreSQLActivations) CreateActivation(
+	ctx context.Context,
+	a domain.Activation,
+	limit int,
+) (domain.Activation, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Activation{}, err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	// Locking the license serializes its activations, so that two machines
+	// cannot both take its last free seat.
+	var licenseID int
+
+	err = tx.QueryRowContext(ctx, `SELECT id FROM license WHERE id = $1 FOR UPDATE`, a.LicenseID).Scan(&licenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Activation{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.Activation{}, err
+	}
+
+	var active int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM activation WHERE license_id = $1 AND deactivated_at IS NULL`,
+		a.LicenseID,
+	).Scan(&active)
+	if err != nil {
+		return domain.Activation{}, err
+	}
+
+	if limit > 0 && active >= limit {
+		return domain.Activation{}, ports.ErrConflict
+	}
+
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO activation (license_id, customer_id, product_id, machine, machine_name, activated_at,
+			last_seen_at, deactivated_at, deactivate_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT DO NOTHING
+		RETURNING id`,
+		a.LicenseID,
+		a.CustomerID,
+		a.ProductID,
+		a.Machine,
+		a.MachineName,
+		a.Activated,
+		a.LastSeen,
+		nullTime(a.Deactivated),
+		a.DeactivateReason,
+	).Scan(&a.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Activation{}, ports.ErrConflict
+	}
+
+	if err != nil {
+		return domain.Activation{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return domain.Activation{}, err
+	}
+
+	return a, nil
+}
+
+func (p *PostgreSQLActivations) Activation(ctx context.Context, id int) (domain.Activation, error) {
+	return p.activation(ctx, `SELECT `+activationColumns+` FROM activation WHERE id = $1`, id)
+}
+
+func (p *PostgreSQLActivations) MachineActivation(
+	ctx context.Context,
+	licenseID int,
+	machine string,
+) (domain.Activation, error) {
+	return p.activation(
+		ctx,
+		`SELECT `+activationColumns+` FROM activation
+		WHERE license_id = $1 AND machine = $2 AND deactivated_at IS NULL`,
+		licenseID,
+		machine,
+	)
+}
+
+func (p *PostgreSQLActivations) LicenseActivations(ctx context.Context, licenseID int) ([]domain.Activation, error) {
+	return p.activations(
+		ctx,
+		`SELECT `+activationColumns+` FROM activation WHERE license_id = $1 ORDER BY id`,
+		licenseID,
+	)
+}
+
+func (p *PostgreSQLActivations) CustomerActivations(ctx context.Context, customerID int) ([]domain.Activation, error) {
+	return p.activations(
+		ctx,
+		`SELECT `+activationColumns+` FROM activation
+		WHERE customer_id = $1 AND deactivated_at IS NULL
+		ORDER BY id`,
+		customerID,
+	)
+}
+
+func (p *PostgreSQLActivations) TouchActivation(ctx context.Context, id int, at time.Time) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE activation SET last_seen_at = $2 WHERE id = $1 AND deactivated_at IS NULL`,
+		id,
+		at,
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLActivations) DeactivateActivation(ctx context.Context, id int, at time.Time, reason string) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE activation SET deactivated_at = COALESCE(deactivated_at, $2),
+			deactivate_reason = CASE WHEN deactivated_at IS NULL THEN $3 ELSE deactivate_reason END
+		WHERE id = $1`,
+		id,
+		at,
+		reason,
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLActivations) ExpireActivations(
+	ctx context.Context,
+	seenBefore, at time.Time,
+	reason string,
+) (int, error) {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE activation SET deactivated_at = $2, deactivate_reason = $3
+		WHERE deactivated_at IS NULL AND last_seen_at < $1`,
+		seenBefore,
+		at,
+		reason,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+func (p *PostgreSQLActivations) ActivationCounts(
+	ctx context.Context,
+	since time.Time,
+	atLeast, limit int,
+) ([]domain.ActivationCount, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT license_id, MIN(customer_id), MIN(product_id),
+			COUNT(*) FILTER (WHERE deactivated_at IS NULL),
+			COUNT(DISTINCT machine) FILTER (WHERE activated_at >= $1) AS machines
+		FROM activation
+		WHERE activated_at >= $1 OR deactivated_at IS NULL
+		GROUP BY license_id
+		HAVING COUNT(DISTINCT machine) FILTER (WHERE activated_at >= $1) >= $2
+		ORDER BY machines DESC, license_id
+		LIMIT $3`,
+		since,
+		atLeast,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	counts := make([]domain.ActivationCount, 0)
+
+	for rows.Next() {
+		var c domain.ActivationCount
+
+		err = rows.Scan(&c.LicenseID, &c.CustomerID, &c.ProductID, &c.Active, &c.Machines)
+		if err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+func (p *PostgreSQLActivations) activation(ctx context.Context, query string, args ...any) (domain.Activation, error) {
+	a, err := scanActivation(p.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Activation{}, ports.ErrNotFound
+	}
+
+	return a, err
+}
+
+func (p *PostgreSQLActivations) activations(ctx context.Context, query string, args ...any) ([]domain.Activation, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	activations := make([]domain.Activation, 0)
+
+	for rows.Next() {
+		a, err := scanActivation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		activations = append(activations, a)
+	}
+
+	return activations, rows.Err()
+}
+
+func scanActivation(row scanner) (domain.Activation, error) {
+	var (
+		a           domain.Activation
+		deactivated sql.NullTime
+	)
+
+	err := row.Scan(
+		&a.ID,
+		&a.LicenseID,
+		&a.CustomerID,
+		&a.ProductID,
+		&a.Machine,
+		&a.MachineName,
+		&a.Activated,
+		&a.LastSeen,
+		&deactivated,
+		&a.DeactivateReason,
+	)
+	if err != nil {
+		return domain.Activation{}, err
+	}
+
+	a.Activated = a.Activated.UTC()
+	a.LastSeen = a.LastSeen.UTC()
+
+	if deactivated.Valid {
+		a.Deactivated = deactivated.Time.UTC()
+	}
+
+	return a, nil
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// PostgreSQLAuditLog is a ports.AuditLog backed by the audit_event table.
+// The schema is created by Migrate.
+type PostgreSQLAuditLog struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLAuditLog(db *sql.DB) *PostgreSQLAuditLog {
+	return &PostgreSQLAuditLog{
+		db: db,
+	}
+}
+
+func (p *PostgreSQLAuditLog) RecordAudit(ctx context.Context, e domain.AuditEvent) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO audit_event (type, subject, ip, occurred_at, detail) VALUES ($1, $2, $3, $4, $5)`,
+		string(e.Type),
+		e.Subject,
+		e.IP,
+		e.Time,
+		e.Detail,
+	)
+
+	return err
+}
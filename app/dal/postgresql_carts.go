@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLCarts is a ports.CartStore backed by the cart and cart_line
+// tables. The schema is created by Migrate.
+type PostgreSQLCarts struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLCarts(db *sql.DB) *PostgreSQLCarts {
+	return &PostgreSQLCarts{
+		db: db,
+	}
+}
+
+const cartColumns = `id, customer_id, created_at, updated_at, expires_at`
+
+func (p *PostgreSQLCarts) SaveCart(ctx context.Context, c domain.Cart) error {
+	customerID := sql.NullInt64{Int64: int64(c.CustomerID), Valid: c.CustomerID != 0}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE cart SET customer_id = $2, updated_at = $3, expires_at = $4
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM cart WHERE customer_id = $2 AND id <> $1)`,
+		c.ID,
+		customerID,
+		c.Updated,
+		c.Expires,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = affectedOne(res)
+	if errors.Is(err, ports.ErrNotFound) {
+		// Either the cart is new, or another cart belongs to the customer,
+		// in which case the insert conflicts as well.
+		res, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO cart (`+cartColumns+`) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING`,
+			c.ID,
+			customerID,
+			c.Created,
+			c.Updated,
+			c.Expires,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = insertedOne(res)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM cart_line WHERE cart_id = $1`, c.ID)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range c.Lines {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO cart_line (cart_id, position, product_id, quantity, gift) VALUES ($1, $2, $3, $4, $5)`,
+			c.ID,
+			i,
+			line.ProductID,
+			line.Quantity,
+			line.Gift,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (p *PostgreSQLCarts) CartByID(ctx context.Context, id string) (domain.Cart, error) {
+	return p.cart(ctx, `SELECT `+cartColumns+` FROM cart WHERE id = $1`, id)
+}
+
+func (p *PostgreSQLCarts) CartByCustomer(ctx context.Context, customerID int) (domain.Cart, error) {
+	return p.cart(ctx, `SELECT `+cartColumns+` FROM cart WHERE customer_id = $1`, customerID)
+}
+
+func (p *PostgreSQLCarts) DeleteCart(ctx context.Context, id string) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM cart WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLCarts) DeleteExpiredCarts(ctx context.Context, now time.Time) (int, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM cart WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+// cart returns the cart selected by query, with its lines.
+func (p *PostgreSQLCarts) cart(ctx context.Context, query string, arg any) (domain.Cart, error) {
+	var (
+		c          domain.Cart
+		customerID sql.NullInt64
+	)
+
+	err := p.db.QueryRowContext(ctx, query, arg).Scan(&c.ID, &customerID, &c.Created, &c.Updated, &c.Expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Cart{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	c.CustomerID = int(customerID.Int64)
+	c.Created = c.Created.UTC()
+	c.Updated = c.Updated.UTC()
+	c.Expires = c.Expires.UTC()
+
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT product_id, quantity, gift FROM cart_line WHERE cart_id = $1 ORDER BY position`,
+		c.ID,
+	)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var line domain.CartLine
+
+		err = rows.Scan(&line.ProductID, &line.Quantity, &line.Gift)
+		if err != nil {
+			return domain.Cart{}, err
+		}
+
+		c.Lines = append(c.Lines, line)
+	}
+
+	return c, rows.Err()
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLCustomers is a ports.CustomerRepository backed by the customer
+// table. The schema is created by Migrate.
+type PostgreSQLCustomers struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLCustomers(db *sql.DB) *PostgreSQLCustomers {
+	return &PostgreSQLCustomers{
+		db: db,
+	}
+}
+
+const customerColumns = `id, email, hashed_password, created_at, verified, payment_customer_id`
+
+func (p *PostgreSQLCustomers) CreateCustomer(ctx context.Context, c domain.Customer) (domain.Customer, error) {
+	err := p.db.QueryRowContext(
+		ctx,
+		`INSERT INTO customer (email, hashed_password, created_at, verified, payment_customer_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (email) DO NOTHING
+		RETURNING id`,
+		c.Email,
+		c.HashedPassword,
+		c.Created,
+		c.Verified,
+		c.PaymentCustomerID,
+	).Scan(&c.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Customer{}, ports.ErrConflict
+	}
+
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	return c, nil
+}
+
+func (p *PostgreSQLCustomers) CustomerByEmail(ctx context.Context, email string) (domain.Customer, error) {
+	return scanCustomer(p.db.QueryRowContext(
+		ctx,
+		`SELECT `+customerColumns+` FROM customer WHERE email = $1`,
+		email,
+	))
+}
+
+func (p *PostgreSQLCustomers) CustomerByID(ctx context.Context, id int) (domain.Customer, error) {
+	return scanCustomer(p.db.QueryRowContext(
+		ctx,
+		`SELECT `+customerColumns+` FROM customer WHERE id = $1`,
+		id,
+	))
+}
+
+func (p *PostgreSQLCustomers) MarkCustomerVerified(ctx context.Context, id int) error {
+	res, err := p.db.ExecContext(ctx, `UPDATE customer SET verified = true WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLCustomers) UpdateCustomerPassword(ctx context.Context, id int, hashedPassword []byte) error {
+	res, err := p.db.ExecContext(ctx, `UPDATE customer SET hashed_password = $2 WHERE id = $1`, id, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLCustomers) SetPaymentCustomerID(ctx context.Context, id int, paymentCustomerID string) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE customer SET payment_customer_id = $2 WHERE id = $1`,
+		id,
+		paymentCustomerID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLCustomers) ReservePaymentCustomerKey(ctx context.Context, id int, key string) (string, error) {
+	err := p.db.QueryRowContext(
+		ctx,
+		`UPDATE customer
+		SET payment_customer_key = CASE WHEN payment_customer_key = '' THEN $2 ELSE payment_customer_key END
+		WHERE id = $1
+		RETURNING payment_customer_key`,
+		id,
+		key,
+	).Scan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ports.ErrNotFound
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func scanCustomer(row scanner) (domain.Customer, error) {
+	var c domain.Customer
+
+	err := row.Scan(&c.ID, &c.Email, &c.HashedPassword, &c.Created, &c.Verified, &c.PaymentCustomerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Customer{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	c.Created = c.Created.UTC()
+
+	return c, nil
+}
@@ -0,0 +1,366 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLDevices is a ports.DeviceStore backed by the
+// device_authorization and customer_device tables. The schema is created by
+// Migrate.
+type PostgreSQLDevices struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLDevices(db *sql.DB) *PostgreSQLDevices {
+	return &PostgreSQLDevices{
+		db: db,
+	}
+}
+
+const deviceAuthorizationColumns = `device_code_hash, user_code, client_id, device_name, scopes, created_at, expires_at,
+	interval_seconds, last_polled_at, status, customer_id`
+
+const deviceColumns = `id, customer_id, client_id, name, scopes, refresh_token_hash, access_token_hash,
+	access_expires_at, created_at, last_seen_at, expires_at`
+
+func (p *PostgreSQLDevices) CreateDeviceAuthorization(ctx context.Context, a domain.DeviceAuthorization) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO device_authorization (`+deviceAuthorizationColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT DO NOTHING`,
+		a.DeviceCodeHash,
+		a.UserCode,
+		a.ClientID,
+		a.DeviceName,
+		strings.Join(a.Scopes, " "),
+		a.Created,
+		a.Expires,
+		int(a.Interval/time.Second),
+		nullTime(a.LastPolled),
+		string(a.Status),
+		a.CustomerID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return insertedOne(res)
+}
+
+func (p *PostgreSQLDevices) DeviceAuthorizationByUserCode(
+	ctx context.Context,
+	userCode string,
+) (domain.DeviceAuthorization, error) {
+	return scanDeviceAuthorization(p.db.QueryRowContext(
+		ctx,
+		`SELECT `+deviceAuthorizationColumns+` FROM device_authorization WHERE user_code = $1`,
+		userCode,
+	))
+}
+
+func (p *PostgreSQLDevices) DeviceAuthorizationByDeviceCode(
+	ctx context.Context,
+	deviceCodeHash []byte,
+) (domain.DeviceAuthorization, error) {
+	return scanDeviceAuthorization(p.db.QueryRowContext(
+		ctx,
+		`SELECT `+deviceAuthorizationColumns+` FROM device_authorization WHERE device_code_hash = $1`,
+		deviceCodeHash,
+	))
+}
+
+func (p *PostgreSQLDevices) PollDeviceAuthorization(
+	ctx context.Context,
+	deviceCodeHash []byte,
+	at time.Time,
+	interval time.Duration,
+) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE device_authorization SET last_polled_at = $2, interval_seconds = $3 WHERE device_code_hash = $1`,
+		deviceCodeHash,
+		at,
+		int(interval/time.Second),
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLDevices) DecideDeviceAuthorization(
+	ctx context.Context,
+	userCode string,
+	customerID int,
+	status domain.DeviceAuthorizationStatus,
+) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE device_authorization SET customer_id = $2, status = $3 WHERE user_code = $1 AND status = $4`,
+		userCode,
+		customerID,
+		string(status),
+		string(domain.DeviceAuthorizationPending),
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLDevices) DeleteDeviceAuthorization(ctx context.Context, deviceCodeHash []byte) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM device_authorization WHERE device_code_hash = $1`, deviceCodeHash)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLDevices) DeleteExpiredDeviceAuthorizations(ctx context.Context, now time.Time) (int, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM device_authorization WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+func (p *PostgreSQLDevices) CreateDevice(ctx context.Context, d domain.Device) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO customer_device (`+deviceColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT DO NOTHING`,
+		d.ID,
+		d.CustomerID,
+		d.ClientID,
+		d.Name,
+		strings.Join(d.Scopes, " "),
+		d.RefreshTokenHash,
+		d.AccessTokenHash,
+		d.AccessExpires,
+		d.Created,
+		d.LastSeen,
+		d.Expires,
+	)
+	if err != nil {
+		return err
+	}
+
+	return insertedOne(res)
+}
+
+func (p *PostgreSQLDevices) DeviceByRefreshToken(ctx context.Context, refreshTokenHash []byte) (domain.Device, error) {
+	return scanDevice(p.db.QueryRowContext(
+		ctx,
+		`SELECT `+deviceColumns+` FROM customer_device WHERE refresh_token_hash = $1`,
+		refreshTokenHash,
+	))
+}
+
+func (p *PostgreSQLDevices) DeviceByAccessToken(ctx context.Context, accessTokenHash []byte) (domain.Device, error) {
+	return scanDevice(p.db.QueryRowContext(
+		ctx,
+		`SELECT `+deviceColumns+` FROM customer_device WHERE access_token_hash = $1`,
+		accessTokenHash,
+	))
+}
+
+func (p *PostgreSQLDevices) RotateDeviceTokens(ctx context.Context, oldRefreshTokenHash []byte, d domain.Device) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE customer_device
+		SET refresh_token_hash = $3, access_token_hash = $4, access_expires_at = $5, last_seen_at = $6, expires_at = $7
+		WHERE id = $1 AND refresh_token_hash = $2`,
+		d.ID,
+		oldRefreshTokenHash,
+		d.RefreshTokenHash,
+		d.AccessTokenHash,
+		d.AccessExpires,
+		d.LastSeen,
+		d.Expires,
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLDevices) CustomerDevices(ctx context.Context, customerID int, now time.Time) ([]domain.Device, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT `+deviceColumns+` FROM customer_device
+		WHERE customer_id = $1 AND expires_at > $2
+		ORDER BY last_seen_at DESC`,
+		customerID,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var devices []domain.Device
+	for rows.Next() {
+		d, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		devices = append(devices, d)
+	}
+
+	return devices, rows.Err()
+}
+
+func (p *PostgreSQLDevices) DeleteDevice(ctx context.Context, id string) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM customer_device WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLDevices) DeleteCustomerDevices(ctx context.Context, customerID int) (int, error) {
+	res, err := p.db.ExecContext(
+		ctx,
+		`WITH approved AS (
+			DELETE FROM device_authorization WHERE customer_id = $1 AND status = $2
+		)
+		DELETE FROM customer_device WHERE customer_id = $1`,
+		customerID,
+		string(domain.DeviceAuthorizationApproved),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+func (p *PostgreSQLDevices) DeleteExpiredDevices(ctx context.Context, now time.Time) (int, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM customer_device WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+func scanDeviceAuthorization(row scanner) (domain.DeviceAuthorization, error) {
+	var (
+		a          domain.DeviceAuthorization
+		scopes     string
+		interval   int
+		lastPolled sql.NullTime
+		status     string
+	)
+
+	err := row.Scan(
+		&a.DeviceCodeHash,
+		&a.UserCode,
+		&a.ClientID,
+		&a.DeviceName,
+		&scopes,
+		&a.Created,
+		&a.Expires,
+		&interval,
+		&lastPolled,
+		&status,
+		&a.CustomerID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.DeviceAuthorization{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.DeviceAuthorization{}, err
+	}
+
+	a.Scopes = strings.Fields(scopes)
+	a.Created = a.Created.UTC()
+	a.Expires = a.Expires.UTC()
+	a.Interval = time.Duration(interval) * time.Second
+	a.Status = domain.DeviceAuthorizationStatus(status)
+
+	if lastPolled.Valid {
+		a.LastPolled = lastPolled.Time.UTC()
+	}
+
+	return a, nil
+}
+
+func scanDevice(row scanner) (domain.Device, error) {
+	var (
+		d      domain.Device
+		scopes string
+	)
+
+	err := row.Scan(
+		&d.ID,
+		&d.CustomerID,
+		&d.ClientID,
+		&d.Name,
+		&scopes,
+		&d.RefreshTokenHash,
+		&d.AccessTokenHash,
+		&d.AccessExpires,
+		&d.Created,
+		&d.LastSeen,
+		&d.Expires,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Device{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.Device{}, err
+	}
+
+	d.Scopes = strings.Fields(scopes)
+	d.AccessExpires = d.AccessExpires.UTC()
+	d.Created = d.Created.UTC()
+	d.LastSeen = d.LastSeen.UTC()
+	d.Expires = d.Expires.UTC()
+
+	return d, nil
+}
+
+// insertedOne returns ports.ErrConflict when an insert ignoring conflicts
+// inserted no row.
+func insertedOne(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ports.ErrConflict
+	}
+
+	return nil
+}
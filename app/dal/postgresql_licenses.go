@@ -0,0 +1,315 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLLicenses is a ports.LicenseStore backed by the license table.
+// The schema is created by Migrate.
+type PostgreSQLLicenses struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLLicenses(db *sql.DB) *PostgreSQLLicenses {
+	return &PostgreSQLLicenses{
+		db: db,
+	}
+}
+
+const licenseColumns = `id, key, order_id, line, seat, customer_id, product_id, version, edition, issued_at,
+	expires_at, revoked_at, revoke_reason, upgraded_from`
+
+func (p *PostgreSQLLicenses) CreateLicense(ctx context.Context, l domain.License) (domain.License, error) {
+	return insertLicense(ctx, p.db, l)
+}
+
+func (p *PostgreSQLLicenses) CreateTrial(ctx context.Context, l domain.License, machine string) (domain.License, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.License{}, err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	l, err = insertLicense(ctx, tx, l)
+	if err != nil {
+		return domain.License{}, err
+	}
+
+	var id int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO license_trial (license_id, customer_id, product_id, machine)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+		RETURNING license_id`,
+		l.ID,
+		l.CustomerID,
+		l.ProductID,
+		machine,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.License{}, ports.ErrConflict
+	}
+
+	if err != nil {
+		return domain.License{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return domain.License{}, err
+	}
+
+	return l, nil
+}
+
+func (p *PostgreSQLLicenses) AbandonTrial(ctx context.Context, id int, at time.Time, reason string) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE license SET revoked_at = COALESCE(revoked_at, $2),
+			revoke_reason = CASE WHEN revoked_at IS NULL THEN $3 ELSE revoke_reason END
+		WHERE id = $1 AND edition = $4`,
+		id,
+		at,
+		reason,
+		domain.EditionTrial,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = affectedOne(res)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM license_trial WHERE license_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *PostgreSQLLicenses) ConvertTrial(ctx context.Context, l domain.License) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE license SET order_id = $2, edition = $3, issued_at = $4, expires_at = $5, version = $7,
+			upgraded_from = $8, line = $9, seat = $10
+		WHERE id = $1 AND edition = $6
+			AND NOT EXISTS (SELECT 1 FROM license l WHERE l.order_id = $2 AND l.line = $9 AND l.seat = $10)`,
+		l.ID,
+		l.OrderID,
+		l.Edition,
+		l.Issued,
+		nullTime(l.Expires),
+		domain.EditionTrial,
+		l.Version,
+		nullID(l.UpgradedFrom),
+		l.Line,
+		l.Seat,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = affectedOne(res)
+	if errors.Is(err, ports.ErrNotFound) {
+		// Either the trial does not exist, or the order has a license for
+		// the line and seat.
+		var exists bool
+
+		err = tx.QueryRowContext(
+			ctx,
+			`SELECT EXISTS (SELECT 1 FROM license WHERE id = $1 AND edition = $2)`,
+			l.ID,
+			domain.EditionTrial,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return ports.ErrConflict
+		}
+
+		return ports.ErrNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rowQuerier is implemented by both sql.DB and sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// insertLicense inserts l, outside or within a transaction.
+func insertLicense(ctx context.Context, q rowQuerier, l domain.License) (domain.License, error) {
+	err := q.QueryRowContext(
+		ctx,
+		`INSERT INTO license (key, order_id, line, seat, customer_id, product_id, version, edition, issued_at,
+			expires_at, revoked_at, revoke_reason, upgraded_from)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT DO NOTHING
+		RETURNING id`,
+		l.Key,
+		nullID(l.OrderID),
+		l.Line,
+		l.Seat,
+		l.CustomerID,
+		l.ProductID,
+		l.Version,
+		l.Edition,
+		l.Issued,
+		nullTime(l.Expires),
+		nullTime(l.Revoked),
+		l.RevokeReason,
+		nullID(l.UpgradedFrom),
+	).Scan(&l.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.License{}, ports.ErrConflict
+	}
+
+	if err != nil {
+		return domain.License{}, err
+	}
+
+	return l, nil
+}
+
+func (p *PostgreSQLLicenses) License(ctx context.Context, id int) (domain.License, error) {
+	return p.license(ctx, `SELECT `+licenseColumns+` FROM license WHERE id = $1`, id)
+}
+
+func (p *PostgreSQLLicenses) LicenseByKey(ctx context.Context, key string) (domain.License, error) {
+	return p.license(ctx, `SELECT `+licenseColumns+` FROM license WHERE key = $1`, key)
+}
+
+func (p *PostgreSQLLicenses) OrderLicenses(ctx context.Context, orderID int) ([]domain.License, error) {
+	return p.licenses(ctx, `SELECT `+licenseColumns+` FROM license WHERE order_id = $1 ORDER BY id`, orderID)
+}
+
+func (p *PostgreSQLLicenses) CustomerLicenses(ctx context.Context, customerID int) ([]domain.License, error) {
+	return p.licenses(ctx, `SELECT `+licenseColumns+` FROM license WHERE customer_id = $1 ORDER BY id`, customerID)
+}
+
+func (p *PostgreSQLLicenses) RevokeLicense(ctx context.Context, id int, at time.Time, reason string) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE license SET revoked_at = COALESCE(revoked_at, $2),
+			revoke_reason = CASE WHEN revoked_at IS NULL THEN $3 ELSE revoke_reason END
+		WHERE id = $1`,
+		id,
+		at,
+		reason,
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLLicenses) license(ctx context.Context, query string, args ...any) (domain.License, error) {
+	l, err := scanLicense(p.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.License{}, ports.ErrNotFound
+	}
+
+	return l, err
+}
+
+func (p *PostgreSQLLicenses) licenses(ctx context.Context, query string, args ...any) ([]domain.License, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	licenses := make([]domain.License, 0)
+
+	for rows.Next() {
+		l, err := scanLicense(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		licenses = append(licenses, l)
+	}
+
+	return licenses, rows.Err()
+}
+
+func scanLicense(row scanner) (domain.License, error) {
+	var (
+		l                     domain.License
+		orderID, upgradedFrom sql.NullInt64
+		expires, revoked      sql.NullTime
+	)
+
+	err := row.Scan(
+		&l.ID,
+		&l.Key,
+		&orderID,
+		&l.Line,
+		&l.Seat,
+		&l.CustomerID,
+		&l.ProductID,
+		&l.Version,
+		&l.Edition,
+		&l.Issued,
+		&expires,
+		&revoked,
+		&l.RevokeReason,
+		&upgradedFrom,
+	)
+	if err != nil {
+		return domain.License{}, err
+	}
+
+	l.OrderID = int(orderID.Int64)
+	l.UpgradedFrom = int(upgradedFrom.Int64)
+	l.Issued = l.Issued.UTC()
+
+	if expires.Valid {
+		l.Expires = expires.Time.UTC()
+	}
+
+	if revoked.Valid {
+		l.Revoked = revoked.Time.UTC()
+	}
+
+	return l, nil
+}
@@ -0,0 +1,393 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLOrders is a ports.OrderStore backed by the customer_order,
+// order_line and order_transition tables. The schema is created by
+// Migrate.
+type PostgreSQLOrders struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLOrders(db *sql.DB) *PostgreSQLOrders {
+	return &PostgreSQLOrders{
+		db: db,
+	}
+}
+
+const orderColumns = `id, customer_id, status, currency, total, refunded, checkout_key, checkout_id,
+	payment_id, created_at, updated_at`
+
+func (p *PostgreSQLOrders) CreateOrder(
+	ctx context.Context,
+	o domain.Order,
+	t domain.OrderTransition,
+) (domain.Order, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO customer_order (customer_id, status, currency, total, refunded, checkout_key, checkout_id,
+			payment_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (checkout_key) DO NOTHING
+		RETURNING id`,
+		o.CustomerID,
+		o.Status,
+		o.Total.Currency,
+		o.Total.Amount,
+		o.Refunded.Amount,
+		o.CheckoutKey,
+		o.CheckoutID,
+		o.PaymentID,
+		o.Created,
+		o.Updated,
+	).Scan(&o.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Order{}, ports.ErrConflict
+	}
+
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	for i, line := range o.Lines {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO order_line (order_id, position, product_id, product_type, name, version, unit_amount,
+				quantity, gift, upgrade_from)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			o.ID,
+			i,
+			line.ProductID,
+			int(line.ProductType),
+			line.Name,
+			line.Version,
+			line.UnitPrice.Amount,
+			line.Quantity,
+			line.Gift,
+			nullID(line.UpgradeFrom),
+		)
+		if err != nil {
+			return domain.Order{}, err
+		}
+	}
+
+	t.OrderID = o.ID
+
+	err = insertTransition(ctx, tx, t)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	return o, nil
+}
+
+func (p *PostgreSQLOrders) Order(ctx context.Context, id int) (domain.Order, error) {
+	return p.order(ctx, `SELECT `+orderColumns+` FROM customer_order WHERE id = $1`, id)
+}
+
+func (p *PostgreSQLOrders) OrderByCheckoutKey(ctx context.Context, key string) (domain.Order, error) {
+	return p.order(ctx, `SELECT `+orderColumns+` FROM customer_order WHERE checkout_key = $1`, key)
+}
+
+func (p *PostgreSQLOrders) OrderByCheckout(ctx context.Context, checkoutID string) (domain.Order, error) {
+	return p.order(
+		ctx,
+		`SELECT `+orderColumns+` FROM customer_order WHERE checkout_id = $1 AND checkout_id <> ''`,
+		checkoutID,
+	)
+}
+
+func (p *PostgreSQLOrders) SetOrderCheckout(ctx context.Context, id int, checkoutID string) error {
+	res, err := p.db.ExecContext(ctx, `UPDATE customer_order SET checkout_id = $2 WHERE id = $1`, id, checkoutID)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLOrders) OrderByPayment(ctx context.Context, paymentID string) (domain.Order, error) {
+	return p.order(
+		ctx,
+		`SELECT `+orderColumns+` FROM customer_order WHERE payment_id = $1 AND payment_id <> ''`,
+		paymentID,
+	)
+}
+
+func (p *PostgreSQLOrders) CustomerOrders(ctx context.Context, customerID int) ([]domain.Order, error) {
+	return p.orders(
+		ctx,
+		`SELECT `+orderColumns+` FROM customer_order WHERE customer_id = $1 ORDER BY created_at DESC, id DESC`,
+		customerID,
+	)
+}
+
+func (p *PostgreSQLOrders) Orders(
+	ctx context.Context,
+	status domain.OrderStatus,
+	limit int,
+) ([]domain.Order, error) {
+	return p.orders(
+		ctx,
+		`SELECT `+orderColumns+` FROM customer_order WHERE status = $1 ORDER BY created_at DESC, id DESC LIMIT $2`,
+		status,
+		limit,
+	)
+}
+
+func (p *PostgreSQLOrders) TransitionOrder(ctx context.Context, o domain.Order, t domain.OrderTransition) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE customer_order SET status = $3, payment_id = $4, refunded = $5, updated_at = $6
+		WHERE id = $1 AND status = $2`,
+		o.ID,
+		t.From,
+		o.Status,
+		o.PaymentID,
+		o.Refunded.Amount,
+		o.Updated,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = affectedOne(res)
+	if errors.Is(err, ports.ErrNotFound) {
+		// Either the order does not exist, or it moved meanwhile.
+		var exists bool
+
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM customer_order WHERE id = $1)`, o.ID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return ports.ErrConflict
+		}
+
+		return ports.ErrNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	t.OrderID = o.ID
+
+	err = insertTransition(ctx, tx, t)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *PostgreSQLOrders) OrderTransitions(ctx context.Context, id int) ([]domain.OrderTransition, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT order_id, from_status, to_status, actor, reason, created_at FROM order_transition
+		WHERE order_id = $1 ORDER BY id`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var transitions []domain.OrderTransition
+
+	for rows.Next() {
+		var t domain.OrderTransition
+
+		err = rows.Scan(&t.OrderID, &t.From, &t.To, &t.Actor, &t.Reason, &t.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		t.Time = t.Time.UTC()
+		transitions = append(transitions, t)
+	}
+
+	return transitions, rows.Err()
+}
+
+func insertTransition(ctx context.Context, tx *sql.Tx, t domain.OrderTransition) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO order_transition (order_id, from_status, to_status, actor, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		t.OrderID,
+		t.From,
+		t.To,
+		t.Actor,
+		t.Reason,
+		t.Time,
+	)
+
+	return err
+}
+
+// order returns the order selected by query, with its lines.
+func (p *PostgreSQLOrders) order(ctx context.Context, query string, args ...any) (domain.Order, error) {
+	o, err := scanOrder(p.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Order{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	orders := []domain.Order{o}
+
+	err = p.loadLines(ctx, orders)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	return orders[0], nil
+}
+
+// orders returns the orders selected by query, with their lines.
+func (p *PostgreSQLOrders) orders(ctx context.Context, query string, args ...any) ([]domain.Order, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var orders []domain.Order
+
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, o)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.loadLines(ctx, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// loadLines sets the lines of orders, with a single query.
+func (p *PostgreSQLOrders) loadLines(ctx context.Context, orders []domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(orders))
+	ids := make([]int64, 0, len(orders))
+
+	for i, o := range orders {
+		index[o.ID] = i
+		ids = append(ids, int64(o.ID))
+	}
+
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT order_id, product_id, product_type, name, version, unit_amount, quantity, gift, upgrade_from
+		FROM order_line WHERE order_id = ANY($1) ORDER BY order_id, position`,
+		ids,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id, productType int
+			upgradeFrom     sql.NullInt64
+			line            domain.OrderLine
+		)
+
+		err = rows.Scan(&id, &line.ProductID, &productType, &line.Name, &line.Version, &line.UnitPrice.Amount,
+			&line.Quantity, &line.Gift, &upgradeFrom)
+		if err != nil {
+			return err
+		}
+
+		i := index[id]
+		line.ProductType = domain.ProductType(productType)
+		line.UpgradeFrom = int(upgradeFrom.Int64)
+		line.UnitPrice.Currency = orders[i].Total.Currency
+		orders[i].Lines = append(orders[i].Lines, line)
+	}
+
+	return rows.Err()
+}
+
+func scanOrder(row scanner) (domain.Order, error) {
+	var (
+		o        domain.Order
+		currency string
+	)
+
+	err := row.Scan(
+		&o.ID,
+		&o.CustomerID,
+		&o.Status,
+		&currency,
+		&o.Total.Amount,
+		&o.Refunded.Amount,
+		&o.CheckoutKey,
+		&o.CheckoutID,
+		&o.PaymentID,
+		&o.Created,
+		&o.Updated,
+	)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	o.Total.Currency = domain.Currency(currency)
+	o.Refunded.Currency = o.Total.Currency
+	o.Created = o.Created.UTC()
+	o.Updated = o.Updated.UTC()
+
+	return o, nil
+}
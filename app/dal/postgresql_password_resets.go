@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLPasswordResets is a ports.PasswordResetStore backed by the
+// password_reset table. The schema is created by Migrate.
+type PostgreSQLPasswordResets struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLPasswordResets(db *sql.DB) *PostgreSQLPasswordResets {
+	return &PostgreSQLPasswordResets{
+		db: db,
+	}
+}
+
+func (p *PostgreSQLPasswordResets) SavePasswordReset(ctx context.Context, r domain.PasswordReset) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO password_reset (customer_id, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (customer_id) DO UPDATE
+		SET token_hash = EXCLUDED.token_hash, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at`,
+		r.CustomerID,
+		r.TokenHash,
+		r.Created,
+		r.Expires,
+	)
+
+	return err
+}
+
+func (p *PostgreSQLPasswordResets) PasswordReset(ctx context.Context, customerID int) (domain.PasswordReset, error) {
+	row := p.db.QueryRowContext(
+		ctx,
+		`SELECT customer_id, token_hash, created_at, expires_at FROM password_reset WHERE customer_id = $1`,
+		customerID,
+	)
+
+	return scanPasswordReset(row)
+}
+
+func (p *PostgreSQLPasswordResets) ConsumePasswordReset(
+	ctx context.Context,
+	tokenHash []byte,
+) (domain.PasswordReset, error) {
+	row := p.db.QueryRowContext(
+		ctx,
+		`DELETE FROM password_reset WHERE token_hash = $1
+		RETURNING customer_id, token_hash, created_at, expires_at`,
+		tokenHash,
+	)
+
+	return scanPasswordReset(row)
+}
+
+func scanPasswordReset(row scanner) (domain.PasswordReset, error) {
+	var r domain.PasswordReset
+
+	err := row.Scan(&r.CustomerID, &r.TokenHash, &r.Created, &r.Expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.PasswordReset{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.PasswordReset{}, err
+	}
+
+	return r, nil
+}
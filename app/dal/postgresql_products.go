@@ -0,0 +1,387 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLProducts is a ports.ProductRepository backed by the product
+// table. The schema is created by Migrate.
+type PostgreSQLProducts struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLProducts(db *sql.DB) *PostgreSQLProducts {
+	return &PostgreSQLProducts{
+		db: db,
+	}
+}
+
+const productColumns = `id, type, slug, name, description, version, machine_limit, media, published, position,
+	stripe_price_id, stripe_product_id, created_at, updated_at`
+
+// mediaRecord is the JSON form of a domain.Media in the media column.
+type mediaRecord struct {
+	Kind string `json:"kind"`
+	URL  string `json:"url"`
+	Alt  string `json:"alt"`
+}
+
+func (p *PostgreSQLProducts) CreateProduct(ctx context.Context, product domain.Product) (domain.Product, error) {
+	media, err := marshalMedia(product.Media)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO product (type, slug, name, description, version, machine_limit, media, published, position,
+			stripe_price_id, stripe_product_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (slug) DO NOTHING
+		RETURNING id`,
+		int(product.Type),
+		product.Slug,
+		product.Name,
+		product.Description,
+		product.Version,
+		product.MachineLimit,
+		media,
+		product.Published,
+		product.Position,
+		product.PriceID,
+		product.ProductID,
+		product.Created,
+		product.Updated,
+	).Scan(&product.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Product{}, ports.ErrConflict
+	}
+
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	err = insertPrices(ctx, tx, product.ID, product.Prices)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (p *PostgreSQLProducts) UpdateProduct(ctx context.Context, product domain.Product) error {
+	media, err := marshalMedia(product.Media)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE product SET type = $2, slug = $3, name = $4, description = $5, version = $6, machine_limit = $7,
+			media = $8, published = $9, position = $10, stripe_price_id = $11, stripe_product_id = $12,
+			updated_at = $13
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM product WHERE slug = $3 AND id <> $1)`,
+		product.ID,
+		int(product.Type),
+		product.Slug,
+		product.Name,
+		product.Description,
+		product.Version,
+		product.MachineLimit,
+		media,
+		product.Published,
+		product.Position,
+		product.PriceID,
+		product.ProductID,
+		product.Updated,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = affectedOne(res)
+	if errors.Is(err, ports.ErrNotFound) {
+		// Either the product does not exist, or another product has the
+		// slug.
+		var exists bool
+
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM product WHERE id = $1)`, product.ID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return ports.ErrConflict
+		}
+
+		return ports.ErrNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM product_price WHERE product_id = $1`, product.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertPrices(ctx, tx, product.ID, product.Prices)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *PostgreSQLProducts) DeleteProduct(ctx context.Context, id int) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM product WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLProducts) ProductByID(ctx context.Context, id int) (domain.Product, error) {
+	product, err := scanProduct(p.db.QueryRowContext(
+		ctx,
+		`SELECT `+productColumns+` FROM product WHERE id = $1`,
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Product{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	products := []domain.Product{product}
+
+	err = p.loadPrices(ctx, products)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return products[0], nil
+}
+
+func (p *PostgreSQLProducts) ProductBySlug(ctx context.Context, slug string) (domain.Product, error) {
+	product, err := scanProduct(p.db.QueryRowContext(
+		ctx,
+		`SELECT `+productColumns+` FROM product WHERE slug = $1`,
+		slug,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Product{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	products := []domain.Product{product}
+
+	err = p.loadPrices(ctx, products)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return products[0], nil
+}
+
+func (p *PostgreSQLProducts) Products(ctx context.Context, filter ports.ProductFilter) ([]domain.Product, error) {
+	types := make([]int32, 0, len(filter.Types))
+	for _, t := range filter.Types {
+		types = append(types, int32(t)) //nolint:gosec // product types are small
+	}
+
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT `+productColumns+` FROM product
+		WHERE (cardinality($1::INTEGER[]) = 0 OR type = ANY($1)) AND (published OR NOT $2)
+		ORDER BY position, id`,
+		types,
+		filter.PublishedOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var products []domain.Product
+
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, product)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.loadPrices(ctx, products)
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// loadPrices sets the prices of products, with a single query.
+func (p *PostgreSQLProducts) loadPrices(ctx context.Context, products []domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(products))
+	ids := make([]int64, 0, len(products))
+
+	for i, product := range products {
+		index[product.ID] = i
+		ids = append(ids, int64(product.ID))
+	}
+
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT product_id, currency, region, amount FROM product_price
+		WHERE product_id = ANY($1) ORDER BY currency, region`,
+		ids,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id               int
+			currency, region string
+			amount           int64
+		)
+
+		err = rows.Scan(&id, &currency, &region, &amount)
+		if err != nil {
+			return err
+		}
+
+		i := index[id]
+		products[i].Prices = append(products[i].Prices, domain.Price{
+			Region: domain.Region(region),
+			Money:  domain.Money{Amount: amount, Currency: domain.Currency(currency)},
+		})
+	}
+
+	return rows.Err()
+}
+
+func insertPrices(ctx context.Context, tx *sql.Tx, productID int, prices []domain.Price) error {
+	for _, price := range prices {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO product_price (product_id, currency, region, amount) VALUES ($1, $2, $3, $4)`,
+			productID,
+			string(price.Money.Currency),
+			string(price.Region),
+			price.Money.Amount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanProduct(row scanner) (domain.Product, error) {
+	var (
+		p     domain.Product
+		t     int
+		media []byte
+	)
+
+	err := row.Scan(
+		&p.ID,
+		&t,
+		&p.Slug,
+		&p.Name,
+		&p.Description,
+		&p.Version,
+		&p.MachineLimit,
+		&media,
+		&p.Published,
+		&p.Position,
+		&p.PriceID,
+		&p.ProductID,
+		&p.Created,
+		&p.Updated,
+	)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	p.Type = domain.ProductType(t)
+	p.Created = p.Created.UTC()
+	p.Updated = p.Updated.UTC()
+
+	var records []mediaRecord
+
+	err = json.Unmarshal(media, &records)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	for _, m := range records {
+		p.Media = append(p.Media, domain.Media{Kind: domain.MediaKind(m.Kind), URL: m.URL, Alt: m.Alt})
+	}
+
+	return p, nil
+}
+
+func marshalMedia(media []domain.Media) (string, error) {
+	records := make([]mediaRecord, 0, len(media))
+	for _, m := range media {
+		records = append(records, mediaRecord{Kind: string(m.Kind), URL: m.URL, Alt: m.Alt})
+	}
+
+	b, err := json.Marshal(records)
+
+	return string(b), err
+}
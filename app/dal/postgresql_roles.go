@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLRoles is a ports.RoleStore backed by the custom_role and
+// customer_role tables. The schema is created by Migrate.
+type PostgreSQLRoles struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLRoles(db *sql.DB) *PostgreSQLRoles {
+	return &PostgreSQLRoles{
+		db: db,
+	}
+}
+
+func (p *PostgreSQLRoles) SaveRole(ctx context.Context, r domain.RoleDefinition) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO custom_role (name, permissions, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET permissions = EXCLUDED.permissions`,
+		string(r.Name),
+		joinPermissions(r.Permissions),
+		r.Created,
+	)
+
+	return err
+}
+
+func (p *PostgreSQLRoles) Role(ctx context.Context, name domain.Role) (domain.RoleDefinition, error) {
+	r, err := scanRole(p.db.QueryRowContext(
+		ctx,
+		`SELECT name, permissions, created_at FROM custom_role WHERE name = $1`,
+		string(name),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.RoleDefinition{}, ports.ErrNotFound
+	}
+
+	return r, err
+}
+
+func (p *PostgreSQLRoles) Roles(ctx context.Context) ([]domain.RoleDefinition, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT name, permissions, created_at FROM custom_role ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var roles []domain.RoleDefinition
+
+	for rows.Next() {
+		r, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, r)
+	}
+
+	return roles, rows.Err()
+}
+
+func (p *PostgreSQLRoles) DeleteRole(ctx context.Context, name domain.Role) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM custom_role WHERE name = $1`, string(name))
+	if err != nil {
+		return err
+	}
+
+	err = affectedOne(res)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM customer_role WHERE role = $1`, string(name))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *PostgreSQLRoles) GrantRole(ctx context.Context, customerID int, role domain.Role, at time.Time) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO customer_role (customer_id, role, granted_at) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`,
+		customerID,
+		string(role),
+		at,
+	)
+	if err != nil {
+		return err
+	}
+
+	return insertedOne(res)
+}
+
+func (p *PostgreSQLRoles) RevokeRole(ctx context.Context, customerID int, role domain.Role) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`DELETE FROM customer_role WHERE customer_id = $1 AND role = $2`,
+		customerID,
+		string(role),
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLRoles) CustomerRoles(ctx context.Context, customerID int) ([]domain.Role, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT role FROM customer_role WHERE customer_id = $1 ORDER BY role`,
+		customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var roles []domain.Role
+
+	for rows.Next() {
+		var role string
+
+		err = rows.Scan(&role)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, domain.Role(role))
+	}
+
+	return roles, rows.Err()
+}
+
+func scanRole(row scanner) (domain.RoleDefinition, error) {
+	var (
+		r           domain.RoleDefinition
+		name        string
+		permissions string
+	)
+
+	err := row.Scan(&name, &permissions, &r.Created)
+	if err != nil {
+		return domain.RoleDefinition{}, err
+	}
+
+	r.Name = domain.Role(name)
+	r.Created = r.Created.UTC()
+
+	for _, p := range strings.Fields(permissions) {
+		r.Permissions = append(r.Permissions, domain.Permission(p))
+	}
+
+	return r, nil
+}
+
+func joinPermissions(permissions []domain.Permission) string {
+	s := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		s = append(s, string(p))
+	}
+
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,245 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLSessions is a ports.SessionStore backed by the customer_session
+// table. The schema is created by Migrate.
+type PostgreSQLSessions struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLSessions(db *sql.DB) *PostgreSQLSessions {
+	return &PostgreSQLSessions{
+		db: db,
+	}
+}
+
+const sessionColumns = `id, token_hash, customer_id, created_at, last_seen_at, expires_at, absolute_expires_at, user_agent, ip,
+	stepped_up_at`
+
+func (p *PostgreSQLSessions) CreateSession(ctx context.Context, s domain.Session) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO customer_session (`+sessionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT DO NOTHING`,
+		s.ID,
+		s.TokenHash,
+		s.CustomerID,
+		s.Created,
+		s.LastSeen,
+		s.Expires,
+		s.AbsoluteExpires,
+		s.UserAgent,
+		s.IP,
+		nullTime(s.SteppedUp),
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ports.ErrConflict
+	}
+
+	return nil
+}
+
+func (p *PostgreSQLSessions) SessionByTokenHash(ctx context.Context, tokenHash []byte) (domain.Session, error) {
+	row := p.db.QueryRowContext(
+		ctx,
+		`SELECT `+sessionColumns+` FROM customer_session WHERE token_hash = $1`,
+		tokenHash,
+	)
+
+	s, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Session{}, ports.ErrNotFound
+	}
+
+	return s, err
+}
+
+func (p *PostgreSQLSessions) TouchSession(ctx context.Context, id string, lastSeen, expires time.Time) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE customer_session SET last_seen_at = $2, expires_at = $3 WHERE id = $1`,
+		id,
+		lastSeen,
+		expires,
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLSessions) StepUpSession(ctx context.Context, id string, at time.Time) error {
+	res, err := p.db.ExecContext(ctx, `UPDATE customer_session SET stepped_up_at = $2 WHERE id = $1`, id, at)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLSessions) CustomerSessions(
+	ctx context.Context,
+	customerID int,
+	now time.Time,
+) ([]domain.Session, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT `+sessionColumns+` FROM customer_session
+		WHERE customer_id = $1 AND expires_at > $2 AND absolute_expires_at > $2
+		ORDER BY last_seen_at DESC`,
+		customerID,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var sessions []domain.Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (p *PostgreSQLSessions) DeleteSession(ctx context.Context, id string) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM customer_session WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLSessions) DeleteCustomerSessions(
+	ctx context.Context,
+	customerID int,
+	except ...string,
+) (int, error) {
+	if except == nil {
+		except = []string{}
+	}
+
+	res, err := p.db.ExecContext(
+		ctx,
+		`DELETE FROM customer_session WHERE customer_id = $1 AND NOT (id = ANY($2))`,
+		customerID,
+		except,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+func (p *PostgreSQLSessions) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	res, err := p.db.ExecContext(
+		ctx,
+		`DELETE FROM customer_session WHERE expires_at <= $1 OR absolute_expires_at <= $1`,
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+// scanner is implemented by both sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row scanner) (domain.Session, error) {
+	var (
+		s         domain.Session
+		steppedUp sql.NullTime
+	)
+
+	err := row.Scan(
+		&s.ID,
+		&s.TokenHash,
+		&s.CustomerID,
+		&s.Created,
+		&s.LastSeen,
+		&s.Expires,
+		&s.AbsoluteExpires,
+		&s.UserAgent,
+		&s.IP,
+		&steppedUp,
+	)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	s.Created = s.Created.UTC()
+	s.LastSeen = s.LastSeen.UTC()
+	s.Expires = s.Expires.UTC()
+	s.AbsoluteExpires = s.AbsoluteExpires.UTC()
+
+	if steppedUp.Valid {
+		s.SteppedUp = steppedUp.Time.UTC()
+	}
+
+	return s, nil
+}
+
+// affectedOne returns ports.ErrNotFound when res affected no row.
+func affectedOne(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ports.ErrNotFound
+	}
+
+	return nil
+}
+
+// nullTime maps the zero time to NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// nullID maps the zero ID to NULL.
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
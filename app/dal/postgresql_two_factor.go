@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLTwoFactor is a ports.TwoFactorStore backed by the customer_totp
+// and recovery_code tables. The schema is created by Migrate.
+type PostgreSQLTwoFactor struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLTwoFactor(db *sql.DB) *PostgreSQLTwoFactor {
+	return &PostgreSQLTwoFactor{
+		db: db,
+	}
+}
+
+func (p *PostgreSQLTwoFactor) SaveTOTP(ctx context.Context, t domain.TOTP) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO customer_totp (customer_id, sealed_secret, confirmed, last_counter, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (customer_id) DO UPDATE
+		SET sealed_secret = EXCLUDED.sealed_secret, confirmed = EXCLUDED.confirmed,
+			last_counter = EXCLUDED.last_counter, created_at = EXCLUDED.created_at`,
+		t.CustomerID,
+		t.SealedSecret,
+		t.Confirmed,
+		t.LastCounter,
+		t.Created,
+	)
+
+	return err
+}
+
+func (p *PostgreSQLTwoFactor) TOTP(ctx context.Context, customerID int) (domain.TOTP, error) {
+	var t domain.TOTP
+
+	err := p.db.QueryRowContext(
+		ctx,
+		`SELECT customer_id, sealed_secret, confirmed, last_counter, created_at
+		FROM customer_totp WHERE customer_id = $1`,
+		customerID,
+	).Scan(&t.CustomerID, &t.SealedSecret, &t.Confirmed, &t.LastCounter, &t.Created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.TOTP{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.TOTP{}, err
+	}
+
+	t.Created = t.Created.UTC()
+
+	return t, nil
+}
+
+func (p *PostgreSQLTwoFactor) AdvanceTOTPCounter(ctx context.Context, customerID int, counter int64) error {
+	// The comparison happens in the update, so that two requests racing with
+	// the same code cannot both succeed.
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE customer_totp SET last_counter = $2 WHERE customer_id = $1 AND last_counter < $2`,
+		customerID,
+		counter,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = affectedOne(res)
+	if !errors.Is(err, ports.ErrNotFound) {
+		return err
+	}
+
+	_, err = p.TOTP(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	return ports.ErrConflict
+}
+
+func (p *PostgreSQLTwoFactor) DeleteTOTP(ctx context.Context, customerID int) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM customer_totp WHERE customer_id = $1`, customerID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM recovery_code WHERE customer_id = $1`, customerID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *PostgreSQLTwoFactor) ReplaceRecoveryCodes(ctx context.Context, customerID int, hashes [][]byte) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM recovery_code WHERE customer_id = $1`, customerID)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range hashes {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO recovery_code (customer_id, code_hash) VALUES ($1, $2)`,
+			customerID,
+			h,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (p *PostgreSQLTwoFactor) ConsumeRecoveryCode(ctx context.Context, customerID int, hash []byte) (int, error) {
+	res, err := p.db.ExecContext(
+		ctx,
+		`DELETE FROM recovery_code WHERE customer_id = $1 AND code_hash = $2`,
+		customerID,
+		hash,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	err = affectedOne(res)
+	if err != nil {
+		return 0, err
+	}
+
+	var left int
+
+	err = p.db.QueryRowContext(
+		ctx,
+		`SELECT count(*) FROM recovery_code WHERE customer_id = $1`,
+		customerID,
+	).Scan(&left)
+
+	return left, err
+}
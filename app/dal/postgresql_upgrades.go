@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// PostgreSQLUpgradeRules is a ports.UpgradeRuleStore backed by the
+// upgrade_rule and upgrade_rule_price tables. The schema is created by
+// Migrate.
+type PostgreSQLUpgradeRules struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLUpgradeRules(db *sql.DB) *PostgreSQLUpgradeRules {
+	return &PostgreSQLUpgradeRules{
+		db: db,
+	}
+}
+
+func (p *PostgreSQLUpgradeRules) CreateUpgradeRule(
+	ctx context.Context,
+	r domain.UpgradeRule,
+) (domain.UpgradeRule, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.UpgradeRule{}, err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO upgrade_rule (from_product_id, from_version, to_product_id, percent_off, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		r.FromProductID,
+		r.FromVersion,
+		r.ToProductID,
+		r.PercentOff,
+		r.Created,
+	).Scan(&r.ID)
+	if err != nil {
+		return domain.UpgradeRule{}, err
+	}
+
+	for _, price := range r.Prices {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO upgrade_rule_price (rule_id, currency, region, amount) VALUES ($1, $2, $3, $4)`,
+			r.ID,
+			string(price.Money.Currency),
+			string(price.Region),
+			price.Money.Amount,
+		)
+		if err != nil {
+			return domain.UpgradeRule{}, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return domain.UpgradeRule{}, err
+	}
+
+	return r, nil
+}
+
+func (p *PostgreSQLUpgradeRules) UpgradeRules(ctx context.Context) ([]domain.UpgradeRule, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT id, from_product_id, from_version, to_product_id, percent_off, created_at FROM upgrade_rule
+		ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rules := make([]domain.UpgradeRule, 0)
+	index := make(map[int]int)
+
+	for rows.Next() {
+		var r domain.UpgradeRule
+
+		err = rows.Scan(&r.ID, &r.FromProductID, &r.FromVersion, &r.ToProductID, &r.PercentOff, &r.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Created = r.Created.UTC()
+		index[r.ID] = len(rules)
+		rules = append(rules, r)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, p.loadPrices(ctx, rules, index)
+}
+
+// loadPrices sets the prices of rules, at their index by ID, with a single
+// query.
+func (p *PostgreSQLUpgradeRules) loadPrices(ctx context.Context, rules []domain.UpgradeRule, index map[int]int) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT rule_id, currency, region, amount FROM upgrade_rule_price ORDER BY currency, region`,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id               int
+			currency, region string
+			amount           int64
+		)
+
+		err = rows.Scan(&id, &currency, &region, &amount)
+		if err != nil {
+			return err
+		}
+
+		i, ok := index[id]
+		if !ok {
+			// The rule was created after the rules were read.
+			continue
+		}
+
+		rules[i].Prices = append(rules[i].Prices, domain.Price{
+			Region: domain.Region(region),
+			Money:  domain.Money{Amount: amount, Currency: domain.Currency(currency)},
+		})
+	}
+
+	return rows.Err()
+}
+
+func (p *PostgreSQLUpgradeRules) DeleteUpgradeRule(ctx context.Context, id int) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM upgrade_rule WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
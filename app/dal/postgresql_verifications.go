@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLVerifications is a ports.VerificationStore backed by the
+// email_verification table. The schema is created by Migrate.
+type PostgreSQLVerifications struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLVerifications(db *sql.DB) *PostgreSQLVerifications {
+	return &PostgreSQLVerifications{
+		db: db,
+	}
+}
+
+func (p *PostgreSQLVerifications) SaveVerification(ctx context.Context, v domain.Verification) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO email_verification (customer_id, nonce_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (customer_id) DO UPDATE
+		SET nonce_hash = EXCLUDED.nonce_hash, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at`,
+		v.CustomerID,
+		v.NonceHash,
+		v.Created,
+		v.Expires,
+	)
+
+	return err
+}
+
+func (p *PostgreSQLVerifications) Verification(ctx context.Context, customerID int) (domain.Verification, error) {
+	row := p.db.QueryRowContext(
+		ctx,
+		`SELECT customer_id, nonce_hash, created_at, expires_at FROM email_verification WHERE customer_id = $1`,
+		customerID,
+	)
+
+	return scanVerification(row)
+}
+
+func (p *PostgreSQLVerifications) ConsumeVerification(
+	ctx context.Context,
+	customerID int,
+	nonceHash []byte,
+) (domain.Verification, error) {
+	row := p.db.QueryRowContext(
+		ctx,
+		`DELETE FROM email_verification WHERE customer_id = $1 AND nonce_hash = $2
+		RETURNING customer_id, nonce_hash, created_at, expires_at`,
+		customerID,
+		nonceHash,
+	)
+
+	return scanVerification(row)
+}
+
+func scanVerification(row scanner) (domain.Verification, error) {
+	var v domain.Verification
+
+	err := row.Scan(&v.CustomerID, &v.NonceHash, &v.Created, &v.Expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Verification{}, ports.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.Verification{}, err
+	}
+
+	return v, nil
+}
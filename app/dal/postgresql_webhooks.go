@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PostgreSQLWebhookInbox is a ports.WebhookInbox backed by the
+// webhook_event table. The schema is created by Migrate.
+type PostgreSQLWebhookInbox struct {
+	db *sql.DB
+}
+
+func NewPostgreSQLWebhookInbox(db *sql.DB) *PostgreSQLWebhookInbox {
+	return &PostgreSQLWebhookInbox{
+		db: db,
+	}
+}
+
+const webhookEventColumns = `id, type, payload, received_at, status, attempts, last_error, next_attempt_at, processed_at`
+
+func (p *PostgreSQLWebhookInbox) SaveWebhookEvent(ctx context.Context, e domain.WebhookEvent) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO webhook_event (`+webhookEventColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT DO NOTHING`,
+		e.ID,
+		e.Type,
+		e.Payload,
+		e.Received,
+		e.Status,
+		e.Attempts,
+		e.LastError,
+		e.NextAttempt,
+		nullTime(e.Processed),
+	)
+	if err != nil {
+		return err
+	}
+
+	return insertedOne(res)
+}
+
+// ClaimWebhookEvents skips the events locked by other dispatchers claiming
+// at the same time, rather than waiting for them.
+func (p *PostgreSQLWebhookInbox) ClaimWebhookEvents(
+	ctx context.Context,
+	now, until time.Time,
+	limit int,
+) ([]domain.WebhookEvent, error) {
+	events, err := p.events(
+		ctx,
+		`UPDATE webhook_event SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_event WHERE status = $3 AND next_attempt_at <= $1
+			ORDER BY received_at, id LIMIT $4 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+webhookEventColumns,
+		now,
+		until,
+		domain.WebhookPending,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(events, func(a, b domain.WebhookEvent) int {
+		return cmp.Or(a.Received.Compare(b.Received), cmp.Compare(a.ID, b.ID))
+	})
+
+	return events, nil
+}
+
+func (p *PostgreSQLWebhookInbox) UpdateWebhookEvent(ctx context.Context, e domain.WebhookEvent) error {
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE webhook_event SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, processed_at = $6
+		WHERE id = $1`,
+		e.ID,
+		e.Status,
+		e.Attempts,
+		e.LastError,
+		e.NextAttempt,
+		nullTime(e.Processed),
+	)
+	if err != nil {
+		return err
+	}
+
+	return affectedOne(res)
+}
+
+func (p *PostgreSQLWebhookInbox) WebhookEvent(ctx context.Context, id string) (domain.WebhookEvent, error) {
+	e, err := scanWebhookEvent(p.db.QueryRowContext(
+		ctx,
+		`SELECT `+webhookEventColumns+` FROM webhook_event WHERE id = $1`,
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.WebhookEvent{}, ports.ErrNotFound
+	}
+
+	return e, err
+}
+
+func (p *PostgreSQLWebhookInbox) WebhookEvents(
+	ctx context.Context,
+	status domain.WebhookStatus,
+	limit int,
+) ([]domain.WebhookEvent, error) {
+	return p.events(
+		ctx,
+		`SELECT `+webhookEventColumns+` FROM webhook_event WHERE status = $1
+		ORDER BY received_at DESC, id DESC LIMIT $2`,
+		status,
+		limit,
+	)
+}
+
+func (p *PostgreSQLWebhookInbox) DeleteProcessedWebhookEvents(ctx context.Context, before time.Time) (int, error) {
+	res, err := p.db.ExecContext(
+		ctx,
+		`DELETE FROM webhook_event WHERE status = $1 AND processed_at < $2`,
+		domain.WebhookProcessed,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+func (p *PostgreSQLWebhookInbox) events(ctx context.Context, query string, args ...any) ([]domain.WebhookEvent, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events := make([]domain.WebhookEvent, 0)
+
+	for rows.Next() {
+		e, err := scanWebhookEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+func scanWebhookEvent(row scanner) (domain.WebhookEvent, error) {
+	var (
+		e         domain.WebhookEvent
+		processed sql.NullTime
+	)
+
+	err := row.Scan(
+		&e.ID,
+		&e.Type,
+		&e.Payload,
+		&e.Received,
+		&e.Status,
+		&e.Attempts,
+		&e.LastError,
+		&e.NextAttempt,
+		&processed,
+	)
+	if err != nil {
+		return domain.WebhookEvent{}, err
+	}
+
+	e.Received = e.Received.UTC()
+	e.NextAttempt = e.NextAttempt.UTC()
+
+	if processed.Valid {
+		e.Processed = processed.Time.UTC()
+	}
+
+	return e, nil
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryProducts(t *testing.T) {
+	testProductRepository(t, dal.NewMemoryProducts())
+}
+
+func TestPostgreSQLProducts(t *testing.T) {
+	testProductRepository(t, dal.NewPostgreSQLProducts(newTestDB(t)))
+}
+
+func testProductRepository(t *testing.T, repo ports.ProductRepository) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newProduct := func(slug string, typ domain.ProductType, position int, published bool) domain.Product {
+		return domain.Product{
+			ID:           0,
+			Type:         typ,
+			Slug:         slug,
+			Name:         slug,
+			Description:  "A product.",
+			Version:      "",
+			MachineLimit: 3,
+			Media: []domain.Media{
+				{Kind: domain.MediaImage, URL: "https://cdn.brokedaear.com/" + slug + ".png", Alt: slug},
+			},
+			Published: published,
+			Position:  position,
+			Prices: []domain.Price{
+				{Region: domain.RegionDefault, Money: domain.Money{Amount: 4900, Currency: domain.CurrencyUSD}},
+				{Region: "DE", Money: domain.Money{Amount: 4500, Currency: domain.CurrencyEUR}},
+			},
+			PriceID:   "price_" + slug,
+			ProductID: "prod_" + slug,
+			Created:   now,
+			Updated:   now,
+		}
+	}
+
+	microwave, err := repo.CreateProduct(ctx, newProduct("microwave", domain.ProductPlugin, 1, true))
+	assert.NoError(t, err)
+	assert.True(t, microwave.ID > 0)
+
+	_, err = repo.CreateProduct(ctx, newProduct("microwave", domain.ProductPlugin, 2, true))
+	assert.Error(t, err, ports.ErrConflict)
+
+	shirt, err := repo.CreateProduct(ctx, newProduct("shirt", domain.ProductMerchandise, 0, true))
+	assert.NoError(t, err)
+
+	draft, err := repo.CreateProduct(ctx, newProduct("draft", domain.ProductPlugin, 0, false))
+	assert.NoError(t, err)
+
+	got, err := repo.ProductBySlug(ctx, "microwave")
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, microwave.ID)
+	assert.Equal(t, got.Type, domain.ProductPlugin)
+	assert.Equal(t, got.PriceID, "price_microwave")
+	assert.Equal(t, got.MachineLimit, 3)
+	assert.Equal(t, len(got.Media), 1)
+	assert.Equal(t, got.Media[0], microwave.Media[0])
+	assert.Equal(t, len(got.Prices), 2)
+
+	price, ok := got.Price(domain.CurrencyEUR, "DE")
+	assert.True(t, ok)
+	assert.Equal(t, price.Amount, 4500)
+	assert.True(t, got.Created.Equal(now))
+
+	_, err = repo.ProductBySlug(ctx, "oven")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	_, err = repo.ProductByID(ctx, 1000)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	products, err := repo.Products(ctx, ports.ProductFilter{Types: nil, PublishedOnly: false})
+	assert.NoError(t, err)
+	assert.Equal(t, len(products), 3)
+
+	// Products at the same position are ordered by ID.
+	assert.Equal(t, products[0].ID, shirt.ID)
+	assert.Equal(t, products[1].ID, draft.ID)
+	assert.Equal(t, products[2].ID, microwave.ID)
+
+	products, err = repo.Products(ctx, ports.ProductFilter{Types: []domain.ProductType{domain.ProductPlugin}, PublishedOnly: true})
+	assert.NoError(t, err)
+	assert.Equal(t, len(products), 1)
+	assert.Equal(t, products[0].ID, microwave.ID)
+
+	// Renaming a product frees its slug, and cannot take the slug of
+	// another product.
+	draft.Slug = "oven"
+	draft.Published = true
+	draft.Prices = draft.Prices[:1]
+	draft.MachineLimit = 5
+	assert.NoError(t, repo.UpdateProduct(ctx, draft))
+
+	_, err = repo.ProductBySlug(ctx, "draft")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	got, err = repo.ProductBySlug(ctx, "oven")
+	assert.NoError(t, err)
+	assert.True(t, got.Published)
+	assert.Equal(t, got.MachineLimit, 5)
+	assert.Equal(t, len(got.Prices), 1)
+
+	draft.Slug = "microwave"
+	assert.Error(t, repo.UpdateProduct(ctx, draft), ports.ErrConflict)
+
+	missing := newProduct("missing", domain.ProductPlugin, 0, false)
+	missing.ID = 1000
+	assert.Error(t, repo.UpdateProduct(ctx, missing), ports.ErrNotFound)
+
+	assert.NoError(t, repo.DeleteProduct(ctx, shirt.ID))
+	assert.Error(t, repo.DeleteProduct(ctx, shirt.ID), ports.ErrNotFound)
+
+	_, err = repo.ProductBySlug(ctx, "shirt")
+	assert.Error(t, err, ports.ErrNotFound)
+}
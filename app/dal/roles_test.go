@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryRoles(t *testing.T) {
+	testRoleStore(t, dal.NewMemoryRoles())
+}
+
+func TestPostgreSQLRoles(t *testing.T) {
+	testRoleStore(t, dal.NewPostgreSQLRoles(newTestDB(t)))
+}
+
+func testRoleStore(t *testing.T, store ports.RoleStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := store.Role(ctx, "refunds")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	err = store.SaveRole(ctx, domain.RoleDefinition{
+		Name:        "refunds",
+		Permissions: []domain.Permission{domain.PermissionAccessAdmin, domain.PermissionRefundOrders},
+		Created:     now,
+	})
+	assert.NoError(t, err)
+
+	err = store.SaveRole(ctx, domain.RoleDefinition{
+		Name:        "catalog",
+		Permissions: []domain.Permission{domain.PermissionManageProducts},
+		Created:     now,
+	})
+	assert.NoError(t, err)
+
+	// Saving again replaces the permissions.
+	err = store.SaveRole(ctx, domain.RoleDefinition{
+		Name:        "refunds",
+		Permissions: []domain.Permission{domain.PermissionRefundOrders},
+		Created:     now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+
+	r, err := store.Role(ctx, "refunds")
+	assert.NoError(t, err)
+	assert.Equal(t, len(r.Permissions), 1)
+	assert.Equal(t, r.Permissions[0], domain.PermissionRefundOrders)
+
+	roles, err := store.Roles(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(roles), 2)
+	assert.Equal(t, roles[0].Name, domain.Role("catalog"))
+
+	err = store.GrantRole(ctx, 1, domain.RoleAdmin, now)
+	assert.NoError(t, err)
+
+	err = store.GrantRole(ctx, 1, "refunds", now)
+	assert.NoError(t, err)
+
+	err = store.GrantRole(ctx, 1, domain.RoleAdmin, now)
+	assert.Error(t, err, ports.ErrConflict)
+
+	granted, err := store.CustomerRoles(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(granted), 2)
+	assert.Equal(t, granted[0], domain.RoleAdmin)
+
+	granted, err = store.CustomerRoles(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, len(granted), 0)
+
+	// Deleting a custom role revokes it.
+	err = store.DeleteRole(ctx, "refunds")
+	assert.NoError(t, err)
+
+	err = store.DeleteRole(ctx, "refunds")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	granted, err = store.CustomerRoles(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(granted), 1)
+
+	err = store.RevokeRole(ctx, 1, domain.RoleAdmin)
+	assert.NoError(t, err)
+
+	err = store.RevokeRole(ctx, 1, domain.RoleAdmin)
+	assert.Error(t, err, ports.ErrNotFound)
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// testDatabaseURLEnv names the environment variable holding the connection
+// string of a disposable PostgreSQL database. PostgreSQL tests are skipped
+// without it.
+const testDatabaseURLEnv = "DAL_TEST_DATABASE_URL"
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(testDatabaseURLEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	assert.NoError(t, err)
+
+	// Every test starts from an empty schema and leaves one behind, so
+	// that new migrations need no cleanup of their own.
+	reset := func() error {
+		_, err := db.Exec(`DROP SCHEMA public CASCADE; CREATE SCHEMA public`)
+		return err
+	}
+
+	t.Cleanup(func() {
+		_ = reset()
+		_ = db.Close()
+	})
+
+	assert.NoError(t, reset())
+
+	err = dal.Migrate(t.Context(), db)
+	assert.NoError(t, err)
+
+	// Migrating twice is a no-op.
+	err = dal.Migrate(t.Context(), db)
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestMemorySessions(t *testing.T) {
+	testSessionStore(t, dal.NewMemorySessions())
+}
+
+func TestPostgreSQLSessions(t *testing.T) {
+	testSessionStore(t, dal.NewPostgreSQLSessions(newTestDB(t)))
+}
+
+func newSession(id string, customerID int, lastSeen time.Time) domain.Session {
+	return domain.Session{
+		ID:              id,
+		TokenHash:       []byte("hash-" + id),
+		CustomerID:      customerID,
+		Created:         lastSeen,
+		LastSeen:        lastSeen,
+		Expires:         lastSeen.Add(time.Hour),
+		AbsoluteExpires: lastSeen.Add(2 * time.Hour),
+		UserAgent:       "test",
+		IP:              "127.0.0.1",
+		SteppedUp:       time.Time{},
+	}
+}
+
+// testSessionStore checks the behavior every ports.SessionStore must have.
+func testSessionStore(t *testing.T, store ports.SessionStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	a := newSession("a", 1, now)
+	b := newSession("b", 1, now.Add(time.Minute))
+	c := newSession("c", 2, now)
+	expired := newSession("expired", 1, now.Add(-3*time.Hour))
+
+	for _, s := range []domain.Session{a, b, c, expired} {
+		err := store.CreateSession(ctx, s)
+		assert.NoError(t, err)
+	}
+
+	err := store.CreateSession(ctx, a)
+	assert.Error(t, err, ports.ErrConflict)
+
+	got, err := store.SessionByTokenHash(ctx, a.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, "a")
+	assert.Equal(t, got.CustomerID, 1)
+	assert.True(t, got.Expires.Equal(a.Expires))
+
+	_, err = store.SessionByTokenHash(ctx, []byte("unknown"))
+	assert.Error(t, err, ports.ErrNotFound)
+
+	err = store.TouchSession(ctx, "a", now.Add(2*time.Minute), now.Add(90*time.Minute))
+	assert.NoError(t, err)
+
+	err = store.TouchSession(ctx, "unknown", now, now)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	assert.True(t, got.SteppedUp.IsZero())
+
+	err = store.StepUpSession(ctx, "a", now.Add(time.Minute))
+	assert.NoError(t, err)
+
+	got, err = store.SessionByTokenHash(ctx, a.TokenHash)
+	assert.NoError(t, err)
+	assert.True(t, got.SteppedUp.Equal(now.Add(time.Minute)))
+
+	err = store.StepUpSession(ctx, "unknown", now)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	list, err := store.CustomerSessions(ctx, 1, now)
+	assert.NoError(t, err)
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[0].ID, "a")
+	assert.Equal(t, list[1].ID, "b")
+
+	n, err := store.DeleteExpiredSessions(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+
+	n, err = store.DeleteCustomerSessions(ctx, 1, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+
+	err = store.DeleteSession(ctx, "a")
+	assert.NoError(t, err)
+
+	err = store.DeleteSession(ctx, "a")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	list, err = store.CustomerSessions(ctx, 1, now)
+	assert.NoError(t, err)
+	assert.Equal(t, len(list), 0)
+
+	n, err = store.DeleteCustomerSessions(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+}
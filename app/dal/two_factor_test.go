@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryTwoFactor(t *testing.T) {
+	testTwoFactorStore(t, dal.NewMemoryTwoFactor())
+}
+
+func TestPostgreSQLTwoFactor(t *testing.T) {
+	testTwoFactorStore(t, dal.NewPostgreSQLTwoFactor(newTestDB(t)))
+}
+
+func testTwoFactorStore(t *testing.T, store ports.TwoFactorStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := store.TOTP(ctx, 1)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	err = store.AdvanceTOTPCounter(ctx, 1, 10)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	err = store.SaveTOTP(ctx, domain.TOTP{
+		CustomerID:   1,
+		SealedSecret: []byte("sealed"),
+		Confirmed:    true,
+		LastCounter:  0,
+		Created:      now,
+	})
+	assert.NoError(t, err)
+
+	totp, err := store.TOTP(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, string(totp.SealedSecret), "sealed")
+	assert.True(t, totp.Confirmed)
+	assert.True(t, totp.Created.Equal(now))
+
+	err = store.AdvanceTOTPCounter(ctx, 1, 10)
+	assert.NoError(t, err)
+
+	// Codes cannot be replayed, nor older ones used.
+	err = store.AdvanceTOTPCounter(ctx, 1, 10)
+	assert.Error(t, err, ports.ErrConflict)
+
+	err = store.AdvanceTOTPCounter(ctx, 1, 9)
+	assert.Error(t, err, ports.ErrConflict)
+
+	err = store.ReplaceRecoveryCodes(ctx, 1, [][]byte{[]byte("a"), []byte("b")})
+	assert.NoError(t, err)
+
+	left, err := store.ConsumeRecoveryCode(ctx, 1, []byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, left, 1)
+
+	_, err = store.ConsumeRecoveryCode(ctx, 1, []byte("a"))
+	assert.Error(t, err, ports.ErrNotFound)
+
+	_, err = store.ConsumeRecoveryCode(ctx, 2, []byte("b"))
+	assert.Error(t, err, ports.ErrNotFound)
+
+	err = store.ReplaceRecoveryCodes(ctx, 1, [][]byte{[]byte("c")})
+	assert.NoError(t, err)
+
+	_, err = store.ConsumeRecoveryCode(ctx, 1, []byte("b"))
+	assert.Error(t, err, ports.ErrNotFound)
+
+	err = store.DeleteTOTP(ctx, 1)
+	assert.NoError(t, err)
+
+	_, err = store.TOTP(ctx, 1)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	_, err = store.ConsumeRecoveryCode(ctx, 1, []byte("c"))
+	assert.Error(t, err, ports.ErrNotFound)
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryUpgradeRules(t *testing.T) {
+	testUpgradeRuleStore(t, dal.NewMemoryUpgradeRules(), dal.NewMemoryProducts())
+}
+
+func TestPostgreSQLUpgradeRules(t *testing.T) {
+	db := newTestDB(t)
+	testUpgradeRuleStore(t, dal.NewPostgreSQLUpgradeRules(db), dal.NewPostgreSQLProducts(db))
+}
+
+// testUpgradeRuleStore checks the behavior every ports.UpgradeRuleStore
+// must have. Rules are between products created in products.
+func testUpgradeRuleStore(t *testing.T, store ports.UpgradeRuleStore, products ports.ProductRepository) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var ids []int
+
+	for _, slug := range []string{"microwave", "oven"} {
+		p, err := products.CreateProduct(ctx, domain.Product{
+			ID:           0,
+			Type:         domain.ProductPlugin,
+			Slug:         slug,
+			Name:         slug,
+			Description:  "",
+			Version:      "2.0.0",
+			MachineLimit: 0,
+			Media:        nil,
+			Published:    true,
+			Position:     0,
+			Prices:       nil,
+			PriceID:      "",
+			ProductID:    "",
+			Created:      now,
+			Updated:      now,
+		})
+		assert.NoError(t, err)
+
+		ids = append(ids, p.ID)
+	}
+
+	rules, err := store.UpgradeRules(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(rules), 0)
+
+	upgrade, err := store.CreateUpgradeRule(ctx, domain.UpgradeRule{
+		ID:            0,
+		FromProductID: ids[0],
+		FromVersion:   "1.",
+		ToProductID:   ids[0],
+		Prices: []domain.Price{
+			{Region: domain.RegionDefault, Money: domain.Money{Amount: 2900, Currency: domain.CurrencyUSD}},
+			{Region: "DE", Money: domain.Money{Amount: 2500, Currency: domain.CurrencyEUR}},
+		},
+		PercentOff: 0,
+		Created:    now,
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, upgrade.ID, 0)
+
+	crossgrade, err := store.CreateUpgradeRule(ctx, domain.UpgradeRule{
+		ID:            0,
+		FromProductID: ids[0],
+		FromVersion:   "",
+		ToProductID:   ids[1],
+		Prices:        nil,
+		PercentOff:    30,
+		Created:       now,
+	})
+	assert.NoError(t, err)
+
+	rules, err = store.UpgradeRules(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(rules), 2)
+	assert.Equal(t, rules[0].ID, upgrade.ID)
+	assert.Equal(t, rules[0].FromVersion, "1.")
+	assert.Equal(t, len(rules[0].Prices), 2)
+	assert.True(t, rules[0].Created.Equal(now))
+	assert.Equal(t, rules[1].PercentOff, 30)
+	assert.Equal(t, len(rules[1].Prices), 0)
+
+	assert.NoError(t, store.DeleteUpgradeRule(ctx, upgrade.ID))
+	assert.Error(t, store.DeleteUpgradeRule(ctx, upgrade.ID), ports.ErrNotFound)
+
+	rules, err = store.UpgradeRules(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(rules), 1)
+	assert.Equal(t, rules[0].ID, crossgrade.ID)
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryVerifications(t *testing.T) {
+	testVerificationStore(t, dal.NewMemoryVerifications())
+}
+
+func TestPostgreSQLVerifications(t *testing.T) {
+	testVerificationStore(t, dal.NewPostgreSQLVerifications(newTestDB(t)))
+}
+
+func testVerificationStore(t *testing.T, store ports.VerificationStore) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := store.Verification(ctx, 1)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	first := domain.Verification{CustomerID: 1, NonceHash: []byte("first"), Created: now, Expires: now.Add(time.Hour)}
+	err = store.SaveVerification(ctx, first)
+	assert.NoError(t, err)
+
+	second := domain.Verification{
+		CustomerID: 1,
+		NonceHash:  []byte("second"),
+		Created:    now.Add(time.Minute),
+		Expires:    now.Add(time.Hour + time.Minute),
+	}
+	err = store.SaveVerification(ctx, second)
+	assert.NoError(t, err)
+
+	v, err := store.Verification(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, string(v.NonceHash), "second")
+	assert.True(t, v.Created.Equal(second.Created))
+
+	// The replaced verification cannot be consumed.
+	_, err = store.ConsumeVerification(ctx, 1, []byte("first"))
+	assert.Error(t, err, ports.ErrNotFound)
+
+	v, err = store.ConsumeVerification(ctx, 1, []byte("second"))
+	assert.NoError(t, err)
+	assert.True(t, v.Expires.Equal(second.Expires))
+
+	_, err = store.ConsumeVerification(ctx, 1, []byte("second"))
+	assert.Error(t, err, ports.ErrNotFound)
+}
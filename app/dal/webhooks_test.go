@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dal_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func TestMemoryWebhookInbox(t *testing.T) {
+	testWebhookInbox(t, dal.NewMemoryWebhookInbox())
+}
+
+func TestPostgreSQLWebhookInbox(t *testing.T) {
+	testWebhookInbox(t, dal.NewPostgreSQLWebhookInbox(newTestDB(t)))
+}
+
+// testWebhookInbox checks the behavior every ports.WebhookInbox must have.
+func testWebhookInbox(t *testing.T, inbox ports.WebhookInbox) {
+	t.Helper()
+
+	ctx := t.Context()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newEvent := func(id string, received time.Time) domain.WebhookEvent {
+		return domain.WebhookEvent{
+			ID:          id,
+			Type:        domain.EventCheckoutCompleted,
+			Payload:     []byte(`{"id":"` + id + `"}`),
+			Received:    received,
+			Status:      domain.WebhookPending,
+			Attempts:    0,
+			LastError:   "",
+			NextAttempt: received,
+			Processed:   time.Time{},
+		}
+	}
+
+	assert.NoError(t, inbox.SaveWebhookEvent(ctx, newEvent("evt_2", now.Add(time.Second))))
+	assert.NoError(t, inbox.SaveWebhookEvent(ctx, newEvent("evt_1", now)))
+	assert.NoError(t, inbox.SaveWebhookEvent(ctx, newEvent("evt_3", now.Add(time.Hour))))
+
+	// Deliveries of the same event are stored once.
+	err := inbox.SaveWebhookEvent(ctx, newEvent("evt_1", now.Add(time.Minute)))
+	assert.Error(t, err, ports.ErrConflict)
+
+	got, err := inbox.WebhookEvent(ctx, "evt_1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(got.Payload), `{"id":"evt_1"}`)
+	assert.True(t, got.Received.Equal(now))
+	assert.True(t, got.Processed.IsZero())
+
+	_, err = inbox.WebhookEvent(ctx, "evt_9")
+	assert.Error(t, err, ports.ErrNotFound)
+
+	// Claims return the due events, oldest first, and hide them from other
+	// claims until the lease ends.
+	lease := now.Add(5 * time.Minute)
+
+	claimed, err := inbox.ClaimWebhookEvents(ctx, now.Add(time.Minute), lease, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(claimed), 2)
+	assert.Equal(t, claimed[0].ID, "evt_1")
+	assert.Equal(t, claimed[1].ID, "evt_2")
+	assert.True(t, claimed[0].NextAttempt.Equal(lease))
+
+	claimed, err = inbox.ClaimWebhookEvents(ctx, now.Add(time.Minute), lease, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(claimed), 0)
+
+	claimed, err = inbox.ClaimWebhookEvents(ctx, lease, lease.Add(5*time.Minute), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(claimed), 1)
+	assert.Equal(t, claimed[0].ID, "evt_1")
+
+	processed := claimed[0]
+	processed.Status = domain.WebhookProcessed
+	processed.Processed = now.Add(10 * time.Minute)
+	assert.NoError(t, inbox.UpdateWebhookEvent(ctx, processed))
+
+	dead := newEvent("evt_2", now.Add(time.Second))
+	dead.Status = domain.WebhookDead
+	dead.Attempts = 5
+	dead.LastError = "handler failed"
+	assert.NoError(t, inbox.UpdateWebhookEvent(ctx, dead))
+
+	err = inbox.UpdateWebhookEvent(ctx, newEvent("evt_9", now))
+	assert.Error(t, err, ports.ErrNotFound)
+
+	got, err = inbox.WebhookEvent(ctx, "evt_2")
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.WebhookDead)
+	assert.Equal(t, got.Attempts, 5)
+	assert.Equal(t, got.LastError, "handler failed")
+
+	// Only pending events are claimed.
+	claimed, err = inbox.ClaimWebhookEvents(ctx, now.Add(2*time.Hour), now.Add(3*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(claimed), 1)
+	assert.Equal(t, claimed[0].ID, "evt_3")
+
+	list, err := inbox.WebhookEvents(ctx, domain.WebhookPending, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(list), 1)
+
+	assert.NoError(t, inbox.SaveWebhookEvent(ctx, newEvent("evt_4", now.Add(2*time.Hour))))
+	assert.NoError(t, inbox.UpdateWebhookEvent(ctx, func() domain.WebhookEvent {
+		e := newEvent("evt_4", now.Add(2*time.Hour))
+		e.Status = domain.WebhookDead
+
+		return e
+	}()))
+
+	// Lists are most recent first.
+	list, err = inbox.WebhookEvents(ctx, domain.WebhookDead, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[0].ID, "evt_4")
+	assert.Equal(t, list[1].ID, "evt_2")
+
+	list, err = inbox.WebhookEvents(ctx, domain.WebhookDead, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(list), 1)
+
+	n, err := inbox.DeleteProcessedWebhookEvents(ctx, now.Add(10*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, n, 0)
+
+	n, err = inbox.DeleteProcessedWebhookEvents(ctx, now.Add(11*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+
+	_, err = inbox.WebhookEvent(ctx, "evt_1")
+	assert.Error(t, err, ports.ErrNotFound)
+}
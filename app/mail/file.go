@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// FileMailer is a ports.Mailer that drops every email as an .eml file in a
+// directory instead of delivering it. It lets flows such as the email
+// verification be followed in development without a mail server.
+type FileMailer struct {
+	dir  string
+	from string
+	now  func() time.Time
+}
+
+// NewFileMailer returns a FileMailer writing to dir, which is created if
+// needed.
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+		now:  time.Now,
+	}, nil
+}
+
+// Dir returns the directory emails are dropped in.
+func (f *FileMailer) Dir() string {
+	return f.dir
+}
+
+func (f *FileMailer) Send(_ context.Context, m domain.Mail) error {
+	now := f.now()
+
+	msg, err := compose(f.from, m, now)
+	if err != nil {
+		return err
+	}
+
+	name := now.UTC().Format("20060102T150405.000000000") + "-" + rand.Text() + ".eml"
+
+	return os.WriteFile(filepath.Join(f.dir, name), msg, 0o600)
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mail_test
+
+import (
+	"io"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend.brokedaear.com/app/mail"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	m, err := mail.NewFileMailer(dir, "Shop <noreply@example.com>")
+	assert.NoError(t, err)
+
+	err = m.Send(t.Context(), domain.Mail{
+		To:      "jane@example.com",
+		Subject: "Vérifiez votre email",
+		Text:    "Open https://example.com/verify?token=abc to verify.\n",
+	})
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.NoError(t, err)
+	assert.Equal(t, len(files), 1)
+
+	f, err := os.Open(files[0])
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	msg, err := netmail.ReadMessage(f)
+	assert.NoError(t, err)
+
+	assert.Equal(t, msg.Header.Get("To"), "jane@example.com")
+	assert.Equal(t, msg.Header.Get("From"), "Shop <noreply@example.com>")
+	assert.NotEqual(t, msg.Header.Get("Message-ID"), "")
+
+	subject, err := new(netmail.AddressParser).WordDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, subject, "Vérifiez votre email")
+
+	body, err := io.ReadAll(msg.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, string(body), "Open https://example.com/verify?token=3Dabc to verify.\r\n")
+}
+
+func TestFileMailer_RejectsHeaderInjection(t *testing.T) {
+	m, err := mail.NewFileMailer(t.TempDir(), "noreply@example.com")
+	assert.NoError(t, err)
+
+	err = m.Send(t.Context(), domain.Mail{
+		To:      "jane@example.com\r\nBcc: everyone@example.com",
+		Subject: "Hello",
+		Text:    "",
+	})
+	assert.Error(t, err, mail.ErrHeaderInjection)
+
+	err = m.Send(t.Context(), domain.Mail{To: "not an address", Subject: "Hello", Text: ""})
+	assert.Error(t, err, mail.ErrInvalidAddress)
+}
+
+func TestSMTPConfig_Validate(t *testing.T) {
+	valid := mail.SMTPConfig{
+		Host:     "smtp.example.com",
+		Port:     587,
+		Username: "",
+		Password: "",
+		From:     "Shop <noreply@example.com>",
+	}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.Host = ""
+	assert.Error(t, invalid.Validate(), mail.ErrSMTPConfig)
+
+	invalid = valid
+	invalid.From = "nobody"
+	assert.Error(t, invalid.Validate(), mail.ErrInvalidAddress)
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mail contains the ports.Mailer adapters.
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// compose renders m as an RFC 5322 message from the given sender. Header
+// values are checked for line breaks, so that they cannot smuggle headers
+// of their own.
+func compose(from string, m domain.Mail, now time.Time) ([]byte, error) {
+	for _, v := range []string{from, m.To, m.Subject} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, ErrHeaderInjection
+		}
+	}
+
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+
+	_, err = mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+
+	domainPart := sender.Address[strings.LastIndex(sender.Address, "@")+1:]
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", rand.Text(), domainPart)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	b.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+
+	_, err = w.Write([]byte(m.Text))
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+type MailError string
+
+func (e MailError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrHeaderInjection     MailError = "mail header contains a line break"
+	ErrInvalidAddress      MailError = "mail address is invalid"
+	ErrSMTPConfig          MailError = "smtp host, port and sender are required"
+	ErrSTARTTLSUnsupported MailError = "smtp server does not support STARTTLS"
+)
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// SMTPConfig configures the delivery of emails through an SMTP relay.
+type SMTPConfig struct {
+	Host string
+	Port int
+
+	// Username and Password authenticate to the relay. Authentication is
+	// skipped when Username is empty.
+	Username string
+	Password string `redact:"true"`
+
+	// From is the sender of every email, such as
+	// "BROKE DA EAR <noreply@brokedaear.com>".
+	From string
+}
+
+func (c SMTPConfig) Validate() error {
+	if c.Host == "" || c.Port <= 0 || c.From == "" {
+		return ErrSMTPConfig
+	}
+
+	_, err := mail.ParseAddress(c.From)
+	if err != nil {
+		return ErrInvalidAddress
+	}
+
+	return nil
+}
+
+func (c SMTPConfig) Value() any {
+	return c
+}
+
+// SMTPMailer is a ports.Mailer delivering emails through an SMTP relay. The
+// connection is always upgraded with STARTTLS, so that credentials and
+// verification links never cross the network in the clear.
+type SMTPMailer struct {
+	config SMTPConfig
+	now    func() time.Time
+}
+
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SMTPMailer{
+		config: config,
+		now:    time.Now,
+	}, nil
+}
+
+func (s *SMTPMailer) Send(ctx context.Context, m domain.Mail) error {
+	msg, err := compose(s.config.From, m, s.now())
+	if err != nil {
+		return err
+	}
+
+	from, _ := mail.ParseAddress(s.config.From)
+	to, _ := mail.ParseAddress(m.To)
+
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+
+	c, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	ok, _ := c.Extension("STARTTLS")
+	if !ok {
+		return ErrSTARTTLSUnsupported
+	}
+
+	err = c.StartTLS(&tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12}) //nolint:exhaustruct // defaults are secure
+	if err != nil {
+		return err
+	}
+
+	if s.config.Username != "" {
+		err = c.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Mail(from.Address)
+	if err != nil {
+		return err
+	}
+
+	err = c.Rcpt(to.Address)
+	if err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(msg)
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
@@ -7,6 +7,7 @@ package main
 import (
 	"context"
 
+	"backend.brokedaear.com/internal/common/telemetry"
 	"backend.brokedaear.com/internal/core/server"
 )
 
@@ -16,11 +17,11 @@ type appServer struct {
 }
 
 func newAppServer(
-	ctx context.Context,
 	logger server.Logger,
 	config *server.Config,
+	t telemetry.Telemetry,
 ) (*appServer, error) {
-	s, err := server.NewHTTPServer(ctx, logger, config)
+	s, err := server.NewHTTPServer(logger, config, t)
 	if err != nil {
 		return nil, err
 	}
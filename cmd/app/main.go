@@ -6,18 +6,42 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap/zapcore"
 	"golang.org/x/sync/errgroup"
 
 	"backend.brokedaear.com"
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/app/mail"
+	"backend.brokedaear.com/internal/adapters"
+	"backend.brokedaear.com/internal/common/encryption"
 	"backend.brokedaear.com/internal/common/infra"
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/common/otp"
+	"backend.brokedaear.com/internal/common/passwords"
+	"backend.brokedaear.com/internal/common/telemetry"
 	"backend.brokedaear.com/internal/common/utils/loggers"
+	"backend.brokedaear.com/internal/common/validator"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
 	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
 )
 
 const (
@@ -25,6 +49,168 @@ const (
 	environment = "development"
 	port        = 1025
 	address     = "localhost"
+
+	// The admin server exposes profiling and introspection, so it only
+	// listens on the loopback interface.
+	adminPort    = 1026
+	adminAddress = "localhost"
+
+	// maxCapture bounds profile and trace captures on the admin server.
+	maxCapture = time.Minute
+
+	// breachedPasswordsPath is the breached password list checked on sign
+	// up, relative to the repository root. Deployments should replace it
+	// with a comprehensive list.
+	breachedPasswordsPath = "app/data/breached-passwords.txt"
+
+	// databaseURLEnv names the environment variable holding the PostgreSQL
+	// connection string. Without it, data is kept in memory.
+	databaseURLEnv = "DATABASE_URL"
+
+	// SMTP relay settings are read from these environment variables. Without
+	// a host, emails are dropped as files instead of being delivered.
+	smtpHostEnv     = "SMTP_HOST"
+	smtpPortEnv     = "SMTP_PORT"
+	smtpUsernameEnv = "SMTP_USERNAME"
+	smtpPasswordEnv = "SMTP_PASSWORD"
+	defaultSMTPPort = 587
+
+	// mailFrom is the sender of every email.
+	mailFrom = "BROKE DA EAR <noreply@brokedaear.com>"
+
+	// alertWebhookURLEnv names the environment variable holding the URL, such
+	// as a chat incoming webhook, that monitor alerts are posted to as JSON.
+	// Without it, alerts are only logged.
+	alertWebhookURLEnv = "ALERT_WEBHOOK_URL"
+
+	// alertWebhookTimeout bounds the delivery of an alert to the webhook.
+	alertWebhookTimeout = 10 * time.Second
+
+	// verificationSecretEnv names the environment variable holding the
+	// secret that signs email verification tokens.
+	verificationSecretEnv = "VERIFICATION_SECRET"
+
+	// cartSecretEnv names the environment variable holding the secret that
+	// signs the cart cookies of guests.
+	cartSecretEnv = "CART_SECRET"
+
+	// stripeAPIKeyEnv names the environment variable holding the secret key
+	// of the Stripe account. Without it, checkout is disabled.
+	stripeAPIKeyEnv = "STRIPE_API_KEY"
+
+	// stripeAPIURLEnv names the environment variable holding the base URL of
+	// the Stripe API, to use a local fake of it. It defaults to the real
+	// one.
+	stripeAPIURLEnv = "STRIPE_API_URL"
+
+	// stripeWebhookSecretsEnv names the environment variable holding the
+	// signing secrets of the Stripe webhook endpoint, separated by commas.
+	// While a secret is rolled, both the old and the new one are listed.
+	// Without it, webhook deliveries are not received.
+	stripeWebhookSecretsEnv = "STRIPE_WEBHOOK_SECRETS"
+
+	// twoFactorKeyEnv names the environment variable holding the base64
+	// encoded 32 byte key that encrypts TOTP secrets at rest. It is required
+	// with a database: without it, no stored secret could be decrypted after
+	// a restart.
+	twoFactorKeyEnv = "TOTP_ENCRYPTION_KEY"
+
+	// tokenKeyFilesEnv names the environment variable holding the list of
+	// PEM files, separated like PATH, of the Ed25519 keys that sign access
+	// tokens. The first key signs and the others only verify, and keys do
+	// not rotate. Without it, random keys sign and rotate on schedule, and
+	// tokens are only valid with the process that issued them.
+	tokenKeyFilesEnv = "JWT_SIGNING_KEY_FILES"
+
+	// licenseKeyFilesEnv names the environment variable holding the list
+	// of PEM files, separated like PATH, of the Ed25519 keys that sign
+	// plugin licenses. The first key signs and the others only verify: a
+	// key is rotated by listing a new one first, and kept for as long as
+	// licenses it signed are in use. It is required with a database, since
+	// licenses outlive the process that signed them.
+	licenseKeyFilesEnv = "LICENSE_SIGNING_KEY_FILES"
+
+	// tokenIssuer and tokenAudience are the iss and aud claims of access
+	// tokens.
+	tokenIssuer   = "https://brokedaear.com"
+	tokenAudience = "brokedaear"
+
+	// tokenRotateInterval is the time between two checks of whether the
+	// signing key of access tokens is due for rotation.
+	tokenRotateInterval = 10 * time.Minute
+
+	// twoFactorIssuer names the app in authenticator apps.
+	twoFactorIssuer = "BROKE DA EAR"
+
+	// storefrontOrigin is the origin of the web shop, the only one allowed
+	// to call the app from browsers.
+	storefrontOrigin = "https://brokedaear.com"
+
+	// developmentOrigin is the origin of the web shop served locally, also
+	// allowed in development.
+	developmentOrigin = "http://localhost:3000"
+
+	// verificationLinkURL is the page of the website that verifies emails.
+	verificationLinkURL = "https://brokedaear.com/verify"
+
+	// deviceVerificationURI is the page of the website where customers
+	// approve the user codes shown by plugins.
+	deviceVerificationURI = "https://brokedaear.com/device"
+
+	// passwordResetLinkURL is the page of the website that resets
+	// passwords.
+	passwordResetLinkURL = "https://brokedaear.com/reset-password"
+
+	// checkoutSuccessURL and checkoutCancelURL are the pages of the website
+	// customers come back to from the payment page.
+	checkoutSuccessURL = "https://brokedaear.com/checkout/success?session={CHECKOUT_SESSION_ID}"
+	checkoutCancelURL  = "https://brokedaear.com/cart"
+
+	// sessionSweepInterval is the time between two deletions of expired
+	// sessions.
+	sessionSweepInterval = 10 * time.Minute
+
+	// deviceSweepInterval is the time between two deletions of expired
+	// device authorizations and devices.
+	deviceSweepInterval = 10 * time.Minute
+
+	// cartSweepInterval is the time between two deletions of expired
+	// carts.
+	cartSweepInterval = time.Hour
+
+	// activationSweepInterval is the time between two deactivations of
+	// inactive machines.
+	activationSweepInterval = time.Hour
+
+	// webhookDispatchInterval is the time between two dispatches of the
+	// webhook events due.
+	webhookDispatchInterval = 5 * time.Second
+
+	// webhookSweepInterval is the time between two deletions of old
+	// processed webhook events.
+	webhookSweepInterval = time.Hour
+
+	// loginSweepInterval is the time between two purges of stale failed
+	// logins.
+	loginSweepInterval = 5 * time.Minute
+
+	// resetQueueSize is how many password reset requests may wait to be
+	// mailed; more are dropped.
+	resetQueueSize = 100
+
+	// resetRequestTimeout bounds the handling of a queued password reset
+	// request.
+	resetRequestTimeout = time.Minute
+
+	// resetSweepInterval is the time between two purges of stale password
+	// reset request counts.
+	resetSweepInterval = 5 * time.Minute
+
+	// shutdownTimeout bounds the whole graceful shutdown.
+	shutdownTimeout = 30 * time.Second
+
+	// componentStopTimeout bounds the shutdown of each single component.
+	componentStopTimeout = 10 * time.Second
 )
 
 func main() {
@@ -41,24 +227,31 @@ func run() error {
 		return fmt.Errorf("failed to parse environment: %w", err)
 	}
 
+	// Logs dropped before telemetry is up are not counted.
+	droppedLogs := loggers.NewDroppedLogCounter()
+
+	logRing, err := loggers.NewRingCore(newLogRingConfig())
+	if err != nil {
+		return fmt.Errorf("failed to initialize log ring: %w", err)
+	}
+
 	config := &loggers.ZapConfig{
 		Env:                env,
 		OtelServiceName:    "app",
 		OtelLoggerProvider: nil,
 		CustomZapper:       nil,
 		WithTelemetry:      false,
+		Sampling:           newLogSamplingConfig(),
+		Dedupe:             newLogDedupeConfig(),
+		DroppedLogs:        droppedLogs,
+		Observers:          []zapcore.Core{logRing},
 	}
+
 	logger, err := loggers.NewZap(config)
 	if err != nil {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
-	defer func() {
-		if syncErr := logger.Sync(); syncErr != nil {
-			fmt.Printf("failed to sync zap logger: %v\n", syncErr) //nolint:forbidigo // logger sync failure, no alternative
-		}
-	}()
-
 	ctx, cancel := signal.NotifyContext(
 		context.Background(),
 		os.Interrupt,
@@ -70,49 +263,1372 @@ func run() error {
 
 	defer cancel()
 
+	lc := infra.NewLifecycle(logger, componentStopTimeout)
+
+	err = lc.Register(infra.Registration{
+		Name: "logger",
+		Component: infra.Hook{
+			OnStart: nil,
+			OnStop: func(context.Context) error {
+				return logger.Sync()
+			},
+		},
+		DependsOn:   nil,
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register logger: %w", err)
+	}
+
+	// abort stops the components registered so far, such as the database
+	// pool, which hold resources from their construction on, and returns
+	// err.
+	abort := func(err error) error {
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer stopCancel()
+
+		return errors.Join(err, lc.Abort(stopCtx))
+	}
+
+	tel, err := telemetry.New(ctx, newTelemetryConfig())
+	if err != nil {
+		logger.Error("failed to initialize telemetry", "error", err)
+		return abort(fmt.Errorf("failed to initialize telemetry: %w", err))
+	}
+
+	err = lc.Register(infra.Registration{
+		Name:        "telemetry",
+		Component:   infra.CloserHook(tel),
+		DependsOn:   []string{"logger"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return errors.Join(abort(fmt.Errorf("failed to register telemetry: %w", err)), tel.Close())
+	}
+
+	droppedCounter, err := tel.Counter(telemetry.MetricLogsDropped)
+	if err != nil {
+		logger.Error("failed to create dropped logs counter", "error", err)
+		return abort(fmt.Errorf("failed to create dropped logs counter: %w", err))
+	}
+
+	droppedLogs.Attach(droppedCounter)
+
+	notifiers, err := newAlertNotifiers(logger)
+	if err != nil {
+		logger.Error("failed to configure alerts", "error", err)
+		return abort(fmt.Errorf("failed to configure alerts: %w", err))
+	}
+
+	monitor, err := infra.NewMonitor(logger, tel, newMonitorConfig(), notifiers...)
+	if err != nil {
+		logger.Error("failed to initialize monitor", "error", err)
+		return abort(fmt.Errorf("failed to initialize monitor: %w", err))
+	}
+
+	err = lc.Register(infra.Registration{
+		Name:        "monitor",
+		Component:   monitor,
+		DependsOn:   []string{"telemetry"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to register monitor: %w", err))
+	}
+
 	cfg, err := server.NewConfig(address, port, environment, version)
 	if err != nil {
 		logger.Error("failed to initialize config", "error", err)
-		return fmt.Errorf("failed to initialize config: %w", err)
+		return abort(fmt.Errorf("failed to initialize config: %w", err))
+	}
+
+	st, err := newStores(lc, monitor)
+	if err != nil {
+		logger.Error("failed to initialize stores", "error", err)
+		return abort(fmt.Errorf("failed to initialize stores: %w", err))
+	}
+
+	s, err := newAppServer(logger, cfg, tel)
+	if err != nil {
+		logger.Error("failed to create app server", "error", err)
+		return abort(fmt.Errorf("failed to create app server: %w", err))
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "app server",
+		Component: infra.Hook{
+			OnStart: nil,
+			OnStop:  s.Shutdown,
+		},
+		DependsOn:   []string{"telemetry", "database"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to register app server: %w", err))
+	}
+
+	hasher, breached, err := newPasswordPolicy()
+	if err != nil {
+		logger.Error("failed to initialize password policy", "error", err)
+		return abort(fmt.Errorf("failed to initialize password policy: %w", err))
 	}
 
-	s, err := newAppServer(ctx, logger, cfg)
+	customers := service.NewCustomerService(st.customers, hasher, breached)
+
+	mailer, err := newMailer(logger)
+	if err != nil {
+		logger.Error("failed to initialize mailer", "error", err)
+		return abort(fmt.Errorf("failed to initialize mailer: %w", err))
+	}
+
+	verificationConfig := newVerificationConfig()
+
+	verifications, err := service.NewVerificationService(st.customers, st.verifications, mailer, verificationConfig)
+	if err != nil {
+		logger.Error("failed to initialize verification service", "error", err)
+		return abort(fmt.Errorf("failed to initialize verification service: %w", err))
+	}
+
+	sessions, err := service.NewSessionService(st.sessions, newSessionConfig())
+	if err != nil {
+		logger.Error("failed to initialize session service", "error", err)
+		return abort(fmt.Errorf("failed to initialize session service: %w", err))
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "session sweeper",
+		Component: infra.NewPeriodic(logger, "session sweep", sessionSweepInterval, func(ctx context.Context) error {
+			_, sweepErr := sessions.Sweep(ctx)
+			return sweepErr
+		}),
+		DependsOn:   []string{"database"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to register session sweeper: %w", err))
+	}
+
+	twoFactorConfig, err := newTwoFactorConfig()
 	if err != nil {
-		logger.Error("failed to create monitor server", "error", err)
-		return fmt.Errorf("failed to create monitor server: %w", err)
+		logger.Error("failed to configure two-factor authentication", "error", err)
+		return abort(fmt.Errorf("failed to configure two-factor authentication: %w", err))
 	}
 
-	return runService(ctx, logger, s)
+	// Failed logins, wrong two-factor codes and password reset requests are
+	// counted in memory: they are short lived, and losing them on restart
+	// only lifts the current lockouts and limits early.
+	failures := dal.NewMemoryLoginFailures()
+	requests := dal.NewMemoryRequestCounts()
+
+	twoFactor, err := service.NewTwoFactorService(
+		st.customers,
+		st.twoFactor,
+		sessions,
+		failures,
+		st.audit,
+		twoFactorConfig,
+	)
+	if err != nil {
+		logger.Error("failed to initialize two-factor service", "error", err)
+		return abort(fmt.Errorf("failed to initialize two-factor service: %w", err))
+	}
+
+	login, err := newLoginService(tel, customers, sessions, twoFactor, failures, st.audit)
+	if err != nil {
+		logger.Error("failed to initialize login service", "error", err)
+		return abort(fmt.Errorf("failed to initialize login service: %w", err))
+	}
+
+	devices, err := service.NewDeviceService(st.customers, st.devices, st.audit, newDeviceConfig())
+	if err != nil {
+		logger.Error("failed to initialize device service", "error", err)
+		return abort(fmt.Errorf("failed to initialize device service: %w", err))
+	}
+
+	passwordService, err := service.NewPasswordService(
+		st.customers,
+		login,
+		hasher,
+		breached,
+		st.resets,
+		requests,
+		mailer,
+		sessions,
+		devices,
+		st.audit,
+		newPasswordResetConfig(),
+	)
+	if err != nil {
+		logger.Error("failed to initialize password service", "error", err)
+		return abort(fmt.Errorf("failed to initialize password service: %w", err))
+	}
+
+	resets, err := registerPasswordResetJobs(lc, logger, passwordService)
+	if err != nil {
+		return abort(err)
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "login failure sweeper",
+		Component: infra.NewPeriodic(logger, "login failure sweep", loginSweepInterval, func(ctx context.Context) error {
+			_, sweepErr := login.Sweep(ctx)
+			return sweepErr
+		}),
+		DependsOn:   []string{"logger"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to register login failure sweeper: %w", err))
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "device sweeper",
+		Component: infra.NewPeriodic(logger, "device sweep", deviceSweepInterval, func(ctx context.Context) error {
+			_, sweepErr := devices.Sweep(ctx)
+			return sweepErr
+		}),
+		DependsOn:   []string{"database"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to register device sweeper: %w", err))
+	}
+
+	tokenConfig, tokenKeys, err := newTokenConfig()
+	if err != nil {
+		logger.Error("failed to configure access tokens", "error", err)
+		return abort(fmt.Errorf("failed to configure access tokens: %w", err))
+	}
+
+	tokens, err := service.NewTokenService(st.customers, tokenConfig, tokenKeys...)
+	if err != nil {
+		logger.Error("failed to initialize token service", "error", err)
+		return abort(fmt.Errorf("failed to initialize token service: %w", err))
+	}
+
+	err = lc.Register(infra.Registration{
+		Name:        "token key rotator",
+		Component:   infra.NewPeriodic(logger, "token key rotation", tokenRotateInterval, tokens.Rotate),
+		DependsOn:   []string{"logger"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to register token key rotator: %w", err))
+	}
+
+	// Roles are granted with the roles command, or by admins from the admin
+	// routes.
+	authz := service.NewAuthorizationService(st.roles, st.audit)
+	catalog := service.NewCatalogService(st.products)
+	cartConfig := newCartConfig()
+
+	upgrades := service.NewUpgradeService(st.upgrades, st.licenses, st.orders, st.products)
+
+	carts, err := service.NewCartService(st.carts, st.products, upgrades, cartConfig)
+	if err != nil {
+		logger.Error("failed to initialize cart service", "error", err)
+		return abort(fmt.Errorf("failed to initialize cart service: %w", err))
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "cart sweeper",
+		Component: infra.NewPeriodic(logger, "cart sweep", cartSweepInterval, func(ctx context.Context) error {
+			_, sweepErr := carts.Sweep(ctx)
+			return sweepErr
+		}),
+		DependsOn:   []string{"database"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to register cart sweeper: %w", err))
+	}
+
+	stripeWebhookConfig, webhookConfig := newStripeWebhookConfig(), newWebhookConfig()
+
+	webhooks, err := newWebhookService(st.webhooks, stripeWebhookConfig, webhookConfig)
+	if err != nil {
+		logger.Error("failed to initialize webhook service", "error", err)
+		return abort(fmt.Errorf("failed to initialize webhook service: %w", err))
+	}
+
+	err = registerWebhookJobs(lc, logger, webhooks)
+	if err != nil {
+		return abort(err)
+	}
+
+	stripeConfig, checkoutConfig := newStripeConfig(), newCheckoutConfig()
+
+	lic, err := newLicensing(tel)
+	if err != nil {
+		logger.Error("failed to initialize licensing", "error", err)
+		return abort(err)
+	}
+
+	paymentRoutes, paymentAdminRoutes, err := newPaymentRoutes(
+		lc, logger, st, sessions, verifications, twoFactor, authz, carts, webhooks, stripeConfig, checkoutConfig, lic,
+	)
+	if err != nil {
+		logger.Error("failed to initialize checkout", "error", err)
+		return abort(fmt.Errorf("failed to initialize checkout: %w", err))
+	}
+
+	webhookRoutes := newWebhookRoutes(logger, webhooks, stripeWebhookConfig)
+
+	webSecurity, adminSecurity := newSecurityPolicies(cfg.Env)
+
+	err = validator.Check(webSecurity, adminSecurity)
+	if err != nil {
+		logger.Error("invalid security policy", "error", err)
+		return abort(fmt.Errorf("invalid security policy: %w", err))
+	}
+
+	// The catalog is read only and cached by shared caches, so it gets no
+	// CSRF cookie.
+	catalogSecurity := webSecurity
+	catalogSecurity.CSRF = nil
+
+	// Webhooks are called by Stripe, not browsers, and authenticated by
+	// their signature.
+	webhookSecurity := webSecurity
+	webhookSecurity.CORS = nil
+	webhookSecurity.CSRF = nil
+
+	s.RegisterRoutes(slices.Concat(webSecurity.Routes(slices.Concat(
+		server.NewCSRFRoutes(),
+		server.NewCustomerRoutes(logger, customers, verifications),
+		server.NewVerificationRoutes(logger, sessions, verifications),
+		server.NewLoginRoutes(logger, login, carts),
+		server.NewPasswordRoutes(logger, sessions, twoFactor, passwordService, resets),
+		server.NewSessionRoutes(logger, sessions, twoFactor, devices),
+		server.NewTwoFactorRoutes(logger, sessions, twoFactor),
+		server.NewDeviceRoutes(logger, sessions, twoFactor, devices),
+		server.NewTokenRoutes(logger, sessions, devices, tokens),
+		server.NewCartRoutes(logger, sessions, carts),
+		server.NewUpgradeRoutes(logger, sessions, upgrades),
+		paymentRoutes,
+	)...), catalogSecurity.Routes(
+		server.NewCatalogRoutes(logger, catalog)...,
+	), webhookSecurity.Routes(
+		webhookRoutes...,
+	), adminSecurity.Routes(
+		server.NewAdminGroup(logger, sessions, authz, slices.Concat(
+			server.NewRoleRoutes(logger, authz, twoFactor),
+			server.NewProductRoutes(logger, authz, catalog),
+			server.NewUpgradeAdminRoutes(logger, authz, upgrades),
+			server.NewWebhookAdminRoutes(logger, authz, webhooks),
+			paymentAdminRoutes,
+		)...)...,
+	))...)
+
+	adminCfg := &server.AdminConfig{
+		Config: server.Config{
+			Addr:      server.Address(adminAddress),
+			Port:      server.Port(adminPort),
+			Env:       cfg.Env,
+			Version:   cfg.Version,
+			Telemetry: false,
+		},
+		TLS:        nil,
+		MaxCapture: maxCapture,
+	}
+
+	admin, err := server.NewAdminServer(
+		logger,
+		adminCfg,
+		tel,
+		server.AdminInfo{
+			Config: effectiveConfig{
+				Server:        cfg,
+				Admin:         adminCfg,
+				Telemetry:     newTelemetryConfig(),
+				Monitor:       newMonitorConfig(),
+				LogSampling:   config.Sampling,
+				LogDedupe:     config.Dedupe,
+				LogRing:       newLogRingConfig(),
+				Argon2id:      passwords.DefaultArgon2idParams(),
+				Sessions:      newSessionConfig(),
+				Login:         newLoginConfig(),
+				Verification:  verificationConfig,
+				Reset:         newPasswordResetConfig(),
+				TwoFactor:     twoFactorConfig,
+				Devices:       newDeviceConfig(),
+				Tokens:        tokenConfig,
+				Carts:         cartConfig,
+				Stripe:        stripeConfig,
+				Checkout:      checkoutConfig,
+				Licenses:      lic.licenses,
+				Activations:   lic.activations,
+				Trials:        lic.trials,
+				StripeWebhook: stripeWebhookConfig,
+				Webhooks:      webhookConfig,
+				WebSecurity:   webSecurity,
+				AdminSecurity: adminSecurity,
+				SMTP:          newSMTPConfig(),
+				DatabaseDSN:   os.Getenv(databaseURLEnv),
+			},
+			Flags: newFeatureFlags(),
+		},
+		ringRoutes(logRing)...,
+	)
+	if err != nil {
+		logger.Error("failed to create admin server", "error", err)
+		return abort(fmt.Errorf("failed to create admin server: %w", err))
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "admin server",
+		Component: infra.Hook{
+			OnStart: nil,
+			OnStop:  admin.Shutdown,
+		},
+		DependsOn:   []string{"telemetry"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return errors.Join(abort(fmt.Errorf("failed to register admin server: %w", err)), admin.Close())
+	}
+
+	return runService(ctx, logger, lc, s, admin)
+}
+
+// effectiveConfig gathers the configuration of every part of the app, as
+// served redacted by the admin server.
+type effectiveConfig struct {
+	Server        *server.Config
+	Admin         *server.AdminConfig
+	Telemetry     *telemetry.Config
+	Monitor       infra.MonitorConfig
+	LogSampling   *loggers.ZapSamplingConfig
+	LogDedupe     *loggers.ZapDedupeConfig
+	LogRing       loggers.RingConfig
+	Argon2id      passwords.Argon2idParams
+	Sessions      service.SessionConfig
+	Login         service.LoginConfig
+	Verification  service.VerificationConfig
+	Reset         service.PasswordResetConfig
+	TwoFactor     service.TwoFactorConfig
+	Devices       service.DeviceConfig
+	Tokens        service.TokenConfig
+	Carts         service.CartConfig
+	Stripe        adapters.StripeConfig
+	Checkout      service.CheckoutConfig
+	Licenses      service.LicenseConfig
+	Activations   service.ActivationConfig
+	Trials        service.TrialConfig
+	StripeWebhook adapters.StripeWebhookConfig
+	Webhooks      service.WebhookConfig
+	WebSecurity   server.SecurityPolicy
+	AdminSecurity server.SecurityPolicy
+	SMTP          mail.SMTPConfig
+	DatabaseDSN   string `redact:"true"`
+}
+
+// newPasswordPolicy returns the hasher of customer passwords and the list
+// of breached passwords customers may not choose.
+func newPasswordPolicy() (*passwords.Argon2id, *passwords.BreachedList, error) {
+	hasher, err := passwords.NewArgon2id(passwords.DefaultArgon2idParams())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	breached, err := passwords.LoadBreachedList(breachedPasswordsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hasher, breached, nil
+}
+
+// stores are the persistence adapters of the app.
+type stores struct {
+	customers     ports.CustomerRepository
+	sessions      ports.SessionStore
+	audit         ports.AuditLog
+	verifications ports.VerificationStore
+	resets        ports.PasswordResetStore
+	twoFactor     ports.TwoFactorStore
+	devices       ports.DeviceStore
+	roles         ports.RoleStore
+	products      ports.ProductRepository
+	carts         ports.CartStore
+	webhooks      ports.WebhookInbox
+	orders        ports.OrderStore
+	licenses      ports.LicenseStore
+	activations   ports.ActivationStore
+	upgrades      ports.UpgradeRuleStore
 }
 
-func runService(ctx context.Context, logger server.Logger, s *appServer) error {
+// newStores returns the stores of the app. With a database configured, data
+// is kept in PostgreSQL, whose schema is migrated on start; otherwise it is
+// kept in memory. Either way, a "database" component is registered for
+// others to depend on.
+func newStores(lc *infra.Lifecycle, monitor *infra.Monitor) (stores, error) {
+	dsn := os.Getenv(databaseURLEnv)
+	if dsn == "" {
+		err := lc.Register(infra.Registration{
+			Name:        "database",
+			Component:   infra.Hook{OnStart: nil, OnStop: nil},
+			DependsOn:   []string{"logger"},
+			StopTimeout: 0,
+		})
+
+		return stores{
+			customers:     dal.NewMemoryCustomers(),
+			sessions:      dal.NewMemorySessions(),
+			audit:         dal.NewMemoryAuditLog(),
+			verifications: dal.NewMemoryVerifications(),
+			resets:        dal.NewMemoryPasswordResets(),
+			twoFactor:     dal.NewMemoryTwoFactor(),
+			devices:       dal.NewMemoryDevices(),
+			roles:         dal.NewMemoryRoles(),
+			products:      dal.NewMemoryProducts(),
+			carts:         dal.NewMemoryCarts(),
+			webhooks:      dal.NewMemoryWebhookInbox(),
+			orders:        dal.NewMemoryOrders(),
+			licenses:      dal.NewMemoryLicenses(),
+			activations:   dal.NewMemoryActivations(),
+			upgrades:      dal.NewMemoryUpgradeRules(),
+		}, err
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return stores{}, err
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "database",
+		Component: infra.Hook{
+			OnStart: func(ctx context.Context) error {
+				pingErr := db.PingContext(ctx)
+				if pingErr != nil {
+					return pingErr
+				}
+
+				return dal.Migrate(ctx, db)
+			},
+			OnStop: func(context.Context) error {
+				return db.Close()
+			},
+		},
+		DependsOn:   []string{"logger"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return stores{}, errors.Join(err, db.Close())
+	}
+
+	monitor.RegisterProbe(infra.NewProbe("database", db.PingContext))
+
+	return stores{
+		customers:     dal.NewPostgreSQLCustomers(db),
+		sessions:      dal.NewPostgreSQLSessions(db),
+		audit:         dal.NewPostgreSQLAuditLog(db),
+		verifications: dal.NewPostgreSQLVerifications(db),
+		resets:        dal.NewPostgreSQLPasswordResets(db),
+		twoFactor:     dal.NewPostgreSQLTwoFactor(db),
+		devices:       dal.NewPostgreSQLDevices(db),
+		roles:         dal.NewPostgreSQLRoles(db),
+		products:      dal.NewPostgreSQLProducts(db),
+		carts:         dal.NewPostgreSQLCarts(db),
+		webhooks:      dal.NewPostgreSQLWebhookInbox(db),
+		orders:        dal.NewPostgreSQLOrders(db),
+		licenses:      dal.NewPostgreSQLLicenses(db),
+		activations:   dal.NewPostgreSQLActivations(db),
+		upgrades:      dal.NewPostgreSQLUpgradeRules(db),
+	}, nil
+}
+
+// newLoginService returns the login service.
+func newLoginService(
+	tel telemetry.Telemetry,
+	customers *service.CustomerService,
+	sessions *service.SessionService,
+	twoFactor *service.TwoFactorService,
+	failures ports.LoginFailures,
+	audit ports.AuditLog,
+) (*service.LoginService, error) {
+	succeeded, err := tel.Counter(telemetry.MetricLoginsSucceeded)
+	if err != nil {
+		return nil, err
+	}
+
+	failed, err := tel.Counter(telemetry.MetricLoginsFailed)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.NewLoginService(
+		customers,
+		sessions,
+		twoFactor,
+		failures,
+		audit,
+		service.LoginMetrics{Succeeded: succeeded, Failed: failed},
+		newLoginConfig(),
+	)
+}
+
+// newLoginConfig returns the defenses of the login against password
+// guessing.
+func newLoginConfig() service.LoginConfig {
+	const (
+		accountThreshold = 10
+		ipThreshold      = 100
+	)
+
+	return service.LoginConfig{
+		AccountThreshold: accountThreshold,
+		IPThreshold:      ipThreshold,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		Lockout:          15 * time.Minute,
+	}
+}
+
+// newMailer returns the mailer of the app. With an SMTP relay configured,
+// emails are delivered through it; otherwise they are dropped as files in
+// the temporary directory.
+func newMailer(logger server.Logger) (ports.Mailer, error) {
+	config := newSMTPConfig()
+	if config.Host != "" {
+		return mail.NewSMTPMailer(config)
+	}
+
+	m, err := mail.NewFileMailer(filepath.Join(os.TempDir(), "brokedaear-mail"), mailFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("dropping emails as files", "dir", m.Dir())
+
+	return m, nil
+}
+
+// newSMTPConfig returns the SMTP relay configured by the environment. The
+// host is empty when no relay is configured.
+func newSMTPConfig() mail.SMTPConfig {
+	port, err := strconv.Atoi(os.Getenv(smtpPortEnv))
+	if err != nil {
+		port = defaultSMTPPort
+	}
+
+	return mail.SMTPConfig{
+		Host:     os.Getenv(smtpHostEnv),
+		Port:     port,
+		Username: os.Getenv(smtpUsernameEnv),
+		Password: os.Getenv(smtpPasswordEnv),
+		From:     mailFrom,
+	}
+}
+
+// newVerificationConfig returns the configuration of email verification.
+// Without a secret in the environment, a random one is drawn, and links
+// sent before a restart stop working.
+func newVerificationConfig() service.VerificationConfig {
+	secret := []byte(os.Getenv(verificationSecretEnv))
+	if len(secret) == 0 {
+		secret = []byte(rand.Text() + rand.Text())
+	}
+
+	return service.VerificationConfig{
+		Secret:         secret,
+		TTL:            24 * time.Hour,
+		ResendInterval: time.Minute,
+		LinkURL:        verificationLinkURL,
+	}
+}
+
+// newCartConfig returns the configuration of shopping carts. Without a
+// secret in the environment, a random one is drawn, and guests lose their
+// cart on restart.
+func newCartConfig() service.CartConfig {
+	secret := []byte(os.Getenv(cartSecretEnv))
+	if len(secret) == 0 {
+		secret = []byte(rand.Text() + rand.Text())
+	}
+
+	return service.CartConfig{
+		Secret:      secret,
+		GuestTTL:    7 * 24 * time.Hour,
+		CustomerTTL: 90 * 24 * time.Hour,
+	}
+}
+
+// newStripeConfig returns the configuration of the Stripe payment provider.
+// The API key is empty when none is configured.
+func newStripeConfig() adapters.StripeConfig {
+	baseURL := os.Getenv(stripeAPIURLEnv)
+	if baseURL == "" {
+		baseURL = adapters.StripeBaseURL
+	}
+
+	return adapters.StripeConfig{
+		APIKey:        os.Getenv(stripeAPIKeyEnv),
+		BaseURL:       baseURL,
+		MaxRetries:    2,
+		RetryDelay:    500 * time.Millisecond,
+		MaxRetryDelay: 5 * time.Second,
+		Timeout:       30 * time.Second,
+	}
+}
+
+// newStripeWebhookConfig returns the configuration of the verification of
+// Stripe webhook deliveries. There are no secrets when none are
+// configured.
+func newStripeWebhookConfig() adapters.StripeWebhookConfig {
+	var secrets []string
+
+	for secret := range strings.SplitSeq(os.Getenv(stripeWebhookSecretsEnv), ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	return adapters.StripeWebhookConfig{
+		Secrets:   secrets,
+		Tolerance: 5 * time.Minute,
+	}
+}
+
+// newWebhookConfig returns the configuration of the processing of webhook
+// events. Stripe retries deliveries for three days, so processed events
+// are kept longer than that.
+func newWebhookConfig() service.WebhookConfig {
+	return service.WebhookConfig{
+		MaxAttempts:   8,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: 6 * time.Hour,
+		Lease:         5 * time.Minute,
+		BatchSize:     50,
+		Retention:     30 * 24 * time.Hour,
+	}
+}
+
+func newCheckoutConfig() service.CheckoutConfig {
+	return service.CheckoutConfig{
+		SuccessURL: checkoutSuccessURL,
+		CancelURL:  checkoutCancelURL,
+	}
+}
+
+// newPaymentRoutes returns the checkout, order, license, activation and
+// trial routes, paid with Stripe, then their admin routes, and registers the
+// handlers of the payment events of checkouts and the sweep of inactive
+// machines. Without a Stripe API key, checkout is disabled and there are
+// none.
+func newPaymentRoutes(
+	lc *infra.Lifecycle,
+	logger server.Logger,
+	st stores,
+	sessions *service.SessionService,
+	verifications *service.VerificationService,
+	twoFactor *service.TwoFactorService,
+	authz *service.AuthorizationService,
+	carts *service.CartService,
+	webhooks *service.WebhookService,
+	stripeConfig adapters.StripeConfig,
+	checkoutConfig service.CheckoutConfig,
+	lic licensing,
+) ([]server.HTTPRoute, []server.HTTPRoute, error) {
+	if stripeConfig.APIKey == "" {
+		logger.Warn("checkout is disabled", "reason", stripeAPIKeyEnv+" is not set")
+		return nil, nil, nil
+	}
+
+	payments, err := adapters.NewStripe(stripeConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orders := service.NewOrderService(st.orders, payments)
+
+	checkout, err := service.NewCheckoutService(st.customers, carts, payments, orders, st.audit, checkoutConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(lic.keys) == 0 {
+		logger.Warn("licenses are signed with a random key", "reason", licenseKeyFilesEnv+" is not set")
+	}
+
+	ring, err := service.NewLicenseKeyRing(lic.keys...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	licenses, err := service.NewLicenseService(st.licenses, orders, st.audit, ring, lic.licenses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	activations, err := service.NewActivationService(st.activations, licenses, st.products, ring, lic.licenses,
+		lic.activations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trials, err := service.NewTrialService(st.customers, st.licenses, orders, activations, st.products,
+		lic.trialMetrics, lic.licenses, lic.trials)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "activation sweeper",
+		Component: infra.NewPeriodic(logger, "activation sweep", activationSweepInterval, func(ctx context.Context) error {
+			_, sweepErr := activations.Sweep(ctx)
+			return sweepErr
+		}),
+		DependsOn:   []string{"database"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to register activation sweeper: %w", err)
+	}
+
+	// Orders are moved first, so that a failure leaves the rest to be done
+	// again when the event is retried, and licenses follow their order.
+	// Trials are converted before licenses are issued for the rest.
+	webhooks.Handle(domain.EventCheckoutCompleted, orders.PayOrder)
+	webhooks.Handle(domain.EventCheckoutCompleted, trials.ConvertTrials)
+	webhooks.Handle(domain.EventCheckoutCompleted, licenses.IssueLicenses)
+	webhooks.Handle(domain.EventCheckoutCompleted, checkout.CompleteCheckout)
+	webhooks.Handle(domain.EventCheckoutAsyncPaymentPaid, orders.PayOrder)
+	webhooks.Handle(domain.EventCheckoutAsyncPaymentPaid, trials.ConvertTrials)
+	webhooks.Handle(domain.EventCheckoutAsyncPaymentPaid, licenses.IssueLicenses)
+	webhooks.Handle(domain.EventCheckoutAsyncPaymentPaid, checkout.CompleteCheckout)
+	webhooks.Handle(domain.EventCheckoutAsyncPaymentFailed, orders.CancelOrder)
+	webhooks.Handle(domain.EventCheckoutExpired, orders.CancelOrder)
+	webhooks.Handle(domain.EventChargeRefunded, orders.RefundOrder)
+	webhooks.Handle(domain.EventChargeRefunded, licenses.RevokeRefunded)
+	webhooks.Handle(domain.EventChargeRefunded, checkout.RecordRefund)
+	webhooks.Handle(domain.EventDisputeCreated, orders.DisputeOrder)
+	webhooks.Handle(domain.EventDisputeCreated, checkout.RecordDispute)
+	webhooks.Handle(domain.EventDisputeClosed, orders.DisputeOrder)
+	webhooks.Handle(domain.EventDisputeClosed, licenses.RevokeRefunded)
+	webhooks.Handle(domain.EventDisputeClosed, checkout.RecordDispute)
+
+	web := slices.Concat(
+		server.NewCheckoutRoutes(logger, sessions, checkout),
+		server.NewOrderRoutes(logger, sessions, orders),
+		server.NewLicenseRoutes(logger, sessions, verifications, licenses),
+		server.NewActivationRoutes(logger, sessions, verifications, activations),
+		server.NewTrialRoutes(logger, sessions, verifications, trials),
+	)
+	admin := slices.Concat(
+		server.NewOrderAdminRoutes(logger, authz, orders),
+		server.NewLicenseAdminRoutes(logger, authz, twoFactor, licenses),
+		server.NewActivationAdminRoutes(logger, authz, activations),
+	)
+
+	return web, admin, nil
+}
+
+// newWebhookService returns the service processing Stripe webhook events.
+func newWebhookService(
+	inbox ports.WebhookInbox,
+	stripeWebhookConfig adapters.StripeWebhookConfig,
+	webhookConfig service.WebhookConfig,
+) (*service.WebhookService, error) {
+	stripe, err := adapters.NewStripeWebhook(stripeWebhookConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.NewWebhookService(inbox, stripe, webhookConfig)
+}
+
+// registerPasswordResetJobs registers the queue that mails the password
+// reset links requested, and the sweep of stale request counts. It returns
+// the queue.
+func registerPasswordResetJobs(
+	lc *infra.Lifecycle,
+	logger server.Logger,
+	passwords *service.PasswordService,
+) (*infra.Queue[domain.RegisteredCustomerEmail], error) {
+	queue := infra.NewQueue(logger, "password reset", resetQueueSize, resetRequestTimeout, passwords.RequestReset)
+
+	err := lc.Register(infra.Registration{
+		Name:        "password reset queue",
+		Component:   queue,
+		DependsOn:   []string{"database"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to register password reset queue: %w", err)
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "password reset sweeper",
+		Component: infra.NewPeriodic(logger, "password reset sweep", resetSweepInterval, func(ctx context.Context) error {
+			_, sweepErr := passwords.SweepRequests(ctx)
+			return sweepErr
+		}),
+		DependsOn:   []string{"logger"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to register password reset sweeper: %w", err)
+	}
+
+	return queue, nil
+}
+
+// registerWebhookJobs registers the periodic dispatch of webhook events,
+// and the sweep of old ones.
+func registerWebhookJobs(lc *infra.Lifecycle, logger server.Logger, webhooks *service.WebhookService) error {
+	err := lc.Register(infra.Registration{
+		Name: "webhook dispatcher",
+		Component: infra.NewPeriodic(logger, "webhook dispatch", webhookDispatchInterval, func(ctx context.Context) error {
+			_, dispatchErr := webhooks.Dispatch(ctx)
+			return dispatchErr
+		}),
+		DependsOn:   []string{"database"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register webhook dispatcher: %w", err)
+	}
+
+	err = lc.Register(infra.Registration{
+		Name: "webhook sweeper",
+		Component: infra.NewPeriodic(logger, "webhook sweep", webhookSweepInterval, func(ctx context.Context) error {
+			_, sweepErr := webhooks.Sweep(ctx)
+			return sweepErr
+		}),
+		DependsOn:   []string{"database"},
+		StopTimeout: 0,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register webhook sweeper: %w", err)
+	}
+
+	return nil
+}
+
+// newWebhookRoutes returns the routes Stripe delivers webhook events to.
+// Without signing secrets, deliveries are not received and there are none.
+func newWebhookRoutes(
+	logger server.Logger,
+	webhooks *service.WebhookService,
+	config adapters.StripeWebhookConfig,
+) []server.HTTPRoute {
+	if len(config.Secrets) == 0 {
+		logger.Warn("stripe webhooks are disabled", "reason", stripeWebhookSecretsEnv+" is not set")
+		return nil
+	}
+
+	return server.NewWebhookRoutes(logger, webhooks)
+}
+
+// newTwoFactorConfig returns the configuration of two-factor
+// authentication. Without a key in the environment, a random one is drawn
+// when data is kept in memory, and the lack of one is an error otherwise.
+func newTwoFactorConfig() (service.TwoFactorConfig, error) {
+	const (
+		maxAttempts = 5
+		lockout     = 15 * time.Minute
+	)
+
+	key := make([]byte, encryption.KeyLength)
+
+	encoded := os.Getenv(twoFactorKeyEnv)
+	switch {
+	case encoded != "":
+		var err error
+
+		key, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return service.TwoFactorConfig{}, fmt.Errorf("%s is not base64: %w", twoFactorKeyEnv, err)
+		}
+	case os.Getenv(databaseURLEnv) != "":
+		return service.TwoFactorConfig{}, fmt.Errorf("%s must be set with %s", twoFactorKeyEnv, databaseURLEnv)
+	default:
+		_, _ = rand.Read(key)
+	}
+
+	return service.TwoFactorConfig{
+		EncryptionSecret: key,
+		Issuer:           twoFactorIssuer,
+		Params:           otp.DefaultParams(),
+		ChallengeTTL:     5 * time.Minute,
+		StepUpTTL:        10 * time.Minute,
+		MaxAttempts:      maxAttempts,
+		Lockout:          lockout,
+	}, nil
+}
+
+// newDeviceConfig returns the configuration of the device authorization
+// grant, with the plugins allowed to use it.
+func newDeviceConfig() service.DeviceConfig {
+	const (
+		codeTTL         = 10 * time.Minute
+		interval        = 5 * time.Second
+		accessTokenTTL  = time.Hour
+		refreshTokenTTL = 90 * 24 * time.Hour
+	)
+
+	return service.DeviceConfig{
+		Clients: []service.DeviceClient{
+			{ID: "microwave", Name: "Microwave", Scopes: []string{"profile", "licenses"}},
+		},
+		VerificationURI: deviceVerificationURI,
+		CodeTTL:         codeTTL,
+		Interval:        interval,
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+// newTokenConfig returns the configuration of access tokens and the keys
+// loaded from the files listed in the environment, if any. Keys loaded
+// from files do not rotate.
+func newTokenConfig() (service.TokenConfig, []jwt.Key, error) {
+	const (
+		ttl      = 10 * time.Minute
+		rotation = 24 * time.Hour
+	)
+
+	config := service.TokenConfig{
+		Issuer:   tokenIssuer,
+		Audience: tokenAudience,
+		TTL:      ttl,
+		Rotation: rotation,
+	}
+
+	paths := os.Getenv(tokenKeyFilesEnv)
+	if paths == "" {
+		return config, nil, nil
+	}
+
+	keys, err := jwt.LoadKeys(filepath.SplitList(paths)...)
+	if err != nil {
+		return service.TokenConfig{}, nil, fmt.Errorf("failed to load %s: %w", tokenKeyFilesEnv, err)
+	}
+
+	config.Rotation = 0
+
+	return config, keys, nil
+}
+
+// newLicenseConfig returns the configuration of plugin licenses and the
+// keys loaded from the files listed in the environment. Without them, the
+// license service signs with a random key.
+func newLicenseConfig() (service.LicenseConfig, []jwt.Key, error) {
+	config := service.LicenseConfig{
+		Issuer:   tokenIssuer,
+		Validity: 0,
+	}
+
+	paths := os.Getenv(licenseKeyFilesEnv)
+	if paths == "" {
+		if os.Getenv(databaseURLEnv) != "" {
+			return service.LicenseConfig{}, nil, fmt.Errorf("%s must be set with %s", licenseKeyFilesEnv, databaseURLEnv)
+		}
+
+		return config, nil, nil
+	}
+
+	keys, err := jwt.LoadKeys(filepath.SplitList(paths)...)
+	if err != nil {
+		return service.LicenseConfig{}, nil, fmt.Errorf("failed to load %s: %w", licenseKeyFilesEnv, err)
+	}
+
+	return config, keys, nil
+}
+
+// licensing gathers the configuration of licenses, with the keys that sign
+// them, of their activations and of trials.
+type licensing struct {
+	licenses     service.LicenseConfig
+	keys         []jwt.Key
+	activations  service.ActivationConfig
+	trials       service.TrialConfig
+	trialMetrics service.TrialMetrics
+}
+
+// newLicensing returns the configuration of licenses, their activations
+// and trials, whose counters are created with tel.
+func newLicensing(tel telemetry.Telemetry) (licensing, error) {
+	config, keys, err := newLicenseConfig()
+	if err != nil {
+		return licensing{}, err
+	}
+
+	started, err := tel.Counter(telemetry.MetricTrialsStarted)
+	if err != nil {
+		return licensing{}, err
+	}
+
+	converted, err := tel.Counter(telemetry.MetricTrialsConverted)
+	if err != nil {
+		return licensing{}, err
+	}
+
+	return licensing{
+		licenses:     config,
+		keys:         keys,
+		activations:  newActivationConfig(),
+		trials:       service.TrialConfig{Duration: 14 * 24 * time.Hour},
+		trialMetrics: service.TrialMetrics{Started: started, Converted: converted},
+	}, nil
+}
+
+// newActivationConfig returns the configuration of license activations.
+// Plugins refresh their activation weekly, and machines unseen for a
+// quarter are freed.
+func newActivationConfig() service.ActivationConfig {
+	return service.ActivationConfig{
+		TokenTTL:        7 * 24 * time.Hour,
+		Inactivity:      90 * 24 * time.Hour,
+		AnomalyMachines: 10,
+		AnomalyWindow:   30 * 24 * time.Hour,
+	}
+}
+
+// newPasswordResetConfig returns the configuration of password resets.
+func newPasswordResetConfig() service.PasswordResetConfig {
+	const (
+		ipLimit    = 20
+		emailLimit = 5
+	)
+
+	return service.PasswordResetConfig{
+		TTL:            30 * time.Minute,
+		ResendInterval: time.Minute,
+		LinkURL:        passwordResetLinkURL,
+		IPLimit:        ipLimit,
+		EmailLimit:     emailLimit,
+		RequestWindow:  time.Hour,
+	}
+}
+
+// newSessionConfig returns the lifetime of customer sessions.
+func newSessionConfig() service.SessionConfig {
+	const (
+		idleTimeout      = 30 * 24 * time.Hour
+		absoluteLifetime = 90 * 24 * time.Hour
+	)
+
+	return service.SessionConfig{
+		IdleTimeout:      idleTimeout,
+		AbsoluteLifetime: absoluteLifetime,
+	}
+}
+
+// newSecurityPolicies returns the browser security of the web routes,
+// called from the storefront across origins, and of the admin routes,
+// which are same-origin only.
+func newSecurityPolicies(env backend.Environment) (server.SecurityPolicy, server.SecurityPolicy) {
+	const (
+		hstsMaxAge    = 365 * 24 * time.Hour
+		preflightAge  = 10 * time.Minute
+		contentPolicy = "default-src 'none'; frame-ancestors 'none'"
+	)
+
+	origins := []string{storefrontOrigin}
+	headers := server.HeadersConfig{
+		HSTSMaxAge:            hstsMaxAge,
+		ContentSecurityPolicy: contentPolicy,
+		ReferrerPolicy:        "no-referrer",
+	}
+
+	if env == backend.EnvDevelopment {
+		origins = append(origins, developmentOrigin)
+		headers.HSTSMaxAge = 0
+	}
+
+	csrf := &server.CSRFConfig{AllowedOrigins: origins}
+
+	web := server.SecurityPolicy{
+		Headers: headers,
+		CORS: &server.CORSConfig{
+			AllowedOrigins:   origins,
+			AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+			AllowedHeaders:   []string{"Content-Type", "Authorization", server.CSRFHeaderName},
+			AllowCredentials: true,
+			MaxAge:           preflightAge,
+		},
+		CSRF: csrf,
+	}
+
+	admin := server.SecurityPolicy{
+		Headers: headers,
+		CORS:    nil,
+		CSRF:    csrf,
+	}
+
+	return web, admin
+}
+
+// newFeatureFlags returns the feature flags of the app. No feature is behind
+// a flag yet.
+func newFeatureFlags() server.StaticFlags {
+	return server.StaticFlags{}
+}
+
+// ringRoutes returns the admin routes serving the recent logs kept in core.
+func ringRoutes(core *loggers.RingCore) []server.HTTPRoute {
+	rr := loggers.NewRingRoutes("/debug/logs", core)
+
+	routes := make([]server.HTTPRoute, 0, len(rr))
+	for _, r := range rr {
+		routes = append(routes, r)
+	}
+
+	return routes
+}
+
+// newTelemetryConfig returns the telemetry configuration of the app. Until a
+// collector is deployed, telemetry is appended to a file in the temporary
+// directory.
+func newTelemetryConfig() *telemetry.Config {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return &telemetry.Config{
+		ServiceName:    "app",
+		ServiceVersion: version,
+		ServiceID:      hostname,
+		ExporterConfig: telemetry.NewExporterConfig(
+			telemetry.ExporterTypeStdout,
+			filepath.Join(os.TempDir(), "brokedaear-app-telemetry.jsonl"),
+			false,
+			nil,
+		),
+	}
+}
+
+// newMonitorConfig returns the thresholds past which the runtime monitor
+// raises alerts.
+func newMonitorConfig() infra.MonitorConfig {
+	const (
+		goroutines    = 10_000
+		heapBytes     = 1 << 30
+		fdPercent     = 80
+		probeFailures = 3
+	)
+
+	return infra.MonitorConfig{
+		Interval:     15 * time.Second,
+		ProbeTimeout: 5 * time.Second,
+		Thresholds: infra.MonitorThresholds{
+			Goroutines:    goroutines,
+			HeapBytes:     heapBytes,
+			GCPause:       100 * time.Millisecond,
+			FDPercent:     fdPercent,
+			ProbeFailures: probeFailures,
+		},
+	}
+}
+
+// newAlertNotifiers returns the notifiers of monitor alerts: the logger, and
+// the webhook configured by the environment, if any.
+func newAlertNotifiers(logger server.Logger) ([]infra.Notifier, error) {
+	notifiers := []infra.Notifier{infra.NewLogNotifier(logger)}
+
+	webhookURL := os.Getenv(alertWebhookURLEnv)
+	if webhookURL == "" {
+		return notifiers, nil
+	}
+
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("%s must be an http or https URL", alertWebhookURLEnv)
+	}
+
+	return append(notifiers, infra.NewWebhookNotifier(webhookURL, alertWebhookTimeout)), nil
+}
+
+// newLogSamplingConfig returns the sampling applied to production logs. Error
+// logs are never sampled; floods of those are collapsed by deduplication.
+func newLogSamplingConfig() *loggers.ZapSamplingConfig {
+	const (
+		first      = 100
+		thereafter = 100
+	)
+
+	return &loggers.ZapSamplingConfig{
+		Tick: time.Second,
+		Levels: map[zapcore.Level]loggers.ZapLevelSampling{
+			zapcore.DebugLevel: {First: first, Thereafter: thereafter},
+			zapcore.InfoLevel:  {First: first, Thereafter: thereafter},
+			zapcore.WarnLevel:  {First: first, Thereafter: thereafter},
+		},
+	}
+}
+
+// newLogDedupeConfig returns the deduplication applied to production logs.
+func newLogDedupeConfig() *loggers.ZapDedupeConfig {
+	const maxKeys = 1024
+
+	return &loggers.ZapDedupeConfig{
+		Window:  10 * time.Second,
+		MaxKeys: maxKeys,
+	}
+}
+
+// newLogRingConfig returns the configuration of the in-memory buffer of
+// recent logs, which is kept for triage when no log aggregation is around.
+func newLogRingConfig() loggers.RingConfig {
+	const (
+		maxEntries = 2048
+		maxBytes   = 4 << 20
+	)
+
+	return loggers.RingConfig{
+		MaxEntries: maxEntries,
+		MaxBytes:   maxBytes,
+		Level:      zapcore.DebugLevel,
+	}
+}
+
+// runService starts every component of the lifecycle, serves until ctx is
+// canceled or a server fails, then stops the lifecycle.
+func runService(
+	ctx context.Context,
+	logger server.Logger,
+	lc *infra.Lifecycle,
+	s *appServer,
+	admin server.HTTPServer,
+) error {
+	err := lc.Start(ctx)
+	if err != nil {
+		logger.Error("failed to start components", "error", err)
+		return fmt.Errorf("failed to start components: %w", err)
+	}
+
 	g, gCtx := errgroup.WithContext(ctx)
 
 	g.Go(
 		func() error {
-			logger.Info("starting monitor server", "address", address, "port", port)
+			logger.Info("starting app server", "address", address, "port", port)
 			return s.Start(gCtx)
 		},
 	)
 
+	g.Go(
+		func() error {
+			logger.Info("starting admin server", "address", adminAddress, "port", adminPort)
+			return admin.ListenAndServe(gCtx)
+		},
+	)
+
 	g.Go(
 		func() error {
 			<-gCtx.Done()
 			logger.Info("shutdown signal received, initiating graceful shutdown")
 
-			const shutdownTimeout = 30 * time.Second
 			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
 			defer shutdownCancel()
 
-			select {
-			case <-shutdownCtx.Done():
-				logger.Warn("shutdown timeout exceeded, forcing exit")
-			default:
-			}
-
-			if teardownErr := infra.Teardown(s); teardownErr != nil {
-				logger.Error("error during teardown", "error", teardownErr)
-				return teardownErr
+			stopErr := lc.Stop(shutdownCtx)
+			if stopErr != nil {
+				logger.Error("error during shutdown", "error", stopErr)
+				return stopErr
 			}
 
 			logger.Info("graceful shutdown completed")
@@ -120,9 +1636,10 @@ func runService(ctx context.Context, logger server.Logger, s *appServer) error {
 		},
 	)
 
-	if err := g.Wait(); err != nil {
-		logger.Error("monitor service error", "error", err)
-		return fmt.Errorf("monitor service error: %w", err)
+	err = g.Wait()
+	if err != nil {
+		logger.Error("app service error", "error", err)
+		return fmt.Errorf("app service error: %w", err)
 	}
 
 	return nil
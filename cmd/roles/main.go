@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command roles grants and revokes the roles of customers, such as to make
+// the first admin, who can then manage roles from the admin routes. Roles
+// are only granted to existing customers. It
+// works on the database named by DATABASE_URL, and records its changes in
+// the audit trail like the admin routes do.
+//
+// Usage:
+//
+//	roles grant <customer-id> <role>
+//	roles revoke <customer-id> <role>
+//	roles list <customer-id>
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// databaseURLEnv names the environment variable holding the PostgreSQL
+// connection string of the app.
+const databaseURLEnv = "DATABASE_URL"
+
+const usage = `usage:
+  roles grant <customer-id> <role>
+  roles revoke <customer-id> <role>
+  roles list <customer-id>
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	err := run(ctx, os.Args[1:], os.Stdout)
+
+	stop()
+
+	if errors.Is(err, errUsage) {
+		_, _ = io.WriteString(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "roles: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+
+	command := args[0]
+
+	customerID, err := strconv.Atoi(args[1])
+	if err != nil || customerID <= 0 {
+		return fmt.Errorf("customer id %q is not a positive integer", args[1])
+	}
+
+	dsn := os.Getenv(databaseURLEnv)
+	if dsn == "" {
+		return fmt.Errorf("%s is not set", databaseURLEnv)
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = db.Close() }()
+
+	err = dal.Migrate(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	authz := service.NewAuthorizationService(dal.NewPostgreSQLRoles(db), dal.NewPostgreSQLAuditLog(db))
+
+	switch {
+	case command == "grant" && len(args) == 3:
+		// Customer IDs are not checked by the role store, and a role granted
+		// to an ID nobody holds yet would go to whoever signs up with it.
+		_, err = dal.NewPostgreSQLCustomers(db).CustomerByID(ctx, customerID)
+		if errors.Is(err, ports.ErrNotFound) {
+			return fmt.Errorf("customer %d does not exist", customerID)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return authz.Grant(ctx, customerID, domain.Role(args[2]), "")
+	case command == "revoke" && len(args) == 3:
+		return authz.Revoke(ctx, customerID, domain.Role(args[2]), "")
+	case command == "list" && len(args) == 2:
+		roles, err := authz.CustomerRoles(ctx, customerID)
+		if err != nil {
+			return err
+		}
+
+		for _, role := range roles {
+			_, err = fmt.Fprintln(out, role)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		return errUsage
+	}
+}
+
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+const errUsage usageError = "invalid usage"
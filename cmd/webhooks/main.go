@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command webhooks replays Stripe webhook events, to test their processing
+// locally and to recover from failures.
+//
+// Send delivers the events of files to a running app, signed with the
+// first secret of STRIPE_WEBHOOK_SECRETS like Stripe would, at WEBHOOK_URL
+// or the app running locally. Events the app received before are ignored
+// by it; replay those instead. Sample events are in app/data/webhooks.
+//
+// List and replay work on the inbox of the database named by DATABASE_URL,
+// which must have been migrated by an app of the same build; they never
+// migrate it. List shows the events with a status, dead by default, and
+// replay queues an event to be processed again by the app.
+//
+// Usage:
+//
+//	webhooks send <file>...
+//	webhooks list [pending|processed|dead]
+//	webhooks replay <event-id>
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/adapters"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+const (
+	// databaseURLEnv names the environment variable holding the PostgreSQL
+	// connection string of the app.
+	databaseURLEnv = "DATABASE_URL"
+
+	// stripeWebhookSecretsEnv names the environment variable holding the
+	// signing secrets of the webhook endpoint of the app, separated by
+	// commas.
+	stripeWebhookSecretsEnv = "STRIPE_WEBHOOK_SECRETS"
+
+	// webhookURLEnv names the environment variable holding the URL events
+	// are sent to. It defaults to defaultWebhookURL.
+	webhookURLEnv     = "WEBHOOK_URL"
+	defaultWebhookURL = "http://localhost:1025/webhooks/stripe"
+
+	// listLimit bounds how many events are listed.
+	listLimit = 100
+)
+
+const usage = `usage:
+  webhooks send <file>...
+  webhooks list [pending|processed|dead]
+  webhooks replay <event-id>
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	err := run(ctx, os.Args[1:], os.Stdout)
+
+	stop()
+
+	if errors.Is(err, errUsage) {
+		_, _ = io.WriteString(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "webhooks: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch command := args[0]; {
+	case command == "send" && len(args) >= 2:
+		return send(ctx, args[1:], out)
+	case command == "list" && len(args) <= 2:
+		status := domain.WebhookDead
+		if len(args) == 2 {
+			status = domain.WebhookStatus(args[1])
+		}
+
+		if !slices.Contains(domain.WebhookStatuses(), status) {
+			return errUsage
+		}
+
+		return list(ctx, status, out)
+	case command == "replay" && len(args) == 2:
+		return replay(ctx, args[1], out)
+	default:
+		return errUsage
+	}
+}
+
+// send delivers the events of files to the app, one at a time.
+func send(ctx context.Context, files []string, out io.Writer) error {
+	secret, _, _ := strings.Cut(os.Getenv(stripeWebhookSecretsEnv), ",")
+	if secret = strings.TrimSpace(secret); secret == "" {
+		return fmt.Errorf("%s is not set", stripeWebhookSecretsEnv)
+	}
+
+	url := os.Getenv(webhookURLEnv)
+	if url == "" {
+		url = defaultWebhookURL
+	}
+
+	client := &http.Client{
+		Transport:     nil,
+		CheckRedirect: nil,
+		Jar:           nil,
+		Timeout:       30 * time.Second,
+	}
+
+	for _, file := range files {
+		payload, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(adapters.StripeSignatureHeader, adapters.SignStripeWebhook(secret, payload, time.Now()))
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1<<10))
+		_ = res.Body.Close()
+
+		_, err = fmt.Fprintf(out, "%s: %s %s\n", file, res.Status, bytes.TrimSpace(body))
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("%s was rejected", file)
+		}
+	}
+
+	return nil
+}
+
+func list(ctx context.Context, status domain.WebhookStatus, out io.Writer) error {
+	return withWebhooks(ctx, func(webhooks *service.WebhookService) error {
+		events, err := webhooks.Events(ctx, status, listLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			_, err = fmt.Fprintf(out, "%s\t%s\t%s\t%d\t%s\n",
+				e.ID, e.Type, e.Received.Format(time.RFC3339), e.Attempts, e.LastError)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func replay(ctx context.Context, id string, out io.Writer) error {
+	return withWebhooks(ctx, func(webhooks *service.WebhookService) error {
+		e, err := webhooks.Replay(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(out, "%s is queued to be processed again\n", e.ID)
+
+		return err
+	})
+}
+
+// withWebhooks calls fn with the webhook service of the database of the
+// app, which must have the schema of this build. Its configuration only
+// matters to dispatching events, which is left to the app.
+func withWebhooks(ctx context.Context, fn func(*service.WebhookService) error) error {
+	dsn := os.Getenv(databaseURLEnv)
+	if dsn == "" {
+		return fmt.Errorf("%s is not set", databaseURLEnv)
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = db.Close() }()
+
+	// The schema is the app's to migrate. Working on one of another
+	// version could lose events or corrupt them.
+	err = dal.CheckSchema(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	stripe, err := adapters.NewStripeWebhook(adapters.StripeWebhookConfig{
+		Secrets:   nil,
+		Tolerance: 5 * time.Minute,
+	})
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := service.NewWebhookService(dal.NewPostgreSQLWebhookInbox(db), stripe, service.WebhookConfig{
+		MaxAttempts:   1,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: time.Minute,
+		Lease:         time.Minute,
+		BatchSize:     1,
+		Retention:     time.Hour,
+	})
+	if err != nil {
+		return err
+	}
+
+	return fn(webhooks)
+}
+
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+const errUsage usageError = "invalid usage"
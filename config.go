@@ -31,6 +31,10 @@ func (e Environment) String() string {
 	return e.v
 }
 
+func (e Environment) MarshalText() ([]byte, error) {
+	return []byte(e.v), nil
+}
+
 //nolint:gochecknoglobals // These simulate enums.
 var (
 	EnvDevelopment = Environment{"development"}
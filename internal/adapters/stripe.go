@@ -3,3 +3,488 @@
 // SPDX-License-Identifier: Apache-2.0
 
 package adapters
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+const (
+	// StripeBaseURL is the base URL of the Stripe API.
+	StripeBaseURL = "https://api.stripe.com"
+
+	// StripeAPIVersion is the version of the Stripe API requests are made
+	// with, pinned so that account settings cannot change the shape of
+	// responses under us.
+	StripeAPIVersion = "2025-06-30.basil"
+
+	// maxStripeResponseSize bounds the responses read from Stripe.
+	maxStripeResponseSize = 1 << 20
+)
+
+// StripeConfig configures the Stripe payment provider.
+type StripeConfig struct {
+	// APIKey is the secret API key of the Stripe account.
+	APIKey string `redact:"true"`
+
+	// BaseURL is the base URL of the Stripe API, StripeBaseURL but in tests.
+	BaseURL string
+
+	// MaxRetries is how many times a failed request is retried when Stripe
+	// says it is safe to.
+	MaxRetries int
+
+	// RetryDelay is the delay before the first retry. It doubles with every
+	// retry, up to MaxRetryDelay.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// Timeout bounds each attempt of a request.
+	Timeout time.Duration
+}
+
+func (c StripeConfig) Validate() error {
+	if c.APIKey == "" {
+		return ErrStripeAPIKey
+	}
+
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return ErrStripeBaseURL
+	}
+
+	if c.MaxRetries < 0 || c.RetryDelay <= 0 || c.MaxRetryDelay < c.RetryDelay || c.Timeout <= 0 {
+		return ErrStripeRetries
+	}
+
+	return nil
+}
+
+func (c StripeConfig) Value() any {
+	return c
+}
+
+// Stripe is a ports.PaymentProvider taking payments with Stripe Checkout.
+//
+// Every POST carries an idempotency key, the one of the request when it has
+// one and a random one otherwise, which is kept across retries so that a
+// retried request is never carried out twice. Requests are retried with
+// exponential backoff on network failures, rate limiting, conflicts and
+// server errors, unless Stripe says not to with the Stripe-Should-Retry
+// header.
+type Stripe struct {
+	config StripeConfig
+	client *http.Client
+}
+
+func NewStripe(config StripeConfig) (*Stripe, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &Stripe{
+		config: config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+		},
+	}, nil
+}
+
+type stripeCheckoutSession struct {
+	ID                string            `json:"id"`
+	URL               string            `json:"url"`
+	ClientReferenceID string            `json:"client_reference_id"`
+	Customer          string            `json:"customer"`
+	Status            string            `json:"status"`
+	PaymentStatus     string            `json:"payment_status"`
+	PaymentIntent     string            `json:"payment_intent"`
+	AmountTotal       int64             `json:"amount_total"`
+	Currency          string            `json:"currency"`
+	ExpiresAt         int64             `json:"expires_at"`
+	Metadata          map[string]string `json:"metadata"`
+}
+
+func (s stripeCheckoutSession) domain() domain.CheckoutSession {
+	customerID, _ := strconv.Atoi(s.Metadata["customer_id"])
+
+	return domain.CheckoutSession{
+		ID:                s.ID,
+		URL:               s.URL,
+		Reference:         s.ClientReferenceID,
+		CustomerID:        customerID,
+		PaymentCustomerID: s.Customer,
+		Status:            domain.CheckoutStatus(s.Status),
+		PaymentStatus:     domain.PaymentStatus(s.PaymentStatus),
+		PaymentID:         s.PaymentIntent,
+		Total:             stripeMoney(s.AmountTotal, s.Currency),
+		Expires:           time.Unix(s.ExpiresAt, 0).UTC(),
+	}
+}
+
+// CreateCheckoutSession opens a Checkout Session in payment mode. Lines
+// with a PriceID are charged that Stripe price; the others are charged
+// their UnitPrice, as prices made up for the session.
+func (s *Stripe) CreateCheckoutSession(
+	ctx context.Context,
+	req domain.CheckoutRequest,
+) (domain.CheckoutSession, error) {
+	form := url.Values{}
+	form.Set("mode", "payment")
+	form.Set("client_reference_id", req.Reference)
+	form.Set("success_url", req.SuccessURL)
+	form.Set("cancel_url", req.CancelURL)
+	form.Set("metadata[customer_id]", strconv.Itoa(req.CustomerID))
+
+	if req.Currency != "" {
+		// Selects the currency of prices with several.
+		form.Set("currency", strings.ToLower(string(req.Currency)))
+	}
+
+	if req.PaymentCustomerID != "" {
+		form.Set("customer", req.PaymentCustomerID)
+	}
+
+	for i, l := range req.Lines {
+		key := "line_items[" + strconv.Itoa(i) + "]"
+		form.Set(key+"[quantity]", strconv.Itoa(l.Quantity))
+
+		if l.PriceID != "" {
+			form.Set(key+"[price]", l.PriceID)
+			continue
+		}
+
+		form.Set(key+"[price_data][currency]", strings.ToLower(string(l.UnitPrice.Currency)))
+		form.Set(key+"[price_data][unit_amount]", strconv.FormatInt(l.UnitPrice.Amount, 10))
+
+		if l.ProductID != "" {
+			form.Set(key+"[price_data][product]", l.ProductID)
+		} else {
+			form.Set(key+"[price_data][product_data][name]", l.Name)
+		}
+	}
+
+	var res stripeCheckoutSession
+
+	err := s.do(ctx, http.MethodPost, "/v1/checkout/sessions", form, req.IdempotencyKey, &res)
+	if err != nil {
+		return domain.CheckoutSession{}, err
+	}
+
+	return res.domain(), nil
+}
+
+func (s *Stripe) CheckoutSession(ctx context.Context, id string) (domain.CheckoutSession, error) {
+	if id == "" {
+		return domain.CheckoutSession{}, ports.ErrNotFound
+	}
+
+	var res stripeCheckoutSession
+
+	err := s.do(ctx, http.MethodGet, "/v1/checkout/sessions/"+url.PathEscape(id), nil, "", &res)
+	if err != nil {
+		return domain.CheckoutSession{}, err
+	}
+
+	return res.domain(), nil
+}
+
+// ExpireCheckoutSession expires an open Checkout Session. Stripe refuses to
+// expire sessions that are over, which are looked up instead.
+func (s *Stripe) ExpireCheckoutSession(ctx context.Context, id string) (domain.CheckoutSession, error) {
+	if id == "" {
+		return domain.CheckoutSession{}, ports.ErrNotFound
+	}
+
+	var res stripeCheckoutSession
+
+	err := s.do(ctx, http.MethodPost, "/v1/checkout/sessions/"+url.PathEscape(id)+"/expire", nil, "", &res)
+	if errors.Is(err, ports.ErrPaymentInvalid) {
+		session, lookupErr := s.CheckoutSession(ctx, id)
+		if lookupErr == nil && session.Status != domain.CheckoutOpen {
+			return session, nil
+		}
+	}
+
+	if err != nil {
+		return domain.CheckoutSession{}, err
+	}
+
+	return res.domain(), nil
+}
+
+type stripeRefund struct {
+	ID            string `json:"id"`
+	PaymentIntent string `json:"payment_intent"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	Status        string `json:"status"`
+}
+
+// Refund refunds a payment intent.
+func (s *Stripe) Refund(ctx context.Context, req domain.RefundRequest) (domain.Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", req.PaymentID)
+
+	if !req.Amount.IsZero() {
+		form.Set("amount", strconv.FormatInt(req.Amount.Amount, 10))
+	}
+
+	if req.Reason != "" {
+		form.Set("reason", string(req.Reason))
+	}
+
+	var res stripeRefund
+
+	err := s.do(ctx, http.MethodPost, "/v1/refunds", form, req.IdempotencyKey, &res)
+	if err != nil {
+		return domain.Refund{}, err
+	}
+
+	return domain.Refund{
+		ID:        res.ID,
+		PaymentID: res.PaymentIntent,
+		Amount:    stripeMoney(res.Amount, res.Currency),
+		Status:    domain.RefundStatus(res.Status),
+	}, nil
+}
+
+type stripeCustomer struct {
+	ID string `json:"id"`
+}
+
+// SyncCustomer creates or updates the Stripe customer of c. Customers are
+// created with idempotencyKey, so that two checkouts racing for a customer
+// without one create a single Stripe customer.
+func (s *Stripe) SyncCustomer(ctx context.Context, c domain.Customer, idempotencyKey string) (string, error) {
+	form := url.Values{}
+	form.Set("email", c.Email)
+	form.Set("metadata[customer_id]", strconv.Itoa(c.ID))
+
+	path, key := "/v1/customers", idempotencyKey
+	if c.PaymentCustomerID != "" {
+		path, key = "/v1/customers/"+url.PathEscape(c.PaymentCustomerID), ""
+	}
+
+	var res stripeCustomer
+
+	err := s.do(ctx, http.MethodPost, path, form, key, &res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.ID, nil
+}
+
+// do sends a request to Stripe and decodes its JSON response into out,
+// retrying it when it is safe to.
+func (s *Stripe) do(ctx context.Context, method, path string, form url.Values, idempotencyKey string, out any) error {
+	if method == http.MethodPost && idempotencyKey == "" {
+		idempotencyKey = rand.Text()
+	}
+
+	body := form.Encode()
+
+	for attempt := 0; ; attempt++ {
+		retry, err := s.attempt(ctx, method, path, body, idempotencyKey, out)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= s.config.MaxRetries {
+			return err
+		}
+
+		timer := time.NewTimer(s.backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends a request to Stripe once, and tells whether it may be
+// retried when it fails.
+func (s *Stripe) attempt(
+	ctx context.Context,
+	method, path, body, idempotencyKey string,
+	out any,
+) (bool, error) {
+	var reader io.Reader
+	if method != http.MethodGet {
+		reader = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.config.BaseURL+path, reader)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	req.Header.Set("Stripe-Version", StripeAPIVersion)
+
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+
+		return true, fmt.Errorf("%w: %w", ports.ErrPaymentUnavailable, err)
+	}
+
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxStripeResponseSize))
+	if err != nil {
+		return true, fmt.Errorf("%w: %w", ports.ErrPaymentUnavailable, err)
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return shouldRetry(res), newStripeError(res, data)
+	}
+
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ports.ErrPaymentUnavailable, err)
+	}
+
+	return false, nil
+}
+
+// backoff returns the delay before retry number attempt, counting from 0:
+// RetryDelay doubled attempt times, capped at MaxRetryDelay, of which up to
+// half is random so that clients failing together do not retry together.
+func (s *Stripe) backoff(attempt int) time.Duration {
+	d := s.config.RetryDelay
+	for range attempt {
+		if d > s.config.MaxRetryDelay/2 {
+			d = s.config.MaxRetryDelay
+			break
+		}
+
+		d *= 2
+	}
+
+	half := d / 2 //nolint:mnd // half of the delay is jitter
+
+	return half + mrand.N(d-half+1) //nolint:gosec // jitter needs no cryptographic randomness
+}
+
+// shouldRetry tells whether a failed request may be retried, as told by
+// Stripe or else guessed from its status.
+func shouldRetry(res *http.Response) bool {
+	switch res.Header.Get("Stripe-Should-Retry") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return res.StatusCode == http.StatusConflict ||
+		res.StatusCode == http.StatusTooManyRequests ||
+		res.StatusCode >= http.StatusInternalServerError
+}
+
+// StripeError is an error answered by Stripe. It wraps the
+// ports.PaymentError, or ports.ErrNotFound, it stands for.
+type StripeError struct {
+	Kind      error
+	Status    int
+	Type      string
+	Code      string
+	Message   string
+	RequestID string
+}
+
+func (e *StripeError) Error() string {
+	return fmt.Sprintf("%s: stripe answered %d %s (code %q, request %s): %s",
+		e.Kind, e.Status, e.Type, e.Code, e.RequestID, e.Message)
+}
+
+func (e *StripeError) Unwrap() error {
+	return e.Kind
+}
+
+type stripeErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newStripeError(res *http.Response, data []byte) *StripeError {
+	var body stripeErrorResponse
+
+	// Bodies that are not Stripe errors, such as those of proxies, leave
+	// the error to be told by the status.
+	_ = json.Unmarshal(data, &body)
+
+	e := &StripeError{
+		Kind:      nil,
+		Status:    res.StatusCode,
+		Type:      body.Error.Type,
+		Code:      body.Error.Code,
+		Message:   body.Error.Message,
+		RequestID: res.Header.Get("Request-Id"),
+	}
+
+	switch {
+	case e.Type == "idempotency_error":
+		e.Kind = ports.ErrPaymentConflict
+	case e.Type == "card_error" || e.Status == http.StatusPaymentRequired:
+		e.Kind = ports.ErrPaymentDeclined
+	case e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden:
+		e.Kind = ports.ErrPaymentAuthentication
+	case e.Status == http.StatusNotFound || e.Code == "resource_missing":
+		e.Kind = ports.ErrNotFound
+	case e.Status == http.StatusConflict:
+		e.Kind = ports.ErrPaymentConflict
+	case e.Status == http.StatusTooManyRequests:
+		e.Kind = ports.ErrPaymentRateLimited
+	case e.Status >= http.StatusInternalServerError:
+		e.Kind = ports.ErrPaymentUnavailable
+	default:
+		e.Kind = ports.ErrPaymentInvalid
+	}
+
+	return e
+}
+
+type AdapterError string
+
+func (e AdapterError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrStripeAPIKey  AdapterError = "stripe API key is required"
+	ErrStripeBaseURL AdapterError = "stripe base URL must be an absolute HTTP URL"
+	ErrStripeRetries AdapterError = "stripe retries and timeout must be positive"
+)
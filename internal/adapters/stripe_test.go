@@ -0,0 +1,405 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package adapters_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/adapters"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// fakeStripe is a local Stripe API answering with the responses it is
+// given, one per request, and recording the requests it gets.
+type fakeStripe struct {
+	mu        sync.Mutex
+	responses []fakeResponse
+	requests  []fakeRequest
+}
+
+type fakeResponse struct {
+	status  int
+	headers map[string]string
+	body    string
+}
+
+type fakeRequest struct {
+	method  string
+	path    string
+	headers http.Header
+	form    url.Values
+}
+
+func newFakeStripe(t *testing.T, responses ...fakeResponse) (*fakeStripe, *adapters.Stripe) {
+	t.Helper()
+
+	f := &fakeStripe{
+		mu:        sync.Mutex{},
+		responses: responses,
+		requests:  nil,
+	}
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	stripe, err := adapters.NewStripe(adapters.StripeConfig{
+		APIKey:        "sk_test_123",
+		BaseURL:       srv.URL + "/",
+		MaxRetries:    2,
+		RetryDelay:    time.Millisecond,
+		MaxRetryDelay: 2 * time.Millisecond,
+		Timeout:       time.Second,
+	})
+	assert.NoError(t, err)
+
+	return f, stripe
+}
+
+func (f *fakeStripe) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	form, _ := url.ParseQuery(string(body))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, fakeRequest{
+		method:  r.Method,
+		path:    r.URL.Path,
+		headers: r.Header.Clone(),
+		form:    form,
+	})
+
+	if len(f.responses) == 0 {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res := f.responses[0]
+	f.responses = f.responses[1:]
+
+	for k, v := range res.headers {
+		w.Header().Set(k, v)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.status)
+	_, _ = io.WriteString(w, res.body)
+}
+
+func (f *fakeStripe) recorded() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.requests
+}
+
+const sessionJSON = `{
+	"id": "cs_test_1",
+	"url": "https://checkout.stripe.com/c/pay/cs_test_1",
+	"client_reference_id": "cart_1",
+	"customer": "cus_1",
+	"status": "open",
+	"payment_status": "unpaid",
+	"payment_intent": null,
+	"amount_total": 370368,
+	"currency": "eur",
+	"expires_at": 1767225600,
+	"metadata": {"customer_id": "7"}
+}`
+
+func TestStripe_CreateCheckoutSession(t *testing.T) {
+	f, stripe := newFakeStripe(t, fakeResponse{status: http.StatusOK, headers: nil, body: sessionJSON})
+
+	got, err := stripe.CreateCheckoutSession(t.Context(), domain.CheckoutRequest{
+		Reference:         "cart_1",
+		CustomerID:        7,
+		PaymentCustomerID: "cus_1",
+		Currency:          domain.CurrencyEUR,
+		Lines: []domain.CheckoutLine{
+			{
+				PriceID:   "price_microwave",
+				ProductID: "prod_microwave",
+				Name:      "Microwave",
+				UnitPrice: domain.Money{Amount: 123456, Currency: domain.CurrencyEUR},
+				Quantity:  1,
+			},
+			{
+				PriceID:   "",
+				ProductID: "prod_shirt",
+				Name:      "Shirt",
+				UnitPrice: domain.Money{Amount: 123456, Currency: domain.CurrencyEUR},
+				Quantity:  2,
+			},
+		},
+		SuccessURL:     "https://brokedaear.com/checkout/success",
+		CancelURL:      "https://brokedaear.com/cart",
+		IdempotencyKey: "checkout-cart_1",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, got.ID, "cs_test_1")
+	assert.Equal(t, got.Reference, "cart_1")
+	assert.Equal(t, got.CustomerID, 7)
+	assert.Equal(t, got.Status, domain.CheckoutOpen)
+	assert.Equal(t, got.PaymentStatus, domain.PaymentUnpaid)
+	assert.Equal(t, got.PaymentID, "")
+	assert.Equal(t, got.Total, domain.Money{Amount: 370368, Currency: domain.CurrencyEUR})
+	assert.Equal(t, got.Expires, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	reqs := f.recorded()
+	assert.Equal(t, len(reqs), 1)
+
+	req := reqs[0]
+	assert.Equal(t, req.method, http.MethodPost)
+	assert.Equal(t, req.path, "/v1/checkout/sessions")
+	assert.Equal(t, req.headers.Get("Authorization"), "Bearer sk_test_123")
+	assert.Equal(t, req.headers.Get("Stripe-Version"), adapters.StripeAPIVersion)
+	assert.Equal(t, req.headers.Get("Idempotency-Key"), "checkout-cart_1")
+
+	for key, want := range map[string]string{
+		"mode":                                    "payment",
+		"currency":                                "eur",
+		"client_reference_id":                     "cart_1",
+		"customer":                                "cus_1",
+		"metadata[customer_id]":                   "7",
+		"line_items[0][price]":                    "price_microwave",
+		"line_items[0][quantity]":                 "1",
+		"line_items[1][price]":                    "",
+		"line_items[1][price_data][currency]":     "eur",
+		"line_items[1][price_data][unit_amount]":  "123456",
+		"line_items[1][price_data][product]":      "prod_shirt",
+		"line_items[1][quantity]":                 "2",
+		"line_items[0][price_data][unit_amount]":  "",
+		"line_items[1][price_data][product_data]": "",
+	} {
+		assert.Equal(t, req.form.Get(key), want)
+	}
+}
+
+func TestStripe_Retries(t *testing.T) {
+	unavailable := fakeResponse{status: http.StatusServiceUnavailable, headers: nil, body: `{}`}
+	f, stripe := newFakeStripe(t,
+		unavailable,
+		fakeResponse{status: http.StatusTooManyRequests, headers: nil, body: `{"error":{"type":"invalid_request_error"}}`},
+		fakeResponse{status: http.StatusOK, headers: nil, body: `{"id":"cus_1"}`},
+	)
+
+	id, err := stripe.SyncCustomer(t.Context(), domain.Customer{
+		ID:                7,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          true,
+		PaymentCustomerID: "",
+	}, "customer-key")
+	assert.NoError(t, err)
+	assert.Equal(t, id, "cus_1")
+
+	// Retries reuse the idempotency key of the request.
+	reqs := f.recorded()
+	assert.Equal(t, len(reqs), 3)
+
+	for _, req := range reqs {
+		assert.Equal(t, req.path, "/v1/customers")
+		assert.Equal(t, req.headers.Get("Idempotency-Key"), "customer-key")
+		assert.Equal(t, req.form.Get("email"), "jane@example.com")
+	}
+
+	// Giving up returns the last error.
+	f, stripe = newFakeStripe(t, unavailable, unavailable, unavailable, unavailable)
+
+	_, err = stripe.CheckoutSession(t.Context(), "cs_test_1")
+	assert.Error(t, err, ports.ErrPaymentUnavailable)
+	assert.Equal(t, len(f.recorded()), 3)
+
+	// Stripe-Should-Retry overrides the status.
+	f, stripe = newFakeStripe(t, fakeResponse{
+		status:  http.StatusServiceUnavailable,
+		headers: map[string]string{"Stripe-Should-Retry": "false"},
+		body:    `{}`,
+	})
+
+	_, err = stripe.Refund(t.Context(), domain.RefundRequest{
+		PaymentID:      "pi_1",
+		Amount:         domain.Money{Amount: 0, Currency: domain.CurrencyEUR},
+		Reason:         "",
+		IdempotencyKey: "",
+	})
+	assert.Error(t, err, ports.ErrPaymentUnavailable)
+	assert.Equal(t, len(f.recorded()), 1)
+
+	// POSTs without an idempotency key get a random one.
+	assert.NotEqual(t, f.recorded()[0].headers.Get("Idempotency-Key"), "")
+}
+
+func TestStripe_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"declined", http.StatusPaymentRequired, `{"error":{"type":"card_error","code":"card_declined"}}`, ports.ErrPaymentDeclined},
+		{"invalid", http.StatusBadRequest, `{"error":{"type":"invalid_request_error","code":"parameter_missing"}}`, ports.ErrPaymentInvalid},
+		{"idempotency", http.StatusBadRequest, `{"error":{"type":"idempotency_error"}}`, ports.ErrPaymentConflict},
+		{"authentication", http.StatusUnauthorized, `{"error":{"type":"invalid_request_error"}}`, ports.ErrPaymentAuthentication},
+		{"missing", http.StatusNotFound, `{"error":{"type":"invalid_request_error","code":"resource_missing"}}`, ports.ErrNotFound},
+		{"not json", http.StatusBadRequest, `<html></html>`, ports.ErrPaymentInvalid},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, stripe := newFakeStripe(t, fakeResponse{
+				status:  tt.status,
+				headers: map[string]string{"Request-Id": "req_1"},
+				body:    tt.body,
+			})
+
+			_, err := stripe.Refund(t.Context(), domain.RefundRequest{
+				PaymentID:      "pi_1",
+				Amount:         domain.Money{Amount: 100, Currency: domain.CurrencyEUR},
+				Reason:         domain.RefundRequestedByCustomer,
+				IdempotencyKey: "refund-1",
+			})
+			assert.Error(t, err, tt.want)
+			assert.Equal(t, len(f.recorded()), 1)
+
+			var stripeErr *adapters.StripeError
+			assert.True(t, errors.As(err, &stripeErr))
+			assert.Equal(t, stripeErr.Status, tt.status)
+			assert.Equal(t, stripeErr.RequestID, "req_1")
+		})
+	}
+}
+
+func TestStripe_Refund(t *testing.T) {
+	f, stripe := newFakeStripe(t, fakeResponse{
+		status:  http.StatusOK,
+		headers: nil,
+		body:    `{"id":"re_1","payment_intent":"pi_1","amount":100,"currency":"eur","status":"succeeded"}`,
+	})
+
+	got, err := stripe.Refund(t.Context(), domain.RefundRequest{
+		PaymentID:      "pi_1",
+		Amount:         domain.Money{Amount: 100, Currency: domain.CurrencyEUR},
+		Reason:         domain.RefundRequestedByCustomer,
+		IdempotencyKey: "refund-1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, got, domain.Refund{
+		ID:        "re_1",
+		PaymentID: "pi_1",
+		Amount:    domain.Money{Amount: 100, Currency: domain.CurrencyEUR},
+		Status:    domain.RefundSucceeded,
+	})
+
+	req := f.recorded()[0]
+	assert.Equal(t, req.path, "/v1/refunds")
+	assert.Equal(t, req.form.Get("payment_intent"), "pi_1")
+	assert.Equal(t, req.form.Get("amount"), "100")
+	assert.Equal(t, req.form.Get("reason"), "requested_by_customer")
+}
+
+func TestStripe_CheckoutSession(t *testing.T) {
+	f, stripe := newFakeStripe(t, fakeResponse{status: http.StatusOK, headers: nil, body: sessionJSON})
+
+	got, err := stripe.CheckoutSession(t.Context(), "cs_test_1")
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, "cs_test_1")
+
+	req := f.recorded()[0]
+	assert.Equal(t, req.method, http.MethodGet)
+	assert.Equal(t, req.path, "/v1/checkout/sessions/cs_test_1")
+	assert.Equal(t, req.headers.Get("Idempotency-Key"), "")
+
+	_, err = stripe.CheckoutSession(t.Context(), "")
+	assert.Error(t, err, ports.ErrNotFound)
+}
+
+func TestStripe_ExpireCheckoutSession(t *testing.T) {
+	expired := strings.Replace(sessionJSON, `"status": "open"`, `"status": "expired"`, 1)
+	f, stripe := newFakeStripe(t, fakeResponse{status: http.StatusOK, headers: nil, body: expired})
+
+	got, err := stripe.ExpireCheckoutSession(t.Context(), "cs_test_1")
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.CheckoutExpired)
+
+	req := f.recorded()[0]
+	assert.Equal(t, req.method, http.MethodPost)
+	assert.Equal(t, req.path, "/v1/checkout/sessions/cs_test_1/expire")
+
+	// Sessions completed meanwhile cannot be expired, and are returned as
+	// they are.
+	complete := strings.Replace(sessionJSON, `"status": "open"`, `"status": "complete"`, 1)
+	_, stripe = newFakeStripe(t,
+		fakeResponse{status: http.StatusBadRequest, headers: nil, body: `{"error":{"type":"invalid_request_error"}}`},
+		fakeResponse{status: http.StatusOK, headers: nil, body: complete},
+	)
+
+	got, err = stripe.ExpireCheckoutSession(t.Context(), "cs_test_1")
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.CheckoutComplete)
+
+	_, err = stripe.ExpireCheckoutSession(t.Context(), "")
+	assert.Error(t, err, ports.ErrNotFound)
+}
+
+func TestStripe_SyncCustomer(t *testing.T) {
+	f, stripe := newFakeStripe(t, fakeResponse{status: http.StatusOK, headers: nil, body: `{"id":"cus_1"}`})
+
+	id, err := stripe.SyncCustomer(t.Context(), domain.Customer{
+		ID:                7,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          true,
+		PaymentCustomerID: "cus_1",
+	}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, id, "cus_1")
+
+	req := f.recorded()[0]
+	assert.Equal(t, req.path, "/v1/customers/cus_1")
+	assert.Equal(t, req.form.Get("email"), "jane@example.com")
+	assert.NotEqual(t, req.headers.Get("Idempotency-Key"), "")
+}
+
+func TestStripeConfig_Validate(t *testing.T) {
+	valid := adapters.StripeConfig{
+		APIKey:        "sk_test_123",
+		BaseURL:       adapters.StripeBaseURL,
+		MaxRetries:    2,
+		RetryDelay:    time.Second,
+		MaxRetryDelay: time.Minute,
+		Timeout:       time.Second,
+	}
+	assert.NoError(t, valid.Validate())
+
+	for _, tt := range []struct {
+		name   string
+		modify func(c *adapters.StripeConfig)
+		want   error
+	}{
+		{"no key", func(c *adapters.StripeConfig) { c.APIKey = "" }, adapters.ErrStripeAPIKey},
+		{"relative url", func(c *adapters.StripeConfig) { c.BaseURL = "/v1" }, adapters.ErrStripeBaseURL},
+		{"negative retries", func(c *adapters.StripeConfig) { c.MaxRetries = -1 }, adapters.ErrStripeRetries},
+		{"max below delay", func(c *adapters.StripeConfig) { c.MaxRetryDelay = time.Millisecond }, adapters.ErrStripeRetries},
+		{"no timeout", func(c *adapters.StripeConfig) { c.Timeout = 0 }, adapters.ErrStripeRetries},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.modify(&c)
+			assert.Error(t, c.Validate(), tt.want)
+		})
+	}
+}
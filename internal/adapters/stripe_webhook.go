@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package adapters
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// StripeSignatureHeader is the header carrying the signature of Stripe
+// webhook deliveries.
+const StripeSignatureHeader = "Stripe-Signature"
+
+// StripeWebhookConfig configures the verification of Stripe webhook
+// deliveries.
+type StripeWebhookConfig struct {
+	// Secrets are the signing secrets of the webhook endpoint. While a
+	// secret is rolled, both the old and the new one are listed, and
+	// deliveries signed with either are accepted. Without any, no delivery
+	// is.
+	Secrets []string `redact:"true"`
+
+	// Tolerance is how far the timestamp of a delivery may be from now,
+	// which bounds how long a captured delivery can be replayed.
+	Tolerance time.Duration
+}
+
+func (c StripeWebhookConfig) Validate() error {
+	if c.Tolerance <= 0 {
+		return ErrStripeWebhookTolerance
+	}
+
+	return nil
+}
+
+func (c StripeWebhookConfig) Value() any {
+	return c
+}
+
+// StripeWebhook is a ports.PaymentWebhook reading Stripe webhook
+// deliveries.
+type StripeWebhook struct {
+	config StripeWebhookConfig
+	now    func() time.Time
+}
+
+func NewStripeWebhook(config StripeWebhookConfig) (*StripeWebhook, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &StripeWebhook{
+		config: config,
+		now:    time.Now,
+	}, nil
+}
+
+// VerifyEvent checks the Stripe-Signature header of a delivery: one of its
+// v1 signatures must be the HMAC-SHA256, keyed by one of the secrets, of
+// its timestamp and payload, and its timestamp must be within the
+// tolerance.
+func (w *StripeWebhook) VerifyEvent(payload []byte, signature string) (domain.PaymentEvent, error) {
+	var (
+		timestamp  string
+		signatures [][]byte
+	)
+
+	for part := range strings.SplitSeq(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			sig, err := hex.DecodeString(value)
+			if err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return domain.PaymentEvent{}, ports.ErrWebhookSignature
+	}
+
+	age := w.now().Sub(time.Unix(unix, 0))
+	if age > w.config.Tolerance || age < -w.config.Tolerance {
+		return domain.PaymentEvent{}, fmt.Errorf("%w: timestamp is %s old", ports.ErrWebhookSignature, age)
+	}
+
+	for _, secret := range w.config.Secrets {
+		want := stripeSignature(secret, timestamp, payload)
+
+		for _, sig := range signatures {
+			if hmac.Equal(sig, want) {
+				return w.ParseEvent(payload)
+			}
+		}
+	}
+
+	return domain.PaymentEvent{}, ports.ErrWebhookSignature
+}
+
+// SignStripeWebhook returns the Stripe-Signature header Stripe would send
+// with payload at t, signed with secret, to replay events locally.
+func SignStripeWebhook(secret string, payload []byte, t time.Time) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(stripeSignature(secret, timestamp, payload))
+}
+
+func stripeSignature(secret, timestamp string, payload []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(payload)
+
+	return h.Sum(nil)
+}
+
+type stripeEvent struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Created int64           `json:"created"`
+	Data    stripeEventData `json:"data"`
+}
+
+type stripeEventData struct {
+	Object json.RawMessage `json:"object"`
+}
+
+type stripeCharge struct {
+	ID             string `json:"id"`
+	PaymentIntent  string `json:"payment_intent"`
+	Amount         int64  `json:"amount"`
+	AmountRefunded int64  `json:"amount_refunded"`
+	Currency       string `json:"currency"`
+	Refunded       bool   `json:"refunded"`
+}
+
+type stripeDispute struct {
+	ID            string `json:"id"`
+	Charge        string `json:"charge"`
+	PaymentIntent string `json:"payment_intent"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	Reason        string `json:"reason"`
+	Status        string `json:"status"`
+}
+
+// ParseEvent decodes a Stripe event, and the checkout session, charge or
+// dispute it is about.
+func (w *StripeWebhook) ParseEvent(payload []byte) (domain.PaymentEvent, error) {
+	var e stripeEvent
+
+	err := json.Unmarshal(payload, &e)
+	if err != nil || e.ID == "" || e.Type == "" {
+		return domain.PaymentEvent{}, fmt.Errorf("%w: not a stripe event", ErrStripeEvent)
+	}
+
+	event := domain.PaymentEvent{
+		ID:       e.ID,
+		Type:     domain.PaymentEventType(e.Type),
+		Created:  time.Unix(e.Created, 0).UTC(),
+		Checkout: nil,
+		Charge:   nil,
+		Dispute:  nil,
+	}
+
+	switch {
+	case strings.HasPrefix(e.Type, "checkout.session."):
+		var s stripeCheckoutSession
+
+		err = json.Unmarshal(e.Data.Object, &s)
+		if err == nil {
+			session := s.domain()
+			event.Checkout = &session
+		}
+	case strings.HasPrefix(e.Type, "charge.dispute."):
+		var d stripeDispute
+
+		err = json.Unmarshal(e.Data.Object, &d)
+		if err == nil {
+			event.Dispute = &domain.Dispute{
+				ID:        d.ID,
+				ChargeID:  d.Charge,
+				PaymentID: d.PaymentIntent,
+				Amount:    stripeMoney(d.Amount, d.Currency),
+				Reason:    d.Reason,
+				Status:    d.Status,
+			}
+		}
+	case strings.HasPrefix(e.Type, "charge."):
+		var c stripeCharge
+
+		err = json.Unmarshal(e.Data.Object, &c)
+		if err == nil {
+			event.Charge = &domain.Charge{
+				ID:            c.ID,
+				PaymentID:     c.PaymentIntent,
+				Amount:        stripeMoney(c.Amount, c.Currency),
+				Refunded:      stripeMoney(c.AmountRefunded, c.Currency),
+				FullyRefunded: c.Refunded,
+			}
+		}
+	}
+
+	if err != nil {
+		return domain.PaymentEvent{}, fmt.Errorf("%w: %s has a malformed object: %w", ErrStripeEvent, e.ID, err)
+	}
+
+	return event, nil
+}
+
+func stripeMoney(amount int64, currency string) domain.Money {
+	return domain.Money{Amount: amount, Currency: domain.Currency(strings.ToUpper(currency))}
+}
+
+const (
+	ErrStripeWebhookTolerance AdapterError = "stripe webhook tolerance must be positive"
+	ErrStripeEvent            AdapterError = "malformed stripe event"
+)
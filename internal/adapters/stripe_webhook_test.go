@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package adapters_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/adapters"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+const checkoutCompletedJSON = `{
+	"id": "evt_1",
+	"type": "checkout.session.completed",
+	"created": 1767225600,
+	"data": {"object": {
+		"id": "cs_test_1",
+		"client_reference_id": "cart_1",
+		"customer": "cus_1",
+		"status": "complete",
+		"payment_status": "paid",
+		"payment_intent": "pi_1",
+		"amount_total": 4900,
+		"currency": "usd",
+		"metadata": {"customer_id": "7"}
+	}}
+}`
+
+func newStripeWebhook(t *testing.T, secrets ...string) *adapters.StripeWebhook {
+	t.Helper()
+
+	w, err := adapters.NewStripeWebhook(adapters.StripeWebhookConfig{
+		Secrets:   secrets,
+		Tolerance: 5 * time.Minute,
+	})
+	assert.NoError(t, err)
+
+	return w
+}
+
+func TestStripeWebhook_VerifyEvent(t *testing.T) {
+	w := newStripeWebhook(t, "whsec_old", "whsec_new")
+	payload := []byte(checkoutCompletedJSON)
+	now := time.Now()
+
+	// Deliveries signed with any of the secrets are accepted.
+	for _, secret := range []string{"whsec_old", "whsec_new"} {
+		e, err := w.VerifyEvent(payload, adapters.SignStripeWebhook(secret, payload, now))
+		assert.NoError(t, err)
+		assert.Equal(t, e.ID, "evt_1")
+	}
+
+	// Stripe sends a signature per secret while a secret is rolled.
+	_, v1, _ := strings.Cut(adapters.SignStripeWebhook("whsec_new", payload, now), ",")
+	_, err := w.VerifyEvent(payload, adapters.SignStripeWebhook("whsec_unknown", payload, now)+","+v1)
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name      string
+		signature string
+	}{
+		{"unknown secret", adapters.SignStripeWebhook("whsec_unknown", payload, now)},
+		{"too old", adapters.SignStripeWebhook("whsec_new", payload, now.Add(-10*time.Minute))},
+		{"too new", adapters.SignStripeWebhook("whsec_new", payload, now.Add(10*time.Minute))},
+		{"no timestamp", "v1=00"},
+		{"no signature", "t=1767225600"},
+		{"empty", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := w.VerifyEvent(payload, tt.signature)
+			assert.Error(t, err, ports.ErrWebhookSignature)
+		})
+	}
+
+	// Tampered payloads do not match their signature.
+	signature := adapters.SignStripeWebhook("whsec_new", payload, now)
+	_, err = w.VerifyEvent([]byte(checkoutCompletedJSON+" "), signature)
+	assert.Error(t, err, ports.ErrWebhookSignature)
+
+	// Without secrets, nothing is accepted.
+	_, err = newStripeWebhook(t).VerifyEvent(payload, signature)
+	assert.Error(t, err, ports.ErrWebhookSignature)
+}
+
+func TestStripeWebhook_ParseEvent(t *testing.T) {
+	w := newStripeWebhook(t, "whsec_new")
+
+	e, err := w.ParseEvent([]byte(checkoutCompletedJSON))
+	assert.NoError(t, err)
+	assert.Equal(t, e.Type, domain.EventCheckoutCompleted)
+	assert.Equal(t, e.Created, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, e.Charge == nil)
+	assert.Equal(t, e.Checkout.Reference, "cart_1")
+	assert.Equal(t, e.Checkout.CustomerID, 7)
+	assert.Equal(t, e.Checkout.PaymentStatus, domain.PaymentPaid)
+	assert.Equal(t, e.Checkout.PaymentID, "pi_1")
+	assert.Equal(t, e.Checkout.Total, domain.Money{Amount: 4900, Currency: domain.CurrencyUSD})
+
+	e, err = w.ParseEvent([]byte(`{"id":"evt_2","type":"charge.refunded","created":1767225600,"data":{"object":{
+		"id":"ch_1","payment_intent":"pi_1","amount":4900,"amount_refunded":4900,"currency":"usd","refunded":true}}}`))
+	assert.NoError(t, err)
+	assert.True(t, e.Checkout == nil)
+	assert.Equal(t, *e.Charge, domain.Charge{
+		ID:            "ch_1",
+		PaymentID:     "pi_1",
+		Amount:        domain.Money{Amount: 4900, Currency: domain.CurrencyUSD},
+		Refunded:      domain.Money{Amount: 4900, Currency: domain.CurrencyUSD},
+		FullyRefunded: true,
+	})
+
+	e, err = w.ParseEvent([]byte(`{"id":"evt_3","type":"charge.dispute.created","created":1767225600,"data":{"object":{
+		"id":"dp_1","charge":"ch_1","payment_intent":"pi_1","amount":4900,"currency":"usd",
+		"reason":"fraudulent","status":"needs_response"}}}`))
+	assert.NoError(t, err)
+	assert.True(t, e.Charge == nil)
+	assert.Equal(t, e.Dispute.ChargeID, "ch_1")
+	assert.Equal(t, e.Dispute.Reason, "fraudulent")
+
+	// Events about other objects are parsed without one.
+	e, err = w.ParseEvent([]byte(`{"id":"evt_4","type":"customer.created","created":1767225600,"data":{"object":{}}}`))
+	assert.NoError(t, err)
+	assert.True(t, e.Checkout == nil && e.Charge == nil && e.Dispute == nil)
+
+	for _, payload := range []string{
+		`not json`,
+		`{"type":"charge.refunded"}`,
+		`{"id":"evt_5","type":"charge.refunded","data":{"object":{"amount":"all"}}}`,
+	} {
+		_, err = w.ParseEvent([]byte(payload))
+		assert.Error(t, err, adapters.ErrStripeEvent)
+	}
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package encryption seals secrets kept at rest.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+)
+
+// KeyLength is the length of AES-256 keys.
+const KeyLength = 32
+
+// AESGCM seals data with AES-256-GCM. Sealed data carries its random nonce,
+// and is bound to additional data, such as the ID of its owner, so that
+// sealed values cannot be swapped between rows.
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+func NewAESGCM(key []byte) (*AESGCM, error) {
+	if len(key) != KeyLength {
+		return nil, ErrKeyLength
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESGCM{
+		aead: aead,
+	}, nil
+}
+
+// Seal encrypts and authenticates plaintext and additionalData.
+func (a *AESGCM) Seal(plaintext, additionalData []byte) []byte {
+	nonce := make([]byte, a.aead.NonceSize(), a.aead.NonceSize()+len(plaintext)+a.aead.Overhead())
+	_, _ = rand.Read(nonce)
+
+	return a.aead.Seal(nonce, nonce, plaintext, additionalData)
+}
+
+// Open decrypts sealed, which must have been sealed with the same key and
+// additional data.
+func (a *AESGCM) Open(sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < a.aead.NonceSize() {
+		return nil, ErrOpen
+	}
+
+	nonce, ciphertext := sealed[:a.aead.NonceSize()], sealed[a.aead.NonceSize():]
+
+	plaintext, err := a.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrOpen
+	}
+
+	return plaintext, nil
+}
+
+type EncryptionError string
+
+func (e EncryptionError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrKeyLength EncryptionError = "encryption key must be 32 bytes"
+	ErrOpen      EncryptionError = "sealed data is corrupt or was sealed with another key"
+)
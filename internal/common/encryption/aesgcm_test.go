@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption_test
+
+import (
+	"bytes"
+	"testing"
+
+	"backend.brokedaear.com/internal/common/encryption"
+	"backend.brokedaear.com/internal/common/tests/assert"
+)
+
+func TestAESGCM(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, encryption.KeyLength)
+
+	a, err := encryption.NewAESGCM(key)
+	assert.NoError(t, err)
+
+	sealed := a.Seal([]byte("secret"), []byte("customer:1"))
+	assert.False(t, bytes.Contains(sealed, []byte("secret")))
+
+	// Sealing twice gives different output.
+	assert.False(t, bytes.Equal(sealed, a.Seal([]byte("secret"), []byte("customer:1"))))
+
+	plaintext, err := a.Open(sealed, []byte("customer:1"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(plaintext), "secret")
+
+	_, err = a.Open(sealed, []byte("customer:2"))
+	assert.Error(t, err, encryption.ErrOpen)
+
+	other, err := encryption.NewAESGCM(bytes.Repeat([]byte{2}, encryption.KeyLength))
+	assert.NoError(t, err)
+
+	_, err = other.Open(sealed, []byte("customer:1"))
+	assert.Error(t, err, encryption.ErrOpen)
+
+	_, err = a.Open([]byte("short"), nil)
+	assert.Error(t, err, encryption.ErrOpen)
+
+	_, err = encryption.NewAESGCM([]byte("short"))
+	assert.Error(t, err, encryption.ErrKeyLength)
+}
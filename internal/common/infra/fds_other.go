@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !unix
+
+package infra
+
+// fileDescriptors is unsupported outside of unix systems.
+func fileDescriptors() (int64, int64, error) {
+	return 0, 0, ErrFDsUnsupported
+}
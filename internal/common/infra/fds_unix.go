@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unix
+
+package infra
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileDescriptors returns the number of open file descriptors of the process
+// and the soft limit on them.
+func fileDescriptors() (int64, int64, error) {
+	var limit syscall.Rlimit
+
+	err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Linux lists descriptors in /proc, other unix systems in /dev/fd.
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		entries, err = os.ReadDir("/dev/fd")
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// Reading the directory opens one descriptor, which is not counted.
+	return int64(len(entries) - 1), int64(limit.Cur), nil //nolint:gosec // limits fit in an int64.
+}
@@ -0,0 +1,335 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Logger defines the logger used by infrastructure facilities.
+type Logger interface {
+	Info(msg string, args ...any)
+	Debug(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Component is a part of the application that must be started before use and
+// stopped before exit. Start must return once the component is ready, leaving
+// any long running work in the background. Stop must release everything the
+// component holds and should respect the deadline of its context.
+type Component interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Hook is a Component made of plain functions. A nil function is a no-op.
+type Hook struct {
+	OnStart func(context.Context) error
+	OnStop  func(context.Context) error
+}
+
+func (h Hook) Start(ctx context.Context) error {
+	if h.OnStart == nil {
+		return nil
+	}
+
+	return h.OnStart(ctx)
+}
+
+func (h Hook) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+
+	return h.OnStop(ctx)
+}
+
+// CloserHook returns a Hook that closes c on stop.
+func CloserHook(c io.Closer) Hook {
+	return Hook{
+		OnStart: nil,
+		OnStop: func(context.Context) error {
+			return c.Close()
+		},
+	}
+}
+
+// Registration describes a component managed by a Lifecycle.
+type Registration struct {
+	// Name identifies the component in logs, errors, and dependencies.
+	Name string
+
+	Component Component
+
+	// DependsOn lists the names of the components that must be started
+	// before this one. They are stopped only after this one has stopped.
+	DependsOn []string
+
+	// StopTimeout bounds the Stop hook of the component. A zero StopTimeout
+	// uses the default timeout of the Lifecycle.
+	StopTimeout time.Duration
+}
+
+// Lifecycle starts registered components in dependency order and stops them
+// in the reverse order. A failing component never prevents the others from
+// stopping; every error is collected and returned together.
+type Lifecycle struct {
+	logger      Logger
+	stopTimeout time.Duration
+
+	mu            sync.Mutex
+	registrations []Registration
+	started       []Registration
+}
+
+// NewLifecycle creates a Lifecycle. stopTimeout is the default deadline of
+// each component's Stop hook.
+func NewLifecycle(logger Logger, stopTimeout time.Duration) *Lifecycle {
+	return &Lifecycle{
+		logger:        logger,
+		stopTimeout:   stopTimeout,
+		mu:            sync.Mutex{},
+		registrations: nil,
+		started:       nil,
+	}
+}
+
+// Register adds a component to the lifecycle. Components must be registered
+// before Start is called.
+func (l *Lifecycle) Register(r Registration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r.Name == "" {
+		return ErrComponentNoName
+	}
+
+	if r.Component == nil {
+		return fmt.Errorf("%w: %s", ErrComponentNil, r.Name)
+	}
+
+	for _, existing := range l.registrations {
+		if existing.Name == r.Name {
+			return fmt.Errorf("%w: %s", ErrComponentDuplicate, r.Name)
+		}
+	}
+
+	l.registrations = append(l.registrations, r)
+
+	return nil
+}
+
+// Start starts every registered component in dependency order. When a
+// component fails to start, the components started before it are stopped
+// and the start error is returned along with any stop errors.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	order, err := l.order()
+	l.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	l.logger.Info("starting components", "count", len(order))
+	begin := time.Now()
+
+	for _, r := range order {
+		t := time.Now()
+
+		err = r.Component.Start(ctx)
+		if err != nil {
+			l.logger.Error(
+				"component failed to start",
+				"component", r.Name,
+				"duration", time.Since(t),
+				"error", err,
+			)
+
+			startErr := fmt.Errorf("start %s: %w", r.Name, err)
+			stopErr := l.Stop(context.WithoutCancel(ctx))
+
+			return errors.Join(startErr, stopErr)
+		}
+
+		l.mu.Lock()
+		l.started = append(l.started, r)
+		l.mu.Unlock()
+
+		l.logger.Info("component started", "component", r.Name, "duration", time.Since(t))
+	}
+
+	l.logger.Info("all components started", "duration", time.Since(begin))
+
+	return nil
+}
+
+// Stop stops every started component in the reverse order of startup. Each
+// Stop hook gets its own deadline, bounded by the deadline of ctx. A hook
+// that does not return in time is abandoned and reported as an error.
+// Stopping a lifecycle that has nothing started is a no-op.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	started := l.started
+	l.started = nil
+	l.mu.Unlock()
+
+	if len(started) == 0 {
+		return nil
+	}
+
+	l.logger.Info("stopping components", "count", len(started))
+	begin := time.Now()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		r := started[i]
+		t := time.Now()
+
+		err := l.stop(ctx, r)
+		if err != nil {
+			l.logger.Error(
+				"component failed to stop",
+				"component", r.Name,
+				"duration", time.Since(t),
+				"error", err,
+			)
+			errs = append(errs, fmt.Errorf("stop %s: %w", r.Name, err))
+
+			continue
+		}
+
+		l.logger.Info("component stopped", "component", r.Name, "duration", time.Since(t))
+	}
+
+	l.logger.Info("all components stopped", "duration", time.Since(begin), "errors", len(errs))
+
+	return errors.Join(errs...)
+}
+
+// Abort stops every registered component, started or not, as Stop does.
+// It is meant for failures before Start, since components such as
+// connection pools or telemetry exporters hold resources from their
+// construction on; the Stop hooks of components must therefore be safe to
+// call before Start. When the dependencies of the components are not all
+// registered yet, they are stopped in the reverse order of registration.
+func (l *Lifecycle) Abort(ctx context.Context) error {
+	l.mu.Lock()
+
+	order, err := l.order()
+	if err != nil {
+		order = slices.Clone(l.registrations)
+	}
+
+	l.started = order
+	l.mu.Unlock()
+
+	return l.Stop(ctx)
+}
+
+// stop runs the Stop hook of r under its deadline.
+func (l *Lifecycle) stop(ctx context.Context, r Registration) error {
+	timeout := r.StopTimeout
+	if timeout == 0 {
+		timeout = l.stopTimeout
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Component.Stop(stopCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-stopCtx.Done():
+		return fmt.Errorf("%w: %w", ErrComponentStopTimeout, stopCtx.Err())
+	}
+}
+
+// order returns the registrations sorted so that every component comes after
+// its dependencies. Components without an order between them keep their
+// registration order. The caller must hold the lock.
+func (l *Lifecycle) order() ([]Registration, error) {
+	byName := make(map[string]Registration, len(l.registrations))
+	for _, r := range l.registrations {
+		byName[r.Name] = r
+	}
+
+	for _, r := range l.registrations {
+		for _, dep := range r.DependsOn {
+			_, ok := byName[dep]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s depends on %s", ErrComponentUnknownDependency, r.Name, dep)
+			}
+		}
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make(map[string]int, len(l.registrations))
+	order := make([]Registration, 0, len(l.registrations))
+
+	var visit func(r Registration) error
+	visit = func(r Registration) error {
+		switch state[r.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrComponentDependencyCycle, r.Name)
+		}
+
+		state[r.Name] = visiting
+
+		for _, dep := range r.DependsOn {
+			err := visit(byName[dep])
+			if err != nil {
+				return err
+			}
+		}
+
+		state[r.Name] = visited
+		order = append(order, r)
+
+		return nil
+	}
+
+	for _, r := range l.registrations {
+		err := visit(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+type LifecycleError string
+
+func (e LifecycleError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrComponentNoName            LifecycleError = "component has no name"
+	ErrComponentNil               LifecycleError = "component is nil"
+	ErrComponentDuplicate         LifecycleError = "component is already registered"
+	ErrComponentUnknownDependency LifecycleError = "component depends on an unregistered component"
+	ErrComponentDependencyCycle   LifecycleError = "component dependency cycle"
+	ErrComponentStopTimeout       LifecycleError = "component did not stop in time"
+)
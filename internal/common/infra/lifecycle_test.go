@@ -0,0 +1,290 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package infra_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/infra"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+// recorder records the order in which components start and stop.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) hook(name string, startErr, stopErr error) infra.Hook {
+	return infra.Hook{
+		OnStart: func(context.Context) error {
+			r.record("start " + name)
+			return startErr
+		},
+		OnStop: func(context.Context) error {
+			r.record("stop " + name)
+			return stopErr
+		},
+	}
+}
+
+func register(t *testing.T, lc *infra.Lifecycle, name string, c infra.Component, deps ...string) {
+	t.Helper()
+
+	err := lc.Register(infra.Registration{
+		Name:        name,
+		Component:   c,
+		DependsOn:   deps,
+		StopTimeout: 0,
+	})
+	assert.NoError(t, err)
+}
+
+func assertEvents(t *testing.T, got []string, want ...string) {
+	t.Helper()
+
+	assert.Equal(t, len(got), len(want))
+	for i := range min(len(got), len(want)) {
+		assert.Equal(t, got[i], want[i])
+	}
+}
+
+func TestLifecycle_StartsInDependencyOrder(t *testing.T) {
+	r := &recorder{mu: sync.Mutex{}, events: nil}
+	lc := infra.NewLifecycle(nopLogger{}, time.Second)
+
+	register(t, lc, "server", r.hook("server", nil, nil), "database", "telemetry")
+	register(t, lc, "database", r.hook("database", nil, nil), "logger")
+	register(t, lc, "telemetry", r.hook("telemetry", nil, nil), "logger")
+	register(t, lc, "logger", r.hook("logger", nil, nil))
+
+	err := lc.Start(t.Context())
+	assert.NoError(t, err)
+
+	err = lc.Stop(t.Context())
+	assert.NoError(t, err)
+
+	assertEvents(
+		t, r.events,
+		"start logger", "start database", "start telemetry", "start server",
+		"stop server", "stop telemetry", "stop database", "stop logger",
+	)
+}
+
+func TestLifecycle_StopContinuesPastErrors(t *testing.T) {
+	r := &recorder{mu: sync.Mutex{}, events: nil}
+	lc := infra.NewLifecycle(nopLogger{}, time.Second)
+
+	errFirst := errors.New("first failed")
+	errThird := errors.New("third failed")
+
+	register(t, lc, "first", r.hook("first", nil, errFirst))
+	register(t, lc, "second", r.hook("second", nil, nil), "first")
+	register(t, lc, "third", r.hook("third", nil, errThird), "second")
+
+	err := lc.Start(t.Context())
+	assert.NoError(t, err)
+
+	err = lc.Stop(t.Context())
+	assert.Error(t, err, errFirst)
+	assert.Error(t, err, errThird)
+
+	assertEvents(t, r.events, "start first", "start second", "start third", "stop third", "stop second", "stop first")
+}
+
+func TestLifecycle_FailedStartStopsStartedComponents(t *testing.T) {
+	r := &recorder{mu: sync.Mutex{}, events: nil}
+	lc := infra.NewLifecycle(nopLogger{}, time.Second)
+
+	errStart := errors.New("no database")
+
+	register(t, lc, "logger", r.hook("logger", nil, nil))
+	register(t, lc, "database", r.hook("database", errStart, nil), "logger")
+	register(t, lc, "server", r.hook("server", nil, nil), "database")
+
+	err := lc.Start(t.Context())
+	assert.Error(t, err, errStart)
+
+	assertEvents(t, r.events, "start logger", "start database", "stop logger")
+
+	// Everything is already stopped.
+	err = lc.Stop(t.Context())
+	assert.NoError(t, err)
+}
+
+func TestLifecycle_Abort(t *testing.T) {
+	r := &recorder{mu: sync.Mutex{}, events: nil}
+	lc := infra.NewLifecycle(nopLogger{}, time.Second)
+
+	register(t, lc, "server", r.hook("server", nil, nil), "database")
+	register(t, lc, "database", r.hook("database", nil, nil), "logger")
+	register(t, lc, "logger", r.hook("logger", nil, nil))
+
+	// Components never started are stopped in dependency order.
+	err := lc.Abort(t.Context())
+	assert.NoError(t, err)
+
+	assertEvents(t, r.events, "stop server", "stop database", "stop logger")
+
+	// Without all of their dependencies, in the reverse order of
+	// registration.
+	r = &recorder{mu: sync.Mutex{}, events: nil}
+	lc = infra.NewLifecycle(nopLogger{}, time.Second)
+
+	register(t, lc, "logger", r.hook("logger", nil, nil))
+	register(t, lc, "sweeper", r.hook("sweeper", nil, nil), "database")
+
+	err = lc.Abort(t.Context())
+	assert.NoError(t, err)
+
+	assertEvents(t, r.events, "stop sweeper", "stop logger")
+}
+
+func TestLifecycle_StopTimeout(t *testing.T) {
+	lc := infra.NewLifecycle(nopLogger{}, time.Hour)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	stuck := infra.Hook{
+		OnStart: nil,
+		OnStop: func(context.Context) error {
+			<-release
+			return nil
+		},
+	}
+
+	err := lc.Register(infra.Registration{
+		Name:        "stuck",
+		Component:   stuck,
+		DependsOn:   nil,
+		StopTimeout: 10 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	err = lc.Start(t.Context())
+	assert.NoError(t, err)
+
+	err = lc.Stop(t.Context())
+	assert.Error(t, err, infra.ErrComponentStopTimeout)
+	assert.Error(t, err, context.DeadlineExceeded)
+}
+
+func TestLifecycle_InvalidRegistrations(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		setup func(lc *infra.Lifecycle) error
+	}{
+		{
+			CaseBase: test.NewCaseBase("missing name", infra.ErrComponentNoName, true),
+			setup: func(lc *infra.Lifecycle) error {
+				return lc.Register(infra.Registration{Name: "", Component: infra.Hook{}, DependsOn: nil, StopTimeout: 0})
+			},
+		},
+		{
+			CaseBase: test.NewCaseBase("nil component", infra.ErrComponentNil, true),
+			setup: func(lc *infra.Lifecycle) error {
+				return lc.Register(infra.Registration{Name: "a", Component: nil, DependsOn: nil, StopTimeout: 0})
+			},
+		},
+		{
+			CaseBase: test.NewCaseBase("duplicate name", infra.ErrComponentDuplicate, true),
+			setup: func(lc *infra.Lifecycle) error {
+				err := lc.Register(infra.Registration{Name: "a", Component: infra.Hook{}, DependsOn: nil, StopTimeout: 0})
+				if err != nil {
+					return err
+				}
+				return lc.Register(infra.Registration{Name: "a", Component: infra.Hook{}, DependsOn: nil, StopTimeout: 0})
+			},
+		},
+		{
+			CaseBase: test.NewCaseBase("unknown dependency", infra.ErrComponentUnknownDependency, true),
+			setup: func(lc *infra.Lifecycle) error {
+				err := lc.Register(infra.Registration{
+					Name:        "a",
+					Component:   infra.Hook{},
+					DependsOn:   []string{"ghost"},
+					StopTimeout: 0,
+				})
+				if err != nil {
+					return err
+				}
+				return lc.Start(context.Background())
+			},
+		},
+		{
+			CaseBase: test.NewCaseBase("dependency cycle", infra.ErrComponentDependencyCycle, true),
+			setup: func(lc *infra.Lifecycle) error {
+				err := lc.Register(infra.Registration{
+					Name:        "a",
+					Component:   infra.Hook{},
+					DependsOn:   []string{"b"},
+					StopTimeout: 0,
+				})
+				if err != nil {
+					return err
+				}
+				err = lc.Register(infra.Registration{
+					Name:        "b",
+					Component:   infra.Hook{},
+					DependsOn:   []string{"a"},
+					StopTimeout: 0,
+				})
+				if err != nil {
+					return err
+				}
+				return lc.Start(context.Background())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			lc := infra.NewLifecycle(nopLogger{}, time.Second)
+			err := tt.setup(lc)
+			assert.Error(t, err, tt.Want.(error))
+		})
+	}
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+func TestCloserHook(t *testing.T) {
+	closed := false
+	h := infra.CloserHook(closerFunc(func() error {
+		closed = true
+		return nil
+	}))
+
+	err := h.Start(t.Context())
+	assert.NoError(t, err)
+	assert.False(t, closed)
+
+	err = h.Stop(t.Context())
+	assert.NoError(t, err)
+	assert.True(t, closed)
+}
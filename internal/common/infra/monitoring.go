@@ -4,4 +4,441 @@
 
 package infra
 
-type Monitor interface{}
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+
+	"backend.brokedaear.com/internal/common/telemetry"
+)
+
+// Probe checks the health of a dependency, such as a database or a payment
+// provider.
+type Probe interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// funcProbe is a Probe made of a name and a check function.
+type funcProbe struct {
+	name  string
+	check func(context.Context) error
+}
+
+// NewProbe creates a Probe from a name and a check function.
+func NewProbe(name string, check func(context.Context) error) Probe {
+	return funcProbe{
+		name:  name,
+		check: check,
+	}
+}
+
+func (p funcProbe) Name() string {
+	return p.name
+}
+
+func (p funcProbe) Check(ctx context.Context) error {
+	return p.check(ctx)
+}
+
+// MonitorThresholds are the limits above which the monitor raises alerts. A
+// zero threshold disables its alert.
+type MonitorThresholds struct {
+	// Goroutines is the number of live goroutines above which a goroutine
+	// leak is suspected.
+	Goroutines int64
+
+	// HeapBytes is the size of the allocated heap above which an alert
+	// is raised.
+	HeapBytes int64
+
+	// GCPause is the duration of a single garbage collection pause above
+	// which an alert is raised.
+	GCPause time.Duration
+
+	// FDPercent is the share of the file descriptor limit, in percent, above
+	// which an alert is raised.
+	FDPercent int64
+
+	// ProbeFailures is the number of consecutive failures of a probe after
+	// which an alert is raised.
+	ProbeFailures int
+}
+
+// MonitorConfig configures a Monitor.
+type MonitorConfig struct {
+	// Interval is the time between two samples.
+	Interval time.Duration
+
+	// ProbeTimeout bounds each dependency probe.
+	ProbeTimeout time.Duration
+
+	Thresholds MonitorThresholds
+}
+
+func (c MonitorConfig) Validate() error {
+	if c.Interval <= 0 {
+		return ErrMonitorInterval
+	}
+
+	if c.ProbeTimeout <= 0 {
+		return ErrMonitorProbeTimeout
+	}
+
+	return nil
+}
+
+func (c MonitorConfig) Value() any {
+	return c
+}
+
+// Sample is a single reading of the runtime and the dependencies.
+type Sample struct {
+	Time       time.Time
+	Goroutines int64
+	HeapBytes  int64
+	GCPause    time.Duration
+	NumGC      uint32
+
+	// OpenFDs and MaxFDs are zero when the platform does not report them.
+	OpenFDs int64
+	MaxFDs  int64
+
+	// Probes holds the result of every probe by name. A nil error means the
+	// probe passed.
+	Probes map[string]error
+}
+
+// Monitor periodically samples the Go runtime, file descriptor usage, and
+// registered dependency probes. It publishes every sample as telemetry gauges
+// and raises alerts through its notifiers when a threshold is crossed. An
+// alert is raised once when its threshold is crossed and resolved once when
+// the value falls back under it.
+//
+// Monitor is a Component; Start runs the sampling loop in the background
+// until Stop is called.
+type Monitor struct {
+	logger    Logger
+	config    MonitorConfig
+	notifiers []Notifier
+	gauges    monitorGauges
+
+	mu       sync.Mutex
+	probes   []Probe
+	failures map[string]int
+	active   map[string]Alert
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// monitorGauges are the gauges a Monitor records samples on.
+type monitorGauges struct {
+	goroutines otelmetric.Int64Gauge
+	heap       otelmetric.Int64Gauge
+	gcPause    otelmetric.Int64Gauge
+	openFDs    otelmetric.Int64Gauge
+	maxFDs     otelmetric.Int64Gauge
+	dependency otelmetric.Int64Gauge
+}
+
+// NewMonitor creates a Monitor that publishes to t and notifies notifiers.
+func NewMonitor(
+	logger Logger,
+	t telemetry.Telemetry,
+	config MonitorConfig,
+	notifiers ...Notifier,
+) (*Monitor, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	gauges, err := newMonitorGauges(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Monitor{
+		logger:    logger,
+		config:    config,
+		notifiers: notifiers,
+		gauges:    gauges,
+		mu:        sync.Mutex{},
+		probes:    nil,
+		failures:  make(map[string]int),
+		active:    make(map[string]Alert),
+		cancel:    nil,
+		done:      nil,
+	}, nil
+}
+
+func newMonitorGauges(t telemetry.Telemetry) (monitorGauges, error) {
+	var (
+		g    monitorGauges
+		errs []error
+		err  error
+	)
+
+	g.goroutines, err = t.Gauge(telemetry.MetricRuntimeGoroutines)
+	errs = append(errs, err)
+	g.heap, err = t.Gauge(telemetry.MetricRuntimeHeapBytes)
+	errs = append(errs, err)
+	g.gcPause, err = t.Gauge(telemetry.MetricRuntimeGCPauseMicros)
+	errs = append(errs, err)
+	g.openFDs, err = t.Gauge(telemetry.MetricProcessOpenFDs)
+	errs = append(errs, err)
+	g.maxFDs, err = t.Gauge(telemetry.MetricProcessMaxFDs)
+	errs = append(errs, err)
+	g.dependency, err = t.Gauge(telemetry.MetricDependencyUp)
+	errs = append(errs, err)
+
+	return g, errors.Join(errs...)
+}
+
+// RegisterProbe adds a dependency probe, checked on every sample.
+func (m *Monitor) RegisterProbe(p Probe) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.probes = append(m.probes, p)
+}
+
+// Start starts the sampling loop, which takes its first sample right away.
+func (m *Monitor) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancel != nil {
+		return ErrMonitorRunning
+	}
+
+	loopCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	m.cancel = cancel
+	m.done = make(chan struct{})
+
+	go m.loop(loopCtx, m.done)
+
+	return nil
+}
+
+// Stop stops the sampling loop and waits for an ongoing sample to finish.
+func (m *Monitor) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Monitor) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.Collect(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect takes one sample, publishes it, and evaluates it against the
+// thresholds. The sampling loop calls Collect on every tick.
+func (m *Monitor) Collect(ctx context.Context) Sample {
+	s := m.sample(ctx)
+	m.publish(ctx, s)
+	m.evaluate(ctx, s)
+
+	return s
+}
+
+func (m *Monitor) sample(ctx context.Context) Sample {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	s := Sample{
+		Time:       time.Now(),
+		Goroutines: int64(runtime.NumGoroutine()),
+		HeapBytes:  int64(ms.HeapAlloc), //nolint:gosec // heap sizes fit in an int64.
+		GCPause:    0,
+		NumGC:      ms.NumGC,
+		OpenFDs:    0,
+		MaxFDs:     0,
+		Probes:     make(map[string]error),
+	}
+
+	if ms.NumGC > 0 {
+		// PauseNs is a circular buffer holding the most recent pause at
+		// (NumGC+255)%256, as documented on runtime.MemStats.
+		const size = uint32(len(ms.PauseNs))
+		s.GCPause = time.Duration(ms.PauseNs[(ms.NumGC+size-1)%size]) //nolint:gosec // pauses fit in a duration.
+	}
+
+	open, limit, err := fileDescriptors()
+	if err == nil {
+		s.OpenFDs, s.MaxFDs = open, limit
+	}
+
+	m.mu.Lock()
+	probes := append([]Probe(nil), m.probes...)
+	m.mu.Unlock()
+
+	for _, p := range probes {
+		probeCtx, cancel := context.WithTimeout(ctx, m.config.ProbeTimeout)
+		s.Probes[p.Name()] = p.Check(probeCtx)
+		cancel()
+	}
+
+	return s
+}
+
+func (m *Monitor) publish(ctx context.Context, s Sample) {
+	m.gauges.goroutines.Record(ctx, s.Goroutines)
+	m.gauges.heap.Record(ctx, s.HeapBytes)
+	m.gauges.gcPause.Record(ctx, s.GCPause.Microseconds())
+
+	if s.MaxFDs > 0 {
+		m.gauges.openFDs.Record(ctx, s.OpenFDs)
+		m.gauges.maxFDs.Record(ctx, s.MaxFDs)
+	}
+
+	for name, err := range s.Probes {
+		up := int64(1)
+		if err != nil {
+			up = 0
+		}
+
+		m.gauges.dependency.Record(ctx, up, otelmetric.WithAttributes(attribute.String("dependency", name)))
+	}
+}
+
+// evaluate raises and resolves alerts based on s.
+func (m *Monitor) evaluate(ctx context.Context, s Sample) {
+	th := m.config.Thresholds
+
+	if th.Goroutines > 0 {
+		m.check(ctx, newAlert(s, "goroutine_leak", "goroutine count above threshold", s.Goroutines, th.Goroutines))
+	}
+
+	if th.HeapBytes > 0 {
+		m.check(ctx, newAlert(s, "heap_size", "heap size above threshold", s.HeapBytes, th.HeapBytes))
+	}
+
+	if th.GCPause > 0 {
+		pause, limit := s.GCPause.Microseconds(), th.GCPause.Microseconds()
+		m.check(ctx, newAlert(s, "gc_pause", "gc pause above threshold", pause, limit))
+	}
+
+	if th.FDPercent > 0 && s.MaxFDs > 0 {
+		const percent = 100
+		usage := s.OpenFDs * percent / s.MaxFDs
+		m.check(ctx, newAlert(s, "fd_usage", "file descriptor usage above threshold", usage, th.FDPercent))
+	}
+
+	for name, err := range s.Probes {
+		m.mu.Lock()
+		if err != nil {
+			m.failures[name]++
+		} else {
+			m.failures[name] = 0
+		}
+		failures := int64(m.failures[name])
+		m.mu.Unlock()
+
+		if th.ProbeFailures <= 0 {
+			continue
+		}
+
+		// The alert is raised on the last allowed failure, so the threshold
+		// is one below the number of failures.
+		a := newAlert(s, "dependency_down:"+name, "dependency probe failing", failures, int64(th.ProbeFailures)-1)
+		a.Severity = AlertSeverityCritical
+		if err != nil {
+			a.Message = fmt.Sprintf("dependency probe failing: %v", err)
+		}
+
+		m.check(ctx, a)
+	}
+}
+
+func newAlert(s Sample, name, message string, value, threshold int64) Alert {
+	return Alert{
+		Name:      name,
+		Severity:  AlertSeverityWarning,
+		Message:   message,
+		Value:     value,
+		Threshold: threshold,
+		Time:      s.Time,
+		Resolved:  false,
+	}
+}
+
+// check notifies a when its value crossed its threshold and no alert of the
+// same name is active, and notifies its resolution when the value is back
+// under the threshold while the alert is active.
+func (m *Monitor) check(ctx context.Context, a Alert) {
+	crossed := a.Value > a.Threshold
+
+	m.mu.Lock()
+	_, active := m.active[a.Name]
+	if crossed == active {
+		m.mu.Unlock()
+		return
+	}
+
+	if crossed {
+		m.active[a.Name] = a
+	} else {
+		delete(m.active, a.Name)
+		a.Resolved = true
+		a.Message = "resolved: " + a.Message
+	}
+	m.mu.Unlock()
+
+	m.notify(ctx, a)
+}
+
+func (m *Monitor) notify(ctx context.Context, a Alert) {
+	for _, n := range m.notifiers {
+		err := n.Notify(ctx, a)
+		if err != nil {
+			m.logger.Error("failed to notify alert", "alert", a.Name, "error", err)
+		}
+	}
+}
+
+type MonitorError string
+
+func (e MonitorError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrMonitorInterval     MonitorError = "monitor interval must be greater than zero"
+	ErrMonitorProbeTimeout MonitorError = "monitor probe timeout must be greater than zero"
+	ErrMonitorRunning      MonitorError = "monitor is already running"
+	ErrFDsUnsupported      MonitorError = "file descriptor usage is not supported on this platform"
+)
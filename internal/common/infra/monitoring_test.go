@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package infra_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/infra"
+	"backend.brokedaear.com/internal/common/telemetry"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+)
+
+// alertRecorder is a Notifier that keeps every alert it receives.
+type alertRecorder struct {
+	mu     sync.Mutex
+	alerts []infra.Alert
+}
+
+func (r *alertRecorder) Notify(_ context.Context, a infra.Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func (r *alertRecorder) all() []infra.Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]infra.Alert(nil), r.alerts...)
+}
+
+func newTestMonitor(t *testing.T, th infra.MonitorThresholds, n infra.Notifier) *infra.Monitor {
+	t.Helper()
+
+	m, err := infra.NewMonitor(
+		nopLogger{},
+		telemetry.NewNoop(),
+		infra.MonitorConfig{
+			Interval:     time.Hour,
+			ProbeTimeout: time.Second,
+			Thresholds:   th,
+		},
+		n,
+	)
+	assert.NoError(t, err)
+
+	return m
+}
+
+func TestMonitor_CollectSamplesRuntime(t *testing.T) {
+	m := newTestMonitor(t, infra.MonitorThresholds{}, &alertRecorder{})
+
+	s := m.Collect(t.Context())
+
+	assert.True(t, s.Goroutines > 0)
+	assert.True(t, s.HeapBytes > 0)
+	assert.False(t, s.Time.IsZero())
+}
+
+func TestMonitor_GoroutineLeakAlertRaisedAndResolvedOnce(t *testing.T) {
+	r := &alertRecorder{}
+	m := newTestMonitor(t, infra.MonitorThresholds{Goroutines: 1}, r)
+
+	m.Collect(t.Context())
+	m.Collect(t.Context())
+
+	alerts := r.all()
+	assert.Equal(t, len(alerts), 1)
+	assert.Equal(t, alerts[0].Name, "goroutine_leak")
+	assert.Equal(t, alerts[0].Severity, infra.AlertSeverityWarning)
+	assert.False(t, alerts[0].Resolved)
+}
+
+func TestMonitor_ProbeFailures(t *testing.T) {
+	r := &alertRecorder{}
+	m := newTestMonitor(t, infra.MonitorThresholds{ProbeFailures: 2}, r)
+
+	var healthy bool
+	m.RegisterProbe(infra.NewProbe("database", func(context.Context) error {
+		if healthy {
+			return nil
+		}
+		return errors.New("connection refused")
+	}))
+
+	s := m.Collect(t.Context())
+	assert.NotEqual(t, s.Probes["database"], nil)
+	assert.Equal(t, len(r.all()), 0)
+
+	m.Collect(t.Context())
+	alerts := r.all()
+	assert.Equal(t, len(alerts), 1)
+	assert.Equal(t, alerts[0].Name, "dependency_down:database")
+	assert.Equal(t, alerts[0].Severity, infra.AlertSeverityCritical)
+
+	healthy = true
+	m.Collect(t.Context())
+	alerts = r.all()
+	assert.Equal(t, len(alerts), 2)
+	assert.True(t, alerts[1].Resolved)
+}
+
+func TestMonitor_StartStop(t *testing.T) {
+	m := newTestMonitor(t, infra.MonitorThresholds{}, &alertRecorder{})
+
+	err := m.Start(t.Context())
+	assert.NoError(t, err)
+
+	err = m.Start(t.Context())
+	assert.Error(t, err, infra.ErrMonitorRunning)
+
+	err = m.Stop(t.Context())
+	assert.NoError(t, err)
+
+	// Stopping twice is a no-op.
+	err = m.Stop(t.Context())
+	assert.NoError(t, err)
+}
+
+func TestMonitorConfig_Validate(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		config infra.MonitorConfig
+	}{
+		{
+			CaseBase: test.NewCaseBase("valid config", nil, false),
+			config:   infra.MonitorConfig{Interval: time.Second, ProbeTimeout: time.Second},
+		},
+		{
+			CaseBase: test.NewCaseBase("zero interval", infra.ErrMonitorInterval, true),
+			config:   infra.MonitorConfig{Interval: 0, ProbeTimeout: time.Second},
+		},
+		{
+			CaseBase: test.NewCaseBase("zero probe timeout", infra.ErrMonitorProbeTimeout, true),
+			config:   infra.MonitorConfig{Interval: time.Second, ProbeTimeout: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.config.Validate()
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+			if tt.WantErr {
+				assert.Error(t, err, tt.Want.(error))
+			}
+		})
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		status int
+	}{
+		{
+			CaseBase: test.NewCaseBase("accepted", nil, false),
+			status:   http.StatusNoContent,
+		},
+		{
+			CaseBase: test.NewCaseBase("rejected", infra.ErrWebhookRejected, true),
+			status:   http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var got infra.Alert
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, http.MethodPost)
+				err := json.NewDecoder(r.Body).Decode(&got)
+				assert.NoError(t, err)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			n := infra.NewWebhookNotifier(srv.URL, time.Second)
+			err := n.Notify(t.Context(), infra.Alert{
+				Name:      "heap_size",
+				Severity:  infra.AlertSeverityWarning,
+				Message:   "heap size above threshold",
+				Value:     2,
+				Threshold: 1,
+				Time:      time.Now(),
+				Resolved:  false,
+			})
+
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+			if tt.WantErr {
+				assert.Error(t, err, tt.Want.(error))
+			}
+			assert.Equal(t, got.Name, "heap_size")
+		})
+	}
+}
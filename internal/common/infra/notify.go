@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AlertSeverity describes how urgently an alert needs attention.
+type AlertSeverity string
+
+const (
+	AlertSeverityWarning  AlertSeverity = "warning"
+	AlertSeverityCritical AlertSeverity = "critical"
+)
+
+// Alert is a structured notice that a monitored value crossed its threshold,
+// or that it went back under it when Resolved is true.
+type Alert struct {
+	Name      string        `json:"name"`
+	Severity  AlertSeverity `json:"severity"`
+	Message   string        `json:"message"`
+	Value     int64         `json:"value"`
+	Threshold int64         `json:"threshold"`
+	Time      time.Time     `json:"time"`
+	Resolved  bool          `json:"resolved"`
+}
+
+// Notifier delivers alerts somewhere a person will see them.
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// LogNotifier delivers alerts to a logger. Raised alerts are logged as errors
+// and resolved alerts as info.
+type LogNotifier struct {
+	logger Logger
+}
+
+func NewLogNotifier(logger Logger) *LogNotifier {
+	return &LogNotifier{
+		logger: logger,
+	}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, a Alert) error {
+	args := []any{
+		"alert", a.Name,
+		"severity", a.Severity,
+		"value", a.Value,
+		"threshold", a.Threshold,
+	}
+
+	if a.Resolved {
+		n.logger.Info(a.Message, args...)
+		return nil
+	}
+
+	n.logger.Error(a.Message, args...)
+
+	return nil
+}
+
+// WebhookNotifier delivers alerts by posting them as JSON to a URL, such as
+// a chat incoming webhook.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier posting to url. Each delivery
+// is bounded by timeout.
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{
+		url: url,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: status %d", ErrWebhookRejected, res.StatusCode)
+	}
+
+	return nil
+}
+
+const ErrWebhookRejected MonitorError = "webhook rejected alert"
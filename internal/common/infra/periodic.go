@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package infra
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Periodic is a Component that runs a task at a fixed interval in the
+// background, such as sweeping expired records. A failing run is logged and
+// the task runs again at the next tick.
+type Periodic struct {
+	logger   Logger
+	name     string
+	interval time.Duration
+	task     func(context.Context) error
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPeriodic creates a Periodic running task every interval. name
+// identifies the task in logs.
+func NewPeriodic(logger Logger, name string, interval time.Duration, task func(context.Context) error) *Periodic {
+	return &Periodic{
+		logger:   logger,
+		name:     name,
+		interval: interval,
+		task:     task,
+		mu:       sync.Mutex{},
+		cancel:   nil,
+		done:     nil,
+	}
+}
+
+// Start runs the task in the background until Stop is called. The first run
+// happens one interval after Start.
+func (p *Periodic) Start(context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		return ErrPeriodicRunning
+	}
+
+	if p.interval <= 0 {
+		return ErrPeriodicInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	p.cancel, p.done = cancel, done
+
+	go p.loop(ctx, done)
+
+	return nil
+}
+
+// Stop stops the background runs, waiting for a run in progress to return
+// until ctx is done.
+func (p *Periodic) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.cancel, p.done = nil, nil
+	p.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Periodic) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			begin := time.Now()
+
+			err := p.task(ctx)
+			if err != nil && ctx.Err() == nil {
+				p.logger.Error("periodic task failed", "task", p.name, "error", err)
+				continue
+			}
+
+			p.logger.Debug("periodic task ran", "task", p.name, "duration", time.Since(begin))
+		}
+	}
+}
+
+type PeriodicError string
+
+func (e PeriodicError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrPeriodicRunning  PeriodicError = "periodic task is already running"
+	ErrPeriodicInterval PeriodicError = "periodic task interval must be positive"
+)
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package infra_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/infra"
+	"backend.brokedaear.com/internal/common/tests/assert"
+)
+
+func TestPeriodic(t *testing.T) {
+	var runs atomic.Int64
+	ran := make(chan struct{}, 1)
+
+	p := infra.NewPeriodic(nopLogger{}, "count", time.Millisecond, func(context.Context) error {
+		runs.Add(1)
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	err := p.Start(t.Context())
+	assert.NoError(t, err)
+
+	err = p.Start(t.Context())
+	assert.Error(t, err, infra.ErrPeriodicRunning)
+
+	<-ran
+
+	err = p.Stop(t.Context())
+	assert.NoError(t, err)
+
+	after := runs.Load()
+	assert.True(t, after > 0)
+
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, runs.Load(), after)
+}
+
+func TestPeriodic_InvalidInterval(t *testing.T) {
+	p := infra.NewPeriodic(nopLogger{}, "never", 0, func(context.Context) error { return nil })
+
+	err := p.Start(t.Context())
+	assert.Error(t, err, infra.ErrPeriodicInterval)
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package infra
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Queue is a Component that handles items one at a time in the background,
+// such as requests answered before they are handled. It holds at most size
+// items waiting; more are refused, so that a burst of requests cannot pile
+// up work or goroutines. A failing item is logged and the next one is
+// handled.
+type Queue[T any] struct {
+	logger  Logger
+	name    string
+	timeout time.Duration
+	handle  func(context.Context, T) error
+	items   chan T
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewQueue creates a Queue of up to size items, each handled by handle
+// within timeout. name identifies the queue in logs.
+func NewQueue[T any](
+	logger Logger,
+	name string,
+	size int,
+	timeout time.Duration,
+	handle func(context.Context, T) error,
+) *Queue[T] {
+	return &Queue[T]{
+		logger:  logger,
+		name:    name,
+		timeout: timeout,
+		handle:  handle,
+		items:   make(chan T, max(size, 0)),
+		mu:      sync.Mutex{},
+		cancel:  nil,
+		done:    nil,
+	}
+}
+
+// Push adds item to the queue without waiting, and reports whether it was
+// added. Items pushed before Start wait for it.
+func (q *Queue[T]) Push(item T) bool {
+	select {
+	case q.items <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// Start handles the queued items in the background until Stop is called.
+func (q *Queue[T]) Start(context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.cancel != nil {
+		return ErrQueueRunning
+	}
+
+	if cap(q.items) == 0 || q.timeout <= 0 {
+		return ErrQueueConfig
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	q.cancel, q.done = cancel, done
+
+	go q.loop(ctx, done)
+
+	return nil
+}
+
+// Stop stops handling items, waiting for the item in progress until ctx is
+// done. Items still queued are dropped.
+func (q *Queue[T]) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	cancel, done := q.cancel, q.done
+	q.cancel, q.done = nil, nil
+	q.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if n := len(q.items); n > 0 {
+		q.logger.Warn("queue stopped with items left", "queue", q.name, "dropped", n)
+	}
+
+	return nil
+}
+
+func (q *Queue[T]) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-q.items:
+			q.run(ctx, item)
+		}
+	}
+}
+
+func (q *Queue[T]) run(ctx context.Context, item T) {
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+
+	err := q.handle(ctx, item)
+	if err != nil {
+		q.logger.Error("queued item failed", "queue", q.name, "error", err)
+	}
+}
+
+type QueueError string
+
+func (e QueueError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrQueueRunning QueueError = "queue is already running"
+	ErrQueueConfig  QueueError = "queue size and timeout must be positive"
+)
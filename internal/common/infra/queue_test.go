@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package infra_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/infra"
+	"backend.brokedaear.com/internal/common/tests/assert"
+)
+
+func TestQueue(t *testing.T) {
+	handled := make(chan int)
+
+	q := infra.NewQueue(nopLogger{}, "numbers", 2, time.Second, func(_ context.Context, n int) error {
+		handled <- n
+		if n == 1 {
+			return errors.New("odd number")
+		}
+		return nil
+	})
+
+	// Items wait for Start, and the queue refuses more than it holds.
+	assert.True(t, q.Push(1))
+	assert.True(t, q.Push(2))
+	assert.False(t, q.Push(3))
+
+	err := q.Start(t.Context())
+	assert.NoError(t, err)
+
+	err = q.Start(t.Context())
+	assert.Error(t, err, infra.ErrQueueRunning)
+
+	// A failing item does not stop the queue.
+	assert.Equal(t, <-handled, 1)
+	assert.Equal(t, <-handled, 2)
+
+	assert.True(t, q.Push(4))
+	assert.Equal(t, <-handled, 4)
+
+	err = q.Stop(t.Context())
+	assert.NoError(t, err)
+
+	assert.True(t, q.Push(5))
+
+	time.Sleep(5 * time.Millisecond)
+
+	select {
+	case n := <-handled:
+		assert.Equal(t, n, 0)
+	default:
+	}
+}
+
+func TestQueue_InvalidConfig(t *testing.T) {
+	handle := func(context.Context, int) error { return nil }
+
+	for _, q := range []*infra.Queue[int]{
+		infra.NewQueue(nopLogger{}, "empty", 0, time.Second, handle),
+		infra.NewQueue(nopLogger{}, "untimed", 1, 0, handle),
+	} {
+		err := q.Start(t.Context())
+		assert.Error(t, err, infra.ErrQueueConfig)
+	}
+}
@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jwt signs and verifies JSON Web Tokens (RFC 7519) with Ed25519
+// keys (RFC 8037), and publishes the verification keys as a JSON Web Key
+// Set, so that other services can verify tokens without calling back.
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// algorithm is the only signature algorithm signed and accepted. Accepting
+// a single algorithm rules out algorithm confusion attacks.
+const algorithm = "EdDSA"
+
+// Leeway is the clock skew tolerated between the issuer and the verifiers
+// of a token.
+const Leeway = 30 * time.Second
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// RegisteredClaims are the claims of RFC 7519 section 4.1 tokens carry.
+// Times are seconds since the Unix epoch.
+type RegisteredClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Audience  string `json:"aud"`
+	Expires   int64  `json:"exp"`
+	NotBefore int64  `json:"nbf"`
+	IssuedAt  int64  `json:"iat"`
+	ID        string `json:"jti"`
+}
+
+// Validate checks that the claims were issued by issuer for audience, and
+// that they are valid at now, give or take Leeway.
+func (c RegisteredClaims) Validate(now time.Time, issuer, audience string) error {
+	if c.Issuer != issuer || c.Audience != audience {
+		return ErrClaims
+	}
+
+	if !now.Before(time.Unix(c.Expires, 0).Add(Leeway)) {
+		return ErrExpired
+	}
+
+	if now.Add(Leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return ErrNotYetValid
+	}
+
+	return nil
+}
+
+// sign returns claims as a token signed by key.
+func sign(key Key, claims any) (string, error) {
+	h, err := json.Marshal(header{Algorithm: algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(key.Private, []byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verify checks the signature of token with the key lookup returns for the
+// key ID of its header, and decodes its payload into claims.
+func verify(token string, lookup func(kid string) (ed25519.PublicKey, bool), claims any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrMalformed
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrMalformed
+	}
+
+	var h header
+
+	err = json.Unmarshal(rawHeader, &h)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	if h.Algorithm != algorithm {
+		return ErrAlgorithm
+	}
+
+	public, ok := lookup(h.KeyID)
+	if !ok {
+		return ErrUnknownKey
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrMalformed
+	}
+
+	signingInput := token[:len(parts[0])+1+len(parts[1])]
+	if !ed25519.Verify(public, []byte(signingInput), signature) {
+		return ErrSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ErrMalformed
+	}
+
+	err = json.Unmarshal(payload, claims)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	return nil
+}
+
+// JWK is a public Ed25519 key as a JSON Web Key (RFC 7517, RFC 8037).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKS is a JSON Web Key Set, as served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key returns the key of set with kid.
+func (s JWKS) Key(kid string) (ed25519.PublicKey, bool) {
+	i := slices.IndexFunc(s.Keys, func(k JWK) bool { return k.KeyID == kid })
+	if i < 0 {
+		return nil, false
+	}
+
+	k := s.Keys[i]
+	if k.KeyType != "OKP" || k.Curve != "Ed25519" {
+		return nil, false
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(x) != ed25519.PublicKeySize {
+		return nil, false
+	}
+
+	return ed25519.PublicKey(x), true
+}
+
+// Verify checks the signature of token against the keys of s, and decodes
+// its payload into claims. It does not validate the claims.
+func (s JWKS) Verify(token string, claims any) error {
+	return verify(token, s.Key, claims)
+}
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+const (
+	ErrMalformed   Error = "token is malformed"
+	ErrAlgorithm   Error = "token algorithm is not supported"
+	ErrUnknownKey  Error = "token is signed with an unknown key"
+	ErrSignature   Error = "token signature is invalid"
+	ErrClaims      Error = "token is for another issuer or audience"
+	ErrExpired     Error = "token is expired"
+	ErrNotYetValid Error = "token is not valid yet"
+	ErrKey         Error = "key is not a PKCS #8 Ed25519 private key"
+	ErrRingConfig  Error = "key ring rotation and retention must not be negative"
+)
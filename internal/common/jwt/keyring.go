@@ -0,0 +1,284 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"sync"
+	"time"
+)
+
+// Key is an Ed25519 signing key.
+type Key struct {
+	// ID is the RFC 7638 thumbprint of the public key, so that the same key
+	// always has the same ID, whichever process loaded it.
+	ID string
+
+	Private ed25519.PrivateKey
+}
+
+// NewKey returns a random key.
+func NewKey() Key {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	return newKey(private)
+}
+
+// ParseKey parses a PEM encoded PKCS #8 Ed25519 private key, as written by
+// "openssl genpkey -algorithm ed25519".
+func ParseKey(data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return Key{}, ErrKey
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return Key{}, ErrKey
+	}
+
+	private, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return Key{}, ErrKey
+	}
+
+	return newKey(private), nil
+}
+
+// LoadKeys reads the keys of the files at paths, in order.
+func LoadKeys(paths ...string) ([]Key, error) {
+	keys := make([]Key, 0, len(paths))
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParseKey(data)
+		if err != nil {
+			return nil, &os.PathError{Op: "parse", Path: path, Err: err}
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// MarshalKey returns key PEM encoded, as read by ParseKey.
+func MarshalKey(key Key) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Headers: nil, Bytes: der}), nil
+}
+
+func newKey(private ed25519.PrivateKey) Key {
+	jwk := publicJWK(private.Public().(ed25519.PublicKey), "")
+
+	// The members of the thumbprint input are the required members of the
+	// key, in lexicographic order, without whitespace.
+	thumbprint := sha256.Sum256([]byte(`{"crv":"` + jwk.Curve + `","kty":"` + jwk.KeyType + `","x":"` + jwk.X + `"}`))
+
+	return Key{
+		ID:      base64.RawURLEncoding.EncodeToString(thumbprint[:]),
+		Private: private,
+	}
+}
+
+func publicJWK(public ed25519.PublicKey, kid string) JWK {
+	return JWK{
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(public),
+		KeyID:     kid,
+		Use:       "sig",
+		Algorithm: algorithm,
+	}
+}
+
+// RingConfig configures the rotation of a key ring.
+type RingConfig struct {
+	// Rotation is how long a key signs before the next key takes over. Zero
+	// disables rotation, such as for keys loaded from files, which are
+	// rotated by replacing the files.
+	Rotation time.Duration
+
+	// Retention is how long a key still verifies once it stopped signing.
+	// It must cover the lifetime of the tokens the key signed.
+	Retention time.Duration
+}
+
+func (c RingConfig) Validate() error {
+	if c.Rotation < 0 || c.Retention < 0 {
+		return ErrRingConfig
+	}
+
+	return nil
+}
+
+func (c RingConfig) Value() any {
+	return c
+}
+
+type ringKey struct {
+	Key
+
+	// activated is when the key started signing.
+	activated time.Time
+
+	// retired is when the key stopped signing.
+	retired time.Time
+}
+
+// KeyRing signs tokens with its active key, and verifies tokens signed by
+// any of its keys. A rotating ring publishes the key that signs next ahead
+// of time, so that verifiers caching the key set already know it when it
+// starts signing, and keeps retired keys until the tokens they signed
+// expired.
+type KeyRing struct {
+	mu     sync.RWMutex
+	config RingConfig
+
+	// next is the key that signs after the active one, or nil when the ring
+	// does not rotate.
+	next *Key
+
+	active  ringKey
+	retired []ringKey
+}
+
+// NewKeyRing returns a key ring whose first key is keys[0]. The other keys
+// only verify. Without keys, a random key signs.
+func NewKeyRing(config RingConfig, now time.Time, keys ...Key) (*KeyRing, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		keys = []Key{NewKey()}
+	}
+
+	r := &KeyRing{
+		mu:      sync.RWMutex{},
+		config:  config,
+		next:    nil,
+		active:  ringKey{Key: keys[0], activated: now, retired: time.Time{}},
+		retired: make([]ringKey, 0, len(keys)-1),
+	}
+
+	for _, key := range keys[1:] {
+		r.retired = append(r.retired, ringKey{Key: key, activated: time.Time{}, retired: now})
+	}
+
+	if config.Rotation > 0 {
+		next := NewKey()
+		r.next = &next
+	}
+
+	return r, nil
+}
+
+// Rotate makes the next key sign if the active key has signed for the
+// rotation interval at now, and forgets the keys retired longer than the
+// retention. It reports whether the keys changed.
+func (r *KeyRing) Rotate(now time.Time) bool {
+	if r.config.Rotation == 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := false
+
+	kept := r.retired[:0]
+	for _, k := range r.retired {
+		if now.Sub(k.retired) < r.config.Retention {
+			kept = append(kept, k)
+		} else {
+			changed = true
+		}
+	}
+
+	r.retired = kept
+
+	if now.Sub(r.active.activated) < r.config.Rotation {
+		return changed
+	}
+
+	r.active.retired = now
+	r.retired = append(r.retired, r.active)
+	r.active = ringKey{Key: *r.next, activated: now, retired: time.Time{}}
+
+	next := NewKey()
+	r.next = &next
+
+	return true
+}
+
+// Sign returns claims as a token signed by the active key.
+func (r *KeyRing) Sign(claims any) (string, error) {
+	r.mu.RLock()
+	key := r.active.Key
+	r.mu.RUnlock()
+
+	return sign(key, claims)
+}
+
+// Verify checks that token was signed by a key of the ring, and decodes its
+// payload into claims. It does not validate the claims.
+func (r *KeyRing) Verify(token string, claims any) error {
+	return verify(token, r.public, claims)
+}
+
+// JWKS returns the public keys of the ring: the next, active and retired
+// keys.
+func (r *KeyRing) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(r.retired)+2)}
+
+	if r.next != nil {
+		set.Keys = append(set.Keys, publicJWK(r.next.Private.Public().(ed25519.PublicKey), r.next.ID))
+	}
+
+	set.Keys = append(set.Keys, publicJWK(r.active.Private.Public().(ed25519.PublicKey), r.active.ID))
+
+	for _, k := range r.retired {
+		set.Keys = append(set.Keys, publicJWK(k.Private.Public().(ed25519.PublicKey), k.ID))
+	}
+
+	return set
+}
+
+// public returns the public key of the active or a retired key with kid.
+// The next key does not verify until it signs.
+func (r *KeyRing) public(kid string) (ed25519.PublicKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.active.ID == kid {
+		return r.active.Private.Public().(ed25519.PublicKey), true
+	}
+
+	for _, k := range r.retired {
+		if k.ID == kid {
+			return k.Private.Public().(ed25519.PublicKey), true
+		}
+	}
+
+	return nil, false
+}
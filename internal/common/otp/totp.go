@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package otp implements time-based one-time passwords, per RFC 6238, as
+// generated by authenticator apps.
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // HMAC-SHA1 is what RFC 6238 and authenticator apps use
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SecretLength is the length of generated secrets, the output size of
+// SHA-1, as recommended by RFC 4226.
+const SecretLength = 20
+
+// Params are the parameters of a TOTP. Authenticator apps only reliably
+// support DefaultParams.
+type Params struct {
+	// Digits is the number of digits of a code.
+	Digits int
+
+	// Period is how long a code is valid.
+	Period time.Duration
+
+	// Skew is the number of periods before and after the current one whose
+	// codes are also accepted, to tolerate clock drift between the server
+	// and the device of the customer.
+	Skew int
+}
+
+// DefaultParams returns 6-digit codes changing every 30 seconds, accepting
+// the codes of the previous and next periods.
+func DefaultParams() Params {
+	return Params{
+		Digits: 6,
+		Period: 30 * time.Second,
+		Skew:   1,
+	}
+}
+
+func (p Params) Validate() error {
+	if p.Digits < 6 || p.Digits > 10 || p.Period < time.Second || p.Skew < 0 {
+		return ErrParams
+	}
+
+	return nil
+}
+
+// NewSecret returns a random secret.
+func NewSecret() []byte {
+	secret := make([]byte, SecretLength)
+	_, _ = rand.Read(secret)
+
+	return secret
+}
+
+// EncodeSecret returns secret as the unpadded base32 authenticator apps
+// expect when the secret is typed in.
+func EncodeSecret(secret []byte) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+}
+
+// Counter returns the number of periods elapsed at t since the Unix epoch.
+func (p Params) Counter(t time.Time) int64 {
+	return t.Unix() / int64(p.Period/time.Second)
+}
+
+// Code returns the code of secret for a counter, per the HOTP algorithm of
+// RFC 4226.
+func (p Params) Code(secret []byte, counter int64) string {
+	mac := hmac.New(sha1.New, secret)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := int64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+
+	mod := int64(1)
+	for range p.Digits {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", p.Digits, value%mod)
+}
+
+// Verify reports whether code is valid for secret at t, allowing for the
+// skew, and returns the counter it was generated for. Callers must reject
+// counters that were already used, so that codes cannot be replayed.
+func (p Params) Verify(secret []byte, code string, t time.Time) (int64, bool) {
+	if len(code) != p.Digits {
+		return 0, false
+	}
+
+	current := p.Counter(t)
+
+	var (
+		matched int64
+		ok      bool
+	)
+
+	// Every candidate is compared, so that the time taken does not tell
+	// which one matched.
+	for i := -p.Skew; i <= p.Skew; i++ {
+		counter := current + int64(i)
+		if subtle.ConstantTimeCompare([]byte(p.Code(secret, counter)), []byte(code)) == 1 && !ok {
+			matched, ok = counter, true
+		}
+	}
+
+	return matched, ok
+}
+
+// URI returns the otpauth URI of a secret, which authenticator apps enroll
+// from, usually scanned as a QR code.
+func (p Params) URI(issuer, account string, secret []byte) string {
+	q := url.Values{}
+	q.Set("secret", EncodeSecret(secret))
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", strconv.Itoa(p.Digits))
+	q.Set("period", strconv.Itoa(int(p.Period/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: q.Encode(),
+	}
+
+	return u.String()
+}
+
+type OTPError string
+
+func (e OTPError) Error() string {
+	return string(e)
+}
+
+const ErrParams OTPError = "totp must have 6 to 10 digits, a period of at least a second and a non-negative skew"
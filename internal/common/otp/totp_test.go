@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package otp_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/otp"
+	"backend.brokedaear.com/internal/common/tests/assert"
+)
+
+func TestCode_RFC6238(t *testing.T) {
+	// The SHA-1 test vectors of RFC 6238, appendix B.
+	secret := []byte("12345678901234567890")
+	p := otp.Params{Digits: 8, Period: 30 * time.Second, Skew: 0}
+
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, v := range vectors {
+		assert.Equal(t, p.Code(secret, p.Counter(time.Unix(v.unix, 0))), v.code)
+	}
+}
+
+func TestVerify_Skew(t *testing.T) {
+	p := otp.DefaultParams()
+	secret := otp.NewSecret()
+	now := time.Unix(1_700_000_000, 0)
+
+	code := p.Code(secret, p.Counter(now))
+
+	counter, ok := p.Verify(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, counter, p.Counter(now))
+
+	// A code from the previous period is still accepted, not one from two
+	// periods ago.
+	_, ok = p.Verify(secret, code, now.Add(30*time.Second))
+	assert.True(t, ok)
+
+	_, ok = p.Verify(secret, code, now.Add(time.Minute))
+	assert.False(t, ok)
+
+	_, ok = p.Verify(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	p := otp.DefaultParams()
+
+	u, err := url.Parse(p.URI("BROKE DA EAR", "jane@example.com", []byte("12345678901234567890")))
+	assert.NoError(t, err)
+
+	assert.Equal(t, u.Scheme, "otpauth")
+	assert.Equal(t, u.Host, "totp")
+	assert.Equal(t, u.Path, "/BROKE DA EAR:jane@example.com")
+	assert.Equal(t, u.Query().Get("secret"), "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	assert.Equal(t, u.Query().Get("issuer"), "BROKE DA EAR")
+	assert.Equal(t, u.Query().Get("digits"), "6")
+	assert.Equal(t, u.Query().Get("period"), "30")
+}
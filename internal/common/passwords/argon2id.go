@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package passwords hashes passwords and screens them against breached
+// password lists.
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2idParams are the cost parameters of argon2id. Raising them makes each
+// hash more expensive to compute, for the server and attackers alike.
+type Argon2idParams struct {
+	// Time is the number of passes over the memory.
+	Time uint32
+
+	// Memory is the size of the memory in KiB.
+	Memory uint32
+
+	// Threads is the degree of parallelism.
+	Threads uint8
+
+	// SaltLength is the length of the random salt in bytes.
+	SaltLength uint32
+
+	// KeyLength is the length of the derived key in bytes.
+	KeyLength uint32
+}
+
+// DefaultArgon2idParams returns the parameters recommended by the OWASP
+// password storage cheat sheet.
+func DefaultArgon2idParams() Argon2idParams {
+	const (
+		memory     = 19 * 1024
+		time       = 2
+		saltLength = 16
+		keyLength  = 32
+	)
+
+	return Argon2idParams{
+		Time:       time,
+		Memory:     memory,
+		Threads:    1,
+		SaltLength: saltLength,
+		KeyLength:  keyLength,
+	}
+}
+
+func (p Argon2idParams) Validate() error {
+	const (
+		minSaltLength = 16
+		minKeyLength  = 16
+	)
+
+	if p.Time == 0 || p.Threads == 0 {
+		return ErrArgon2idParams
+	}
+
+	// argon2 requires at least 8 KiB of memory per thread.
+	if p.Memory < 8*uint32(p.Threads) {
+		return ErrArgon2idParams
+	}
+
+	if p.SaltLength < minSaltLength || p.KeyLength < minKeyLength {
+		return ErrArgon2idParams
+	}
+
+	return nil
+}
+
+func (p Argon2idParams) Value() any {
+	return p
+}
+
+// Argon2id hashes passwords with argon2id. Hashes are encoded in the PHC
+// string format, which records the parameters next to the salt and the key,
+// so hashes made with older parameters still verify.
+type Argon2id struct {
+	params Argon2idParams
+}
+
+func NewArgon2id(params Argon2idParams) (*Argon2id, error) {
+	err := params.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Argon2id{
+		params: params,
+	}, nil
+}
+
+func (a *Argon2id) Hash(password string) ([]byte, error) {
+	salt := make([]byte, a.params.SaltLength)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.params.Time, a.params.Memory, a.params.Threads, a.params.KeyLength)
+
+	return []byte(encodeArgon2id(a.params, salt, key)), nil
+}
+
+// Verify reports whether password matches hash and, when it does, whether
+// hash was made with parameters other than the current ones.
+func (a *Argon2id) Verify(password string, hash []byte) (bool, bool, error) {
+	params, salt, key, err := decodeArgon2id(string(hash))
+	if err != nil {
+		return false, false, err
+	}
+
+	//nolint:gosec // Lengths come from a hash this package encoded.
+	candidate := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return false, false, nil
+	}
+
+	return true, params != a.params, nil
+}
+
+// encodeArgon2id encodes a hash as $argon2id$v=19$m=<memory>,t=<time>,
+// p=<threads>$<salt>$<key>, with the salt and the key in unpadded base64.
+func encodeArgon2id(p Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		p.Memory,
+		p.Time,
+		p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	const fields = 6
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != fields || parts[0] != "" || parts[1] != "argon2id" {
+		return Argon2idParams{}, nil, nil, ErrArgon2idHash
+	}
+
+	var version int
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return Argon2idParams{}, nil, nil, ErrArgon2idHash
+	}
+
+	var p Argon2idParams
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads)
+	if err != nil {
+		return Argon2idParams{}, nil, nil, ErrArgon2idHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, ErrArgon2idHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2idParams{}, nil, nil, ErrArgon2idHash
+	}
+
+	//nolint:gosec // Decoded lengths are bounded by the stored hash.
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+
+	return p, salt, key, nil
+}
+
+type PasswordError string
+
+func (e PasswordError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrArgon2idParams PasswordError = "argon2id parameters are out of range"
+	ErrArgon2idHash   PasswordError = "malformed argon2id hash"
+)
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package passwords
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+)
+
+// BreachedList is a set of passwords known from data breaches. Only digests
+// of the passwords are kept in memory.
+type BreachedList struct {
+	digests map[[sha256.Size]byte]struct{}
+}
+
+// LoadBreachedList reads a breached password list from a file with one
+// password per line. Empty lines are skipped.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return ReadBreachedList(f)
+}
+
+// ReadBreachedList reads a breached password list with one password per line.
+// Empty lines are skipped.
+func ReadBreachedList(r io.Reader) (*BreachedList, error) {
+	l := &BreachedList{
+		digests: make(map[[sha256.Size]byte]struct{}),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		l.digests[sha256.Sum256(line)] = struct{}{}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *BreachedList) Breached(_ context.Context, password string) (bool, error) {
+	_, ok := l.digests[sha256.Sum256([]byte(password))]
+	return ok, nil
+}
+
+// Len returns the number of passwords in the list.
+func (l *BreachedList) Len() int {
+	return len(l.digests)
+}
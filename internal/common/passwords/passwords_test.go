@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package passwords_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"backend.brokedaear.com/internal/common/passwords"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+)
+
+// cheapParams keeps hashing fast in tests.
+func cheapParams() passwords.Argon2idParams {
+	return passwords.Argon2idParams{
+		Time:       1,
+		Memory:     64,
+		Threads:    1,
+		SaltLength: 16,
+		KeyLength:  32,
+	}
+}
+
+func newHasher(t *testing.T, p passwords.Argon2idParams) *passwords.Argon2id {
+	t.Helper()
+
+	h, err := passwords.NewArgon2id(p)
+	assert.NoError(t, err)
+
+	return h
+}
+
+func TestArgon2id_HashAndVerify(t *testing.T) {
+	h := newHasher(t, cheapParams())
+
+	hash, err := h.Hash("correct horse battery")
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(hash, []byte("$argon2id$v=19$m=64,t=1,p=1$")))
+
+	match, rehash, err := h.Verify("correct horse battery", hash)
+	assert.NoError(t, err)
+	assert.True(t, match)
+	assert.False(t, rehash)
+
+	match, _, err = h.Verify("wrong horse battery", hash)
+	assert.NoError(t, err)
+	assert.False(t, match)
+}
+
+func TestArgon2id_SaltsEveryHash(t *testing.T) {
+	h := newHasher(t, cheapParams())
+
+	a, err := h.Hash("correct horse battery")
+	assert.NoError(t, err)
+
+	b, err := h.Hash("correct horse battery")
+	assert.NoError(t, err)
+
+	assert.False(t, bytes.Equal(a, b))
+}
+
+func TestArgon2id_RehashWhenParamsChange(t *testing.T) {
+	old := newHasher(t, cheapParams())
+
+	hash, err := old.Hash("correct horse battery")
+	assert.NoError(t, err)
+
+	stronger := cheapParams()
+	stronger.Time = 2
+	h := newHasher(t, stronger)
+
+	match, rehash, err := h.Verify("correct horse battery", hash)
+	assert.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, rehash)
+}
+
+func TestArgon2id_MalformedHash(t *testing.T) {
+	h := newHasher(t, cheapParams())
+
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=64$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!$a2V5",
+	} {
+		_, _, err := h.Verify("correct horse battery", []byte(hash))
+		assert.Error(t, err, passwords.ErrArgon2idHash)
+	}
+}
+
+func TestArgon2idParams_Validate(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		mutate func(p *passwords.Argon2idParams)
+	}{
+		{
+			CaseBase: test.NewCaseBase("defaults", nil, false),
+			mutate:   func(p *passwords.Argon2idParams) { *p = passwords.DefaultArgon2idParams() },
+		},
+		{
+			CaseBase: test.NewCaseBase("no time", passwords.ErrArgon2idParams, true),
+			mutate:   func(p *passwords.Argon2idParams) { p.Time = 0 },
+		},
+		{
+			CaseBase: test.NewCaseBase("too little memory", passwords.ErrArgon2idParams, true),
+			mutate:   func(p *passwords.Argon2idParams) { p.Memory = 4 },
+		},
+		{
+			CaseBase: test.NewCaseBase("short salt", passwords.ErrArgon2idParams, true),
+			mutate:   func(p *passwords.Argon2idParams) { p.SaltLength = 8 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			p := cheapParams()
+			tt.mutate(&p)
+
+			err := p.Validate()
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+			if tt.WantErr {
+				assert.Error(t, err, tt.Want.(error))
+			}
+		})
+	}
+}
+
+func TestBreachedList(t *testing.T) {
+	l, err := passwords.ReadBreachedList(strings.NewReader("password1234\n\nqwertyuiop12\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, l.Len(), 2)
+
+	breached, err := l.Breached(t.Context(), "qwertyuiop12")
+	assert.NoError(t, err)
+	assert.True(t, breached)
+
+	breached, err = l.Breached(t.Context(), "correct horse battery")
+	assert.NoError(t, err)
+	assert.False(t, breached)
+}
@@ -72,7 +72,7 @@ type ExporterConfig struct {
 
 	// Headers defines any HTTP headers that could be sent with the
 	// request to the endpoint.
-	Headers map[string]string
+	Headers map[string]string `redact:"true"`
 }
 
 func NewExporterConfig(
@@ -26,3 +26,90 @@ var MetricRequestsInFlight = Metric{ //nolint:gochecknoglobals // makes more sen
 	Unit:        "{count}",
 	Description: "Measures the number of requests currently being processed by the server.",
 }
+
+// MetricLogsDropped is a metric that counts log entries suppressed by
+// sampling or deduplication before reaching any log output.
+var MetricLogsDropped = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "logs_dropped",
+	Unit:        "{count}",
+	Description: "Counts log entries suppressed by sampling or deduplication.",
+}
+
+// MetricRuntimeGoroutines is a metric that measures the number of live
+// goroutines.
+var MetricRuntimeGoroutines = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "runtime_goroutines",
+	Unit:        "{goroutine}",
+	Description: "Measures the number of live goroutines.",
+}
+
+// MetricRuntimeHeapBytes is a metric that measures the bytes of allocated
+// heap objects.
+var MetricRuntimeHeapBytes = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "runtime_heap_bytes",
+	Unit:        "By",
+	Description: "Measures the bytes of allocated heap objects.",
+}
+
+// MetricRuntimeGCPauseMicros is a metric that measures the duration of the
+// most recent garbage collection pause, in microseconds.
+var MetricRuntimeGCPauseMicros = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "runtime_gc_pause_micros",
+	Unit:        "us",
+	Description: "Measures the duration of the most recent garbage collection pause, in microseconds.",
+}
+
+// MetricProcessOpenFDs is a metric that measures the number of file
+// descriptors open in the process.
+var MetricProcessOpenFDs = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "process_open_fds",
+	Unit:        "{fd}",
+	Description: "Measures the number of file descriptors open in the process.",
+}
+
+// MetricProcessMaxFDs is a metric that measures the maximum number of file
+// descriptors the process may open.
+var MetricProcessMaxFDs = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "process_max_fds",
+	Unit:        "{fd}",
+	Description: "Measures the maximum number of file descriptors the process may open.",
+}
+
+// MetricDependencyUp is a metric that reports whether a dependency probe
+// passed, 1 if it did and 0 otherwise.
+var MetricDependencyUp = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "dependency_up",
+	Unit:        "{status}",
+	Description: "Reports whether a dependency probe passed, 1 if it did and 0 otherwise.",
+}
+
+// MetricLoginsSucceeded is a metric that counts successful customer logins.
+var MetricLoginsSucceeded = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "logins_succeeded",
+	Unit:        "{count}",
+	Description: "Counts successful customer logins.",
+}
+
+// MetricLoginsFailed is a metric that counts failed customer logins, by the
+// reason of the failure.
+var MetricLoginsFailed = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "logins_failed",
+	Unit:        "{count}",
+	Description: "Counts failed customer logins by reason.",
+}
+
+// MetricTrialsStarted is a metric that counts the trials customers start,
+// by product.
+var MetricTrialsStarted = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "trials_started",
+	Unit:        "{count}",
+	Description: "Counts trials started by product.",
+}
+
+// MetricTrialsConverted is a metric that counts the trials bought, by
+// product.
+var MetricTrialsConverted = Metric{ //nolint:gochecknoglobals // makes more sense like this.
+	Name:        "trials_converted",
+	Unit:        "{count}",
+	Description: "Counts trials converted to licenses on purchase by product.",
+}
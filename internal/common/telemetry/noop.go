@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry
+
+import (
+	"context"
+
+	otelmetric "go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// noopTelemetry is a Telemetry that records nothing.
+type noopTelemetry struct {
+	meter  otelmetric.Meter
+	tracer oteltrace.Tracer
+}
+
+// NewNoop creates a Telemetry that records nothing. It suits tests and
+// components that run with telemetry disabled.
+func NewNoop() Telemetry {
+	return &noopTelemetry{
+		meter:  metricnoop.NewMeterProvider().Meter(""),
+		tracer: tracenoop.NewTracerProvider().Tracer(""),
+	}
+}
+
+func (t *noopTelemetry) Histogram(metric Metric) (otelmetric.Int64Histogram, error) {
+	return t.meter.Int64Histogram(metric.Name)
+}
+
+func (t *noopTelemetry) UpDownCounter(metric Metric) (otelmetric.Int64UpDownCounter, error) {
+	return t.meter.Int64UpDownCounter(metric.Name)
+}
+
+func (t *noopTelemetry) Counter(metric Metric) (otelmetric.Int64Counter, error) {
+	return t.meter.Int64Counter(metric.Name)
+}
+
+func (t *noopTelemetry) Gauge(metric Metric) (otelmetric.Int64Gauge, error) {
+	return t.meter.Int64Gauge(metric.Name)
+}
+
+func (t *noopTelemetry) TraceStart(ctx context.Context, name string) (
+	context.Context,
+	oteltrace.Span,
+) { //nolint:ireturn // interface requires returning concrete type
+	//nolint:spancheck // span is intentionally returned for caller to manage
+	return t.tracer.Start(ctx, name)
+}
+
+func (t *noopTelemetry) Close() error {
+	return nil
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry_test
+
+import (
+	"testing"
+
+	"backend.brokedaear.com/internal/common/telemetry"
+	"backend.brokedaear.com/internal/common/tests/assert"
+)
+
+func TestNewNoop(t *testing.T) {
+	tel := telemetry.NewNoop()
+
+	counter, err := tel.Counter(telemetry.MetricLogsDropped)
+	assert.NoError(t, err)
+	counter.Add(t.Context(), 1)
+
+	gauge, err := tel.Gauge(telemetry.MetricRuntimeGoroutines)
+	assert.NoError(t, err)
+	gauge.Record(t.Context(), 1)
+
+	histogram, err := tel.Histogram(telemetry.MetricRequestDurationMillis)
+	assert.NoError(t, err)
+	histogram.Record(t.Context(), 1)
+
+	upDown, err := tel.UpDownCounter(telemetry.MetricRequestsInFlight)
+	assert.NoError(t, err)
+	upDown.Add(t.Context(), 1)
+
+	_, span := tel.TraceStart(t.Context(), "noop")
+	span.End()
+
+	assert.NoError(t, tel.Close())
+}
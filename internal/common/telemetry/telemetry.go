@@ -21,6 +21,7 @@ import (
 type Telemetry interface {
 	Histogram(Metric) (otelmetric.Int64Histogram, error)
 	UpDownCounter(Metric) (otelmetric.Int64UpDownCounter, error)
+	Counter(Metric) (otelmetric.Int64Counter, error)
 	Gauge(Metric) (otelmetric.Int64Gauge, error)
 	TraceStart(context.Context, string) (context.Context, oteltrace.Span)
 	io.Closer
@@ -115,6 +116,20 @@ func (t *otelTelemetry) UpDownCounter(metric Metric) (
 	return counter, nil
 }
 
+// Counter creates a new monotonic int64 counter meter.
+func (t *otelTelemetry) Counter(metric Metric) (otelmetric.Int64Counter, error) {
+	counter, err := t.meter.Int64Counter(
+		metric.Name,
+		otelmetric.WithDescription(metric.Description),
+		otelmetric.WithUnit(metric.Unit),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create int64 counter")
+	}
+
+	return counter, nil
+}
+
 // Gauge creates a new int64 gauge meter.
 func (t *otelTelemetry) Gauge(metric Metric) (otelmetric.Int64Gauge, error) {
 	gauge, err := t.meter.Int64Gauge(
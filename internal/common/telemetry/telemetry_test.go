@@ -123,6 +123,26 @@ func TestOtelTelemetry_UpDownCounter(t *testing.T) {
 	counter.Add(ctx, -2)
 }
 
+func TestOtelTelemetry_Counter(t *testing.T) {
+	ctx := t.Context()
+	tel, err := telemetry.New(ctx, newTelConfig())
+	assert.NoError(t, err)
+	if tel == nil {
+		t.Error("expected non-nil telemetry")
+	}
+	defer func() {
+		_ = tel.Close()
+	}()
+
+	counter, err := tel.Counter(telemetry.MetricLogsDropped)
+	assert.NoError(t, err)
+	if counter == nil {
+		t.Error("expected non-nil counter")
+	}
+
+	counter.Add(ctx, 3)
+}
+
 func TestOtelTelemetry_Gauge(t *testing.T) {
 	ctx := t.Context()
 
@@ -0,0 +1,274 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loggers
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RingConfig configures a RingCore.
+type RingConfig struct {
+	// MaxEntries is the number of most recent entries kept.
+	MaxEntries int
+
+	// MaxBytes caps the approximate size of all kept entries. The oldest
+	// entries are evicted first when the cap is exceeded.
+	MaxBytes int
+
+	// Level is the minimum level of kept entries.
+	Level zapcore.Level
+}
+
+func (r RingConfig) Validate() error {
+	if r.MaxEntries <= 0 {
+		return errors.New("ring max entries must be greater than zero")
+	}
+
+	if r.MaxBytes <= 0 {
+		return errors.New("ring max bytes must be greater than zero")
+	}
+
+	return nil
+}
+
+func (r RingConfig) Value() any {
+	return r
+}
+
+// RingEntry is a log entry kept by a RingCore.
+type RingEntry struct {
+	// Seq is the position of the entry in the order of all entries written
+	// to the ring. It starts at 1 and only grows.
+	Seq     uint64          `json:"seq"`
+	Time    time.Time       `json:"time"`
+	Level   zapcore.Level   `json:"level"`
+	Logger  string          `json:"logger,omitempty"`
+	Message string          `json:"message"`
+	Caller  string          `json:"caller,omitempty"`
+	Fields  json.RawMessage `json:"fields,omitempty"`
+
+	// evicted marks the entry left in the slot of an evicted one.
+	evicted bool
+}
+
+// size approximates the memory held by the entry.
+func (e *RingEntry) size() int64 {
+	return int64(len(e.Logger) + len(e.Message) + len(e.Caller) + len(e.Fields))
+}
+
+// RingCore is a zap core that keeps the most recent log entries in memory,
+// capped by count and by size. Writers never block each other or readers;
+// the ring is built only on atomic operations. Register it through the
+// Observers of ZapConfig.
+type RingCore struct {
+	zapcore.LevelEnabler
+	ring   *ring
+	fields []zapcore.Field
+}
+
+// NewRingCore creates an empty RingCore.
+func NewRingCore(config RingConfig) (*RingCore, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RingCore{
+		LevelEnabler: config.Level,
+		ring:         newRing(config.MaxEntries, int64(config.MaxBytes)),
+		fields:       nil,
+	}, nil
+}
+
+func (c *RingCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+
+	return &RingCore{
+		LevelEnabler: c.LevelEnabler,
+		ring:         c.ring,
+		fields:       all,
+	}
+}
+
+func (c *RingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *RingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	var raw json.RawMessage
+	if len(enc.Fields) > 0 {
+		b, err := json.Marshal(enc.Fields)
+		if err != nil {
+			return err
+		}
+		raw = b
+	}
+
+	caller := ""
+	if ent.Caller.Defined {
+		caller = ent.Caller.TrimmedPath()
+	}
+
+	c.ring.put(&RingEntry{
+		Seq:     0,
+		Time:    ent.Time,
+		Level:   ent.Level,
+		Logger:  ent.LoggerName,
+		Message: ent.Message,
+		Caller:  caller,
+		Fields:  raw,
+		evicted: false,
+	})
+
+	return nil
+}
+
+func (c *RingCore) Sync() error {
+	return nil
+}
+
+// Entries returns the kept entries in the order they were written.
+func (c *RingCore) Entries() []*RingEntry {
+	return c.ring.since(0)
+}
+
+// Since returns the kept entries written after the entry with sequence
+// number seq, in the order they were written. It stops short of entries
+// still being written, which later calls return.
+func (c *RingCore) Since(seq uint64) []*RingEntry {
+	return c.ring.since(seq)
+}
+
+// ring is a fixed size lock-free ring of entries. Each entry is stored in the
+// slot of its sequence number modulo the slot count, so a slot holds either
+// nil, the entry it was last given, or the marker left by its eviction.
+// Sequence numbers are taken before their entry is stored, so a slot may
+// still hold an older entry, or nil, while its new one is being published.
+type ring struct {
+	slots    []atomic.Pointer[RingEntry]
+	last     atomic.Uint64
+	bytes    atomic.Int64
+	maxBytes int64
+}
+
+func newRing(n int, maxBytes int64) *ring {
+	return &ring{
+		slots:    make([]atomic.Pointer[RingEntry], n),
+		last:     atomic.Uint64{},
+		bytes:    atomic.Int64{},
+		maxBytes: maxBytes,
+	}
+}
+
+func (r *ring) slot(seq uint64) *atomic.Pointer[RingEntry] {
+	return &r.slots[seq%uint64(len(r.slots))]
+}
+
+// put stores e, evicting the oldest entries while the ring is over its byte
+// cap. Entries larger than the whole cap are never stored.
+func (r *ring) put(e *RingEntry) {
+	size := e.size()
+	if size > r.maxBytes {
+		return
+	}
+
+	e.Seq = r.last.Add(1)
+
+	old := r.slot(e.Seq).Swap(e)
+	if old != nil {
+		r.bytes.Add(-old.size())
+	}
+
+	if r.bytes.Add(size) <= r.maxBytes {
+		return
+	}
+
+	for seq := r.oldest(e.Seq); seq < e.Seq && r.bytes.Load() > r.maxBytes; seq++ {
+		s := r.slot(seq)
+
+		p := s.Load()
+		if p == nil || p.Seq != seq || p.evicted {
+			continue
+		}
+
+		// Only the goroutine that wins the swap accounts for the eviction.
+		if s.CompareAndSwap(p, evictedEntry(seq)) {
+			r.bytes.Add(-p.size())
+		}
+	}
+}
+
+// oldest returns the sequence number of the oldest entry that may still be
+// kept when head is the newest.
+func (r *ring) oldest(head uint64) uint64 {
+	n := uint64(len(r.slots))
+	if head < n {
+		return 1
+	}
+
+	return head - n + 1
+}
+
+// since returns the kept entries after seq. It stops at the first entry not
+// published yet, so that readers following the ring by sequence number never
+// move past an entry before it is stored.
+func (r *ring) since(seq uint64) []*RingEntry {
+	head := r.last.Load()
+
+	start := max(r.oldest(head), seq+1)
+	if start > head {
+		return nil
+	}
+
+	entries := make([]*RingEntry, 0, head-start+1)
+	for s := start; s <= head; s++ {
+		p := r.slot(s).Load()
+		if p == nil || p.Seq < s {
+			break
+		}
+
+		if p.Seq > s || p.evicted {
+			continue
+		}
+
+		entries = append(entries, p)
+	}
+
+	return entries
+}
+
+// evictedEntry returns the marker left in the slot of the evicted entry with
+// sequence number seq.
+func evictedEntry(seq uint64) *RingEntry {
+	return &RingEntry{
+		Seq:     seq,
+		Time:    time.Time{},
+		Level:   zapcore.DebugLevel,
+		Logger:  "",
+		Message: "",
+		Caller:  "",
+		Fields:  nil,
+		evicted: true,
+	}
+}
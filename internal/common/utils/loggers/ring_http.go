@@ -0,0 +1,256 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loggers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RingRoute is an admin HTTP route that serves the entries of a RingCore. It
+// satisfies the HTTPRoute interface of the server package.
+type RingRoute struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+func (r RingRoute) String() string {
+	return r.pattern
+}
+
+func (r RingRoute) Route() http.HandlerFunc {
+	return r.handler
+}
+
+// ringTailInterval is how often the tail stream checks the ring for new
+// entries.
+const ringTailInterval = 250 * time.Millisecond
+
+// NewRingRoutes returns the admin routes of a RingCore, mounted under prefix.
+// Both routes accept the filters below as query parameters.
+//
+//   - level: the minimum level of returned entries, such as "warn".
+//   - since and until: RFC 3339 bounds on the entry time.
+//   - field: a "key=value" pair the entry fields must contain. It may be
+//     repeated.
+//
+// "GET <prefix>" returns the matching entries as a JSON array. An optional
+// limit parameter keeps only the most recent entries. "GET <prefix>/tail"
+// streams matching entries as server-sent events, starting after the
+// Last-Event-ID header if the client sends one.
+func NewRingRoutes(prefix string, core *RingCore) []RingRoute {
+	return []RingRoute{
+		{
+			pattern: "GET " + prefix,
+			handler: ringListHandler(core),
+		},
+		{
+			pattern: "GET " + prefix + "/tail",
+			handler: ringTailHandler(core),
+		},
+	}
+}
+
+func ringListHandler(core *RingCore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := ringFilterFromQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		limit := 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 0 {
+				http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+
+		entries := filter.apply(core.Entries())
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(entries)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func ringTailHandler(core *RingCore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := ringFilterFromQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var last uint64
+		if v := r.Header.Get("Last-Event-Id"); v != "" {
+			last, err = strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// A tail outlives any write timeout configured on the server.
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+
+		ticker := time.NewTicker(ringTailInterval)
+		defer ticker.Stop()
+
+		for {
+			entries := core.Since(last)
+			if len(entries) > 0 {
+				last = entries[len(entries)-1].Seq
+			}
+
+			err = writeRingEvents(w, filter.apply(entries))
+			if err != nil {
+				return
+			}
+
+			err = rc.Flush()
+			if err != nil {
+				return
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+func writeRingEvents(w http.ResponseWriter, entries []*RingEntry) error {
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", e.Seq, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ringFilter selects ring entries by level, time, and fields.
+type ringFilter struct {
+	level  zapcore.Level
+	since  time.Time
+	until  time.Time
+	fields map[string]string
+}
+
+func ringFilterFromQuery(r *http.Request) (ringFilter, error) {
+	q := r.URL.Query()
+
+	f := ringFilter{
+		level:  zapcore.DebugLevel,
+		since:  time.Time{},
+		until:  time.Time{},
+		fields: make(map[string]string),
+	}
+
+	if v := q.Get("level"); v != "" {
+		err := f.level.UnmarshalText([]byte(v))
+		if err != nil {
+			return f, fmt.Errorf("invalid level %q", v)
+		}
+	}
+
+	var err error
+
+	if v := q.Get("since"); v != "" {
+		f.since, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, fmt.Errorf("invalid since %q", v)
+		}
+	}
+
+	if v := q.Get("until"); v != "" {
+		f.until, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, fmt.Errorf("invalid until %q", v)
+		}
+	}
+
+	for _, v := range q["field"] {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return f, fmt.Errorf("invalid field %q, want key=value", v)
+		}
+		f.fields[key] = value
+	}
+
+	return f, nil
+}
+
+func (f ringFilter) apply(entries []*RingEntry) []*RingEntry {
+	matched := make([]*RingEntry, 0, len(entries))
+	for _, e := range entries {
+		if f.match(e) {
+			matched = append(matched, e)
+		}
+	}
+
+	return matched
+}
+
+func (f ringFilter) match(e *RingEntry) bool {
+	if e.Level < f.level {
+		return false
+	}
+
+	if !f.since.IsZero() && e.Time.Before(f.since) {
+		return false
+	}
+
+	if !f.until.IsZero() && e.Time.After(f.until) {
+		return false
+	}
+
+	if len(f.fields) == 0 {
+		return true
+	}
+
+	var fields map[string]any
+	err := json.Unmarshal(e.Fields, &fields)
+	if err != nil {
+		return false
+	}
+
+	for key, want := range f.fields {
+		got, ok := fields[key]
+		if !ok || fmt.Sprint(got) != want {
+			return false
+		}
+	}
+
+	return true
+}
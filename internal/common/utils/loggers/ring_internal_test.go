@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loggers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+)
+
+func newRingEntry(message string) *RingEntry {
+	return &RingEntry{
+		Seq:     0,
+		Time:    time.Time{},
+		Level:   zapcore.InfoLevel,
+		Logger:  "",
+		Message: message,
+		Caller:  "",
+		Fields:  nil,
+		evicted: false,
+	}
+}
+
+func TestRing_SinceStopsAtUnpublishedEntries(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		slots   int
+		written int
+		want    int
+	}{
+		{name: "empty slot", slots: 8, written: 2, want: 2},
+		{name: "slot of an older entry", slots: 3, written: 4, want: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRing(tt.slots, 1<<20)
+			for i := range tt.written {
+				r.put(newRingEntry(fmt.Sprintf("entry %d", i+1)))
+			}
+
+			// A writer took the next sequence number but has not stored
+			// its entry yet, while another stored the one after.
+			seq := r.last.Add(1)
+			r.put(newRingEntry("after"))
+
+			entries := r.since(0)
+			assert.Equal(t, len(entries), tt.want)
+			assert.Equal(t, entries[len(entries)-1].Seq, uint64(tt.written))
+			assert.Equal(t, len(r.since(uint64(tt.written))), 0)
+
+			late := newRingEntry("late")
+			late.Seq = seq
+			r.slot(seq).Store(late)
+
+			entries = r.since(uint64(tt.written))
+			assert.Equal(t, len(entries), 2)
+			assert.Equal(t, entries[0].Message, "late")
+			assert.Equal(t, entries[1].Message, "after")
+		})
+	}
+}
+
+func TestRing_SinceSkipsEvictedEntries(t *testing.T) {
+	// Each message is 3 bytes, so only two fit under the cap.
+	r := newRing(8, 7)
+	r.put(newRingEntry("one"))
+	r.put(newRingEntry("two"))
+	r.put(newRingEntry("six"))
+
+	entries := r.since(0)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].Message, "two")
+	assert.Equal(t, r.bytes.Load(), int64(6))
+}
@@ -0,0 +1,276 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loggers_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"backend.brokedaear.com"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/common/utils/loggers"
+)
+
+func newTestRing(t *testing.T, maxEntries, maxBytes int) *loggers.RingCore {
+	t.Helper()
+
+	core, err := loggers.NewRingCore(loggers.RingConfig{
+		MaxEntries: maxEntries,
+		MaxBytes:   maxBytes,
+		Level:      zapcore.DebugLevel,
+	})
+	assert.NoError(t, err)
+
+	return core
+}
+
+func TestRingCore_KeepsMostRecentEntries(t *testing.T) {
+	core := newTestRing(t, 3, 1<<20)
+	zl := zap.New(core)
+
+	for i := range 5 {
+		zl.Info(fmt.Sprintf("entry %d", i))
+	}
+
+	entries := core.Entries()
+	assert.Equal(t, len(entries), 3)
+	assert.Equal(t, entries[0].Message, "entry 2")
+	assert.Equal(t, entries[2].Message, "entry 4")
+	assert.Equal(t, entries[2].Seq, uint64(5))
+}
+
+func TestRingCore_ByteCapEvictsOldest(t *testing.T) {
+	// Each message is 10 bytes, so only two fit under the cap.
+	core := newTestRing(t, 10, 25)
+	zl := zap.New(core)
+
+	zl.Info("aaaaaaaaaa")
+	zl.Info("bbbbbbbbbb")
+	zl.Info("cccccccccc")
+
+	entries := core.Entries()
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].Message, "bbbbbbbbbb")
+}
+
+func TestRingCore_Since(t *testing.T) {
+	core := newTestRing(t, 10, 1<<20)
+	zl := zap.New(core)
+
+	zl.Info("one")
+	zl.Info("two")
+	zl.Info("three")
+
+	entries := core.Since(1)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].Message, "two")
+	assert.Equal(t, len(core.Since(3)), 0)
+}
+
+func TestRingCore_ConcurrentWriters(t *testing.T) {
+	core := newTestRing(t, 64, 1<<20)
+	zl := zap.New(core)
+
+	const writers, perWriter = 8, 100
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWriter {
+				zl.Info("concurrent", zap.Int("writer", w), zap.Int("i", i))
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries := core.Entries()
+	assert.Equal(t, len(entries), 64)
+	for i := 1; i < len(entries); i++ {
+		assert.True(t, entries[i].Seq > entries[i-1].Seq)
+	}
+}
+
+func TestRingCore_RegistersThroughNewZap(t *testing.T) {
+	core := newTestRing(t, 10, 1<<20)
+
+	config := &loggers.ZapConfig{
+		Env:                backend.EnvDevelopment,
+		OtelServiceName:    "",
+		OtelLoggerProvider: nil,
+		CustomZapper:       nil,
+		WithTelemetry:      false,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          []zapcore.Core{core},
+	}
+	logger, err := loggers.NewZap(config)
+	assert.NoError(t, err)
+
+	logger.Warn("kept in ring", "order", 42)
+
+	entries := core.Entries()
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Level, zapcore.WarnLevel)
+	assert.Equal(t, string(entries[0].Fields), `{"order":42}`)
+}
+
+func TestRingConfig_Validate(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		config loggers.RingConfig
+	}{
+		{
+			CaseBase: test.NewCaseBase("valid config", nil, false),
+			config:   loggers.RingConfig{MaxEntries: 1, MaxBytes: 1, Level: zapcore.InfoLevel},
+		},
+		{
+			CaseBase: test.NewCaseBase("zero entries", nil, true),
+			config:   loggers.RingConfig{MaxEntries: 0, MaxBytes: 1, Level: zapcore.InfoLevel},
+		},
+		{
+			CaseBase: test.NewCaseBase("zero bytes", nil, true),
+			config:   loggers.RingConfig{MaxEntries: 1, MaxBytes: 0, Level: zapcore.InfoLevel},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.config.Validate()
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+		})
+	}
+}
+
+func newRingServer(t *testing.T, core *loggers.RingCore) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for _, r := range loggers.NewRingRoutes("/debug/logs", core) {
+		mux.HandleFunc(r.String(), r.Route())
+	}
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestRingRoutes_ListFilters(t *testing.T) {
+	core := newTestRing(t, 10, 1<<20)
+	zl := zap.New(core)
+
+	zl.Debug("noise")
+	zl.Warn("payment failed", zap.String("provider", "stripe"))
+	zl.Error("payment failed", zap.String("provider", "paypal"))
+	zl.Error("db down")
+
+	srv := newRingServer(t, core)
+
+	tests := []struct {
+		test.CaseBase
+		query string
+	}{
+		{
+			CaseBase: test.NewCaseBase("no filters", 4, false),
+			query:    "",
+		},
+		{
+			CaseBase: test.NewCaseBase("minimum level", 3, false),
+			query:    "?level=warn",
+		},
+		{
+			CaseBase: test.NewCaseBase("field filter", 1, false),
+			query:    "?field=provider=stripe",
+		},
+		{
+			CaseBase: test.NewCaseBase("limit", 2, false),
+			query:    "?limit=2",
+		},
+		{
+			CaseBase: test.NewCaseBase("future since", 0, false),
+			query:    "?since=" + time.Now().Add(time.Hour).Format(time.RFC3339),
+		},
+		{
+			CaseBase: test.NewCaseBase("invalid level", http.StatusBadRequest, true),
+			query:    "?level=loud",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			res, err := http.Get(srv.URL + "/debug/logs" + tt.query)
+			assert.NoError(t, err)
+			defer res.Body.Close()
+
+			if tt.WantErr {
+				assert.Equal(t, res.StatusCode, tt.Want.(int))
+				return
+			}
+
+			var entries []loggers.RingEntry
+			err = json.NewDecoder(res.Body).Decode(&entries)
+			assert.NoError(t, err)
+			assert.Equal(t, len(entries), tt.Want.(int))
+		})
+	}
+}
+
+func TestRingRoutes_TailStreamsEvents(t *testing.T) {
+	core := newTestRing(t, 10, 1<<20)
+	zl := zap.New(core)
+
+	zl.Info("before tail")
+
+	srv := newRingServer(t, core)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/debug/logs/tail?level=error", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Last-Event-Id", "1")
+
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, res.Header.Get("Content-Type"), "text/event-stream")
+
+	zl.Info("filtered out")
+	zl.Error("streamed")
+
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var e loggers.RingEntry
+		err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e)
+		assert.NoError(t, err)
+		assert.Equal(t, e.Message, "streamed")
+		assert.Equal(t, e.Seq, uint64(3))
+
+		return
+	}
+
+	t.Error("tail ended without streaming an entry")
+}
@@ -0,0 +1,426 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loggers
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ZapSamplingConfig configures per level log sampling. Within every Tick, the
+// first First entries with the same level and message are logged, then only
+// every Thereafter-th entry after that. Levels without an entry in Levels are
+// never sampled.
+type ZapSamplingConfig struct {
+	Tick   time.Duration
+	Levels map[zapcore.Level]ZapLevelSampling
+}
+
+// ZapLevelSampling is the sampling policy of a single log level.
+type ZapLevelSampling struct {
+	First      int
+	Thereafter int
+}
+
+func (s ZapSamplingConfig) Validate() error {
+	if s.Tick <= 0 {
+		return errors.New("sampling tick must be greater than zero")
+	}
+
+	for level, ls := range s.Levels {
+		if ls.First < 0 || ls.Thereafter < 0 {
+			return fmt.Errorf("sampling for level %s must not be negative", level)
+		}
+	}
+
+	return nil
+}
+
+func (s ZapSamplingConfig) Value() any {
+	return s
+}
+
+// ZapDedupeConfig configures collapsing of identical log entries. An entry is
+// identical to another when its level, message, and fields are equal.
+type ZapDedupeConfig struct {
+	// Window is how long repeats of an entry are suppressed after the entry
+	// is first written.
+	Window time.Duration
+
+	// MaxKeys bounds the number of distinct entries tracked at once. Entries
+	// past the bound are written without deduplication.
+	MaxKeys int
+}
+
+func (d ZapDedupeConfig) Validate() error {
+	if d.Window <= 0 {
+		return errors.New("dedupe window must be greater than zero")
+	}
+
+	if d.MaxKeys <= 0 {
+		return errors.New("dedupe max keys must be greater than zero")
+	}
+
+	return nil
+}
+
+func (d ZapDedupeConfig) Value() any {
+	return d
+}
+
+// dropReason describes why a log entry never reached the log outputs.
+type dropReason string
+
+const (
+	dropReasonSampled      dropReason = "sampled"
+	dropReasonDeduplicated dropReason = "deduplicated"
+)
+
+// DroppedLogCounter counts log entries suppressed by sampling or
+// deduplication on a telemetry counter. Loggers are built before telemetry,
+// so the counter is attached to it afterwards; entries dropped before then
+// are not counted.
+type DroppedLogCounter struct {
+	counter atomic.Pointer[otelmetric.Int64Counter]
+}
+
+func NewDroppedLogCounter() *DroppedLogCounter {
+	return &DroppedLogCounter{counter: atomic.Pointer[otelmetric.Int64Counter]{}}
+}
+
+// Attach makes c count dropped entries on counter.
+func (c *DroppedLogCounter) Attach(counter otelmetric.Int64Counter) {
+	c.counter.Store(&counter)
+}
+
+// dropRecorder records suppressed log entries on a counter. A nil counter,
+// or one not attached yet, records nothing.
+type dropRecorder struct {
+	counter *DroppedLogCounter
+}
+
+func (d dropRecorder) record(level zapcore.Level, reason dropReason) {
+	if d.counter == nil {
+		return
+	}
+
+	counter := d.counter.counter.Load()
+	if counter == nil {
+		return
+	}
+
+	(*counter).Add(
+		context.Background(),
+		1,
+		otelmetric.WithAttributes(
+			attribute.String("level", level.String()),
+			attribute.String("reason", string(reason)),
+		),
+	)
+}
+
+// levelSamplerCore routes entries to a zap sampler specific to their level.
+// Entries of levels without a sampler pass straight through to the
+// wrapped core.
+type levelSamplerCore struct {
+	zapcore.Core
+	samplers map[zapcore.Level]zapcore.Core
+}
+
+func newLevelSamplerCore(core zapcore.Core, config ZapSamplingConfig, drops dropRecorder) zapcore.Core {
+	hook := zapcore.SamplerHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+		if dec&zapcore.LogDropped != 0 {
+			drops.record(ent.Level, dropReasonSampled)
+		}
+	})
+
+	samplers := make(map[zapcore.Level]zapcore.Core, len(config.Levels))
+	for level, ls := range config.Levels {
+		samplers[level] = zapcore.NewSamplerWithOptions(core, config.Tick, ls.First, ls.Thereafter, hook)
+	}
+
+	return &levelSamplerCore{
+		Core:     core,
+		samplers: samplers,
+	}
+}
+
+func (c *levelSamplerCore) With(fields []zapcore.Field) zapcore.Core {
+	samplers := make(map[zapcore.Level]zapcore.Core, len(c.samplers))
+	for level, s := range c.samplers {
+		samplers[level] = s.With(fields)
+	}
+
+	return &levelSamplerCore{
+		Core:     c.Core.With(fields),
+		samplers: samplers,
+	}
+}
+
+func (c *levelSamplerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	s, ok := c.samplers[ent.Level]
+	if !ok {
+		return c.Core.Check(ent, ce)
+	}
+
+	return s.Check(ent, ce)
+}
+
+// dedupeCore collapses identical entries written within a window. The first
+// entry is written immediately. Repeats inside the window are suppressed and
+// counted, then written as a single summary entry carrying the repeat count
+// once the window has passed or the logger is synced.
+type dedupeCore struct {
+	core   zapcore.Core
+	config ZapDedupeConfig
+	drops  dropRecorder
+	state  *dedupeState
+
+	// context is the hash of the fields added by With.
+	context uint64
+}
+
+// dedupeState is the bookkeeping shared by a dedupeCore and the cores
+// derived from it by With.
+type dedupeState struct {
+	mu        sync.Mutex
+	seed      maphash.Seed
+	entries   map[uint64]*dedupeEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// dedupeEntry is a tracked entry, the core it is written through, and the
+// number of times it repeated.
+type dedupeEntry struct {
+	core    zapcore.Core
+	ent     zapcore.Entry
+	fields  []zapcore.Field
+	first   time.Time
+	repeats int
+}
+
+// repeatCountKey is the field holding the number of suppressed repeats on a
+// dedupe summary entry.
+const repeatCountKey = "repeat_count"
+
+func newDedupeCore(core zapcore.Core, config ZapDedupeConfig, drops dropRecorder) *dedupeCore {
+	return &dedupeCore{
+		core:    core,
+		config:  config,
+		drops:   drops,
+		state:   newDedupeState(time.Now),
+		context: 0,
+	}
+}
+
+func newDedupeState(now func() time.Time) *dedupeState {
+	return &dedupeState{
+		mu:        sync.Mutex{},
+		seed:      maphash.MakeSeed(),
+		entries:   make(map[uint64]*dedupeEntry),
+		lastSweep: now(),
+		now:       now,
+	}
+}
+
+func (c *dedupeCore) Enabled(level zapcore.Level) bool {
+	return c.core.Enabled(level)
+}
+
+// With returns a core with added context fields. The returned core shares
+// the state of c, and its entries are told apart by the added fields.
+func (c *dedupeCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupeCore{
+		core:    c.core.With(fields),
+		config:  c.config,
+		drops:   c.drops,
+		state:   c.state,
+		context: c.context + c.state.hashFields(fields),
+	}
+}
+
+func (c *dedupeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *dedupeCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := c.state.key(ent, c.context+c.state.hashFields(fields))
+
+	c.state.mu.Lock()
+
+	now := c.state.now()
+	expired := c.sweep(now, false)
+
+	e, ok := c.state.entries[key]
+	if ok && now.Sub(e.first) >= c.config.Window {
+		delete(c.state.entries, key)
+
+		if e.repeats > 0 {
+			expired = append(expired, e)
+		}
+
+		ok = false
+	}
+
+	if ok {
+		e.repeats++
+		c.state.mu.Unlock()
+
+		c.drops.record(ent.Level, dropReasonDeduplicated)
+		c.writeSummaries(expired)
+
+		return nil
+	}
+
+	if len(c.state.entries) < c.config.MaxKeys {
+		c.state.entries[key] = &dedupeEntry{
+			core:    c.core,
+			ent:     ent,
+			fields:  fields,
+			first:   now,
+			repeats: 0,
+		}
+	}
+
+	c.state.mu.Unlock()
+
+	c.writeSummaries(expired)
+	writeThrough(c.core, ent, fields)
+
+	return nil
+}
+
+// Sync writes every pending summary before syncing the wrapped core.
+func (c *dedupeCore) Sync() error {
+	c.state.mu.Lock()
+	pending := c.sweep(c.state.now(), true)
+	c.state.mu.Unlock()
+
+	c.writeSummaries(pending)
+
+	return c.core.Sync()
+}
+
+// sweep removes tracked entries whose window has passed, or all tracked
+// entries when all is true. It returns the removed entries that repeated.
+// Sweeps are skipped until a full window has passed since the last one.
+// The caller must hold the state lock.
+func (c *dedupeCore) sweep(now time.Time, all bool) []*dedupeEntry {
+	if !all && now.Sub(c.state.lastSweep) < c.config.Window {
+		return nil
+	}
+
+	c.state.lastSweep = now
+
+	var repeated []*dedupeEntry
+	for key, e := range c.state.entries {
+		if !all && now.Sub(e.first) < c.config.Window {
+			continue
+		}
+
+		delete(c.state.entries, key)
+
+		if e.repeats > 0 {
+			repeated = append(repeated, e)
+		}
+	}
+
+	return repeated
+}
+
+// writeSummaries writes the summary of each entry through the core the entry
+// was written through, so that it carries the same context fields.
+func (c *dedupeCore) writeSummaries(entries []*dedupeEntry) {
+	for _, e := range entries {
+		ent := e.ent
+		ent.Time = c.state.now()
+
+		fields := make([]zapcore.Field, 0, len(e.fields)+1)
+		fields = append(fields, e.fields...)
+		fields = append(fields, zap.Int(repeatCountKey, e.repeats))
+
+		writeThrough(e.core, ent, fields)
+	}
+}
+
+// writeThrough writes an entry by checking it against core first, so that
+// wrapped samplers and level filters still apply. The checked entry has no
+// error output, so write errors of core are dropped.
+func writeThrough(core zapcore.Core, ent zapcore.Entry, fields []zapcore.Field) {
+	ce := core.Check(ent, nil)
+	if ce == nil {
+		return
+	}
+
+	ce.Write(fields...)
+}
+
+// key returns a key that is equal for entries with an equal level, logger
+// name, message, and hash of fields.
+func (s *dedupeState) key(ent zapcore.Entry, fields uint64) uint64 {
+	var h maphash.Hash
+	h.SetSeed(s.seed)
+
+	_ = h.WriteByte(byte(ent.Level))
+	_, _ = h.WriteString(ent.LoggerName)
+	_ = h.WriteByte(0)
+	_, _ = h.WriteString(ent.Message)
+	_ = h.WriteByte(0)
+	_, _ = h.Write(binary.LittleEndian.AppendUint64(nil, fields))
+
+	return h.Sum64()
+}
+
+// hashFields returns a hash that is equal for equal sets of fields. Each
+// field is hashed on its own and the hashes are summed, so that the order
+// of the fields does not matter and the fields of a core and of its
+// entries can be hashed apart.
+func (s *dedupeState) hashFields(fields []zapcore.Field) uint64 {
+	var sum uint64
+	for _, f := range fields {
+		sum += s.hashField(f)
+	}
+
+	return sum
+}
+
+func (s *dedupeState) hashField(f zapcore.Field) uint64 {
+	var h maphash.Hash
+	h.SetSeed(s.seed)
+
+	_, _ = h.WriteString(f.Key)
+	_ = h.WriteByte(byte(f.Type))
+	_, _ = h.Write(binary.LittleEndian.AppendUint64(nil, uint64(f.Integer)))
+	_, _ = h.WriteString(f.String)
+
+	switch v := f.Interface.(type) {
+	case nil:
+	case error:
+		_, _ = h.WriteString(v.Error())
+	default:
+		// Objects, arrays and other values are hashed as they are logged.
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		_, _ = fmt.Fprint(&h, enc.Fields[f.Key])
+	}
+
+	return h.Sum64()
+}
@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package loggers
+
+import (
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"backend.brokedaear.com"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+)
+
+// fakeClock is a manually advanced clock for dedupe tests.
+type fakeClock struct {
+	t time.Time
+}
+
+func (f *fakeClock) now() time.Time {
+	return f.t
+}
+
+func (f *fakeClock) advance(d time.Duration) {
+	f.t = f.t.Add(d)
+}
+
+func newTestDedupeCore(core zapcore.Core, window time.Duration, clock *fakeClock) *dedupeCore {
+	dc := newDedupeCore(core, ZapDedupeConfig{Window: window, MaxKeys: 16}, dropRecorder{counter: nil})
+	dc.state = newDedupeState(clock.now)
+	return dc
+}
+
+func writeEntry(c zapcore.Core, level zapcore.Level, msg string, fields ...zapcore.Field) {
+	ent := zapcore.Entry{
+		Level:   level,
+		Message: msg,
+	}
+
+	ce := c.Check(ent, nil)
+	ce.Write(fields...)
+}
+
+func TestDedupeCore_CollapsesRepeats(t *testing.T) {
+	obsCore, logs := observer.New(zapcore.DebugLevel)
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	dc := newTestDedupeCore(obsCore, time.Second, clock)
+
+	for range 5 {
+		writeEntry(dc, zapcore.ErrorLevel, "dependency down", zap.String("dep", "db"))
+	}
+
+	assert.Equal(t, logs.Len(), 1)
+
+	clock.advance(2 * time.Second)
+	writeEntry(dc, zapcore.ErrorLevel, "dependency down", zap.String("dep", "db"))
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, len(entries), 3)
+
+	summary := entries[1]
+	assert.Equal(t, summary.Message, "dependency down")
+	assert.Equal(t, summary.ContextMap()[repeatCountKey].(int64), 4)
+}
+
+func TestDedupeCore_DistinctEntries(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		first  []zapcore.Field
+		second []zapcore.Field
+		level  zapcore.Level
+	}{
+		{
+			CaseBase: test.NewCaseBase("different fields are distinct", 2, false),
+			first:    []zapcore.Field{zap.String("dep", "db")},
+			second:   []zapcore.Field{zap.String("dep", "stripe")},
+			level:    zapcore.ErrorLevel,
+		},
+		{
+			CaseBase: test.NewCaseBase("equal fields are identical", 1, false),
+			first:    []zapcore.Field{zap.Int("n", 1)},
+			second:   []zapcore.Field{zap.Int("n", 1)},
+			level:    zapcore.WarnLevel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			obsCore, logs := observer.New(zapcore.DebugLevel)
+			clock := &fakeClock{t: time.Unix(0, 0)}
+			dc := newTestDedupeCore(obsCore, time.Minute, clock)
+
+			writeEntry(dc, tt.level, "msg", tt.first...)
+			writeEntry(dc, tt.level, "msg", tt.second...)
+
+			assert.Equal(t, logs.Len(), tt.Want.(int))
+		})
+	}
+}
+
+func TestDedupeCore_SharesStateAcrossWith(t *testing.T) {
+	obsCore, logs := observer.New(zapcore.DebugLevel)
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	dc := newTestDedupeCore(obsCore, time.Minute, clock)
+
+	// Loggers derived per request repeat the same entries.
+	for range 3 {
+		writeEntry(dc.With([]zapcore.Field{zap.String("dep", "db")}), zapcore.ErrorLevel, "down", zap.Int("n", 1))
+	}
+
+	// Other context fields are told apart, but the same fields split another
+	// way between the logger and the entry are not.
+	writeEntry(dc.With([]zapcore.Field{zap.String("dep", "stripe")}), zapcore.ErrorLevel, "down", zap.Int("n", 1))
+	writeEntry(dc.With([]zapcore.Field{zap.Int("n", 1)}), zapcore.ErrorLevel, "down", zap.String("dep", "db"))
+
+	assert.Equal(t, logs.Len(), 2)
+
+	// Summaries carry the context fields of the core that wrote the entry.
+	err := dc.Sync()
+	assert.NoError(t, err)
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, len(entries), 3)
+	assert.Equal(t, entries[2].ContextMap()["dep"].(string), "db")
+	assert.Equal(t, entries[2].ContextMap()[repeatCountKey].(int64), 3)
+}
+
+func TestDedupeCore_SyncFlushesSummaries(t *testing.T) {
+	obsCore, logs := observer.New(zapcore.DebugLevel)
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	dc := newTestDedupeCore(obsCore, time.Hour, clock)
+
+	writeEntry(dc, zapcore.InfoLevel, "tick")
+	writeEntry(dc, zapcore.InfoLevel, "tick")
+	writeEntry(dc, zapcore.InfoLevel, "tick")
+
+	err := dc.Sync()
+	assert.NoError(t, err)
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[1].ContextMap()[repeatCountKey].(int64), 2)
+
+	// A second sync has nothing left to flush.
+	err = dc.Sync()
+	assert.NoError(t, err)
+	assert.Equal(t, logs.Len(), 2)
+}
+
+func TestLevelSamplerCore_SamplesOnlyConfiguredLevels(t *testing.T) {
+	reader := metric.NewManualReader()
+	mp := metric.NewMeterProvider(metric.WithReader(reader))
+	counter, err := mp.Meter("test").Int64Counter("logs_dropped")
+	assert.NoError(t, err)
+
+	obsCore, logs := observer.New(zapcore.DebugLevel)
+	config := ZapSamplingConfig{
+		Tick: time.Minute,
+		Levels: map[zapcore.Level]ZapLevelSampling{
+			zapcore.InfoLevel: {First: 2, Thereafter: 0},
+		},
+	}
+	dropped := NewDroppedLogCounter()
+	core := newLevelSamplerCore(obsCore, config, dropRecorder{counter: dropped})
+
+	// Entries dropped before a counter is attached are not counted.
+	for range 3 {
+		writeEntry(core, zapcore.InfoLevel, "before attach")
+	}
+
+	dropped.Attach(counter)
+
+	for range 10 {
+		writeEntry(core, zapcore.InfoLevel, "hot path")
+		writeEntry(core, zapcore.ErrorLevel, "unsampled")
+	}
+
+	assert.Equal(t, logs.FilterMessage("hot path").Len(), 2)
+	assert.Equal(t, logs.FilterMessage("unsampled").Len(), 10)
+
+	var rm metricdata.ResourceMetrics
+	err = reader.Collect(t.Context(), &rm)
+	assert.NoError(t, err)
+
+	sum, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	assert.True(t, ok)
+	assert.Equal(t, sum.DataPoints[0].Value, int64(8))
+}
+
+func TestZapSamplingConfig_Validate(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		config ZapSamplingConfig
+	}{
+		{
+			CaseBase: test.NewCaseBase("valid config", nil, false),
+			config: ZapSamplingConfig{
+				Tick:   time.Second,
+				Levels: map[zapcore.Level]ZapLevelSampling{zapcore.InfoLevel: {First: 10, Thereafter: 100}},
+			},
+		},
+		{
+			CaseBase: test.NewCaseBase("zero tick", nil, true),
+			config:   ZapSamplingConfig{Tick: 0, Levels: nil},
+		},
+		{
+			CaseBase: test.NewCaseBase("negative first", nil, true),
+			config: ZapSamplingConfig{
+				Tick:   time.Second,
+				Levels: map[zapcore.Level]ZapLevelSampling{zapcore.WarnLevel: {First: -1, Thereafter: 1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.config.Validate()
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+		})
+	}
+}
+
+func TestZapDedupeConfig_Validate(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		config ZapDedupeConfig
+	}{
+		{
+			CaseBase: test.NewCaseBase("valid config", nil, false),
+			config:   ZapDedupeConfig{Window: time.Second, MaxKeys: 1},
+		},
+		{
+			CaseBase: test.NewCaseBase("zero window", nil, true),
+			config:   ZapDedupeConfig{Window: 0, MaxKeys: 1},
+		},
+		{
+			CaseBase: test.NewCaseBase("zero max keys", nil, true),
+			config:   ZapDedupeConfig{Window: time.Second, MaxKeys: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.config.Validate()
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+		})
+	}
+}
+
+func Test_switchZapProdLogger_InvalidSuppression(t *testing.T) {
+	config := &ZapConfig{
+		Env:                backend.EnvProduction,
+		OtelServiceName:    "test-service",
+		OtelLoggerProvider: nil,
+		CustomZapper:       nil,
+		WithTelemetry:      false,
+		Sampling:           nil,
+		Dedupe:             &ZapDedupeConfig{Window: 0, MaxKeys: 1},
+		DroppedLogs:        nil,
+		Observers:          nil,
+	}
+	zapConfig := zap.NewProductionConfig()
+
+	_, err := switchZapProdLogger(config, zapConfig)
+	assert.ErrorOrNoError(t, err, true)
+}
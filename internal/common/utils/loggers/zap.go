@@ -27,6 +27,27 @@ type ZapConfig struct {
 	OtelLoggerProvider *log.LoggerProvider
 	CustomZapper       *CustomZapWriter
 	WithTelemetry      bool
+
+	// Sampling configures per level sampling of staging and production logs,
+	// applied to every core. A nil Sampling leaves only zap's default
+	// sampling of its default core, which is not written to with telemetry,
+	// so the telemetry cores and the cores passed to NewZap go unsampled.
+	// Development logs are never sampled.
+	Sampling *ZapSamplingConfig
+
+	// Dedupe configures collapsing of identical production log entries. A nil
+	// Dedupe opts out of deduplication.
+	Dedupe *ZapDedupeConfig
+
+	// DroppedLogs counts log entries suppressed by Sampling or Dedupe. It may
+	// be nil.
+	DroppedLogs *DroppedLogCounter
+
+	// Observers receive every entry alongside the output of the logger, in
+	// every environment. Unlike the cores passed to NewZap, which replace the
+	// default output of staging and production logs without telemetry, they
+	// never silence it.
+	Observers []zapcore.Core
 }
 
 // ZapWriter satisfies the zap.Sink interface.
@@ -95,7 +116,7 @@ func NewZap(config *ZapConfig, cores ...zapcore.Core) (logger, error) {
 
 	switch config.Env {
 	case backend.EnvDevelopment:
-		return newZapDevLogger(zc)
+		return newZapDevLogger(zc, config.Observers...)
 	case backend.EnvStaging:
 		return switchZapProdLogger(config, zc, cores...)
 	case backend.EnvProduction:
@@ -125,8 +146,12 @@ type ZapDevelopmentLogger struct {
 	sugared *zap.SugaredLogger
 }
 
-func newZapDevLogger(config zap.Config) (*ZapDevelopmentLogger, error) {
-	zl, err := config.Build()
+// newZapDevLogger builds a development logger. Observers receive every entry
+// alongside the default console output.
+func newZapDevLogger(config zap.Config, observers ...zapcore.Core) (*ZapDevelopmentLogger, error) {
+	zl, err := config.Build(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return observe(c, observers)
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -175,11 +200,22 @@ func switchZapProdLogger(zc *ZapConfig, zd zap.Config, cores ...zapcore.Core) (l
 		}
 		allCores = append(allCores, prodCores...)
 	}
+	err := validateZapSuppression(zc)
+	if err != nil {
+		return nil, err
+	}
+
+	if zc.Sampling != nil {
+		// Sampling is applied to the whole tee below, so zap's own sampler
+		// must not sample the default core a second time.
+		zd.Sampling = nil
+	}
+
 	zl, err := zd.Build(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
 		if len(allCores) > 0 {
-			return zapcore.NewTee(allCores...)
+			c = zapcore.NewTee(allCores...)
 		}
-		return c
+		return wrapZapSuppression(zc, observe(c, zc.Observers))
 	}))
 	if err != nil {
 		return nil, err
@@ -187,6 +223,52 @@ func switchZapProdLogger(zc *ZapConfig, zd zap.Config, cores ...zapcore.Core) (l
 	return newZapProdLogger(zl), nil
 }
 
+// observe tees core with observers, if any.
+func observe(core zapcore.Core, observers []zapcore.Core) zapcore.Core {
+	if len(observers) == 0 {
+		return core
+	}
+
+	return zapcore.NewTee(append([]zapcore.Core{core}, observers...)...)
+}
+
+// validateZapSuppression validates the sampling and dedupe configurations of
+// zc, if any are set.
+func validateZapSuppression(zc *ZapConfig) error {
+	if zc.Sampling != nil {
+		err := zc.Sampling.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if zc.Dedupe != nil {
+		err := zc.Dedupe.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// wrapZapSuppression wraps core with the sampling and dedupe cores configured
+// in zc. Deduplication happens before sampling, so a flood of one message is
+// collapsed without using up the sampling budget of other messages.
+func wrapZapSuppression(zc *ZapConfig, core zapcore.Core) zapcore.Core {
+	drops := dropRecorder{counter: zc.DroppedLogs}
+
+	if zc.Sampling != nil {
+		core = newLevelSamplerCore(core, *zc.Sampling, drops)
+	}
+
+	if zc.Dedupe != nil {
+		core = newDedupeCore(core, *zc.Dedupe, drops)
+	}
+
+	return core
+}
+
 // Info logs an info level message using the development logger.
 func (l *ZapDevelopmentLogger) Info(msg string, args ...any) {
 	if len(args) == 0 {
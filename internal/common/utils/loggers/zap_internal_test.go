@@ -105,6 +105,10 @@ func Test_switchZapProdLogger(t *testing.T) {
 				OtelLoggerProvider: nil,
 				CustomZapper:       nil,
 				WithTelemetry:      false,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			},
 			cores:        []zapcore.Core{},
 			expectError:  false,
@@ -118,6 +122,10 @@ func Test_switchZapProdLogger(t *testing.T) {
 				OtelLoggerProvider: nil,
 				CustomZapper:       nil,
 				WithTelemetry:      false,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			},
 			cores:        []zapcore.Core{mockCore},
 			expectError:  false,
@@ -131,6 +139,10 @@ func Test_switchZapProdLogger(t *testing.T) {
 				OtelLoggerProvider: mockLoggerProvider,
 				CustomZapper:       nil,
 				WithTelemetry:      true,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			},
 			cores:        []zapcore.Core{},
 			expectError:  false,
@@ -144,6 +156,10 @@ func Test_switchZapProdLogger(t *testing.T) {
 				OtelLoggerProvider: mockLoggerProvider,
 				CustomZapper:       nil,
 				WithTelemetry:      true,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			},
 			cores:        []zapcore.Core{mockCore},
 			expectError:  false,
@@ -157,6 +173,10 @@ func Test_switchZapProdLogger(t *testing.T) {
 				OtelLoggerProvider: nil,
 				CustomZapper:       nil,
 				WithTelemetry:      true,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			},
 			cores:        []zapcore.Core{},
 			expectError:  true,
@@ -170,6 +190,10 @@ func Test_switchZapProdLogger(t *testing.T) {
 				OtelLoggerProvider: nil,
 				CustomZapper:       nil,
 				WithTelemetry:      true,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			},
 			cores:        []zapcore.Core{mockCore},
 			expectError:  true,
@@ -183,6 +207,10 @@ func Test_switchZapProdLogger(t *testing.T) {
 				OtelLoggerProvider: mockLoggerProvider,
 				CustomZapper:       nil,
 				WithTelemetry:      true,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			},
 			cores:        []zapcore.Core{},
 			expectError:  false,
@@ -196,6 +224,10 @@ func Test_switchZapProdLogger(t *testing.T) {
 				OtelLoggerProvider: nil,
 				CustomZapper:       nil,
 				WithTelemetry:      false,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			},
 			cores:        []zapcore.Core{},
 			expectError:  false,
@@ -209,6 +241,10 @@ func Test_switchZapProdLogger(t *testing.T) {
 				OtelLoggerProvider: nil,
 				CustomZapper:       nil,
 				WithTelemetry:      false,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			},
 			cores:        []zapcore.Core{mockCore, mockCore, mockCore},
 			expectError:  false,
@@ -264,6 +300,10 @@ func Test_switchZapProdLogger_LoggerType(t *testing.T) {
 		OtelLoggerProvider: nil,
 		CustomZapper:       nil,
 		WithTelemetry:      false,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 	zapConfig, err := zapConfigFromEnv(config.Env)
 	assert.NoError(t, err)
@@ -289,6 +329,10 @@ func Test_switchZapProdLogger_TelemetryPath(t *testing.T) {
 		OtelLoggerProvider: mockLoggerProvider,
 		CustomZapper:       nil,
 		WithTelemetry:      true,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 	zapConfig, err := zapConfigFromEnv(config.Env)
 	assert.NoError(t, err)
@@ -324,6 +368,10 @@ func Test_switchZapProdLogger_CoreCounting(t *testing.T) {
 		OtelLoggerProvider: nil,
 		CustomZapper:       nil,
 		WithTelemetry:      false,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 	zapConfig1, err := zapConfigFromEnv(config1.Env)
 	assert.NoError(t, err)
@@ -342,6 +390,10 @@ func Test_switchZapProdLogger_CoreCounting(t *testing.T) {
 		OtelLoggerProvider: mockLoggerProvider,
 		CustomZapper:       nil,
 		WithTelemetry:      true,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 	zapConfig2, err2 := zapConfigFromEnv(config2.Env)
 	assert.NoError(t, err2)
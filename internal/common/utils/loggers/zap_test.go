@@ -42,6 +42,10 @@ func TestZapLogger_Sync(t *testing.T) {
 				OtelLoggerProvider: nil,
 				CustomZapper:       nil,
 				WithTelemetry:      false,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			}
 			logger, err := loggers.NewZap(config)
 			assert.NoError(t, err)
@@ -71,6 +75,10 @@ func TestZapLogger_Sync_ErrorHandling(t *testing.T) {
 		OtelLoggerProvider: nil,
 		CustomZapper:       nil,
 		WithTelemetry:      false,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 	logger, err := loggers.NewZap(config)
 	assert.NoError(t, err)
@@ -93,6 +101,10 @@ func TestZapLogger_Sync_ENOTTYHandling(t *testing.T) {
 		OtelLoggerProvider: nil,
 		CustomZapper:       nil,
 		WithTelemetry:      false,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 	logger, err := loggers.NewZap(config)
 	assert.NoError(t, err)
@@ -185,6 +197,10 @@ func TestZapProductionLogger_OutputValidation(t *testing.T) {
 		CustomZapper:       customZapWriter,
 		OtelLoggerProvider: lp,
 		WithTelemetry:      true,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 
 	logger, err := loggers.NewZap(config)
@@ -245,6 +261,10 @@ func TestZapStagingLogger_OutputValidation(t *testing.T) {
 		OtelLoggerProvider: nil,
 		CustomZapper:       customZapWriter,
 		WithTelemetry:      false,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 	logger, err := loggers.NewZap(config)
 	assert.NoError(t, err)
@@ -313,6 +333,10 @@ func TestZapLogger_TelemetryError(t *testing.T) {
 		OtelLoggerProvider: nil,
 		CustomZapper:       nil,
 		WithTelemetry:      true,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 	_, err := loggers.NewZap(config)
 	assert.True(t, err != nil)
@@ -372,6 +396,10 @@ func TestZapProductionLogger_StructuredFieldsEdgeCases(t *testing.T) {
 				OtelLoggerProvider: nil,
 				CustomZapper:       customZapWriter,
 				WithTelemetry:      false,
+				Sampling:           nil,
+				Dedupe:             nil,
+				DroppedLogs:        nil,
+				Observers:          nil,
 			}
 			logger, err := loggers.NewZap(config)
 			assert.NoError(t, err)
@@ -427,6 +455,10 @@ func TestZapDevelopmentLogger_OutputValidation(t *testing.T) {
 		CustomZapper:       customZapWriter,
 		OtelLoggerProvider: nil,
 		WithTelemetry:      false,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 	logger, err := loggers.NewZap(config)
 	assert.NoError(t, err)
@@ -486,6 +518,10 @@ func TestZapLogger_CustomWriter(t *testing.T) {
 		OtelLoggerProvider: nil,
 		CustomZapper:       customZapWriter,
 		WithTelemetry:      false,
+		Sampling:           nil,
+		Dedupe:             nil,
+		DroppedLogs:        nil,
+		Observers:          nil,
 	}
 
 	logger, err := loggers.NewZap(config)
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MinMachineLength and MaxMachineLength bound the length of machine
+	// fingerprints.
+	MinMachineLength = 16
+	MaxMachineLength = 128
+
+	// MaxMachineNameLength bounds the length of the names of machines.
+	MaxMachineNameLength = 100
+)
+
+// Activation is a license activated on a machine. A license is active on
+// at most the machine limit of its product at once.
+type Activation struct {
+	ID         int
+	LicenseID  int
+	CustomerID int
+	ProductID  int
+
+	// Machine is the fingerprint the plugin computed for the machine it
+	// runs on, such as a hash of hardware identifiers. It is opaque to the
+	// app.
+	Machine string
+
+	// MachineName is the name of the machine shown to the customer, such
+	// as "Studio Mac".
+	MachineName string
+
+	Activated time.Time
+
+	// LastSeen is when the plugin last activated or refreshed the
+	// activation.
+	LastSeen time.Time
+
+	// Deactivated is when the activation ended, and DeactivateReason why,
+	// or zero for an active activation.
+	Deactivated      time.Time
+	DeactivateReason string
+}
+
+// Active reports whether the activation still counts against the machine
+// limit.
+func (a Activation) Active() bool {
+	return a.Deactivated.IsZero()
+}
+
+// ActivationCount counts the machines of a license, to tell staff of
+// licenses shared beyond their machine limit.
+type ActivationCount struct {
+	LicenseID  int
+	CustomerID int
+	ProductID  int
+
+	// Active is the number of machines the license is active on.
+	Active int
+
+	// Machines is the number of machines the license was activated on
+	// over a period, deactivated or not.
+	Machines int
+}
+
+// ValidateMachine returns ErrInvalidMachine when the fingerprint machine is
+// not 16 to 128 printable ASCII characters without spaces, and
+// ErrInvalidMachineName when name is too long or has surrounding spaces.
+func ValidateMachine(machine, name string) error {
+	if len(machine) < MinMachineLength || len(machine) > MaxMachineLength {
+		return ErrInvalidMachine
+	}
+
+	for i := range len(machine) {
+		if machine[i] <= ' ' || machine[i] > '~' {
+			return ErrInvalidMachine
+		}
+	}
+
+	if strings.TrimSpace(name) != name || utf8.RuneCountInString(name) > MaxMachineNameLength {
+		return ErrInvalidMachineName
+	}
+
+	return nil
+}
+
+type ActivationError string
+
+func (e ActivationError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidMachine     ActivationError = "machine fingerprint must be 16 to 128 printable characters without spaces"
+	ErrInvalidMachineName ActivationError = "machine name must be at most 100 characters without surrounding spaces"
+)
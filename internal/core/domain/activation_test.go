@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+func TestValidateMachine(t *testing.T) {
+	const machine = "3f9a1c2e7b4d8f60"
+
+	for _, tt := range []struct {
+		name, machine, machineName string
+		want                       error
+	}{
+		{"valid", machine, "Studio Mac", nil},
+		{"unnamed", machine, "", nil},
+		{"longest", strings.Repeat("a", domain.MaxMachineLength), "", nil},
+		{"too short", machine[1:], "", domain.ErrInvalidMachine},
+		{"too long", strings.Repeat("a", domain.MaxMachineLength+1), "", domain.ErrInvalidMachine},
+		{"space", "3f9a1c2e 7b4d8f60", "", domain.ErrInvalidMachine},
+		{"not ASCII", "3f9a1c2e7b4d8f6é", "", domain.ErrInvalidMachine},
+		{"name with spaces around", machine, " Studio Mac", domain.ErrInvalidMachineName},
+		{"name too long", machine, strings.Repeat("a", domain.MaxMachineNameLength+1), domain.ErrInvalidMachineName},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.ValidateMachine(tt.machine, tt.machineName)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import "slices"
+
+const (
+	// MaxCartLines is the maximum number of lines of a cart.
+	MaxCartLines = 50
+
+	// MaxLineQuantity is the maximum quantity of a line of a cart.
+	MaxLineQuantity = 99
+)
+
+// MaxQuantity returns the maximum quantity of a cart line of a product of
+// type t. A plugin bought for oneself is a single license; gifted plugins
+// are bought by the license, one per recipient.
+func MaxQuantity(t ProductType, gift bool) int {
+	if t == ProductPlugin && !gift {
+		return 1
+	}
+
+	return MaxLineQuantity
+}
+
+// Line returns the line of c with productID and the gift flag.
+func (c *Cart) Line(productID int, gift bool) (CartLine, bool) {
+	i := c.lineIndex(productID, gift)
+	if i < 0 {
+		return CartLine{}, false
+	}
+
+	return c.Lines[i], true
+}
+
+// Put sets the quantity of the line of c with the product and gift flag of
+// line, a product of type t. The line is added when c has none, and removed
+// when the quantity is 0.
+func (c *Cart) Put(t ProductType, line CartLine) error {
+	if line.Quantity < 0 || line.Quantity > MaxLineQuantity {
+		return ErrInvalidQuantity
+	}
+
+	if line.Quantity > MaxQuantity(t, line.Gift) {
+		return ErrPluginQuantity
+	}
+
+	i := c.lineIndex(line.ProductID, line.Gift)
+
+	switch {
+	case i < 0 && line.Quantity == 0:
+	case i < 0:
+		if len(c.Lines) >= MaxCartLines {
+			return ErrCartFull
+		}
+
+		c.Lines = append(c.Lines, line)
+	case line.Quantity == 0:
+		c.Lines = slices.Delete(c.Lines, i, i+1)
+	default:
+		c.Lines[i].Quantity = line.Quantity
+	}
+
+	return nil
+}
+
+func (c *Cart) lineIndex(productID int, gift bool) int {
+	return slices.IndexFunc(c.Lines, func(l CartLine) bool {
+		return l.ProductID == productID && l.Gift == gift
+	})
+}
+
+type CartError string
+
+func (e CartError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidQuantity CartError = "quantity must be 1 to 99"
+	ErrPluginQuantity  CartError = "a plugin is bought one license per line, unless it is a gift"
+	ErrCartFull        CartError = "cart holds at most 50 lines"
+)
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"testing"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+func TestCart_Put(t *testing.T) {
+	var cart domain.Cart
+
+	assert.NoError(t, cart.Put(domain.ProductPlugin, domain.CartLine{ProductID: 1, Quantity: 1, Gift: false}))
+	assert.NoError(t, cart.Put(domain.ProductPlugin, domain.CartLine{ProductID: 1, Quantity: 3, Gift: true}))
+	assert.NoError(t, cart.Put(domain.ProductMerchandise, domain.CartLine{ProductID: 2, Quantity: 2, Gift: false}))
+	assert.Equal(t, len(cart.Lines), 3)
+
+	err := cart.Put(domain.ProductPlugin, domain.CartLine{ProductID: 1, Quantity: 2, Gift: false})
+	assert.Error(t, err, domain.ErrPluginQuantity)
+
+	err = cart.Put(domain.ProductMerchandise, domain.CartLine{ProductID: 2, Quantity: 100, Gift: false})
+	assert.Error(t, err, domain.ErrInvalidQuantity)
+
+	err = cart.Put(domain.ProductMerchandise, domain.CartLine{ProductID: 2, Quantity: -1, Gift: false})
+	assert.Error(t, err, domain.ErrInvalidQuantity)
+
+	assert.NoError(t, cart.Put(domain.ProductMerchandise, domain.CartLine{ProductID: 2, Quantity: 5, Gift: false}))
+
+	line, ok := cart.Line(2, false)
+	assert.True(t, ok)
+	assert.Equal(t, line.Quantity, 5)
+
+	// Gift lines are apart from the lines bought for oneself.
+	line, ok = cart.Line(1, true)
+	assert.True(t, ok)
+	assert.Equal(t, line.Quantity, 3)
+
+	assert.NoError(t, cart.Put(domain.ProductPlugin, domain.CartLine{ProductID: 1, Quantity: 0, Gift: false}))
+	_, ok = cart.Line(1, false)
+	assert.False(t, ok)
+	assert.Equal(t, cart.Lines[0].ProductID, 1)
+	assert.True(t, cart.Lines[0].Gift)
+
+	// Removing a line the cart does not have changes nothing.
+	assert.NoError(t, cart.Put(domain.ProductPlugin, domain.CartLine{ProductID: 9, Quantity: 0, Gift: false}))
+	assert.Equal(t, len(cart.Lines), 2)
+}
+
+func TestCart_PutFull(t *testing.T) {
+	var cart domain.Cart
+
+	for id := range domain.MaxCartLines {
+		assert.NoError(t, cart.Put(domain.ProductMerchandise, domain.CartLine{ProductID: id, Quantity: 1, Gift: false}))
+	}
+
+	err := cart.Put(domain.ProductMerchandise, domain.CartLine{ProductID: -1, Quantity: 1, Gift: false})
+	assert.Error(t, err, domain.ErrCartFull)
+
+	// Lines already in a full cart can still change.
+	assert.NoError(t, cart.Put(domain.ProductMerchandise, domain.CartLine{ProductID: 0, Quantity: 2, Gift: false}))
+}
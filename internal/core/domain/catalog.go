@@ -0,0 +1,268 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// ProductType is the kind of a product. Its values are stored, so existing
+// values must not change.
+type ProductType int
+
+const (
+	ProductPlugin ProductType = iota
+	ProductMerchandise
+)
+
+const (
+	// maxSlugLength bounds the slugs of products.
+	maxSlugLength = 64
+
+	// MaxProductNameLength is the maximum number of characters in the name
+	// of a product.
+	MaxProductNameLength = 100
+
+	// MaxProductDescriptionLength is the maximum number of characters in
+	// the description of a product.
+	MaxProductDescriptionLength = 5000
+
+	// MaxProductMedia is the maximum number of media of a product.
+	MaxProductMedia = 20
+
+	// maxMediaAltLength bounds the text alternatives of media.
+	maxMediaAltLength = 300
+)
+
+// Region is where a price applies: an ISO 3166-1 alpha-2 country code,
+// such as "DE", or RegionDefault.
+type Region string
+
+// RegionDefault is the region of the prices that apply in every country
+// without a price of its own.
+const RegionDefault Region = "default"
+
+// Valid returns ErrInvalidRegion when r is neither RegionDefault nor two
+// uppercase letters.
+func (r Region) Valid() error {
+	if r == RegionDefault {
+		return nil
+	}
+
+	if len(r) != 2 || r[0] < 'A' || r[0] > 'Z' || r[1] < 'A' || r[1] > 'Z' {
+		return ErrInvalidRegion
+	}
+
+	return nil
+}
+
+// Price returns the price of p in currency for customers in region, which
+// is the price of the region if p has one, else its default price in the
+// currency.
+func (p Product) Price(currency Currency, region Region) (Money, bool) {
+	return priceIn(p.Prices, currency, region)
+}
+
+// priceIn returns the price of prices in currency for customers in region,
+// falling back to the default region.
+func priceIn(prices []Price, currency Currency, region Region) (Money, bool) {
+	var (
+		fallback Money
+		found    bool
+	)
+
+	for _, price := range prices {
+		if price.Money.Currency != currency {
+			continue
+		}
+
+		if price.Region == region {
+			return price.Money, true
+		}
+
+		if price.Region == RegionDefault {
+			fallback, found = price.Money, true
+		}
+	}
+
+	return fallback, found
+}
+
+// ProductTypes returns every product type, in a stable order.
+func ProductTypes() []ProductType {
+	return []ProductType{ProductPlugin, ProductMerchandise}
+}
+
+// ParseProductType returns the product type named s, as returned by
+// ProductType.String.
+func ParseProductType(s string) (ProductType, error) {
+	for _, t := range ProductTypes() {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+
+	return 0, ErrInvalidProductType
+}
+
+func (t ProductType) String() string {
+	switch t {
+	case ProductPlugin:
+		return "plugin"
+	case ProductMerchandise:
+		return "merchandise"
+	default:
+		return "unknown"
+	}
+}
+
+// Valid returns ErrInvalidProductType when t is not a known product type.
+func (t ProductType) Valid() error {
+	if t != ProductPlugin && t != ProductMerchandise {
+		return ErrInvalidProductType
+	}
+
+	return nil
+}
+
+func (t ProductType) MarshalText() ([]byte, error) {
+	err := t.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(t.String()), nil
+}
+
+func (t *ProductType) UnmarshalText(text []byte) error {
+	parsed, err := ParseProductType(string(text))
+	if err != nil {
+		return err
+	}
+
+	*t = parsed
+
+	return nil
+}
+
+// ValidSlug reports whether slug can identify a product: 1 to 64 lowercase
+// letters, digits and single dashes, neither starting nor ending with a
+// dash.
+func ValidSlug(slug string) bool {
+	if slug == "" || len(slug) > maxSlugLength || slug[0] == '-' || slug[len(slug)-1] == '-' {
+		return false
+	}
+
+	if strings.Contains(slug, "--") {
+		return false
+	}
+
+	for _, c := range slug {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateProduct checks the catalog fields of p, which staff enter.
+func ValidateProduct(p Product) error {
+	err := p.Type.Valid()
+	if err != nil {
+		return err
+	}
+
+	if !ValidSlug(p.Slug) {
+		return ErrInvalidSlug
+	}
+
+	name := strings.TrimSpace(p.Name)
+	if name == "" || name != p.Name || utf8.RuneCountInString(name) > MaxProductNameLength {
+		return ErrInvalidProductName
+	}
+
+	if utf8.RuneCountInString(p.Description) > MaxProductDescriptionLength {
+		return ErrInvalidProductDescription
+	}
+
+	if p.Position < 0 {
+		return ErrInvalidProductPosition
+	}
+
+	if p.MachineLimit < 0 {
+		return ErrInvalidMachineLimit
+	}
+
+	if len(p.Media) > MaxProductMedia {
+		return ErrInvalidMedia
+	}
+
+	for _, m := range p.Media {
+		err = validateMedia(m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return validatePrices(p.Prices)
+}
+
+// validatePrices checks that prices are positive amounts of supported
+// currencies, and that no two apply to the same currency and region.
+func validatePrices(prices []Price) error {
+	for i, price := range prices {
+		if price.Money.Valid() != nil || price.Region.Valid() != nil || price.Money.Amount <= 0 {
+			return ErrInvalidPrice
+		}
+
+		for _, other := range prices[:i] {
+			if other.Money.Currency == price.Money.Currency && other.Region == price.Region {
+				return ErrInvalidPrice
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateMedia checks that m is of a known kind, and references an HTTPS
+// URL.
+func validateMedia(m Media) error {
+	if m.Kind != MediaImage && m.Kind != MediaVideo && m.Kind != MediaAudio {
+		return ErrInvalidMedia
+	}
+
+	u, err := url.Parse(m.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return ErrInvalidMedia
+	}
+
+	if utf8.RuneCountInString(m.Alt) > maxMediaAltLength {
+		return ErrInvalidMedia
+	}
+
+	return nil
+}
+
+type ProductError string
+
+func (e ProductError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidProductType        ProductError = "product type must be plugin or merchandise"
+	ErrInvalidSlug               ProductError = "slug must be 1 to 64 lowercase letters, digits and single dashes"
+	ErrInvalidProductName        ProductError = "product name must be 1 to 100 characters without surrounding spaces"
+	ErrInvalidProductDescription ProductError = "product description is too long"
+	ErrInvalidProductPosition    ProductError = "product position must not be negative"
+	ErrInvalidMachineLimit       ProductError = "machine limit must not be negative"
+	ErrInvalidMedia              ProductError = "media must be at most 20 images, videos or audio files with HTTPS URLs"
+	ErrInvalidRegion             ProductError = "region must be a country code or default"
+	ErrInvalidPrice              ProductError = "prices must be positive, in supported currencies, and one per currency and region"
+)
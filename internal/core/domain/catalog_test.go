@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+func TestProductType_Text(t *testing.T) {
+	for _, typ := range domain.ProductTypes() {
+		b, err := json.Marshal(typ)
+		assert.NoError(t, err)
+
+		var got domain.ProductType
+		assert.NoError(t, json.Unmarshal(b, &got))
+		assert.Equal(t, got, typ)
+	}
+
+	_, err := domain.ParseProductType("vinyl")
+	assert.Error(t, err, domain.ErrInvalidProductType)
+
+	_, err = json.Marshal(domain.ProductType(7))
+	assert.ErrorAndWant(t, err, true)
+}
+
+func TestValidSlug(t *testing.T) {
+	for slug, want := range map[string]bool{
+		"microwave":             true,
+		"microwave-2":           true,
+		"":                      false,
+		"Microwave":             false,
+		"-microwave":            false,
+		"microwave-":            false,
+		"micro--wave":           false,
+		"micro wave":            false,
+		strings.Repeat("a", 65): false,
+	} {
+		assert.Equal(t, domain.ValidSlug(slug), want)
+	}
+}
+
+func TestValidateProduct(t *testing.T) {
+	valid := func() domain.Product {
+		return domain.Product{
+			Type:        domain.ProductPlugin,
+			Slug:        "microwave",
+			Name:        "Microwave",
+			Description: "Our very first audio plugin.",
+			Version:     "",
+			Media: []domain.Media{
+				{Kind: domain.MediaImage, URL: "https://cdn.brokedaear.com/microwave.png", Alt: "Microwave"},
+			},
+		} //nolint:exhaustruct // only catalog fields are validated
+	}
+
+	tests := []struct {
+		test.CaseBase
+		modify func(*domain.Product)
+	}{
+		{
+			CaseBase: test.NewCaseBase("valid", nil, false),
+			modify:   func(*domain.Product) {},
+		},
+		{
+			CaseBase: test.NewCaseBase("unknown type", domain.ErrInvalidProductType, true),
+			modify:   func(p *domain.Product) { p.Type = 7 },
+		},
+		{
+			CaseBase: test.NewCaseBase("invalid slug", domain.ErrInvalidSlug, true),
+			modify:   func(p *domain.Product) { p.Slug = "Microwave" },
+		},
+		{
+			CaseBase: test.NewCaseBase("blank name", domain.ErrInvalidProductName, true),
+			modify:   func(p *domain.Product) { p.Name = " " },
+		},
+		{
+			CaseBase: test.NewCaseBase("long description", domain.ErrInvalidProductDescription, true),
+			modify: func(p *domain.Product) {
+				p.Description = strings.Repeat("a", domain.MaxProductDescriptionLength+1)
+			},
+		},
+		{
+			CaseBase: test.NewCaseBase("negative position", domain.ErrInvalidProductPosition, true),
+			modify:   func(p *domain.Product) { p.Position = -1 },
+		},
+		{
+			CaseBase: test.NewCaseBase("negative machine limit", domain.ErrInvalidMachineLimit, true),
+			modify:   func(p *domain.Product) { p.MachineLimit = -1 },
+		},
+		{
+			CaseBase: test.NewCaseBase("insecure media", domain.ErrInvalidMedia, true),
+			modify:   func(p *domain.Product) { p.Media[0].URL = "http://cdn.brokedaear.com/microwave.png" },
+		},
+		{
+			CaseBase: test.NewCaseBase("unknown media kind", domain.ErrInvalidMedia, true),
+			modify:   func(p *domain.Product) { p.Media[0].Kind = "pdf" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			p := valid()
+			tt.modify(&p)
+
+			err := domain.ValidateProduct(p)
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+			if tt.WantErr {
+				assert.Error(t, err, tt.Want.(error))
+			}
+		})
+	}
+}
@@ -14,6 +14,273 @@ type Customer struct {
 	Email          string
 	HashedPassword []byte
 	Created        time.Time
+
+	// Verified tells whether the customer proved they own their email. Only
+	// verified customers may purchase and download plugins.
+	Verified bool
+
+	// PaymentCustomerID is the ID of the customer at the payment provider,
+	// empty until their first checkout.
+	PaymentCustomerID string
+}
+
+// Verification is a pending email verification of a customer. A customer
+// has at most one: sending a new verification replaces the previous one.
+type Verification struct {
+	CustomerID int
+
+	// NonceHash is the SHA-256 digest of the random nonce carried by the
+	// verification token. It is deleted when the token is used, so that the
+	// token cannot be used twice.
+	NonceHash []byte
+
+	Created time.Time
+	Expires time.Time
+}
+
+// PasswordReset is a pending password reset of a customer. A customer has
+// at most one: requesting a new reset replaces the previous one.
+type PasswordReset struct {
+	CustomerID int
+
+	// TokenHash is the SHA-256 digest of the reset token mailed to the
+	// customer. It is deleted when the token is used.
+	TokenHash []byte
+
+	Created time.Time
+	Expires time.Time
+}
+
+// TOTP is the time-based one-time password second factor of a customer.
+type TOTP struct {
+	CustomerID int
+
+	// SealedSecret is the shared secret, encrypted with a key from the
+	// configuration, so that a leak of the database alone does not leak
+	// second factors.
+	SealedSecret []byte
+
+	// Confirmed tells whether the customer proved their authenticator app
+	// generates codes from the secret. Only confirmed TOTPs are enforced.
+	Confirmed bool
+
+	// LastCounter is the counter of the last code accepted. Codes for it or
+	// earlier counters are rejected, so that codes cannot be replayed.
+	LastCounter int64
+
+	Created time.Time
+}
+
+// Mail is an email sent to a customer.
+type Mail struct {
+	To      string
+	Subject string
+
+	// Text is the plain text body of the email.
+	Text string
+}
+
+// Session is a logged in browser session of a customer. The session token
+// itself is only known to the browser; the server keeps a digest of it.
+type Session struct {
+	// ID publicly identifies the session, such as when a customer lists or
+	// revokes their sessions. It cannot be used to authenticate.
+	ID string
+
+	// TokenHash is the SHA-256 digest of the session token.
+	TokenHash []byte
+
+	CustomerID int
+
+	Created time.Time
+
+	// LastSeen is the last time the session was used.
+	LastSeen time.Time
+
+	// Expires is the sliding expiry of the session, pushed back as the
+	// session is used.
+	Expires time.Time
+
+	// AbsoluteExpires is the time past which the session expires no matter
+	// how recently it was used.
+	AbsoluteExpires time.Time
+
+	UserAgent string
+	IP        string
+
+	// SteppedUp is the last time the customer proved their second factor
+	// in this session, or the zero time if they never did.
+	SteppedUp time.Time
+}
+
+// DeviceAuthorizationStatus is the decision of a customer on a device
+// authorization.
+type DeviceAuthorizationStatus string
+
+const (
+	DeviceAuthorizationPending  DeviceAuthorizationStatus = "pending"
+	DeviceAuthorizationApproved DeviceAuthorizationStatus = "approved"
+	DeviceAuthorizationDenied   DeviceAuthorizationStatus = "denied"
+)
+
+// DeviceAuthorization is a pending OAuth 2.0 device authorization (RFC
+// 8628): a plugin waiting for a customer to approve its user code on the
+// website.
+type DeviceAuthorization struct {
+	// DeviceCodeHash is the SHA-256 digest of the device code, which only
+	// the plugin knows and polls with.
+	DeviceCodeHash []byte
+
+	// UserCode is the short code the customer enters on the website,
+	// without its dash.
+	UserCode string
+
+	ClientID   string
+	DeviceName string
+	Scopes     []string
+
+	Created time.Time
+	Expires time.Time
+
+	// Interval is the shortest time the plugin must wait between two polls.
+	// It grows when the plugin polls too fast.
+	Interval time.Duration
+
+	// LastPolled is the time of the last poll, or the zero time.
+	LastPolled time.Time
+
+	Status DeviceAuthorizationStatus
+
+	// CustomerID is the customer who decided, or zero while pending.
+	CustomerID int
+}
+
+// Device is a plugin a customer signed in to through a device
+// authorization. Like sessions, it only keeps the digests of its tokens.
+type Device struct {
+	// ID publicly identifies the device, such as when a customer lists or
+	// revokes their devices.
+	ID string
+
+	CustomerID int
+	ClientID   string
+	Name       string
+	Scopes     []string
+
+	// RefreshTokenHash is the SHA-256 digest of the refresh token. Refresh
+	// tokens are rotated on every use.
+	RefreshTokenHash []byte
+
+	// AccessTokenHash is the SHA-256 digest of the current access token.
+	AccessTokenHash []byte
+
+	// AccessExpires is the expiry of the current access token.
+	AccessExpires time.Time
+
+	Created  time.Time
+	LastSeen time.Time
+
+	// Expires is the expiry of the refresh token, pushed back on every
+	// refresh. A device left unused past it is signed out.
+	Expires time.Time
+}
+
+// Role names a set of permissions granted to customers. Every customer has
+// RoleCustomer; staff are granted more roles on top of it.
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleSupport  Role = "support"
+	RoleAdmin    Role = "admin"
+)
+
+// Permission is a staff operation a role allows. What customers do with
+// their own data, such as reading their orders, needs no permission.
+type Permission string
+
+const (
+	// PermissionAccessAdmin lets staff reach the admin routes at all.
+	PermissionAccessAdmin Permission = "admin:access"
+
+	PermissionViewCustomers  Permission = "customers:view"
+	PermissionRefundOrders   Permission = "orders:refund"
+	PermissionManageOrders   Permission = "orders:manage"
+	PermissionManageLicenses Permission = "licenses:manage"
+	PermissionManageProducts Permission = "products:manage"
+	PermissionManageRoles    Permission = "roles:manage"
+	PermissionViewAudit      Permission = "audit:view"
+	PermissionManagePayments Permission = "payments:manage"
+)
+
+// Permissions returns every permission, in a stable order.
+func Permissions() []Permission {
+	return []Permission{
+		PermissionAccessAdmin,
+		PermissionViewCustomers,
+		PermissionRefundOrders,
+		PermissionManageOrders,
+		PermissionManageLicenses,
+		PermissionManageProducts,
+		PermissionManageRoles,
+		PermissionViewAudit,
+		PermissionManagePayments,
+	}
+}
+
+// RoleDefinition is a custom role, defined by admins in addition to the
+// built-in roles.
+type RoleDefinition struct {
+	Name        Role
+	Permissions []Permission
+	Created     time.Time
+}
+
+// AuditEventType is the kind of a security relevant event.
+type AuditEventType string
+
+const (
+	AuditAccountLocked   AuditEventType = "account_locked"
+	AuditAccountUnlocked AuditEventType = "account_unlocked"
+	AuditIPLocked        AuditEventType = "ip_locked"
+	AuditIPUnlocked      AuditEventType = "ip_unlocked"
+	AuditPasswordReset   AuditEventType = "password_reset"
+	AuditPasswordChanged AuditEventType = "password_changed"
+
+	AuditTwoFactorEnabled         AuditEventType = "two_factor_enabled"
+	AuditTwoFactorDisabled        AuditEventType = "two_factor_disabled"
+	AuditRecoveryCodeUsed         AuditEventType = "recovery_code_used"
+	AuditRecoveryCodesRegenerated AuditEventType = "recovery_codes_regenerated"
+
+	AuditDeviceAuthorized AuditEventType = "device_authorized"
+	AuditDeviceRevoked    AuditEventType = "device_revoked"
+
+	AuditAccessDenied AuditEventType = "access_denied"
+	AuditRoleGranted  AuditEventType = "role_granted"
+	AuditRoleRevoked  AuditEventType = "role_revoked"
+
+	AuditPaymentRefunded AuditEventType = "payment_refunded"
+	AuditPaymentDisputed AuditEventType = "payment_disputed"
+
+	AuditLicenseRevoked     AuditEventType = "license_revoked"
+	AuditUpgradeDoubleSpent AuditEventType = "upgrade_double_spent"
+)
+
+// AuditEvent records a security relevant event in the audit trail.
+type AuditEvent struct {
+	Type AuditEventType
+
+	// Subject is what the event is about, such as the email of an account or
+	// an IP address.
+	Subject string
+
+	// IP is the address of the client that caused the event.
+	IP string
+
+	Time time.Time
+
+	// Detail is a human readable description of the event.
+	Detail string
 }
 
 // Product represents a product that we sell. This could be an audio plugin,
@@ -21,16 +288,140 @@ type Customer struct {
 type Product struct {
 	ID int
 
-	// Type of the product, 0 is plugin, 1 is merchandise.
-	Type int
+	Type ProductType
+
+	// Slug identifies the product in URLs, such as "microwave". It is unique
+	// across the catalog.
+	Slug string
 
 	// Name is the name of the product, for example, "Microwave", which is
 	// our very first audio plugin.
 	Name string
 
+	// Description is the plain text description shown in the catalog.
+	Description string
+
+	// Version is the current release of the product, such as "1.2.0" for a
+	// plugin. Orders record the version bought. Products without releases,
+	// such as merchandise, have none.
+	Version string
+
+	// MachineLimit is how many machines a license of the product may be
+	// activated on at once, for example 3, or zero for no limit. Products
+	// without licenses, such as merchandise, have none.
+	MachineLimit int
+
+	// Media are the images, videos and audio demos of the product, in the
+	// order they are shown.
+	Media []Media
+
+	// Published tells whether the product is listed in the public catalog.
+	// Unpublished products are only seen by staff.
+	Published bool
+
+	// Position orders the catalog, lowest first. Products at the same
+	// position are ordered by ID.
+	Position int
+
+	// Prices are the prices of the product, at most one per currency and
+	// region.
+	Prices []Price
+
 	// PriceID is found on stripe.
 	PriceID string
 
 	// ProductID is found on stripe.
 	ProductID string
+
+	Created time.Time
+	Updated time.Time
+}
+
+// Price is what a product costs in a region, in one currency.
+type Price struct {
+	Region Region
+	Money  Money
+}
+
+// MediaKind is the kind of a media of a product.
+type MediaKind string
+
+const (
+	MediaImage MediaKind = "image"
+	MediaVideo MediaKind = "video"
+	MediaAudio MediaKind = "audio"
+)
+
+// Media references a file of a product served by the CDN. The catalog only
+// keeps its URL.
+type Media struct {
+	Kind MediaKind
+	URL  string
+
+	// Alt is the text alternative of the media, for screen readers.
+	Alt string
+}
+
+// Cart is the shopping cart of a customer, or of a guest who has not
+// logged in. Carts only hold what is bought: prices are always computed
+// afresh from the catalog.
+type Cart struct {
+	// ID identifies the cart. Guests hold it in a signed cookie.
+	ID string
+
+	// CustomerID is the owner of the cart, or 0 for the cart of a guest. A
+	// customer has at most one cart.
+	CustomerID int
+
+	// Lines are the lines of the cart, in the order they were added. No two
+	// lines have the same product and gift flag.
+	Lines []CartLine
+
+	Created time.Time
+	Updated time.Time
+
+	// Expires is when the cart is deleted, pushed back as it is updated.
+	Expires time.Time
+}
+
+// CartLine is a product in a cart.
+type CartLine struct {
+	ProductID int
+	Quantity  int
+
+	// Gift tells whether the line is bought for someone else. Licenses of
+	// gifted plugins are redeemed by their recipients.
+	Gift bool
+}
+
+// PricedCart is a cart priced from the catalog in a currency and region.
+type PricedCart struct {
+	Cart     Cart
+	Currency Currency
+	Region   Region
+
+	// Lines are priced in the order of the lines of the cart.
+	Lines []PricedCartLine
+
+	// Total is the sum of the available lines.
+	Total Money
+}
+
+// PricedCartLine is a line of a cart with its product as it is in the
+// catalog.
+type PricedCartLine struct {
+	Line    CartLine
+	Product Product
+
+	// Available tells whether the product can be bought: it is published
+	// and has a price in the currency. Unavailable lines are left out of the
+	// total and cannot be checked out.
+	Available bool
+
+	UnitPrice Money
+	Subtotal  Money
+
+	// Upgrade is the upgrade the line is priced at, or nil for a line at
+	// its list price.
+	Upgrade *Upgrade
 }
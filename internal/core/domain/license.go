@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"crypto/rand"
+	"strings"
+	"time"
+)
+
+// LicenseEdition is the edition of a plugin a license unlocks.
+type LicenseEdition string
+
+const (
+	// EditionStandard is the edition of the plugins sold in the catalog.
+	EditionStandard LicenseEdition = "standard"
+
+	// EditionTrial is the edition of trials: the standard edition, for a
+	// limited time. Trials have no order until they are bought, which
+	// converts them to the standard edition.
+	EditionTrial LicenseEdition = "trial"
+)
+
+// License is the right of a customer to use a plugin, issued for the order
+// they bought it with. Plugins verify it offline from its signed token,
+// and customers type its key to get that token.
+type License struct {
+	ID int
+
+	// Key identifies the license to customers, formatted by
+	// FormatLicenseKey.
+	Key string
+
+	// OrderID is zero for trials not bought yet. Line is the position of
+	// the order line the license was issued for, and Seat which of the
+	// licenses of its quantity it is.
+	OrderID    int
+	Line       int
+	Seat       int
+	CustomerID int
+	ProductID  int
+
+	// Version is the version of the plugin the license was issued for,
+	// which upgrade rules match.
+	Version string
+	Edition LicenseEdition
+	Issued  time.Time
+
+	// Expires is when the license stops being valid, or zero for a license
+	// that does not expire.
+	Expires time.Time
+
+	// Revoked is when the license was revoked, and RevokeReason why, or
+	// zero for a license in force.
+	Revoked      time.Time
+	RevokeReason string
+
+	// UpgradedFrom is the license the customer upgraded from to buy this
+	// one at an upgrade price, or zero.
+	UpgradedFrom int
+}
+
+// Trial reports whether the license is a trial, not bought yet.
+func (l License) Trial() bool {
+	return l.Edition == EditionTrial
+}
+
+// Valid reports whether the license is in force at now: neither revoked
+// nor expired.
+func (l License) Valid(now time.Time) bool {
+	return l.Revoked.IsZero() && (l.Expires.IsZero() || now.Before(l.Expires))
+}
+
+const (
+	// licenseKeyAlphabet is the Crockford base32 alphabet, which leaves
+	// out the letters mistaken for digits, and U.
+	licenseKeyAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+	// licenseKeyLength is the number of symbols of a license key, the last
+	// of which is a check symbol. The others carry 95 random bits.
+	licenseKeyLength = 20
+
+	// licenseKeyGroup is the number of symbols between the dashes of a
+	// formatted license key.
+	licenseKeyGroup = 5
+)
+
+// NewLicenseKey returns a random license key, formatted by
+// FormatLicenseKey.
+func NewLicenseKey() string {
+	random := make([]byte, licenseKeyLength-1)
+	_, _ = rand.Read(random)
+
+	symbols := make([]byte, 0, licenseKeyLength)
+	for _, b := range random {
+		// 256 is a multiple of 32, so every symbol is equally likely.
+		symbols = append(symbols, licenseKeyAlphabet[b%32])
+	}
+
+	symbols = append(symbols, licenseKeyAlphabet[licenseKeyCheck(symbols)])
+
+	return FormatLicenseKey(string(symbols))
+}
+
+// FormatLicenseKey returns the symbols of a license key in groups of five
+// separated by dashes, such as "7K2QM-9XD4T-HB3NW-RZ8VG".
+func FormatLicenseKey(symbols string) string {
+	var b strings.Builder
+
+	for i, r := range symbols {
+		if i > 0 && i%licenseKeyGroup == 0 {
+			b.WriteByte('-')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// ParseLicenseKey returns the license key s as typed by a customer,
+// formatted by FormatLicenseKey. Case, dashes and spaces do not matter,
+// and O, I and L are read as the digits they are mistaken for. Keys with
+// a typo fail with ErrInvalidLicenseKey, without being looked up: the
+// check symbol catches every mistyped symbol, and nearly every swap of
+// two neighboring symbols.
+func ParseLicenseKey(s string) (string, error) {
+	symbols := make([]byte, 0, licenseKeyLength)
+
+	for _, r := range strings.ToUpper(s) {
+		switch r {
+		case '-', ' ':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+
+		if !strings.ContainsRune(licenseKeyAlphabet, r) || len(symbols) == licenseKeyLength {
+			return "", ErrInvalidLicenseKey
+		}
+
+		symbols = append(symbols, byte(r))
+	}
+
+	if len(symbols) != licenseKeyLength {
+		return "", ErrInvalidLicenseKey
+	}
+
+	check := strings.IndexByte(licenseKeyAlphabet, symbols[licenseKeyLength-1])
+	if licenseKeyCheck(symbols[:licenseKeyLength-1]) != check {
+		return "", ErrInvalidLicenseKey
+	}
+
+	return FormatLicenseKey(string(symbols)), nil
+}
+
+// licenseKeyCheck returns the index of the check symbol of symbols, with
+// the Luhn mod N algorithm over the alphabet.
+func licenseKeyCheck(symbols []byte) int {
+	const n = len(licenseKeyAlphabet)
+
+	factor, sum := 2, 0
+
+	for i := len(symbols) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(licenseKeyAlphabet, symbols[i])
+		sum += addend/n + addend%n
+		factor = 3 - factor
+	}
+
+	return (n - sum%n) % n
+}
+
+type LicenseError string
+
+func (e LicenseError) Error() string {
+	return string(e)
+}
+
+const ErrInvalidLicenseKey LicenseError = "license key is mistyped: it has 20 letters and digits, such as " +
+	"7K2QM-9XD4T-HB3NW-RZ8VG"
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+func TestNewLicenseKey(t *testing.T) {
+	key := domain.NewLicenseKey()
+	assert.Equal(t, len(key), 23)
+	assert.NotEqual(t, key, domain.NewLicenseKey())
+
+	parsed, err := domain.ParseLicenseKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, parsed, key)
+}
+
+func TestParseLicenseKey(t *testing.T) {
+	const key = "7K2QM-9XD4T-HB3NW-RZ8VG"
+
+	for _, tt := range []struct {
+		name, input string
+		valid       bool
+	}{
+		{"formatted", key, true},
+		{"lower case", "7k2qm-9xd4t-hb3nw-rz8vg", true},
+		{"no dashes", "7K2QM9XD4THB3NWRZ8VG", true},
+		{"spaces", "7K2QM 9XD4T HB3NW RZ8VG", true},
+		{"mistyped symbol", "7K2QM-9XD4T-HB3NW-RZ8VH", false},
+		{"swapped symbols", "K72QM-9XD4T-HB3NW-RZ8VG", false},
+		{"too short", "7K2QM-9XD4T-HB3NW-RZ8V", false},
+		{"too long", "7K2QM-9XD4T-HB3NW-RZ8VG0", false},
+		{"outside the alphabet", "7K2QM-9XD4T-HB3NW-RZ8U*", false},
+		{"empty", "", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.ParseLicenseKey(tt.input)
+			if !tt.valid {
+				assert.Error(t, err, domain.ErrInvalidLicenseKey)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, got, key)
+		})
+	}
+
+	// Letters mistaken for digits are read as the digits.
+	got, err := domain.ParseLicenseKey("lOOOO-oOOOO-00000-0000y")
+	assert.NoError(t, err)
+	assert.Equal(t, got, "10000-00000-00000-0000Y")
+}
+
+func TestLicense_Valid(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := domain.License{ //nolint:exhaustruct // only the dates matter
+		Issued: now.Add(-time.Hour),
+	}
+
+	assert.True(t, l.Valid(now))
+
+	l.Expires = now
+	assert.False(t, l.Valid(now))
+
+	l.Expires = now.Add(time.Hour)
+	assert.True(t, l.Valid(now))
+
+	l.Revoked = now.Add(-time.Minute)
+	assert.False(t, l.Valid(now))
+}
@@ -0,0 +1,431 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"cmp"
+	"encoding/json"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code, such as "USD".
+type Currency string
+
+const (
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+	CurrencyGBP Currency = "GBP"
+	CurrencyJPY Currency = "JPY"
+	CurrencyCAD Currency = "CAD"
+	CurrencyAUD Currency = "AUD"
+)
+
+// Currencies returns the supported currencies, in a stable order.
+func Currencies() []Currency {
+	return []Currency{CurrencyUSD, CurrencyEUR, CurrencyGBP, CurrencyJPY, CurrencyCAD, CurrencyAUD}
+}
+
+// Valid returns ErrInvalidCurrency when c is not a supported currency.
+func (c Currency) Valid() error {
+	if !slices.Contains(Currencies(), c) {
+		return ErrInvalidCurrency
+	}
+
+	return nil
+}
+
+// Digits returns the number of digits of the minor unit of c, such as 2 for
+// the cents of USD, or 0 for JPY, which has no minor unit.
+func (c Currency) Digits() int {
+	if c == CurrencyJPY {
+		return 0
+	}
+
+	return 2
+}
+
+// Symbol returns the symbol of c, or its code when it has none.
+func (c Currency) Symbol() string {
+	switch c {
+	case CurrencyUSD:
+		return "$"
+	case CurrencyEUR:
+		return "€"
+	case CurrencyGBP:
+		return "£"
+	case CurrencyJPY:
+		return "¥"
+	case CurrencyCAD:
+		return "CA$"
+	case CurrencyAUD:
+		return "A$"
+	default:
+		return string(c)
+	}
+}
+
+// RoundingMode tells how Money.Scale rounds amounts that fall between two
+// minor units.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest minor unit, and halves to the
+	// even one. It is unbiased, so it suits sums of many amounts, such as
+	// taxes of order lines.
+	RoundHalfEven RoundingMode = iota
+
+	// RoundHalfUp rounds to the nearest minor unit, and halves away from
+	// zero.
+	RoundHalfUp
+
+	// RoundDown rounds toward zero, such as for discounts that must never
+	// exceed their rate.
+	RoundDown
+
+	// RoundUp rounds away from zero.
+	RoundUp
+)
+
+// Money is an amount of a currency, counted in its minor units, such as
+// cents, so that arithmetic is exact. Operations on amounts of different
+// currencies fail with ErrCurrencyMismatch, and results that do not fit in
+// an int64 with ErrMoneyOverflow.
+type Money struct {
+	// Amount is the number of minor units, such as 1999 for 19.99 USD.
+	Amount int64
+
+	Currency Currency
+}
+
+// Sum returns the sum of amounts, all of currency c. The sum of no amounts
+// is zero.
+func Sum(c Currency, amounts ...Money) (Money, error) {
+	total := Money{Amount: 0, Currency: c}
+
+	for _, m := range amounts {
+		var err error
+
+		total, err = total.Add(m)
+		if err != nil {
+			return Money{}, err
+		}
+	}
+
+	return total, nil
+}
+
+// Valid returns ErrInvalidCurrency when the currency of m is not supported.
+func (m Money) Valid() error {
+	return m.Currency.Valid()
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+// Mul returns m times n, such as the price of n items.
+func (m Money) Mul(n int64) (Money, error) {
+	return m.Scale(n, 1, RoundDown)
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to, or greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+
+	return cmp.Compare(m.Amount, o.Amount), nil
+}
+
+// Scale returns m times num/den, rounded to a minor unit with mode, such as
+// m.Scale(85, 100, RoundHalfEven) for 15% off. den must be positive.
+func (m Money) Scale(num, den int64, mode RoundingMode) (Money, error) {
+	if den <= 0 {
+		return Money{}, ErrInvalidRatio
+	}
+
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num))
+	q, r := new(big.Int).QuoRem(product, big.NewInt(den), new(big.Int))
+
+	if r.Sign() != 0 {
+		// |r| is compared to half of den as 2|r| to den, to stay exact.
+		twice := new(big.Int).Lsh(new(big.Int).Abs(r), 1).Cmp(big.NewInt(den))
+
+		var away bool
+
+		switch mode {
+		case RoundHalfEven:
+			away = twice > 0 || twice == 0 && q.Bit(0) == 1
+		case RoundHalfUp:
+			away = twice >= 0
+		case RoundDown:
+			away = false
+		case RoundUp:
+			away = true
+		}
+
+		if away {
+			q.Add(q, big.NewInt(int64(product.Sign())))
+		}
+	}
+
+	if !q.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return Money{Amount: q.Int64(), Currency: m.Currency}, nil
+}
+
+// Allocate splits m in parts proportional to ratios, without losing minor
+// units: the parts add up to m. The minor units left over by rounding go
+// to the parts that lost the most to it, the first ones on ties.
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	if m.Amount == math.MinInt64 {
+		return nil, ErrMoneyOverflow
+	}
+
+	total := new(big.Int)
+
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, ErrInvalidRatio
+		}
+
+		total.Add(total, big.NewInt(r))
+	}
+
+	if total.Sign() == 0 {
+		return nil, ErrInvalidRatio
+	}
+
+	// Allocating the absolute amount and restoring the sign afterwards
+	// rounds negative amounts like positive ones.
+	amount := new(big.Int).Abs(big.NewInt(m.Amount))
+	parts := make([]Money, len(ratios))
+	remainders := make([]*big.Int, len(ratios))
+	left := new(big.Int).Set(amount)
+
+	for i, r := range ratios {
+		q, rem := new(big.Int).QuoRem(new(big.Int).Mul(amount, big.NewInt(r)), total, new(big.Int))
+		left.Sub(left, q)
+		parts[i] = Money{Amount: q.Int64(), Currency: m.Currency}
+		remainders[i] = rem
+	}
+
+	order := make([]int, len(ratios))
+	for i := range order {
+		order[i] = i
+	}
+
+	slices.SortStableFunc(order, func(a, b int) int {
+		return remainders[b].Cmp(remainders[a])
+	})
+
+	for _, i := range order[:left.Int64()] {
+		parts[i].Amount++
+	}
+
+	if m.Amount < 0 {
+		for i := range parts {
+			parts[i].Amount = -parts[i].Amount
+		}
+	}
+
+	return parts, nil
+}
+
+// Split splits m in n parts as equal as possible, the first ones getting
+// the minor units left over.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, ErrInvalidRatio
+	}
+
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+
+	return m.Allocate(ratios...)
+}
+
+// Decimal returns the amount of m in major units, such as "19.99" or
+// "-0.05", or "1235" for JPY.
+func (m Money) Decimal() string {
+	integer, fraction := m.digits()
+
+	s := integer
+	if fraction != "" {
+		s += "." + fraction
+	}
+
+	if m.Amount < 0 {
+		return "-" + s
+	}
+
+	return s
+}
+
+// String returns m as its decimal amount and currency code, such as
+// "19.99 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.Currency)
+}
+
+// Format returns m as written in locale, a BCP 47 language tag such as
+// "en-US" or "de-DE": "$1,234.56" in English, or "1.234,56 $" in German.
+// Locales are told apart by language; unknown languages are formatted as
+// English.
+func (m Money) Format(locale string) string {
+	f := localeFormat(locale)
+	integer, fraction := m.digits()
+
+	var b strings.Builder
+
+	for i, c := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(f.group)
+		}
+
+		b.WriteRune(c)
+	}
+
+	if fraction != "" {
+		b.WriteString(f.decimal + fraction)
+	}
+
+	number := b.String()
+	sign := ""
+
+	if m.Amount < 0 {
+		sign = "-"
+	}
+
+	if f.symbolAfter {
+		return sign + number + "\u00a0" + m.Currency.Symbol()
+	}
+
+	return sign + m.Currency.Symbol() + number
+}
+
+// digits returns the integer and fraction digits of the absolute amount of
+// m.
+func (m Money) digits() (string, string) {
+	// Negating in uint64 also works for math.MinInt64.
+	abs := uint64(m.Amount) //nolint:gosec // two's complement is intended
+	if m.Amount < 0 {
+		abs = -abs
+	}
+
+	n := m.Currency.Digits()
+	s := strconv.FormatUint(abs, 10)
+
+	if n == 0 {
+		return s, ""
+	}
+
+	if len(s) <= n {
+		s = strings.Repeat("0", n-len(s)+1) + s
+	}
+
+	return s[:len(s)-n], s[len(s)-n:]
+}
+
+type moneyJSON struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON encodes m as its amount in minor units and currency code,
+// such as {"amount":1999,"currency":"USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON(m))
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	err = v.Currency.Valid()
+	if err != nil {
+		return err
+	}
+
+	*m = Money(v)
+
+	return nil
+}
+
+// numberFormat is how a locale writes amounts of money.
+type numberFormat struct {
+	decimal string
+	group   string
+
+	// symbolAfter writes the currency symbol after the number, separated by
+	// a no-break space.
+	symbolAfter bool
+}
+
+func localeFormat(locale string) numberFormat {
+	language, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+
+	switch strings.ToLower(language) {
+	case "de", "es", "it", "nl", "pt":
+		return numberFormat{decimal: ",", group: ".", symbolAfter: true}
+	case "fr":
+		// French groups digits with a narrow no-break space.
+		return numberFormat{decimal: ",", group: "\u202f", symbolAfter: true}
+	default:
+		return numberFormat{decimal: ".", group: ",", symbolAfter: false}
+	}
+}
+
+type MoneyError string
+
+func (e MoneyError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidCurrency  MoneyError = "currency is not supported"
+	ErrCurrencyMismatch MoneyError = "amounts are in different currencies"
+	ErrMoneyOverflow    MoneyError = "amount is out of range"
+	ErrInvalidRatio     MoneyError = "ratios must not be negative, and must not all be zero"
+)
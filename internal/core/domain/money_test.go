@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+func usd(amount int64) domain.Money {
+	return domain.Money{Amount: amount, Currency: domain.CurrencyUSD}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	sum, err := usd(1999).Add(usd(1))
+	assert.NoError(t, err)
+	assert.Equal(t, sum, usd(2000))
+
+	diff, err := usd(500).Sub(usd(700))
+	assert.NoError(t, err)
+	assert.Equal(t, diff, usd(-200))
+	assert.True(t, diff.IsNegative())
+
+	product, err := usd(1999).Mul(3)
+	assert.NoError(t, err)
+	assert.Equal(t, product, usd(5997))
+
+	_, err = usd(1).Add(domain.Money{Amount: 1, Currency: domain.CurrencyEUR})
+	assert.Error(t, err, domain.ErrCurrencyMismatch)
+
+	_, err = usd(math.MaxInt64).Add(usd(1))
+	assert.Error(t, err, domain.ErrMoneyOverflow)
+
+	_, err = usd(math.MinInt64).Sub(usd(1))
+	assert.Error(t, err, domain.ErrMoneyOverflow)
+
+	_, err = usd(math.MaxInt64 / 2).Mul(3)
+	assert.Error(t, err, domain.ErrMoneyOverflow)
+
+	total, err := domain.Sum(domain.CurrencyUSD, usd(3000), usd(7000), usd(1000))
+	assert.NoError(t, err)
+	assert.Equal(t, total, usd(11000))
+
+	c, err := usd(1).Cmp(usd(2))
+	assert.NoError(t, err)
+	assert.Equal(t, c, -1)
+}
+
+func TestMoney_Scale(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		amount int64
+		mode   domain.RoundingMode
+		want   int64
+	}{
+		// Half of 5 and of 15 cents fall between two cents.
+		{"half even down", 5, domain.RoundHalfEven, 2},
+		{"half even up", 15, domain.RoundHalfEven, 8},
+		{"half up", 5, domain.RoundHalfUp, 3},
+		{"half up negative", -5, domain.RoundHalfUp, -3},
+		{"down", 5, domain.RoundDown, 2},
+		{"down negative", -5, domain.RoundDown, -2},
+		{"up", 5, domain.RoundUp, 3},
+		{"exact", 4, domain.RoundUp, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := usd(tt.amount).Scale(1, 2, tt.mode)
+			assert.NoError(t, err)
+			assert.Equal(t, got, usd(tt.want))
+		})
+	}
+
+	// 15% off 19.99 is 16.9915, which rounds to 16.99.
+	got, err := usd(1999).Scale(85, 100, domain.RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, got, usd(1699))
+
+	_, err = usd(1).Scale(1, 0, domain.RoundDown)
+	assert.Error(t, err, domain.ErrInvalidRatio)
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	parts, err := usd(100).Split(3)
+	assert.NoError(t, err)
+	assert.Equal(t, len(parts), 3)
+	assert.Equal(t, parts[0], usd(34))
+	assert.Equal(t, parts[1], usd(33))
+	assert.Equal(t, parts[2], usd(33))
+
+	// The cent left over goes to the part that lost the most to rounding:
+	// 5 × 70% = 3.5 and 5 × 30% = 1.5 lose as much, so the first one.
+	parts, err = usd(5).Allocate(70, 30)
+	assert.NoError(t, err)
+	assert.Equal(t, parts[0], usd(4))
+	assert.Equal(t, parts[1], usd(1))
+
+	// 10 × 1/6 = 1.67 loses more than 10 × 5/6 = 8.33.
+	parts, err = usd(10).Allocate(5, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, parts[0], usd(8))
+	assert.Equal(t, parts[1], usd(2))
+
+	parts, err = usd(-100).Split(3)
+	assert.NoError(t, err)
+	assert.Equal(t, parts[0], usd(-34))
+
+	total, err := domain.Sum(domain.CurrencyUSD, parts...)
+	assert.NoError(t, err)
+	assert.Equal(t, total, usd(-100))
+
+	_, err = usd(100).Allocate(0, 0)
+	assert.Error(t, err, domain.ErrInvalidRatio)
+
+	_, err = usd(100).Allocate(1, -1)
+	assert.Error(t, err, domain.ErrInvalidRatio)
+}
+
+func TestMoney_Format(t *testing.T) {
+	for _, tt := range []struct {
+		money  domain.Money
+		locale string
+		want   string
+	}{
+		{usd(123456), "en-US", "$1,234.56"},
+		{usd(-5), "en-US", "-$0.05"},
+		{domain.Money{Amount: 123456, Currency: domain.CurrencyEUR}, "de-DE", "1.234,56\u00a0€"},
+		{domain.Money{Amount: 123456789, Currency: domain.CurrencyEUR}, "fr_FR", "1\u202f234\u202f567,89\u00a0€"},
+		{domain.Money{Amount: 1235, Currency: domain.CurrencyJPY}, "ja-JP", "¥1,235"},
+		{domain.Money{Amount: 999, Currency: domain.CurrencyGBP}, "", "£9.99"},
+	} {
+		assert.Equal(t, tt.money.Format(tt.locale), tt.want)
+	}
+
+	assert.Equal(t, usd(1999).String(), "19.99 USD")
+	assert.Equal(t, usd(7).Decimal(), "0.07")
+	assert.Equal(t, usd(math.MinInt64).Decimal(), "-92233720368547758.08")
+}
+
+func TestMoney_JSON(t *testing.T) {
+	b, err := json.Marshal(usd(1999))
+	assert.NoError(t, err)
+	assert.Equal(t, string(b), `{"amount":1999,"currency":"USD"}`)
+
+	var m domain.Money
+	assert.NoError(t, json.Unmarshal(b, &m))
+	assert.Equal(t, m, usd(1999))
+
+	err = json.Unmarshal([]byte(`{"amount":1,"currency":"XXX"}`), &m)
+	assert.Error(t, err, domain.ErrInvalidCurrency)
+}
+
+func TestProduct_Price(t *testing.T) {
+	p := domain.Product{
+		Prices: []domain.Price{
+			{Region: domain.RegionDefault, Money: usd(4900)},
+			{Region: "IN", Money: usd(1900)},
+		},
+	} //nolint:exhaustruct // only prices are read
+
+	price, ok := p.Price(domain.CurrencyUSD, "IN")
+	assert.True(t, ok)
+	assert.Equal(t, price, usd(1900))
+
+	price, ok = p.Price(domain.CurrencyUSD, "US")
+	assert.True(t, ok)
+	assert.Equal(t, price, usd(4900))
+
+	_, ok = p.Price(domain.CurrencyEUR, "DE")
+	assert.False(t, ok)
+}
@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// OrderStatus is the state of an order. Orders move between states along
+// the transitions of OrderStatus.CanTransition only.
+type OrderStatus string
+
+const (
+	// OrderPending orders were checked out and wait for their payment.
+	OrderPending OrderStatus = "pending"
+
+	// OrderPaid orders were paid for, and wait to be fulfilled.
+	OrderPaid OrderStatus = "paid"
+
+	// OrderHeld orders were paid, but not the total they were checked out
+	// for, and wait for staff to review them. They are never fulfilled:
+	// staff cancel them, refunding what was paid.
+	OrderHeld OrderStatus = "held"
+
+	// OrderFulfilled orders were delivered: their licenses were issued, or
+	// their merchandise shipped.
+	OrderFulfilled OrderStatus = "fulfilled"
+
+	// OrderPartiallyRefunded orders had part of their payment refunded.
+	OrderPartiallyRefunded OrderStatus = "partially_refunded"
+
+	// OrderRefunded orders had their whole payment refunded, or lost a
+	// dispute.
+	OrderRefunded OrderStatus = "refunded"
+
+	// OrderDisputed orders have their payment disputed by the cardholder,
+	// until the dispute is closed.
+	OrderDisputed OrderStatus = "disputed"
+
+	// OrderCanceled orders were never paid, or were called off before they
+	// were fulfilled.
+	OrderCanceled OrderStatus = "canceled"
+)
+
+// OrderStatuses returns every order status, in a stable order.
+func OrderStatuses() []OrderStatus {
+	return []OrderStatus{
+		OrderPending,
+		OrderPaid,
+		OrderHeld,
+		OrderFulfilled,
+		OrderPartiallyRefunded,
+		OrderRefunded,
+		OrderDisputed,
+		OrderCanceled,
+	}
+}
+
+// orderTransitions are the statuses each status may move to. Refunded and
+// canceled orders are final. Partially refunded orders may be refunded
+// partially again, disputed orders record the refunds made during the
+// dispute, and go back to where they were when the dispute is won.
+//
+//nolint:gochecknoglobals // Read-only lookup table.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending: {OrderPaid, OrderHeld, OrderCanceled},
+	OrderPaid: {
+		OrderFulfilled, OrderPartiallyRefunded, OrderRefunded, OrderDisputed, OrderCanceled,
+	},
+	OrderHeld:              {OrderRefunded, OrderDisputed, OrderCanceled},
+	OrderFulfilled:         {OrderPartiallyRefunded, OrderRefunded, OrderDisputed},
+	OrderPartiallyRefunded: {OrderPartiallyRefunded, OrderRefunded, OrderDisputed},
+	OrderDisputed: {
+		OrderPaid, OrderHeld, OrderFulfilled, OrderPartiallyRefunded, OrderRefunded, OrderDisputed,
+	},
+	OrderRefunded: {},
+	OrderCanceled: {},
+}
+
+// CanTransition reports whether orders with status s may move to status to.
+func (s OrderStatus) CanTransition(to OrderStatus) bool {
+	return slices.Contains(orderTransitions[s], to)
+}
+
+// Order is what a customer bought in a checkout. Its lines are snapshots
+// of the catalog at the time, and do not change with it.
+type Order struct {
+	ID         int
+	CustomerID int
+	Status     OrderStatus
+
+	Lines []OrderLine
+
+	// Total is what the customer was charged, and Refunded how much of it
+	// was refunded so far.
+	Total    Money
+	Refunded Money
+
+	// CheckoutKey is the idempotency key of the checkout the order was
+	// placed for, known before its session is opened. CheckoutID is the
+	// checkout session of the payment provider, empty until it is opened,
+	// and PaymentID its payment once it is paid.
+	CheckoutKey string
+	CheckoutID  string
+	PaymentID   string
+
+	Created time.Time
+	Updated time.Time
+}
+
+// OrderLine is a product in an order, as it was when it was bought.
+type OrderLine struct {
+	ProductID   int
+	ProductType ProductType
+	Name        string
+	Version     string
+	UnitPrice   Money
+	Quantity    int
+	Gift        bool
+
+	// UpgradeFrom is the license of the customer the line was bought as an
+	// upgrade of, or zero.
+	UpgradeFrom int
+}
+
+// OrderTransition records a change of the status of an order.
+type OrderTransition struct {
+	OrderID int
+
+	// From is the status the order left, empty for the transition that
+	// placed it.
+	From OrderStatus
+	To   OrderStatus
+
+	// Actor is who made the transition, such as "customer 7", "staff 3" or
+	// "payments" for the events of the payment provider.
+	Actor string
+
+	// Reason is why the transition was made, in words.
+	Reason string
+
+	Time time.Time
+}
+
+// Transition moves o to status to, and returns the record of the
+// transition. Transitions that are not allowed fail with a
+// *TransitionError and leave o unchanged.
+func (o *Order) Transition(to OrderStatus, actor, reason string, now time.Time) (OrderTransition, error) {
+	if !o.Status.CanTransition(to) {
+		return OrderTransition{}, &TransitionError{From: o.Status, To: to}
+	}
+
+	t := OrderTransition{
+		OrderID: o.ID,
+		From:    o.Status,
+		To:      to,
+		Actor:   actor,
+		Reason:  reason,
+		Time:    now,
+	}
+
+	o.Status = to
+	o.Updated = now
+
+	return t, nil
+}
+
+// TransitionError is the error of an order transition that is not
+// allowed. It matches ErrIllegalTransition.
+type TransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: order cannot go from %s to %s", ErrIllegalTransition, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+type OrderError string
+
+func (e OrderError) Error() string {
+	return string(e)
+}
+
+const ErrIllegalTransition OrderError = "illegal order transition"
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+func TestOrder_Transition(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	order := domain.Order{ID: 1, Status: domain.OrderPending} //nolint:exhaustruct // only the status matters
+
+	transition, err := order.Transition(domain.OrderPaid, "payments", "checkout completed", now)
+	assert.NoError(t, err)
+	assert.Equal(t, transition, domain.OrderTransition{
+		OrderID: 1,
+		From:    domain.OrderPending,
+		To:      domain.OrderPaid,
+		Actor:   "payments",
+		Reason:  "checkout completed",
+		Time:    now,
+	})
+	assert.Equal(t, order.Status, domain.OrderPaid)
+	assert.Equal(t, order.Updated, now)
+
+	// Illegal transitions leave the order as it was.
+	_, err = order.Transition(domain.OrderPending, "staff 3", "", now.Add(time.Hour))
+	assert.Error(t, err, domain.ErrIllegalTransition)
+	assert.Equal(t, order.Status, domain.OrderPaid)
+	assert.Equal(t, order.Updated, now)
+
+	var transitionErr *domain.TransitionError
+	assert.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, *transitionErr, domain.TransitionError{From: domain.OrderPaid, To: domain.OrderPending})
+}
+
+func TestOrderStatus_CanTransition(t *testing.T) {
+	for _, tt := range []struct {
+		from, to domain.OrderStatus
+		want     bool
+	}{
+		{domain.OrderPending, domain.OrderPaid, true},
+		{domain.OrderPending, domain.OrderCanceled, true},
+		{domain.OrderPending, domain.OrderFulfilled, false},
+		{domain.OrderPending, domain.OrderRefunded, false},
+		{domain.OrderPending, domain.OrderHeld, true},
+		{domain.OrderHeld, domain.OrderCanceled, true},
+		{domain.OrderHeld, domain.OrderPaid, false},
+		{domain.OrderHeld, domain.OrderFulfilled, false},
+		{domain.OrderPaid, domain.OrderFulfilled, true},
+		{domain.OrderPaid, domain.OrderCanceled, true},
+		{domain.OrderPaid, domain.OrderPaid, false},
+		{domain.OrderFulfilled, domain.OrderPartiallyRefunded, true},
+		{domain.OrderFulfilled, domain.OrderDisputed, true},
+		{domain.OrderFulfilled, domain.OrderCanceled, false},
+		{domain.OrderPartiallyRefunded, domain.OrderPartiallyRefunded, true},
+		{domain.OrderPartiallyRefunded, domain.OrderRefunded, true},
+		{domain.OrderDisputed, domain.OrderFulfilled, true},
+		{domain.OrderDisputed, domain.OrderRefunded, true},
+		{domain.OrderDisputed, domain.OrderDisputed, true},
+		{domain.OrderDisputed, domain.OrderCanceled, false},
+		{domain.OrderRefunded, domain.OrderPaid, false},
+		{domain.OrderCanceled, domain.OrderPaid, false},
+	} {
+		assert.Equal(t, tt.from.CanTransition(tt.to), tt.want)
+	}
+
+	// Every status is known to the state machine.
+	for _, s := range domain.OrderStatuses() {
+		assert.False(t, s.CanTransition(""))
+	}
+}
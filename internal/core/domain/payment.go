@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import "time"
+
+// CheckoutRequest asks the payment provider for a hosted payment page.
+type CheckoutRequest struct {
+	// Reference identifies the checkout on our side, such as the ID of the
+	// cart. It comes back with the session and its events.
+	Reference string
+
+	// CustomerID is the customer paying, and PaymentCustomerID their ID at
+	// the payment provider.
+	CustomerID        int
+	PaymentCustomerID string
+
+	Currency Currency
+	Lines    []CheckoutLine
+
+	// SuccessURL and CancelURL are the pages the customer is sent back to
+	// once they paid or gave up.
+	SuccessURL string
+	CancelURL  string
+
+	// IdempotencyKey makes the provider answer repeated requests with the
+	// same session, such as when a customer clicks twice.
+	IdempotencyKey string
+}
+
+// CheckoutLine is a line of a checkout. It is charged the provider price
+// PriceID when set, or else UnitPrice for the provider product ProductID,
+// or for an ad hoc product named Name when ProductID is empty too.
+type CheckoutLine struct {
+	PriceID   string
+	ProductID string
+	Name      string
+	UnitPrice Money
+	Quantity  int
+}
+
+// CheckoutStatus is the state of a checkout session.
+type CheckoutStatus string
+
+const (
+	CheckoutOpen     CheckoutStatus = "open"
+	CheckoutComplete CheckoutStatus = "complete"
+	CheckoutExpired  CheckoutStatus = "expired"
+)
+
+// PaymentStatus tells whether the payment of a checkout session went
+// through.
+type PaymentStatus string
+
+const (
+	PaymentUnpaid            PaymentStatus = "unpaid"
+	PaymentPaid              PaymentStatus = "paid"
+	PaymentNoPaymentRequired PaymentStatus = "no_payment_required"
+)
+
+// CheckoutSession is a hosted payment page of the payment provider.
+type CheckoutSession struct {
+	ID string
+
+	// URL is the payment page to send the customer to. It is empty once the
+	// session is over.
+	URL string
+
+	Reference         string
+	CustomerID        int
+	PaymentCustomerID string
+
+	Status        CheckoutStatus
+	PaymentStatus PaymentStatus
+
+	// PaymentID identifies the payment once the customer paid, and is what
+	// refunds refer to.
+	PaymentID string
+
+	Total   Money
+	Expires time.Time
+}
+
+// RefundReason is why a payment is refunded, as told to the provider.
+type RefundReason string
+
+const (
+	RefundRequestedByCustomer RefundReason = "requested_by_customer"
+	RefundDuplicate           RefundReason = "duplicate"
+	RefundFraudulent          RefundReason = "fraudulent"
+)
+
+// RefundRequest asks the payment provider to refund a payment.
+type RefundRequest struct {
+	PaymentID string
+
+	// Amount is how much to refund, or zero for what is left of the
+	// payment.
+	Amount Money
+
+	Reason         RefundReason
+	IdempotencyKey string
+}
+
+// RefundStatus is the state of a refund.
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+	RefundCanceled  RefundStatus = "canceled"
+)
+
+// Refund is a refund of a payment.
+type Refund struct {
+	ID        string
+	PaymentID string
+	Amount    Money
+	Status    RefundStatus
+}
+
+// PaymentEventType is the kind of an event of the payment provider, named
+// like the Stripe event types.
+type PaymentEventType string
+
+const (
+	EventCheckoutCompleted          PaymentEventType = "checkout.session.completed"
+	EventCheckoutAsyncPaymentPaid   PaymentEventType = "checkout.session.async_payment_succeeded"
+	EventCheckoutAsyncPaymentFailed PaymentEventType = "checkout.session.async_payment_failed"
+	EventCheckoutExpired            PaymentEventType = "checkout.session.expired"
+	EventChargeRefunded             PaymentEventType = "charge.refunded"
+	EventDisputeCreated             PaymentEventType = "charge.dispute.created"
+	EventDisputeClosed              PaymentEventType = "charge.dispute.closed"
+)
+
+// PaymentEvent is an event of the payment provider, such as a completed
+// checkout. At most one of Checkout, Charge and Dispute is set, depending
+// on what the event is about; none is for events about something else.
+type PaymentEvent struct {
+	ID      string
+	Type    PaymentEventType
+	Created time.Time
+
+	Checkout *CheckoutSession
+	Charge   *Charge
+	Dispute  *Dispute
+}
+
+// Charge is a charge of a payment, and what of it was refunded.
+type Charge struct {
+	ID        string
+	PaymentID string
+	Amount    Money
+	Refunded  Money
+
+	// FullyRefunded tells whether the whole charge was refunded.
+	FullyRefunded bool
+}
+
+// Dispute is a charge a customer disputed with their bank.
+type Dispute struct {
+	ID        string
+	ChargeID  string
+	PaymentID string
+	Amount    Money
+	Reason    string
+	Status    string
+}
+
+// DisputeLost is the status of disputes closed in favor of the customer,
+// who keeps the money.
+const DisputeLost = "lost"
+
+// WebhookStatus is the state of a webhook event in the inbox.
+type WebhookStatus string
+
+const (
+	// WebhookPending events are waiting to be dispatched, for the first
+	// time or again after a failure.
+	WebhookPending WebhookStatus = "pending"
+
+	// WebhookProcessed events were dispatched successfully.
+	WebhookProcessed WebhookStatus = "processed"
+
+	// WebhookDead events failed too many times, and wait for staff to look
+	// into them and replay them.
+	WebhookDead WebhookStatus = "dead"
+)
+
+// WebhookStatuses returns every webhook status, in a stable order.
+func WebhookStatuses() []WebhookStatus {
+	return []WebhookStatus{WebhookPending, WebhookProcessed, WebhookDead}
+}
+
+// WebhookEvent is a webhook delivery of the payment provider, kept in the
+// inbox so that every event is processed once, however many times it is
+// delivered.
+type WebhookEvent struct {
+	// ID is the ID of the event at the provider.
+	ID   string
+	Type PaymentEventType
+
+	// Payload is the body of the delivery, as received.
+	Payload []byte
+
+	Received time.Time
+	Status   WebhookStatus
+
+	// Attempts counts the failed dispatches of the event, and LastError
+	// tells why the last one failed.
+	Attempts  int
+	LastError string
+
+	// NextAttempt is when a pending event is due to be dispatched.
+	NextAttempt time.Time
+
+	// Processed is when the event was dispatched successfully, or zero.
+	Processed time.Time
+}
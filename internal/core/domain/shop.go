@@ -4,127 +4,49 @@
 
 package domain
 
-//
-// import (
-// 	"fmt"
-// )
-//
-// type WebShop interface {
-// 	SignUp() error
-// 	Login() error
-// 	Buy() error
-// }
-//
-// // Credential represents any type of credential, such as an email,
-// // name, session JWT, etc.
-// type Credential interface {
-// 	Valid() error
-// 	String() string
-// }
-//
-// // A transactional setting is one in which a customer can potentially purchase
-// // a product.
-//
-// // user represents a user in the overall application system.
-// type user struct {
-// 	email    Credential
-// 	password Credential
-// }
-//
-// // possibleCustomer represents a possible new customer based on a sign up query.
-// // To become a registeredCustomer, verified must be true, email must be valid,
-// // and password must be valid.
-// type possibleCustomer struct {
-// 	user
-// 	// verified bool
-// }
-//
-// func NewPossibleCustomer(email, password Credential, _ ...Credential) (*possibleCustomer, error) {
-// 	var err error
-//
-// 	err = email.Valid()
-// 	if err != nil {
-// 		return nil, invalidEmailError{email.String()}
-// 	}
-//
-// 	err = password.Valid()
-// 	if err != nil {
-// 		return nil, invalidPasswordError{password.String()}
-// 	}
-//
-// 	c := &possibleCustomer{
-// 		user: user{
-// 			email:    email,
-// 			password: password,
-// 		},
-// 	}
-//
-// 	return c, nil
-// }
-//
-// // registeredCustomer represents a customer in any possible transactional
-// // setting, such as being in a checkout scenario, looking at the cart, or even
-// // just being logged in. registeredCustomer is different from possibleCustomer,
-// // because of the password validation methods.
-// type registeredCustomer struct {
-// 	user
-// 	sessionToken Credential
-// }
-//
-// // NewRegisteredCustomer takes an email, password, and optionally a
-// // sessionToken to create a new customer instance.
-// func NewRegisteredCustomer(email, password Credential, sessionToken ...Credential) (*registeredCustomer, error) {
-// 	var err error
-//
-// 	err = email.Valid()
-// 	if err != nil {
-// 		return nil, invalidEmailError{email.String()}
-// 	}
-//
-// 	err = password.Valid()
-// 	if err != nil {
-// 		return nil, invalidPasswordError{password.String()}
-// 	}
-//
-// 	c := &registeredCustomer{
-// 		user: user{
-// 			email:    email,
-// 			password: password,
-// 		},
-// 	}
-//
-// 	if len(sessionToken) >= 1 {
-// 		err = sessionToken[0].Valid()
-// 		if err != nil {
-// 			return nil, invalidSessionTokenError{sessionToken[0].String()}
-// 		}
-//
-// 		c.sessionToken = sessionToken[0]
-// 	}
-//
-// 	return c, nil
-// }
-//
-// type invalidEmailError struct {
-// 	email string
-// }
-//
-// func (e invalidEmailError) Error() string {
-// 	return fmt.Sprintf("invalid email %s", e.email)
-// }
-//
-// type invalidPasswordError struct {
-// 	password string
-// }
-//
-// func (e invalidPasswordError) Error() string {
-// 	return fmt.Sprintf("invalid password %s", e.password)
-// }
-//
-// type invalidSessionTokenError struct {
-// 	token string
-// }
-//
-// func (e invalidSessionTokenError) Error() string {
-// 	return fmt.Sprintf("invalid session token %s", e.token)
-// }
+// Credential represents any type of credential, such as an email,
+// name, session JWT, etc.
+type Credential interface {
+	Valid() error
+	String() string
+}
+
+// PossibleCustomer represents a possible new customer based on a sign up
+// query. Its email is normalized and its password satisfies the length
+// policy. It becomes a Customer once its password is hashed and it is stored.
+type PossibleCustomer struct {
+	Email    string
+	Password NewCustomerPassword
+}
+
+// NewPossibleCustomer validates the credentials of a sign up query.
+func NewPossibleCustomer(email NewCustomerEmail, password NewCustomerPassword) (PossibleCustomer, error) {
+	normalized, err := NormalizeEmail(email.String())
+	if err != nil {
+		return PossibleCustomer{}, err
+	}
+
+	err = password.Valid()
+	if err != nil {
+		return PossibleCustomer{}, err
+	}
+
+	return PossibleCustomer{
+		Email:    normalized,
+		Password: password,
+	}, nil
+}
+
+type CredentialError string
+
+func (e CredentialError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidEmail       CredentialError = "email is not a valid address"
+	ErrPasswordTooShort   CredentialError = "password is too short"
+	ErrPasswordTooLong    CredentialError = "password is too long"
+	ErrPasswordBreached   CredentialError = "password appears in a list of breached passwords"
+	ErrInvalidCredentials CredentialError = "invalid email or password"
+)
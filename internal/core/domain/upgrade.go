@@ -0,0 +1,250 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxUpgradeVersionLength is the longest version an upgrade rule matches.
+const maxUpgradeVersionLength = 32
+
+// UpgradeRule prices a plugin for the owners of a license of another
+// plugin, or of an older version of the same one. Rules from a plugin to
+// itself are upgrades, and rules between plugins crossgrades.
+type UpgradeRule struct {
+	ID            int
+	FromProductID int
+
+	// FromVersion is the version of the licenses of FromProductID the rule
+	// matches: empty for any version, a prefix ending with a dot such as
+	// "1." for a series of versions, or else an exact version.
+	FromVersion string
+	ToProductID int
+
+	// Prices are the prices of ToProductID for the owners of a matching
+	// license, and PercentOff the discount on its list price instead.
+	// Exactly one of them is set.
+	Prices     []Price
+	PercentOff int
+
+	Created time.Time
+}
+
+// ValidateUpgradeRule checks the fields of r, which staff enter.
+func ValidateUpgradeRule(r UpgradeRule) error {
+	if r.FromProductID <= 0 || r.ToProductID <= 0 {
+		return ErrInvalidUpgradeProducts
+	}
+
+	// Owning a plugin makes no one eligible to buy it again at a lower
+	// price, unless they own an older version of it.
+	if r.FromProductID == r.ToProductID && r.FromVersion == "" {
+		return ErrInvalidUpgradeProducts
+	}
+
+	if len(r.FromVersion) > maxUpgradeVersionLength || strings.ContainsFunc(r.FromVersion, unicode.IsSpace) {
+		return ErrInvalidUpgradeVersion
+	}
+
+	if len(r.Prices) > 0 == (r.PercentOff != 0) {
+		return ErrInvalidUpgradeDiscount
+	}
+
+	if r.PercentOff < 0 || r.PercentOff > 100 {
+		return ErrInvalidUpgradeDiscount
+	}
+
+	return validatePrices(r.Prices)
+}
+
+// Matches reports whether l is of the product and version r upgrades
+// from.
+func (r UpgradeRule) Matches(l License) bool {
+	if l.ProductID != r.FromProductID {
+		return false
+	}
+
+	switch {
+	case r.FromVersion == "":
+		return true
+	case strings.HasSuffix(r.FromVersion, "."):
+		return strings.HasPrefix(l.Version, r.FromVersion)
+	default:
+		return l.Version == r.FromVersion
+	}
+}
+
+// Price returns the price of the target of r whose list price is list,
+// for customers in region. Percentages are rounded in favor of the
+// customer. It reports false when r has no price in the currency of list,
+// or does not make the product cheaper.
+func (r UpgradeRule) Price(list Money, region Region) (Money, bool) {
+	var (
+		price Money
+		ok    bool
+	)
+
+	if r.PercentOff > 0 {
+		var err error
+
+		price, err = list.Scale(int64(100-r.PercentOff), 100, RoundDown)
+		ok = err == nil
+	} else {
+		price, ok = priceIn(r.Prices, list.Currency, region)
+	}
+
+	if !ok {
+		return Money{}, false
+	}
+
+	cheaper, err := price.Cmp(list)
+	if err != nil || cheaper >= 0 {
+		return Money{}, false
+	}
+
+	return price, true
+}
+
+// Upgrade is the upgrade price of a line of a cart.
+type Upgrade struct {
+	// RuleID is the rule the line is priced by, and FromLicenseID the
+	// license of the customer that makes them eligible to it.
+	RuleID        int
+	FromLicenseID int
+
+	// ListPrice is the unit price of the line without the upgrade.
+	ListPrice Money
+}
+
+// UpgradeSource is a license of a customer that may make them eligible to
+// upgrade rules.
+type UpgradeSource struct {
+	License License
+
+	// ReservedFor is the product a pending order of the customer upgrades
+	// to from the license, or zero. Until that order is paid or canceled,
+	// the license backs upgrades to that product only, so that one license
+	// is not spent on several orders at once.
+	ReservedFor int
+}
+
+// BestUpgrade returns the lowest upgrade price of product, whose list
+// price is list, for customers in region that own sources. Ties go to
+// the rule and then the license with the lowest ID, so that the same cart
+// is always priced the same. It reports false when no rule makes product
+// cheaper.
+func BestUpgrade(rules []UpgradeRule, sources []UpgradeSource, product int, list Money, region Region) (Upgrade, Money, bool) {
+	var (
+		best  Upgrade
+		price Money
+		found bool
+	)
+
+	for _, r := range rules {
+		if r.ToProductID != product {
+			continue
+		}
+
+		p, ok := r.Price(list, region)
+		if !ok {
+			continue
+		}
+
+		for _, s := range sources {
+			if s.ReservedFor != 0 && s.ReservedFor != product || !r.Matches(s.License) {
+				continue
+			}
+
+			if found && !upgradeBefore(p, r.ID, s.License.ID, price, best) {
+				continue
+			}
+
+			best = Upgrade{RuleID: r.ID, FromLicenseID: s.License.ID, ListPrice: list}
+			price, found = p, true
+		}
+	}
+
+	return best, price, found
+}
+
+// upgradeBefore reports whether the upgrade by rule from license at price
+// is preferred to best, at bestPrice.
+func upgradeBefore(price Money, rule, license int, bestPrice Money, best Upgrade) bool {
+	cmp, _ := price.Cmp(bestPrice)
+	if cmp != 0 {
+		return cmp < 0
+	}
+
+	if rule != best.RuleID {
+		return rule < best.RuleID
+	}
+
+	return license < best.FromLicenseID
+}
+
+// ApplyUpgrades returns c with the available plugin lines it can, that are
+// not gifts, priced at their best upgrade price for a customer that owns
+// sources, and its total recomputed. Upgrades do not stack: a line gets
+// one upgrade at most, and a license backs one line at most, so that
+// owning one plugin does not discount two copies of another.
+func ApplyUpgrades(c PricedCart, rules []UpgradeRule, sources []UpgradeSource) (PricedCart, error) {
+	c.Lines = slices.Clone(c.Lines)
+	spent := make(map[int]bool)
+
+	total := Money{Amount: 0, Currency: c.Currency}
+
+	for i := range c.Lines {
+		line := &c.Lines[i]
+		if !line.Available {
+			continue
+		}
+
+		if line.Product.Type == ProductPlugin && !line.Line.Gift && line.Upgrade == nil {
+			unspent := slices.DeleteFunc(slices.Clone(sources), func(s UpgradeSource) bool {
+				return spent[s.License.ID]
+			})
+
+			u, price, ok := BestUpgrade(rules, unspent, line.Product.ID, line.UnitPrice, c.Region)
+			if ok {
+				subtotal, err := price.Mul(int64(line.Line.Quantity))
+				if err != nil {
+					return PricedCart{}, err
+				}
+
+				spent[u.FromLicenseID] = true
+				line.Upgrade = &u
+				line.UnitPrice = price
+				line.Subtotal = subtotal
+			}
+		}
+
+		var err error
+
+		total, err = total.Add(line.Subtotal)
+		if err != nil {
+			return PricedCart{}, err
+		}
+	}
+
+	c.Total = total
+
+	return c, nil
+}
+
+type UpgradeError string
+
+func (e UpgradeError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidUpgradeProducts UpgradeError = "upgrade rule must go from a product to another, or from a version of a product"
+	ErrInvalidUpgradeVersion  UpgradeError = "upgrade version must be at most 32 characters, without spaces"
+	ErrInvalidUpgradeDiscount UpgradeError = "upgrade rule must have either prices or a percentage off of 1 to 100"
+)
@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+func upgradeRule(id, from int, version string, to int, prices []domain.Price, percent int) domain.UpgradeRule {
+	return domain.UpgradeRule{
+		ID:            id,
+		FromProductID: from,
+		FromVersion:   version,
+		ToProductID:   to,
+		Prices:        prices,
+		PercentOff:    percent,
+		Created:       time.Time{},
+	}
+}
+
+func upgradeSource(id, product int, version string, reserved int) domain.UpgradeSource {
+	return domain.UpgradeSource{
+		License: domain.License{
+			ID:           id,
+			Key:          "",
+			OrderID:      id,
+			Line:         0,
+			Seat:         0,
+			CustomerID:   1,
+			ProductID:    product,
+			Version:      version,
+			Edition:      domain.EditionStandard,
+			Issued:       time.Time{},
+			Expires:      time.Time{},
+			Revoked:      time.Time{},
+			RevokeReason: "",
+			UpgradedFrom: 0,
+		},
+		ReservedFor: reserved,
+	}
+}
+
+func TestValidateUpgradeRule(t *testing.T) {
+	price := []domain.Price{{Region: domain.RegionDefault, Money: usd(4900)}}
+
+	for _, tt := range []struct {
+		name string
+		rule domain.UpgradeRule
+		want error
+	}{
+		{"crossgrade", upgradeRule(0, 1, "", 2, nil, 30), nil},
+		{"upgrade", upgradeRule(0, 1, "1.", 1, price, 0), nil},
+		{"free", upgradeRule(0, 1, "", 2, nil, 100), nil},
+		{"same product", upgradeRule(0, 1, "", 1, nil, 30), domain.ErrInvalidUpgradeProducts},
+		{"no source", upgradeRule(0, 0, "", 2, nil, 30), domain.ErrInvalidUpgradeProducts},
+		{"spaced version", upgradeRule(0, 1, "1. 2", 1, nil, 30), domain.ErrInvalidUpgradeVersion},
+		{"no discount", upgradeRule(0, 1, "", 2, nil, 0), domain.ErrInvalidUpgradeDiscount},
+		{"both discounts", upgradeRule(0, 1, "", 2, price, 30), domain.ErrInvalidUpgradeDiscount},
+		{"over 100 percent", upgradeRule(0, 1, "", 2, nil, 101), domain.ErrInvalidUpgradeDiscount},
+		{"negative percent", upgradeRule(0, 1, "", 2, nil, -5), domain.ErrInvalidUpgradeDiscount},
+		{"invalid price", upgradeRule(0, 1, "", 2, []domain.Price{{Region: domain.RegionDefault, Money: usd(0)}}, 0), domain.ErrInvalidPrice},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.ValidateUpgradeRule(tt.rule)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err, tt.want)
+		})
+	}
+}
+
+func TestUpgradeRule_Matches(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		version string
+		license domain.UpgradeSource
+		want    bool
+	}{
+		{"any version", "", upgradeSource(1, 1, "2.3.0", 0), true},
+		{"series", "1.", upgradeSource(1, 1, "1.4.2", 0), true},
+		{"other series", "1.", upgradeSource(1, 1, "10.0.0", 0), false},
+		{"exact", "1.4.2", upgradeSource(1, 1, "1.4.2", 0), true},
+		{"other version", "1.4", upgradeSource(1, 1, "1.4.2", 0), false},
+		{"other product", "", upgradeSource(1, 3, "1.0.0", 0), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := upgradeRule(1, 1, tt.version, 2, nil, 50)
+			assert.Equal(t, r.Matches(tt.license.License), tt.want)
+		})
+	}
+}
+
+func TestUpgradeRule_Price(t *testing.T) {
+	prices := []domain.Price{
+		{Region: domain.RegionDefault, Money: usd(2900)},
+		{Region: "IN", Money: usd(900)},
+	}
+
+	fixed := upgradeRule(1, 1, "1.", 1, prices, 0)
+
+	price, ok := fixed.Price(usd(4900), domain.RegionDefault)
+	assert.True(t, ok)
+	assert.Equal(t, price, usd(2900))
+
+	price, ok = fixed.Price(usd(4900), "IN")
+	assert.True(t, ok)
+	assert.Equal(t, price, usd(900))
+
+	// An upgrade price never exceeds the list price, which a sale may have
+	// brought below it.
+	_, ok = fixed.Price(usd(1900), domain.RegionDefault)
+	assert.False(t, ok)
+
+	_, ok = fixed.Price(domain.Money{Amount: 4900, Currency: domain.CurrencyEUR}, domain.RegionDefault)
+	assert.False(t, ok)
+
+	// Percentages round in favor of the customer.
+	price, ok = upgradeRule(2, 1, "", 2, nil, 30).Price(usd(4999), domain.RegionDefault)
+	assert.True(t, ok)
+	assert.Equal(t, price, usd(3499))
+
+	price, ok = upgradeRule(2, 1, "", 2, nil, 100).Price(usd(4999), domain.RegionDefault)
+	assert.True(t, ok)
+	assert.Equal(t, price, usd(0))
+}
+
+func TestApplyUpgrades(t *testing.T) {
+	const microwave, microwave2, oven = 1, 2, 3
+
+	line := func(product int, typ domain.ProductType, quantity int, gift bool) domain.PricedCartLine {
+		return domain.PricedCartLine{
+			Line: domain.CartLine{ProductID: product, Quantity: quantity, Gift: gift},
+			Product: domain.Product{
+				ID:           product,
+				Type:         typ,
+				Slug:         "",
+				Name:         "",
+				Description:  "",
+				Version:      "",
+				MachineLimit: 0,
+				Media:        nil,
+				Published:    true,
+				Position:     0,
+				Prices:       nil,
+				PriceID:      "",
+				ProductID:    "",
+				Created:      time.Time{},
+				Updated:      time.Time{},
+			},
+			Available: true,
+			UnitPrice: usd(9900),
+			Subtotal:  usd(9900 * int64(quantity)),
+			Upgrade:   nil,
+		}
+	}
+
+	cart := domain.PricedCart{
+		Cart:     domain.Cart{Lines: nil},
+		Currency: domain.CurrencyUSD,
+		Region:   domain.RegionDefault,
+		Lines: []domain.PricedCartLine{
+			line(microwave2, domain.ProductPlugin, 1, false),
+			line(oven, domain.ProductPlugin, 1, false),
+			line(oven, domain.ProductPlugin, 2, true),
+			line(9, domain.ProductMerchandise, 1, false),
+		},
+		Total: usd(9900 * 5),
+	}
+
+	rules := []domain.UpgradeRule{
+		upgradeRule(1, microwave, "1.", microwave2, []domain.Price{{Region: domain.RegionDefault, Money: usd(4900)}}, 0),
+		upgradeRule(2, microwave, "", oven, nil, 20),
+		upgradeRule(3, microwave, "", microwave2, nil, 50),
+	}
+
+	// One license of the first Microwave backs one line only: the upgrade
+	// to Microwave 2, cheaper by rule 1 than by rule 3.
+	priced, err := domain.ApplyUpgrades(cart, rules, []domain.UpgradeSource{upgradeSource(1, microwave, "1.2.0", 0)})
+	assert.NoError(t, err)
+	assert.Equal(t, priced.Lines[0].UnitPrice, usd(4900))
+	assert.Equal(t, *priced.Lines[0].Upgrade, domain.Upgrade{RuleID: 1, FromLicenseID: 1, ListPrice: usd(9900)})
+	assert.True(t, priced.Lines[1].Upgrade == nil)
+	assert.Equal(t, priced.Total, usd(4900+9900*4))
+
+	// The cart priced is left as it was.
+	assert.True(t, cart.Lines[0].Upgrade == nil)
+	assert.Equal(t, cart.Total, usd(9900*5))
+
+	// A second license backs the crossgrade, and gifts are never upgraded.
+	priced, err = domain.ApplyUpgrades(cart, rules, []domain.UpgradeSource{
+		upgradeSource(2, microwave, "2.0.0", 0),
+		upgradeSource(1, microwave, "1.2.0", 0),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, priced.Lines[0].Upgrade.FromLicenseID, 1)
+	assert.Equal(t, priced.Lines[0].Upgrade.RuleID, 1)
+	assert.Equal(t, priced.Lines[1].Upgrade.FromLicenseID, 2)
+	assert.Equal(t, priced.Lines[1].Subtotal, usd(7920))
+	assert.True(t, priced.Lines[2].Upgrade == nil)
+	assert.True(t, priced.Lines[3].Upgrade == nil)
+	assert.Equal(t, priced.Total, usd(4900+7920+9900*3))
+
+	// A license reserved by a pending order for Microwave 2 backs no
+	// crossgrade to the oven.
+	priced, err = domain.ApplyUpgrades(cart, rules[1:2], []domain.UpgradeSource{
+		upgradeSource(1, microwave, "1.2.0", microwave2),
+	})
+	assert.NoError(t, err)
+	assert.True(t, priced.Lines[1].Upgrade == nil)
+	assert.Equal(t, priced.Total, cart.Total)
+}
@@ -4,12 +4,73 @@
 
 package domain
 
+import (
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
 type Shop struct{}
 
+const (
+	// MinPasswordLength is the minimum number of characters in a password.
+	MinPasswordLength = 12
+
+	// MaxPasswordLength is the maximum number of characters in a password.
+	// It bounds the cost of hashing attacker supplied input.
+	MaxPasswordLength = 128
+
+	// maxEmailLength is the maximum length of an address in a SMTP path, per
+	// RFC 5321.
+	maxEmailLength = 254
+)
+
+// NormalizeEmail parses email as a RFC 5322 address and returns its
+// canonical form, under which customers are stored and looked up. The
+// address must be bare, without a display name or angle brackets. The whole
+// address is lowercased, since virtually every mail provider treats the
+// local part as case-insensitive.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+
+	if email == "" || len(email) > maxEmailLength {
+		return "", ErrInvalidEmail
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+
+	_, domain, ok := strings.Cut(addr.Address, "@")
+	if !ok || !strings.Contains(domain, ".") {
+		return "", ErrInvalidEmail
+	}
+
+	return strings.ToLower(addr.Address), nil
+}
+
+// validPasswordLength reports how the length of password compares to the
+// password length bounds.
+func validPasswordLength(password string) error {
+	n := utf8.RuneCountInString(password)
+
+	if n < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+
+	if n > MaxPasswordLength {
+		return ErrPasswordTooLong
+	}
+
+	return nil
+}
+
 type NewCustomerEmail string
 
 func (n NewCustomerEmail) Valid() error {
-	return nil
+	_, err := NormalizeEmail(string(n))
+	return err
 }
 
 func (n NewCustomerEmail) String() string {
@@ -19,17 +80,19 @@ func (n NewCustomerEmail) String() string {
 type NewCustomerPassword string
 
 func (n NewCustomerPassword) Valid() error {
-	return nil
+	return validPasswordLength(string(n))
 }
 
+// String redacts the password, so that it never ends up in logs or errors.
 func (n NewCustomerPassword) String() string {
-	return string(n)
+	return redactedPassword
 }
 
 type RegisteredCustomerEmail string
 
 func (r RegisteredCustomerEmail) Valid() error {
-	return nil
+	_, err := NormalizeEmail(string(r))
+	return err
 }
 
 func (r RegisteredCustomerEmail) String() string {
@@ -38,10 +101,19 @@ func (r RegisteredCustomerEmail) String() string {
 
 type RegisteredCustomerPassword string
 
+// Valid only bounds the length of the password. The password policy may have
+// changed since the customer chose it, so it is not enforced here.
 func (r RegisteredCustomerPassword) Valid() error {
+	if r == "" || utf8.RuneCountInString(string(r)) > MaxPasswordLength {
+		return ErrInvalidCredentials
+	}
+
 	return nil
 }
 
+// String redacts the password, so that it never ends up in logs or errors.
 func (r RegisteredCustomerPassword) String() string {
-	return string(r)
+	return redactedPassword
 }
+
+const redactedPassword = "[REDACTED]"
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		email string
+	}{
+		{
+			CaseBase: test.NewCaseBase("plain address", "jane@example.com", false),
+			email:    "jane@example.com",
+		},
+		{
+			CaseBase: test.NewCaseBase("mixed case and spaces", "jane.doe+shop@example.com", false),
+			email:    "  Jane.Doe+Shop@Example.COM ",
+		},
+		{
+			CaseBase: test.NewCaseBase("empty", domain.ErrInvalidEmail, true),
+			email:    "",
+		},
+		{
+			CaseBase: test.NewCaseBase("missing at", domain.ErrInvalidEmail, true),
+			email:    "jane.example.com",
+		},
+		{
+			CaseBase: test.NewCaseBase("display name", domain.ErrInvalidEmail, true),
+			email:    "Jane <jane@example.com>",
+		},
+		{
+			CaseBase: test.NewCaseBase("domain without dot", domain.ErrInvalidEmail, true),
+			email:    "jane@localhost",
+		},
+		{
+			CaseBase: test.NewCaseBase("too long", domain.ErrInvalidEmail, true),
+			email:    strings.Repeat("a", 250) + "@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			got, err := domain.NormalizeEmail(tt.email)
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+			if tt.WantErr {
+				assert.Error(t, err, tt.Want.(error))
+				return
+			}
+			assert.Equal(t, got, tt.Want.(string))
+		})
+	}
+}
+
+func TestNewCustomerPassword_Valid(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		password domain.NewCustomerPassword
+	}{
+		{
+			CaseBase: test.NewCaseBase("long enough", nil, false),
+			password: "correct horse battery",
+		},
+		{
+			CaseBase: test.NewCaseBase("multibyte characters count once", nil, false),
+			password: "ééééééééééée",
+		},
+		{
+			CaseBase: test.NewCaseBase("too short", domain.ErrPasswordTooShort, true),
+			password: "short",
+		},
+		{
+			CaseBase: test.NewCaseBase("too long", domain.ErrPasswordTooLong, true),
+			password: domain.NewCustomerPassword(strings.Repeat("a", domain.MaxPasswordLength+1)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.password.Valid()
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+			if tt.WantErr {
+				assert.Error(t, err, tt.Want.(error))
+			}
+		})
+	}
+}
+
+func TestNewCustomerPassword_StringIsRedacted(t *testing.T) {
+	p := domain.NewCustomerPassword("correct horse battery")
+	assert.False(t, strings.Contains(p.String(), "horse"))
+}
+
+func TestNewPossibleCustomer(t *testing.T) {
+	pc, err := domain.NewPossibleCustomer("Jane@Example.com", "correct horse battery")
+	assert.NoError(t, err)
+	assert.Equal(t, pc.Email, "jane@example.com")
+
+	_, err = domain.NewPossibleCustomer("jane@example.com", "short")
+	assert.Error(t, err, domain.ErrPasswordTooShort)
+}
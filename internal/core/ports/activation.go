@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// ActivationStore stores the activations of licenses on machines.
+type ActivationStore interface {
+	// CreateActivation stores a and returns it with its ID. Limit is the
+	// machine limit of the license of a, or zero for none. It returns
+	// ErrConflict when the license is active on the machine of a already,
+	// or on limit machines. Concurrent activations of a license are
+	// serialized, so that the limit holds.
+	CreateActivation(ctx context.Context, a domain.Activation, limit int) (domain.Activation, error)
+
+	// Activation returns ErrNotFound when no activation has id.
+	Activation(ctx context.Context, id int) (domain.Activation, error)
+
+	// MachineActivation returns the activation of a license on a machine,
+	// and ErrNotFound when the license is not active on it.
+	MachineActivation(ctx context.Context, licenseID int, machine string) (domain.Activation, error)
+
+	// LicenseActivations returns every activation of a license, active or
+	// not, oldest first.
+	LicenseActivations(ctx context.Context, licenseID int) ([]domain.Activation, error)
+
+	// CustomerActivations returns the active activations of the licenses of
+	// a customer, oldest first.
+	CustomerActivations(ctx context.Context, customerID int) ([]domain.Activation, error)
+
+	// TouchActivation sets when the activation with id was last seen. It
+	// returns ErrNotFound when no active activation has id.
+	TouchActivation(ctx context.Context, id int, at time.Time) error
+
+	// DeactivateActivation deactivates the activation with id at at, for
+	// reason. It returns ErrNotFound when no activation has id, and leaves
+	// activations deactivated already as they are.
+	DeactivateActivation(ctx context.Context, id int, at time.Time, reason string) error
+
+	// ExpireActivations deactivates at at, for reason, the active
+	// activations last seen before seenBefore, and returns how many.
+	ExpireActivations(ctx context.Context, seenBefore, at time.Time, reason string) (int, error)
+
+	// ActivationCounts counts the machines of each license activated since
+	// since, and returns those with at least atLeast machines, the most
+	// machines first, up to limit.
+	ActivationCounts(ctx context.Context, since time.Time, atLeast, limit int) ([]domain.ActivationCount, error)
+}
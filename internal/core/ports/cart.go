@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// CartStore stores the carts of customers and guests.
+type CartStore interface {
+	// SaveCart creates or replaces the cart with the ID of c, lines
+	// included. It returns ErrConflict when another cart belongs to the
+	// customer of c.
+	SaveCart(ctx context.Context, c domain.Cart) error
+
+	// CartByID returns ErrNotFound when no cart has id.
+	CartByID(ctx context.Context, id string) (domain.Cart, error)
+
+	// CartByCustomer returns ErrNotFound when the customer has no cart.
+	CartByCustomer(ctx context.Context, customerID int) (domain.Cart, error)
+
+	// DeleteCart returns ErrNotFound when no cart has id.
+	DeleteCart(ctx context.Context, id string) error
+
+	// DeleteExpiredCarts deletes the carts that have expired by now, and
+	// returns how many were deleted.
+	DeleteExpiredCarts(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// CustomerRepository stores customers. Customers are looked up by their
+// normalized email.
+type CustomerRepository interface {
+	// CreateCustomer stores c and returns it with its ID assigned. It
+	// returns ErrConflict when a customer with the same email exists.
+	CreateCustomer(ctx context.Context, c domain.Customer) (domain.Customer, error)
+
+	// CustomerByEmail returns ErrNotFound when no customer has email.
+	CustomerByEmail(ctx context.Context, email string) (domain.Customer, error)
+
+	// CustomerByID returns ErrNotFound when no customer has id.
+	CustomerByID(ctx context.Context, id int) (domain.Customer, error)
+
+	// MarkCustomerVerified records that a customer verified their email. It
+	// returns ErrNotFound when no customer has id.
+	MarkCustomerVerified(ctx context.Context, id int) error
+
+	// UpdateCustomerPassword replaces the hashed password of a customer. It
+	// returns ErrNotFound when no customer has id.
+	UpdateCustomerPassword(ctx context.Context, id int, hashedPassword []byte) error
+
+	// SetPaymentCustomerID records the ID of a customer at the payment
+	// provider. It returns ErrNotFound when no customer has id.
+	SetPaymentCustomerID(ctx context.Context, id int, paymentCustomerID string) error
+
+	// ReservePaymentCustomerKey records key as the idempotency key of
+	// creating a customer at the payment provider, unless one was recorded
+	// already, and returns the recorded one. It returns ErrNotFound when no
+	// customer has id.
+	ReservePaymentCustomerKey(ctx context.Context, id int, key string) (string, error)
+}
+
+// PasswordHasher hashes passwords for storage.
+type PasswordHasher interface {
+	Hash(password string) ([]byte, error)
+
+	// Verify reports whether password matches hash and, when it does,
+	// whether hash was made with parameters other than the current ones and
+	// should be replaced by a fresh hash.
+	Verify(password string, hash []byte) (bool, bool, error)
+}
+
+// BreachedPasswords tells passwords known from data breaches.
+type BreachedPasswords interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+type RepositoryError string
+
+func (e RepositoryError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrNotFound RepositoryError = "not found"
+	ErrConflict RepositoryError = "already exists"
+)
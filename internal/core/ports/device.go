@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// DeviceStore stores device authorizations and the devices they sign in.
+// Like sessions, they are looked up by the digests of their codes and
+// tokens, never by the codes and tokens themselves.
+type DeviceStore interface {
+	// CreateDeviceAuthorization returns ErrConflict when the user code or
+	// the device code is taken.
+	CreateDeviceAuthorization(ctx context.Context, a domain.DeviceAuthorization) error
+
+	// DeviceAuthorizationByUserCode returns ErrNotFound when no
+	// authorization has userCode.
+	DeviceAuthorizationByUserCode(ctx context.Context, userCode string) (domain.DeviceAuthorization, error)
+
+	// DeviceAuthorizationByDeviceCode returns ErrNotFound when no
+	// authorization has deviceCodeHash.
+	DeviceAuthorizationByDeviceCode(ctx context.Context, deviceCodeHash []byte) (domain.DeviceAuthorization, error)
+
+	// PollDeviceAuthorization records a poll and the polling interval that
+	// now applies. It returns ErrNotFound when no authorization has
+	// deviceCodeHash.
+	PollDeviceAuthorization(ctx context.Context, deviceCodeHash []byte, at time.Time, interval time.Duration) error
+
+	// DecideDeviceAuthorization records the decision of a customer on a
+	// pending authorization. It returns ErrNotFound when no pending
+	// authorization has userCode.
+	DecideDeviceAuthorization(
+		ctx context.Context,
+		userCode string,
+		customerID int,
+		status domain.DeviceAuthorizationStatus,
+	) error
+
+	// DeleteDeviceAuthorization returns ErrNotFound when no authorization
+	// has deviceCodeHash, so that of two concurrent deletions only one
+	// succeeds.
+	DeleteDeviceAuthorization(ctx context.Context, deviceCodeHash []byte) error
+
+	// DeleteExpiredDeviceAuthorizations deletes the authorizations that have
+	// expired by now, and returns how many were deleted.
+	DeleteExpiredDeviceAuthorizations(ctx context.Context, now time.Time) (int, error)
+
+	// CreateDevice returns ErrConflict when the ID or a token is taken.
+	CreateDevice(ctx context.Context, d domain.Device) error
+
+	// DeviceByRefreshToken returns ErrNotFound when no device has
+	// refreshTokenHash.
+	DeviceByRefreshToken(ctx context.Context, refreshTokenHash []byte) (domain.Device, error)
+
+	// DeviceByAccessToken returns ErrNotFound when no device has
+	// accessTokenHash.
+	DeviceByAccessToken(ctx context.Context, accessTokenHash []byte) (domain.Device, error)
+
+	// RotateDeviceTokens replaces the tokens and expiries of the device with
+	// the ID of d by those of d, provided its refresh token still has
+	// oldRefreshTokenHash. It returns ErrNotFound otherwise, so that a
+	// refresh token can only be used once.
+	RotateDeviceTokens(ctx context.Context, oldRefreshTokenHash []byte, d domain.Device) error
+
+	// CustomerDevices returns the devices of a customer that have not
+	// expired by now, most recently used first.
+	CustomerDevices(ctx context.Context, customerID int, now time.Time) ([]domain.Device, error)
+
+	// DeleteDevice returns ErrNotFound when no device has id.
+	DeleteDevice(ctx context.Context, id string) error
+
+	// DeleteCustomerDevices deletes every device of a customer, and the
+	// authorizations they approved that no device has redeemed yet, and
+	// returns how many devices were deleted.
+	DeleteCustomerDevices(ctx context.Context, customerID int) (int, error)
+
+	// DeleteExpiredDevices deletes the devices that have expired by now, and
+	// returns how many were deleted.
+	DeleteExpiredDevices(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// LicenseStore stores the licenses of plugins.
+type LicenseStore interface {
+	// CreateLicense stores l and returns it with its ID. It returns
+	// ErrConflict when a license has the key of l, or was issued for the
+	// same order, line and seat.
+	CreateLicense(ctx context.Context, l domain.License) (domain.License, error)
+
+	// CreateTrial stores the trial license l, started on machine, and
+	// returns it with its ID. It returns ErrConflict when a license has the
+	// key of l, or when the customer of l or machine started a trial of
+	// the product of l before, converted or not.
+	CreateTrial(ctx context.Context, l domain.License, machine string) (domain.License, error)
+
+	// AbandonTrial revokes the trial license with id at at, for reason, and
+	// forgets that its customer and machine started it, so that they may
+	// start a trial of its product again. It returns ErrNotFound when no
+	// trial license that was not converted has id.
+	AbandonTrial(ctx context.Context, id int, at time.Time, reason string) error
+
+	// ConvertTrial stores the order, line, seat, version, edition, issue,
+	// expiry and lineage of l, the trial license with l.ID bought with that
+	// order. It returns ErrNotFound when no trial license has l.ID, and
+	// ErrConflict when the order has a license for the line and seat
+	// already.
+	ConvertTrial(ctx context.Context, l domain.License) error
+
+	// License returns ErrNotFound when no license has id.
+	License(ctx context.Context, id int) (domain.License, error)
+
+	// LicenseByKey returns ErrNotFound when no license has the key.
+	LicenseByKey(ctx context.Context, key string) (domain.License, error)
+
+	// OrderLicenses returns the licenses issued for an order, oldest first.
+	OrderLicenses(ctx context.Context, orderID int) ([]domain.License, error)
+
+	// CustomerLicenses returns the licenses of a customer, oldest first.
+	CustomerLicenses(ctx context.Context, customerID int) ([]domain.License, error)
+
+	// RevokeLicense marks the license with id revoked at at, for reason. It
+	// returns ErrNotFound when no license has id, and leaves licenses
+	// revoked already as they are.
+	RevokeLicense(ctx context.Context, id int, at time.Time, reason string) error
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// LoginFailures counts consecutive failed logins by key, such as an account
+// or an IP address.
+type LoginFailures interface {
+	// LoginFailures returns the number of failures recorded for key and the
+	// time of the last one. A key without failures returns zero and the zero
+	// time.
+	LoginFailures(ctx context.Context, key string) (int, time.Time, error)
+
+	// RecordLoginFailure records a failure for key at the given time and
+	// returns the new number of failures.
+	RecordLoginFailure(ctx context.Context, key string, at time.Time) (int, error)
+
+	// ForgiveLoginFailure takes back a failure recorded for key at the given
+	// time, for an attempt that turned out not to fail. The time of the last
+	// failure goes back to previous unless another failure was recorded
+	// since.
+	ForgiveLoginFailure(ctx context.Context, key string, at, previous time.Time) error
+
+	// ResetLoginFailures forgets the failures of key.
+	ResetLoginFailures(ctx context.Context, key string) error
+
+	// ForgetLoginFailures forgets the failures of every key whose last
+	// failure happened before the given time, and returns how many keys were
+	// forgotten.
+	ForgetLoginFailures(ctx context.Context, before time.Time) (int, error)
+}
+
+// AuditLog is the trail of security relevant events.
+type AuditLog interface {
+	RecordAudit(ctx context.Context, e domain.AuditEvent) error
+}
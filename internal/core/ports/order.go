@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// OrderStore stores orders and the history of their transitions.
+type OrderStore interface {
+	// CreateOrder stores o, lines included, with t as the first entry of
+	// its history, and returns it with its ID. It returns ErrConflict when
+	// an order has the checkout key of o.
+	CreateOrder(ctx context.Context, o domain.Order, t domain.OrderTransition) (domain.Order, error)
+
+	// Order returns ErrNotFound when no order has id.
+	Order(ctx context.Context, id int) (domain.Order, error)
+
+	// OrderByCheckoutKey returns ErrNotFound when no order has the
+	// checkout key.
+	OrderByCheckoutKey(ctx context.Context, key string) (domain.Order, error)
+
+	// OrderByCheckout returns ErrNotFound when no order has the checkout
+	// ID.
+	OrderByCheckout(ctx context.Context, checkoutID string) (domain.Order, error)
+
+	// SetOrderCheckout records the checkout session opened for the order
+	// with id. It returns ErrNotFound when no order has id.
+	SetOrderCheckout(ctx context.Context, id int, checkoutID string) error
+
+	// OrderByPayment returns ErrNotFound when no order has the payment ID.
+	OrderByPayment(ctx context.Context, paymentID string) (domain.Order, error)
+
+	// CustomerOrders returns the orders of a customer, most recent first.
+	CustomerOrders(ctx context.Context, customerID int) ([]domain.Order, error)
+
+	// Orders returns up to limit orders with status, most recent first.
+	Orders(ctx context.Context, status domain.OrderStatus, limit int) ([]domain.Order, error)
+
+	// TransitionOrder saves the status, payment ID and refunded amount of
+	// o, which t moved from t.From, and appends t to its history. It
+	// returns ErrNotFound when no order has the ID of o, and ErrConflict
+	// when the order is no longer in t.From, having been moved meanwhile.
+	TransitionOrder(ctx context.Context, o domain.Order, t domain.OrderTransition) error
+
+	// OrderTransitions returns the history of the order with id, oldest
+	// first.
+	OrderTransitions(ctx context.Context, id int) ([]domain.OrderTransition, error)
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// PasswordResetStore stores the pending password resets of customers.
+type PasswordResetStore interface {
+	// SavePasswordReset stores r, replacing any pending reset of the same
+	// customer.
+	SavePasswordReset(ctx context.Context, r domain.PasswordReset) error
+
+	// PasswordReset returns the pending reset of a customer, or ErrNotFound.
+	PasswordReset(ctx context.Context, customerID int) (domain.PasswordReset, error)
+
+	// ConsumePasswordReset deletes the pending reset with the given token
+	// hash and returns it. It returns ErrNotFound when there is no such
+	// reset, such as when it was already consumed.
+	ConsumePasswordReset(ctx context.Context, tokenHash []byte) (domain.PasswordReset, error)
+}
+
+// RequestCounts counts requests by key, such as an IP address or an email,
+// to limit how often they are made.
+type RequestCounts interface {
+	// CountRequest records a request for key at the given time and returns
+	// how many were recorded, this one included, since a gap of window
+	// without any. Requests recorded at the same time each get their own
+	// count.
+	CountRequest(ctx context.Context, key string, at time.Time, window time.Duration) (int, error)
+
+	// ForgetRequests forgets the requests of every key whose last request was
+	// made before the given time, and returns how many keys were forgotten.
+	ForgetRequests(ctx context.Context, before time.Time) (int, error)
+}
@@ -3,3 +3,89 @@
 // SPDX-License-Identifier: Apache-2.0
 
 package ports
+
+import (
+	"context"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// PaymentProvider takes payments through pages hosted by a payment
+// provider, such as Stripe Checkout. Failures answered by the provider wrap
+// a PaymentError.
+type PaymentProvider interface {
+	// CreateCheckoutSession opens a payment page for req.
+	CreateCheckoutSession(ctx context.Context, req domain.CheckoutRequest) (domain.CheckoutSession, error)
+
+	// CheckoutSession returns ErrNotFound when no session has id.
+	CheckoutSession(ctx context.Context, id string) (domain.CheckoutSession, error)
+
+	// ExpireCheckoutSession expires the open session with id, so that it
+	// can no longer be paid, and returns it. Sessions that are over are
+	// returned as they are.
+	ExpireCheckoutSession(ctx context.Context, id string) (domain.CheckoutSession, error)
+
+	// Refund refunds a payment, in whole or in part.
+	Refund(ctx context.Context, req domain.RefundRequest) (domain.Refund, error)
+
+	// SyncCustomer creates c at the provider when c has no
+	// PaymentCustomerID, or else updates it, and returns its ID there.
+	// Creations sharing idempotencyKey create a single customer.
+	SyncCustomer(ctx context.Context, c domain.Customer, idempotencyKey string) (string, error)
+}
+
+type PaymentError string
+
+func (e PaymentError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrPaymentDeclined       PaymentError = "payment was declined"
+	ErrPaymentInvalid        PaymentError = "payment provider rejected the request"
+	ErrPaymentAuthentication PaymentError = "payment provider rejected the credentials"
+	ErrPaymentConflict       PaymentError = "payment provider request conflicts with an earlier one"
+	ErrPaymentRateLimited    PaymentError = "payment provider is rate limiting requests"
+	ErrPaymentUnavailable    PaymentError = "payment provider is unavailable"
+	ErrWebhookSignature      PaymentError = "webhook signature is invalid"
+)
+
+// PaymentWebhook reads the webhook deliveries of a payment provider.
+type PaymentWebhook interface {
+	// VerifyEvent checks that payload was signed by the provider, as told
+	// by its signature header, and returns the event it carries. It returns
+	// ErrWebhookSignature when it was not.
+	VerifyEvent(payload []byte, signature string) (domain.PaymentEvent, error)
+
+	// ParseEvent returns the event carried by a payload already verified.
+	ParseEvent(payload []byte) (domain.PaymentEvent, error)
+}
+
+// WebhookInbox stores the webhook events received from the payment
+// provider until they are processed.
+type WebhookInbox interface {
+	// SaveWebhookEvent stores a received event. It returns ErrConflict
+	// when an event with the same ID was received before.
+	SaveWebhookEvent(ctx context.Context, e domain.WebhookEvent) error
+
+	// ClaimWebhookEvents returns up to limit pending events due at now,
+	// oldest first, and pushes back their next attempt to until, so that no
+	// other dispatcher claims them in the meantime.
+	ClaimWebhookEvents(ctx context.Context, now, until time.Time, limit int) ([]domain.WebhookEvent, error)
+
+	// UpdateWebhookEvent replaces a stored event. It returns ErrNotFound
+	// when no event has its ID.
+	UpdateWebhookEvent(ctx context.Context, e domain.WebhookEvent) error
+
+	// WebhookEvent returns ErrNotFound when no event has id.
+	WebhookEvent(ctx context.Context, id string) (domain.WebhookEvent, error)
+
+	// WebhookEvents returns up to limit events with status, most recently
+	// received first.
+	WebhookEvents(ctx context.Context, status domain.WebhookStatus, limit int) ([]domain.WebhookEvent, error)
+
+	// DeleteProcessedWebhookEvents deletes the events processed before
+	// before, and returns how many were deleted.
+	DeleteProcessedWebhookEvents(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// ProductRepository stores the product catalog.
+type ProductRepository interface {
+	// CreateProduct stores p and returns it with its ID assigned. It returns
+	// ErrConflict when a product with the same slug exists.
+	CreateProduct(ctx context.Context, p domain.Product) (domain.Product, error)
+
+	// UpdateProduct replaces the product with the ID of p. It returns
+	// ErrNotFound when no product has the ID, and ErrConflict when another
+	// product has the slug of p.
+	UpdateProduct(ctx context.Context, p domain.Product) error
+
+	// DeleteProduct returns ErrNotFound when no product has id.
+	DeleteProduct(ctx context.Context, id int) error
+
+	// ProductByID returns ErrNotFound when no product has id.
+	ProductByID(ctx context.Context, id int) (domain.Product, error)
+
+	// ProductBySlug returns ErrNotFound when no product has slug.
+	ProductBySlug(ctx context.Context, slug string) (domain.Product, error)
+
+	// Products returns the products matching filter, by position then ID.
+	Products(ctx context.Context, filter ProductFilter) ([]domain.Product, error)
+}
+
+// ProductFilter selects products of the catalog.
+type ProductFilter struct {
+	// Types are the types of the products, or empty for every type.
+	Types []domain.ProductType
+
+	// PublishedOnly leaves out the unpublished products.
+	PublishedOnly bool
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// RoleStore stores custom roles and the roles granted to customers. The
+// built-in roles are not stored, only their grants.
+type RoleStore interface {
+	// SaveRole creates r, or replaces the permissions of the role with its
+	// name.
+	SaveRole(ctx context.Context, r domain.RoleDefinition) error
+
+	// Role returns ErrNotFound when no custom role has name.
+	Role(ctx context.Context, name domain.Role) (domain.RoleDefinition, error)
+
+	// Roles returns the custom roles, by name.
+	Roles(ctx context.Context) ([]domain.RoleDefinition, error)
+
+	// DeleteRole deletes a custom role along with its grants. It returns
+	// ErrNotFound when no custom role has name.
+	DeleteRole(ctx context.Context, name domain.Role) error
+
+	// GrantRole grants a role to a customer. It returns ErrConflict when the
+	// customer already has the role.
+	GrantRole(ctx context.Context, customerID int, role domain.Role, at time.Time) error
+
+	// RevokeRole returns ErrNotFound when the customer does not have the
+	// role.
+	RevokeRole(ctx context.Context, customerID int, role domain.Role) error
+
+	// CustomerRoles returns the roles granted to a customer, by name.
+	CustomerRoles(ctx context.Context, customerID int) ([]domain.Role, error)
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// SessionStore stores sessions. Sessions are looked up by the digest of
+// their token, never by the token itself.
+type SessionStore interface {
+	CreateSession(ctx context.Context, s domain.Session) error
+
+	// SessionByTokenHash returns ErrNotFound when no session has tokenHash.
+	SessionByTokenHash(ctx context.Context, tokenHash []byte) (domain.Session, error)
+
+	// TouchSession records a use of a session and moves its sliding expiry.
+	// It returns ErrNotFound when no session has id.
+	TouchSession(ctx context.Context, id string, lastSeen, expires time.Time) error
+
+	// StepUpSession records that the customer proved their second factor in
+	// a session. It returns ErrNotFound when no session has id.
+	StepUpSession(ctx context.Context, id string, at time.Time) error
+
+	// CustomerSessions returns the sessions of a customer that have not
+	// expired by now, most recently used first.
+	CustomerSessions(ctx context.Context, customerID int, now time.Time) ([]domain.Session, error)
+
+	// DeleteSession returns ErrNotFound when no session has id.
+	DeleteSession(ctx context.Context, id string) error
+
+	// DeleteCustomerSessions deletes every session of a customer but the
+	// ones listed in except, and returns how many were deleted.
+	DeleteCustomerSessions(ctx context.Context, customerID int, except ...string) (int, error)
+
+	// DeleteExpiredSessions deletes the sessions that have expired by now,
+	// and returns how many were deleted.
+	DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// TwoFactorStore stores the second factors of customers: their TOTP and
+// their recovery codes.
+type TwoFactorStore interface {
+	// SaveTOTP stores t, replacing any TOTP of the same customer.
+	SaveTOTP(ctx context.Context, t domain.TOTP) error
+
+	// TOTP returns the TOTP of a customer, or ErrNotFound.
+	TOTP(ctx context.Context, customerID int) (domain.TOTP, error)
+
+	// AdvanceTOTPCounter records that the code for counter was accepted. It
+	// returns ErrConflict when a code for counter or a later one was already
+	// accepted, and ErrNotFound when the customer has no TOTP.
+	AdvanceTOTPCounter(ctx context.Context, customerID int, counter int64) error
+
+	// DeleteTOTP deletes the TOTP and the recovery codes of a customer.
+	DeleteTOTP(ctx context.Context, customerID int) error
+
+	// ReplaceRecoveryCodes replaces the recovery codes of a customer with
+	// the given hashes.
+	ReplaceRecoveryCodes(ctx context.Context, customerID int, hashes [][]byte) error
+
+	// ConsumeRecoveryCode deletes the recovery code of a customer with the
+	// given hash, and returns how many codes the customer has left. It
+	// returns ErrNotFound when the customer has no such code.
+	ConsumeRecoveryCode(ctx context.Context, customerID int, hash []byte) (int, error)
+}
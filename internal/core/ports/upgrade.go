@@ -0,0 +1,23 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// UpgradeRuleStore stores the upgrade rules of the catalog.
+type UpgradeRuleStore interface {
+	// CreateUpgradeRule stores r and returns it with its ID.
+	CreateUpgradeRule(ctx context.Context, r domain.UpgradeRule) (domain.UpgradeRule, error)
+
+	// UpgradeRules returns every upgrade rule, by ID.
+	UpgradeRules(ctx context.Context) ([]domain.UpgradeRule, error)
+
+	// DeleteUpgradeRule returns ErrNotFound when no rule has id.
+	DeleteUpgradeRule(ctx context.Context, id int) error
+}
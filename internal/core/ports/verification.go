@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ports
+
+import (
+	"context"
+
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// VerificationStore stores the pending email verifications of customers.
+type VerificationStore interface {
+	// SaveVerification stores v, replacing any pending verification of the
+	// same customer.
+	SaveVerification(ctx context.Context, v domain.Verification) error
+
+	// Verification returns the pending verification of a customer, or
+	// ErrNotFound.
+	Verification(ctx context.Context, customerID int) (domain.Verification, error)
+
+	// ConsumeVerification deletes the pending verification of a customer if
+	// its nonce hash matches, and returns it. It returns ErrNotFound when
+	// there is no such verification, such as when it was already consumed.
+	ConsumeVerification(ctx context.Context, customerID int, nonceHash []byte) (domain.Verification, error)
+}
+
+// Mailer delivers emails.
+type Mailer interface {
+	Send(ctx context.Context, m domain.Mail) error
+}
@@ -0,0 +1,268 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// anomalyListLimit bounds how many anomalies the admin routes list.
+const anomalyListLimit = 100
+
+// ActivationService activates licenses on machines.
+type ActivationService interface {
+	Activate(ctx context.Context, key, machine, name string) (service.SignedActivation, error)
+	Activations(ctx context.Context, customerID int) ([]domain.Activation, error)
+	Deactivate(ctx context.Context, customerID, id int) error
+	LicenseActivations(ctx context.Context, key string) ([]domain.Activation, error)
+	Anomalies(ctx context.Context, limit int) ([]domain.ActivationCount, error)
+}
+
+// NewActivationRoutes returns the activation routes of plugins and
+// customers.
+//
+//   - POST /licenses/activate: activates a license on the machine of a
+//     plugin, or refreshes its activation there, and returns a token bound
+//     to the machine. Plugins refresh it before it expires.
+//   - GET /activations: lists the machines the licenses of the customer of
+//     the session are active on.
+//   - DELETE /activations/{id}: deactivates a machine of the customer,
+//     freeing it for another.
+//
+// The routes of customers answer those who have not verified their email
+// with 403.
+func NewActivationRoutes(
+	logger Logger,
+	sessions SessionService,
+	verifications VerificationService,
+	activations ActivationService,
+) []HTTPRoute {
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequireSession(logger, sessions, RequireVerified(logger, verifications, h))
+	}
+
+	return []HTTPRoute{
+		NewRoute("POST /licenses/activate", activateHandler(logger, activations)),
+		NewRoute("GET /activations", auth(customerActivationsHandler(logger, activations))),
+		NewRoute("DELETE /activations/{id}", auth(deactivateHandler(logger, activations))),
+	}
+}
+
+// NewActivationAdminRoutes returns the admin routes of activations. They
+// require the customers:view permission.
+//
+//   - GET /licenses/{key}/activations: lists every activation of a
+//     license, active or not.
+//   - GET /activations/anomalies: lists the licenses activated on the most
+//     machines lately, beyond what one customer needs.
+func NewActivationAdminRoutes(logger Logger, authz Authorizer, activations ActivationService) []HTTPRoute {
+	require := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequirePermission(logger, authz, domain.PermissionViewCustomers, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /licenses/{key}/activations", require(licenseActivationsHandler(logger, activations))),
+		NewRoute("GET /activations/anomalies", require(anomaliesHandler(logger, activations))),
+	}
+}
+
+type activationResponse struct {
+	ID          int       `json:"id"`
+	LicenseID   int       `json:"license_id"`
+	ProductID   int       `json:"product_id"`
+	MachineName string    `json:"machine_name,omitempty"`
+	Activated   time.Time `json:"activated"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// adminActivationResponse is an activation as staff see it, with its
+// machine and whether and why it ended.
+type adminActivationResponse struct {
+	activationResponse
+
+	CustomerID       int        `json:"customer_id"`
+	Machine          string     `json:"machine"`
+	Deactivated      *time.Time `json:"deactivated,omitempty"`
+	DeactivateReason string     `json:"deactivate_reason,omitempty"`
+}
+
+type signedActivationResponse struct {
+	activationResponse
+
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+type activationCountResponse struct {
+	LicenseID  int `json:"license_id"`
+	CustomerID int `json:"customer_id"`
+	ProductID  int `json:"product_id"`
+	Active     int `json:"active"`
+	Machines   int `json:"machines"`
+}
+
+func newActivationResponse(a domain.Activation) activationResponse {
+	return activationResponse{
+		ID:          a.ID,
+		LicenseID:   a.LicenseID,
+		ProductID:   a.ProductID,
+		MachineName: a.MachineName,
+		Activated:   a.Activated,
+		LastSeen:    a.LastSeen,
+	}
+}
+
+func newAdminActivationResponse(a domain.Activation) adminActivationResponse {
+	res := adminActivationResponse{
+		activationResponse: newActivationResponse(a),
+		CustomerID:         a.CustomerID,
+		Machine:            a.Machine,
+		Deactivated:        nil,
+		DeactivateReason:   a.DeactivateReason,
+	}
+
+	if !a.Active() {
+		res.Deactivated = &a.Deactivated
+	}
+
+	return res
+}
+
+type activateRequest struct {
+	Key     string `json:"key"`
+	Machine string `json:"machine"`
+	Name    string `json:"name"`
+}
+
+func activateHandler(logger Logger, activations ActivationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req activateRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		a, err := activations.Activate(r.Context(), req.Key, req.Machine, req.Name)
+		if err != nil {
+			writeActivationError(logger, w, err, "failed to activate license")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, signedActivationResponse{
+			activationResponse: newActivationResponse(a.Activation),
+			Token:              a.Token,
+			Expires:            a.Expires,
+		})
+	}
+}
+
+func customerActivationsHandler(logger Logger, activations ActivationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		list, err := activations.Activations(r.Context(), sess.CustomerID)
+		if err != nil {
+			writeActivationError(logger, w, err, "failed to list activations")
+			return
+		}
+
+		res := make([]activationResponse, 0, len(list))
+		for _, a := range list {
+			res = append(res, newActivationResponse(a))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func deactivateHandler(logger Logger, activations ActivationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, service.ErrActivationNotFound)
+			return
+		}
+
+		err = activations.Deactivate(r.Context(), sess.CustomerID, id)
+		if err != nil {
+			writeActivationError(logger, w, err, "failed to deactivate machine")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func licenseActivationsHandler(logger Logger, activations ActivationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := activations.LicenseActivations(r.Context(), r.PathValue("key"))
+		if err != nil {
+			writeActivationError(logger, w, err, "failed to list activations")
+			return
+		}
+
+		res := make([]adminActivationResponse, 0, len(list))
+		for _, a := range list {
+			res = append(res, newAdminActivationResponse(a))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func anomaliesHandler(logger Logger, activations ActivationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		counts, err := activations.Anomalies(r.Context(), anomalyListLimit)
+		if err != nil {
+			writeActivationError(logger, w, err, "failed to list anomalies")
+			return
+		}
+
+		res := make([]activationCountResponse, 0, len(counts))
+		for _, c := range counts {
+			res = append(res, activationCountResponse{
+				LicenseID:  c.LicenseID,
+				CustomerID: c.CustomerID,
+				ProductID:  c.ProductID,
+				Active:     c.Active,
+				Machines:   c.Machines,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func writeActivationError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	var (
+		keyErr     domain.LicenseError
+		machineErr domain.ActivationError
+	)
+
+	switch {
+	case errors.Is(err, service.ErrLicenseNotFound), errors.Is(err, service.ErrActivationNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, service.ErrLicenseInactive):
+		writeError(w, http.StatusForbidden, err)
+	case errors.Is(err, service.ErrMachineLimit):
+		writeError(w, http.StatusConflict, err)
+	case errors.As(err, &keyErr), errors.As(err, &machineErr):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		logger.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
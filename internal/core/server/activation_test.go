@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+type activationJSON struct {
+	ID          int    `json:"id"`
+	MachineName string `json:"machine_name"`
+	Token       string `json:"token"`
+	Machine     string `json:"machine"`
+	Deactivated string `json:"deactivated"`
+}
+
+// newActivationTest returns an activation service whose products allow
+// one machine, with the licenses of newLicenseTest, and their keys.
+func newActivationTest(t *testing.T) (*service.ActivationService, []string) {
+	t.Helper()
+
+	store, keys := newLicenseStore(t)
+	licenses, ring := newLicenseService(t, store)
+	products := dal.NewMemoryProducts()
+
+	_, err := products.CreateProduct(t.Context(), domain.Product{
+		ID:           0,
+		Type:         domain.ProductPlugin,
+		Slug:         "microwave",
+		Name:         "Microwave",
+		Description:  "",
+		Version:      "1.2.0",
+		MachineLimit: 1,
+		Media:        nil,
+		Published:    true,
+		Position:     0,
+		Prices:       nil,
+		PriceID:      "",
+		ProductID:    "",
+		Created:      time.Time{},
+		Updated:      time.Time{},
+	})
+	assert.NoError(t, err)
+
+	activations, err := service.NewActivationService(dal.NewMemoryActivations(), licenses, products,
+		ring, testLicenseConfig, service.ActivationConfig{
+			TokenTTL:        24 * time.Hour,
+			Inactivity:      0,
+			AnomalyMachines: 1,
+			AnomalyWindow:   time.Hour,
+		})
+	assert.NoError(t, err)
+
+	return activations, keys
+}
+
+func TestActivationRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	activations, keys := newActivationTest(t)
+	verifications := &fakeVerifications{sendErr: nil, verified: map[int]bool{1: true, 2: true}}
+	mux := newMux(server.NewActivationRoutes(nopLogger{}, sessions, verifications, activations)...)
+
+	activate := func(key, machine string) string {
+		return `{"key":"` + key + `","machine":"` + machine + `","name":"Studio Mac"}`
+	}
+
+	var activation activationJSON
+
+	rec := send(t, mux, http.MethodPost, "/licenses/activate", activate(keys[0], "studio-fingerprint-1"), "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &activation))
+	assert.NotEqual(t, activation.Token, "")
+	assert.Equal(t, activation.MachineName, "Studio Mac")
+
+	// Customers are not shown machine fingerprints.
+	assert.Equal(t, activation.Machine, "")
+
+	for _, tt := range []struct {
+		name, body string
+		want       int
+	}{
+		{"refresh", activate(keys[0], "studio-fingerprint-1"), http.StatusOK},
+		{"machine limit", activate(keys[0], "laptop-fingerprint-2"), http.StatusConflict},
+		{"malformed body", `{`, http.StatusBadRequest},
+		{"invalid machine", activate(keys[0], "short"), http.StatusUnprocessableEntity},
+		{"mistyped key", activate(keys[0][1:], "studio-fingerprint-1"), http.StatusUnprocessableEntity},
+		{"unknown key", activate(domain.NewLicenseKey(), "studio-fingerprint-1"), http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send(t, mux, http.MethodPost, "/licenses/activate", tt.body, "")
+			assert.Equal(t, rec.Code, tt.want)
+		})
+	}
+
+	rec = request(t, mux, http.MethodGet, "/activations", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	other, _, err := sessions.Create(t.Context(), 2, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	unverified, _, err := sessions.Create(t.Context(), 3, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	rec = request(t, mux, http.MethodGet, "/activations", unverified)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	var list []activationJSON
+
+	rec = request(t, mux, http.MethodGet, "/activations", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].ID, activation.ID)
+
+	target := "/activations/" + strconv.Itoa(activation.ID)
+
+	rec = request(t, mux, http.MethodDelete, target, other)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	rec = request(t, mux, http.MethodDelete, target, token)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+
+	rec = request(t, mux, http.MethodDelete, "/activations/x", token)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	// The machine freed, another takes its place.
+	rec = send(t, mux, http.MethodPost, "/licenses/activate", activate(keys[0], "laptop-fingerprint-2"), "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
+
+func TestActivationAdminRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), dal.NewMemoryAuditLog())
+	activations, keys := newActivationTest(t)
+	ctx := t.Context()
+
+	mux := newMux(server.NewAdminGroup(nopLogger{}, sessions, authz,
+		server.NewActivationAdminRoutes(nopLogger{}, authz, activations)...)...)
+
+	token, _, err := sessions.Create(ctx, 5, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, authz.Grant(ctx, 5, domain.RoleSupport, ""))
+
+	first, err := activations.Activate(ctx, keys[0], "studio-fingerprint-1", "")
+	assert.NoError(t, err)
+	assert.NoError(t, activations.Deactivate(ctx, 1, first.ID))
+
+	_, err = activations.Activate(ctx, keys[0], "laptop-fingerprint-2", "")
+	assert.NoError(t, err)
+
+	var list []activationJSON
+
+	rec := send(t, mux, http.MethodGet, "/admin/licenses/"+keys[0]+"/activations", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[0].Machine, "studio-fingerprint-1")
+	assert.NotEqual(t, list[0].Deactivated, "")
+	assert.Equal(t, list[1].Deactivated, "")
+
+	rec = send(t, mux, http.MethodGet, "/admin/licenses/"+domain.NewLicenseKey()+"/activations", "", token)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	var anomalies []struct {
+		LicenseID int `json:"license_id"`
+		Active    int `json:"active"`
+		Machines  int `json:"machines"`
+	}
+
+	rec = send(t, mux, http.MethodGet, "/admin/activations/anomalies", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &anomalies))
+	assert.Equal(t, len(anomalies), 1)
+	assert.Equal(t, anomalies[0].Active, 1)
+	assert.Equal(t, anomalies[0].Machines, 2)
+}
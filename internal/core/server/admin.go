@@ -0,0 +1,399 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"crypto/tls"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/common/telemetry"
+)
+
+// AdminConfig configures the admin server. The admin server exposes the
+// internals of the process, so it is bound to a loopback address unless it
+// is served over mutual TLS.
+type AdminConfig struct {
+	Config
+
+	// TLS, when set, serves the admin server over TLS. It must require and
+	// verify client certificates.
+	TLS *tls.Config `json:"-"`
+
+	// MaxCapture bounds the duration of profile and trace captures.
+	MaxCapture time.Duration
+}
+
+func (c AdminConfig) Validate() error {
+	err := c.Config.Validate()
+	if err != nil {
+		return err
+	}
+
+	if c.MaxCapture <= 0 {
+		return ErrAdminMaxCapture
+	}
+
+	if c.TLS == nil {
+		if !isLoopback(c.Addr.String()) {
+			return ErrAdminNotLoopback
+		}
+
+		return nil
+	}
+
+	if c.TLS.ClientAuth != tls.RequireAndVerifyClientCert {
+		return ErrAdminClientAuth
+	}
+
+	return nil
+}
+
+func (c AdminConfig) Value() any {
+	return c
+}
+
+// isLoopback reports whether addr only accepts connections from the local
+// host.
+func isLoopback(addr string) bool {
+	if addr == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(addr)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// FeatureFlags reports the feature flags in effect.
+type FeatureFlags interface {
+	Flags() map[string]bool
+}
+
+// StaticFlags is a fixed set of feature flags.
+type StaticFlags map[string]bool
+
+func (f StaticFlags) Flags() map[string]bool {
+	return f
+}
+
+// AdminInfo is what the admin server reports about the application.
+type AdminInfo struct {
+	// Config is the effective configuration of the application. It is served
+	// as JSON, with the fields tagged as secret redacted, as Redact does.
+	Config any
+
+	Flags FeatureFlags
+}
+
+// NewAdminServer creates the admin server. Besides the health check, it
+// serves the routes below, followed by any extra routes.
+//
+//   - /debug/pprof/: the net/http/pprof profiles, including the execution
+//     trace at /debug/pprof/trace. Captures are bounded by MaxCapture.
+//   - /debug/buildinfo: the build information of the binary.
+//   - /debug/config: the redacted effective configuration.
+//   - /debug/flags: the feature flags in effect.
+func NewAdminServer(
+	logger Logger,
+	config *AdminConfig,
+	t telemetry.Telemetry,
+	info AdminInfo,
+	routes ...HTTPRoute,
+) (HTTPServer, error) {
+	if config == nil {
+		return nil, ErrNilConfig
+	}
+
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := NewBase(logger, &config.Config, t)
+	if err != nil {
+		return nil, err
+	}
+
+	// Captures stream their result once they are over, so the write timeout
+	// must outlast the longest one.
+	const writeMargin = 10 * time.Second
+
+	s, err := newHTTPServer(b, config.MaxCapture+writeMargin, config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	s.RegisterRoutes(append(adminRoutes(config, info), routes...)...)
+
+	return s, nil
+}
+
+// Route is an HTTPRoute made of a pattern and a handler.
+type Route struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+func NewRoute(pattern string, handler http.HandlerFunc) Route {
+	return Route{
+		pattern: pattern,
+		handler: handler,
+	}
+}
+
+func (r Route) String() string {
+	return r.pattern
+}
+
+func (r Route) Route() http.HandlerFunc {
+	return r.handler
+}
+
+func adminRoutes(config *AdminConfig, info AdminInfo) []HTTPRoute {
+	capture := func(h http.HandlerFunc) http.HandlerFunc {
+		return boundedCapture(config.MaxCapture, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /debug/pprof/", capture(pprof.Index)),
+		NewRoute("GET /debug/pprof/cmdline", pprof.Cmdline),
+		NewRoute("GET /debug/pprof/profile", capture(pprof.Profile)),
+		NewRoute("GET /debug/pprof/symbol", pprof.Symbol),
+		NewRoute("POST /debug/pprof/symbol", pprof.Symbol),
+		NewRoute("GET /debug/pprof/trace", capture(pprof.Trace)),
+		NewRoute("GET /debug/buildinfo", buildInfoHandler(config.Version)),
+		NewRoute("GET /debug/config", configHandler(info.Config)),
+		NewRoute("GET /debug/flags", flagsHandler(info.Flags)),
+	}
+}
+
+// boundedCapture rejects requests asking for a capture longer than limit
+// through the seconds query parameter.
+func boundedCapture(limit time.Duration, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if v := r.URL.Query().Get("seconds"); v != "" {
+			seconds, err := strconv.ParseFloat(v, 64)
+			if err != nil || seconds <= 0 {
+				http.Error(w, "seconds must be a positive number", http.StatusBadRequest)
+				return
+			}
+
+			if time.Duration(seconds*float64(time.Second)) > limit {
+				http.Error(w, fmt.Sprintf("captures are limited to %s", limit), http.StatusBadRequest)
+				return
+			}
+		}
+
+		h(w, r)
+	}
+}
+
+// BuildInfo describes the binary of the running application.
+type BuildInfo struct {
+	Version     string `json:"version"`
+	GoVersion   string `json:"go_version"`
+	Path        string `json:"path"`
+	Revision    string `json:"revision,omitempty"`
+	RevisionAt  string `json:"revision_time,omitempty"`
+	Modified    bool   `json:"modified"`
+	MainVersion string `json:"main_version"`
+}
+
+func buildInfoHandler(version Version) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			http.Error(w, "build info is unavailable", http.StatusNotFound)
+			return
+		}
+
+		info := BuildInfo{
+			Version:     version.String(),
+			GoVersion:   bi.GoVersion,
+			Path:        bi.Path,
+			Revision:    "",
+			RevisionAt:  "",
+			Modified:    false,
+			MainVersion: bi.Main.Version,
+		}
+
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Revision = s.Value
+			case "vcs.time":
+				info.RevisionAt = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+
+		writeJSON(w, http.StatusOK, info)
+	}
+}
+
+func configHandler(config any) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		redacted, err := Redact(config)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, redacted)
+	}
+}
+
+func flagsHandler(flags FeatureFlags) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		active := map[string]bool{}
+		if flags != nil {
+			active = flags.Flags()
+		}
+
+		writeJSON(w, http.StatusOK, active)
+	}
+}
+
+// redacted replaces the values of secret fields.
+const redacted = "[REDACTED]"
+
+// Redact returns the JSON representation of v with the values of its secret
+// fields replaced. A struct field is secret when it is tagged
+// `redact:"true"`, as passwords, API keys and signing secrets are; fields
+// are never told secret by their name.
+func Redact(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree any
+
+	err = json.Unmarshal(b, &tree)
+	if err != nil {
+		return nil, err
+	}
+
+	redact(reflect.ValueOf(v), tree)
+
+	return tree, nil
+}
+
+// redact replaces the values of the secret fields of v in tree, the JSON
+// representation of v. Values that marshal themselves are left as they are,
+// since their representation does not follow their fields.
+func redact(v reflect.Value, tree any) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() || v.Type().Implements(reflect.TypeFor[json.Marshaler]()) ||
+		v.Type().Implements(reflect.TypeFor[encoding.TextMarshaler]()) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if obj, ok := tree.(map[string]any); ok {
+			redactStruct(v, obj)
+		}
+	case reflect.Slice, reflect.Array:
+		if arr, ok := tree.([]any); ok {
+			for i := range min(v.Len(), len(arr)) {
+				redact(v.Index(i), arr[i])
+			}
+		}
+	case reflect.Map:
+		obj, ok := tree.(map[string]any)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return
+		}
+
+		for iter := v.MapRange(); iter.Next(); {
+			if child, ok := obj[iter.Key().String()]; ok {
+				redact(iter.Value(), child)
+			}
+		}
+	default:
+	}
+}
+
+// redactStruct redacts the fields of the struct v in obj, under the names
+// encoding/json gives them. The fields of embedded structs are promoted.
+func redactStruct(v reflect.Value, obj map[string]any) {
+	t := v.Type()
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		if f.Anonymous && name == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+
+			if fv.Kind() == reflect.Struct {
+				redactStruct(fv, obj)
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		child, ok := obj[name]
+		if !ok {
+			continue
+		}
+
+		if f.Tag.Get("redact") == "true" {
+			obj[name] = redacted
+			continue
+		}
+
+		redact(fv, child)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type AdminError string
+
+func (e AdminError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrAdminMaxCapture  AdminError = "admin max capture must be positive"
+	ErrAdminNotLoopback AdminError = "admin server must bind to a loopback address unless served over mTLS"
+	ErrAdminClientAuth  AdminError = "admin server TLS must require and verify client certificates"
+)
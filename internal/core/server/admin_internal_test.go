@@ -0,0 +1,244 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+)
+
+func newTestAdminConfig(addr string, tlsConfig *tls.Config) AdminConfig {
+	return AdminConfig{
+		Config: Config{
+			Addr:      Address(addr),
+			Port:      Port(8081),
+			Env:       backend.EnvDevelopment,
+			Version:   Version("1.2.3"),
+			Telemetry: false,
+		},
+		TLS:        tlsConfig,
+		MaxCapture: time.Second,
+	}
+}
+
+func TestAdminConfig_Validate(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		config AdminConfig
+	}{
+		{
+			CaseBase: test.NewCaseBase("localhost", nil, false),
+			config:   newTestAdminConfig("localhost", nil),
+		},
+		{
+			CaseBase: test.NewCaseBase("loopback ip", nil, false),
+			config:   newTestAdminConfig("127.0.0.1", nil),
+		},
+		{
+			CaseBase: test.NewCaseBase("public address without tls", ErrAdminNotLoopback, true),
+			config:   newTestAdminConfig("0.0.0.0", nil),
+		},
+		{
+			CaseBase: test.NewCaseBase("public address with mtls", nil, false),
+			config:   newTestAdminConfig("0.0.0.0", &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}), //nolint:exhaustruct,gosec // test config
+		},
+		{
+			CaseBase: test.NewCaseBase("tls without client auth", ErrAdminClientAuth, true),
+			config:   newTestAdminConfig("0.0.0.0", &tls.Config{ClientAuth: tls.NoClientCert}), //nolint:exhaustruct,gosec // test config
+		},
+		{
+			CaseBase: test.NewCaseBase("no max capture", ErrAdminMaxCapture, true),
+			config: func() AdminConfig {
+				c := newTestAdminConfig("localhost", nil)
+				c.MaxCapture = 0
+				return c
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.config.Validate()
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+			if tt.WantErr {
+				assert.Error(t, err, tt.Want.(error))
+			}
+		})
+	}
+}
+
+func newAdminMux(t *testing.T, info AdminInfo) *http.ServeMux {
+	t.Helper()
+
+	config := newTestAdminConfig("localhost", nil)
+	mux := http.NewServeMux()
+	for _, r := range adminRoutes(&config, info) {
+		mux.HandleFunc(r.String(), r.Route())
+	}
+
+	return mux
+}
+
+func get(t *testing.T, h http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	return rec
+}
+
+func TestAdminRoutes_Config(t *testing.T) {
+	type database struct {
+		Host     string
+		Password string `redact:"true"`
+	}
+
+	type webhook struct {
+		URL    string
+		Secret string `json:"secret" redact:"true"`
+	}
+
+	type smtp struct {
+		Password string `redact:"true"`
+	}
+
+	type cors struct {
+		AllowedHeaders   []string
+		AllowCredentials bool
+	}
+
+	type policy struct {
+		Headers struct{ HSTSMaxAge int }
+		CORS    cors
+	}
+
+	mux := newAdminMux(t, AdminInfo{
+		Config: struct {
+			*smtp
+			Database database
+			Headers  map[string]string `redact:"true"`
+			Tokens   struct{ TTL int }
+			Stripe   struct{ APIKey string `redact:"true"` }
+			Webhooks []*webhook
+			Policy   policy
+			Env      backend.Environment
+		}{
+			smtp:     &smtp{Password: "mailpass"},
+			Database: database{Host: "db.internal", Password: "hunter2"},
+			Headers:  map[string]string{"x-api-key": "secret"},
+			Tokens:   struct{ TTL int }{TTL: 600},
+			Stripe:   struct{ APIKey string `redact:"true"` }{APIKey: "sk_live"},
+			Webhooks: []*webhook{{URL: "https://example.com", Secret: "whsec"}},
+			Policy: policy{
+				Headers: struct{ HSTSMaxAge int }{HSTSMaxAge: 31536000},
+				CORS:    cors{AllowedHeaders: []string{"Content-Type"}, AllowCredentials: true},
+			},
+			Env: backend.EnvProduction,
+		},
+		Flags: nil,
+	})
+
+	rec := get(t, mux, "/debug/config")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var got map[string]any
+	err := json.NewDecoder(rec.Body).Decode(&got)
+	assert.NoError(t, err)
+
+	db := got["Database"].(map[string]any)
+	assert.Equal(t, db["Host"], any("db.internal"))
+	assert.Equal(t, db["Password"], any(redacted))
+	assert.Equal(t, got["Headers"], any(redacted))
+	assert.Equal(t, got["Stripe"].(map[string]any)["APIKey"], any(redacted))
+	assert.Equal(t, got["Env"], any("production"))
+	assert.Equal(t, got["Password"], any(redacted))
+
+	hook := got["Webhooks"].([]any)[0].(map[string]any)
+	assert.Equal(t, hook["URL"], any("https://example.com"))
+	assert.Equal(t, hook["secret"], any(redacted))
+
+	// Only tagged fields are redacted: neither token lifetimes nor header
+	// and credential policies are secrets.
+	assert.Equal(t, got["Tokens"].(map[string]any)["TTL"], any(float64(600)))
+
+	p := got["Policy"].(map[string]any)
+	assert.Equal(t, p["Headers"].(map[string]any)["HSTSMaxAge"], any(float64(31536000)))
+	assert.Equal(t, p["CORS"].(map[string]any)["AllowCredentials"], any(true))
+	assert.Equal(t, len(p["CORS"].(map[string]any)["AllowedHeaders"].([]any)), 1)
+}
+
+func TestAdminRoutes_Flags(t *testing.T) {
+	mux := newAdminMux(t, AdminInfo{
+		Config: nil,
+		Flags:  StaticFlags{"new_checkout": true},
+	})
+
+	rec := get(t, mux, "/debug/flags")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var got map[string]bool
+	err := json.NewDecoder(rec.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.True(t, got["new_checkout"])
+}
+
+func TestAdminRoutes_BuildInfo(t *testing.T) {
+	mux := newAdminMux(t, AdminInfo{Config: nil, Flags: nil})
+
+	rec := get(t, mux, "/debug/buildinfo")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var got BuildInfo
+	err := json.NewDecoder(rec.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Version, "1.2.3")
+	assert.NotEqual(t, got.GoVersion, "")
+}
+
+func TestAdminRoutes_CaptureIsBounded(t *testing.T) {
+	mux := newAdminMux(t, AdminInfo{Config: nil, Flags: nil})
+
+	tests := []struct {
+		test.CaseBase
+		target string
+	}{
+		{
+			CaseBase: test.NewCaseBase("trace over limit", http.StatusBadRequest, false),
+			target:   "/debug/pprof/trace?seconds=5",
+		},
+		{
+			CaseBase: test.NewCaseBase("profile over limit", http.StatusBadRequest, false),
+			target:   "/debug/pprof/profile?seconds=60",
+		},
+		{
+			CaseBase: test.NewCaseBase("invalid seconds", http.StatusBadRequest, false),
+			target:   "/debug/pprof/trace?seconds=abc",
+		},
+		{
+			CaseBase: test.NewCaseBase("trace within limit", http.StatusOK, false),
+			target:   "/debug/pprof/trace?seconds=0.01",
+		},
+		{
+			CaseBase: test.NewCaseBase("named profile", http.StatusOK, false),
+			target:   "/debug/pprof/goroutine",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			rec := get(t, mux, tt.target)
+			assert.Equal(t, rec.Code, tt.Want.(int))
+		})
+	}
+}
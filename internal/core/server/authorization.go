@@ -0,0 +1,245 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// adminPrefix is where the admin route group is mounted.
+const adminPrefix = "/admin"
+
+// Authorizer checks the permissions of customers.
+type Authorizer interface {
+	Authorize(ctx context.Context, customerID int, p domain.Permission, ip string) error
+}
+
+// AuthorizationService manages roles and checks permissions.
+type AuthorizationService interface {
+	Authorizer
+	Roles(ctx context.Context) ([]service.RoleInfo, error)
+	DefineRole(ctx context.Context, name domain.Role, permissions []domain.Permission) error
+	DeleteRole(ctx context.Context, name domain.Role) error
+	CustomerRoles(ctx context.Context, customerID int) ([]domain.Role, error)
+	Grant(ctx context.Context, customerID int, role domain.Role, ip string) error
+	Revoke(ctx context.Context, customerID int, role domain.Role, ip string) error
+}
+
+// RequirePermission only lets requests of customers with permission p
+// through to next. It must be behind RequireSession or RequireAccessToken,
+// which tell who the customer is. Other requests are answered 403
+// Forbidden, and recorded in the audit trail.
+func RequirePermission(logger Logger, authz Authorizer, p domain.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		customerID, ok := authenticatedCustomer(r.Context())
+		if !ok {
+			writeError(w, http.StatusUnauthorized, ErrUnauthenticated)
+			return
+		}
+
+		err := authz.Authorize(r.Context(), customerID, p, ClientIP(r))
+		if err != nil {
+			if errors.Is(err, service.ErrForbidden) {
+				writeError(w, http.StatusForbidden, err)
+				return
+			}
+
+			logger.Error("failed to authorize customer", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// authenticatedCustomer returns the customer of the session or access token
+// of a request.
+func authenticatedCustomer(ctx context.Context) (int, bool) {
+	sess, ok := SessionFromContext(ctx)
+	if ok {
+		return sess.CustomerID, true
+	}
+
+	claims, ok := ClaimsFromContext(ctx)
+	if ok {
+		return claims.CustomerID, true
+	}
+
+	return 0, false
+}
+
+// NewAdminGroup mounts routes under /admin, only reachable by staff logged
+// in with the admin:access permission. Routes of the group may require
+// more permissions with RequirePermission.
+func NewAdminGroup(logger Logger, sessions SessionService, authz Authorizer, routes ...HTTPRoute) []HTTPRoute {
+	return NewRouteGroup(adminPrefix, func(h http.HandlerFunc) http.HandlerFunc {
+		return RequireSession(logger, sessions, RequirePermission(logger, authz, domain.PermissionAccessAdmin, h))
+	}, routes...)
+}
+
+// NewRoleRoutes returns the routes with which admins manage roles. They
+// require the roles:manage permission, and are meant to be mounted with
+// NewAdminGroup. The routes that change roles or who holds them also
+// require a stepped up session.
+//
+//   - GET /roles: lists the built-in and custom roles.
+//   - PUT /roles/{name}: defines a custom role from a JSON body listing its
+//     permissions.
+//   - DELETE /roles/{name}: deletes a custom role.
+//   - GET /customers/{id}/roles: lists the roles of a customer.
+//   - PUT /customers/{id}/roles/{role}: grants a role to a customer.
+//   - DELETE /customers/{id}/roles/{role}: revokes a role from a customer.
+func NewRoleRoutes(logger Logger, authz AuthorizationService, twoFactor TwoFactorService) []HTTPRoute {
+	manage := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequirePermission(logger, authz, domain.PermissionManageRoles, h)
+	}
+
+	change := func(h http.HandlerFunc) http.HandlerFunc {
+		return manage(RequireStepUp(logger, twoFactor, h))
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /roles", manage(listRolesHandler(logger, authz))),
+		NewRoute("PUT /roles/{name}", change(defineRoleHandler(logger, authz))),
+		NewRoute("DELETE /roles/{name}", change(deleteRoleHandler(logger, authz))),
+		NewRoute("GET /customers/{id}/roles", manage(customerRolesHandler(logger, authz))),
+		NewRoute("PUT /customers/{id}/roles/{role}", change(grantRoleHandler(logger, authz, true))),
+		NewRoute("DELETE /customers/{id}/roles/{role}", change(grantRoleHandler(logger, authz, false))),
+	}
+}
+
+type roleResponse struct {
+	Name        domain.Role         `json:"name"`
+	Permissions []domain.Permission `json:"permissions"`
+	Builtin     bool                `json:"builtin"`
+}
+
+func listRolesHandler(logger Logger, authz AuthorizationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roles, err := authz.Roles(r.Context())
+		if err != nil {
+			logger.Error("failed to list roles", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		res := make([]roleResponse, 0, len(roles))
+		for _, role := range roles {
+			res = append(res, roleResponse{Name: role.Name, Permissions: role.Permissions, Builtin: role.Builtin})
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+type defineRoleRequest struct {
+	Permissions []domain.Permission `json:"permissions"`
+}
+
+func defineRoleHandler(logger Logger, authz AuthorizationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req defineRoleRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = authz.DefineRole(r.Context(), domain.Role(r.PathValue("name")), req.Permissions)
+		if err != nil {
+			writeRoleError(logger, w, err, "failed to define role")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func deleteRoleHandler(logger Logger, authz AuthorizationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := authz.DeleteRole(r.Context(), domain.Role(r.PathValue("name")))
+		if err != nil {
+			writeRoleError(logger, w, err, "failed to delete role")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func customerRolesHandler(logger Logger, authz AuthorizationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, ErrCustomerNotFound)
+			return
+		}
+
+		roles, err := authz.CustomerRoles(r.Context(), id)
+		if err != nil {
+			logger.Error("failed to list customer roles", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		writeJSON(w, http.StatusOK, roles)
+	}
+}
+
+func grantRoleHandler(logger Logger, authz AuthorizationService, grant bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, ErrCustomerNotFound)
+			return
+		}
+
+		role := domain.Role(r.PathValue("role"))
+
+		if grant {
+			err = authz.Grant(r.Context(), id, role, ClientIP(r))
+		} else {
+			err = authz.Revoke(r.Context(), id, role, ClientIP(r))
+		}
+
+		if err != nil {
+			writeRoleError(logger, w, err, "failed to change customer roles")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeRoleError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrUnknownRole), errors.Is(err, service.ErrRoleNotGranted):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, service.ErrRoleGranted):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, service.ErrRoleName),
+		errors.Is(err, service.ErrRoleBuiltin),
+		errors.Is(err, service.ErrRolePermissions),
+		errors.Is(err, service.ErrUnknownPermission),
+		errors.Is(err, service.ErrRoleImplicit):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		logger.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
+
+const ErrCustomerNotFound HandlerError = "customer not found"
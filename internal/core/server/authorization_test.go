@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+func send(t *testing.T, h http.Handler, method, target, body, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: server.SessionCookieName, Value: token}) //nolint:exhaustruct // request cookie
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestNewRouteGroup(t *testing.T) {
+	var calls []string
+
+	middleware := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "middleware")
+			next(w, r)
+		}
+	}
+
+	routes := server.NewRouteGroup("/admin/", middleware,
+		server.NewRoute("GET /roles", func(w http.ResponseWriter, _ *http.Request) {
+			calls = append(calls, "handler")
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	)
+
+	assert.Equal(t, routes[0].String(), "GET /admin/roles")
+
+	rec := request(t, newMux(routes...), http.MethodGet, "/admin/roles", "")
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, strings.Join(calls, ","), "middleware,handler")
+}
+
+func TestAdminGroup(t *testing.T) {
+	sessions := newSessionService(t)
+	audit := dal.NewMemoryAuditLog()
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), audit)
+
+	mux := newMux(server.NewAdminGroup(nopLogger{}, sessions, authz, server.NewRoleRoutes(nopLogger{}, authz, fakeTwoFactor{err: nil})...)...)
+
+	rec := send(t, mux, http.MethodGet, "/admin/roles", "", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	// Customers are kept out of the whole group, and the attempt is audited.
+	rec = send(t, mux, http.MethodGet, "/admin/roles", "", token)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+	assert.Equal(t, audit.Events()[0].Type, domain.AuditAccessDenied)
+
+	// Support staff reach the group but may not manage roles.
+	assert.NoError(t, authz.Grant(t.Context(), 1, domain.RoleSupport, ""))
+
+	rec = send(t, mux, http.MethodGet, "/admin/roles", "", token)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	assert.NoError(t, authz.Grant(t.Context(), 1, domain.RoleAdmin, ""))
+
+	rec = send(t, mux, http.MethodGet, "/admin/roles", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var roles []struct {
+		Name string `json:"name"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &roles))
+	assert.Equal(t, len(roles), 3)
+
+	for _, tt := range []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPut, "/admin/roles/refunds", `{"permissions":["admin:access","orders:refund"]}`, http.StatusNoContent},
+		{http.MethodPut, "/admin/roles/admin", `{"permissions":["orders:refund"]}`, http.StatusUnprocessableEntity},
+		{http.MethodPut, "/admin/roles/refunds", `{"permissions":["orders:delete"]}`, http.StatusUnprocessableEntity},
+		{http.MethodPut, "/admin/customers/2/roles/refunds", "", http.StatusNoContent},
+		{http.MethodPut, "/admin/customers/2/roles/refunds", "", http.StatusConflict},
+		{http.MethodPut, "/admin/customers/2/roles/owner", "", http.StatusNotFound},
+		{http.MethodGet, "/admin/customers/2/roles", "", http.StatusOK},
+		{http.MethodDelete, "/admin/customers/2/roles/refunds", "", http.StatusNoContent},
+		{http.MethodDelete, "/admin/customers/2/roles/refunds", "", http.StatusNotFound},
+		{http.MethodDelete, "/admin/roles/refunds", "", http.StatusNoContent},
+		{http.MethodDelete, "/admin/roles/refunds", "", http.StatusNotFound},
+		{http.MethodGet, "/admin/customers/jane/roles", "", http.StatusNotFound},
+	} {
+		rec = send(t, mux, tt.method, tt.target, tt.body, token)
+		assert.Equal(t, rec.Code, tt.want)
+	}
+}
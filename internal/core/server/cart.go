@@ -0,0 +1,376 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// CartCookieName is the name of the cookie holding the cart token of a
+// guest.
+const CartCookieName = "__Host-cart"
+
+// CartService manages the shopping carts of customers and guests.
+type CartService interface {
+	CartMerger
+	Cart(ctx context.Context, owner service.CartOwner) (domain.Cart, error)
+	AddLine(ctx context.Context, owner service.CartOwner, line domain.CartLine) (domain.Cart, error)
+	SetQuantity(ctx context.Context, owner service.CartOwner, line domain.CartLine) (domain.Cart, error)
+	RemoveLine(ctx context.Context, owner service.CartOwner, productID int, gift bool) (domain.Cart, error)
+	Price(
+		ctx context.Context,
+		c domain.Cart,
+		currency domain.Currency,
+		region domain.Region,
+	) (domain.PricedCart, error)
+	GuestToken(c domain.Cart) string
+}
+
+// CartMerger moves the cart of a guest to the customer they log in as.
+type CartMerger interface {
+	MergeGuestCart(ctx context.Context, customerID int, guestToken string) error
+}
+
+// NewCartRoutes returns the routes of the shopping cart. Logged in
+// customers use their own cart; guests get one kept by a cookie, created
+// when they add their first line. Every route answers with the cart priced
+// from the catalog in the currency and region query parameters, USD and
+// default when missing, with prices formatted for the first language of
+// Accept-Language.
+//
+//   - GET /cart: returns the cart.
+//   - POST /cart/lines: adds a line from a JSON body with a product_id, a
+//     quantity and a gift flag, or adds the quantity to the line of the
+//     cart with the same product and gift flag.
+//   - PUT /cart/lines/{product}: sets the quantity of a line from a JSON
+//     body with it. The gift query parameter selects the gift line.
+//   - DELETE /cart/lines/{product}: removes a line. The gift query
+//     parameter selects the gift line.
+func NewCartRoutes(logger Logger, sessions SessionService, carts CartService) []HTTPRoute {
+	optional := func(h http.HandlerFunc) http.HandlerFunc {
+		return WithSession(logger, sessions, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /cart", optional(cartHandler(logger, carts))),
+		NewRoute("POST /cart/lines", optional(addCartLineHandler(logger, carts))),
+		NewRoute("PUT /cart/lines/{product}", optional(setCartLineHandler(logger, carts))),
+		NewRoute("DELETE /cart/lines/{product}", optional(removeCartLineHandler(logger, carts))),
+	}
+}
+
+type cartLineRequest struct {
+	ProductID int  `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+	Gift      bool `json:"gift"`
+}
+
+type quantityRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+type cartResponse struct {
+	Lines   []cartLineResponse `json:"lines"`
+	Total   priceJSON          `json:"total"`
+	Expires *time.Time         `json:"expires,omitempty"`
+}
+
+type cartLineResponse struct {
+	ProductID int `json:"product_id"`
+
+	// Type, Slug and Name are missing for products no longer in the
+	// catalog.
+	Type     *domain.ProductType `json:"type,omitempty"`
+	Slug     string              `json:"slug,omitempty"`
+	Name     string              `json:"name,omitempty"`
+	Quantity int                 `json:"quantity"`
+	Gift     bool                `json:"gift"`
+
+	// Available tells whether the line can be checked out. Unavailable
+	// lines have no prices.
+	Available bool       `json:"available"`
+	UnitPrice *priceJSON `json:"unit_price,omitempty"`
+	Subtotal  *priceJSON `json:"subtotal,omitempty"`
+
+	// ListPrice is the unit price of lines priced as upgrades without the
+	// upgrade, missing for other lines.
+	ListPrice *priceJSON `json:"list_price,omitempty"`
+}
+
+func newPriceJSON(m domain.Money, region domain.Region, locale string) priceJSON {
+	return priceJSON{
+		Region:    region,
+		Amount:    m.Amount,
+		Currency:  m.Currency,
+		Formatted: m.Format(locale),
+	}
+}
+
+func newCartResponse(c domain.PricedCart, locale string) cartResponse {
+	res := cartResponse{
+		Lines:   make([]cartLineResponse, 0, len(c.Lines)),
+		Total:   newPriceJSON(c.Total, c.Region, locale),
+		Expires: nil,
+	}
+
+	if c.Cart.ID != "" {
+		res.Expires = &c.Cart.Expires
+	}
+
+	for _, l := range c.Lines {
+		line := cartLineResponse{
+			ProductID: l.Line.ProductID,
+			Type:      nil,
+			Slug:      l.Product.Slug,
+			Name:      l.Product.Name,
+			Quantity:  l.Line.Quantity,
+			Gift:      l.Line.Gift,
+			Available: l.Available,
+			UnitPrice: nil,
+			Subtotal:  nil,
+			ListPrice: nil,
+		}
+
+		if l.Product.Slug != "" {
+			line.Type = &l.Product.Type
+		}
+
+		if l.Available {
+			unit := newPriceJSON(l.UnitPrice, c.Region, locale)
+			subtotal := newPriceJSON(l.Subtotal, c.Region, locale)
+			line.UnitPrice, line.Subtotal = &unit, &subtotal
+		}
+
+		if l.Available && l.Upgrade != nil {
+			list := newPriceJSON(l.Upgrade.ListPrice, c.Region, locale)
+			line.ListPrice = &list
+		}
+
+		res.Lines = append(res.Lines, line)
+	}
+
+	return res
+}
+
+// cartOwner returns whose cart r is on: the customer of its session, or
+// else the guest holding the cart cookie.
+func cartOwner(r *http.Request) service.CartOwner {
+	sess, ok := SessionFromContext(r.Context())
+	if ok {
+		return service.CartOwner{CustomerID: sess.CustomerID, GuestToken: ""}
+	}
+
+	token := ""
+
+	c, err := r.Cookie(CartCookieName)
+	if err == nil {
+		token = c.Value
+	}
+
+	return service.CartOwner{CustomerID: 0, GuestToken: token}
+}
+
+// cartLine returns the line of the cart r is on, from the product path
+// value and gift query parameter.
+func cartLine(r *http.Request) (domain.CartLine, bool) {
+	id, err := strconv.Atoi(r.PathValue("product"))
+	if err != nil || id <= 0 {
+		return domain.CartLine{}, false
+	}
+
+	gift := false
+
+	if v := r.URL.Query().Get("gift"); v != "" {
+		gift, err = strconv.ParseBool(v)
+		if err != nil {
+			return domain.CartLine{}, false
+		}
+	}
+
+	return domain.CartLine{ProductID: id, Quantity: 0, Gift: gift}, true
+}
+
+func cartHandler(logger Logger, carts CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := carts.Cart(r.Context(), cartOwner(r))
+		if err != nil {
+			writeCartError(logger, w, err, "failed to find cart")
+			return
+		}
+
+		writeCart(logger, w, r, carts, c)
+	}
+}
+
+func addCartLineHandler(logger Logger, carts CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req cartLineRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		c, err := carts.AddLine(r.Context(), cartOwner(r), domain.CartLine{
+			ProductID: req.ProductID,
+			Quantity:  req.Quantity,
+			Gift:      req.Gift,
+		})
+		if err != nil {
+			writeCartError(logger, w, err, "failed to add cart line")
+			return
+		}
+
+		writeCart(logger, w, r, carts, c)
+	}
+}
+
+func setCartLineHandler(logger Logger, carts CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		line, ok := cartLine(r)
+		if !ok {
+			writeError(w, http.StatusNotFound, service.ErrCartLineNotFound)
+			return
+		}
+
+		var req quantityRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		line.Quantity = req.Quantity
+
+		c, err := carts.SetQuantity(r.Context(), cartOwner(r), line)
+		if err != nil {
+			writeCartError(logger, w, err, "failed to set cart line quantity")
+			return
+		}
+
+		writeCart(logger, w, r, carts, c)
+	}
+}
+
+func removeCartLineHandler(logger Logger, carts CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		line, ok := cartLine(r)
+		if !ok {
+			writeError(w, http.StatusNotFound, service.ErrCartLineNotFound)
+			return
+		}
+
+		c, err := carts.RemoveLine(r.Context(), cartOwner(r), line.ProductID, line.Gift)
+		if err != nil {
+			writeCartError(logger, w, err, "failed to remove cart line")
+			return
+		}
+
+		writeCart(logger, w, r, carts, c)
+	}
+}
+
+// writeCart answers with c priced as asked by r. Guests get their cart
+// cookie refreshed, so that it lasts as long as their cart.
+func writeCart(logger Logger, w http.ResponseWriter, r *http.Request, carts CartService, c domain.Cart) {
+	currency, region := cartPricing(r)
+
+	priced, err := carts.Price(r.Context(), c, currency, region)
+	if err != nil {
+		writeCartError(logger, w, err, "failed to price cart")
+		return
+	}
+
+	if c.ID != "" && c.CustomerID == 0 {
+		setCartCookie(w, carts.GuestToken(c), c.Expires)
+	}
+
+	writeJSON(w, http.StatusOK, newCartResponse(priced, requestLocale(r)))
+}
+
+// cartPricing returns the currency and region carts are priced in, from
+// the query parameters of r, USD and default when missing.
+func cartPricing(r *http.Request) (domain.Currency, domain.Region) {
+	currency := domain.CurrencyUSD
+	if v := r.URL.Query().Get("currency"); v != "" {
+		currency = domain.Currency(v)
+	}
+
+	region := domain.RegionDefault
+	if v := r.URL.Query().Get("region"); v != "" {
+		region = domain.Region(v)
+	}
+
+	return currency, region
+}
+
+func setCartCookie(w http.ResponseWriter, token string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CartCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearCartCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CartCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// mergeGuestCart moves the cart of the guest r comes from, if any, to the
+// customer they logged in as. Failures are logged but do not fail the
+// login: the guest keeps their cookie, and the cart expires in time.
+func mergeGuestCart(logger Logger, w http.ResponseWriter, r *http.Request, carts CartMerger, customerID int) {
+	c, err := r.Cookie(CartCookieName)
+	if err != nil {
+		return
+	}
+
+	err = carts.MergeGuestCart(r.Context(), customerID, c.Value)
+	if err != nil {
+		logger.Error("failed to merge guest cart", "error", err)
+		return
+	}
+
+	clearCartCookie(w)
+}
+
+func writeCartError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	var (
+		cartErr    domain.CartError
+		productErr domain.ProductError
+		moneyErr   domain.MoneyError
+	)
+
+	switch {
+	case errors.Is(err, service.ErrCartLineNotFound), errors.Is(err, service.ErrProductNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.As(err, &cartErr), errors.As(err, &productErr), errors.As(err, &moneyErr):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		logger.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+type cartJSON struct {
+	Lines []struct {
+		ProductID int    `json:"product_id"`
+		Type      string `json:"type"`
+		Slug      string `json:"slug"`
+		Quantity  int    `json:"quantity"`
+		Gift      bool   `json:"gift"`
+		Available bool   `json:"available"`
+		Subtotal  *struct {
+			Amount int64 `json:"amount"`
+		} `json:"subtotal"`
+	} `json:"lines"`
+	Total struct {
+		Amount    int64  `json:"amount"`
+		Currency  string `json:"currency"`
+		Formatted string `json:"formatted"`
+	} `json:"total"`
+}
+
+func newCartService(t *testing.T) *service.CartService {
+	t.Helper()
+
+	carts, err := service.NewCartService(dal.NewMemoryCarts(), newTestProducts(t), nil, service.CartConfig{
+		Secret:      []byte(strings.Repeat("s", 32)),
+		GuestTTL:    24 * time.Hour,
+		CustomerTTL: 30 * 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	return carts
+}
+
+// sendCart sends a request with the cart cookie of a guest, if any, and
+// the session cookie of a customer, if any.
+func sendCart(t *testing.T, h http.Handler, method, target, body, cart, session string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if cart != "" {
+		req.AddCookie(&http.Cookie{Name: server.CartCookieName, Value: cart}) //nolint:exhaustruct // request cookie
+	}
+
+	if session != "" {
+		req.AddCookie(&http.Cookie{Name: server.SessionCookieName, Value: session}) //nolint:exhaustruct // request cookie
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func decodeCart(t *testing.T, rec *httptest.ResponseRecorder) cartJSON {
+	t.Helper()
+
+	var res cartJSON
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	return res
+}
+
+func TestCartRoutes_Guest(t *testing.T) {
+	mux := newMux(server.NewCartRoutes(nopLogger{}, newSessionService(t), newCartService(t))...)
+
+	rec := sendCart(t, mux, http.MethodGet, "/cart", "", "", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, len(rec.Result().Cookies()), 0)
+	assert.Equal(t, len(decodeCart(t, rec).Lines), 0)
+
+	rec = sendCart(t, mux, http.MethodPost, "/cart/lines", `{"product_id":1,"quantity":1}`, "", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].Name, server.CartCookieName)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+
+	guest := cookies[0].Value
+
+	rec = sendCart(t, mux, http.MethodPost, "/cart/lines?currency=EUR", `{"product_id":2,"quantity":2}`, guest, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	res := decodeCart(t, rec)
+	assert.Equal(t, len(res.Lines), 2)
+	assert.Equal(t, res.Lines[0].Type, "plugin")
+	assert.Equal(t, res.Lines[1].Slug, "shirt")
+	assert.Equal(t, res.Lines[1].Subtotal.Amount, 246912)
+	assert.Equal(t, res.Total.Amount, 370368)
+	assert.Equal(t, res.Total.Currency, "EUR")
+
+	// Lines without a price in the currency are unavailable.
+	rec = sendCart(t, mux, http.MethodGet, "/cart?currency=USD", "", guest, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	res = decodeCart(t, rec)
+	assert.False(t, res.Lines[0].Available)
+	assert.True(t, res.Lines[0].Subtotal == nil)
+	assert.Equal(t, res.Total.Amount, 0)
+
+	rec = sendCart(t, mux, http.MethodPut, "/cart/lines/2", `{"quantity":5}`, guest, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, decodeCart(t, rec).Lines[1].Quantity, 5)
+
+	rec = sendCart(t, mux, http.MethodDelete, "/cart/lines/1", "", guest, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, len(decodeCart(t, rec).Lines), 1)
+
+	// Forged cookies open no cart.
+	rec = sendCart(t, mux, http.MethodGet, "/cart", "", "forged."+guest, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, len(decodeCart(t, rec).Lines), 0)
+}
+
+func TestCartRoutes_Errors(t *testing.T) {
+	mux := newMux(server.NewCartRoutes(nopLogger{}, newSessionService(t), newCartService(t))...)
+
+	rec := sendCart(t, mux, http.MethodPost, "/cart/lines", `{"product_id":1,"quantity":1}`, "", "")
+	guest := rec.Result().Cookies()[0].Value
+
+	for _, tt := range []struct {
+		name, method, target, body string
+		want                       int
+	}{
+		{"second license", http.MethodPost, "/cart/lines", `{"product_id":1,"quantity":1}`, http.StatusUnprocessableEntity},
+		{"unpublished product", http.MethodPost, "/cart/lines", `{"product_id":3,"quantity":1}`, http.StatusNotFound},
+		{"unknown product", http.MethodPost, "/cart/lines", `{"product_id":9,"quantity":1}`, http.StatusNotFound},
+		{"no quantity", http.MethodPost, "/cart/lines", `{"product_id":2}`, http.StatusUnprocessableEntity},
+		{"malformed", http.MethodPost, "/cart/lines", `{"product_id":"2"}`, http.StatusBadRequest},
+		{"plugin quantity", http.MethodPut, "/cart/lines/1", `{"quantity":2}`, http.StatusUnprocessableEntity},
+		{"no gift line", http.MethodPut, "/cart/lines/1?gift=true", `{"quantity":2}`, http.StatusNotFound},
+		{"invalid gift", http.MethodDelete, "/cart/lines/1?gift=maybe", "", http.StatusNotFound},
+		{"invalid product", http.MethodDelete, "/cart/lines/microwave", "", http.StatusNotFound},
+		{"invalid currency", http.MethodGet, "/cart?currency=XXX", "", http.StatusUnprocessableEntity},
+		{"invalid region", http.MethodGet, "/cart?region=germany", "", http.StatusUnprocessableEntity},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := sendCart(t, mux, tt.method, tt.target, tt.body, guest, "")
+			assert.Equal(t, rec.Code, tt.want)
+		})
+	}
+
+	// Gifted plugins are bought by the license.
+	rec = sendCart(t, mux, http.MethodPost, "/cart/lines", `{"product_id":1,"quantity":3,"gift":true}`, guest, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, len(decodeCart(t, rec).Lines), 2)
+
+	rec = sendCart(t, mux, http.MethodDelete, "/cart/lines/1?gift=true", "", guest, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, len(decodeCart(t, rec).Lines), 1)
+}
+
+func TestCartRoutes_Customer(t *testing.T) {
+	sessions := newSessionService(t)
+	carts := newCartService(t)
+	mux := newMux(server.NewCartRoutes(nopLogger{}, sessions, carts)...)
+
+	rec := sendCart(t, mux, http.MethodPost, "/cart/lines", `{"product_id":2,"quantity":1}`, "", "")
+	guest := rec.Result().Cookies()[0].Value
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	// Customers use their own cart, and get no cart cookie.
+	rec = sendCart(t, mux, http.MethodPost, "/cart/lines", `{"product_id":1,"quantity":1}`, guest, token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, len(rec.Result().Cookies()), 0)
+	assert.Equal(t, len(decodeCart(t, rec).Lines), 1)
+
+	assert.NoError(t, carts.MergeGuestCart(t.Context(), 1, guest))
+
+	rec = sendCart(t, mux, http.MethodGet, "/cart", "", "", token)
+	assert.Equal(t, len(decodeCart(t, rec).Lines), 2)
+
+	// Stale sessions are served as guests.
+	rec = sendCart(t, mux, http.MethodGet, "/cart", "", "", "stale")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, len(decodeCart(t, rec).Lines), 0)
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].Name, server.SessionCookieName)
+	assert.Equal(t, cookies[0].MaxAge, -1)
+}
@@ -0,0 +1,435 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// catalogMaxAge is how long clients may use a catalog response before
+// revalidating it with its ETag.
+const catalogMaxAge = time.Minute
+
+// Catalog serves the published products.
+type Catalog interface {
+	Catalog(ctx context.Context, types ...domain.ProductType) ([]domain.Product, error)
+	PublishedProduct(ctx context.Context, slug string) (domain.Product, error)
+}
+
+// CatalogService serves and manages the product catalog.
+type CatalogService interface {
+	Catalog
+	Products(ctx context.Context) ([]domain.Product, error)
+	Product(ctx context.Context, id int) (domain.Product, error)
+	CreateProduct(ctx context.Context, p domain.Product) (domain.Product, error)
+	UpdateProduct(ctx context.Context, p domain.Product) (domain.Product, error)
+	DeleteProduct(ctx context.Context, id int) error
+}
+
+// NewCatalogRoutes returns the public routes of the product catalog. Their
+// responses carry an ETag, and are answered 304 Not Modified when
+// If-None-Match has it. Prices are formatted for the first language of
+// Accept-Language.
+//
+//   - GET /products: lists the published products, in catalog order. The
+//     type query parameter, which may be repeated, keeps the products of
+//     the given types, such as "plugin".
+//   - GET /products/{slug}: returns a published product.
+func NewCatalogRoutes(logger Logger, catalog Catalog) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("GET /products", listCatalogHandler(logger, catalog)),
+		NewRoute("GET /products/{slug}", catalogProductHandler(logger, catalog)),
+	}
+}
+
+type mediaJSON struct {
+	Kind domain.MediaKind `json:"kind"`
+	URL  string           `json:"url"`
+	Alt  string           `json:"alt"`
+}
+
+type priceJSON struct {
+	Region   domain.Region   `json:"region"`
+	Amount   int64           `json:"amount"`
+	Currency domain.Currency `json:"currency"`
+
+	// Formatted is the price as written in the locale of the client. It is
+	// ignored in requests.
+	Formatted string `json:"formatted,omitempty"`
+}
+
+type productResponse struct {
+	ID          int                `json:"id"`
+	Type        domain.ProductType `json:"type"`
+	Slug        string             `json:"slug"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Version     string             `json:"version"`
+	Media       []mediaJSON        `json:"media"`
+	Prices      []priceJSON        `json:"prices"`
+
+	// MachineLimit is how many machines a license may be activated on at
+	// once, missing for no limit.
+	MachineLimit int `json:"machine_limit,omitempty"`
+}
+
+// newProductResponse returns p with its prices formatted for locale.
+func newProductResponse(p domain.Product, locale string) productResponse {
+	media := make([]mediaJSON, 0, len(p.Media))
+	for _, m := range p.Media {
+		media = append(media, mediaJSON{Kind: m.Kind, URL: m.URL, Alt: m.Alt})
+	}
+
+	prices := make([]priceJSON, 0, len(p.Prices))
+	for _, price := range p.Prices {
+		prices = append(prices, priceJSON{
+			Region:    price.Region,
+			Amount:    price.Money.Amount,
+			Currency:  price.Money.Currency,
+			Formatted: price.Money.Format(locale),
+		})
+	}
+
+	return productResponse{
+		ID:           p.ID,
+		Type:         p.Type,
+		Slug:         p.Slug,
+		Name:         p.Name,
+		Description:  p.Description,
+		Version:      p.Version,
+		Media:        media,
+		Prices:       prices,
+		MachineLimit: p.MachineLimit,
+	}
+}
+
+// requestLocale returns the preferred locale of the client: the first
+// language of Accept-Language, or the empty string.
+func requestLocale(r *http.Request) string {
+	tag, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	tag, _, _ = strings.Cut(tag, ";")
+
+	return strings.TrimSpace(tag)
+}
+
+func listCatalogHandler(logger Logger, catalog Catalog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var types []domain.ProductType
+
+		for _, name := range r.URL.Query()["type"] {
+			t, err := domain.ParseProductType(name)
+			if err != nil {
+				writeError(w, http.StatusUnprocessableEntity, err)
+				return
+			}
+
+			types = append(types, t)
+		}
+
+		products, err := catalog.Catalog(r.Context(), types...)
+		if err != nil {
+			logger.Error("failed to list catalog", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		res := make([]productResponse, 0, len(products))
+		for _, p := range products {
+			res = append(res, newProductResponse(p, requestLocale(r)))
+		}
+
+		err = writeCachedJSON(w, r, res)
+		if err != nil {
+			logger.Error("failed to encode catalog", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+		}
+	}
+}
+
+func catalogProductHandler(logger Logger, catalog Catalog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := catalog.PublishedProduct(r.Context(), r.PathValue("slug"))
+		if errors.Is(err, service.ErrProductNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		if err != nil {
+			logger.Error("failed to find product", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		err = writeCachedJSON(w, r, newProductResponse(p, requestLocale(r)))
+		if err != nil {
+			logger.Error("failed to encode product", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+		}
+	}
+}
+
+// writeCachedJSON answers with v as JSON and its ETag, a digest of the
+// body, or with 304 Not Modified when If-None-Match has the ETag. Nothing
+// is written when v cannot be encoded.
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+	h := w.Header()
+	h.Add("Vary", "Accept-Language")
+	h.Set("ETag", etag)
+	h.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(catalogMaxAge/time.Second)))
+
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	h.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(body, '\n'))
+
+	return nil
+}
+
+// etagMatch reports whether the If-None-Match header has etag. Weak
+// comparison is used, as for every GET request.
+func etagMatch(header, etag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewProductRoutes returns the routes with which staff manage the catalog.
+// They require the products:manage permission, and are meant to be mounted
+// with NewAdminGroup.
+//
+//   - GET /products: lists every product, published or not.
+//   - POST /products: creates a product from a JSON body.
+//   - GET /products/{id}: returns a product.
+//   - PUT /products/{id}: replaces a product from a JSON body.
+//   - DELETE /products/{id}: deletes a product.
+func NewProductRoutes(logger Logger, authz Authorizer, catalog CatalogService) []HTTPRoute {
+	manage := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequirePermission(logger, authz, domain.PermissionManageProducts, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /products", manage(listProductsHandler(logger, catalog))),
+		NewRoute("POST /products", manage(saveProductHandler(logger, catalog, false))),
+		NewRoute("GET /products/{id}", manage(productHandler(logger, catalog))),
+		NewRoute("PUT /products/{id}", manage(saveProductHandler(logger, catalog, true))),
+		NewRoute("DELETE /products/{id}", manage(deleteProductHandler(logger, catalog))),
+	}
+}
+
+type productRequest struct {
+	Type         domain.ProductType `json:"type"`
+	Slug         string             `json:"slug"`
+	Name         string             `json:"name"`
+	Description  string             `json:"description"`
+	Version      string             `json:"version"`
+	MachineLimit int                `json:"machine_limit"`
+	Media        []mediaJSON        `json:"media"`
+	Published    bool               `json:"published"`
+	Position     int                `json:"position"`
+	Prices       []priceJSON        `json:"prices"`
+	PriceID      string             `json:"stripe_price_id"`
+	ProductID    string             `json:"stripe_product_id"`
+}
+
+func (req productRequest) product(id int) domain.Product {
+	media := make([]domain.Media, 0, len(req.Media))
+	for _, m := range req.Media {
+		media = append(media, domain.Media{Kind: m.Kind, URL: m.URL, Alt: m.Alt})
+	}
+
+	prices := make([]domain.Price, 0, len(req.Prices))
+	for _, price := range req.Prices {
+		prices = append(prices, domain.Price{
+			Region: price.Region,
+			Money:  domain.Money{Amount: price.Amount, Currency: price.Currency},
+		})
+	}
+
+	return domain.Product{
+		ID:           id,
+		Type:         req.Type,
+		Slug:         req.Slug,
+		Name:         req.Name,
+		Description:  req.Description,
+		Version:      req.Version,
+		MachineLimit: req.MachineLimit,
+		Media:        media,
+		Published:    req.Published,
+		Position:     req.Position,
+		Prices:       prices,
+		PriceID:      req.PriceID,
+		ProductID:    req.ProductID,
+		Created:      time.Time{},
+		Updated:      time.Time{},
+	}
+}
+
+type adminProductResponse struct {
+	productResponse
+
+	Published bool      `json:"published"`
+	Position  int       `json:"position"`
+	PriceID   string    `json:"stripe_price_id"`
+	ProductID string    `json:"stripe_product_id"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+}
+
+func newAdminProductResponse(p domain.Product, locale string) adminProductResponse {
+	return adminProductResponse{
+		productResponse: newProductResponse(p, locale),
+		Published:       p.Published,
+		Position:        p.Position,
+		PriceID:         p.PriceID,
+		ProductID:       p.ProductID,
+		Created:         p.Created,
+		Updated:         p.Updated,
+	}
+}
+
+func listProductsHandler(logger Logger, catalog CatalogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		products, err := catalog.Products(r.Context())
+		if err != nil {
+			logger.Error("failed to list products", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		res := make([]adminProductResponse, 0, len(products))
+		for _, p := range products {
+			res = append(res, newAdminProductResponse(p, requestLocale(r)))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func productHandler(logger Logger, catalog CatalogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, service.ErrProductNotFound)
+			return
+		}
+
+		p, err := catalog.Product(r.Context(), id)
+		if err != nil {
+			writeProductError(logger, w, err, "failed to find product")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAdminProductResponse(p, requestLocale(r)))
+	}
+}
+
+func saveProductHandler(logger Logger, catalog CatalogService, update bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := 0
+
+		if update {
+			var err error
+
+			id, err = strconv.Atoi(r.PathValue("id"))
+			if err != nil || id <= 0 {
+				writeError(w, http.StatusNotFound, service.ErrProductNotFound)
+				return
+			}
+		}
+
+		var req productRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		var p domain.Product
+
+		if update {
+			p, err = catalog.UpdateProduct(r.Context(), req.product(id))
+		} else {
+			p, err = catalog.CreateProduct(r.Context(), req.product(0))
+		}
+
+		if err != nil {
+			writeProductError(logger, w, err, "failed to save product")
+			return
+		}
+
+		status := http.StatusOK
+		if !update {
+			status = http.StatusCreated
+		}
+
+		writeJSON(w, status, newAdminProductResponse(p, requestLocale(r)))
+	}
+}
+
+func deleteProductHandler(logger Logger, catalog CatalogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, service.ErrProductNotFound)
+			return
+		}
+
+		err = catalog.DeleteProduct(r.Context(), id)
+		if err != nil {
+			writeProductError(logger, w, err, "failed to delete product")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeProductError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	var productErr domain.ProductError
+
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, service.ErrSlugTaken):
+		writeError(w, http.StatusConflict, err)
+	case errors.As(err, &productErr):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		logger.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
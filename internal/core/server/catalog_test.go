@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+func newTestCatalog(t *testing.T) *service.CatalogService {
+	t.Helper()
+
+	return service.NewCatalogService(newTestProducts(t))
+}
+
+// newTestProducts returns a catalog of a plugin, a piece of merchandise and
+// an unpublished plugin, with IDs 1, 2 and 3.
+func newTestProducts(t *testing.T) *dal.MemoryProducts {
+	t.Helper()
+
+	products := dal.NewMemoryProducts()
+
+	for _, p := range []struct {
+		slug      string
+		typ       domain.ProductType
+		published bool
+	}{
+		{"microwave", domain.ProductPlugin, true},
+		{"shirt", domain.ProductMerchandise, true},
+		{"oven", domain.ProductPlugin, false},
+	} {
+		_, err := products.CreateProduct(t.Context(), domain.Product{
+			ID:           0,
+			Type:         p.typ,
+			Slug:         p.slug,
+			Name:         p.slug,
+			Description:  "",
+			Version:      "",
+			MachineLimit: 0,
+			Media:        nil,
+			Published:    p.published,
+			Position:     0,
+			Prices: []domain.Price{
+				{Region: domain.RegionDefault, Money: domain.Money{Amount: 123456, Currency: domain.CurrencyEUR}},
+			},
+			PriceID:   "",
+			ProductID: "",
+			Created:   time.Time{},
+			Updated:   time.Time{},
+		})
+		assert.NoError(t, err)
+	}
+
+	return products
+}
+
+func TestCatalogRoutes(t *testing.T) {
+	mux := newMux(server.NewCatalogRoutes(nopLogger{}, newTestCatalog(t))...)
+
+	rec := request(t, mux, http.MethodGet, "/products", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var products []struct {
+		Slug string `json:"slug"`
+		Type string `json:"type"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &products))
+	assert.Equal(t, len(products), 2)
+	assert.Equal(t, products[0].Type, "plugin")
+
+	etag := rec.Header().Get("ETag")
+	assert.True(t, etag != "")
+
+	req := httptest.NewRequest(http.MethodGet, "/products", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusNotModified)
+	assert.Equal(t, rec.Body.Len(), 0)
+
+	rec = request(t, mux, http.MethodGet, "/products?type=merchandise", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &products))
+	assert.Equal(t, len(products), 1)
+	assert.Equal(t, products[0].Slug, "shirt")
+
+	// Filtering changes the representation, so the ETag.
+	assert.NotEqual(t, rec.Header().Get("ETag"), etag)
+
+	rec = request(t, mux, http.MethodGet, "/products?type=vinyl", "")
+	assert.Equal(t, rec.Code, http.StatusUnprocessableEntity)
+
+	req = httptest.NewRequest(http.MethodGet, "/products/microwave", nil)
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9,en;q=0.8")
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var product struct {
+		Prices []struct {
+			Amount    int64  `json:"amount"`
+			Currency  string `json:"currency"`
+			Formatted string `json:"formatted"`
+		} `json:"prices"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &product))
+	assert.Equal(t, len(product.Prices), 1)
+	assert.Equal(t, product.Prices[0].Amount, 123456)
+	assert.Equal(t, product.Prices[0].Formatted, "1.234,56\u00a0€")
+
+	rec = request(t, mux, http.MethodGet, "/products/oven", "")
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestProductRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), dal.NewMemoryAuditLog())
+	catalog := newTestCatalog(t)
+
+	mux := newMux(server.NewAdminGroup(nopLogger{}, sessions, authz, server.NewProductRoutes(nopLogger{}, authz, catalog)...)...)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	// Support staff reach the admin routes but may not manage products.
+	assert.NoError(t, authz.Grant(t.Context(), 1, domain.RoleSupport, ""))
+
+	rec := send(t, mux, http.MethodGet, "/admin/products", "", token)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	assert.NoError(t, authz.Grant(t.Context(), 1, domain.RoleAdmin, ""))
+
+	rec = send(t, mux, http.MethodGet, "/admin/products", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var products []struct {
+		ID        int  `json:"id"`
+		Published bool `json:"published"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &products))
+	assert.Equal(t, len(products), 3)
+
+	const body = `{"type":"plugin","slug":"toaster","name":"Toaster","description":"Warm saturation.",
+		"media":[{"kind":"image","url":"https://cdn.brokedaear.com/toaster.png","alt":"Toaster"}],
+		"published":true,"position":2,"prices":[{"region":"default","amount":2900,"currency":"USD"}],
+		"stripe_price_id":"price_1","stripe_product_id":"prod_1"}`
+
+	rec = send(t, mux, http.MethodPost, "/admin/products", body, token)
+	assert.Equal(t, rec.Code, http.StatusCreated)
+
+	var created struct {
+		ID int `json:"id"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, created.ID, 4)
+
+	toaster, err := catalog.PublishedProduct(t.Context(), "toaster")
+	assert.NoError(t, err)
+
+	// Customers in the US pay the default price.
+	price, ok := toaster.Price(domain.CurrencyUSD, "US")
+	assert.True(t, ok)
+	assert.Equal(t, price.Amount, 2900)
+
+	for _, tt := range []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPost, "/admin/products", body, http.StatusConflict},
+		{http.MethodPost, "/admin/products", `{"type":"plugin","slug":"Toaster","name":"Toaster"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/admin/products", `{"type":"vinyl","slug":"record","name":"Record"}`, http.StatusBadRequest},
+		{http.MethodPost, "/admin/products", `{"type":"plugin","slug":"grill","name":"Grill","prices":[{"region":"default","amount":-1,"currency":"USD"}]}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/admin/products/1000", "", http.StatusNotFound},
+		{http.MethodPut, "/admin/products/1", `{"type":"plugin","slug":"microwave","name":"Microwave 2"}`, http.StatusOK},
+		{http.MethodPut, "/admin/products/1", `{"type":"plugin","slug":"toaster","name":"Microwave"}`, http.StatusConflict},
+		{http.MethodPut, "/admin/products/1000", `{"type":"plugin","slug":"grill","name":"Grill"}`, http.StatusNotFound},
+		{http.MethodDelete, "/admin/products/1", "", http.StatusNoContent},
+		{http.MethodDelete, "/admin/products/1", "", http.StatusNotFound},
+		{http.MethodDelete, "/admin/products/oven", "", http.StatusNotFound},
+	} {
+		rec = send(t, mux, tt.method, tt.target, tt.body, token)
+		assert.Equal(t, rec.Code, tt.want)
+	}
+}
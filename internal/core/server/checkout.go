@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// CheckoutService checks out the carts of customers.
+type CheckoutService interface {
+	Checkout(
+		ctx context.Context,
+		customerID int,
+		currency domain.Currency,
+		region domain.Region,
+	) (domain.CheckoutSession, error)
+}
+
+// NewCheckoutRoutes returns the checkout routes. They require a session.
+//
+//   - POST /checkout: opens a payment page for the cart of the customer,
+//     priced in the currency and region query parameters like the cart, and
+//     answers with its URL. Customers who did not verify their email are
+//     answered with 403, and carts that are empty or have unavailable lines
+//     with 422.
+func NewCheckoutRoutes(logger Logger, sessions SessionService, checkout CheckoutService) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("POST /checkout", RequireSession(logger, sessions, checkoutHandler(logger, checkout))),
+	}
+}
+
+type checkoutResponse struct {
+	ID      string    `json:"id"`
+	URL     string    `json:"url"`
+	Expires time.Time `json:"expires"`
+}
+
+func checkoutHandler(logger Logger, checkout CheckoutService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+		currency, region := cartPricing(r)
+
+		session, err := checkout.Checkout(r.Context(), sess.CustomerID, currency, region)
+		if err != nil {
+			writeCheckoutError(logger, w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, checkoutResponse{
+			ID:      session.ID,
+			URL:     session.URL,
+			Expires: session.Expires,
+		})
+	}
+}
+
+func writeCheckoutError(logger Logger, w http.ResponseWriter, err error) {
+	var (
+		checkoutErr service.CheckoutError
+		productErr  domain.ProductError
+		moneyErr    domain.MoneyError
+	)
+
+	switch {
+	case errors.Is(err, service.ErrCustomerUnverified):
+		writeError(w, http.StatusForbidden, err)
+	case errors.As(err, &checkoutErr), errors.As(err, &productErr), errors.As(err, &moneyErr):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, ports.ErrPaymentUnavailable), errors.Is(err, ports.ErrPaymentRateLimited):
+		logger.Warn("payment provider unavailable", "error", err)
+		writeError(w, http.StatusServiceUnavailable, errPaymentUnavailable)
+	default:
+		logger.Error("failed to check out", "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
+
+const errPaymentUnavailable HandlerError = "payments are unavailable, try again later"
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// fakeCheckout opens sessions for the pricing it is asked for, or fails
+// with err.
+type fakeCheckout struct {
+	err error
+}
+
+func (f fakeCheckout) Checkout(
+	_ context.Context,
+	customerID int,
+	currency domain.Currency,
+	region domain.Region,
+) (domain.CheckoutSession, error) {
+	if f.err != nil {
+		return domain.CheckoutSession{}, f.err
+	}
+
+	return domain.CheckoutSession{
+		ID:                fmt.Sprintf("cs_%d_%s_%s", customerID, currency, region),
+		URL:               "https://checkout.example.com/cs",
+		Reference:         "cart",
+		CustomerID:        customerID,
+		PaymentCustomerID: "cus_1",
+		Status:            domain.CheckoutOpen,
+		PaymentStatus:     domain.PaymentUnpaid,
+		PaymentID:         "",
+		Total:             domain.Money{Amount: 4900, Currency: currency},
+		Expires:           time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func TestCheckoutRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	mux := newMux(server.NewCheckoutRoutes(nopLogger{}, sessions, fakeCheckout{err: nil})...)
+
+	rec := request(t, mux, http.MethodPost, "/checkout", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	rec = request(t, mux, http.MethodPost, "/checkout?currency=EUR&region=DE", token)
+	assert.Equal(t, rec.Code, http.StatusCreated)
+
+	var res struct {
+		ID      string    `json:"id"`
+		URL     string    `json:"url"`
+		Expires time.Time `json:"expires"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, res.ID, "cs_1_EUR_DE")
+	assert.Equal(t, res.URL, "https://checkout.example.com/cs")
+
+	rec = request(t, mux, http.MethodPost, "/checkout", token)
+	assert.Equal(t, rec.Code, http.StatusCreated)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, res.ID, "cs_1_USD_default")
+}
+
+func TestCheckoutRoutes_Errors(t *testing.T) {
+	sessions := newSessionService(t)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unverified", service.ErrCustomerUnverified, http.StatusForbidden},
+		{"empty cart", service.ErrCartEmpty, http.StatusUnprocessableEntity},
+		{"unavailable lines", service.ErrCartUnavailable, http.StatusUnprocessableEntity},
+		{"invalid currency", domain.ErrInvalidCurrency, http.StatusUnprocessableEntity},
+		{"invalid region", domain.ErrInvalidRegion, http.StatusUnprocessableEntity},
+		{"provider down", fmt.Errorf("failed: %w", ports.ErrPaymentUnavailable), http.StatusServiceUnavailable},
+		{"failure", errors.New("boom"), http.StatusInternalServerError},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := newMux(server.NewCheckoutRoutes(nopLogger{}, sessions, fakeCheckout{err: tt.err})...)
+
+			rec := request(t, mux, http.MethodPost, "/checkout", token)
+			assert.Equal(t, rec.Code, tt.want)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// SignUpService creates customers.
+type SignUpService interface {
+	SignUp(ctx context.Context, email domain.NewCustomerEmail, password domain.NewCustomerPassword) (domain.Customer, error)
+}
+
+// VerificationSender mails verification links to customers.
+type VerificationSender interface {
+	Send(ctx context.Context, customerID int) error
+}
+
+// maxBodyBytes bounds the size of JSON request bodies.
+const maxBodyBytes = 1 << 20
+
+// NewCustomerRoutes returns the public customer routes.
+//
+//   - POST /customers: signs a customer up from a JSON body with an email and
+//     a password, mails them a verification link, and answers with the
+//     created customer.
+func NewCustomerRoutes(logger Logger, signUp SignUpService, verifier VerificationSender) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("POST /customers", signUpHandler(logger, signUp, verifier)),
+	}
+}
+
+type signUpRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type customerResponse struct {
+	ID       int       `json:"id"`
+	Email    string    `json:"email"`
+	Created  time.Time `json:"created"`
+	Verified bool      `json:"verified"`
+}
+
+func newCustomerResponse(c domain.Customer) customerResponse {
+	return customerResponse{
+		ID:       c.ID,
+		Email:    c.Email,
+		Created:  c.Created,
+		Verified: c.Verified,
+	}
+}
+
+func signUpHandler(logger Logger, svc SignUpService, verifier VerificationSender) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req signUpRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		c, err := svc.SignUp(r.Context(), domain.NewCustomerEmail(req.Email), domain.NewCustomerPassword(req.Password))
+		if err != nil {
+			var credErr domain.CredentialError
+
+			switch {
+			case errors.As(err, &credErr):
+				writeError(w, http.StatusUnprocessableEntity, err)
+			case errors.Is(err, service.ErrEmailTaken):
+				writeError(w, http.StatusConflict, err)
+			default:
+				logger.Error("failed to sign up customer", "error", err)
+				writeError(w, http.StatusInternalServerError, errInternal)
+			}
+
+			return
+		}
+
+		// The customer is created either way; they can ask for another link
+		// if this one is lost.
+		err = verifier.Send(r.Context(), c.ID)
+		if err != nil {
+			logger.Warn("failed to send verification email", "customer", c.ID, "error", err)
+		}
+
+		writeJSON(w, http.StatusCreated, newCustomerResponse(c))
+	}
+}
+
+// decodeJSON decodes a bounded JSON request body into v, rejecting unknown
+// fields.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err != nil {
+		return ErrMalformedBody
+	}
+
+	return nil
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError answers with err as a JSON error body.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeRetry answers a rejected attempt with 429 Too Many Requests and the
+// number of seconds to wait in Retry-After.
+func writeRetry(w http.ResponseWriter, err *service.RetryError) {
+	seconds := int(math.Ceil(err.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	writeError(w, http.StatusTooManyRequests, err.Err)
+}
+
+type HandlerError string
+
+func (e HandlerError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrMalformedBody HandlerError = "request body is malformed"
+
+	// errInternal hides the details of unexpected failures from clients.
+	errInternal HandlerError = "internal server error"
+)
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+func (nopLogger) Sync() error          { return nil }
+
+type fakeSignUp struct {
+	err error
+}
+
+func (f fakeSignUp) SignUp(
+	_ context.Context,
+	email domain.NewCustomerEmail,
+	_ domain.NewCustomerPassword,
+) (domain.Customer, error) {
+	if f.err != nil {
+		return domain.Customer{}, f.err
+	}
+
+	return domain.Customer{
+		ID:                1,
+		Email:             email.String(),
+		HashedPassword:    []byte("hash"),
+		Created:           time.Now(),
+		Verified:          false,
+		PaymentCustomerID: "",
+	}, nil
+}
+
+// fakeSender records the customers it was asked to mail.
+type fakeSender struct {
+	sent []int
+}
+
+func (f *fakeSender) Send(_ context.Context, customerID int) error {
+	f.sent = append(f.sent, customerID)
+	return nil
+}
+
+// newMux mounts routes on a mux the way an HTTPServer does.
+func newMux(routes ...server.HTTPRoute) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, r := range routes {
+		mux.HandleFunc(r.String(), r.Route())
+	}
+
+	return mux
+}
+
+func TestCustomerRoutes_SignUp(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		body string
+		err  error
+	}{
+		{
+			CaseBase: test.NewCaseBase("created", http.StatusCreated, false),
+			body:     `{"email":"jane@example.com","password":"correct horse battery"}`,
+			err:      nil,
+		},
+		{
+			CaseBase: test.NewCaseBase("malformed body", http.StatusBadRequest, false),
+			body:     `{"email":`,
+			err:      nil,
+		},
+		{
+			CaseBase: test.NewCaseBase("unknown field", http.StatusBadRequest, false),
+			body:     `{"email":"jane@example.com","password":"correct horse battery","admin":true}`,
+			err:      nil,
+		},
+		{
+			CaseBase: test.NewCaseBase("invalid credentials", http.StatusUnprocessableEntity, false),
+			body:     `{"email":"jane@example.com","password":"password1234"}`,
+			err:      domain.ErrPasswordBreached,
+		},
+		{
+			CaseBase: test.NewCaseBase("email taken", http.StatusConflict, false),
+			body:     `{"email":"jane@example.com","password":"correct horse battery"}`,
+			err:      service.ErrEmailTaken,
+		},
+		{
+			CaseBase: test.NewCaseBase("internal failure", http.StatusInternalServerError, false),
+			body:     `{"email":"jane@example.com","password":"correct horse battery"}`,
+			err:      errors.New("database is down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			sender := &fakeSender{sent: nil}
+			mux := newMux(server.NewCustomerRoutes(nopLogger{}, fakeSignUp{err: tt.err}, sender)...)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/customers", strings.NewReader(tt.body))
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, tt.Want.(int))
+			assert.Equal(t, rec.Header().Get("Content-Type"), "application/json")
+			assert.False(t, strings.Contains(rec.Body.String(), "database is down"))
+
+			if rec.Code == http.StatusCreated {
+				assert.Equal(t, len(sender.sent), 1)
+			} else {
+				assert.Equal(t, len(sender.sent), 0)
+			}
+		})
+	}
+}
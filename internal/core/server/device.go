@@ -0,0 +1,392 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+const (
+	grantTypeDeviceCode   = "urn:ietf:params:oauth:grant-type:device_code"
+	grantTypeRefreshToken = "refresh_token"
+)
+
+// DeviceService signs plugins in through the OAuth 2.0 device authorization
+// grant.
+type DeviceService interface {
+	Authorize(ctx context.Context, clientID, scope, deviceName string) (service.DeviceCode, error)
+	Request(ctx context.Context, userCode string) (service.DeviceRequest, error)
+	Approve(ctx context.Context, customerID int, userCode, ip string) error
+	Deny(ctx context.Context, customerID int, userCode string) error
+	Poll(ctx context.Context, clientID, deviceCode string) (service.DeviceTokens, error)
+	Refresh(ctx context.Context, clientID, refreshToken string) (service.DeviceTokens, error)
+	Authenticate(ctx context.Context, accessToken string) (domain.Device, error)
+	List(ctx context.Context, customerID int) ([]domain.Device, error)
+	Revoke(ctx context.Context, customerID int, id, ip string) error
+	RevokeAll(ctx context.Context, customerID int, ip string) (int, error)
+}
+
+type deviceContextKey struct{}
+
+// NewDeviceRoutes returns the routes of the device authorization grant (RFC
+// 8628). The OAuth endpoints, used by plugins, take form encoded bodies and
+// answer errors with an OAuth error code. The others, used by the website,
+// require a session.
+//
+//   - POST /oauth/device_authorization: starts a device authorization from
+//     a client_id, an optional scope and an optional device_name.
+//   - POST /oauth/token: answers a device polling with grant_type
+//     urn:ietf:params:oauth:grant-type:device_code, or refreshes tokens with
+//     grant_type refresh_token.
+//   - GET /device?user_code=: shows which plugin waits on a user code.
+//   - POST /device/approve and POST /device/deny: decide on a user code from
+//     a JSON body. Approving requires a stepped up session.
+//   - GET /devices: lists the signed in devices of the customer.
+//   - DELETE /devices/{id}: signs a device of the customer out.
+func NewDeviceRoutes(
+	logger Logger,
+	sessions SessionService,
+	twoFactor TwoFactorService,
+	devices DeviceService,
+) []HTTPRoute {
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequireSession(logger, sessions, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("POST /oauth/device_authorization", deviceAuthorizationHandler(logger, devices)),
+		NewRoute("POST /oauth/token", tokenHandler(logger, devices)),
+		NewRoute("GET /device", auth(deviceRequestHandler(logger, devices))),
+		NewRoute("POST /device/approve",
+			auth(RequireStepUp(logger, twoFactor, decideDeviceHandler(logger, devices, true)))),
+		NewRoute("POST /device/deny", auth(decideDeviceHandler(logger, devices, false))),
+		NewRoute("GET /devices", auth(listDevicesHandler(logger, devices))),
+		NewRoute("DELETE /devices/{id}", auth(revokeDeviceHandler(logger, devices))),
+	}
+}
+
+type deviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func deviceAuthorizationHandler(logger Logger, svc DeviceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := parseForm(w, r)
+		if err != nil {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_request", err)
+			return
+		}
+
+		code, err := svc.Authorize(
+			r.Context(),
+			r.PostForm.Get("client_id"),
+			r.PostForm.Get("scope"),
+			r.PostForm.Get("device_name"),
+		)
+		if err != nil {
+			writeDeviceError(logger, w, err, "failed to start device authorization")
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, deviceCodeResponse{
+			DeviceCode:              code.DeviceCode,
+			UserCode:                code.UserCode,
+			VerificationURI:         code.VerificationURI,
+			VerificationURIComplete: code.VerificationURIComplete,
+			ExpiresIn:               int(time.Until(code.Expires).Round(time.Second) / time.Second),
+			Interval:                int(code.Interval / time.Second),
+		})
+	}
+}
+
+func tokenHandler(logger Logger, svc DeviceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := parseForm(w, r)
+		if err != nil {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_request", err)
+			return
+		}
+
+		clientID := r.PostForm.Get("client_id")
+
+		var tokens service.DeviceTokens
+
+		switch r.PostForm.Get("grant_type") {
+		case grantTypeDeviceCode:
+			tokens, err = svc.Poll(r.Context(), clientID, r.PostForm.Get("device_code"))
+		case grantTypeRefreshToken:
+			tokens, err = svc.Refresh(r.Context(), clientID, r.PostForm.Get("refresh_token"))
+		default:
+			writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", ErrUnsupportedGrantType)
+			return
+		}
+
+		if err != nil {
+			writeDeviceError(logger, w, err, "failed to issue device tokens")
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, tokenResponse{
+			AccessToken:  tokens.AccessToken,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(time.Until(tokens.AccessExpires).Round(time.Second) / time.Second),
+			RefreshToken: tokens.RefreshToken,
+			Scope:        strings.Join(tokens.Device.Scopes, " "),
+		})
+	}
+}
+
+type deviceRequestResponse struct {
+	ClientName string    `json:"client_name"`
+	DeviceName string    `json:"device_name"`
+	Scopes     []string  `json:"scopes"`
+	Expires    time.Time `json:"expires"`
+}
+
+func deviceRequestHandler(logger Logger, svc DeviceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := svc.Request(r.Context(), r.URL.Query().Get("user_code"))
+		if err != nil {
+			writeUserCodeError(logger, w, err, "failed to find device authorization")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, deviceRequestResponse{
+			ClientName: req.ClientName,
+			DeviceName: req.DeviceName,
+			Scopes:     req.Scopes,
+			Expires:    req.Expires,
+		})
+	}
+}
+
+type userCodeRequest struct {
+	UserCode string `json:"user_code"`
+}
+
+func decideDeviceHandler(logger Logger, svc DeviceService, approve bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req userCodeRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		sess, _ := SessionFromContext(r.Context())
+
+		if approve {
+			err = svc.Approve(r.Context(), sess.CustomerID, req.UserCode, ClientIP(r))
+		} else {
+			err = svc.Deny(r.Context(), sess.CustomerID, req.UserCode)
+		}
+
+		if err != nil {
+			writeUserCodeError(logger, w, err, "failed to decide device authorization")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type deviceResponse struct {
+	ID       string    `json:"id"`
+	Client   string    `json:"client"`
+	Name     string    `json:"name"`
+	Scopes   []string  `json:"scopes"`
+	Created  time.Time `json:"created"`
+	LastSeen time.Time `json:"last_seen"`
+	Expires  time.Time `json:"expires"`
+}
+
+func listDevicesHandler(logger Logger, devices DeviceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		list, err := devices.List(r.Context(), sess.CustomerID)
+		if err != nil {
+			logger.Error("failed to list devices", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		res := make([]deviceResponse, 0, len(list))
+		for _, d := range list {
+			res = append(res, deviceResponse{
+				ID:       d.ID,
+				Client:   d.ClientID,
+				Name:     d.Name,
+				Scopes:   d.Scopes,
+				Created:  d.Created,
+				LastSeen: d.LastSeen,
+				Expires:  d.Expires,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func revokeDeviceHandler(logger Logger, devices DeviceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		err := devices.Revoke(r.Context(), sess.CustomerID, r.PathValue("id"), ClientIP(r))
+		if err != nil {
+			if errors.Is(err, service.ErrDeviceNotFound) {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+
+			logger.Error("failed to revoke device", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeviceFromContext returns the device stored by RequireDeviceToken.
+func DeviceFromContext(ctx context.Context) (domain.Device, bool) {
+	d, ok := ctx.Value(deviceContextKey{}).(domain.Device)
+	return d, ok
+}
+
+// RequireDeviceToken only lets requests with a valid bearer access token
+// granting scope through to next, with the device available from
+// DeviceFromContext. Other requests are answered as RFC 6750 says: 401
+// Unauthorized for missing or invalid tokens, 403 Forbidden for tokens
+// lacking the scope.
+func RequireDeviceToken(logger Logger, devices DeviceService, scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			writeError(w, http.StatusUnauthorized, ErrUnauthenticated)
+
+			return
+		}
+
+		d, err := devices.Authenticate(r.Context(), token)
+		if err != nil {
+			if errors.Is(err, service.ErrAccessTokenInvalid) || errors.Is(err, service.ErrAccessTokenExpired) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, err)
+
+				return
+			}
+
+			logger.Error("failed to authenticate device", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		if !slices.Contains(d.Scopes, scope) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			writeError(w, http.StatusForbidden, ErrInsufficientScope)
+
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), deviceContextKey{}, d)))
+	}
+}
+
+// parseForm parses the form encoded body of r, bounded like JSON bodies.
+func parseForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	err := r.ParseForm()
+	if err != nil {
+		return ErrMalformedBody
+	}
+
+	return nil
+}
+
+// writeOAuthError answers with an OAuth 2.0 error response (RFC 6749
+// section 5.2).
+func writeOAuthError(w http.ResponseWriter, status int, code string, err error) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, oauthErrorResponse{Error: code, ErrorDescription: err.Error()})
+}
+
+// writeDeviceError answers a failed OAuth request with the error code RFC
+// 6749 and RFC 8628 assign to err.
+func writeDeviceError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	codes := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{service.ErrUnknownClient, http.StatusUnauthorized, "invalid_client"},
+		{service.ErrInvalidScope, http.StatusBadRequest, "invalid_scope"},
+		{service.ErrDeviceNameTooLong, http.StatusBadRequest, "invalid_request"},
+		{service.ErrAuthorizationPending, http.StatusBadRequest, "authorization_pending"},
+		{service.ErrSlowDown, http.StatusBadRequest, "slow_down"},
+		{service.ErrAccessDenied, http.StatusBadRequest, "access_denied"},
+		{service.ErrDeviceCodeExpired, http.StatusBadRequest, "expired_token"},
+		{service.ErrInvalidGrant, http.StatusBadRequest, "invalid_grant"},
+	}
+
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			writeOAuthError(w, c.status, c.code, err)
+			return
+		}
+	}
+
+	logger.Error(msg, "error", err)
+	writeOAuthError(w, http.StatusInternalServerError, "server_error", errInternal)
+}
+
+func writeUserCodeError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, service.ErrUserCodeInvalid) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	logger.Error(msg, "error", err)
+	writeError(w, http.StatusInternalServerError, errInternal)
+}
+
+const (
+	ErrUnsupportedGrantType HandlerError = "grant type is not supported"
+	ErrInsufficientScope    HandlerError = "access token lacks the required scope"
+)
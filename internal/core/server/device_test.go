@@ -0,0 +1,306 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// fakeDevices answers every call with err, and knows a single access token.
+type fakeDevices struct {
+	err error
+}
+
+func (f fakeDevices) device() domain.Device {
+	now := time.Now()
+
+	return domain.Device{
+		ID:               "device",
+		CustomerID:       1,
+		ClientID:         "microwave",
+		Name:             "Studio Mac",
+		Scopes:           []string{"profile"},
+		RefreshTokenHash: nil,
+		AccessTokenHash:  nil,
+		AccessExpires:    now.Add(time.Hour),
+		Created:          now,
+		LastSeen:         now,
+		Expires:          now.Add(24 * time.Hour),
+	}
+}
+
+func (f fakeDevices) tokens() service.DeviceTokens {
+	return service.DeviceTokens{
+		Device:        f.device(),
+		AccessToken:   "access",
+		RefreshToken:  "refresh",
+		AccessExpires: time.Now().Add(time.Hour),
+	}
+}
+
+func (f fakeDevices) Authorize(context.Context, string, string, string) (service.DeviceCode, error) {
+	return service.DeviceCode{
+		DeviceCode:              "device-code",
+		UserCode:                "BCDF-GHJK",
+		VerificationURI:         "https://brokedaear.com/device",
+		VerificationURIComplete: "https://brokedaear.com/device?user_code=BCDF-GHJK",
+		Expires:                 time.Now().Add(10 * time.Minute),
+		Interval:                5 * time.Second,
+	}, f.err
+}
+
+func (f fakeDevices) Request(context.Context, string) (service.DeviceRequest, error) {
+	return service.DeviceRequest{
+		ClientName: "Microwave",
+		DeviceName: "Studio Mac",
+		Scopes:     []string{"profile"},
+		Expires:    time.Now(),
+	}, f.err
+}
+
+func (f fakeDevices) Approve(context.Context, int, string, string) error {
+	return f.err
+}
+
+func (f fakeDevices) Deny(context.Context, int, string) error {
+	return f.err
+}
+
+func (f fakeDevices) Poll(context.Context, string, string) (service.DeviceTokens, error) {
+	return f.tokens(), f.err
+}
+
+func (f fakeDevices) Refresh(context.Context, string, string) (service.DeviceTokens, error) {
+	return f.tokens(), f.err
+}
+
+func (f fakeDevices) Authenticate(_ context.Context, token string) (domain.Device, error) {
+	if token != "access" {
+		return domain.Device{}, service.ErrAccessTokenInvalid
+	}
+
+	return f.device(), f.err
+}
+
+func (f fakeDevices) List(context.Context, int) ([]domain.Device, error) {
+	return []domain.Device{f.device()}, f.err
+}
+
+func (f fakeDevices) Revoke(context.Context, int, string, string) error {
+	return f.err
+}
+
+func (f fakeDevices) RevokeAll(context.Context, int, string) (int, error) {
+	return 1, f.err
+}
+
+func postForm(h http.Handler, target string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestDeviceRoutes_Token(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		grantType string
+		err       error
+		code      string
+	}{
+		{
+			CaseBase:  test.NewCaseBase("issued", http.StatusOK, false),
+			grantType: "urn:ietf:params:oauth:grant-type:device_code",
+			err:       nil,
+			code:      "",
+		},
+		{
+			CaseBase:  test.NewCaseBase("refreshed", http.StatusOK, false),
+			grantType: "refresh_token",
+			err:       nil,
+			code:      "",
+		},
+		{
+			CaseBase:  test.NewCaseBase("pending", http.StatusBadRequest, false),
+			grantType: "urn:ietf:params:oauth:grant-type:device_code",
+			err:       service.ErrAuthorizationPending,
+			code:      "authorization_pending",
+		},
+		{
+			CaseBase:  test.NewCaseBase("slow down", http.StatusBadRequest, false),
+			grantType: "urn:ietf:params:oauth:grant-type:device_code",
+			err:       service.ErrSlowDown,
+			code:      "slow_down",
+		},
+		{
+			CaseBase:  test.NewCaseBase("unknown client", http.StatusUnauthorized, false),
+			grantType: "refresh_token",
+			err:       service.ErrUnknownClient,
+			code:      "invalid_client",
+		},
+		{
+			CaseBase:  test.NewCaseBase("unsupported grant", http.StatusBadRequest, false),
+			grantType: "password",
+			err:       nil,
+			code:      "unsupported_grant_type",
+		},
+		{
+			CaseBase:  test.NewCaseBase("internal failure", http.StatusInternalServerError, false),
+			grantType: "refresh_token",
+			err:       errors.New("database is down"),
+			code:      "server_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mux := newMux(server.NewDeviceRoutes(nopLogger{}, newSessionService(t), fakeTwoFactor{err: nil}, fakeDevices{err: tt.err})...)
+
+			rec := postForm(mux, "/oauth/token", url.Values{
+				"grant_type":  {tt.grantType},
+				"client_id":   {"microwave"},
+				"device_code": {"device-code"},
+			})
+			assert.Equal(t, rec.Code, tt.Want.(int))
+			assert.Equal(t, rec.Header().Get("Cache-Control"), "no-store")
+			assert.False(t, strings.Contains(rec.Body.String(), "database is down"))
+
+			var res struct {
+				Error       string `json:"error"`
+				AccessToken string `json:"access_token"`
+				TokenType   string `json:"token_type"`
+			}
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, res.Error, tt.code)
+
+			if tt.code == "" {
+				assert.Equal(t, res.AccessToken, "access")
+				assert.Equal(t, res.TokenType, "Bearer")
+			}
+		})
+	}
+}
+
+func TestDeviceRoutes_Authorization(t *testing.T) {
+	mux := newMux(server.NewDeviceRoutes(nopLogger{}, newSessionService(t), fakeTwoFactor{err: nil}, fakeDevices{err: nil})...)
+
+	rec := postForm(mux, "/oauth/device_authorization", url.Values{"client_id": {"microwave"}})
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var res struct {
+		DeviceCode string `json:"device_code"`
+		UserCode   string `json:"user_code"`
+		Interval   int    `json:"interval"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, res.DeviceCode, "device-code")
+	assert.Equal(t, res.UserCode, "BCDF-GHJK")
+	assert.Equal(t, res.Interval, 5)
+}
+
+func TestDeviceRoutes_Website(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusNoContent},
+		{service.ErrUserCodeInvalid, http.StatusNotFound},
+		{errors.New("database is down"), http.StatusInternalServerError},
+	} {
+		sessions := newSessionService(t)
+		mux := newMux(server.NewDeviceRoutes(nopLogger{}, sessions, fakeTwoFactor{err: nil}, fakeDevices{err: tt.err})...)
+
+		rec := post(mux, "/device/approve", `{"user_code":"BCDF-GHJK"}`, "")
+		assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+		token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+		assert.NoError(t, err)
+
+		for _, target := range []string{"/device/approve", "/device/deny"} {
+			rec = post(mux, target, `{"user_code":"BCDF-GHJK"}`, token)
+			assert.Equal(t, rec.Code, tt.want)
+		}
+
+		rec = request(t, mux, http.MethodGet, "/device?user_code=BCDF-GHJK", token)
+		if tt.err == nil {
+			assert.Equal(t, rec.Code, http.StatusOK)
+		} else {
+			assert.Equal(t, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestRequireDeviceToken(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		header string
+		scope  string
+	}{
+		{
+			CaseBase: test.NewCaseBase("granted", http.StatusNoContent, false),
+			header:   "Bearer access",
+			scope:    "profile",
+		},
+		{
+			CaseBase: test.NewCaseBase("missing token", http.StatusUnauthorized, false),
+			header:   "",
+			scope:    "profile",
+		},
+		{
+			CaseBase: test.NewCaseBase("invalid token", http.StatusUnauthorized, false),
+			header:   "Bearer forged",
+			scope:    "profile",
+		},
+		{
+			CaseBase: test.NewCaseBase("insufficient scope", http.StatusForbidden, false),
+			header:   "Bearer access",
+			scope:    "licenses",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			h := server.RequireDeviceToken(nopLogger{}, fakeDevices{err: nil}, tt.scope,
+				func(w http.ResponseWriter, r *http.Request) {
+					d, ok := server.DeviceFromContext(r.Context())
+					assert.True(t, ok)
+					assert.Equal(t, d.ID, "device")
+					w.WriteHeader(http.StatusNoContent)
+				},
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/licenses", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, tt.Want.(int))
+
+			if tt.Want.(int) != http.StatusNoContent {
+				assert.True(t, strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer"))
+			}
+		})
+	}
+}
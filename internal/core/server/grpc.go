@@ -4,10 +4,86 @@
 
 package server
 
-import "io"
+import (
+	"context"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
 
 type GRPCServer interface {
 	io.Closer
 }
 
 // type grpcServer struct{}
+
+// UnaryAccessTokenInterceptor only lets calls with a valid bearer JWT access
+// token in their authorization metadata through, with its claims available
+// from ClaimsFromContext. Other calls fail with codes.Unauthenticated.
+func UnaryAccessTokenInterceptor(logger Logger, tokens TokenVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticateCall(ctx, logger, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamAccessTokenInterceptor is UnaryAccessTokenInterceptor for streams.
+func StreamAccessTokenInterceptor(logger Logger, tokens TokenVerifier) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateCall(ss.Context(), logger, tokens)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &claimsStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticateCall returns ctx with the claims of the access token of the
+// call.
+func authenticateCall(ctx context.Context, logger Logger, tokens TokenVerifier) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get("authorization")
+	if len(values) != 1 {
+		return nil, status.Error(codes.Unauthenticated, ErrUnauthenticated.Error())
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" {
+		return nil, status.Error(codes.Unauthenticated, ErrUnauthenticated.Error())
+	}
+
+	claims, err := tokens.Verify(ctx, token)
+	if err != nil {
+		if invalidAccessToken(err) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		logger.Error("failed to verify access token", "error", err)
+
+		return nil, status.Error(codes.Internal, errInternal.Error())
+	}
+
+	return context.WithValue(ctx, claimsContextKey{}, claims), nil
+}
+
+// claimsStream is a server stream whose context carries access token
+// claims.
+type claimsStream struct {
+	grpc.ServerStream
+
+	ctx context.Context //nolint:containedctx // grpc.ServerStream exposes its context through Context
+}
+
+func (s *claimsStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/server"
+)
+
+// claimsStream is a server stream with a given context.
+type claimsStream struct {
+	grpc.ServerStream
+
+	ctx context.Context //nolint:containedctx // fakes grpc.ServerStream
+}
+
+func (s claimsStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAccessTokenInterceptors(t *testing.T) {
+	tokens := newTokenService(t)
+
+	token, err := tokens.Issue(t.Context(), 1, "microwave", []string{"licenses"})
+	assert.NoError(t, err)
+
+	unary := server.UnaryAccessTokenInterceptor(nopLogger{}, tokens)
+	stream := server.StreamAccessTokenInterceptor(nopLogger{}, tokens)
+
+	for _, tt := range []struct {
+		md   metadata.MD
+		want codes.Code
+	}{
+		{metadata.Pairs("authorization", "Bearer "+token.Token), codes.OK},
+		{metadata.Pairs("authorization", "Bearer forged"), codes.Unauthenticated},
+		{metadata.MD{}, codes.Unauthenticated},
+	} {
+		ctx := metadata.NewIncomingContext(t.Context(), tt.md)
+
+		_, err = unary(ctx, nil, nil, func(ctx context.Context, _ any) (any, error) {
+			claims, ok := server.ClaimsFromContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, claims.CustomerID, 1)
+			assert.True(t, claims.HasScope("licenses"))
+
+			return nil, nil //nolint:nilnil // the reply is not looked at
+		})
+		assert.Equal(t, status.Code(err), tt.want)
+
+		err = stream(nil, claimsStream{ServerStream: nil, ctx: ctx}, nil, func(_ any, ss grpc.ServerStream) error {
+			claims, ok := server.ClaimsFromContext(ss.Context())
+			assert.True(t, ok)
+			assert.Equal(t, claims.ClientID, "microwave")
+
+			return nil
+		})
+		assert.Equal(t, status.Code(err), tt.want)
+	}
+}
@@ -6,14 +6,18 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"io"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/alexliesenfeld/health"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"backend.brokedaear.com/internal/common/telemetry"
 )
 
 // HTTPServer represents an HTTP server that is capable of accepting routes
@@ -21,55 +25,71 @@ import (
 // include endpoints that serve GraphQL or simple barebones requests.
 //
 // This interface also implements the io.Closer interface, for use in global
-// teardown operations.
+// teardown operations. Shutdown is the graceful variant of Close, bounded by
+// the deadline of its context.
 type HTTPServer interface {
 	ListenAndServe(context.Context) error
-	// RegisterRoutes(...HTTPRoute)
+	RegisterRoutes(...HTTPRoute)
+	Shutdown(context.Context) error
 	io.Closer
 }
 
 type httpServer struct {
 	*Base
-	srv *http.Server
+	srv    *http.Server
+	health http.Handler
 }
 
 const httpHealthTimeout = 10 * time.Second
 
-// NewHTTPServer creates a new HTTP server using a logger and a config.
-// The server comes with telemetry enabled by default.
-func NewHTTPServer(ctx context.Context, logger Logger, config *Config) (HTTPServer, error) {
-	const (
-		readTimeout  = 10 * time.Second
-		writeTimeout = 30 * time.Second
-	)
+// NewHTTPServer creates a new HTTP server using a logger, a config, and the
+// telemetry it reports to. The server comes with telemetry enabled by default.
+func NewHTTPServer(logger Logger, config *Config, t telemetry.Telemetry) (HTTPServer, error) {
+	const writeTimeout = 30 * time.Second
 
-	b, err := NewBase(ctx, logger, config)
+	b, err := NewBase(logger, config, t)
 	if err != nil {
 		return nil, err
 	}
 
-	address := net.JoinHostPort(config.Addr.String(), config.Port.String())
+	return newHTTPServer(b, writeTimeout, nil)
+}
+
+// newHTTPServer binds the listener of b and creates a server around it that
+// only serves the health check until routes are registered. A non-nil
+// tlsConfig serves over TLS.
+func newHTTPServer(b *Base, writeTimeout time.Duration, tlsConfig *tls.Config) (*httpServer, error) {
+	const readTimeout = 10 * time.Second
 
-	b.listener, err = net.Listen("tcp", address)
+	address := net.JoinHostPort(b.config.Addr.String(), b.config.Port.String())
+
+	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		return nil, err
 	}
 
-	mux := http.NewServeMux()
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
 
-	checker := health.NewChecker(health.WithCacheDuration(1*time.Second), health.WithTimeout(httpHealthTimeout))
+	b.listener = listener
 
-	mux.Handle("/health", health.NewHandler(checker))
+	checker := health.NewChecker(health.WithCacheDuration(1*time.Second), health.WithTimeout(httpHealthTimeout))
 
-	return &httpServer{
+	s := &httpServer{
 		Base: b,
 		srv: &http.Server{
 			IdleTimeout:  time.Minute,
 			ReadTimeout:  readTimeout,
 			WriteTimeout: writeTimeout,
-			Handler:      mux,
+			TLSConfig:    tlsConfig,
 		},
-	}, nil
+		health: health.NewHandler(checker),
+	}
+
+	s.RegisterRoutes()
+
+	return s, nil
 }
 
 // ListenAndServe listens to specified route endpoints given by route functions
@@ -97,6 +117,8 @@ func (s httpServer) ListenAndServe(ctx context.Context) error {
 	return serverError
 }
 
+// Close shuts the server down gracefully, waiting a bounded time for open
+// connections to finish.
 func (s httpServer) Close() error {
 	const shutdownTimeout = 20 * time.Second
 	shutdownCtx, shutdownCancel := context.WithDeadline(
@@ -106,7 +128,13 @@ func (s httpServer) Close() error {
 
 	defer shutdownCancel()
 
-	err := s.srv.Shutdown(shutdownCtx)
+	return s.Shutdown(shutdownCtx)
+}
+
+// Shutdown stops accepting connections and waits for open connections to
+// finish until ctx is done, after which the remaining connections are killed.
+func (s httpServer) Shutdown(ctx context.Context) error {
+	err := s.srv.Shutdown(ctx)
 	if err != nil {
 		s.logger.Warn("failed to shutdown http server, killing", "err", err)
 		err = s.srv.Close()
@@ -116,7 +144,7 @@ func (s httpServer) Close() error {
 	}
 
 	err = s.listener.Close()
-	if err != nil {
+	if err != nil && !errors.Is(err, net.ErrClosed) {
 		return errors.Wrap(err, "failed to close http listener")
 	}
 
@@ -130,15 +158,47 @@ type HTTPRoute interface {
 	Route() http.HandlerFunc
 }
 
+// Middleware wraps a handler, such as to authenticate requests before
+// they reach it.
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// NewRouteGroup mounts routes under prefix, each behind middleware. The
+// patterns of routes are relative to prefix: with prefix /admin, the route
+// "GET /roles" is served at "GET /admin/roles".
+func NewRouteGroup(prefix string, middleware Middleware, routes ...HTTPRoute) []HTTPRoute {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	group := make([]HTTPRoute, 0, len(routes))
+
+	for _, r := range routes {
+		method, path, ok := strings.Cut(r.String(), " ")
+		if !ok {
+			method, path = "", method
+		}
+
+		pattern := prefix + path
+		if method != "" {
+			pattern = method + " " + pattern
+		}
+
+		group = append(group, NewRoute(pattern, middleware(r.Route())))
+	}
+
+	return group
+}
+
+// RegisterRoutes replaces the routes served by the server. The health check
+// is always served at /health. Routes must be registered before the server
+// starts listening.
 func (s httpServer) RegisterRoutes(routes ...HTTPRoute) {
 	s.srv.Handler = s.registerRoutes(routes...)
 }
 
-// registerRoutes needs to be REFACTORED.
-// TODO: Refactor registerRoutes
 func (s httpServer) registerRoutes(routes ...HTTPRoute) http.Handler {
 	mux := http.NewServeMux()
 
+	mux.Handle("/health", s.health)
+
 	handleFunc := func(pattern string, handlerFunc http.HandlerFunc) {
 		mux.HandleFunc(pattern, handlerFunc)
 	}
@@ -0,0 +1,301 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// LicenseService issues, looks up and revokes the licenses of plugins.
+type LicenseService interface {
+	Lookup(ctx context.Context, key string) (service.SignedLicense, error)
+	License(ctx context.Context, key string) (domain.License, error)
+	Lineage(ctx context.Context, key string) ([]domain.License, error)
+	CustomerLicenses(ctx context.Context, customerID int) ([]service.SignedLicense, error)
+	OrderLicenses(ctx context.Context, orderID int) ([]domain.License, error)
+	Revoke(ctx context.Context, staffID int, key, reason, ip string) (domain.License, error)
+	JWKS() jwt.JWKS
+}
+
+// NewLicenseRoutes returns the license routes of plugins and customers.
+//
+//   - GET /licenses/keys: the public keys that verify license tokens, for
+//     plugins to embed.
+//   - POST /licenses/lookup: returns the license with a key, as typed by
+//     a customer, with its token when it is valid. Plugins call it to
+//     activate and to learn of revocations.
+//   - GET /licenses: lists the licenses of the customer of the session,
+//     with their tokens. Customers who have not verified their email are
+//     answered with 403.
+func NewLicenseRoutes(
+	logger Logger,
+	sessions SessionService,
+	verifications VerificationService,
+	licenses LicenseService,
+) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("GET /licenses/keys", jwksHandler(licenses)),
+		NewRoute("POST /licenses/lookup", lookupLicenseHandler(logger, licenses)),
+		NewRoute("GET /licenses", RequireSession(logger, sessions,
+			RequireVerified(logger, verifications, customerLicensesHandler(logger, licenses)))),
+	}
+}
+
+// NewLicenseAdminRoutes returns the admin routes of licenses. Reading
+// licenses requires the customers:view permission, and revoking them
+// licenses:manage.
+//
+//   - GET /licenses: lists the licenses of the customer query parameter,
+//     or of the order query parameter.
+//   - GET /licenses/{key}: returns a license.
+//   - GET /licenses/{key}/lineage: returns a license followed by the
+//     licenses it was upgraded from, newest first.
+//   - POST /licenses/{key}/revoke: revokes a license, for a reason. It
+//     requires a stepped up session.
+func NewLicenseAdminRoutes(
+	logger Logger,
+	authz Authorizer,
+	twoFactor TwoFactorService,
+	licenses LicenseService,
+) []HTTPRoute {
+	require := func(p domain.Permission, h http.HandlerFunc) http.HandlerFunc {
+		return RequirePermission(logger, authz, p, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /licenses", require(domain.PermissionViewCustomers, listLicensesHandler(logger, licenses))),
+		NewRoute("GET /licenses/{key}", require(domain.PermissionViewCustomers, licenseHandler(logger, licenses))),
+		NewRoute("GET /licenses/{key}/lineage",
+			require(domain.PermissionViewCustomers, licenseLineageHandler(logger, licenses))),
+		NewRoute("POST /licenses/{key}/revoke",
+			require(domain.PermissionManageLicenses,
+				RequireStepUp(logger, twoFactor, revokeLicenseHandler(logger, licenses)))),
+	}
+}
+
+type licenseResponse struct {
+	Key       string                `json:"key"`
+	OrderID   int                   `json:"order_id"`
+	ProductID int                   `json:"product_id"`
+	Version   string                `json:"version,omitempty"`
+	Edition   domain.LicenseEdition `json:"edition"`
+	Issued    time.Time             `json:"issued"`
+	Expires   *time.Time            `json:"expires,omitempty"`
+	Revoked   *time.Time            `json:"revoked,omitempty"`
+	Valid     bool                  `json:"valid"`
+
+	// Token is the signed license, missing when it is not valid.
+	Token string `json:"token,omitempty"`
+}
+
+// adminLicenseResponse is a license as staff see it, with who owns it, why
+// it was revoked and the license it was upgraded from.
+type adminLicenseResponse struct {
+	licenseResponse
+
+	ID           int    `json:"id"`
+	CustomerID   int    `json:"customer_id"`
+	RevokeReason string `json:"revoke_reason,omitempty"`
+	UpgradedFrom int    `json:"upgraded_from,omitempty"`
+}
+
+func newLicenseResponse(l domain.License, token string) licenseResponse {
+	res := licenseResponse{
+		Key:       l.Key,
+		OrderID:   l.OrderID,
+		ProductID: l.ProductID,
+		Version:   l.Version,
+		Edition:   l.Edition,
+		Issued:    l.Issued,
+		Expires:   nil,
+		Revoked:   nil,
+		Valid:     l.Valid(time.Now()),
+		Token:     token,
+	}
+
+	if !l.Expires.IsZero() {
+		res.Expires = &l.Expires
+	}
+
+	if !l.Revoked.IsZero() {
+		res.Revoked = &l.Revoked
+	}
+
+	return res
+}
+
+func newAdminLicenseResponse(l domain.License) adminLicenseResponse {
+	return adminLicenseResponse{
+		licenseResponse: newLicenseResponse(l, ""),
+		ID:              l.ID,
+		CustomerID:      l.CustomerID,
+		RevokeReason:    l.RevokeReason,
+		UpgradedFrom:    l.UpgradedFrom,
+	}
+}
+
+type licenseLookupRequest struct {
+	Key string `json:"key"`
+}
+
+type licenseRevokeRequest struct {
+	Reason string `json:"reason"`
+}
+
+func lookupLicenseHandler(logger Logger, licenses LicenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req licenseLookupRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		l, err := licenses.Lookup(r.Context(), req.Key)
+		if err != nil {
+			writeLicenseError(logger, w, err, "failed to look license up")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newLicenseResponse(l.License, l.Token))
+	}
+}
+
+func customerLicensesHandler(logger Logger, licenses LicenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		list, err := licenses.CustomerLicenses(r.Context(), sess.CustomerID)
+		if err != nil {
+			writeLicenseError(logger, w, err, "failed to list licenses")
+			return
+		}
+
+		res := make([]licenseResponse, 0, len(list))
+		for _, l := range list {
+			res = append(res, newLicenseResponse(l.License, l.Token))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func listLicensesHandler(logger Logger, licenses LicenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var list []domain.License
+
+		query := r.URL.Query()
+
+		customerID, customerErr := strconv.Atoi(query.Get("customer"))
+		orderID, orderErr := strconv.Atoi(query.Get("order"))
+
+		switch {
+		case customerErr == nil:
+			signed, err := licenses.CustomerLicenses(r.Context(), customerID)
+			if err != nil {
+				writeLicenseError(logger, w, err, "failed to list licenses")
+				return
+			}
+
+			for _, l := range signed {
+				list = append(list, l.License)
+			}
+		case orderErr == nil:
+			var err error
+
+			list, err = licenses.OrderLicenses(r.Context(), orderID)
+			if err != nil {
+				writeLicenseError(logger, w, err, "failed to list licenses")
+				return
+			}
+		default:
+			writeError(w, http.StatusUnprocessableEntity, ErrLicenseQuery)
+			return
+		}
+
+		res := make([]adminLicenseResponse, 0, len(list))
+		for _, l := range list {
+			res = append(res, newAdminLicenseResponse(l))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func licenseHandler(logger Logger, licenses LicenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l, err := licenses.License(r.Context(), r.PathValue("key"))
+		if err != nil {
+			writeLicenseError(logger, w, err, "failed to find license")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAdminLicenseResponse(l))
+	}
+}
+
+func licenseLineageHandler(logger Logger, licenses LicenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lineage, err := licenses.Lineage(r.Context(), r.PathValue("key"))
+		if err != nil {
+			writeLicenseError(logger, w, err, "failed to find license lineage")
+			return
+		}
+
+		res := make([]adminLicenseResponse, 0, len(lineage))
+		for _, l := range lineage {
+			res = append(res, newAdminLicenseResponse(l))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func revokeLicenseHandler(logger Logger, licenses LicenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		var req licenseRevokeRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		l, err := licenses.Revoke(r.Context(), sess.CustomerID, r.PathValue("key"), req.Reason, ClientIP(r))
+		if err != nil {
+			writeLicenseError(logger, w, err, "failed to revoke license")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAdminLicenseResponse(l))
+	}
+}
+
+func writeLicenseError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	var keyErr domain.LicenseError
+
+	switch {
+	case errors.Is(err, service.ErrLicenseNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.As(err, &keyErr):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		logger.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
+
+const ErrLicenseQuery HandlerError = "licenses are listed by customer or by order"
@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+type licenseJSON struct {
+	Key          string `json:"key"`
+	OrderID      int    `json:"order_id"`
+	Valid        bool   `json:"valid"`
+	Token        string `json:"token"`
+	CustomerID   int    `json:"customer_id"`
+	RevokeReason string `json:"revoke_reason"`
+}
+
+// newLicenseTest returns a license service over newLicenseStore, and the
+// keys of its licenses.
+func newLicenseTest(t *testing.T) (*service.LicenseService, []string) {
+	t.Helper()
+
+	store, keys := newLicenseStore(t)
+	licenses, _ := newLicenseService(t, store)
+
+	return licenses, keys
+}
+
+// newLicenseService returns a license service over store, and the key ring
+// signing its tokens.
+func newLicenseService(t *testing.T, store *dal.MemoryLicenses) (*service.LicenseService, *jwt.KeyRing) {
+	t.Helper()
+
+	ring, err := service.NewLicenseKeyRing()
+	assert.NoError(t, err)
+
+	licenses, err := service.NewLicenseService(store, nil, dal.NewMemoryAuditLog(), ring, testLicenseConfig)
+	assert.NoError(t, err)
+
+	return licenses, ring
+}
+
+// testLicenseConfig configures the license services of tests.
+var testLicenseConfig = service.LicenseConfig{Issuer: "https://brokedaear.com", Validity: 0}
+
+// newLicenseStore returns a license store with a license of customer 1 for
+// order 1, and one of customer 2 for order 2, and their keys.
+func newLicenseStore(t *testing.T) (*dal.MemoryLicenses, []string) {
+	t.Helper()
+
+	store := dal.NewMemoryLicenses()
+	keys := make([]string, 0, 2)
+
+	for _, id := range []int{1, 2} {
+		l, err := store.CreateLicense(t.Context(), domain.License{
+			ID:           0,
+			Key:          domain.NewLicenseKey(),
+			OrderID:      id,
+			Line:         0,
+			Seat:         0,
+			CustomerID:   id,
+			ProductID:    1,
+			Version:      "1.2.0",
+			Edition:      domain.EditionStandard,
+			Issued:       time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+			Expires:      time.Time{},
+			Revoked:      time.Time{},
+			RevokeReason: "",
+			UpgradedFrom: 0,
+		})
+		assert.NoError(t, err)
+
+		keys = append(keys, l.Key)
+	}
+
+	return store, keys
+}
+
+func TestLicenseRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	licenses, keys := newLicenseTest(t)
+	verifications := &fakeVerifications{sendErr: nil, verified: map[int]bool{1: true}}
+	mux := newMux(server.NewLicenseRoutes(nopLogger{}, sessions, verifications, licenses)...)
+
+	rec := request(t, mux, http.MethodGet, "/licenses/keys", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Cache-Control"), "public, max-age=300")
+
+	var jwks jwt.JWKS
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+
+	var license licenseJSON
+
+	// Keys are looked up however customers type them, and their token
+	// verifies with the published keys.
+	rec = send(t, mux, http.MethodPost, "/licenses/lookup",
+		`{"key":"`+strings.ToLower(strings.ReplaceAll(keys[0], "-", " "))+`"}`, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &license))
+	assert.Equal(t, license.Key, keys[0])
+	assert.True(t, license.Valid)
+	assert.NoError(t, jwks.Verify(license.Token, &struct{}{}))
+
+	// Plugins are not told who owns the license.
+	assert.Equal(t, license.CustomerID, 0)
+
+	rec = request(t, mux, http.MethodGet, "/licenses", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	unverified, _, err := sessions.Create(t.Context(), 2, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	rec = request(t, mux, http.MethodGet, "/licenses", unverified)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	var list []licenseJSON
+
+	rec = request(t, mux, http.MethodGet, "/licenses", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].Key, keys[0])
+	assert.NotEqual(t, list[0].Token, "")
+
+	for _, tt := range []struct {
+		name, body string
+		want       int
+	}{
+		{"malformed body", `{`, http.StatusBadRequest},
+		{"mistyped key", `{"key":"` + keys[0][:len(keys[0])-1] + `"}`, http.StatusUnprocessableEntity},
+		{"unknown key", `{"key":"` + domain.NewLicenseKey() + `"}`, http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send(t, mux, http.MethodPost, "/licenses/lookup", tt.body, "")
+			assert.Equal(t, rec.Code, tt.want)
+		})
+	}
+}
+
+func TestLicenseAdminRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), dal.NewMemoryAuditLog())
+	licenses, keys := newLicenseTest(t)
+	ctx := t.Context()
+
+	mux := newMux(server.NewAdminGroup(nopLogger{}, sessions, authz,
+		server.NewLicenseAdminRoutes(nopLogger{}, authz, fakeTwoFactor{err: nil}, licenses)...)...)
+
+	token, _, err := sessions.Create(ctx, 5, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, authz.Grant(ctx, 5, domain.RoleSupport, ""))
+
+	var list []licenseJSON
+
+	rec := send(t, mux, http.MethodGet, "/admin/licenses?customer=2", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].Key, keys[1])
+	assert.Equal(t, list[0].CustomerID, 2)
+
+	// Staff do not get tokens.
+	assert.Equal(t, list[0].Token, "")
+
+	rec = send(t, mux, http.MethodGet, "/admin/licenses?order=1", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].Key, keys[0])
+
+	var license licenseJSON
+
+	rec = send(t, mux, http.MethodPost, "/admin/licenses/"+keys[0]+"/revoke", `{"reason":"chargeback"}`, token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &license))
+	assert.False(t, license.Valid)
+	assert.Equal(t, license.RevokeReason, "chargeback")
+
+	rec = send(t, mux, http.MethodGet, "/admin/licenses/"+strings.ToLower(keys[0]), "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &license))
+	assert.False(t, license.Valid)
+
+	// A license that was not upgraded from another is its whole lineage.
+	rec = send(t, mux, http.MethodGet, "/admin/licenses/"+keys[1]+"/lineage", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].Key, keys[1])
+
+	for _, tt := range []struct {
+		name, target, body string
+		want               int
+	}{
+		{"no filter", "/admin/licenses", "", http.StatusUnprocessableEntity},
+		{"mistyped key", "/admin/licenses/ABCDE", "", http.StatusUnprocessableEntity},
+		{"unknown key", "/admin/licenses/" + domain.NewLicenseKey(), "", http.StatusNotFound},
+		{"malformed body", "/admin/licenses/" + keys[1] + "/revoke", `{`, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			method := http.MethodPost
+			if tt.body == "" {
+				method = http.MethodGet
+			}
+
+			rec := send(t, mux, method, tt.target, tt.body, token)
+			assert.Equal(t, rec.Code, tt.want)
+		})
+	}
+}
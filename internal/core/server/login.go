@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// LoginService logs customers in.
+type LoginService interface {
+	Login(ctx context.Context, req service.LoginRequest) (service.LoginResult, error)
+	CompleteLogin(ctx context.Context, req service.SecondFactorRequest) (service.LoginResult, error)
+}
+
+// NewLoginRoutes returns the login routes. Throttled and locked out attempts
+// are answered with 429 and a Retry-After header.
+//
+//   - POST /login: logs a customer in from a JSON body with an email and a
+//     password, sets the session cookie and answers with the customer.
+//     Customers with two-factor authentication are answered with 202
+//     Accepted and a challenge instead.
+//   - POST /login/2fa: completes a challenge from a JSON body with it and a
+//     TOTP or recovery code, sets the session cookie and answers with the
+//     customer.
+//
+// Guests who log in with a cart have it merged into the cart of the
+// customer.
+func NewLoginRoutes(logger Logger, login LoginService, carts CartMerger) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("POST /login", loginHandler(logger, login, carts)),
+		NewRoute("POST /login/2fa", secondFactorHandler(logger, login, carts)),
+	}
+}
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type challengeResponse struct {
+	Challenge string    `json:"challenge"`
+	Expires   time.Time `json:"expires"`
+}
+
+type secondFactorRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+func loginHandler(logger Logger, svc LoginService, carts CartMerger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req loginRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := svc.Login(r.Context(), service.LoginRequest{
+			Email:        domain.RegisteredCustomerEmail(req.Email),
+			Password:     domain.RegisteredCustomerPassword(req.Password),
+			Meta:         SessionMeta(r),
+			SessionToken: currentSessionToken(r),
+		})
+		if err != nil {
+			writeLoginError(logger, w, err)
+			return
+		}
+
+		if res.Challenge != "" {
+			writeJSON(w, http.StatusAccepted, challengeResponse{Challenge: res.Challenge, Expires: res.ChallengeExpires})
+			return
+		}
+
+		SetSessionCookie(w, res.Token, res.Session.AbsoluteExpires)
+		mergeGuestCart(logger, w, r, carts, res.Customer.ID)
+		writeJSON(w, http.StatusOK, newCustomerResponse(res.Customer))
+	}
+}
+
+func secondFactorHandler(logger Logger, svc LoginService, carts CartMerger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req secondFactorRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := svc.CompleteLogin(r.Context(), service.SecondFactorRequest{
+			Challenge:    req.Challenge,
+			Code:         req.Code,
+			Meta:         SessionMeta(r),
+			SessionToken: currentSessionToken(r),
+		})
+		if err != nil {
+			writeLoginError(logger, w, err)
+			return
+		}
+
+		SetSessionCookie(w, res.Token, res.Session.AbsoluteExpires)
+		mergeGuestCart(logger, w, r, carts, res.Customer.ID)
+		writeJSON(w, http.StatusOK, newCustomerResponse(res.Customer))
+	}
+}
+
+// currentSessionToken returns the session token the client already holds,
+// if any.
+func currentSessionToken(r *http.Request) string {
+	c, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return ""
+	}
+
+	return c.Value
+}
+
+func writeLoginError(logger Logger, w http.ResponseWriter, err error) {
+	var retryErr *service.RetryError
+
+	switch {
+	case errors.As(err, &retryErr):
+		writeRetry(w, retryErr)
+	case errors.Is(err, domain.ErrInvalidCredentials),
+		errors.Is(err, service.ErrInvalidCode),
+		errors.Is(err, service.ErrChallengeInvalid),
+		errors.Is(err, service.ErrChallengeExpired),
+		errors.Is(err, service.ErrTwoFactorDisabled):
+		writeError(w, http.StatusUnauthorized, err)
+	default:
+		logger.Error("failed to log customer in", "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
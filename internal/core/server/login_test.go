@@ -0,0 +1,216 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// fakeLogin answers every call with err, and with a challenge when
+// challenge is set.
+type fakeLogin struct {
+	err       error
+	challenge string
+}
+
+func (f fakeLogin) Login(_ context.Context, req service.LoginRequest) (service.LoginResult, error) {
+	if f.err != nil {
+		return service.LoginResult{}, f.err
+	}
+
+	if f.challenge != "" {
+		return service.LoginResult{
+			Customer:         domain.Customer{},
+			Session:          domain.Session{},
+			Token:            "",
+			Challenge:        f.challenge,
+			ChallengeExpires: time.Now().Add(5 * time.Minute),
+		}, nil
+	}
+
+	return f.result(req.Meta), nil
+}
+
+func (f fakeLogin) CompleteLogin(_ context.Context, req service.SecondFactorRequest) (service.LoginResult, error) {
+	if f.err != nil {
+		return service.LoginResult{}, f.err
+	}
+
+	if req.Challenge != f.challenge {
+		return service.LoginResult{}, service.ErrChallengeInvalid
+	}
+
+	return f.result(req.Meta), nil
+}
+
+func (f fakeLogin) result(meta service.SessionMeta) service.LoginResult {
+	now := time.Now()
+
+	return service.LoginResult{
+		Customer: domain.Customer{ID: 1, Email: "jane@example.com", HashedPassword: nil, Created: now, Verified: true, PaymentCustomerID: ""},
+		Session: domain.Session{
+			ID:              "id",
+			TokenHash:       nil,
+			CustomerID:      1,
+			Created:         now,
+			LastSeen:        now,
+			Expires:         now.Add(time.Hour),
+			AbsoluteExpires: now.Add(2 * time.Hour),
+			UserAgent:       meta.UserAgent,
+			IP:              meta.IP,
+			SteppedUp:       time.Time{},
+		},
+		Token:            "token",
+		Challenge:        "",
+		ChallengeExpires: time.Time{},
+	}
+}
+
+// fakeMerger records the guest carts it is asked to merge, and fails with
+// err.
+type fakeMerger struct {
+	err    error
+	merged []string
+}
+
+func (f *fakeMerger) MergeGuestCart(_ context.Context, _ int, guestToken string) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	f.merged = append(f.merged, guestToken)
+
+	return nil
+}
+
+func TestLoginRoutes_Login(t *testing.T) {
+	const body = `{"email":"jane@example.com","password":"correct horse battery"}`
+
+	tests := []struct {
+		test.CaseBase
+		err        error
+		retryAfter string
+	}{
+		{
+			CaseBase:   test.NewCaseBase("logged in", http.StatusOK, false),
+			err:        nil,
+			retryAfter: "",
+		},
+		{
+			CaseBase:   test.NewCaseBase("invalid credentials", http.StatusUnauthorized, false),
+			err:        domain.ErrInvalidCredentials,
+			retryAfter: "",
+		},
+		{
+			CaseBase:   test.NewCaseBase("throttled", http.StatusTooManyRequests, false),
+			err:        &service.RetryError{Err: service.ErrLoginThrottled, RetryAfter: 1500 * time.Millisecond},
+			retryAfter: "2",
+		},
+		{
+			CaseBase:   test.NewCaseBase("locked", http.StatusTooManyRequests, false),
+			err:        &service.RetryError{Err: service.ErrAccountLocked, RetryAfter: 10 * time.Minute},
+			retryAfter: "600",
+		},
+		{
+			CaseBase:   test.NewCaseBase("internal failure", http.StatusInternalServerError, false),
+			err:        errors.New("database is down"),
+			retryAfter: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mux := newMux(server.NewLoginRoutes(nopLogger{}, fakeLogin{err: tt.err, challenge: ""}, &fakeMerger{err: nil, merged: nil})...)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, tt.Want.(int))
+			assert.Equal(t, rec.Header().Get("Retry-After"), tt.retryAfter)
+			assert.False(t, strings.Contains(rec.Body.String(), "database is down"))
+
+			cookies := rec.Result().Cookies()
+			if tt.err != nil {
+				assert.Equal(t, len(cookies), 0)
+				return
+			}
+
+			assert.Equal(t, len(cookies), 1)
+			assert.Equal(t, cookies[0].Name, server.SessionCookieName)
+			assert.Equal(t, cookies[0].Value, "token")
+		})
+	}
+}
+
+func TestLoginRoutes_TwoFactor(t *testing.T) {
+	mux := newMux(server.NewLoginRoutes(nopLogger{}, fakeLogin{err: nil, challenge: "challenge"}, &fakeMerger{err: nil, merged: nil})...)
+
+	// The password alone earns a challenge, not a session.
+	rec := post(mux, "/login", `{"email":"jane@example.com","password":"correct horse battery"}`, "")
+	assert.Equal(t, rec.Code, http.StatusAccepted)
+	assert.Equal(t, len(rec.Result().Cookies()), 0)
+
+	var res struct {
+		Challenge string `json:"challenge"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, res.Challenge, "challenge")
+
+	rec = post(mux, "/login/2fa", `{"challenge":"forged","code":"123456"}`, "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+	assert.Equal(t, len(rec.Result().Cookies()), 0)
+
+	rec = post(mux, "/login/2fa", `{"challenge":"challenge","code":"123456"}`, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].Value, "token")
+}
+
+func TestLoginRoutes_MergeGuestCart(t *testing.T) {
+	const body = `{"email":"jane@example.com","password":"correct horse battery"}`
+
+	login := func(merger *fakeMerger) *httptest.ResponseRecorder {
+		mux := newMux(server.NewLoginRoutes(nopLogger{}, fakeLogin{err: nil, challenge: ""}, merger)...)
+
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: server.CartCookieName, Value: "cart"}) //nolint:exhaustruct // request cookie
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	merger := &fakeMerger{err: nil, merged: nil}
+	rec := login(merger)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, strings.Join(merger.merged, ","), "cart")
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 2)
+	assert.Equal(t, cookies[1].Name, server.CartCookieName)
+	assert.Equal(t, cookies[1].MaxAge, -1)
+
+	// Failing to merge does not fail the login, and keeps the guest cart.
+	rec = login(&fakeMerger{err: errors.New("database is down"), merged: nil})
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, len(rec.Result().Cookies()), 1)
+}
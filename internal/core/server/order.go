@@ -0,0 +1,361 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// orderListLimit bounds how many orders the admin routes list at once.
+const orderListLimit = 100
+
+// OrderService keeps track of orders and moves them along their state
+// machine.
+type OrderService interface {
+	CustomerOrders(ctx context.Context, customerID int) ([]domain.Order, error)
+	CustomerOrder(ctx context.Context, customerID, id int) (domain.Order, error)
+	Order(ctx context.Context, id int) (domain.Order, error)
+	Orders(ctx context.Context, status domain.OrderStatus, limit int) ([]domain.Order, error)
+	History(ctx context.Context, id int) ([]domain.OrderTransition, error)
+	Fulfill(ctx context.Context, staffID, id int, reason string) (domain.Order, error)
+	Cancel(ctx context.Context, staffID, id int, reason string) (domain.Order, error)
+	Refund(ctx context.Context, staffID, id int, amount domain.Money, reason string) (domain.Order, error)
+}
+
+// NewOrderRoutes returns the order history routes of customers. They
+// require a session, and only ever show the orders of its customer.
+//
+//   - GET /orders: lists the orders of the customer, most recent first.
+//   - GET /orders/{id}: returns an order, with the history of its status.
+func NewOrderRoutes(logger Logger, sessions SessionService, orders OrderService) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("GET /orders", RequireSession(logger, sessions, customerOrdersHandler(logger, orders))),
+		NewRoute("GET /orders/{id}", RequireSession(logger, sessions, customerOrderHandler(logger, orders))),
+	}
+}
+
+// NewOrderAdminRoutes returns the admin routes of orders. Listing and
+// reading orders requires the customers:view permission, fulfilling them
+// orders:manage, and canceling or refunding them orders:refund.
+//
+//   - GET /orders: lists the most recent orders with the status query
+//     parameter, paid by default: the orders waiting to be fulfilled.
+//   - GET /orders/{id}: returns an order, with every transition it made.
+//   - POST /orders/{id}/fulfill: marks a paid order fulfilled.
+//   - POST /orders/{id}/cancel: cancels an order that was not fulfilled,
+//     refunding what was paid.
+//   - POST /orders/{id}/refund: refunds an amount of an order, or what is
+//     left of it without one.
+//
+// Actions take a reason, recorded in the history of the order. Those the
+// order cannot make from its status are answered with 409.
+func NewOrderAdminRoutes(logger Logger, authz Authorizer, orders OrderService) []HTTPRoute {
+	require := func(p domain.Permission, h http.HandlerFunc) http.HandlerFunc {
+		return RequirePermission(logger, authz, p, h)
+	}
+
+	fulfill := func(ctx context.Context, staffID, id int, req orderActionRequest) (domain.Order, error) {
+		return orders.Fulfill(ctx, staffID, id, req.Reason)
+	}
+	cancel := func(ctx context.Context, staffID, id int, req orderActionRequest) (domain.Order, error) {
+		return orders.Cancel(ctx, staffID, id, req.Reason)
+	}
+	refund := func(ctx context.Context, staffID, id int, req orderActionRequest) (domain.Order, error) {
+		return orders.Refund(ctx, staffID, id, req.amount(), req.Reason)
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /orders", require(domain.PermissionViewCustomers, listOrdersHandler(logger, orders))),
+		NewRoute("GET /orders/{id}", require(domain.PermissionViewCustomers, orderHandler(logger, orders))),
+		NewRoute("POST /orders/{id}/fulfill", require(domain.PermissionManageOrders, orderActionHandler(logger, fulfill))),
+		NewRoute("POST /orders/{id}/cancel", require(domain.PermissionRefundOrders, orderActionHandler(logger, cancel))),
+		NewRoute("POST /orders/{id}/refund", require(domain.PermissionRefundOrders, orderActionHandler(logger, refund))),
+	}
+}
+
+type orderResponse struct {
+	ID       int                 `json:"id"`
+	Status   domain.OrderStatus  `json:"status"`
+	Lines    []orderLineResponse `json:"lines"`
+	Total    priceJSON           `json:"total"`
+	Refunded priceJSON           `json:"refunded"`
+	Created  time.Time           `json:"created"`
+	Updated  time.Time           `json:"updated"`
+
+	// History is the history of the status of the order, oldest first. It
+	// is missing from lists.
+	History []orderTransitionResponse `json:"history,omitempty"`
+}
+
+// adminOrderResponse is an order as staff see it, with what refers to it
+// at the payment provider.
+type adminOrderResponse struct {
+	orderResponse
+
+	CustomerID int    `json:"customer_id"`
+	CheckoutID string `json:"checkout_id"`
+	PaymentID  string `json:"payment_id,omitempty"`
+}
+
+type orderLineResponse struct {
+	ProductID int                `json:"product_id"`
+	Type      domain.ProductType `json:"type"`
+	Name      string             `json:"name"`
+	Version   string             `json:"version,omitempty"`
+	UnitPrice priceJSON          `json:"unit_price"`
+	Quantity  int                `json:"quantity"`
+	Gift      bool               `json:"gift"`
+}
+
+type orderTransitionResponse struct {
+	From domain.OrderStatus `json:"from,omitempty"`
+	To   domain.OrderStatus `json:"to"`
+	Time time.Time          `json:"time"`
+
+	// Actor and Reason are only shown to staff.
+	Actor  string `json:"actor,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func newOrderResponse(o domain.Order, locale string) orderResponse {
+	res := orderResponse{
+		ID:       o.ID,
+		Status:   o.Status,
+		Lines:    make([]orderLineResponse, 0, len(o.Lines)),
+		Total:    newPriceJSON(o.Total, domain.RegionDefault, locale),
+		Refunded: newPriceJSON(o.Refunded, domain.RegionDefault, locale),
+		Created:  o.Created,
+		Updated:  o.Updated,
+		History:  nil,
+	}
+
+	for _, l := range o.Lines {
+		res.Lines = append(res.Lines, orderLineResponse{
+			ProductID: l.ProductID,
+			Type:      l.ProductType,
+			Name:      l.Name,
+			Version:   l.Version,
+			UnitPrice: newPriceJSON(l.UnitPrice, domain.RegionDefault, locale),
+			Quantity:  l.Quantity,
+			Gift:      l.Gift,
+		})
+	}
+
+	return res
+}
+
+func newAdminOrderResponse(o domain.Order, locale string) adminOrderResponse {
+	return adminOrderResponse{
+		orderResponse: newOrderResponse(o, locale),
+		CustomerID:    o.CustomerID,
+		CheckoutID:    o.CheckoutID,
+		PaymentID:     o.PaymentID,
+	}
+}
+
+// newHistoryResponse returns the history of an order, with who made each
+// transition and why when staff is set.
+func newHistoryResponse(history []domain.OrderTransition, staff bool) []orderTransitionResponse {
+	res := make([]orderTransitionResponse, 0, len(history))
+
+	for _, t := range history {
+		transition := orderTransitionResponse{
+			From:   t.From,
+			To:     t.To,
+			Time:   t.Time,
+			Actor:  "",
+			Reason: "",
+		}
+
+		if staff {
+			transition.Actor, transition.Reason = t.Actor, t.Reason
+		}
+
+		res = append(res, transition)
+	}
+
+	return res
+}
+
+type orderActionRequest struct {
+	Reason string `json:"reason"`
+
+	// Amount and Currency are the amount to refund, or zero for what is
+	// left of the order.
+	Amount   int64           `json:"amount"`
+	Currency domain.Currency `json:"currency"`
+}
+
+func (r orderActionRequest) amount() domain.Money {
+	return domain.Money{Amount: r.Amount, Currency: r.Currency}
+}
+
+func customerOrdersHandler(logger Logger, orders OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		list, err := orders.CustomerOrders(r.Context(), sess.CustomerID)
+		if err != nil {
+			writeOrderError(logger, w, err, "failed to list orders")
+			return
+		}
+
+		res := make([]orderResponse, 0, len(list))
+		for _, o := range list {
+			res = append(res, newOrderResponse(o, requestLocale(r)))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func customerOrderHandler(logger Logger, orders OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, service.ErrOrderNotFound)
+			return
+		}
+
+		o, err := orders.CustomerOrder(r.Context(), sess.CustomerID, id)
+		if err != nil {
+			writeOrderError(logger, w, err, "failed to find order")
+			return
+		}
+
+		history, err := orders.History(r.Context(), o.ID)
+		if err != nil {
+			writeOrderError(logger, w, err, "failed to list order history")
+			return
+		}
+
+		res := newOrderResponse(o, requestLocale(r))
+		res.History = newHistoryResponse(history, false)
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func listOrdersHandler(logger Logger, orders OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := domain.OrderPaid
+
+		if v := r.URL.Query().Get("status"); v != "" {
+			status = domain.OrderStatus(v)
+
+			if !slices.Contains(domain.OrderStatuses(), status) {
+				writeError(w, http.StatusUnprocessableEntity, ErrInvalidOrderStatus)
+				return
+			}
+		}
+
+		list, err := orders.Orders(r.Context(), status, orderListLimit)
+		if err != nil {
+			writeOrderError(logger, w, err, "failed to list orders")
+			return
+		}
+
+		res := make([]adminOrderResponse, 0, len(list))
+		for _, o := range list {
+			res = append(res, newAdminOrderResponse(o, requestLocale(r)))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func orderHandler(logger Logger, orders OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, service.ErrOrderNotFound)
+			return
+		}
+
+		o, err := orders.Order(r.Context(), id)
+		if err != nil {
+			writeOrderError(logger, w, err, "failed to find order")
+			return
+		}
+
+		history, err := orders.History(r.Context(), o.ID)
+		if err != nil {
+			writeOrderError(logger, w, err, "failed to list order history")
+			return
+		}
+
+		res := newAdminOrderResponse(o, requestLocale(r))
+		res.History = newHistoryResponse(history, true)
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// orderAction is an action of staff on an order.
+type orderAction func(ctx context.Context, staffID, id int, req orderActionRequest) (domain.Order, error)
+
+func orderActionHandler(logger Logger, action orderAction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, service.ErrOrderNotFound)
+			return
+		}
+
+		var req orderActionRequest
+
+		err = decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		o, err := action(r.Context(), sess.CustomerID, id, req)
+		if err != nil {
+			writeOrderError(logger, w, err, "failed to change order")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAdminOrderResponse(o, requestLocale(r)))
+	}
+}
+
+func writeOrderError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	var (
+		orderErr service.OrderError
+		moneyErr domain.MoneyError
+	)
+
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrIllegalTransition), errors.Is(err, service.ErrOrderChanged):
+		writeError(w, http.StatusConflict, err)
+	case errors.As(err, &orderErr), errors.As(err, &moneyErr):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, ports.ErrPaymentUnavailable), errors.Is(err, ports.ErrPaymentRateLimited):
+		logger.Warn("payment provider unavailable", "error", err)
+		writeError(w, http.StatusServiceUnavailable, errPaymentUnavailable)
+	default:
+		logger.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
+
+const ErrInvalidOrderStatus HandlerError = "order status must be pending, paid, held, fulfilled, " +
+	"partially_refunded, refunded, disputed or canceled"
@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// fakeRefunds is a ports.PaymentProvider that only refunds, or fails with
+// err.
+type fakeRefunds struct {
+	err error
+}
+
+func (f *fakeRefunds) CreateCheckoutSession(context.Context, domain.CheckoutRequest) (domain.CheckoutSession, error) {
+	return domain.CheckoutSession{}, ports.ErrPaymentInvalid
+}
+
+func (f *fakeRefunds) CheckoutSession(context.Context, string) (domain.CheckoutSession, error) {
+	return domain.CheckoutSession{}, ports.ErrNotFound
+}
+
+func (f *fakeRefunds) ExpireCheckoutSession(context.Context, string) (domain.CheckoutSession, error) {
+	return domain.CheckoutSession{}, ports.ErrPaymentInvalid
+}
+
+func (f *fakeRefunds) Refund(_ context.Context, req domain.RefundRequest) (domain.Refund, error) {
+	if f.err != nil {
+		return domain.Refund{}, f.err
+	}
+
+	return domain.Refund{ID: "re_1", PaymentID: req.PaymentID, Amount: req.Amount, Status: domain.RefundSucceeded}, nil
+}
+
+func (f *fakeRefunds) SyncCustomer(context.Context, domain.Customer, string) (string, error) {
+	return "", ports.ErrPaymentInvalid
+}
+
+type orderJSON struct {
+	ID       int    `json:"id"`
+	Status   string `json:"status"`
+	Refunded struct {
+		Amount int64 `json:"amount"`
+	} `json:"refunded"`
+	Lines []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"lines"`
+	History []struct {
+		To     string `json:"to"`
+		Actor  string `json:"actor"`
+		Reason string `json:"reason"`
+	} `json:"history"`
+	PaymentID string `json:"payment_id"`
+}
+
+// newOrderTest returns an order service with a paid order of customer 1,
+// with the payment pi_1, and a pending order of customer 2.
+func newOrderTest(t *testing.T, payments ports.PaymentProvider) *service.OrderService {
+	t.Helper()
+
+	store := dal.NewMemoryOrders()
+	usd := func(amount int64) domain.Money { return domain.Money{Amount: amount, Currency: domain.CurrencyUSD} }
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, customerID := range []int{1, 2} {
+		_, err := store.CreateOrder(t.Context(), domain.Order{
+			ID:         0,
+			CustomerID: customerID,
+			Status:     domain.OrderPending,
+			Lines: []domain.OrderLine{{
+				ProductID:   1,
+				ProductType: domain.ProductPlugin,
+				Name:        "Microwave",
+				Version:     "1.2.0",
+				UnitPrice:   usd(4900),
+				Quantity:    1,
+				Gift:        false,
+				UpgradeFrom: 0,
+			}},
+			Total:       usd(4900),
+			Refunded:    usd(0),
+			CheckoutKey: []string{"checkout-1", "checkout-2"}[i],
+			CheckoutID:  []string{"cs_1", "cs_2"}[i],
+			PaymentID:   "",
+			Created:     created,
+			Updated:     created,
+		}, domain.OrderTransition{
+			OrderID: 0,
+			From:    "",
+			To:      domain.OrderPending,
+			Actor:   "customer 1",
+			Reason:  "checked out",
+			Time:    created,
+		})
+		assert.NoError(t, err)
+	}
+
+	orders := service.NewOrderService(store, payments)
+
+	assert.NoError(t, orders.PayOrder(t.Context(), domain.PaymentEvent{
+		ID:      "evt_1",
+		Type:    domain.EventCheckoutCompleted,
+		Created: time.Time{},
+		Checkout: &domain.CheckoutSession{
+			ID:                "cs_1",
+			URL:               "",
+			Reference:         "cart",
+			CustomerID:        1,
+			PaymentCustomerID: "cus_1",
+			Status:            domain.CheckoutComplete,
+			PaymentStatus:     domain.PaymentPaid,
+			PaymentID:         "pi_1",
+			Total:             usd(4900),
+			Expires:           time.Time{},
+		},
+		Charge:  nil,
+		Dispute: nil,
+	}))
+
+	return orders
+}
+
+func TestOrderRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	mux := newMux(server.NewOrderRoutes(nopLogger{}, sessions, newOrderTest(t, &fakeRefunds{err: nil}))...)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	rec := request(t, mux, http.MethodGet, "/orders", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	var orders []orderJSON
+
+	rec = request(t, mux, http.MethodGet, "/orders", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &orders))
+	assert.Equal(t, len(orders), 1)
+	assert.Equal(t, orders[0].Status, "paid")
+	assert.Equal(t, orders[0].Lines[0].Version, "1.2.0")
+	assert.Equal(t, len(orders[0].History), 0)
+
+	var order orderJSON
+
+	rec = request(t, mux, http.MethodGet, "/orders/1", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &order))
+	assert.Equal(t, len(order.History), 2)
+	assert.Equal(t, order.History[1].To, "paid")
+
+	// Customers see neither who moved their orders, nor the payment.
+	assert.Equal(t, order.History[1].Actor, "")
+	assert.Equal(t, order.PaymentID, "")
+
+	// The orders of other customers are not found.
+	rec = request(t, mux, http.MethodGet, "/orders/2", token)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	rec = request(t, mux, http.MethodGet, "/orders/x", token)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestOrderAdminRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), dal.NewMemoryAuditLog())
+	payments := &fakeRefunds{err: nil}
+	ctx := t.Context()
+
+	mux := newMux(server.NewAdminGroup(nopLogger{}, sessions, authz,
+		server.NewOrderAdminRoutes(nopLogger{}, authz, newOrderTest(t, payments))...)...)
+
+	token, _, err := sessions.Create(ctx, 5, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, authz.Grant(ctx, 5, domain.RoleSupport, ""))
+
+	var orders []orderJSON
+
+	// The paid orders are listed by default.
+	rec := send(t, mux, http.MethodGet, "/admin/orders", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &orders))
+	assert.Equal(t, len(orders), 1)
+	assert.Equal(t, orders[0].PaymentID, "pi_1")
+
+	rec = send(t, mux, http.MethodGet, "/admin/orders?status=pending", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &orders))
+	assert.Equal(t, len(orders), 1)
+	assert.Equal(t, orders[0].ID, 2)
+
+	var order orderJSON
+
+	rec = send(t, mux, http.MethodPost, "/admin/orders/1/refund",
+		`{"amount":900,"currency":"USD","reason":"discount"}`, token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &order))
+	assert.Equal(t, order.Status, "partially_refunded")
+	assert.Equal(t, order.Refunded.Amount, 900)
+
+	rec = send(t, mux, http.MethodGet, "/admin/orders/1", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &order))
+	assert.Equal(t, len(order.History), 3)
+	assert.Equal(t, order.History[2].Actor, "staff 5")
+	assert.Equal(t, order.History[2].Reason, "discount")
+
+	payments.err = ports.ErrPaymentUnavailable
+
+	rec = send(t, mux, http.MethodPost, "/admin/orders/1/refund", `{"reason":"asked"}`, token)
+	assert.Equal(t, rec.Code, http.StatusServiceUnavailable)
+
+	payments.err = nil
+
+	for _, tt := range []struct {
+		name, target, body string
+		want               int
+	}{
+		{"unknown status", "/admin/orders?status=shipped", "", http.StatusUnprocessableEntity},
+		{"unknown order", "/admin/orders/3", "", http.StatusNotFound},
+		{"malformed body", "/admin/orders/1/refund", `{`, http.StatusBadRequest},
+		{"too much", "/admin/orders/1/refund", `{"amount":5000,"currency":"USD"}`, http.StatusUnprocessableEntity},
+		{"other currency", "/admin/orders/1/refund", `{"amount":100,"currency":"EUR"}`, http.StatusUnprocessableEntity},
+		{"unpaid", "/admin/orders/2/refund", `{}`, http.StatusConflict},
+		{"fulfill partially refunded", "/admin/orders/1/fulfill", `{}`, http.StatusConflict},
+		{"cancel partially refunded", "/admin/orders/1/cancel", `{}`, http.StatusConflict},
+		{"cancel", "/admin/orders/2/cancel", `{"reason":"fraud"}`, http.StatusOK},
+		{"unknown action", "/admin/orders/3/cancel", `{}`, http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			method := http.MethodPost
+			if tt.body == "" {
+				method = http.MethodGet
+			}
+
+			rec := send(t, mux, method, tt.target, tt.body, token)
+			assert.Equal(t, rec.Code, tt.want)
+		})
+	}
+}
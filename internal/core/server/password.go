@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// PasswordService changes and resets the passwords of customers.
+type PasswordService interface {
+	AdmitReset(ctx context.Context, email domain.RegisteredCustomerEmail, ip string) (bool, error)
+	ResetPassword(ctx context.Context, token string, password domain.NewCustomerPassword, ip string) error
+	ChangePassword(
+		ctx context.Context,
+		session domain.Session,
+		current domain.RegisteredCustomerPassword,
+		password domain.NewCustomerPassword,
+		ip string,
+	) error
+}
+
+// ResetQueue holds the password reset requests admitted, for a worker to
+// mail their links after they are answered. Push reports whether the
+// request was queued; a full queue refuses it.
+type ResetQueue interface {
+	Push(email domain.RegisteredCustomerEmail) bool
+}
+
+// NewPasswordRoutes returns the password routes.
+//
+//   - POST /password/forgot: mails a reset link to the customer with the
+//     email of the JSON body. It answers 202 Accepted whether or not the
+//     email belongs to a customer, and whether the request is handled or
+//     dropped, because its address or email made too many or the queue of
+//     requests is full.
+//   - POST /password/reset: sets a new password from a JSON body with the
+//     token of a reset link and the password, and logs the customer out
+//     everywhere.
+//   - POST /password/change: sets a new password for the logged in customer
+//     from a JSON body with their current and new passwords, logs them out
+//     of their other sessions and rotates the current one. Wrong current
+//     passwords are throttled like failed logins, and answered with 429
+//     and a Retry-After header once too many. It requires a stepped up
+//     session.
+func NewPasswordRoutes(
+	logger Logger,
+	sessions SessionService,
+	twoFactor TwoFactorService,
+	passwords PasswordService,
+	resets ResetQueue,
+) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("POST /password/forgot", forgotPasswordHandler(logger, passwords, resets)),
+		NewRoute("POST /password/reset", resetPasswordHandler(logger, passwords)),
+		NewRoute(
+			"POST /password/change",
+			RequireSession(logger, sessions,
+				RequireStepUp(logger, twoFactor, changePasswordHandler(logger, sessions, passwords))),
+		),
+	}
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+func forgotPasswordHandler(logger Logger, svc PasswordService, resets ResetQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req forgotPasswordRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// The request is handled by the queue after answering, so that
+		// neither the response nor its timing tell whether the email belongs
+		// to a customer. Admitting it does not look the email up.
+		email := domain.RegisteredCustomerEmail(req.Email)
+
+		admitted, err := svc.AdmitReset(r.Context(), email, ClientIP(r))
+		switch {
+		case err != nil:
+			logger.Error("failed to admit password reset", "error", err)
+		case admitted && !resets.Push(email):
+			logger.Warn("password reset queue is full, request dropped")
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func resetPasswordHandler(logger Logger, svc PasswordService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req resetPasswordRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = svc.ResetPassword(r.Context(), req.Token, domain.NewCustomerPassword(req.Password), ClientIP(r))
+		if err != nil {
+			writePasswordError(logger, w, err, "failed to reset password")
+			return
+		}
+
+		ClearSessionCookie(w)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func changePasswordHandler(logger Logger, sessions SessionService, svc PasswordService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req changePasswordRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		sess, _ := SessionFromContext(r.Context())
+
+		err = svc.ChangePassword(
+			r.Context(),
+			sess,
+			domain.RegisteredCustomerPassword(req.CurrentPassword),
+			domain.NewCustomerPassword(req.NewPassword),
+			ClientIP(r),
+		)
+		if err != nil {
+			writePasswordError(logger, w, err, "failed to change password")
+			return
+		}
+
+		err = rotateSession(w, r, sessions)
+		if err != nil {
+			logger.Error("failed to rotate session", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writePasswordError answers a failed password reset or change. A wrong
+// current password is answered with 403 rather than 401, which would tell
+// the client its session is gone.
+func writePasswordError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	var (
+		credErr  domain.CredentialError
+		retryErr *service.RetryError
+	)
+
+	switch {
+	case errors.As(err, &retryErr):
+		writeRetry(w, retryErr)
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		writeError(w, http.StatusForbidden, err)
+	case errors.As(err, &credErr):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, service.ErrResetInvalid), errors.Is(err, service.ErrResetExpired):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		logger.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// fakePasswords answers every call with err, and admits reset requests
+// unless refused.
+type fakePasswords struct {
+	err     error
+	refused bool
+}
+
+func (f fakePasswords) AdmitReset(context.Context, domain.RegisteredCustomerEmail, string) (bool, error) {
+	return !f.refused, f.err
+}
+
+func (f fakePasswords) ResetPassword(context.Context, string, domain.NewCustomerPassword, string) error {
+	return f.err
+}
+
+func (f fakePasswords) ChangePassword(
+	context.Context,
+	domain.Session,
+	domain.RegisteredCustomerPassword,
+	domain.NewCustomerPassword,
+	string,
+) error {
+	return f.err
+}
+
+// fakeResets queues up to size reset requests.
+type fakeResets struct {
+	size   int
+	queued []domain.RegisteredCustomerEmail
+}
+
+func (f *fakeResets) Push(email domain.RegisteredCustomerEmail) bool {
+	if len(f.queued) == f.size {
+		return false
+	}
+
+	f.queued = append(f.queued, email)
+
+	return true
+}
+
+func post(h http.Handler, target, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: server.SessionCookieName, Value: token}) //nolint:exhaustruct // request cookie
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestPasswordRoutes_Forgot(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		passwords fakePasswords
+		size      int
+	}{
+		{
+			CaseBase:  test.NewCaseBase("queued", 1, false),
+			passwords: fakePasswords{err: nil, refused: false},
+			size:      1,
+		},
+		{
+			CaseBase:  test.NewCaseBase("throttled", 0, false),
+			passwords: fakePasswords{err: nil, refused: true},
+			size:      1,
+		},
+		{
+			CaseBase:  test.NewCaseBase("admission failure", 0, false),
+			passwords: fakePasswords{err: errors.New("database is down"), refused: true},
+			size:      1,
+		},
+		{
+			CaseBase:  test.NewCaseBase("queue full", 0, false),
+			passwords: fakePasswords{err: nil, refused: false},
+			size:      0,
+		},
+	}
+
+	// The answer is the same whether the request is queued or not.
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			resets := &fakeResets{size: tt.size, queued: nil}
+			routes := server.NewPasswordRoutes(nopLogger{}, newSessionService(t), fakeTwoFactor{err: nil}, tt.passwords, resets)
+			mux := newMux(routes...)
+
+			rec := post(mux, "/password/forgot", `{"email":"jane@example.com"}`, "")
+			assert.Equal(t, rec.Code, http.StatusAccepted)
+			assert.Equal(t, rec.Body.String(), "")
+			assert.Equal(t, len(resets.queued), tt.Want.(int))
+		})
+	}
+}
+
+func TestPasswordRoutes_ResetAndChange(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		err   error
+		reset int
+	}{
+		{
+			CaseBase: test.NewCaseBase("success", http.StatusNoContent, false),
+			err:      nil,
+			reset:    http.StatusNoContent,
+		},
+		{
+			CaseBase: test.NewCaseBase("weak password", http.StatusUnprocessableEntity, false),
+			err:      domain.ErrPasswordTooShort,
+			reset:    http.StatusUnprocessableEntity,
+		},
+		{
+			CaseBase: test.NewCaseBase("wrong current password", http.StatusForbidden, false),
+			err:      domain.ErrInvalidCredentials,
+			reset:    http.StatusForbidden,
+		},
+		{
+			CaseBase: test.NewCaseBase("locked", http.StatusTooManyRequests, false),
+			err:      &service.RetryError{Err: service.ErrAccountLocked, RetryAfter: time.Minute},
+			reset:    http.StatusTooManyRequests,
+		},
+		{
+			CaseBase: test.NewCaseBase("invalid token", http.StatusBadRequest, false),
+			err:      service.ErrResetInvalid,
+			reset:    http.StatusBadRequest,
+		},
+		{
+			CaseBase: test.NewCaseBase("internal failure", http.StatusInternalServerError, false),
+			err:      errors.New("database is down"),
+			reset:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			sessions := newSessionService(t)
+			passwords := fakePasswords{err: tt.err, refused: false}
+			resets := &fakeResets{size: 1, queued: nil}
+			mux := newMux(server.NewPasswordRoutes(nopLogger{}, sessions, fakeTwoFactor{err: nil}, passwords, resets)...)
+
+			token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "", IP: ""})
+			assert.NoError(t, err)
+
+			rec := post(mux, "/password/reset", `{"token":"t","password":"a brand new passphrase"}`, "")
+			assert.Equal(t, rec.Code, tt.reset)
+
+			body := `{"current_password":"correct horse battery","new_password":"a brand new passphrase"}`
+
+			rec = post(mux, "/password/change", body, "")
+			assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+			rec = post(mux, "/password/change", body, token)
+			assert.Equal(t, rec.Code, tt.Want.(int))
+			assert.False(t, strings.Contains(rec.Body.String(), "database is down"))
+
+			// A changed password rotates the session token.
+			_, err = sessions.Validate(t.Context(), token)
+			assert.Equal(t, err == nil, tt.err != nil)
+			assert.Equal(t, rotatedToken(rec) != "", tt.err == nil)
+		})
+	}
+}
@@ -0,0 +1,454 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CSRFCookieName is the name of the cookie holding the CSRF token.
+	// Scripts of the same origin may read it; those of other allowed
+	// origins, such as the storefront, cannot, and get the token from
+	// GET /csrf instead. Either echo it in CSRFHeaderName.
+	CSRFCookieName = "__Host-csrf"
+
+	// CSRFHeaderName is the request header that must echo the CSRF cookie.
+	CSRFHeaderName = "X-CSRF-Token"
+
+	// anyOrigin allows every origin in a CORS policy.
+	anyOrigin = "*"
+)
+
+// Chain returns a middleware applying middlewares in order, the first one
+// outermost.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		for _, m := range slices.Backward(middlewares) {
+			h = m(h)
+		}
+
+		return h
+	}
+}
+
+// CSRFConfig configures the protection of browser flows from cross-site
+// request forgery.
+type CSRFConfig struct {
+	// AllowedOrigins are the origins allowed to send state changing
+	// requests, such as "https://brokedaear.com".
+	AllowedOrigins []string
+}
+
+func (c CSRFConfig) Validate() error {
+	if len(c.AllowedOrigins) == 0 {
+		return ErrInvalidOrigin
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if !validOrigin(origin) {
+			return ErrInvalidOrigin
+		}
+	}
+
+	return nil
+}
+
+func (c CSRFConfig) Value() any {
+	return c
+}
+
+// CSRF protects state changing requests with a double-submit token and
+// Origin checks. Browsers get a random token in a cookie, which the allowed
+// origins learn from GET /csrf, and must echo it in the X-CSRF-Token header.
+//
+// State changing requests from a disallowed Origin are always rejected.
+// Those carrying an ambient credential, the session or guest cart cookie,
+// must also come from an allowed Origin, or Referer when browsers send no
+// Origin, and echo the token. Plugins, which authenticate with bearer
+// tokens, send neither cookies nor Origin and are let through. Rejected
+// requests are answered 403 Forbidden.
+func CSRF(config CSRFConfig) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie(CSRFCookieName)
+			if err != nil || cookie.Value == "" {
+				cookie = setCSRFCookie(w)
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, cookie.Value))
+
+			if safeMethod(r.Method) {
+				next(w, r)
+				return
+			}
+
+			origin := r.Header.Get("Origin")
+			if origin != "" && !slices.Contains(config.AllowedOrigins, origin) {
+				writeError(w, http.StatusForbidden, ErrCrossOrigin)
+				return
+			}
+
+			if !hasAmbientCredential(r) {
+				next(w, r)
+				return
+			}
+
+			if origin == "" && !slices.Contains(config.AllowedOrigins, refererOrigin(r)) {
+				writeError(w, http.StatusForbidden, ErrCrossOrigin)
+				return
+			}
+
+			token := r.Header.Get(CSRFHeaderName)
+			if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+				writeError(w, http.StatusForbidden, ErrCSRFToken)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+type csrfContextKey struct{}
+
+type csrfResponse struct {
+	Token string `json:"token"`
+}
+
+// NewCSRFRoutes returns the route handing the CSRF token to scripts of the
+// allowed origins, which cannot read the cookie of another origin. It must
+// be mounted behind a SecurityPolicy with CSRF, and with CORS for the
+// allowed origins to read it.
+//
+//   - GET /csrf: answers with the CSRF token of the browser, setting its
+//     cookie first when it has none.
+func NewCSRFRoutes() []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("GET /csrf", csrfHandler),
+	}
+}
+
+func csrfHandler(w http.ResponseWriter, r *http.Request) {
+	token, ok := r.Context().Value(csrfContextKey{}).(string)
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrCSRFDisabled)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, csrfResponse{Token: token})
+}
+
+// hasAmbientCredential reports whether r carries a cookie that identifies
+// its client.
+func hasAmbientCredential(r *http.Request) bool {
+	for _, name := range []string{SessionCookieName, CartCookieName} {
+		_, err := r.Cookie(name)
+		if err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setCSRFCookie(w http.ResponseWriter) *http.Cookie {
+	c := &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    rand.Text(),
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: false,
+		SameSite: http.SameSiteStrictMode,
+	}
+
+	http.SetCookie(w, c)
+
+	return c
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// refererOrigin returns the origin of the Referer of r, or the empty string.
+func refererOrigin(r *http.Request) string {
+	u, err := url.Parse(r.Referer())
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+
+	return u.Scheme + "://" + u.Host
+}
+
+// validOrigin reports whether origin is a scheme and a host, without path.
+func validOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" && u.Path == "" &&
+		u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
+
+// CORSConfig is the cross-origin resource sharing policy of a route group.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to read responses, or "*" for
+	// every origin.
+	AllowedOrigins []string
+
+	AllowedMethods []string
+	AllowedHeaders []string
+
+	// AllowCredentials lets the allowed origins send cookies. It cannot be
+	// set with "*".
+	AllowCredentials bool
+
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+func (c CORSConfig) Validate() error {
+	if len(c.AllowedOrigins) == 0 || len(c.AllowedMethods) == 0 || c.MaxAge < 0 {
+		return ErrInvalidCORS
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if origin == anyOrigin && !c.AllowCredentials {
+			continue
+		}
+
+		if !validOrigin(origin) {
+			return ErrInvalidCORS
+		}
+	}
+
+	return nil
+}
+
+func (c CORSConfig) Value() any {
+	return c
+}
+
+func (c CORSConfig) allowed(origin string) bool {
+	return origin != "" && (slices.Contains(c.AllowedOrigins, anyOrigin) || slices.Contains(c.AllowedOrigins, origin))
+}
+
+// CORS lets the allowed origins of config read the responses of a route
+// group. Preflight requests are answered by the routes of PreflightRoutes.
+func CORS(config CORSConfig) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			setCORSHeaders(w, r, config)
+			next(w, r)
+		}
+	}
+}
+
+// PreflightRoutes returns the OPTIONS routes answering CORS preflight
+// requests for the paths of routes, which must not have OPTIONS routes of
+// their own.
+func PreflightRoutes(config CORSConfig, routes ...HTTPRoute) []HTTPRoute {
+	var (
+		paths     []string
+		preflight []HTTPRoute
+	)
+
+	for _, route := range routes {
+		_, path, ok := strings.Cut(route.String(), " ")
+		if !ok || slices.Contains(paths, path) {
+			continue
+		}
+
+		paths = append(paths, path)
+		preflight = append(preflight, NewRoute("OPTIONS "+path, preflightHandler(config)))
+	}
+
+	return preflight
+}
+
+func preflightHandler(config CORSConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w, r, config)
+
+		if config.allowed(r.Header.Get("Origin")) && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+
+			if len(config.AllowedHeaders) > 0 {
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+			}
+
+			if config.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge/time.Second)))
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func setCORSHeaders(w http.ResponseWriter, r *http.Request, config CORSConfig) {
+	w.Header().Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	if !config.allowed(origin) {
+		return
+	}
+
+	if config.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	} else if slices.Contains(config.AllowedOrigins, anyOrigin) {
+		w.Header().Set("Access-Control-Allow-Origin", anyOrigin)
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+}
+
+// HeadersConfig configures the security headers of the responses of a
+// route group.
+type HeadersConfig struct {
+	// HSTSMaxAge is how long browsers only reach the app over HTTPS. Zero
+	// sends no Strict-Transport-Security header, such as in development.
+	HSTSMaxAge time.Duration
+
+	// ContentSecurityPolicy is the Content-Security-Policy header, or empty
+	// for none.
+	ContentSecurityPolicy string
+
+	// ReferrerPolicy is the Referrer-Policy header, or empty for none.
+	ReferrerPolicy string
+}
+
+func (c HeadersConfig) Validate() error {
+	if c.HSTSMaxAge < 0 {
+		return ErrInvalidHSTS
+	}
+
+	return nil
+}
+
+func (c HeadersConfig) Value() any {
+	return c
+}
+
+// SecurityHeaders sets the security headers of config on responses, and
+// always forbids browsers from sniffing content types and framing
+// responses.
+func SecurityHeaders(config HeadersConfig) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+
+			if config.HSTSMaxAge > 0 {
+				h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(config.HSTSMaxAge/time.Second))+"; includeSubDomains")
+			}
+
+			if config.ContentSecurityPolicy != "" {
+				h.Set("Content-Security-Policy", config.ContentSecurityPolicy)
+			}
+
+			if config.ReferrerPolicy != "" {
+				h.Set("Referrer-Policy", config.ReferrerPolicy)
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// SecurityPolicy is the browser security of a route group. CORS and CSRF
+// are optional: a group without CORS is same-origin only, and a group
+// without CSRF is not meant for browsers.
+type SecurityPolicy struct {
+	Headers HeadersConfig
+	CORS    *CORSConfig
+	CSRF    *CSRFConfig
+}
+
+func (p SecurityPolicy) Validate() error {
+	err := p.Headers.Validate()
+	if err != nil {
+		return err
+	}
+
+	if p.CORS != nil {
+		err = p.CORS.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.CSRF != nil {
+		return p.CSRF.Validate()
+	}
+
+	return nil
+}
+
+func (p SecurityPolicy) Value() any {
+	return p
+}
+
+// Middleware returns the middleware enforcing p: security headers, then
+// CORS, then CSRF.
+func (p SecurityPolicy) Middleware() Middleware {
+	middlewares := []Middleware{SecurityHeaders(p.Headers)}
+
+	if p.CORS != nil {
+		middlewares = append(middlewares, CORS(*p.CORS))
+	}
+
+	if p.CSRF != nil {
+		middlewares = append(middlewares, CSRF(*p.CSRF))
+	}
+
+	return Chain(middlewares...)
+}
+
+// Routes returns routes behind p, along with their preflight routes when p
+// has a CORS policy.
+func (p SecurityPolicy) Routes(routes ...HTTPRoute) []HTTPRoute {
+	secured := NewRouteGroup("", p.Middleware(), routes...)
+
+	if p.CORS != nil {
+		secured = append(secured, PreflightRoutes(*p.CORS, routes...)...)
+	}
+
+	return secured
+}
+
+type SecurityError string
+
+func (e SecurityError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidOrigin SecurityError = "allowed origins must be schemes and hosts without path"
+	ErrInvalidCORS   SecurityError = "cors policy needs origins and methods, and credentials cannot be allowed to every origin"
+	ErrInvalidHSTS   SecurityError = "hsts max age must not be negative"
+	ErrCrossOrigin   SecurityError = "request comes from a disallowed origin"
+	ErrCSRFToken     SecurityError = "csrf token is missing or invalid"
+	ErrCSRFDisabled  SecurityError = "csrf protection is not enabled for this route"
+)
@@ -0,0 +1,276 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/server"
+)
+
+const testOrigin = "https://brokedaear.com"
+
+func newTestPolicy() server.SecurityPolicy {
+	return server.SecurityPolicy{
+		Headers: server.HeadersConfig{
+			HSTSMaxAge:            time.Hour,
+			ContentSecurityPolicy: "default-src 'none'",
+			ReferrerPolicy:        "no-referrer",
+		},
+		CORS: &server.CORSConfig{
+			AllowedOrigins:   []string{testOrigin},
+			AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+			AllowedHeaders:   []string{"Content-Type", server.CSRFHeaderName},
+			AllowCredentials: true,
+			MaxAge:           10 * time.Minute,
+		},
+		CSRF: &server.CSRFConfig{AllowedOrigins: []string{testOrigin}},
+	}
+}
+
+func newSecuredMux(policy server.SecurityPolicy) http.Handler {
+	ok := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	return newMux(policy.Routes(slices.Concat(
+		server.NewCSRFRoutes(),
+		[]server.HTTPRoute{
+			server.NewRoute("GET /cart", ok),
+			server.NewRoute("POST /cart", ok),
+		},
+	)...)...)
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+
+	middleware := func(name string) server.Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next(w, r)
+			}
+		}
+	}
+
+	h := server.Chain(middleware("first"), middleware("second"))(func(http.ResponseWriter, *http.Request) {
+		calls = append(calls, "handler")
+	})
+
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, strings.Join(calls, ","), "first,second,handler")
+}
+
+func TestCSRF(t *testing.T) {
+	mux := newSecuredMux(newTestPolicy())
+
+	rec := request(t, mux, http.MethodGet, "/cart", "")
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+
+	var csrf *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == server.CSRFCookieName {
+			csrf = c
+		}
+	}
+
+	assert.True(t, csrf != nil)
+	assert.True(t, csrf.Secure)
+	assert.False(t, csrf.HttpOnly)
+	assert.Equal(t, csrf.SameSite, http.SameSiteStrictMode)
+
+	for _, tt := range []struct {
+		name            string
+		origin, referer string
+		session         bool
+		token           string
+		want            int
+	}{
+		{"no credentials", "", "", false, "", http.StatusNoContent},
+		{"allowed origin", testOrigin, "", false, "", http.StatusNoContent},
+		{"cross origin", "https://evil.example", "", false, "", http.StatusForbidden},
+		{"session without token", testOrigin, "", true, "", http.StatusForbidden},
+		{"session with wrong token", testOrigin, "", true, "wrong", http.StatusForbidden},
+		{"session with token", testOrigin, "", true, csrf.Value, http.StatusNoContent},
+		{"session without origin", "", "", true, csrf.Value, http.StatusForbidden},
+		{"session with referer", "", testOrigin + "/checkout", true, csrf.Value, http.StatusNoContent},
+		{"session with cross referer", "", "https://evil.example/", true, csrf.Value, http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/cart", nil)
+			req.AddCookie(csrf)
+
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+
+			if tt.session {
+				req.AddCookie(&http.Cookie{Name: server.SessionCookieName, Value: "session"}) //nolint:exhaustruct // request cookie
+			}
+
+			if tt.token != "" {
+				req.Header.Set(server.CSRFHeaderName, tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			assert.Equal(t, rec.Code, tt.want)
+		})
+	}
+
+	// The cart cookie of guests is a credential too.
+	req := httptest.NewRequest(http.MethodPost, "/cart", nil)
+	req.Header.Set("Origin", testOrigin)
+	req.AddCookie(csrf)
+	req.AddCookie(&http.Cookie{Name: server.CartCookieName, Value: "cart"}) //nolint:exhaustruct // request cookie
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+}
+
+func TestCSRF_CrossOrigin(t *testing.T) {
+	const storefront = "https://shop.brokedaear.com"
+
+	policy := newTestPolicy()
+	policy.CORS.AllowedOrigins = []string{storefront}
+	policy.CSRF.AllowedOrigins = []string{storefront}
+
+	mux := newSecuredMux(policy)
+
+	// The storefront cannot read the cookie of the API, so it asks for the
+	// token, which the browser lets it read along with the cookie.
+	req := httptest.NewRequest(http.MethodGet, "/csrf", nil)
+	req.Header.Set("Origin", storefront)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), storefront)
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Credentials"), "true")
+	assert.Equal(t, rec.Header().Get("Cache-Control"), "no-store")
+
+	var body struct {
+		Token string `json:"token"`
+	}
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].Name, server.CSRFCookieName)
+	assert.Equal(t, body.Token, cookies[0].Value)
+
+	// Asking again with the cookie gives the same token.
+	req = httptest.NewRequest(http.MethodGet, "/csrf", nil)
+	req.Header.Set("Origin", storefront)
+	req.AddCookie(cookies[0])
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, len(rec.Result().Cookies()), 0)
+	assert.True(t, strings.Contains(rec.Body.String(), body.Token))
+
+	// The token echoed, a logged in request of the storefront goes through.
+	req = httptest.NewRequest(http.MethodPost, "/cart", nil)
+	req.Header.Set("Origin", storefront)
+	req.Header.Set(server.CSRFHeaderName, body.Token)
+	req.AddCookie(cookies[0])
+	req.AddCookie(&http.Cookie{Name: server.SessionCookieName, Value: "session"}) //nolint:exhaustruct // request cookie
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), storefront)
+
+	// Without CSRF protection, there is no token to hand out.
+	policy.CSRF = nil
+
+	rec = request(t, newSecuredMux(policy), http.MethodGet, "/csrf", "")
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestCORS(t *testing.T) {
+	mux := newSecuredMux(newTestPolicy())
+
+	req := httptest.NewRequest(http.MethodOptions, "/cart", nil)
+	req.Header.Set("Origin", testOrigin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), testOrigin)
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Credentials"), "true")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Methods"), "GET, POST")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Headers"), "Content-Type, X-CSRF-Token")
+	assert.Equal(t, rec.Header().Get("Access-Control-Max-Age"), "600")
+
+	req = httptest.NewRequest(http.MethodGet, "/cart", nil)
+	req.Header.Set("Origin", "https://evil.example")
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "")
+	assert.Equal(t, rec.Header().Get("Vary"), "Origin")
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	rec := request(t, newSecuredMux(newTestPolicy()), http.MethodGet, "/cart", "")
+
+	assert.Equal(t, rec.Header().Get("X-Content-Type-Options"), "nosniff")
+	assert.Equal(t, rec.Header().Get("X-Frame-Options"), "DENY")
+	assert.Equal(t, rec.Header().Get("Strict-Transport-Security"), "max-age=3600; includeSubDomains")
+	assert.Equal(t, rec.Header().Get("Content-Security-Policy"), "default-src 'none'")
+	assert.Equal(t, rec.Header().Get("Referrer-Policy"), "no-referrer")
+}
+
+func TestSecurityPolicyWithoutCORS(t *testing.T) {
+	policy := newTestPolicy()
+	policy.CORS = nil
+
+	routes := policy.Routes(server.NewRoute("POST /admin/roles", func(http.ResponseWriter, *http.Request) {}))
+	assert.Equal(t, len(routes), 1)
+}
+
+func TestSecurityPolicyValidate(t *testing.T) {
+	assert.NoError(t, newTestPolicy().Validate())
+
+	for _, tt := range []struct {
+		name   string
+		modify func(*server.SecurityPolicy)
+		want   error
+	}{
+		{"negative hsts", func(p *server.SecurityPolicy) { p.Headers.HSTSMaxAge = -1 }, server.ErrInvalidHSTS},
+		{"origin with path", func(p *server.SecurityPolicy) { p.CSRF.AllowedOrigins = []string{testOrigin + "/shop"} }, server.ErrInvalidOrigin},
+		{"no csrf origins", func(p *server.SecurityPolicy) { p.CSRF.AllowedOrigins = nil }, server.ErrInvalidOrigin},
+		{"any origin with credentials", func(p *server.SecurityPolicy) { p.CORS.AllowedOrigins = []string{"*"} }, server.ErrInvalidCORS},
+		{"no methods", func(p *server.SecurityPolicy) { p.CORS.AllowedMethods = nil }, server.ErrInvalidCORS},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPolicy()
+			tt.modify(&p)
+			assert.Error(t, p.Validate(), tt.want)
+		})
+	}
+}
@@ -5,7 +5,6 @@
 package server
 
 import (
-	"context"
 	"net"
 
 	"backend.brokedaear.com/internal/common/telemetry"
@@ -19,26 +18,15 @@ type Base struct {
 	listener  net.Listener
 }
 
-func NewBase(ctx context.Context, logger Logger, config *Config) (*Base, error) {
+// NewBase creates the base of a server. The server reports to t, which stays
+// owned by the caller; closing the server does not close t.
+func NewBase(logger Logger, config *Config, t telemetry.Telemetry) (*Base, error) {
 	if config == nil {
 		return nil, ErrNilConfig
 	}
 
-	tc := &telemetry.Config{
-		ServiceName:    "",
-		ServiceVersion: config.Version.String(),
-		ServiceID:      "",
-		ExporterConfig: telemetry.ExporterConfig{
-			Type:     telemetry.ExporterTypeGRPC,
-			Endpoint: "localhost:4713",
-			Insecure: false,
-			Headers:  nil,
-		},
-	}
-
-	t, err := telemetry.New(ctx, tc)
-	if err != nil {
-		return nil, err
+	if t == nil {
+		return nil, ErrNilTelemetry
 	}
 
 	return &Base{
@@ -55,4 +43,7 @@ func (b BaseError) Error() string {
 	return string(b)
 }
 
-var ErrNilConfig BaseError = "config is nil"
+const (
+	ErrNilConfig    BaseError = "config is nil"
+	ErrNilTelemetry BaseError = "telemetry is nil"
+)
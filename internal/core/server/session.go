@@ -0,0 +1,302 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// SessionCookieName is the name of the session cookie. The __Host- prefix
+// makes browsers only accept the cookie when it is Secure, has no Domain,
+// and has a Path of /, so it cannot be planted by a sibling subdomain.
+const SessionCookieName = "__Host-session"
+
+// SessionService validates, rotates and revokes sessions.
+type SessionService interface {
+	Validate(ctx context.Context, token string) (domain.Session, error)
+	Rotate(ctx context.Context, token string) (string, domain.Session, error)
+	List(ctx context.Context, customerID int) ([]domain.Session, error)
+	Revoke(ctx context.Context, customerID int, id string) error
+	RevokeAll(ctx context.Context, customerID int, except ...string) (int, error)
+	Logout(ctx context.Context, token string) error
+}
+
+// SetSessionCookie hands a session token to the browser. The cookie lasts
+// until the absolute expiry of the session; the idle timeout is enforced by
+// the server.
+func SetSessionCookie(w http.ResponseWriter, token string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearSessionCookie removes the session cookie from the browser.
+func ClearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// rotateSession replaces the session token of r with a fresh one, after the
+// privileges of its session changed, and hands it to the browser.
+func rotateSession(w http.ResponseWriter, r *http.Request, sessions SessionService) error {
+	token, sess, err := sessions.Rotate(r.Context(), currentSessionToken(r))
+	if err != nil {
+		return err
+	}
+
+	SetSessionCookie(w, token, sess.AbsoluteExpires)
+
+	return nil
+}
+
+// SessionMeta describes the client of r.
+func SessionMeta(r *http.Request) service.SessionMeta {
+	return service.SessionMeta{
+		UserAgent: r.UserAgent(),
+		IP:        ClientIP(r),
+	}
+}
+
+// ClientIP returns the IP address of the peer of r. Forwarding headers are
+// ignored, since any client can set them.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+type sessionContextKey struct{}
+
+// SessionFromContext returns the session stored by RequireSession.
+func SessionFromContext(ctx context.Context) (domain.Session, bool) {
+	s, ok := ctx.Value(sessionContextKey{}).(domain.Session)
+	return s, ok
+}
+
+// RequireSession only lets requests with a valid session cookie through to
+// next, with the session available from SessionFromContext. Other requests
+// are answered with 401 Unauthorized and their stale cookie is cleared.
+func RequireSession(logger Logger, sessions SessionService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(SessionCookieName)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, ErrUnauthenticated)
+			return
+		}
+
+		sess, err := sessions.Validate(r.Context(), cookie.Value)
+		if err != nil {
+			if errors.Is(err, service.ErrSessionInvalid) || errors.Is(err, service.ErrSessionExpired) {
+				ClearSessionCookie(w)
+				writeError(w, http.StatusUnauthorized, ErrUnauthenticated)
+
+				return
+			}
+
+			logger.Error("failed to validate session", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, sess)))
+	}
+}
+
+// WithSession lets every request through to next, with the session
+// available from SessionFromContext when the request has a valid session
+// cookie. Stale cookies are cleared, and their requests served as if they
+// had none.
+func WithSession(logger Logger, sessions SessionService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(SessionCookieName)
+		if err != nil {
+			next(w, r)
+			return
+		}
+
+		sess, err := sessions.Validate(r.Context(), cookie.Value)
+		if err != nil {
+			if errors.Is(err, service.ErrSessionInvalid) || errors.Is(err, service.ErrSessionExpired) {
+				ClearSessionCookie(w)
+				next(w, r)
+
+				return
+			}
+
+			logger.Error("failed to validate session", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, sess)))
+	}
+}
+
+// NewSessionRoutes returns the routes with which customers manage their
+// sessions. They all require a session.
+//
+//   - GET /sessions: lists the active sessions of the customer.
+//   - DELETE /sessions/{id}: revokes a session of the customer.
+//   - DELETE /sessions: revokes every session of the customer but the
+//     current one, and signs out all their devices. It requires a stepped
+//     up session.
+//   - POST /logout: ends the current session.
+func NewSessionRoutes(
+	logger Logger,
+	sessions SessionService,
+	twoFactor TwoFactorService,
+	devices DeviceService,
+) []HTTPRoute {
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequireSession(logger, sessions, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /sessions", auth(listSessionsHandler(logger, sessions))),
+		NewRoute("DELETE /sessions/{id}", auth(revokeSessionHandler(logger, sessions))),
+		NewRoute("DELETE /sessions",
+			auth(RequireStepUp(logger, twoFactor, revokeOtherSessionsHandler(logger, sessions, devices)))),
+		NewRoute("POST /logout", auth(logoutHandler(logger, sessions))),
+	}
+}
+
+type sessionResponse struct {
+	ID        string    `json:"id"`
+	Created   time.Time `json:"created"`
+	LastSeen  time.Time `json:"last_seen"`
+	Expires   time.Time `json:"expires"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	Current   bool      `json:"current"`
+}
+
+func listSessionsHandler(logger Logger, sessions SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, _ := SessionFromContext(r.Context())
+
+		list, err := sessions.List(r.Context(), current.CustomerID)
+		if err != nil {
+			logger.Error("failed to list sessions", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		res := make([]sessionResponse, 0, len(list))
+		for _, s := range list {
+			res = append(res, sessionResponse{
+				ID:        s.ID,
+				Created:   s.Created,
+				LastSeen:  s.LastSeen,
+				Expires:   s.Expires,
+				UserAgent: s.UserAgent,
+				IP:        s.IP,
+				Current:   s.ID == current.ID,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func revokeSessionHandler(logger Logger, sessions SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, _ := SessionFromContext(r.Context())
+
+		err := sessions.Revoke(r.Context(), current.CustomerID, r.PathValue("id"))
+		if err != nil {
+			if errors.Is(err, service.ErrSessionNotFound) {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+
+			logger.Error("failed to revoke session", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		if r.PathValue("id") == current.ID {
+			ClearSessionCookie(w)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type revokedResponse struct {
+	Revoked int `json:"revoked"`
+	Devices int `json:"devices"`
+}
+
+func revokeOtherSessionsHandler(logger Logger, sessions SessionService, devices DeviceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, _ := SessionFromContext(r.Context())
+
+		n, err := sessions.RevokeAll(r.Context(), current.CustomerID, current.ID)
+		if err != nil {
+			logger.Error("failed to revoke sessions", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		d, err := devices.RevokeAll(r.Context(), current.CustomerID, ClientIP(r))
+		if err != nil {
+			logger.Error("failed to revoke devices", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		writeJSON(w, http.StatusOK, revokedResponse{Revoked: n, Devices: d})
+	}
+}
+
+func logoutHandler(logger Logger, sessions SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(SessionCookieName)
+		if err == nil {
+			err = sessions.Logout(r.Context(), cookie.Value)
+		}
+
+		if err != nil {
+			logger.Error("failed to log out", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		ClearSessionCookie(w)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+const ErrUnauthenticated HandlerError = "authentication required"
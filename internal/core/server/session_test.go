@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+func newSessionService(t *testing.T) *service.SessionService {
+	t.Helper()
+
+	sessions, err := service.NewSessionService(dal.NewMemorySessions(), service.SessionConfig{
+		IdleTimeout:      time.Hour,
+		AbsoluteLifetime: 2 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	return sessions
+}
+
+func request(t *testing.T, h http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: server.SessionCookieName, Value: token}) //nolint:exhaustruct // request cookie
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// rotatedToken returns the session token set by rec, if any.
+func rotatedToken(rec *httptest.ResponseRecorder) string {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == server.SessionCookieName {
+			return c.Value
+		}
+	}
+
+	return ""
+}
+
+func TestSetSessionCookie(t *testing.T) {
+	rec := httptest.NewRecorder()
+	server.SetSessionCookie(rec, "token", time.Now().Add(time.Hour))
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+
+	c := cookies[0]
+	assert.Equal(t, c.Name, "__Host-session")
+	assert.Equal(t, c.Path, "/")
+	assert.Equal(t, c.Domain, "")
+	assert.True(t, c.Secure)
+	assert.True(t, c.HttpOnly)
+	assert.Equal(t, c.SameSite, http.SameSiteLaxMode)
+}
+
+func TestSessionRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	mux := newMux(server.NewSessionRoutes(nopLogger{}, sessions, fakeTwoFactor{err: nil}, fakeDevices{err: nil})...)
+
+	token, current, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "browser", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	_, other, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "phone", IP: "127.0.0.2"})
+	assert.NoError(t, err)
+
+	rec := request(t, mux, http.MethodGet, "/sessions", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	rec = request(t, mux, http.MethodGet, "/sessions", "forged")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	rec = request(t, mux, http.MethodGet, "/sessions", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var list []struct {
+		ID      string `json:"id"`
+		Current bool   `json:"current"`
+	}
+	err = json.NewDecoder(rec.Body).Decode(&list)
+	assert.NoError(t, err)
+	assert.Equal(t, len(list), 2)
+	for _, s := range list {
+		assert.Equal(t, s.Current, s.ID == current.ID)
+	}
+
+	rec = request(t, mux, http.MethodDelete, "/sessions/"+other.ID, token)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+
+	rec = request(t, mux, http.MethodDelete, "/sessions/"+other.ID, token)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	// Logging out everywhere else also signs out the devices.
+	_, _, err = sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "tablet", IP: "127.0.0.3"})
+	assert.NoError(t, err)
+
+	rec = request(t, mux, http.MethodDelete, "/sessions", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var revoked struct {
+		Revoked int `json:"revoked"`
+		Devices int `json:"devices"`
+	}
+	err = json.NewDecoder(rec.Body).Decode(&revoked)
+	assert.NoError(t, err)
+	assert.Equal(t, revoked.Revoked, 1)
+	assert.Equal(t, revoked.Devices, 1)
+
+	rec = request(t, mux, http.MethodPost, "/logout", token)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, rec.Result().Cookies()[0].MaxAge, -1)
+
+	rec = request(t, mux, http.MethodGet, "/sessions", token)
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+}
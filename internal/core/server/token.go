@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set. It is shorter
+// than the rotation interval, so that verifiers learn the next key before
+// it signs.
+const jwksMaxAge = "300"
+
+// TokenVerifier verifies JWT access tokens.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (service.CustomerClaims, error)
+}
+
+// TokenService issues and verifies JWT access tokens.
+type TokenService interface {
+	TokenVerifier
+	Issue(ctx context.Context, customerID int, clientID string, scopes []string) (service.AccessToken, error)
+	JWKS() jwt.JWKS
+}
+
+type claimsContextKey struct{}
+
+// NewTokenRoutes returns the routes that publish the signing keys and issue
+// JWT access tokens.
+//
+//   - GET /.well-known/jwks.json: the public keys that verify tokens.
+//   - POST /token: issues a token to the session of the website.
+//   - POST /oauth/jwt: issues a token to a plugin, against a device access
+//     token with the profile scope, since tokens carry the email of the
+//     customer. The token has the scopes of the device.
+func NewTokenRoutes(logger Logger, sessions SessionService, devices DeviceService, tokens TokenService) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("GET /.well-known/jwks.json", jwksHandler(tokens)),
+		NewRoute("POST /token", RequireSession(logger, sessions, sessionTokenHandler(logger, tokens))),
+		NewRoute("POST /oauth/jwt", RequireDeviceToken(logger, devices, "profile", deviceTokenHandler(logger, tokens))),
+	}
+}
+
+// jwksHandler serves the public keys of keys, access tokens or licenses.
+func jwksHandler(keys interface{ JWKS() jwt.JWKS }) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age="+jwksMaxAge)
+		writeJSON(w, http.StatusOK, keys.JWKS())
+	}
+}
+
+type accessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+func sessionTokenHandler(logger Logger, tokens TokenService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		token, err := tokens.Issue(r.Context(), sess.CustomerID, "", nil)
+		writeAccessToken(logger, w, token, err)
+	}
+}
+
+func deviceTokenHandler(logger Logger, tokens TokenService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d, _ := DeviceFromContext(r.Context())
+
+		token, err := tokens.Issue(r.Context(), d.CustomerID, d.ClientID, d.Scopes)
+		writeAccessToken(logger, w, token, err)
+	}
+}
+
+func writeAccessToken(logger Logger, w http.ResponseWriter, token service.AccessToken, err error) {
+	if err != nil {
+		logger.Error("failed to issue access token", "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, accessTokenResponse{
+		AccessToken: token.Token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(token.Claims.Expires).Round(time.Second) / time.Second),
+		Scope:       strings.Join(token.Claims.Scopes, " "),
+	})
+}
+
+// ClaimsFromContext returns the claims stored by RequireAccessToken or the
+// gRPC access token interceptors.
+func ClaimsFromContext(ctx context.Context) (service.CustomerClaims, bool) {
+	c, ok := ctx.Value(claimsContextKey{}).(service.CustomerClaims)
+	return c, ok
+}
+
+// RequireAccessToken only lets requests with a valid bearer JWT access
+// token through to next, with its claims available from ClaimsFromContext.
+// Other requests are answered 401 Unauthorized.
+func RequireAccessToken(logger Logger, tokens TokenVerifier, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			writeError(w, http.StatusUnauthorized, ErrUnauthenticated)
+
+			return
+		}
+
+		claims, err := tokens.Verify(r.Context(), token)
+		if err != nil {
+			if invalidAccessToken(err) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, err)
+
+				return
+			}
+
+			logger.Error("failed to verify access token", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims)))
+	}
+}
+
+func invalidAccessToken(err error) bool {
+	return errors.Is(err, service.ErrTokenInvalid) || errors.Is(err, service.ErrTokenExpired)
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// newTokenService returns a token service knowing the customer with ID 1.
+func newTokenService(t *testing.T) *service.TokenService {
+	t.Helper()
+
+	customers := dal.NewMemoryCustomers()
+
+	_, err := customers.CreateCustomer(t.Context(), domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          true,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	tokens, err := service.NewTokenService(customers, service.TokenConfig{
+		Issuer:   "https://brokedaear.com",
+		Audience: "brokedaear",
+		TTL:      5 * time.Minute,
+		Rotation: time.Hour,
+	})
+	assert.NoError(t, err)
+
+	return tokens
+}
+
+func decodeAccessToken(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, res.TokenType, "Bearer")
+	assert.True(t, res.ExpiresIn > 0)
+
+	return res.AccessToken
+}
+
+func TestTokenRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	tokens := newTokenService(t)
+	mux := newMux(server.NewTokenRoutes(nopLogger{}, sessions, fakeDevices{err: nil}, tokens)...)
+
+	rec := request(t, mux, http.MethodGet, "/.well-known/jwks.json", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var set jwt.JWKS
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	assert.Equal(t, len(set.Keys), 2)
+
+	rec = post(mux, "/token", "", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	session, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	rec = post(mux, "/token", "", session)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Cache-Control"), "no-store")
+
+	// Other services verify tokens with the published keys alone.
+	var claims jwt.RegisteredClaims
+	assert.NoError(t, set.Verify(decodeAccessToken(t, rec), &claims))
+	assert.Equal(t, claims.Subject, "1")
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth/jwt", nil)
+	req.Header.Set("Authorization", "Bearer access")
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	got, err := tokens.Verify(t.Context(), decodeAccessToken(t, rec))
+	assert.NoError(t, err)
+	assert.Equal(t, got.ClientID, "microwave")
+	assert.True(t, got.HasScope("profile"))
+	assert.False(t, got.HasScope("licenses"))
+}
+
+func TestRequireAccessToken(t *testing.T) {
+	tokens := newTokenService(t)
+
+	token, err := tokens.Issue(t.Context(), 1, "", nil)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		test.CaseBase
+		header string
+	}{
+		{
+			CaseBase: test.NewCaseBase("valid token", http.StatusNoContent, false),
+			header:   "Bearer " + token.Token,
+		},
+		{
+			CaseBase: test.NewCaseBase("missing token", http.StatusUnauthorized, false),
+			header:   "",
+		},
+		{
+			CaseBase: test.NewCaseBase("forged token", http.StatusUnauthorized, false),
+			header:   "Bearer " + token.Token + "x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			h := server.RequireAccessToken(nopLogger{}, tokens, func(w http.ResponseWriter, r *http.Request) {
+				claims, ok := server.ClaimsFromContext(r.Context())
+				assert.True(t, ok)
+				assert.Equal(t, claims.CustomerID, 1)
+				assert.Equal(t, claims.Email, "jane@example.com")
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, tt.Want.(int))
+
+			if tt.Want.(int) != http.StatusNoContent {
+				assert.Equal(t, rec.Header().Get("WWW-Authenticate")[:6], "Bearer")
+			}
+		})
+	}
+}
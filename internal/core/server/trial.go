@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// TrialService starts the trials of plugins.
+type TrialService interface {
+	StartTrial(ctx context.Context, customerID, productID int, machine, name string) (service.SignedActivation, error)
+}
+
+// NewTrialRoutes returns the trial routes of customers.
+//
+//   - POST /trials: starts a trial of a plugin for the customer of the
+//     session, activated on the machine of the plugin, and returns its
+//     activation like POST /licenses/activate. The trial license is listed
+//     with the others of the customer until it expires or is bought.
+//
+// Customers who have not verified their email are answered with 403.
+func NewTrialRoutes(
+	logger Logger,
+	sessions SessionService,
+	verifications VerificationService,
+	trials TrialService,
+) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("POST /trials", RequireSession(logger, sessions,
+			RequireVerified(logger, verifications, startTrialHandler(logger, trials)))),
+	}
+}
+
+type startTrialRequest struct {
+	ProductID int    `json:"product_id"`
+	Machine   string `json:"machine"`
+	Name      string `json:"name"`
+}
+
+func startTrialHandler(logger Logger, trials TrialService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		var req startTrialRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		a, err := trials.StartTrial(r.Context(), sess.CustomerID, req.ProductID, req.Machine, req.Name)
+		if err != nil {
+			writeTrialError(logger, w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, signedActivationResponse{
+			activationResponse: newActivationResponse(a.Activation),
+			Token:              a.Token,
+			Expires:            a.Expires,
+		})
+	}
+}
+
+func writeTrialError(logger Logger, w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, service.ErrCustomerUnverified):
+		writeError(w, http.StatusForbidden, err)
+	case errors.Is(err, service.ErrTrialUsed), errors.Is(err, service.ErrLicenseOwned):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, service.ErrTrialUnavailable):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		writeActivationError(logger, w, err, "failed to start trial")
+	}
+}
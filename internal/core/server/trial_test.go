@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// newTrialTest returns a trial service over the licenses of newLicenseStore,
+// of the microwave plugin, with the oven plugin and a shirt to try, and
+// their product IDs. Customer 1 verified their email.
+func newTrialTest(t *testing.T) (*service.TrialService, map[string]int) {
+	t.Helper()
+
+	store, _ := newLicenseStore(t)
+	licenses, ring := newLicenseService(t, store)
+
+	products := dal.NewMemoryProducts()
+	ids := make(map[string]int)
+
+	for _, slug := range []string{"microwave", "oven", "shirt"} {
+		typ := domain.ProductPlugin
+		if slug == "shirt" {
+			typ = domain.ProductMerchandise
+		}
+
+		p, err := products.CreateProduct(t.Context(), domain.Product{
+			ID:           0,
+			Type:         typ,
+			Slug:         slug,
+			Name:         slug,
+			Description:  "",
+			Version:      "1.0.0",
+			MachineLimit: 0,
+			Media:        nil,
+			Published:    true,
+			Position:     0,
+			Prices:       nil,
+			PriceID:      "",
+			ProductID:    "",
+			Created:      time.Time{},
+			Updated:      time.Time{},
+		})
+		assert.NoError(t, err)
+
+		ids[slug] = p.ID
+	}
+
+	activations, err := service.NewActivationService(dal.NewMemoryActivations(), licenses, products,
+		ring, testLicenseConfig, service.ActivationConfig{
+			TokenTTL:        24 * time.Hour,
+			Inactivity:      0,
+			AnomalyMachines: 1,
+			AnomalyWindow:   time.Hour,
+		})
+	assert.NoError(t, err)
+
+	meter := noop.NewMeterProvider().Meter("test")
+	started, err := meter.Int64Counter("started")
+	assert.NoError(t, err)
+	converted, err := meter.Int64Counter("converted")
+	assert.NoError(t, err)
+
+	customers := dal.NewMemoryCustomers()
+
+	_, err = customers.CreateCustomer(t.Context(), domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          true,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	trials, err := service.NewTrialService(customers, store, nil, activations, products,
+		service.TrialMetrics{Started: started, Converted: converted}, testLicenseConfig,
+		service.TrialConfig{Duration: 14 * 24 * time.Hour})
+	assert.NoError(t, err)
+
+	return trials, ids
+}
+
+func TestTrialRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	trials, ids := newTrialTest(t)
+	verifications := &fakeVerifications{sendErr: nil, verified: map[int]bool{1: true}}
+	mux := newMux(server.NewTrialRoutes(nopLogger{}, sessions, verifications, trials)...)
+
+	start := func(productID int, machine string) string {
+		return `{"product_id":` + strconv.Itoa(productID) + `,"machine":"` + machine + `","name":"Studio Mac"}`
+	}
+
+	rec := send(t, mux, http.MethodPost, "/trials", start(ids["oven"], "studio-fingerprint-1"), "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	unverified, _, err := sessions.Create(t.Context(), 2, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	rec = send(t, mux, http.MethodPost, "/trials", start(ids["oven"], "studio-fingerprint-1"), unverified)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	var trial activationJSON
+
+	rec = send(t, mux, http.MethodPost, "/trials", start(ids["oven"], "studio-fingerprint-1"), token)
+	assert.Equal(t, rec.Code, http.StatusCreated)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &trial))
+	assert.NotEqual(t, trial.Token, "")
+	assert.Equal(t, trial.MachineName, "Studio Mac")
+
+	for _, tt := range []struct {
+		name, body string
+		want       int
+	}{
+		{"tried", start(ids["oven"], "laptop-fingerprint-2"), http.StatusConflict},
+		{"owned", start(ids["microwave"], "laptop-fingerprint-2"), http.StatusConflict},
+		{"merchandise", start(ids["shirt"], "laptop-fingerprint-2"), http.StatusUnprocessableEntity},
+		{"unknown product", start(1000, "laptop-fingerprint-2"), http.StatusNotFound},
+		{"invalid machine", start(ids["oven"], "short"), http.StatusUnprocessableEntity},
+		{"malformed body", `{`, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send(t, mux, http.MethodPost, "/trials", tt.body, token)
+			assert.Equal(t, rec.Code, tt.want)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// TwoFactorService manages the second factor of customers.
+type TwoFactorService interface {
+	BeginEnrollment(ctx context.Context, customerID int) (service.Enrollment, error)
+	ConfirmEnrollment(ctx context.Context, session domain.Session, code, ip string) ([]string, error)
+	Disable(ctx context.Context, session domain.Session, code, ip string) error
+	RegenerateRecoveryCodes(ctx context.Context, session domain.Session, code, ip string) ([]string, error)
+	StepUp(ctx context.Context, session domain.Session, code, ip string) error
+	RequireStepUp(ctx context.Context, session domain.Session) error
+}
+
+// NewTwoFactorRoutes returns the routes with which customers manage their
+// second factor. They all require a session, and all but the first take a
+// JSON body with a code: a TOTP code, or a recovery code where noted.
+//
+//   - POST /2fa/enroll: draws a TOTP secret and answers with it and its
+//     provisioning URI, to show as a QR code.
+//   - POST /2fa/confirm: enables the second factor with a first TOTP code,
+//     and answers with the recovery codes.
+//   - POST /2fa/disable: disables the second factor.
+//   - POST /2fa/recovery-codes: replaces the recovery codes and answers with
+//     the new ones.
+//   - POST /2fa/step-up: steps the session up before a sensitive action,
+//     and rotates its token.
+//
+// Wrong codes are answered with 403, and too many with 429 and a
+// Retry-After header.
+func NewTwoFactorRoutes(logger Logger, sessions SessionService, twoFactor TwoFactorService) []HTTPRoute {
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequireSession(logger, sessions, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("POST /2fa/enroll", auth(enrollHandler(logger, twoFactor))),
+		NewRoute("POST /2fa/confirm", auth(codeHandler(logger, "failed to confirm two-factor enrollment",
+			func(ctx context.Context, sess domain.Session, code, ip string) (any, error) {
+				codes, err := twoFactor.ConfirmEnrollment(ctx, sess, code, ip)
+				return recoveryCodesResponse{RecoveryCodes: codes}, err
+			}))),
+		NewRoute("POST /2fa/disable", auth(codeHandler(logger, "failed to disable two-factor authentication",
+			func(ctx context.Context, sess domain.Session, code, ip string) (any, error) {
+				return nil, twoFactor.Disable(ctx, sess, code, ip)
+			}))),
+		NewRoute("POST /2fa/recovery-codes", auth(codeHandler(logger, "failed to regenerate recovery codes",
+			func(ctx context.Context, sess domain.Session, code, ip string) (any, error) {
+				codes, err := twoFactor.RegenerateRecoveryCodes(ctx, sess, code, ip)
+				return recoveryCodesResponse{RecoveryCodes: codes}, err
+			}))),
+		NewRoute("POST /2fa/step-up", auth(stepUpHandler(logger, sessions, twoFactor))),
+	}
+}
+
+type enrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type codeRequest struct {
+	Code string `json:"code"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func enrollHandler(logger Logger, svc TwoFactorService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		e, err := svc.BeginEnrollment(r.Context(), sess.CustomerID)
+		if err != nil {
+			writeTwoFactorError(logger, w, err, "failed to begin two-factor enrollment")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, enrollmentResponse{Secret: e.Secret, URI: e.URI})
+	}
+}
+
+// codeHandler decodes a code and passes it to do, answering with the value
+// do returns, or 204 No Content when it is nil.
+func codeHandler(
+	logger Logger,
+	msg string,
+	do func(ctx context.Context, sess domain.Session, code, ip string) (any, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req codeRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		sess, _ := SessionFromContext(r.Context())
+
+		res, err := do(r.Context(), sess, req.Code, ClientIP(r))
+		if err != nil {
+			writeTwoFactorError(logger, w, err, msg)
+			return
+		}
+
+		if res == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func stepUpHandler(logger Logger, sessions SessionService, svc TwoFactorService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req codeRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		sess, _ := SessionFromContext(r.Context())
+
+		err = svc.StepUp(r.Context(), sess, req.Code, ClientIP(r))
+		if err != nil {
+			writeTwoFactorError(logger, w, err, "failed to step up session")
+			return
+		}
+
+		err = rotateSession(w, r, sessions)
+		if err != nil {
+			logger.Error("failed to rotate session", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeTwoFactorError answers a failed two-factor operation. A wrong code is
+// answered with 403 rather than 401, which would tell the client its
+// session is gone.
+func writeTwoFactorError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	var retryErr *service.RetryError
+
+	switch {
+	case errors.As(err, &retryErr):
+		writeRetry(w, retryErr)
+	case errors.Is(err, service.ErrInvalidCode):
+		writeError(w, http.StatusForbidden, err)
+	case errors.Is(err, service.ErrTwoFactorEnabled),
+		errors.Is(err, service.ErrTwoFactorDisabled),
+		errors.Is(err, service.ErrEnrollmentNotStarted):
+		writeError(w, http.StatusConflict, err)
+	default:
+		logger.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
+
+// RequireStepUp only lets sessions that entered a two-factor code recently
+// through to next; others are answered with 403 Forbidden, and must go
+// through POST /2fa/step-up first. Customers without a second factor are let
+// through. It guards sensitive actions, such as changing the email or
+// transferring a license, and must be wrapped by RequireSession.
+func RequireStepUp(logger Logger, twoFactor TwoFactorService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, ok := SessionFromContext(r.Context())
+		if !ok {
+			writeError(w, http.StatusUnauthorized, ErrUnauthenticated)
+			return
+		}
+
+		err := twoFactor.RequireStepUp(r.Context(), sess)
+		if err != nil {
+			if errors.Is(err, service.ErrStepUpRequired) {
+				writeError(w, http.StatusForbidden, err)
+				return
+			}
+
+			logger.Error("failed to check step-up", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		next(w, r)
+	}
+}
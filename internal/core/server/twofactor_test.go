@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// fakeTwoFactor answers every call with err.
+type fakeTwoFactor struct {
+	err error
+}
+
+func (f fakeTwoFactor) BeginEnrollment(context.Context, int) (service.Enrollment, error) {
+	return service.Enrollment{Secret: "SECRET", URI: "otpauth://totp/BROKE%20DA%20EAR:jane@example.com"}, f.err
+}
+
+func (f fakeTwoFactor) ConfirmEnrollment(context.Context, domain.Session, string, string) ([]string, error) {
+	return []string{"AAAA-BBBB-CCCC-DDDD"}, f.err
+}
+
+func (f fakeTwoFactor) Disable(context.Context, domain.Session, string, string) error {
+	return f.err
+}
+
+func (f fakeTwoFactor) RegenerateRecoveryCodes(context.Context, domain.Session, string, string) ([]string, error) {
+	return []string{"AAAA-BBBB-CCCC-DDDD"}, f.err
+}
+
+func (f fakeTwoFactor) StepUp(context.Context, domain.Session, string, string) error {
+	return f.err
+}
+
+func (f fakeTwoFactor) RequireStepUp(context.Context, domain.Session) error {
+	return f.err
+}
+
+func TestTwoFactorRoutes(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		err        error
+		retryAfter string
+	}{
+		{
+			CaseBase:   test.NewCaseBase("success", http.StatusOK, false),
+			err:        nil,
+			retryAfter: "",
+		},
+		{
+			CaseBase:   test.NewCaseBase("invalid code", http.StatusForbidden, false),
+			err:        service.ErrInvalidCode,
+			retryAfter: "",
+		},
+		{
+			CaseBase:   test.NewCaseBase("locked", http.StatusTooManyRequests, false),
+			err:        &service.RetryError{Err: service.ErrTwoFactorLocked, RetryAfter: time.Minute},
+			retryAfter: "60",
+		},
+		{
+			CaseBase:   test.NewCaseBase("not enabled", http.StatusConflict, false),
+			err:        service.ErrTwoFactorDisabled,
+			retryAfter: "",
+		},
+		{
+			CaseBase:   test.NewCaseBase("internal failure", http.StatusInternalServerError, false),
+			err:        errors.New("database is down"),
+			retryAfter: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			sessions := newSessionService(t)
+			mux := newMux(server.NewTwoFactorRoutes(nopLogger{}, sessions, fakeTwoFactor{err: tt.err})...)
+
+			token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+			assert.NoError(t, err)
+
+			for _, target := range []string{"/2fa/confirm", "/2fa/disable", "/2fa/recovery-codes", "/2fa/step-up"} {
+				rec := post(mux, target, `{"code":"123456"}`, token)
+
+				want := tt.Want.(int)
+				if want == http.StatusOK && (target == "/2fa/disable" || target == "/2fa/step-up") {
+					want = http.StatusNoContent
+				}
+
+				assert.Equal(t, rec.Code, want)
+				assert.Equal(t, rec.Header().Get("Retry-After"), tt.retryAfter)
+				assert.False(t, strings.Contains(rec.Body.String(), "database is down"))
+
+				// A step-up rotates the session token.
+				if target == "/2fa/step-up" && tt.err == nil {
+					token = rotatedToken(rec)
+					assert.NotEqual(t, token, "")
+				}
+			}
+		})
+	}
+}
+
+func TestTwoFactorRoutes_Enroll(t *testing.T) {
+	sessions := newSessionService(t)
+	mux := newMux(server.NewTwoFactorRoutes(nopLogger{}, sessions, fakeTwoFactor{err: nil})...)
+
+	rec := post(mux, "/2fa/enroll", "", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	rec = post(mux, "/2fa/enroll", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var res struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, res.Secret, "SECRET")
+	assert.True(t, strings.HasPrefix(res.URI, "otpauth://"))
+}
+
+func TestRequireStepUp(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusNoContent},
+		{service.ErrStepUpRequired, http.StatusForbidden},
+		{errors.New("database is down"), http.StatusInternalServerError},
+	} {
+		sessions := newSessionService(t)
+
+		token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+		assert.NoError(t, err)
+
+		h := server.RequireSession(nopLogger{}, sessions,
+			server.RequireStepUp(nopLogger{}, fakeTwoFactor{err: tt.err}, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}),
+		)
+
+		rec := request(t, h, http.MethodPost, "/sensitive", token)
+		assert.Equal(t, rec.Code, tt.want)
+	}
+}
+
+func TestStepUpRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), dal.NewMemoryAuditLog())
+	licenses, keys := newLicenseTest(t)
+	stale := fakeTwoFactor{err: service.ErrStepUpRequired}
+
+	mux := newMux(slices.Concat(
+		server.NewSessionRoutes(nopLogger{}, sessions, stale, fakeDevices{err: nil}),
+		server.NewPasswordRoutes(nopLogger{}, sessions, stale, fakePasswords{err: nil, refused: false},
+			&fakeResets{size: 1, queued: nil}),
+		server.NewDeviceRoutes(nopLogger{}, sessions, stale, fakeDevices{err: nil}),
+		server.NewAdminGroup(nopLogger{}, sessions, authz, slices.Concat(
+			server.NewRoleRoutes(nopLogger{}, authz, stale),
+			server.NewLicenseAdminRoutes(nopLogger{}, authz, stale, licenses),
+		)...),
+	)...)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, authz.Grant(t.Context(), 1, domain.RoleAdmin, ""))
+
+	// Sessions that did not step up recently are turned away before the
+	// body is read.
+	for _, tt := range []struct{ method, target string }{
+		{http.MethodDelete, "/sessions"},
+		{http.MethodPost, "/password/change"},
+		{http.MethodPost, "/device/approve"},
+		{http.MethodPut, "/admin/roles/auditor"},
+		{http.MethodDelete, "/admin/roles/auditor"},
+		{http.MethodPut, "/admin/customers/2/roles/support"},
+		{http.MethodDelete, "/admin/customers/2/roles/support"},
+		{http.MethodPost, "/admin/licenses/" + keys[0] + "/revoke"},
+	} {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			rec := send(t, mux, tt.method, tt.target, `{}`, token)
+			assert.Equal(t, rec.Code, http.StatusForbidden)
+			assert.True(t, strings.Contains(rec.Body.String(), service.ErrStepUpRequired.Error()))
+		})
+	}
+
+	// Reading needs no step-up.
+	rec := send(t, mux, http.MethodGet, "/admin/roles", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// UpgradeService prices the upgrades of customers, and manages the rules
+// they are priced by.
+type UpgradeService interface {
+	Offers(
+		ctx context.Context,
+		customerID int,
+		currency domain.Currency,
+		region domain.Region,
+	) ([]service.UpgradeOffer, error)
+	Rules(ctx context.Context) ([]domain.UpgradeRule, error)
+	CreateRule(ctx context.Context, r domain.UpgradeRule) (domain.UpgradeRule, error)
+	DeleteRule(ctx context.Context, id int) error
+}
+
+// NewUpgradeRoutes returns the upgrade routes of customers.
+//
+//   - GET /upgrades: lists the plugins the customer of the session may buy
+//     at an upgrade price, with the license that makes them eligible, in
+//     the currency and region query parameters like GET /cart. Carts
+//     apply these prices by themselves.
+func NewUpgradeRoutes(logger Logger, sessions SessionService, upgrades UpgradeService) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("GET /upgrades", RequireSession(logger, sessions, upgradeOffersHandler(logger, upgrades))),
+	}
+}
+
+// NewUpgradeAdminRoutes returns the routes with which staff manage upgrade
+// rules. They require the products:manage permission, and are meant to be
+// mounted with NewAdminGroup.
+//
+//   - GET /upgrades: lists the upgrade rules.
+//   - POST /upgrades: creates an upgrade rule from a JSON body with a
+//     from_product_id, a from_version, a to_product_id, and either prices
+//     or a percent_off.
+//   - DELETE /upgrades/{id}: deletes an upgrade rule.
+func NewUpgradeAdminRoutes(logger Logger, authz Authorizer, upgrades UpgradeService) []HTTPRoute {
+	manage := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequirePermission(logger, authz, domain.PermissionManageProducts, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /upgrades", manage(listUpgradeRulesHandler(logger, upgrades))),
+		NewRoute("POST /upgrades", manage(createUpgradeRuleHandler(logger, upgrades))),
+		NewRoute("DELETE /upgrades/{id}", manage(deleteUpgradeRuleHandler(logger, upgrades))),
+	}
+}
+
+type upgradeRuleRequest struct {
+	FromProductID int         `json:"from_product_id"`
+	FromVersion   string      `json:"from_version"`
+	ToProductID   int         `json:"to_product_id"`
+	Prices        []priceJSON `json:"prices"`
+	PercentOff    int         `json:"percent_off"`
+}
+
+func (req upgradeRuleRequest) rule() domain.UpgradeRule {
+	prices := make([]domain.Price, 0, len(req.Prices))
+	for _, price := range req.Prices {
+		prices = append(prices, domain.Price{
+			Region: price.Region,
+			Money:  domain.Money{Amount: price.Amount, Currency: price.Currency},
+		})
+	}
+
+	return domain.UpgradeRule{
+		ID:            0,
+		FromProductID: req.FromProductID,
+		FromVersion:   req.FromVersion,
+		ToProductID:   req.ToProductID,
+		Prices:        prices,
+		PercentOff:    req.PercentOff,
+		Created:       time.Time{},
+	}
+}
+
+type upgradeRuleResponse struct {
+	ID            int         `json:"id"`
+	FromProductID int         `json:"from_product_id"`
+	FromVersion   string      `json:"from_version"`
+	ToProductID   int         `json:"to_product_id"`
+	Prices        []priceJSON `json:"prices,omitempty"`
+	PercentOff    int         `json:"percent_off,omitempty"`
+	Created       time.Time   `json:"created"`
+}
+
+func newUpgradeRuleResponse(r domain.UpgradeRule, locale string) upgradeRuleResponse {
+	prices := make([]priceJSON, 0, len(r.Prices))
+	for _, price := range r.Prices {
+		prices = append(prices, newPriceJSON(price.Money, price.Region, locale))
+	}
+
+	return upgradeRuleResponse{
+		ID:            r.ID,
+		FromProductID: r.FromProductID,
+		FromVersion:   r.FromVersion,
+		ToProductID:   r.ToProductID,
+		Prices:        prices,
+		PercentOff:    r.PercentOff,
+		Created:       r.Created,
+	}
+}
+
+type upgradeOfferResponse struct {
+	ProductID int    `json:"product_id"`
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+
+	// FromKey is the key of the license of the customer the upgrade is
+	// from.
+	FromKey   string    `json:"from_key"`
+	ListPrice priceJSON `json:"list_price"`
+	Price     priceJSON `json:"price"`
+}
+
+func upgradeOffersHandler(logger Logger, upgrades UpgradeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+		currency, region := cartPricing(r)
+		locale := requestLocale(r)
+
+		offers, err := upgrades.Offers(r.Context(), sess.CustomerID, currency, region)
+		if err != nil {
+			writeUpgradeError(logger, w, err, "failed to list upgrade offers")
+			return
+		}
+
+		res := make([]upgradeOfferResponse, 0, len(offers))
+		for _, o := range offers {
+			res = append(res, upgradeOfferResponse{
+				ProductID: o.Product.ID,
+				Slug:      o.Product.Slug,
+				Name:      o.Product.Name,
+				FromKey:   o.From.Key,
+				ListPrice: newPriceJSON(o.ListPrice, region, locale),
+				Price:     newPriceJSON(o.Price, region, locale),
+			})
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func listUpgradeRulesHandler(logger Logger, upgrades UpgradeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := upgrades.Rules(r.Context())
+		if err != nil {
+			writeUpgradeError(logger, w, err, "failed to list upgrade rules")
+			return
+		}
+
+		res := make([]upgradeRuleResponse, 0, len(rules))
+		for _, rule := range rules {
+			res = append(res, newUpgradeRuleResponse(rule, requestLocale(r)))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func createUpgradeRuleHandler(logger Logger, upgrades UpgradeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req upgradeRuleRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		rule, err := upgrades.CreateRule(r.Context(), req.rule())
+		if err != nil {
+			writeUpgradeError(logger, w, err, "failed to create upgrade rule")
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, newUpgradeRuleResponse(rule, requestLocale(r)))
+	}
+}
+
+func deleteUpgradeRuleHandler(logger Logger, upgrades UpgradeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, service.ErrUpgradeRuleNotFound)
+			return
+		}
+
+		err = upgrades.DeleteRule(r.Context(), id)
+		if err != nil {
+			writeUpgradeError(logger, w, err, "failed to delete upgrade rule")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeUpgradeError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	var (
+		ruleErr    domain.UpgradeError
+		upgradeErr service.UpgradeError
+		productErr domain.ProductError
+		moneyErr   domain.MoneyError
+	)
+
+	switch {
+	case errors.Is(err, service.ErrUpgradeRuleNotFound), errors.Is(err, service.ErrProductNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.As(err, &ruleErr), errors.As(err, &upgradeErr), errors.As(err, &productErr),
+		errors.As(err, &moneyErr):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		logger.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, errInternal)
+	}
+}
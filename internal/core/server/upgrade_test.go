@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// newUpgradeService returns an upgrade service over the test products,
+// with a license of customer 1 of version 1.2.0 of the microwave, and its
+// key.
+func newUpgradeService(t *testing.T) (*service.UpgradeService, string) {
+	t.Helper()
+
+	licenses := dal.NewMemoryLicenses()
+
+	l, err := licenses.CreateLicense(t.Context(), domain.License{
+		ID:           0,
+		Key:          domain.NewLicenseKey(),
+		OrderID:      1,
+		Line:         0,
+		Seat:         0,
+		CustomerID:   1,
+		ProductID:    1,
+		Version:      "1.2.0",
+		Edition:      domain.EditionStandard,
+		Issued:       time.Now(),
+		Expires:      time.Time{},
+		Revoked:      time.Time{},
+		RevokeReason: "",
+		UpgradedFrom: 0,
+	})
+	assert.NoError(t, err)
+
+	upgrades := service.NewUpgradeService(dal.NewMemoryUpgradeRules(), licenses, dal.NewMemoryOrders(), newTestProducts(t))
+
+	return upgrades, l.Key
+}
+
+func TestUpgradeAdminRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), dal.NewMemoryAuditLog())
+	upgrades, _ := newUpgradeService(t)
+
+	mux := newMux(server.NewAdminGroup(nopLogger{}, sessions, authz,
+		server.NewUpgradeAdminRoutes(nopLogger{}, authz, upgrades)...)...)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	// Support staff reach the admin routes but may not manage upgrades.
+	assert.NoError(t, authz.Grant(t.Context(), 1, domain.RoleSupport, ""))
+
+	rec := send(t, mux, http.MethodGet, "/admin/upgrades", "", token)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	assert.NoError(t, authz.Grant(t.Context(), 1, domain.RoleAdmin, ""))
+
+	rec = send(t, mux, http.MethodPost, "/admin/upgrades",
+		`{"from_product_id":1,"from_version":"1.","to_product_id":1,"percent_off":50}`, token)
+	assert.Equal(t, rec.Code, http.StatusCreated)
+
+	var rules []struct {
+		ID          int    `json:"id"`
+		FromVersion string `json:"from_version"`
+		PercentOff  int    `json:"percent_off"`
+	}
+
+	rec = send(t, mux, http.MethodGet, "/admin/upgrades", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rules))
+	assert.Equal(t, len(rules), 1)
+	assert.Equal(t, rules[0].FromVersion, "1.")
+	assert.Equal(t, rules[0].PercentOff, 50)
+
+	for _, tt := range []struct {
+		name, method, target, body string
+		want                       int
+	}{
+		{"malformed body", http.MethodPost, "/admin/upgrades", `{`, http.StatusBadRequest},
+		{"no discount", http.MethodPost, "/admin/upgrades", `{"from_product_id":1,"to_product_id":3}`, http.StatusUnprocessableEntity},
+		{"merchandise", http.MethodPost, "/admin/upgrades", `{"from_product_id":1,"to_product_id":2,"percent_off":10}`, http.StatusUnprocessableEntity},
+		{"unknown product", http.MethodPost, "/admin/upgrades", `{"from_product_id":1,"to_product_id":1000,"percent_off":10}`, http.StatusNotFound},
+		{"delete", http.MethodDelete, "/admin/upgrades/1", "", http.StatusNoContent},
+		{"deleted", http.MethodDelete, "/admin/upgrades/1", "", http.StatusNotFound},
+		{"malformed id", http.MethodDelete, "/admin/upgrades/first", "", http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send(t, mux, tt.method, tt.target, tt.body, token)
+			assert.Equal(t, rec.Code, tt.want)
+		})
+	}
+}
+
+func TestUpgradeRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	upgrades, key := newUpgradeService(t)
+	mux := newMux(server.NewUpgradeRoutes(nopLogger{}, sessions, upgrades)...)
+
+	_, err := upgrades.CreateRule(t.Context(), domain.UpgradeRule{
+		ID:            0,
+		FromProductID: 1,
+		FromVersion:   "1.",
+		ToProductID:   1,
+		Prices:        nil,
+		PercentOff:    50,
+		Created:       time.Time{},
+	})
+	assert.NoError(t, err)
+
+	rec := request(t, mux, http.MethodGet, "/upgrades?currency=EUR", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	var offers []struct {
+		Slug      string `json:"slug"`
+		FromKey   string `json:"from_key"`
+		ListPrice struct {
+			Amount int64 `json:"amount"`
+		} `json:"list_price"`
+		Price struct {
+			Amount int64 `json:"amount"`
+		} `json:"price"`
+	}
+
+	for _, tt := range []struct {
+		customerID int
+		target     string
+		want       int
+	}{
+		{1, "/upgrades?currency=EUR", 1},
+		{1, "/upgrades", 0},
+		{2, "/upgrades?currency=EUR", 0},
+	} {
+		token, _, err := sessions.Create(t.Context(), tt.customerID, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+		assert.NoError(t, err)
+
+		rec = request(t, mux, http.MethodGet, tt.target, token)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &offers))
+		assert.Equal(t, len(offers), tt.want)
+	}
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	rec = request(t, mux, http.MethodGet, "/upgrades?currency=EUR", token)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &offers))
+	assert.Equal(t, offers[0].Slug, "microwave")
+	assert.Equal(t, offers[0].FromKey, key)
+	assert.Equal(t, offers[0].ListPrice.Amount, 123456)
+	assert.Equal(t, offers[0].Price.Amount, 61728)
+
+	rec = request(t, mux, http.MethodGet, "/upgrades?currency=XXX", token)
+	assert.Equal(t, rec.Code, http.StatusUnprocessableEntity)
+}
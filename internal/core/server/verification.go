@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// VerificationService verifies the emails of customers.
+type VerificationService interface {
+	VerificationSender
+	Verify(ctx context.Context, token string) (int, error)
+	RequireVerified(ctx context.Context, customerID int) error
+}
+
+// NewVerificationRoutes returns the email verification routes.
+//
+//   - POST /customers/verification: mails the logged in customer a new
+//     verification link. Asking again too soon is answered with 429 and a
+//     Retry-After header.
+//   - POST /customers/verify: verifies the email of a customer from a JSON
+//     body with the token of a verification link.
+func NewVerificationRoutes(logger Logger, sessions SessionService, verifications VerificationService) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute(
+			"POST /customers/verification",
+			RequireSession(logger, sessions, resendVerificationHandler(logger, verifications)),
+		),
+		NewRoute("POST /customers/verify", verifyHandler(logger, verifications)),
+	}
+}
+
+func resendVerificationHandler(logger Logger, svc VerificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := SessionFromContext(r.Context())
+
+		err := svc.Send(r.Context(), sess.CustomerID)
+		if err != nil {
+			var retryErr *service.RetryError
+
+			switch {
+			case errors.As(err, &retryErr):
+				writeRetry(w, retryErr)
+			case errors.Is(err, service.ErrAlreadyVerified):
+				writeError(w, http.StatusConflict, err)
+			default:
+				logger.Error("failed to send verification email", "error", err)
+				writeError(w, http.StatusInternalServerError, errInternal)
+			}
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+type verifyRequest struct {
+	Token string `json:"token"`
+}
+
+func verifyHandler(logger Logger, svc VerificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req verifyRequest
+
+		err := decodeJSON(w, r, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		_, err = svc.Verify(r.Context(), req.Token)
+		if err != nil {
+			if errors.Is(err, service.ErrVerificationInvalid) || errors.Is(err, service.ErrVerificationExpired) {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			logger.Error("failed to verify email", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RequireVerified only lets customers who verified their email through to
+// next; others are answered with 403 Forbidden. It guards purchases and
+// downloads, and must be wrapped by RequireSession.
+func RequireVerified(logger Logger, verifications VerificationService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, ok := SessionFromContext(r.Context())
+		if !ok {
+			writeError(w, http.StatusUnauthorized, ErrUnauthenticated)
+			return
+		}
+
+		err := verifications.RequireVerified(r.Context(), sess.CustomerID)
+		if err != nil {
+			if errors.Is(err, service.ErrCustomerUnverified) {
+				writeError(w, http.StatusForbidden, err)
+				return
+			}
+
+			logger.Error("failed to check verification", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// fakeVerifications verifies the token "valid" and considers the
+// customers in verified as verified.
+type fakeVerifications struct {
+	sendErr  error
+	verified map[int]bool
+}
+
+func (f *fakeVerifications) Send(context.Context, int) error {
+	return f.sendErr
+}
+
+func (f *fakeVerifications) Verify(_ context.Context, token string) (int, error) {
+	if token != "valid" {
+		return 0, service.ErrVerificationInvalid
+	}
+
+	f.verified[1] = true
+
+	return 1, nil
+}
+
+func (f *fakeVerifications) RequireVerified(_ context.Context, customerID int) error {
+	if !f.verified[customerID] {
+		return service.ErrCustomerUnverified
+	}
+
+	return nil
+}
+
+func TestVerificationRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	verifications := &fakeVerifications{sendErr: nil, verified: map[int]bool{}}
+	mux := newMux(server.NewVerificationRoutes(nopLogger{}, sessions, verifications)...)
+
+	token, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	rec := request(t, mux, http.MethodPost, "/customers/verification", "")
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	rec = request(t, mux, http.MethodPost, "/customers/verification", token)
+	assert.Equal(t, rec.Code, http.StatusAccepted)
+
+	verifications.sendErr = &service.RetryError{Err: service.ErrVerificationThrottled, RetryAfter: 30 * time.Second}
+	rec = request(t, mux, http.MethodPost, "/customers/verification", token)
+	assert.Equal(t, rec.Code, http.StatusTooManyRequests)
+	assert.Equal(t, rec.Header().Get("Retry-After"), "30")
+
+	verify := func(body string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/customers/verify", strings.NewReader(body)))
+
+		return rec.Code
+	}
+
+	assert.Equal(t, verify(`{"token":"forged"}`), http.StatusBadRequest)
+	assert.Equal(t, verify(`{"token":"valid"}`), http.StatusNoContent)
+}
+
+func TestRequireVerified(t *testing.T) {
+	sessions := newSessionService(t)
+	verifications := &fakeVerifications{sendErr: nil, verified: map[int]bool{2: true}}
+
+	h := server.RequireSession(nopLogger{}, sessions, server.RequireVerified(nopLogger{}, verifications,
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	))
+
+	unverified, _, err := sessions.Create(t.Context(), 1, service.SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	verified, _, err := sessions.Create(t.Context(), 2, service.SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	rec := request(t, h, http.MethodGet, "/downloads", unverified)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	rec = request(t, h, http.MethodGet, "/downloads", verified)
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
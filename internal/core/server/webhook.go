@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+// stripeSignatureHeader is the header carrying the signature of Stripe
+// webhook deliveries.
+const stripeSignatureHeader = "Stripe-Signature"
+
+// webhookListLimit bounds how many events the admin routes list at once.
+const webhookListLimit = 100
+
+// WebhookReceiver receives the webhook deliveries of the payment provider.
+type WebhookReceiver interface {
+	Receive(ctx context.Context, payload []byte, signature string) (domain.WebhookEvent, error)
+}
+
+// WebhookInbox is the inbox of received webhook events, as staff see it.
+type WebhookInbox interface {
+	Event(ctx context.Context, id string) (domain.WebhookEvent, error)
+	Events(ctx context.Context, status domain.WebhookStatus, limit int) ([]domain.WebhookEvent, error)
+	Replay(ctx context.Context, id string) (domain.WebhookEvent, error)
+}
+
+// NewWebhookRoutes returns the routes Stripe delivers webhook events to.
+// They are authenticated by the signature of deliveries, not by sessions,
+// and must not be behind CSRF protection.
+//
+//   - POST /webhooks/stripe: stores the delivered event to be processed,
+//     and answers with 204. Deliveries that are not signed by Stripe are
+//     answered with 400, which Stripe does not retry.
+func NewWebhookRoutes(logger Logger, webhooks WebhookReceiver) []HTTPRoute {
+	return []HTTPRoute{
+		NewRoute("POST /webhooks/stripe", receiveWebhookHandler(logger, webhooks)),
+	}
+}
+
+func receiveWebhookHandler(logger Logger, webhooks WebhookReceiver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The signature covers the exact bytes delivered, so the body is
+		// read as is rather than decoded.
+		payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ErrMalformedBody)
+			return
+		}
+
+		_, err = webhooks.Receive(r.Context(), payload, r.Header.Get(stripeSignatureHeader))
+		switch {
+		case errors.Is(err, service.ErrWebhookInvalid):
+			logger.Warn("rejected webhook delivery", "error", err)
+			writeError(w, http.StatusBadRequest, service.ErrWebhookInvalid)
+		case err != nil:
+			logger.Error("failed to receive webhook", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// NewWebhookAdminRoutes returns the admin routes of the webhook inbox. They
+// require the payments:manage permission.
+//
+//   - GET /webhooks: lists the most recently received events with the
+//     status query parameter, dead by default: the dead-letter queue.
+//   - GET /webhooks/{id}: returns an event, with its payload.
+//   - POST /webhooks/{id}/replay: queues an event to be processed again.
+func NewWebhookAdminRoutes(logger Logger, authz Authorizer, inbox WebhookInbox) []HTTPRoute {
+	manage := func(h http.HandlerFunc) http.HandlerFunc {
+		return RequirePermission(logger, authz, domain.PermissionManagePayments, h)
+	}
+
+	return []HTTPRoute{
+		NewRoute("GET /webhooks", manage(listWebhooksHandler(logger, inbox))),
+		NewRoute("GET /webhooks/{id}", manage(webhookHandler(logger, inbox))),
+		NewRoute("POST /webhooks/{id}/replay", manage(replayWebhookHandler(logger, inbox))),
+	}
+}
+
+type webhookEventResponse struct {
+	ID          string                  `json:"id"`
+	Type        domain.PaymentEventType `json:"type"`
+	Status      domain.WebhookStatus    `json:"status"`
+	Attempts    int                     `json:"attempts"`
+	LastError   string                  `json:"last_error,omitempty"`
+	Received    time.Time               `json:"received"`
+	NextAttempt time.Time               `json:"next_attempt"`
+	Processed   *time.Time              `json:"processed,omitempty"`
+	Payload     json.RawMessage         `json:"payload,omitempty"`
+}
+
+// newWebhookEventResponse returns the response describing e, with its
+// payload when withPayload is set.
+func newWebhookEventResponse(e domain.WebhookEvent, withPayload bool) webhookEventResponse {
+	res := webhookEventResponse{
+		ID:          e.ID,
+		Type:        e.Type,
+		Status:      e.Status,
+		Attempts:    e.Attempts,
+		LastError:   e.LastError,
+		Received:    e.Received,
+		NextAttempt: e.NextAttempt,
+		Processed:   nil,
+		Payload:     nil,
+	}
+
+	if !e.Processed.IsZero() {
+		res.Processed = &e.Processed
+	}
+
+	if withPayload && json.Valid(e.Payload) {
+		res.Payload = e.Payload
+	}
+
+	return res
+}
+
+func listWebhooksHandler(logger Logger, inbox WebhookInbox) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := domain.WebhookDead
+
+		if v := r.URL.Query().Get("status"); v != "" {
+			status = domain.WebhookStatus(v)
+
+			if !slices.Contains(domain.WebhookStatuses(), status) {
+				writeError(w, http.StatusUnprocessableEntity, ErrInvalidWebhookStatus)
+				return
+			}
+		}
+
+		events, err := inbox.Events(r.Context(), status, webhookListLimit)
+		if err != nil {
+			logger.Error("failed to list webhook events", "error", err)
+			writeError(w, http.StatusInternalServerError, errInternal)
+
+			return
+		}
+
+		res := make([]webhookEventResponse, 0, len(events))
+		for _, e := range events {
+			res = append(res, newWebhookEventResponse(e, false))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func webhookHandler(logger Logger, inbox WebhookInbox) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		e, err := inbox.Event(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeWebhookError(logger, w, err, "failed to find webhook event")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newWebhookEventResponse(e, true))
+	}
+}
+
+func replayWebhookHandler(logger Logger, inbox WebhookInbox) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		e, err := inbox.Replay(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeWebhookError(logger, w, err, "failed to replay webhook event")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newWebhookEventResponse(e, false))
+	}
+}
+
+func writeWebhookError(logger Logger, w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, service.ErrWebhookEventNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	logger.Error(msg, "error", err)
+	writeError(w, http.StatusInternalServerError, errInternal)
+}
+
+const ErrInvalidWebhookStatus HandlerError = "webhook status must be pending, processed or dead"
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/adapters"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/server"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+const refundedEvent = `{"id":"evt_1","type":"charge.refunded","created":1767225600,"data":{"object":{
+	"id":"ch_1","payment_intent":"pi_1","amount":4900,"amount_refunded":4900,"currency":"usd","refunded":true}}}`
+
+func newWebhookService(t *testing.T) *service.WebhookService {
+	t.Helper()
+
+	stripe, err := adapters.NewStripeWebhook(adapters.StripeWebhookConfig{
+		Secrets:   []string{"whsec_test"},
+		Tolerance: 5 * time.Minute,
+	})
+	assert.NoError(t, err)
+
+	webhooks, err := service.NewWebhookService(dal.NewMemoryWebhookInbox(), stripe, service.WebhookConfig{
+		MaxAttempts:   1,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: time.Hour,
+		Lease:         time.Minute,
+		BatchSize:     10,
+		Retention:     time.Hour,
+	})
+	assert.NoError(t, err)
+
+	return webhooks
+}
+
+func deliver(t *testing.T, h http.Handler, payload, signature string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", strings.NewReader(payload))
+	req.Header.Set(adapters.StripeSignatureHeader, signature)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestWebhookRoutes(t *testing.T) {
+	webhooks := newWebhookService(t)
+	mux := newMux(server.NewWebhookRoutes(nopLogger{}, webhooks)...)
+	signature := adapters.SignStripeWebhook("whsec_test", []byte(refundedEvent), time.Now())
+
+	rec := deliver(t, mux, refundedEvent, signature)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+
+	// Stripe may deliver an event more than once.
+	rec = deliver(t, mux, refundedEvent, signature)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+
+	e, err := webhooks.Event(t.Context(), "evt_1")
+	assert.NoError(t, err)
+	assert.Equal(t, e.Type, domain.EventChargeRefunded)
+	assert.Equal(t, e.Status, domain.WebhookPending)
+
+	for _, tt := range []struct {
+		name, payload, signature string
+	}{
+		{"tampered", strings.Replace(refundedEvent, "4900", "9900", 1), signature},
+		{"unsigned", refundedEvent, ""},
+		{"other secret", refundedEvent, adapters.SignStripeWebhook("whsec_other", []byte(refundedEvent), time.Now())},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := deliver(t, mux, tt.payload, tt.signature)
+			assert.Equal(t, rec.Code, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestWebhookAdminRoutes(t *testing.T) {
+	sessions := newSessionService(t)
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), dal.NewMemoryAuditLog())
+	webhooks := newWebhookService(t)
+	ctx := t.Context()
+
+	mux := newMux(server.NewAdminGroup(nopLogger{}, sessions, authz,
+		server.NewWebhookAdminRoutes(nopLogger{}, authz, webhooks)...)...)
+
+	token, _, err := sessions.Create(ctx, 1, service.SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	_, err = webhooks.Receive(ctx, []byte(refundedEvent),
+		adapters.SignStripeWebhook("whsec_test", []byte(refundedEvent), time.Now()))
+	assert.NoError(t, err)
+
+	webhooks.Handle(domain.EventChargeRefunded, func(context.Context, domain.PaymentEvent) error {
+		return errors.New("audit log is down")
+	})
+
+	_, err = webhooks.Dispatch(ctx)
+	assert.NoError(t, err)
+
+	// Support staff reach the admin routes but may not manage payments.
+	assert.NoError(t, authz.Grant(ctx, 1, domain.RoleSupport, ""))
+
+	rec := send(t, mux, http.MethodGet, "/admin/webhooks", "", token)
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	assert.NoError(t, authz.Grant(ctx, 1, domain.RoleAdmin, ""))
+
+	var events []struct {
+		ID        string `json:"id"`
+		Status    string `json:"status"`
+		Attempts  int    `json:"attempts"`
+		LastError string `json:"last_error"`
+	}
+
+	// The dead events are listed by default.
+	rec = send(t, mux, http.MethodGet, "/admin/webhooks", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ID, "evt_1")
+	assert.Equal(t, events[0].Attempts, 1)
+	assert.Equal(t, events[0].LastError, "audit log is down")
+
+	rec = send(t, mux, http.MethodGet, "/admin/webhooks/evt_1", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var event struct {
+		Payload struct {
+			Type string `json:"type"`
+		} `json:"payload"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &event))
+	assert.Equal(t, event.Payload.Type, "charge.refunded")
+
+	rec = send(t, mux, http.MethodPost, "/admin/webhooks/evt_1/replay", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	rec = send(t, mux, http.MethodGet, "/admin/webhooks?status=pending", "", token)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Attempts, 0)
+
+	for _, tt := range []struct {
+		method, target string
+		want           int
+	}{
+		{http.MethodGet, "/admin/webhooks?status=failed", http.StatusUnprocessableEntity},
+		{http.MethodGet, "/admin/webhooks/evt_2", http.StatusNotFound},
+		{http.MethodPost, "/admin/webhooks/evt_2/replay", http.StatusNotFound},
+	} {
+		rec = send(t, mux, tt.method, tt.target, "", token)
+		assert.Equal(t, rec.Code, tt.want)
+	}
+}
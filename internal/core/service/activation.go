@@ -0,0 +1,374 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+const (
+	// reasonCustomerDeactivated and reasonInactive are why activations are
+	// deactivated.
+	reasonCustomerDeactivated = "deactivated by customer"
+	reasonInactive            = "inactive"
+)
+
+// ActivationConfig configures the activations of licenses on machines.
+type ActivationConfig struct {
+	// TokenTTL is how long activation tokens are valid. Plugins refresh
+	// their activation before it ends, which tells that the machine is
+	// still in use.
+	TokenTTL time.Duration
+
+	// Inactivity is how long an activation lasts without being refreshed
+	// before it is deactivated, freeing its machine, or zero for
+	// activations that last until deactivated. It must be longer than
+	// TokenTTL.
+	Inactivity time.Duration
+
+	// AnomalyMachines is how many machines a license must be activated on
+	// over AnomalyWindow to be shown to staff as an anomaly.
+	AnomalyMachines int
+	AnomalyWindow   time.Duration
+}
+
+func (c ActivationConfig) Validate() error {
+	if c.TokenTTL <= 0 || c.Inactivity < 0 || c.Inactivity > 0 && c.Inactivity <= c.TokenTTL ||
+		c.AnomalyMachines <= 0 || c.AnomalyWindow <= 0 {
+		return ErrActivationConfig
+	}
+
+	return nil
+}
+
+func (c ActivationConfig) Value() any {
+	return c
+}
+
+// ActivationClaims are what an activation token tells a plugin, which
+// verifies it offline with the published license keys.
+type ActivationClaims struct {
+	ActivationID int
+	Key          string
+	CustomerID   int
+	ProductID    int
+	Edition      domain.LicenseEdition
+	Machine      string
+	Issued       time.Time
+	Expires      time.Time
+}
+
+// activationClaims is the payload of activation tokens. They carry the
+// claims of license tokens, with the activation use, plus the machine and
+// the activation, and always expire. Times are seconds since the Unix epoch.
+type activationClaims struct {
+	licenseClaims
+
+	Machine    string `json:"mid"`
+	Activation int    `json:"act"`
+}
+
+// SignedActivation is an activation with its token.
+type SignedActivation struct {
+	domain.Activation
+
+	Token string
+
+	// Expires is when the token expires, by which the plugin refreshes the
+	// activation.
+	Expires time.Time
+}
+
+// ActivationService activates licenses on the machines plugins run on, up
+// to the machine limit of their product. Plugins send the key of their
+// license and a fingerprint of their machine, and get back a token signed
+// like licenses, bound to the machine. Plugins must require this token,
+// whose use claim is "activation", rather than a license token. Tokens are
+// signed by ring, the key ring of the license service, with the issuer of
+// licenseConfig.
+type ActivationService struct {
+	activations   ports.ActivationStore
+	licenses      *LicenseService
+	products      ports.ProductRepository
+	ring          *jwt.KeyRing
+	licenseConfig LicenseConfig
+	config        ActivationConfig
+	now           func() time.Time
+}
+
+func NewActivationService(
+	activations ports.ActivationStore,
+	licenses *LicenseService,
+	products ports.ProductRepository,
+	ring *jwt.KeyRing,
+	licenseConfig LicenseConfig,
+	config ActivationConfig,
+) (*ActivationService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActivationService{
+		activations:   activations,
+		licenses:      licenses,
+		products:      products,
+		ring:          ring,
+		licenseConfig: licenseConfig,
+		config:        config,
+		now:           time.Now,
+	}, nil
+}
+
+// Activate activates the license with key, as typed by a customer, on
+// machine, or refreshes its activation there. It returns ErrLicenseInactive
+// for revoked or expired licenses, and ErrMachineLimit when the license is
+// active on as many other machines as its product allows.
+func (s *ActivationService) Activate(ctx context.Context, key, machine, name string) (SignedActivation, error) {
+	err := domain.ValidateMachine(machine, name)
+	if err != nil {
+		return SignedActivation{}, err
+	}
+
+	l, err := s.licenses.License(ctx, key)
+	if err != nil {
+		return SignedActivation{}, err
+	}
+
+	now := s.now().UTC()
+
+	if !l.Valid(now) {
+		return SignedActivation{}, ErrLicenseInactive
+	}
+
+	a, err := s.activations.MachineActivation(ctx, l.ID, machine)
+	if err == nil {
+		return s.refresh(ctx, l, a)
+	}
+
+	if !errors.Is(err, ports.ErrNotFound) {
+		return SignedActivation{}, fmt.Errorf("failed to find activation: %w", err)
+	}
+
+	product, err := s.products.ProductByID(ctx, l.ProductID)
+	if err != nil {
+		return SignedActivation{}, fmt.Errorf("failed to find product: %w", err)
+	}
+
+	a, err = s.activations.CreateActivation(ctx, domain.Activation{
+		ID:               0,
+		LicenseID:        l.ID,
+		CustomerID:       l.CustomerID,
+		ProductID:        l.ProductID,
+		Machine:          machine,
+		MachineName:      name,
+		Activated:        now,
+		LastSeen:         now,
+		Deactivated:      time.Time{},
+		DeactivateReason: "",
+	}, product.MachineLimit)
+	if errors.Is(err, ports.ErrConflict) {
+		// Either the machine was activated meanwhile, by a retry of the
+		// plugin, or there is no seat left.
+		a, err = s.activations.MachineActivation(ctx, l.ID, machine)
+		if errors.Is(err, ports.ErrNotFound) {
+			return SignedActivation{}, ErrMachineLimit
+		}
+
+		if err != nil {
+			return SignedActivation{}, fmt.Errorf("failed to find activation: %w", err)
+		}
+
+		return s.refresh(ctx, l, a)
+	}
+
+	if err != nil {
+		return SignedActivation{}, fmt.Errorf("failed to create activation: %w", err)
+	}
+
+	return s.sign(l, a)
+}
+
+// refresh marks a seen now, and signs it anew.
+func (s *ActivationService) refresh(ctx context.Context, l domain.License, a domain.Activation) (SignedActivation, error) {
+	now := s.now().UTC()
+
+	err := s.activations.TouchActivation(ctx, a.ID, now)
+	if errors.Is(err, ports.ErrNotFound) {
+		return SignedActivation{}, ErrActivationNotFound
+	}
+
+	if err != nil {
+		return SignedActivation{}, fmt.Errorf("failed to refresh activation: %w", err)
+	}
+
+	a.LastSeen = now
+
+	return s.sign(l, a)
+}
+
+// Activations returns the machines the licenses of a customer are active
+// on, oldest first.
+func (s *ActivationService) Activations(ctx context.Context, customerID int) ([]domain.Activation, error) {
+	activations, err := s.activations.CustomerActivations(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activations: %w", err)
+	}
+
+	return activations, nil
+}
+
+// Deactivate deactivates an activation of a customer, freeing its machine.
+// The plugin keeps working there until its token expires. Activations of
+// other customers are not found.
+func (s *ActivationService) Deactivate(ctx context.Context, customerID, id int) error {
+	a, err := s.activations.Activation(ctx, id)
+	if errors.Is(err, ports.ErrNotFound) || err == nil && a.CustomerID != customerID {
+		return ErrActivationNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to find activation: %w", err)
+	}
+
+	err = s.activations.DeactivateActivation(ctx, id, s.now().UTC(), reasonCustomerDeactivated)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate activation: %w", err)
+	}
+
+	return nil
+}
+
+// LicenseActivations returns every activation of the license with key,
+// active or not, oldest first.
+func (s *ActivationService) LicenseActivations(ctx context.Context, key string) ([]domain.Activation, error) {
+	l, err := s.licenses.License(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	activations, err := s.activations.LicenseActivations(ctx, l.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activations: %w", err)
+	}
+
+	return activations, nil
+}
+
+// Anomalies returns up to limit licenses activated on at least
+// AnomalyMachines machines over the last AnomalyWindow, the most machines
+// first: licenses likely shared beyond their customer.
+func (s *ActivationService) Anomalies(ctx context.Context, limit int) ([]domain.ActivationCount, error) {
+	since := s.now().Add(-s.config.AnomalyWindow)
+
+	counts, err := s.activations.ActivationCounts(ctx, since, s.config.AnomalyMachines, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count activations: %w", err)
+	}
+
+	return counts, nil
+}
+
+// Sweep deactivates the activations not refreshed for Inactivity, and
+// returns how many.
+func (s *ActivationService) Sweep(ctx context.Context) (int, error) {
+	if s.config.Inactivity == 0 {
+		return 0, nil
+	}
+
+	now := s.now().UTC()
+
+	n, err := s.activations.ExpireActivations(ctx, now.Add(-s.config.Inactivity), now, reasonInactive)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire activations: %w", err)
+	}
+
+	return n, nil
+}
+
+// Verify returns the claims of an activation token issued by this app. It
+// returns ErrActivationTokenInvalid for forged, foreign or expired tokens,
+// and for license tokens.
+func (s *ActivationService) Verify(token string) (ActivationClaims, error) {
+	var claims activationClaims
+
+	err := s.ring.Verify(token, &claims)
+	if err != nil || claims.Issuer != s.licenseConfig.Issuer || claims.Use != tokenUseActivation ||
+		claims.Activation == 0 || !s.now().Before(time.Unix(claims.Expires, 0)) {
+		return ActivationClaims{}, ErrActivationTokenInvalid
+	}
+
+	customerID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return ActivationClaims{}, ErrActivationTokenInvalid
+	}
+
+	return ActivationClaims{
+		ActivationID: claims.Activation,
+		Key:          claims.Key,
+		CustomerID:   customerID,
+		ProductID:    claims.Product,
+		Edition:      claims.Edition,
+		Machine:      claims.Machine,
+		Issued:       time.Unix(claims.IssuedAt, 0).UTC(),
+		Expires:      time.Unix(claims.Expires, 0).UTC(),
+	}, nil
+}
+
+// sign returns a with its token, which expires after TokenTTL, or with the
+// license.
+func (s *ActivationService) sign(l domain.License, a domain.Activation) (SignedActivation, error) {
+	now := s.now().UTC()
+
+	expires := now.Add(s.config.TokenTTL)
+	if !l.Expires.IsZero() && l.Expires.Before(expires) {
+		expires = l.Expires
+	}
+
+	token, err := s.ring.Sign(activationClaims{
+		licenseClaims: licenseClaims{
+			Issuer:   s.licenseConfig.Issuer,
+			Subject:  strconv.Itoa(l.CustomerID),
+			Use:      tokenUseActivation,
+			Key:      l.Key,
+			Order:    l.OrderID,
+			Product:  l.ProductID,
+			Edition:  l.Edition,
+			IssuedAt: now.Unix(),
+			Expires:  expires.Unix(),
+		},
+		Machine:    a.Machine,
+		Activation: a.ID,
+	})
+	if err != nil {
+		return SignedActivation{}, fmt.Errorf("failed to sign activation: %w", err)
+	}
+
+	return SignedActivation{Activation: a, Token: token, Expires: expires}, nil
+}
+
+type ActivationError string
+
+func (e ActivationError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrActivationConfig ActivationError = "activation token TTL, anomaly machines and window must be positive, " +
+		"and inactivity zero or longer than the token TTL"
+	ErrActivationNotFound     ActivationError = "activation not found"
+	ErrActivationTokenInvalid ActivationError = "activation token is invalid"
+	ErrLicenseInactive        ActivationError = "license is revoked or expired"
+	ErrMachineLimit           ActivationError = "license is active on as many machines as it allows: " +
+		"deactivate one from your account first"
+)
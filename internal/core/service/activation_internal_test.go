@@ -0,0 +1,323 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+type activationTest struct {
+	*ActivationService
+	licenses *licenseTest
+	products *dal.MemoryProducts
+	clock    time.Time
+
+	// key is the key of a license of customer 1 for the microwave plugin,
+	// with a limit of two machines, and unlimited the key of a license of
+	// customer 2 for the oven plugin, without limit. Their orders are not
+	// stored.
+	key, unlimited  string
+	microwave, oven int
+}
+
+func (a *activationTest) advance(dur time.Duration) {
+	a.clock = a.clock.Add(dur)
+}
+
+func activationConfig() ActivationConfig {
+	return ActivationConfig{
+		TokenTTL:        7 * 24 * time.Hour,
+		Inactivity:      30 * 24 * time.Hour,
+		AnomalyMachines: 3,
+		AnomalyWindow:   30 * 24 * time.Hour,
+	}
+}
+
+func newActivationTest(t *testing.T, config ActivationConfig) *activationTest {
+	t.Helper()
+
+	licenses := newLicenseTest(t, licenseConfig())
+	products := dal.NewMemoryProducts()
+
+	svc, err := NewActivationService(dal.NewMemoryActivations(), licenses.LicenseService, products,
+		licenses.ring, licenses.config, config)
+	assert.NoError(t, err)
+
+	a := &activationTest{
+		ActivationService: svc,
+		licenses:          licenses,
+		products:          products,
+		clock:             time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		key:               "",
+		unlimited:         "",
+		microwave:         0,
+		oven:              0,
+	}
+	a.now = func() time.Time { return a.clock }
+	licenses.now = func() time.Time { return a.clock }
+
+	for i, limit := range []int{2, 0} {
+		p, err := products.CreateProduct(t.Context(), domain.Product{
+			ID:           0,
+			Type:         domain.ProductPlugin,
+			Slug:         []string{"microwave", "oven"}[i],
+			Name:         "Plugin",
+			Description:  "",
+			Version:      "1.0.0",
+			MachineLimit: limit,
+			Media:        nil,
+			Published:    true,
+			Position:     0,
+			Prices:       nil,
+			PriceID:      "",
+			ProductID:    "",
+			Created:      a.clock,
+			Updated:      a.clock,
+		})
+		assert.NoError(t, err)
+
+		l, err := licenses.licenses.CreateLicense(t.Context(), domain.License{
+			ID:           0,
+			Key:          domain.NewLicenseKey(),
+			OrderID:      100 + i,
+			Line:         0,
+			Seat:         0,
+			CustomerID:   i + 1,
+			ProductID:    p.ID,
+			Version:      "1.2.0",
+			Edition:      domain.EditionStandard,
+			Issued:       a.clock,
+			Expires:      time.Time{},
+			Revoked:      time.Time{},
+			RevokeReason: "",
+			UpgradedFrom: 0,
+		})
+		assert.NoError(t, err)
+
+		if limit > 0 {
+			a.key, a.microwave = l.Key, p.ID
+		} else {
+			a.unlimited, a.oven = l.Key, p.ID
+		}
+	}
+
+	return a
+}
+
+func TestNewActivationService(t *testing.T) {
+	for _, modify := range []func(*ActivationConfig){
+		func(c *ActivationConfig) { c.TokenTTL = 0 },
+		func(c *ActivationConfig) { c.Inactivity = -time.Hour },
+		func(c *ActivationConfig) { c.Inactivity = c.TokenTTL },
+		func(c *ActivationConfig) { c.AnomalyMachines = 0 },
+		func(c *ActivationConfig) { c.AnomalyWindow = 0 },
+	} {
+		config := activationConfig()
+		modify(&config)
+
+		_, err := NewActivationService(dal.NewMemoryActivations(), nil, dal.NewMemoryProducts(), nil, licenseConfig(), config)
+		assert.Error(t, err, ErrActivationConfig)
+	}
+}
+
+func TestActivationService_Activate(t *testing.T) {
+	svc := newActivationTest(t, activationConfig())
+	ctx := t.Context()
+
+	const studio, laptop, third = "studio-fingerprint-1", "laptop-fingerprint-2", "others-fingerprint-3"
+
+	first, err := svc.Activate(ctx, svc.key, studio, "Studio Mac")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID, 0)
+	assert.Equal(t, first.CustomerID, 1)
+	assert.Equal(t, first.MachineName, "Studio Mac")
+	assert.True(t, first.Expires.Equal(svc.clock.Add(7*24*time.Hour)))
+
+	claims, err := svc.Verify(first.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, claims.ActivationID, first.ID)
+	assert.Equal(t, claims.Machine, studio)
+	assert.Equal(t, claims.Key, svc.key)
+	assert.Equal(t, claims.CustomerID, 1)
+	assert.True(t, claims.Expires.Equal(first.Expires))
+
+	// Plugins verify activation tokens with the license keys. They carry
+	// the claims of the license, but their use tells them apart: neither
+	// kind of token passes for the other.
+	var payload activationClaims
+	assert.NoError(t, svc.licenses.JWKS().Verify(first.Token, &payload))
+	assert.Equal(t, payload.Use, tokenUseActivation)
+
+	_, err = svc.licenses.Verify(first.Token)
+	assert.Error(t, err, ErrLicenseTokenInvalid)
+
+	signed, err := svc.licenses.Lookup(ctx, svc.key)
+	assert.NoError(t, err)
+
+	_, err = svc.Verify(signed.Token)
+	assert.Error(t, err, ErrActivationTokenInvalid)
+
+	payload.Use = tokenUseLicense
+	relabeled, err := svc.ring.Sign(payload)
+	assert.NoError(t, err)
+
+	_, err = svc.Verify(relabeled)
+	assert.Error(t, err, ErrActivationTokenInvalid)
+
+	// Activating the same machine again refreshes its activation.
+	svc.advance(time.Hour)
+
+	again, err := svc.Activate(ctx, svc.key, studio, "Studio Mac")
+	assert.NoError(t, err)
+	assert.Equal(t, again.ID, first.ID)
+	assert.True(t, again.LastSeen.Equal(svc.clock))
+
+	_, err = svc.Activate(ctx, svc.key, laptop, "")
+	assert.NoError(t, err)
+
+	_, err = svc.Activate(ctx, svc.key, third, "")
+	assert.Error(t, err, ErrMachineLimit)
+
+	// Products without limit activate on any number of machines.
+	for _, machine := range []string{studio, laptop, third} {
+		_, err = svc.Activate(ctx, svc.unlimited, machine, "")
+		assert.NoError(t, err)
+	}
+
+	for _, tt := range []struct {
+		name, key, machine string
+		want               error
+	}{
+		{"mistyped key", svc.key[1:], studio, domain.ErrInvalidLicenseKey},
+		{"unknown key", domain.NewLicenseKey(), studio, ErrLicenseNotFound},
+		{"invalid machine", svc.key, "short", domain.ErrInvalidMachine},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Activate(ctx, tt.key, tt.machine, "")
+			assert.Error(t, err, tt.want)
+		})
+	}
+
+	// Tokens expire, and revoked licenses are no longer activated.
+	svc.advance(8 * 24 * time.Hour)
+
+	_, err = svc.Verify(first.Token)
+	assert.Error(t, err, ErrActivationTokenInvalid)
+
+	_, err = svc.licenses.Revoke(ctx, 5, svc.key, "fraud", "10.0.0.1")
+	assert.NoError(t, err)
+
+	_, err = svc.Activate(ctx, svc.key, studio, "Studio Mac")
+	assert.Error(t, err, ErrLicenseInactive)
+}
+
+func TestActivationService_Deactivate(t *testing.T) {
+	svc := newActivationTest(t, activationConfig())
+	ctx := t.Context()
+
+	studio, err := svc.Activate(ctx, svc.key, "studio-fingerprint-1", "Studio Mac")
+	assert.NoError(t, err)
+
+	_, err = svc.Activate(ctx, svc.key, "laptop-fingerprint-2", "")
+	assert.NoError(t, err)
+
+	activations, err := svc.Activations(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(activations), 2)
+
+	// Customers only deactivate their own machines.
+	assert.Error(t, svc.Deactivate(ctx, 2, studio.ID), ErrActivationNotFound)
+	assert.Error(t, svc.Deactivate(ctx, 1, 1000), ErrActivationNotFound)
+	assert.NoError(t, svc.Deactivate(ctx, 1, studio.ID))
+
+	activations, err = svc.Activations(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(activations), 1)
+
+	// Deactivating frees the machine for another.
+	_, err = svc.Activate(ctx, svc.key, "others-fingerprint-3", "")
+	assert.NoError(t, err)
+
+	all, err := svc.LicenseActivations(ctx, svc.key)
+	assert.NoError(t, err)
+	assert.Equal(t, len(all), 3)
+	assert.Equal(t, all[0].DeactivateReason, reasonCustomerDeactivated)
+}
+
+func TestActivationService_Sweep(t *testing.T) {
+	svc := newActivationTest(t, activationConfig())
+	ctx := t.Context()
+
+	_, err := svc.Activate(ctx, svc.key, "studio-fingerprint-1", "")
+	assert.NoError(t, err)
+
+	svc.advance(20 * 24 * time.Hour)
+
+	_, err = svc.Activate(ctx, svc.key, "laptop-fingerprint-2", "")
+	assert.NoError(t, err)
+
+	svc.advance(15 * 24 * time.Hour)
+
+	n, err := svc.Sweep(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+
+	activations, err := svc.Activations(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(activations), 1)
+	assert.Equal(t, activations[0].Machine, "laptop-fingerprint-2")
+
+	// Without inactivity, activations last until deactivated.
+	config := activationConfig()
+	config.Inactivity = 0
+
+	svc = newActivationTest(t, config)
+
+	_, err = svc.Activate(ctx, svc.key, "studio-fingerprint-1", "")
+	assert.NoError(t, err)
+
+	svc.advance(365 * 24 * time.Hour)
+
+	n, err = svc.Sweep(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, n, 0)
+}
+
+func TestActivationService_Anomalies(t *testing.T) {
+	svc := newActivationTest(t, activationConfig())
+	ctx := t.Context()
+
+	// A shared license moves from machine to machine within its limit.
+	for i := range 4 {
+		a, err := svc.Activate(ctx, svc.unlimited, "shared-fingerprint-"+string(rune('a'+i)), "")
+		assert.NoError(t, err)
+
+		if i%2 == 0 {
+			assert.NoError(t, svc.Deactivate(ctx, 2, a.ID))
+		}
+	}
+
+	_, err := svc.Activate(ctx, svc.key, "studio-fingerprint-1", "")
+	assert.NoError(t, err)
+
+	anomalies, err := svc.Anomalies(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(anomalies), 1)
+	assert.Equal(t, anomalies[0].CustomerID, 2)
+	assert.Equal(t, anomalies[0].Machines, 4)
+	assert.Equal(t, anomalies[0].Active, 2)
+
+	// Activations leave the window with time.
+	svc.advance(31 * 24 * time.Hour)
+
+	anomalies, err = svc.Anomalies(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(anomalies), 0)
+}
@@ -0,0 +1,338 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// maxRoleNameLength bounds the names of custom roles.
+const maxRoleNameLength = 32
+
+// RoleInfo is a role and the permissions it grants.
+type RoleInfo struct {
+	Name        domain.Role
+	Permissions []domain.Permission
+
+	// Builtin tells the built-in roles, which cannot be changed, from the
+	// custom roles.
+	Builtin bool
+}
+
+// AuthorizationService decides which staff operations customers may carry
+// out, from the roles granted to them. Handlers check permissions with
+// RequirePermission, which calls Authorize; the services of staff
+// operations, such as OrderService.Refund or LicenseService.Revoke, trust
+// the staff ID they are given and only record it.
+type AuthorizationService struct {
+	store ports.RoleStore
+	audit ports.AuditLog
+	now   func() time.Time
+}
+
+func NewAuthorizationService(store ports.RoleStore, audit ports.AuditLog) *AuthorizationService {
+	return &AuthorizationService{
+		store: store,
+		audit: audit,
+		now:   time.Now,
+	}
+}
+
+// builtinPermissions returns the permissions of a built-in role, and
+// whether role is built in.
+func builtinPermissions(role domain.Role) ([]domain.Permission, bool) {
+	switch role {
+	case domain.RoleCustomer:
+		return []domain.Permission{}, true
+	case domain.RoleSupport:
+		return []domain.Permission{
+			domain.PermissionAccessAdmin,
+			domain.PermissionViewCustomers,
+			domain.PermissionRefundOrders,
+			domain.PermissionManageOrders,
+			domain.PermissionManageLicenses,
+		}, true
+	case domain.RoleAdmin:
+		return domain.Permissions(), true
+	default:
+		return nil, false
+	}
+}
+
+// Roles returns the built-in roles, then the custom roles by name.
+func (s *AuthorizationService) Roles(ctx context.Context) ([]RoleInfo, error) {
+	custom, err := s.store.Roles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	roles := make([]RoleInfo, 0, len(custom)+3)
+
+	for _, name := range []domain.Role{domain.RoleCustomer, domain.RoleSupport, domain.RoleAdmin} {
+		permissions, _ := builtinPermissions(name)
+		roles = append(roles, RoleInfo{Name: name, Permissions: permissions, Builtin: true})
+	}
+
+	for _, r := range custom {
+		roles = append(roles, RoleInfo{Name: r.Name, Permissions: r.Permissions, Builtin: false})
+	}
+
+	return roles, nil
+}
+
+// DefineRole creates a custom role, or replaces the permissions of one.
+func (s *AuthorizationService) DefineRole(ctx context.Context, name domain.Role, permissions []domain.Permission) error {
+	err := validateRoleName(name)
+	if err != nil {
+		return err
+	}
+
+	if len(permissions) == 0 {
+		return ErrRolePermissions
+	}
+
+	for _, p := range permissions {
+		if !slices.Contains(domain.Permissions(), p) {
+			return ErrUnknownPermission
+		}
+	}
+
+	permissions = slices.Clone(permissions)
+	slices.Sort(permissions)
+
+	err = s.store.SaveRole(ctx, domain.RoleDefinition{
+		Name:        name,
+		Permissions: slices.Compact(permissions),
+		Created:     s.now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRole deletes a custom role, which revokes it from every customer.
+func (s *AuthorizationService) DeleteRole(ctx context.Context, name domain.Role) error {
+	_, builtin := builtinPermissions(name)
+	if builtin {
+		return ErrRoleBuiltin
+	}
+
+	err := s.store.DeleteRole(ctx, name)
+	if errors.Is(err, ports.ErrNotFound) {
+		return ErrUnknownRole
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	return nil
+}
+
+// CustomerRoles returns the roles of a customer: the customer role, then
+// the roles granted to them.
+func (s *AuthorizationService) CustomerRoles(ctx context.Context, customerID int) ([]domain.Role, error) {
+	granted, err := s.store.CustomerRoles(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer roles: %w", err)
+	}
+
+	return append([]domain.Role{domain.RoleCustomer}, granted...), nil
+}
+
+// Permissions returns the permissions a customer has through their roles.
+func (s *AuthorizationService) Permissions(ctx context.Context, customerID int) ([]domain.Permission, error) {
+	roles, err := s.CustomerRoles(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var permissions []domain.Permission
+
+	for _, role := range roles {
+		granted, builtin := builtinPermissions(role)
+		if !builtin {
+			r, err := s.store.Role(ctx, role)
+			if errors.Is(err, ports.ErrNotFound) {
+				// The role was deleted since it was listed.
+				continue
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("failed to find role: %w", err)
+			}
+
+			granted = r.Permissions
+		}
+
+		permissions = append(permissions, granted...)
+	}
+
+	slices.Sort(permissions)
+
+	return slices.Compact(permissions), nil
+}
+
+// Can reports whether a customer has a permission.
+func (s *AuthorizationService) Can(ctx context.Context, customerID int, p domain.Permission) (bool, error) {
+	permissions, err := s.Permissions(ctx, customerID)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(permissions, p), nil
+}
+
+// Authorize returns ErrForbidden when a customer, acting from ip, lacks a
+// permission. Denied attempts are recorded in the audit trail.
+func (s *AuthorizationService) Authorize(ctx context.Context, customerID int, p domain.Permission, ip string) error {
+	ok, err := s.Can(ctx, customerID, p)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return nil
+	}
+
+	err = s.record(ctx, domain.AuditAccessDenied, customerID, ip, fmt.Sprintf("lacks permission %s", p))
+	if err != nil {
+		return err
+	}
+
+	return ErrForbidden
+}
+
+// Grant grants a role to a customer.
+func (s *AuthorizationService) Grant(ctx context.Context, customerID int, role domain.Role, ip string) error {
+	if role == domain.RoleCustomer {
+		return ErrRoleImplicit
+	}
+
+	err := s.exists(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.GrantRole(ctx, customerID, role, s.now().UTC())
+	if errors.Is(err, ports.ErrConflict) {
+		return ErrRoleGranted
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to grant role: %w", err)
+	}
+
+	return s.record(ctx, domain.AuditRoleGranted, customerID, ip, fmt.Sprintf("granted role %s", role))
+}
+
+// Revoke revokes a role from a customer.
+func (s *AuthorizationService) Revoke(ctx context.Context, customerID int, role domain.Role, ip string) error {
+	if role == domain.RoleCustomer {
+		return ErrRoleImplicit
+	}
+
+	err := s.store.RevokeRole(ctx, customerID, role)
+	if errors.Is(err, ports.ErrNotFound) {
+		return ErrRoleNotGranted
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	return s.record(ctx, domain.AuditRoleRevoked, customerID, ip, fmt.Sprintf("revoked role %s", role))
+}
+
+// exists returns ErrUnknownRole when role is neither built in nor custom.
+func (s *AuthorizationService) exists(ctx context.Context, role domain.Role) error {
+	_, builtin := builtinPermissions(role)
+	if builtin {
+		return nil
+	}
+
+	_, err := s.store.Role(ctx, role)
+	if errors.Is(err, ports.ErrNotFound) {
+		return ErrUnknownRole
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to find role: %w", err)
+	}
+
+	return nil
+}
+
+// record records an audit event about a customer. Roles may be granted
+// from the command line, where customers can only be named by their ID.
+func (s *AuthorizationService) record(
+	ctx context.Context,
+	t domain.AuditEventType,
+	customerID int,
+	ip, detail string,
+) error {
+	err := s.audit.RecordAudit(ctx, domain.AuditEvent{
+		Type:    t,
+		Subject: "customer " + strconv.Itoa(customerID),
+		IP:      ip,
+		Time:    s.now().UTC(),
+		Detail:  detail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// validateRoleName checks that name can be the name of a custom role: 1 to
+// 32 lowercase letters, digits, dashes and underscores, starting with a
+// letter, and not the name of a built-in role.
+func validateRoleName(name domain.Role) error {
+	_, builtin := builtinPermissions(name)
+	if builtin {
+		return ErrRoleBuiltin
+	}
+
+	if name == "" || len(name) > maxRoleNameLength || name[0] < 'a' || name[0] > 'z' {
+		return ErrRoleName
+	}
+
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return ErrRoleName
+		}
+	}
+
+	return nil
+}
+
+type AuthorizationError string
+
+func (e AuthorizationError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrForbidden         AuthorizationError = "permission denied"
+	ErrUnknownRole       AuthorizationError = "role does not exist"
+	ErrUnknownPermission AuthorizationError = "permission does not exist"
+	ErrRoleName          AuthorizationError = "role names are 1 to 32 lowercase letters, digits, dashes and underscores, starting with a letter"
+	ErrRoleBuiltin       AuthorizationError = "built-in roles cannot be changed"
+	ErrRolePermissions   AuthorizationError = "role must grant permissions"
+	ErrRoleImplicit      AuthorizationError = "every customer has the customer role"
+	ErrRoleGranted       AuthorizationError = "customer already has the role"
+	ErrRoleNotGranted    AuthorizationError = "customer does not have the role"
+)
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service_test
+
+import (
+	"testing"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+func TestAuthorizationService_BuiltinRoles(t *testing.T) {
+	ctx := t.Context()
+	audit := dal.NewMemoryAuditLog()
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), audit)
+
+	// Customers have no staff permission, and being denied is audited.
+	err := authz.Authorize(ctx, 1, domain.PermissionAccessAdmin, "10.0.0.1")
+	assert.Error(t, err, service.ErrForbidden)
+
+	events := audit.Events()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, domain.AuditAccessDenied)
+	assert.Equal(t, events[0].IP, "10.0.0.1")
+
+	assert.NoError(t, authz.Grant(ctx, 1, domain.RoleSupport, ""))
+	assert.Error(t, authz.Grant(ctx, 1, domain.RoleSupport, ""), service.ErrRoleGranted)
+	assert.Error(t, authz.Grant(ctx, 1, domain.RoleCustomer, ""), service.ErrRoleImplicit)
+	assert.Error(t, authz.Grant(ctx, 1, "owner", ""), service.ErrUnknownRole)
+
+	assert.NoError(t, authz.Authorize(ctx, 1, domain.PermissionRefundOrders, ""))
+	assert.Error(t, authz.Authorize(ctx, 1, domain.PermissionManageRoles, ""), service.ErrForbidden)
+
+	roles, err := authz.CustomerRoles(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(roles), 2)
+	assert.Equal(t, roles[0], domain.RoleCustomer)
+
+	assert.NoError(t, authz.Grant(ctx, 1, domain.RoleAdmin, ""))
+
+	permissions, err := authz.Permissions(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(permissions), len(domain.Permissions()))
+
+	assert.NoError(t, authz.Revoke(ctx, 1, domain.RoleAdmin, ""))
+	assert.Error(t, authz.Revoke(ctx, 1, domain.RoleAdmin, ""), service.ErrRoleNotGranted)
+
+	ok, err := authz.Can(ctx, 1, domain.PermissionManageRoles)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestAuthorizationService_CustomRoles(t *testing.T) {
+	ctx := t.Context()
+	authz := service.NewAuthorizationService(dal.NewMemoryRoles(), dal.NewMemoryAuditLog())
+
+	assert.Error(t, authz.DefineRole(ctx, domain.RoleAdmin, []domain.Permission{domain.PermissionViewAudit}), service.ErrRoleBuiltin)
+	assert.Error(t, authz.DefineRole(ctx, "Catalog", []domain.Permission{domain.PermissionManageProducts}), service.ErrRoleName)
+	assert.Error(t, authz.DefineRole(ctx, "catalog", nil), service.ErrRolePermissions)
+	assert.Error(t, authz.DefineRole(ctx, "catalog", []domain.Permission{"products:delete"}), service.ErrUnknownPermission)
+
+	err := authz.DefineRole(ctx, "catalog", []domain.Permission{
+		domain.PermissionManageProducts,
+		domain.PermissionAccessAdmin,
+		domain.PermissionManageProducts,
+	})
+	assert.NoError(t, err)
+
+	roles, err := authz.Roles(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(roles), 4)
+	assert.Equal(t, roles[3].Name, domain.Role("catalog"))
+	assert.False(t, roles[3].Builtin)
+	assert.Equal(t, len(roles[3].Permissions), 2)
+
+	assert.NoError(t, authz.Grant(ctx, 7, "catalog", ""))
+	assert.NoError(t, authz.Authorize(ctx, 7, domain.PermissionManageProducts, ""))
+
+	// Deleting the role takes its permissions away.
+	assert.Error(t, authz.DeleteRole(ctx, domain.RoleSupport), service.ErrRoleBuiltin)
+	assert.NoError(t, authz.DeleteRole(ctx, "catalog"))
+	assert.Error(t, authz.DeleteRole(ctx, "catalog"), service.ErrUnknownRole)
+	assert.Error(t, authz.Authorize(ctx, 7, domain.PermissionManageProducts, ""), service.ErrForbidden)
+}
@@ -0,0 +1,473 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// minCartSecretLength is the shortest accepted signing secret of guest
+// cart tokens, the output size of SHA-256.
+const minCartSecretLength = 32
+
+// CartConfig configures shopping carts.
+type CartConfig struct {
+	// Secret signs the cart tokens of guests. It must be at least 32 bytes
+	// long and be kept secret: anyone holding it can open the cart of any
+	// guest whose cart ID they learn.
+	Secret []byte `redact:"true"`
+
+	// GuestTTL is how long the cart of a guest is kept after its last
+	// change.
+	GuestTTL time.Duration
+
+	// CustomerTTL is how long the cart of a customer is kept after its last
+	// change.
+	CustomerTTL time.Duration
+}
+
+func (c CartConfig) Validate() error {
+	if len(c.Secret) < minCartSecretLength || c.GuestTTL <= 0 || c.CustomerTTL <= 0 {
+		return ErrCartConfig
+	}
+
+	return nil
+}
+
+func (c CartConfig) Value() any {
+	return c
+}
+
+// CartOwner tells whose cart an operation is on: the cart of a logged in
+// customer, or else the cart of a guest, which they hold a token of.
+type CartOwner struct {
+	// CustomerID is the logged in customer, or 0 for a guest.
+	CustomerID int
+
+	// GuestToken is the token of the cart of a guest, as returned by
+	// GuestToken, or empty when the guest has no cart yet. It is ignored
+	// for customers.
+	GuestToken string
+}
+
+// CartService manages the shopping carts of customers and guests. Carts
+// only hold products and quantities; they are priced from the catalog
+// every time, so that customers always see the current prices, and the
+// upgrade prices their licenses make them eligible to.
+type CartService struct {
+	carts    ports.CartStore
+	products ports.ProductRepository
+	upgrades *UpgradeService
+	config   CartConfig
+	now      func() time.Time
+}
+
+// NewCartService returns a cart service pricing the carts of customers
+// with upgrades, or at list prices when upgrades is nil.
+func NewCartService(
+	carts ports.CartStore,
+	products ports.ProductRepository,
+	upgrades *UpgradeService,
+	config CartConfig,
+) (*CartService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CartService{
+		carts:    carts,
+		products: products,
+		upgrades: upgrades,
+		config:   config,
+		now:      time.Now,
+	}, nil
+}
+
+// Cart returns the cart of owner. Owners without a cart get an empty one
+// with no ID, which is only stored once a line is added.
+func (s *CartService) Cart(ctx context.Context, owner CartOwner) (domain.Cart, error) {
+	return s.cart(ctx, owner)
+}
+
+// AddLine adds line to the cart of owner, or adds its quantity to the line
+// of the cart with the same product and gift flag. Only published products
+// can be added, and a plugin bought for oneself only once.
+func (s *CartService) AddLine(ctx context.Context, owner CartOwner, line domain.CartLine) (domain.Cart, error) {
+	if line.Quantity <= 0 {
+		return domain.Cart{}, domain.ErrInvalidQuantity
+	}
+
+	c, err := s.cart(ctx, owner)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	p, err := s.publishedProduct(ctx, line.ProductID)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	existing, _ := c.Line(line.ProductID, line.Gift)
+	line.Quantity += existing.Quantity
+
+	err = c.Put(p.Type, line)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	return s.save(ctx, c)
+}
+
+// SetQuantity sets the quantity of the line of the cart of owner with the
+// product and gift flag of line. A quantity of 0 removes the line.
+func (s *CartService) SetQuantity(ctx context.Context, owner CartOwner, line domain.CartLine) (domain.Cart, error) {
+	c, err := s.cart(ctx, owner)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	_, ok := c.Line(line.ProductID, line.Gift)
+	if !ok {
+		return domain.Cart{}, ErrCartLineNotFound
+	}
+
+	// Lines of products taken off the catalog can still be removed.
+	t := domain.ProductMerchandise
+
+	if line.Quantity != 0 {
+		p, err := s.publishedProduct(ctx, line.ProductID)
+		if err != nil {
+			return domain.Cart{}, err
+		}
+
+		t = p.Type
+	}
+
+	err = c.Put(t, line)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	return s.save(ctx, c)
+}
+
+// RemoveLine removes the line of the cart of owner with productID and the
+// gift flag.
+func (s *CartService) RemoveLine(ctx context.Context, owner CartOwner, productID int, gift bool) (domain.Cart, error) {
+	return s.SetQuantity(ctx, owner, domain.CartLine{ProductID: productID, Quantity: 0, Gift: gift})
+}
+
+// Price prices c from the catalog in currency, for customers in region.
+// Lines of products that are unpublished, deleted, or have no price in the
+// currency are marked unavailable and left out of the total. The lines of
+// the cart of a customer are priced at the upgrade prices their licenses
+// make them eligible to.
+func (s *CartService) Price(
+	ctx context.Context,
+	c domain.Cart,
+	currency domain.Currency,
+	region domain.Region,
+) (domain.PricedCart, error) {
+	err := currency.Valid()
+	if err != nil {
+		return domain.PricedCart{}, err
+	}
+
+	err = region.Valid()
+	if err != nil {
+		return domain.PricedCart{}, err
+	}
+
+	products, err := s.publishedProducts(ctx)
+	if err != nil {
+		return domain.PricedCart{}, err
+	}
+
+	zero := domain.Money{Amount: 0, Currency: currency}
+	priced := domain.PricedCart{
+		Cart:     c,
+		Currency: currency,
+		Region:   region,
+		Lines:    make([]domain.PricedCartLine, 0, len(c.Lines)),
+		Total:    zero,
+	}
+
+	for _, line := range c.Lines {
+		pl := domain.PricedCartLine{
+			Line:      line,
+			Product:   domain.Product{ID: line.ProductID}, //nolint:exhaustruct // only the ID is known
+			Available: false,
+			UnitPrice: zero,
+			Subtotal:  zero,
+			Upgrade:   nil,
+		}
+
+		p, ok := products[line.ProductID]
+		if ok {
+			pl.Product = p
+
+			price, found := p.Price(currency, region)
+			if found {
+				pl.Available = true
+				pl.UnitPrice = price
+
+				pl.Subtotal, err = price.Mul(int64(line.Quantity))
+				if err != nil {
+					return domain.PricedCart{}, err
+				}
+
+				priced.Total, err = priced.Total.Add(pl.Subtotal)
+				if err != nil {
+					return domain.PricedCart{}, err
+				}
+			}
+		}
+
+		priced.Lines = append(priced.Lines, pl)
+	}
+
+	if s.upgrades == nil {
+		return priced, nil
+	}
+
+	return s.upgrades.Apply(ctx, c.CustomerID, priced)
+}
+
+// GuestToken returns the token a guest holds of c: its ID and a signature
+// of it, so that guests cannot guess the carts of others.
+func (s *CartService) GuestToken(c domain.Cart) string {
+	return c.ID + "." + base64.RawURLEncoding.EncodeToString(s.mac(c.ID))
+}
+
+// MergeGuestCart moves the lines of the cart of a guest who logged in to
+// the cart of the customer, and deletes the cart of the guest. Lines of
+// products no longer published are dropped, quantities added up to what a
+// line may hold, and lines that do not fit in the cart dropped. Tokens of
+// no cart are ignored.
+func (s *CartService) MergeGuestCart(ctx context.Context, customerID int, guestToken string) error {
+	guest, err := s.cart(ctx, CartOwner{CustomerID: 0, GuestToken: guestToken})
+	if err != nil || guest.ID == "" {
+		return err
+	}
+
+	if len(guest.Lines) > 0 {
+		err = s.merge(ctx, customerID, guest)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.carts.DeleteCart(ctx, guest.ID)
+	if err != nil && !errors.Is(err, ports.ErrNotFound) {
+		return fmt.Errorf("failed to delete guest cart: %w", err)
+	}
+
+	return nil
+}
+
+// Clear deletes the cart with id, such as once it was paid for. Clearing a
+// cart that does not exist does nothing.
+func (s *CartService) Clear(ctx context.Context, id string) error {
+	err := s.carts.DeleteCart(ctx, id)
+	if err != nil && !errors.Is(err, ports.ErrNotFound) {
+		return fmt.Errorf("failed to delete cart: %w", err)
+	}
+
+	return nil
+}
+
+// Sweep deletes the expired carts, and returns how many were deleted.
+func (s *CartService) Sweep(ctx context.Context) (int, error) {
+	return s.carts.DeleteExpiredCarts(ctx, s.now().UTC())
+}
+
+func (s *CartService) merge(ctx context.Context, customerID int, guest domain.Cart) error {
+	c, err := s.cart(ctx, CartOwner{CustomerID: customerID, GuestToken: ""})
+	if err != nil {
+		return err
+	}
+
+	products, err := s.publishedProducts(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range guest.Lines {
+		p, ok := products[line.ProductID]
+		if !ok {
+			continue
+		}
+
+		existing, _ := c.Line(line.ProductID, line.Gift)
+		line.Quantity = min(existing.Quantity+line.Quantity, domain.MaxQuantity(p.Type, line.Gift))
+
+		err = c.Put(p.Type, line)
+		if errors.Is(err, domain.ErrCartFull) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.save(ctx, c)
+
+	return err
+}
+
+// cart returns the stored cart of owner, or an empty cart with no ID when
+// owner has none. An expired cart that was not swept yet is returned
+// emptied.
+func (s *CartService) cart(ctx context.Context, owner CartOwner) (domain.Cart, error) {
+	var (
+		c   domain.Cart
+		err error
+	)
+
+	if owner.CustomerID != 0 {
+		c, err = s.carts.CartByCustomer(ctx, owner.CustomerID)
+	} else {
+		id, ok := s.parse(owner.GuestToken)
+		if !ok {
+			return newCart(0), nil
+		}
+
+		c, err = s.carts.CartByID(ctx, id)
+		if err == nil && c.CustomerID != 0 {
+			// Guest tokens never give access to the carts of customers.
+			err = ports.ErrNotFound
+		}
+	}
+
+	if errors.Is(err, ports.ErrNotFound) {
+		return newCart(owner.CustomerID), nil
+	}
+
+	if err != nil {
+		return domain.Cart{}, fmt.Errorf("failed to find cart: %w", err)
+	}
+
+	now := s.now().UTC()
+	if !c.Expires.After(now) {
+		c.Lines = nil
+		c.Created = now
+	}
+
+	return c, nil
+}
+
+// newCart returns an empty cart of a customer, or of a guest when
+// customerID is 0, not stored yet.
+func newCart(customerID int) domain.Cart {
+	return domain.Cart{
+		ID:         "",
+		CustomerID: customerID,
+		Lines:      nil,
+		Created:    time.Time{},
+		Updated:    time.Time{},
+		Expires:    time.Time{},
+	}
+}
+
+// save stores c, with an ID drawn if it has none, and returns it as
+// stored. Each change pushes back the expiry of the cart.
+func (s *CartService) save(ctx context.Context, c domain.Cart) (domain.Cart, error) {
+	now := s.now().UTC()
+
+	if c.ID == "" {
+		c.ID = rand.Text()
+		c.Created = now
+	}
+
+	ttl := s.config.GuestTTL
+	if c.CustomerID != 0 {
+		ttl = s.config.CustomerTTL
+	}
+
+	c.Updated = now
+	c.Expires = now.Add(ttl)
+
+	err := s.carts.SaveCart(ctx, c)
+	if err != nil {
+		return domain.Cart{}, fmt.Errorf("failed to save cart: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *CartService) publishedProduct(ctx context.Context, id int) (domain.Product, error) {
+	p, err := s.products.ProductByID(ctx, id)
+	if errors.Is(err, ports.ErrNotFound) || err == nil && !p.Published {
+		return domain.Product{}, ErrProductNotFound
+	}
+
+	if err != nil {
+		return domain.Product{}, fmt.Errorf("failed to find product: %w", err)
+	}
+
+	return p, nil
+}
+
+// publishedProducts returns the published products by ID. The catalog is
+// small enough to be read whole, rather than product by product.
+func (s *CartService) publishedProducts(ctx context.Context) (map[int]domain.Product, error) {
+	products, err := s.products.Products(ctx, ports.ProductFilter{Types: nil, PublishedOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	byID := make(map[int]domain.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	return byID, nil
+}
+
+// parse returns the cart ID of a guest token, if it is signed.
+func (s *CartService) parse(token string) (string, bool) {
+	id, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return "", false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.mac(id)) {
+		return "", false
+	}
+
+	return id, true
+}
+
+func (s *CartService) mac(id string) []byte {
+	h := hmac.New(sha256.New, s.config.Secret)
+	h.Write([]byte(id))
+
+	return h.Sum(nil)
+}
+
+type CartError string
+
+func (e CartError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrCartConfig       CartError = "cart secret must be at least 32 bytes, and ttls positive"
+	ErrCartLineNotFound CartError = "cart has no such line"
+)
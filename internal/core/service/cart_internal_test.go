@@ -0,0 +1,323 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+type cartTest struct {
+	*CartService
+	products *dal.MemoryProducts
+	clock    time.Time
+
+	// plugin, merch and draft are a plugin, a piece of merchandise and an
+	// unpublished plugin of the catalog.
+	plugin, merch, draft domain.Product
+}
+
+func (c *cartTest) advance(dur time.Duration) {
+	c.clock = c.clock.Add(dur)
+}
+
+func (c *cartTest) add(t *testing.T, slug string, typ domain.ProductType, published bool) domain.Product {
+	t.Helper()
+
+	p, err := c.products.CreateProduct(t.Context(), domain.Product{
+		ID:           0,
+		Type:         typ,
+		Slug:         slug,
+		Name:         slug,
+		Description:  "",
+		Version:      "",
+		MachineLimit: 0,
+		Media:        nil,
+		Published:    published,
+		Position:     0,
+		Prices: []domain.Price{
+			{Region: domain.RegionDefault, Money: domain.Money{Amount: 4900, Currency: domain.CurrencyUSD}},
+			{Region: "DE", Money: domain.Money{Amount: 4500, Currency: domain.CurrencyEUR}},
+		},
+		PriceID:   "",
+		ProductID: "",
+		Created:   time.Time{},
+		Updated:   time.Time{},
+	})
+	assert.NoError(t, err)
+
+	return p
+}
+
+func newCartTest(t *testing.T) *cartTest {
+	t.Helper()
+
+	products := dal.NewMemoryProducts()
+
+	svc, err := NewCartService(dal.NewMemoryCarts(), products, nil, CartConfig{
+		Secret:      []byte(strings.Repeat("s", minCartSecretLength)),
+		GuestTTL:    7 * 24 * time.Hour,
+		CustomerTTL: 90 * 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	c := &cartTest{
+		CartService: svc,
+		products:    products,
+		clock:       time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		plugin:      domain.Product{}, //nolint:exhaustruct // set below
+		merch:       domain.Product{}, //nolint:exhaustruct // set below
+		draft:       domain.Product{}, //nolint:exhaustruct // set below
+	}
+	svc.now = func() time.Time { return c.clock }
+
+	c.plugin = c.add(t, "microwave", domain.ProductPlugin, true)
+	c.merch = c.add(t, "shirt", domain.ProductMerchandise, true)
+	c.draft = c.add(t, "draft", domain.ProductPlugin, false)
+
+	return c
+}
+
+func cartLine(productID, quantity int, gift bool) domain.CartLine {
+	return domain.CartLine{ProductID: productID, Quantity: quantity, Gift: gift}
+}
+
+func TestCartService_Lines(t *testing.T) {
+	c := newCartTest(t)
+	ctx := t.Context()
+	owner := CartOwner{CustomerID: 1, GuestToken: ""}
+
+	cart, err := c.Cart(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, cart.ID, "")
+	assert.Equal(t, len(cart.Lines), 0)
+
+	cart, err = c.AddLine(ctx, owner, cartLine(c.plugin.ID, 1, false))
+	assert.NoError(t, err)
+	assert.NotEqual(t, cart.ID, "")
+	assert.Equal(t, cart.CustomerID, 1)
+	assert.True(t, cart.Expires.Equal(c.clock.Add(90*24*time.Hour)))
+
+	_, err = c.AddLine(ctx, owner, cartLine(c.plugin.ID, 1, false))
+	assert.Error(t, err, domain.ErrPluginQuantity)
+
+	_, err = c.AddLine(ctx, owner, cartLine(c.plugin.ID, 2, true))
+	assert.NoError(t, err)
+
+	cart, err = c.AddLine(ctx, owner, cartLine(c.plugin.ID, 1, true))
+	assert.NoError(t, err)
+
+	gifts, _ := cart.Line(c.plugin.ID, true)
+	assert.Equal(t, gifts.Quantity, 3)
+
+	_, err = c.AddLine(ctx, owner, cartLine(c.draft.ID, 1, false))
+	assert.Error(t, err, ErrProductNotFound)
+
+	_, err = c.AddLine(ctx, owner, cartLine(c.merch.ID, 0, false))
+	assert.Error(t, err, domain.ErrInvalidQuantity)
+
+	_, err = c.AddLine(ctx, owner, cartLine(c.merch.ID, 2, false))
+	assert.NoError(t, err)
+
+	cart, err = c.SetQuantity(ctx, owner, cartLine(c.merch.ID, 5, false))
+	assert.NoError(t, err)
+
+	merch, _ := cart.Line(c.merch.ID, false)
+	assert.Equal(t, merch.Quantity, 5)
+
+	_, err = c.SetQuantity(ctx, owner, cartLine(c.plugin.ID, 2, false))
+	assert.Error(t, err, domain.ErrPluginQuantity)
+
+	_, err = c.SetQuantity(ctx, owner, cartLine(c.merch.ID, 1, true))
+	assert.Error(t, err, ErrCartLineNotFound)
+
+	cart, err = c.RemoveLine(ctx, owner, c.plugin.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cart.Lines), 2)
+
+	_, err = c.RemoveLine(ctx, owner, c.plugin.ID, true)
+	assert.Error(t, err, ErrCartLineNotFound)
+
+	// Lines of products taken off the catalog can still be removed.
+	c.merch.Published = false
+	assert.NoError(t, c.products.UpdateProduct(ctx, c.merch))
+
+	_, err = c.SetQuantity(ctx, owner, cartLine(c.merch.ID, 2, false))
+	assert.Error(t, err, ErrProductNotFound)
+
+	cart, err = c.RemoveLine(ctx, owner, c.merch.ID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cart.Lines), 1)
+}
+
+func TestCartService_Price(t *testing.T) {
+	c := newCartTest(t)
+	ctx := t.Context()
+	owner := CartOwner{CustomerID: 1, GuestToken: ""}
+
+	_, err := c.AddLine(ctx, owner, cartLine(c.plugin.ID, 1, false))
+	assert.NoError(t, err)
+
+	cart, err := c.AddLine(ctx, owner, cartLine(c.merch.ID, 3, false))
+	assert.NoError(t, err)
+
+	priced, err := c.Price(ctx, cart, domain.CurrencyEUR, "DE")
+	assert.NoError(t, err)
+	assert.Equal(t, len(priced.Lines), 2)
+	assert.True(t, priced.Lines[0].Available)
+	assert.Equal(t, priced.Lines[0].Product.Slug, "microwave")
+	assert.Equal(t, priced.Lines[1].UnitPrice, domain.Money{Amount: 4500, Currency: domain.CurrencyEUR})
+	assert.Equal(t, priced.Lines[1].Subtotal, domain.Money{Amount: 13500, Currency: domain.CurrencyEUR})
+	assert.Equal(t, priced.Total, domain.Money{Amount: 18000, Currency: domain.CurrencyEUR})
+
+	// Prices are read from the catalog every time.
+	c.plugin.Prices[1].Money.Amount = 3000
+	assert.NoError(t, c.products.UpdateProduct(ctx, c.plugin))
+
+	priced, err = c.Price(ctx, cart, domain.CurrencyEUR, "DE")
+	assert.NoError(t, err)
+	assert.Equal(t, priced.Total.Amount, 16500)
+
+	// Products without a price in the currency, or taken off the catalog,
+	// are left out of the total.
+	priced, err = c.Price(ctx, cart, domain.CurrencyEUR, "FR")
+	assert.NoError(t, err)
+	assert.False(t, priced.Lines[0].Available)
+	assert.Equal(t, priced.Total.Amount, 0)
+
+	c.plugin.Published = false
+	assert.NoError(t, c.products.UpdateProduct(ctx, c.plugin))
+
+	priced, err = c.Price(ctx, cart, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+	assert.False(t, priced.Lines[0].Available)
+	assert.True(t, priced.Lines[1].Available)
+	assert.Equal(t, priced.Total.Amount, 14700)
+
+	_, err = c.Price(ctx, cart, "XXX", domain.RegionDefault)
+	assert.Error(t, err, domain.ErrInvalidCurrency)
+
+	_, err = c.Price(ctx, cart, domain.CurrencyUSD, "germany")
+	assert.Error(t, err, domain.ErrInvalidRegion)
+}
+
+func TestCartService_Guest(t *testing.T) {
+	c := newCartTest(t)
+	ctx := t.Context()
+
+	cart, err := c.AddLine(ctx, CartOwner{CustomerID: 0, GuestToken: ""}, cartLine(c.merch.ID, 1, false))
+	assert.NoError(t, err)
+	assert.Equal(t, cart.CustomerID, 0)
+	assert.True(t, cart.Expires.Equal(c.clock.Add(7*24*time.Hour)))
+
+	token := c.GuestToken(cart)
+	guest := CartOwner{CustomerID: 0, GuestToken: token}
+
+	got, err := c.Cart(ctx, guest)
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, cart.ID)
+	assert.Equal(t, len(got.Lines), 1)
+
+	// Forged tokens open no cart.
+	for _, forged := range []string{cart.ID, cart.ID + ".", cart.ID + "x" + token[len(cart.ID):], "." + token} {
+		got, err = c.Cart(ctx, CartOwner{CustomerID: 0, GuestToken: forged})
+		assert.NoError(t, err)
+		assert.Equal(t, got.ID, "")
+	}
+
+	// Guest tokens never open the carts of customers.
+	customerCart, err := c.AddLine(ctx, CartOwner{CustomerID: 1, GuestToken: ""}, cartLine(c.merch.ID, 1, false))
+	assert.NoError(t, err)
+
+	got, err = c.Cart(ctx, CartOwner{CustomerID: 0, GuestToken: c.GuestToken(customerCart)})
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, "")
+
+	// Expired carts are emptied, then swept.
+	c.advance(7 * 24 * time.Hour)
+
+	got, err = c.Cart(ctx, guest)
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, cart.ID)
+	assert.Equal(t, len(got.Lines), 0)
+
+	n, err := c.Sweep(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+
+	got, err = c.Cart(ctx, guest)
+	assert.NoError(t, err)
+	assert.Equal(t, got.ID, "")
+}
+
+func TestCartService_MergeGuestCart(t *testing.T) {
+	c := newCartTest(t)
+	ctx := t.Context()
+	customer := CartOwner{CustomerID: 1, GuestToken: ""}
+
+	_, err := c.AddLine(ctx, customer, cartLine(c.plugin.ID, 1, false))
+	assert.NoError(t, err)
+
+	_, err = c.AddLine(ctx, customer, cartLine(c.merch.ID, 98, false))
+	assert.NoError(t, err)
+
+	guestCart, err := c.AddLine(ctx, CartOwner{CustomerID: 0, GuestToken: ""}, cartLine(c.plugin.ID, 1, false))
+	assert.NoError(t, err)
+
+	token := c.GuestToken(guestCart)
+	guest := CartOwner{CustomerID: 0, GuestToken: token}
+
+	_, err = c.AddLine(ctx, guest, cartLine(c.merch.ID, 5, false))
+	assert.NoError(t, err)
+
+	guestCart, err = c.AddLine(ctx, guest, cartLine(c.plugin.ID, 2, true))
+	assert.NoError(t, err)
+
+	// The guest holds a line of a product unpublished since.
+	guestCart.Lines = append(guestCart.Lines, cartLine(c.draft.ID, 1, false))
+	_, err = c.save(ctx, guestCart)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.MergeGuestCart(ctx, 1, token))
+
+	cart, err := c.Cart(ctx, customer)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cart.Lines), 3)
+
+	plugin, _ := cart.Line(c.plugin.ID, false)
+	assert.Equal(t, plugin.Quantity, 1)
+
+	merch, _ := cart.Line(c.merch.ID, false)
+	assert.Equal(t, merch.Quantity, domain.MaxLineQuantity)
+
+	gifts, _ := cart.Line(c.plugin.ID, true)
+	assert.Equal(t, gifts.Quantity, 2)
+
+	_, ok := cart.Line(c.draft.ID, false)
+	assert.False(t, ok)
+
+	// The guest cart is gone, so merging again changes nothing.
+	guestCart, err = c.Cart(ctx, guest)
+	assert.NoError(t, err)
+	assert.Equal(t, guestCart.ID, "")
+
+	assert.NoError(t, c.MergeGuestCart(ctx, 1, token))
+	assert.NoError(t, c.MergeGuestCart(ctx, 1, "forged"))
+
+	// Customers without a cart get the lines of the guest.
+	guestCart, err = c.AddLine(ctx, CartOwner{CustomerID: 0, GuestToken: ""}, cartLine(c.merch.ID, 1, false))
+	assert.NoError(t, err)
+	assert.NoError(t, c.MergeGuestCart(ctx, 2, c.GuestToken(guestCart)))
+
+	cart, err = c.Cart(ctx, CartOwner{CustomerID: 2, GuestToken: ""})
+	assert.NoError(t, err)
+	assert.Equal(t, len(cart.Lines), 1)
+	assert.Equal(t, cart.CustomerID, 2)
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// CatalogService serves the product catalog: the published products to
+// everyone, and every product to the staff managing them.
+type CatalogService struct {
+	products ports.ProductRepository
+	now      func() time.Time
+}
+
+func NewCatalogService(products ports.ProductRepository) *CatalogService {
+	return &CatalogService{
+		products: products,
+		now:      time.Now,
+	}
+}
+
+// Catalog returns the published products of types, or of every type when
+// types is empty, in catalog order.
+func (s *CatalogService) Catalog(ctx context.Context, types ...domain.ProductType) ([]domain.Product, error) {
+	products, err := s.products.Products(ctx, ports.ProductFilter{Types: types, PublishedOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	return products, nil
+}
+
+// PublishedProduct returns the published product with slug. Unpublished
+// products are not found.
+func (s *CatalogService) PublishedProduct(ctx context.Context, slug string) (domain.Product, error) {
+	p, err := s.products.ProductBySlug(ctx, slug)
+	if errors.Is(err, ports.ErrNotFound) || err == nil && !p.Published {
+		return domain.Product{}, ErrProductNotFound
+	}
+
+	if err != nil {
+		return domain.Product{}, fmt.Errorf("failed to find product: %w", err)
+	}
+
+	return p, nil
+}
+
+// Products returns every product, published or not, in catalog order.
+func (s *CatalogService) Products(ctx context.Context) ([]domain.Product, error) {
+	products, err := s.products.Products(ctx, ports.ProductFilter{Types: nil, PublishedOnly: false})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	return products, nil
+}
+
+// Product returns the product with id, published or not.
+func (s *CatalogService) Product(ctx context.Context, id int) (domain.Product, error) {
+	p, err := s.products.ProductByID(ctx, id)
+	if errors.Is(err, ports.ErrNotFound) {
+		return domain.Product{}, ErrProductNotFound
+	}
+
+	if err != nil {
+		return domain.Product{}, fmt.Errorf("failed to find product: %w", err)
+	}
+
+	return p, nil
+}
+
+// CreateProduct adds p to the catalog, and returns it with its ID
+// assigned.
+func (s *CatalogService) CreateProduct(ctx context.Context, p domain.Product) (domain.Product, error) {
+	err := domain.ValidateProduct(p)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	now := s.now().UTC()
+	p.ID = 0
+	p.Created = now
+	p.Updated = now
+
+	p, err = s.products.CreateProduct(ctx, p)
+	if errors.Is(err, ports.ErrConflict) {
+		return domain.Product{}, ErrSlugTaken
+	}
+
+	if err != nil {
+		return domain.Product{}, fmt.Errorf("failed to create product: %w", err)
+	}
+
+	return p, nil
+}
+
+// UpdateProduct replaces the product with the ID of p, and returns it as
+// stored.
+func (s *CatalogService) UpdateProduct(ctx context.Context, p domain.Product) (domain.Product, error) {
+	err := domain.ValidateProduct(p)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	old, err := s.Product(ctx, p.ID)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	p.Created = old.Created
+	p.Updated = s.now().UTC()
+
+	err = s.products.UpdateProduct(ctx, p)
+	if errors.Is(err, ports.ErrNotFound) {
+		return domain.Product{}, ErrProductNotFound
+	}
+
+	if errors.Is(err, ports.ErrConflict) {
+		return domain.Product{}, ErrSlugTaken
+	}
+
+	if err != nil {
+		return domain.Product{}, fmt.Errorf("failed to update product: %w", err)
+	}
+
+	return p, nil
+}
+
+// DeleteProduct removes a product from the catalog. Products that were
+// sold should rather be unpublished.
+func (s *CatalogService) DeleteProduct(ctx context.Context, id int) error {
+	err := s.products.DeleteProduct(ctx, id)
+	if errors.Is(err, ports.ErrNotFound) {
+		return ErrProductNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+
+	return nil
+}
+
+type CatalogError string
+
+func (e CatalogError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrProductNotFound CatalogError = "product not found"
+	ErrSlugTaken       CatalogError = "another product has the slug"
+)
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+func newCatalogProduct(slug string, typ domain.ProductType, published bool) domain.Product {
+	return domain.Product{
+		ID:           0,
+		Type:         typ,
+		Slug:         slug,
+		Name:         "Product " + slug,
+		Description:  "",
+		Version:      "",
+		MachineLimit: 0,
+		Media:        nil,
+		Published:    published,
+		Position:     0,
+		Prices:       nil,
+		PriceID:      "",
+		ProductID:    "",
+		Created:      time.Time{},
+		Updated:      time.Time{},
+	}
+}
+
+func TestCatalogService(t *testing.T) {
+	ctx := t.Context()
+	catalog := service.NewCatalogService(dal.NewMemoryProducts())
+
+	microwave, err := catalog.CreateProduct(ctx, newCatalogProduct("microwave", domain.ProductPlugin, true))
+	assert.NoError(t, err)
+	assert.False(t, microwave.Created.IsZero())
+
+	_, err = catalog.CreateProduct(ctx, newCatalogProduct("microwave", domain.ProductPlugin, true))
+	assert.Error(t, err, service.ErrSlugTaken)
+
+	_, err = catalog.CreateProduct(ctx, newCatalogProduct("Microwave", domain.ProductPlugin, true))
+	assert.Error(t, err, domain.ErrInvalidSlug)
+
+	_, err = catalog.CreateProduct(ctx, newCatalogProduct("shirt", domain.ProductMerchandise, true))
+	assert.NoError(t, err)
+
+	draft, err := catalog.CreateProduct(ctx, newCatalogProduct("oven", domain.ProductPlugin, false))
+	assert.NoError(t, err)
+
+	// Unpublished products are kept out of the public catalog.
+	products, err := catalog.Catalog(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(products), 2)
+
+	products, err = catalog.Catalog(ctx, domain.ProductPlugin)
+	assert.NoError(t, err)
+	assert.Equal(t, len(products), 1)
+
+	_, err = catalog.PublishedProduct(ctx, "oven")
+	assert.Error(t, err, service.ErrProductNotFound)
+
+	products, err = catalog.Products(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(products), 3)
+
+	// Updates keep the creation time.
+	created := draft.Created
+	draft.Published = true
+	draft.Created = time.Time{}
+
+	updated, err := catalog.UpdateProduct(ctx, draft)
+	assert.NoError(t, err)
+	assert.True(t, updated.Created.Equal(created))
+
+	p, err := catalog.PublishedProduct(ctx, "oven")
+	assert.NoError(t, err)
+	assert.Equal(t, p.ID, draft.ID)
+
+	draft.Slug = "shirt"
+	_, err = catalog.UpdateProduct(ctx, draft)
+	assert.Error(t, err, service.ErrSlugTaken)
+
+	assert.NoError(t, catalog.DeleteProduct(ctx, draft.ID))
+	assert.Error(t, catalog.DeleteProduct(ctx, draft.ID), service.ErrProductNotFound)
+
+	_, err = catalog.UpdateProduct(ctx, draft)
+	assert.Error(t, err, service.ErrProductNotFound)
+}
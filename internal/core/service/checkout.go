@@ -0,0 +1,357 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// CheckoutConfig configures checkout.
+type CheckoutConfig struct {
+	// SuccessURL is the page customers are sent back to once they paid.
+	// Stripe replaces {CHECKOUT_SESSION_ID} in it with the ID of the
+	// session.
+	SuccessURL string
+
+	// CancelURL is the page customers are sent back to when they give up
+	// paying.
+	CancelURL string
+}
+
+func (c CheckoutConfig) Validate() error {
+	for _, page := range []string{c.SuccessURL, c.CancelURL} {
+		u, err := url.Parse(page)
+		if err != nil || !u.IsAbs() {
+			return ErrCheckoutConfig
+		}
+	}
+
+	return nil
+}
+
+func (c CheckoutConfig) Value() any {
+	return c
+}
+
+// CheckoutService checks out the carts of customers, by opening a payment
+// page of the payment provider for them.
+type CheckoutService struct {
+	customers ports.CustomerRepository
+	carts     *CartService
+	payments  ports.PaymentProvider
+	orders    *OrderService
+	audit     ports.AuditLog
+	config    CheckoutConfig
+	now       func() time.Time
+}
+
+func NewCheckoutService(
+	customers ports.CustomerRepository,
+	carts *CartService,
+	payments ports.PaymentProvider,
+	orders *OrderService,
+	audit ports.AuditLog,
+	config CheckoutConfig,
+) (*CheckoutService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckoutService{
+		customers: customers,
+		carts:     carts,
+		payments:  payments,
+		orders:    orders,
+		audit:     audit,
+		config:    config,
+		now:       time.Now,
+	}, nil
+}
+
+// Checkout opens a payment page for the cart of a customer, priced in
+// currency for customers in region. Only verified customers may check out,
+// and only carts with every line available.
+//
+// Lines charged the default price of their product use its provider price,
+// which mirrors the default prices; lines charged a regional or an upgrade
+// price are charged it as a price made up for the session. Checking out the
+// same cart twice opens the same page, as long as neither the cart nor its
+// prices changed.
+//
+// Every session opened comes with a pending order, placed before the
+// session and moved along by its payment events. Carts checked out again
+// once their order was closed, such as when its session expired, get a new
+// order and session. A license backs one open upgrade: checking out an
+// upgrade cancels the other pending orders upgrading from its license, and
+// expires their sessions.
+func (s *CheckoutService) Checkout(
+	ctx context.Context,
+	customerID int,
+	currency domain.Currency,
+	region domain.Region,
+) (domain.CheckoutSession, error) {
+	c, err := s.customers.CustomerByID(ctx, customerID)
+	if err != nil {
+		return domain.CheckoutSession{}, fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	if !c.Verified {
+		return domain.CheckoutSession{}, ErrCustomerUnverified
+	}
+
+	cart, err := s.carts.Cart(ctx, CartOwner{CustomerID: customerID, GuestToken: ""})
+	if err != nil {
+		return domain.CheckoutSession{}, err
+	}
+
+	if len(cart.Lines) == 0 {
+		return domain.CheckoutSession{}, ErrCartEmpty
+	}
+
+	priced, err := s.carts.Price(ctx, cart, currency, region)
+	if err != nil {
+		return domain.CheckoutSession{}, err
+	}
+
+	lines := make([]domain.CheckoutLine, 0, len(priced.Lines))
+
+	for _, l := range priced.Lines {
+		if !l.Available {
+			return domain.CheckoutSession{}, ErrCartUnavailable
+		}
+
+		line := domain.CheckoutLine{
+			PriceID:   "",
+			ProductID: l.Product.ProductID,
+			Name:      l.Product.Name,
+			UnitPrice: l.UnitPrice,
+			Quantity:  l.Line.Quantity,
+		}
+
+		listPrice, _ := l.Product.Price(currency, domain.RegionDefault)
+		if l.Upgrade == nil && listPrice == l.UnitPrice {
+			line.PriceID = l.Product.PriceID
+		}
+
+		lines = append(lines, line)
+	}
+
+	paymentCustomerID, err := s.syncCustomer(ctx, c)
+	if err != nil {
+		return domain.CheckoutSession{}, err
+	}
+
+	req := domain.CheckoutRequest{
+		Reference:         cart.ID,
+		CustomerID:        customerID,
+		PaymentCustomerID: paymentCustomerID,
+		Currency:          currency,
+		Lines:             lines,
+		SuccessURL:        s.config.SuccessURL,
+		CancelURL:         s.config.CancelURL,
+		IdempotencyKey:    "",
+	}
+	key := checkoutKey(req)
+	req.IdempotencyKey = key
+
+	o, err := s.orders.Place(ctx, customerID, req.IdempotencyKey, priced)
+	for err == nil && o.Status != domain.OrderPending {
+		// The key of each closed order leads to the next one.
+		req.IdempotencyKey = key + "-" + strconv.Itoa(o.ID)
+		o, err = s.orders.Place(ctx, customerID, req.IdempotencyKey, priced)
+	}
+
+	if err != nil {
+		return domain.CheckoutSession{}, err
+	}
+
+	err = s.supersede(ctx, o)
+	if err != nil {
+		return domain.CheckoutSession{}, err
+	}
+
+	session, err := s.payments.CreateCheckoutSession(ctx, req)
+	if err != nil {
+		return domain.CheckoutSession{}, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+
+	_, err = s.orders.AttachCheckout(ctx, o, session)
+	if err != nil {
+		return domain.CheckoutSession{}, err
+	}
+
+	return session, nil
+}
+
+// CompleteCheckout handles the events of completed checkouts: once the
+// payment of a checkout went through, it empties the cart it was opened
+// for. Payments that take time, such as bank debits, go through with a
+// later EventCheckoutAsyncPaymentPaid event.
+func (s *CheckoutService) CompleteCheckout(ctx context.Context, e domain.PaymentEvent) error {
+	if e.Checkout == nil || e.Checkout.PaymentStatus == domain.PaymentUnpaid {
+		return nil
+	}
+
+	// Only the cart of the customer who checked out is cleared, whatever
+	// the session refers to.
+	cart, err := s.carts.Cart(ctx, CartOwner{CustomerID: e.Checkout.CustomerID, GuestToken: ""})
+	if err != nil || cart.ID == "" || cart.ID != e.Checkout.Reference {
+		return err
+	}
+
+	return s.carts.Clear(ctx, cart.ID)
+}
+
+// RecordRefund handles the events of refunded charges by recording them in
+// the audit trail.
+func (s *CheckoutService) RecordRefund(ctx context.Context, e domain.PaymentEvent) error {
+	if e.Charge == nil {
+		return nil
+	}
+
+	return s.record(ctx, domain.AuditPaymentRefunded, e.Charge.PaymentID,
+		fmt.Sprintf("charge %s refunded %s of %s", e.Charge.ID, e.Charge.Refunded, e.Charge.Amount))
+}
+
+// RecordDispute handles the events of disputed charges by recording them
+// in the audit trail.
+func (s *CheckoutService) RecordDispute(ctx context.Context, e domain.PaymentEvent) error {
+	if e.Dispute == nil {
+		return nil
+	}
+
+	return s.record(ctx, domain.AuditPaymentDisputed, e.Dispute.PaymentID,
+		fmt.Sprintf("dispute %s of %s on charge %s is %s: %s",
+			e.Dispute.ID, e.Dispute.Amount, e.Dispute.ChargeID, e.Dispute.Status, e.Dispute.Reason))
+}
+
+func (s *CheckoutService) record(ctx context.Context, t domain.AuditEventType, paymentID, detail string) error {
+	err := s.audit.RecordAudit(ctx, domain.AuditEvent{
+		Type:    t,
+		Subject: "payment " + paymentID,
+		IP:      "",
+		Time:    s.now().UTC(),
+		Detail:  detail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// supersede cancels the other pending orders of the customer of o that
+// upgrade from a license o upgrades from. It returns ErrUpgradeInProgress
+// when one of them was paid meanwhile.
+func (s *CheckoutService) supersede(ctx context.Context, o domain.Order) error {
+	sources := make(map[int]bool)
+
+	for _, line := range o.Lines {
+		if line.UpgradeFrom != 0 {
+			sources[line.UpgradeFrom] = true
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil
+	}
+
+	orders, err := s.orders.CustomerOrders(ctx, o.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range orders {
+		if other.ID == o.ID || other.Status != domain.OrderPending ||
+			!slices.ContainsFunc(other.Lines, func(l domain.OrderLine) bool { return sources[l.UpgradeFrom] }) {
+			continue
+		}
+
+		err = s.orders.Supersede(ctx, other, o)
+		if errors.Is(err, ErrOrderPaid) || errors.Is(err, ErrOrderChanged) {
+			return ErrUpgradeInProgress
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncCustomer brings the customer at the payment provider up to date with
+// c, and returns their ID there. Customers are created with a random key
+// recorded beforehand, so that retries and racing checkouts create a single
+// one, whichever customer had the ID of c before.
+func (s *CheckoutService) syncCustomer(ctx context.Context, c domain.Customer) (string, error) {
+	var key string
+
+	if c.PaymentCustomerID == "" {
+		var err error
+
+		key, err = s.customers.ReservePaymentCustomerKey(ctx, c.ID, "customer-"+rand.Text())
+		if err != nil {
+			return "", fmt.Errorf("failed to reserve payment customer key: %w", err)
+		}
+	}
+
+	id, err := s.payments.SyncCustomer(ctx, c, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sync payment customer: %w", err)
+	}
+
+	if id != c.PaymentCustomerID {
+		err = s.customers.SetPaymentCustomerID(ctx, c.ID, id)
+		if err != nil {
+			return "", fmt.Errorf("failed to save payment customer: %w", err)
+		}
+	}
+
+	return id, nil
+}
+
+// checkoutKey returns the idempotency key of req, a digest of everything it
+// asks for, so that only identical requests share a key.
+func checkoutKey(req domain.CheckoutRequest) string {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%s\x00%d\x00%s\x00%s\x00%s\x00%s", req.Reference, req.CustomerID,
+		req.PaymentCustomerID, req.Currency, req.SuccessURL, req.CancelURL)
+
+	for _, l := range req.Lines {
+		fmt.Fprintf(h, "\x00%s\x00%s\x00%s\x00%d\x00%s\x00%d", l.PriceID, l.ProductID,
+			l.Name, l.UnitPrice.Amount, l.UnitPrice.Currency, l.Quantity)
+	}
+
+	return "checkout-" + hex.EncodeToString(h.Sum(nil))
+}
+
+type CheckoutError string
+
+func (e CheckoutError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrCheckoutConfig  CheckoutError = "checkout success and cancel URLs must be absolute"
+	ErrCartEmpty       CheckoutError = "cart is empty"
+	ErrCartUnavailable CheckoutError = "cart has lines that cannot be bought"
+
+	ErrUpgradeInProgress CheckoutError = "an upgrade in the cart is being paid for in another checkout"
+)
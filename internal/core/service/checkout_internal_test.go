@@ -0,0 +1,413 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// fakePayments is a ports.PaymentProvider recording the checkout and
+// refund requests it gets, the sessions it expires, and the keys customers
+// are created with.
+type fakePayments struct {
+	requests     []domain.CheckoutRequest
+	refunds      []domain.RefundRequest
+	expired      []string
+	customerKeys []string
+	synced       int
+	err          error
+}
+
+func (f *fakePayments) CreateCheckoutSession(
+	_ context.Context,
+	req domain.CheckoutRequest,
+) (domain.CheckoutSession, error) {
+	if f.err != nil {
+		return domain.CheckoutSession{}, f.err
+	}
+
+	f.requests = append(f.requests, req)
+
+	return domain.CheckoutSession{
+		ID:                "cs_" + req.IdempotencyKey,
+		URL:               "https://checkout.example.com/" + req.IdempotencyKey,
+		Reference:         req.Reference,
+		CustomerID:        req.CustomerID,
+		PaymentCustomerID: req.PaymentCustomerID,
+		Status:            domain.CheckoutOpen,
+		PaymentStatus:     domain.PaymentUnpaid,
+		PaymentID:         "",
+		Total:             domain.Money{Amount: 0, Currency: req.Currency},
+		Expires:           time.Time{},
+	}, nil
+}
+
+func (f *fakePayments) CheckoutSession(context.Context, string) (domain.CheckoutSession, error) {
+	return domain.CheckoutSession{}, ports.ErrNotFound
+}
+
+func (f *fakePayments) ExpireCheckoutSession(_ context.Context, id string) (domain.CheckoutSession, error) {
+	if f.err != nil {
+		return domain.CheckoutSession{}, f.err
+	}
+
+	f.expired = append(f.expired, id)
+
+	return domain.CheckoutSession{
+		ID:                id,
+		URL:               "",
+		Reference:         "",
+		CustomerID:        0,
+		PaymentCustomerID: "",
+		Status:            domain.CheckoutExpired,
+		PaymentStatus:     domain.PaymentUnpaid,
+		PaymentID:         "",
+		Total:             domain.Money{Amount: 0, Currency: ""},
+		Expires:           time.Time{},
+	}, nil
+}
+
+func (f *fakePayments) Refund(_ context.Context, req domain.RefundRequest) (domain.Refund, error) {
+	if f.err != nil {
+		return domain.Refund{}, f.err
+	}
+
+	f.refunds = append(f.refunds, req)
+
+	return domain.Refund{
+		ID:        "re_" + req.IdempotencyKey,
+		PaymentID: req.PaymentID,
+		Amount:    req.Amount,
+		Status:    domain.RefundSucceeded,
+	}, nil
+}
+
+func (f *fakePayments) SyncCustomer(_ context.Context, c domain.Customer, idempotencyKey string) (string, error) {
+	f.synced++
+
+	if c.PaymentCustomerID != "" {
+		return c.PaymentCustomerID, nil
+	}
+
+	f.customerKeys = append(f.customerKeys, idempotencyKey)
+
+	return "cus_1", nil
+}
+
+func newCheckoutTest(t *testing.T, verified bool) (*CheckoutService, *cartTest, *fakePayments, *dal.MemoryCustomers) {
+	t.Helper()
+
+	carts := newCartTest(t)
+	customers := dal.NewMemoryCustomers()
+	payments := &fakePayments{requests: nil, refunds: nil, expired: nil, customerKeys: nil, synced: 0, err: nil}
+
+	_, err := customers.CreateCustomer(t.Context(), domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          verified,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	carts.plugin.PriceID = "price_microwave"
+	carts.plugin.ProductID = "prod_microwave"
+	assert.NoError(t, carts.products.UpdateProduct(t.Context(), carts.plugin))
+
+	svc, err := NewCheckoutService(
+		customers,
+		carts.CartService,
+		payments,
+		NewOrderService(dal.NewMemoryOrders(), payments),
+		dal.NewMemoryAuditLog(),
+		CheckoutConfig{
+			SuccessURL: "https://brokedaear.com/checkout/success?session={CHECKOUT_SESSION_ID}",
+			CancelURL:  "https://brokedaear.com/cart",
+		},
+	)
+	assert.NoError(t, err)
+
+	return svc, carts, payments, customers
+}
+
+func TestCheckoutService_Checkout(t *testing.T) {
+	svc, carts, payments, customers := newCheckoutTest(t, true)
+	ctx := t.Context()
+	owner := CartOwner{CustomerID: 1, GuestToken: ""}
+
+	_, err := svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.Error(t, err, ErrCartEmpty)
+
+	_, err = carts.AddLine(ctx, owner, cartLine(carts.plugin.ID, 1, false))
+	assert.NoError(t, err)
+
+	cart, err := carts.AddLine(ctx, owner, cartLine(carts.merch.ID, 2, false))
+	assert.NoError(t, err)
+
+	session, err := svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+	assert.Equal(t, session.Reference, cart.ID)
+	assert.Equal(t, session.PaymentCustomerID, "cus_1")
+
+	req := payments.requests[0]
+	assert.Equal(t, req.CustomerID, 1)
+	assert.Equal(t, req.Currency, domain.CurrencyUSD)
+	assert.Equal(t, len(req.Lines), 2)
+	assert.Equal(t, req.Lines[0], domain.CheckoutLine{
+		PriceID:   "price_microwave",
+		ProductID: "prod_microwave",
+		Name:      "microwave",
+		UnitPrice: domain.Money{Amount: 4900, Currency: domain.CurrencyUSD},
+		Quantity:  1,
+	})
+	assert.Equal(t, req.Lines[1].PriceID, "")
+	assert.Equal(t, req.Lines[1].Quantity, 2)
+
+	c, err := customers.CustomerByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, c.PaymentCustomerID, "cus_1")
+
+	// The customer was created with the random key reserved for them.
+	key, err := customers.ReservePaymentCustomerKey(ctx, 1, "customer-other")
+	assert.NoError(t, err)
+	assert.Equal(t, len(payments.customerKeys), 1)
+	assert.Equal(t, payments.customerKeys[0], key)
+	assert.NotEqual(t, key, "customer-other")
+
+	// The session comes with a pending order of what was in the cart.
+	order, err := svc.orders.orders.OrderByCheckout(ctx, session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, order.Status, domain.OrderPending)
+	assert.Equal(t, order.CustomerID, 1)
+	assert.Equal(t, len(order.Lines), 2)
+	assert.Equal(t, order.Lines[0].Name, "microwave")
+
+	// Checking out the same cart again asks for the same session.
+	again, err := svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+	assert.Equal(t, again.ID, session.ID)
+	assert.Equal(t, payments.synced, 2)
+
+	orders, err := svc.orders.CustomerOrders(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), 1)
+
+	// Regional prices are charged as made up prices.
+	_, err = svc.Checkout(ctx, 1, domain.CurrencyEUR, "DE")
+	assert.NoError(t, err)
+
+	regional := payments.requests[2]
+	assert.NotEqual(t, regional.IdempotencyKey, req.IdempotencyKey)
+	assert.Equal(t, regional.Lines[0].PriceID, "")
+	assert.Equal(t, regional.Lines[0].UnitPrice, domain.Money{Amount: 4500, Currency: domain.CurrencyEUR})
+
+	// Changing the cart asks for another session.
+	_, err = carts.SetQuantity(ctx, owner, cartLine(carts.merch.ID, 3, false))
+	assert.NoError(t, err)
+
+	changed, err := svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+	assert.NotEqual(t, changed.ID, session.ID)
+}
+
+func TestCheckoutService_Errors(t *testing.T) {
+	svc, carts, payments, _ := newCheckoutTest(t, false)
+	ctx := t.Context()
+	owner := CartOwner{CustomerID: 1, GuestToken: ""}
+
+	_, err := carts.AddLine(ctx, owner, cartLine(carts.merch.ID, 1, false))
+	assert.NoError(t, err)
+
+	_, err = svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.Error(t, err, ErrCustomerUnverified)
+
+	_, err = svc.Checkout(ctx, 2, domain.CurrencyUSD, domain.RegionDefault)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	svc, carts, payments, _ = newCheckoutTest(t, true)
+
+	_, err = carts.AddLine(ctx, owner, cartLine(carts.merch.ID, 1, false))
+	assert.NoError(t, err)
+
+	// The catalog has no GBP prices.
+	_, err = svc.Checkout(ctx, 1, domain.CurrencyGBP, domain.RegionDefault)
+	assert.Error(t, err, ErrCartUnavailable)
+
+	_, err = svc.Checkout(ctx, 1, "XXX", domain.RegionDefault)
+	assert.Error(t, err, domain.ErrInvalidCurrency)
+
+	payments.err = ports.ErrPaymentUnavailable
+
+	_, err = svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.Error(t, err, ports.ErrPaymentUnavailable)
+	assert.Equal(t, len(payments.requests), 0)
+
+	// The order was placed before the session, which trying again opens
+	// for it.
+	orders, err := svc.orders.CustomerOrders(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), 1)
+	assert.Equal(t, orders[0].CheckoutID, "")
+
+	payments.err = nil
+
+	session, err := svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+
+	order, err := svc.orders.OrderByCheckout(ctx, session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, order.ID, orders[0].ID)
+}
+
+func TestCheckoutService_CheckoutAgain(t *testing.T) {
+	svc, carts, _, _ := newCheckoutTest(t, true)
+	ctx := t.Context()
+	owner := CartOwner{CustomerID: 1, GuestToken: ""}
+
+	_, err := carts.AddLine(ctx, owner, cartLine(carts.merch.ID, 1, false))
+	assert.NoError(t, err)
+
+	expired, err := svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+
+	expired.Status = domain.CheckoutExpired
+	assert.NoError(t, svc.orders.CancelOrder(ctx, domain.PaymentEvent{
+		ID: "evt_1", Type: domain.EventCheckoutExpired, Created: time.Time{}, Checkout: &expired, Charge: nil,
+		Dispute: nil,
+	}))
+
+	// The same cart gets a new order and session once its order is closed,
+	// and keeps them afterwards.
+	session, err := svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+	assert.NotEqual(t, session.ID, expired.ID)
+
+	again, err := svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+	assert.Equal(t, again.ID, session.ID)
+
+	order, err := svc.orders.OrderByCheckout(ctx, session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, order.Status, domain.OrderPending)
+
+	orders, err := svc.orders.CustomerOrders(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), 2)
+}
+
+func TestCheckoutConfig_Validate(t *testing.T) {
+	assert.Error(t, CheckoutConfig{SuccessURL: "/success", CancelURL: "https://brokedaear.com/cart"}.Validate(),
+		ErrCheckoutConfig)
+	assert.Error(t, CheckoutConfig{SuccessURL: "https://brokedaear.com/success", CancelURL: ""}.Validate(),
+		ErrCheckoutConfig)
+}
+
+func TestCheckoutService_CompleteCheckout(t *testing.T) {
+	svc, carts, _, _ := newCheckoutTest(t, true)
+	ctx := t.Context()
+	owner := CartOwner{CustomerID: 1, GuestToken: ""}
+
+	cart, err := carts.AddLine(ctx, owner, cartLine(carts.merch.ID, 1, false))
+	assert.NoError(t, err)
+
+	session, err := svc.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+
+	event := domain.PaymentEvent{
+		ID:       "evt_1",
+		Type:     domain.EventCheckoutCompleted,
+		Created:  time.Time{},
+		Checkout: &session,
+		Charge:   nil,
+		Dispute:  nil,
+	}
+
+	// Sessions still waiting for an asynchronous payment keep the cart.
+	assert.NoError(t, svc.CompleteCheckout(ctx, event))
+
+	kept, err := carts.Cart(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, kept.ID, cart.ID)
+
+	session.PaymentStatus = domain.PaymentPaid
+
+	// Sessions of other carts, or of other customers, do not clear it.
+	other := session
+	other.Reference = "cart_other"
+	assert.NoError(t, svc.CompleteCheckout(ctx, domain.PaymentEvent{
+		ID: "evt_2", Type: event.Type, Created: time.Time{}, Checkout: &other, Charge: nil, Dispute: nil,
+	}))
+
+	other = session
+	other.CustomerID = 2
+	assert.NoError(t, svc.CompleteCheckout(ctx, domain.PaymentEvent{
+		ID: "evt_3", Type: event.Type, Created: time.Time{}, Checkout: &other, Charge: nil, Dispute: nil,
+	}))
+
+	kept, err = carts.Cart(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, kept.ID, cart.ID)
+
+	assert.NoError(t, svc.CompleteCheckout(ctx, event))
+
+	cleared, err := carts.Cart(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, cleared.ID, "")
+
+	// Handling the event again does nothing.
+	assert.NoError(t, svc.CompleteCheckout(ctx, event))
+}
+
+func TestCheckoutService_RecordPaymentEvents(t *testing.T) {
+	svc, _, _, _ := newCheckoutTest(t, true)
+	ctx := t.Context()
+	usd := func(amount int64) domain.Money { return domain.Money{Amount: amount, Currency: domain.CurrencyUSD} }
+
+	assert.NoError(t, svc.RecordRefund(ctx, domain.PaymentEvent{
+		ID:       "evt_1",
+		Type:     domain.EventChargeRefunded,
+		Created:  time.Time{},
+		Checkout: nil,
+		Charge: &domain.Charge{
+			ID:            "ch_1",
+			PaymentID:     "pi_1",
+			Amount:        usd(4900),
+			Refunded:      usd(1000),
+			FullyRefunded: false,
+		},
+		Dispute: nil,
+	}))
+
+	assert.NoError(t, svc.RecordDispute(ctx, domain.PaymentEvent{
+		ID:       "evt_2",
+		Type:     domain.EventDisputeCreated,
+		Created:  time.Time{},
+		Checkout: nil,
+		Charge:   nil,
+		Dispute: &domain.Dispute{
+			ID:        "dp_1",
+			ChargeID:  "ch_1",
+			PaymentID: "pi_1",
+			Amount:    usd(4900),
+			Reason:    "fraudulent",
+			Status:    "needs_response",
+		},
+	}))
+
+	events := svc.audit.(*dal.MemoryAuditLog).Events()
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Type, domain.AuditPaymentRefunded)
+	assert.Equal(t, events[0].Subject, "payment pi_1")
+	assert.Equal(t, events[1].Type, domain.AuditPaymentDisputed)
+	assert.Equal(t, events[1].Subject, "payment pi_1")
+}
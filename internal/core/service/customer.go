@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// CustomerService signs customers up and authenticates them.
+type CustomerService struct {
+	customers ports.CustomerRepository
+	hasher    ports.PasswordHasher
+	breached  ports.BreachedPasswords
+	now       func() time.Time
+
+	// dummyHash is verified against when no customer matches an email, so
+	// that unknown emails take as long as known ones.
+	dummyOnce sync.Once
+	dummyHash []byte
+}
+
+func NewCustomerService(
+	customers ports.CustomerRepository,
+	hasher ports.PasswordHasher,
+	breached ports.BreachedPasswords,
+) *CustomerService {
+	return &CustomerService{
+		customers: customers,
+		hasher:    hasher,
+		breached:  breached,
+		now:       time.Now,
+		dummyOnce: sync.Once{},
+		dummyHash: nil,
+	}
+}
+
+// SignUp creates a customer. The email must be a valid address not used by
+// another customer, and the password must satisfy the password policy: it is
+// long enough and does not appear in the breached password list.
+func (s *CustomerService) SignUp(
+	ctx context.Context,
+	email domain.NewCustomerEmail,
+	password domain.NewCustomerPassword,
+) (domain.Customer, error) {
+	pc, err := domain.NewPossibleCustomer(email, password)
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	hash, err := hashNewPassword(ctx, s.hasher, s.breached, pc.Password)
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	c, err := s.customers.CreateCustomer(ctx, domain.Customer{
+		ID:                0,
+		Email:             pc.Email,
+		HashedPassword:    hash,
+		Created:           s.now().UTC(),
+		Verified:          false,
+		PaymentCustomerID: "",
+	})
+	if err != nil {
+		if errors.Is(err, ports.ErrConflict) {
+			return domain.Customer{}, ErrEmailTaken
+		}
+
+		return domain.Customer{}, fmt.Errorf("failed to create customer: %w", err)
+	}
+
+	return c, nil
+}
+
+// hashNewPassword enforces the password policy on password and hashes it.
+// The policy is the same wherever a customer chooses a password: it must be
+// long enough and must not appear in the breached password list.
+func hashNewPassword(
+	ctx context.Context,
+	hasher ports.PasswordHasher,
+	breached ports.BreachedPasswords,
+	password domain.NewCustomerPassword,
+) ([]byte, error) {
+	err := password.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := breached.Breached(ctx, string(password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check breached passwords: %w", err)
+	}
+
+	if found {
+		return nil, domain.ErrPasswordBreached
+	}
+
+	hash, err := hasher.Hash(string(password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return hash, nil
+}
+
+// Authenticate returns the customer with the given credentials, or
+// domain.ErrInvalidCredentials. When the stored hash was made with outdated
+// parameters, it is transparently replaced by a fresh one.
+func (s *CustomerService) Authenticate(
+	ctx context.Context,
+	email domain.RegisteredCustomerEmail,
+	password domain.RegisteredCustomerPassword,
+) (domain.Customer, error) {
+	err := password.Valid()
+	if err != nil {
+		return domain.Customer{}, domain.ErrInvalidCredentials
+	}
+
+	normalized, err := domain.NormalizeEmail(email.String())
+	if err != nil {
+		s.verifyDummy(string(password))
+		return domain.Customer{}, domain.ErrInvalidCredentials
+	}
+
+	c, err := s.customers.CustomerByEmail(ctx, normalized)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			s.verifyDummy(string(password))
+			return domain.Customer{}, domain.ErrInvalidCredentials
+		}
+
+		return domain.Customer{}, fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	match, rehash, err := s.hasher.Verify(string(password), c.HashedPassword)
+	if err != nil {
+		return domain.Customer{}, fmt.Errorf("failed to verify password: %w", err)
+	}
+
+	if !match {
+		return domain.Customer{}, domain.ErrInvalidCredentials
+	}
+
+	if rehash {
+		s.rehash(ctx, &c, string(password))
+	}
+
+	return c, nil
+}
+
+// verifyDummy spends the time of a password verification without any
+// customer to verify against, so that response times do not tell which
+// emails belong to customers.
+func (s *CustomerService) verifyDummy(password string) {
+	s.dummyOnce.Do(func() {
+		hash, err := s.hasher.Hash(rand.Text())
+		if err == nil {
+			s.dummyHash = hash
+		}
+	})
+
+	if s.dummyHash != nil {
+		_, _, _ = s.hasher.Verify(password, s.dummyHash)
+	}
+}
+
+// rehash replaces the hashed password of c with one made with the current
+// parameters. Failures are not fatal: the old hash still verifies, and the
+// rehash is attempted again on the next login.
+func (s *CustomerService) rehash(ctx context.Context, c *domain.Customer, password string) {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return
+	}
+
+	err = s.customers.UpdateCustomerPassword(ctx, c.ID, hash)
+	if err != nil {
+		return
+	}
+
+	c.HashedPassword = hash
+}
+
+type CustomerError string
+
+func (e CustomerError) Error() string {
+	return string(e)
+}
+
+const ErrEmailTaken CustomerError = "email is already used by another customer"
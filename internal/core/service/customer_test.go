@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/passwords"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/common/tests/test"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/service"
+)
+
+func cheapArgon2idParams() passwords.Argon2idParams {
+	return passwords.Argon2idParams{
+		Time:       1,
+		Memory:     64,
+		Threads:    1,
+		SaltLength: 16,
+		KeyLength:  32,
+	}
+}
+
+func newCustomerService(t *testing.T, customers *dal.MemoryCustomers, params passwords.Argon2idParams) *service.CustomerService {
+	t.Helper()
+
+	hasher, err := passwords.NewArgon2id(params)
+	assert.NoError(t, err)
+
+	breached, err := passwords.ReadBreachedList(strings.NewReader("password1234\n"))
+	assert.NoError(t, err)
+
+	return service.NewCustomerService(customers, hasher, breached)
+}
+
+func TestCustomerService_SignUp(t *testing.T) {
+	tests := []struct {
+		test.CaseBase
+		email    domain.NewCustomerEmail
+		password domain.NewCustomerPassword
+	}{
+		{
+			CaseBase: test.NewCaseBase("valid credentials", nil, false),
+			email:    "Jane@Example.com",
+			password: "correct horse battery",
+		},
+		{
+			CaseBase: test.NewCaseBase("invalid email", domain.ErrInvalidEmail, true),
+			email:    "jane",
+			password: "correct horse battery",
+		},
+		{
+			CaseBase: test.NewCaseBase("short password", domain.ErrPasswordTooShort, true),
+			email:    "jane@example.com",
+			password: "short",
+		},
+		{
+			CaseBase: test.NewCaseBase("breached password", domain.ErrPasswordBreached, true),
+			email:    "jane@example.com",
+			password: "password1234",
+		},
+		{
+			CaseBase: test.NewCaseBase("email taken", service.ErrEmailTaken, true),
+			email:    "taken@example.com",
+			password: "correct horse battery",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			s := newCustomerService(t, dal.NewMemoryCustomers(), cheapArgon2idParams())
+
+			_, err := s.SignUp(t.Context(), "TAKEN@example.com", "another horse battery")
+			assert.NoError(t, err)
+
+			c, err := s.SignUp(t.Context(), tt.email, tt.password)
+			assert.ErrorOrNoError(t, err, tt.WantErr)
+			if tt.WantErr {
+				assert.Error(t, err, tt.Want.(error))
+				return
+			}
+
+			assert.Equal(t, c.Email, "jane@example.com")
+			assert.True(t, c.ID > 0)
+			assert.False(t, strings.Contains(string(c.HashedPassword), string(tt.password)))
+		})
+	}
+}
+
+func TestCustomerService_Authenticate(t *testing.T) {
+	s := newCustomerService(t, dal.NewMemoryCustomers(), cheapArgon2idParams())
+
+	created, err := s.SignUp(t.Context(), "jane@example.com", "correct horse battery")
+	assert.NoError(t, err)
+
+	c, err := s.Authenticate(t.Context(), "JANE@example.com", "correct horse battery")
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, created.ID)
+
+	_, err = s.Authenticate(t.Context(), "jane@example.com", "wrong horse battery")
+	assert.Error(t, err, domain.ErrInvalidCredentials)
+
+	_, err = s.Authenticate(t.Context(), "john@example.com", "correct horse battery")
+	assert.Error(t, err, domain.ErrInvalidCredentials)
+}
+
+func TestCustomerService_AuthenticateRehashes(t *testing.T) {
+	customers := dal.NewMemoryCustomers()
+
+	old := newCustomerService(t, customers, cheapArgon2idParams())
+	created, err := old.SignUp(t.Context(), "jane@example.com", "correct horse battery")
+	assert.NoError(t, err)
+
+	stronger := cheapArgon2idParams()
+	stronger.Time = 2
+	s := newCustomerService(t, customers, stronger)
+
+	c, err := s.Authenticate(t.Context(), "jane@example.com", "correct horse battery")
+	assert.NoError(t, err)
+	assert.NotEqual(t, string(c.HashedPassword), string(created.HashedPassword))
+
+	stored, err := customers.CustomerByEmail(t.Context(), "jane@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, string(stored.HashedPassword), string(c.HashedPassword))
+	assert.True(t, strings.Contains(string(stored.HashedPassword), "t=2"))
+}
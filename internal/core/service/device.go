@@ -0,0 +1,674 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+const (
+	// userCodeAlphabet has no vowels, so that user codes spell no words, and
+	// no characters easily mistaken for one another, as advised by RFC 8628.
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+	// userCodeLength gives 20^8, about 2^34, user codes, which is plenty
+	// for codes that live minutes.
+	userCodeLength = 8
+
+	// slowDownStep is how much the polling interval grows each time a
+	// device polls too fast, as set by RFC 8628.
+	slowDownStep = 5 * time.Second
+
+	// maxDeviceNameLength bounds the device names plugins send.
+	maxDeviceNameLength = 64
+
+	// userCodeAttempts bounds the draws of a user code that is not taken.
+	userCodeAttempts = 3
+)
+
+// DeviceClient is a plugin allowed to sign customers in through the device
+// authorization grant. Plugins are public clients: they cannot keep a
+// secret, so they only identify themselves by their ID.
+type DeviceClient struct {
+	ID string
+
+	// Name is shown to customers approving the plugin, such as "Microwave".
+	Name string
+
+	// Scopes are the scopes the plugin may ask for. Plugins asking for none
+	// are granted them all.
+	Scopes []string
+}
+
+// DeviceConfig configures the OAuth 2.0 device authorization grant (RFC
+// 8628).
+type DeviceConfig struct {
+	Clients []DeviceClient
+
+	// VerificationURI is the page of the website where customers enter
+	// user codes.
+	VerificationURI string
+
+	// CodeTTL is how long a customer has to approve a user code.
+	CodeTTL time.Duration
+
+	// Interval is the shortest time between two polls of a device.
+	Interval time.Duration
+
+	// AccessTokenTTL is how long an access token can be used.
+	AccessTokenTTL time.Duration
+
+	// RefreshTokenTTL is how long a device stays signed in without
+	// refreshing its tokens.
+	RefreshTokenTTL time.Duration
+}
+
+func (c DeviceConfig) Validate() error {
+	if len(c.Clients) == 0 || c.CodeTTL <= 0 || c.Interval <= 0 || c.AccessTokenTTL <= 0 {
+		return ErrDeviceConfig
+	}
+
+	if c.RefreshTokenTTL < c.AccessTokenTTL {
+		return ErrDeviceConfig
+	}
+
+	u, err := url.Parse(c.VerificationURI)
+	if err != nil || !u.IsAbs() {
+		return ErrDeviceConfig
+	}
+
+	for i, client := range c.Clients {
+		if client.ID == "" || client.Name == "" || len(client.Scopes) == 0 {
+			return ErrDeviceConfig
+		}
+
+		if slices.ContainsFunc(c.Clients[:i], func(other DeviceClient) bool { return other.ID == client.ID }) {
+			return ErrDeviceConfig
+		}
+	}
+
+	return nil
+}
+
+func (c DeviceConfig) Value() any {
+	return c
+}
+
+// DeviceCode is a started device authorization, as answered to the
+// plugin.
+type DeviceCode struct {
+	// DeviceCode is what the plugin polls with. It is only ever known to
+	// the plugin.
+	DeviceCode string
+
+	// UserCode is what the plugin shows the customer, such as "BCDF-GHJK".
+	UserCode string
+
+	VerificationURI string
+
+	// VerificationURIComplete is VerificationURI with the user code, for
+	// plugins that show a QR code or open a browser.
+	VerificationURIComplete string
+
+	Expires  time.Time
+	Interval time.Duration
+}
+
+// DeviceRequest is a pending device authorization, as shown to the
+// customer asked to approve it.
+type DeviceRequest struct {
+	ClientName string
+	DeviceName string
+	Scopes     []string
+	Expires    time.Time
+}
+
+// DeviceTokens are the tokens issued to a signed in device.
+type DeviceTokens struct {
+	Device        domain.Device
+	AccessToken   string
+	RefreshToken  string
+	AccessExpires time.Time
+}
+
+// DeviceService signs plugins in through the OAuth 2.0 device authorization
+// grant (RFC 8628): the plugin asks for a user code, the customer approves
+// it on the website, and the plugin, which polls meanwhile, receives scoped
+// access and refresh tokens.
+type DeviceService struct {
+	customers ports.CustomerRepository
+	store     ports.DeviceStore
+	audit     ports.AuditLog
+	config    DeviceConfig
+	now       func() time.Time
+}
+
+func NewDeviceService(
+	customers ports.CustomerRepository,
+	store ports.DeviceStore,
+	audit ports.AuditLog,
+	config DeviceConfig,
+) (*DeviceService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeviceService{
+		customers: customers,
+		store:     store,
+		audit:     audit,
+		config:    config,
+		now:       time.Now,
+	}, nil
+}
+
+// Authorize starts a device authorization for a plugin asking for the
+// space separated scopes of scope. The device name tells the customer which
+// of their machines asks, and defaults to the name of the plugin.
+func (s *DeviceService) Authorize(ctx context.Context, clientID, scope, deviceName string) (DeviceCode, error) {
+	client, ok := s.client(clientID)
+	if !ok {
+		return DeviceCode{}, ErrUnknownClient
+	}
+
+	scopes, err := grantedScopes(client, scope)
+	if err != nil {
+		return DeviceCode{}, err
+	}
+
+	deviceName = strings.TrimSpace(deviceName)
+	if deviceName == "" {
+		deviceName = client.Name
+	}
+
+	if len(deviceName) > maxDeviceNameLength {
+		return DeviceCode{}, ErrDeviceNameTooLong
+	}
+
+	now := s.now().UTC()
+	deviceCode := rand.Text()
+
+	a := domain.DeviceAuthorization{
+		DeviceCodeHash: hashDeviceToken(deviceCode),
+		UserCode:       "",
+		ClientID:       client.ID,
+		DeviceName:     deviceName,
+		Scopes:         scopes,
+		Created:        now,
+		Expires:        now.Add(s.config.CodeTTL),
+		Interval:       s.config.Interval,
+		LastPolled:     time.Time{},
+		Status:         domain.DeviceAuthorizationPending,
+		CustomerID:     0,
+	}
+
+	// User codes are short enough to collide now and then.
+	for range userCodeAttempts {
+		a.UserCode = newUserCode()
+
+		err = s.store.CreateDeviceAuthorization(ctx, a)
+		if !errors.Is(err, ports.ErrConflict) {
+			break
+		}
+	}
+
+	if err != nil {
+		return DeviceCode{}, fmt.Errorf("failed to save device authorization: %w", err)
+	}
+
+	userCode := formatUserCode(a.UserCode)
+
+	return DeviceCode{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         s.config.VerificationURI,
+		VerificationURIComplete: userCodeLink(s.config.VerificationURI, userCode),
+		Expires:                 a.Expires,
+		Interval:                a.Interval,
+	}, nil
+}
+
+// Request returns the pending device authorization of a user code, for the
+// customer to review before approving it. User codes are accepted in any
+// case, with or without their dash.
+func (s *DeviceService) Request(ctx context.Context, userCode string) (DeviceRequest, error) {
+	a, err := s.pending(ctx, userCode)
+	if err != nil {
+		return DeviceRequest{}, err
+	}
+
+	client, _ := s.client(a.ClientID)
+
+	return DeviceRequest{
+		ClientName: client.Name,
+		DeviceName: a.DeviceName,
+		Scopes:     a.Scopes,
+		Expires:    a.Expires,
+	}, nil
+}
+
+// Approve signs the device waiting on a user code in to the account of a
+// customer. The device receives its tokens on its next poll.
+func (s *DeviceService) Approve(ctx context.Context, customerID int, userCode, ip string) error {
+	a, err := s.decide(ctx, customerID, userCode, domain.DeviceAuthorizationApproved)
+	if err != nil {
+		return err
+	}
+
+	client, _ := s.client(a.ClientID)
+
+	return s.record(ctx, domain.AuditDeviceAuthorized, customerID, ip, fmt.Sprintf(
+		"%s on %q with scopes %q", client.Name, a.DeviceName, strings.Join(a.Scopes, " "),
+	))
+}
+
+// Deny refuses the device waiting on a user code. The device is told so on
+// its next poll.
+func (s *DeviceService) Deny(ctx context.Context, customerID int, userCode string) error {
+	_, err := s.decide(ctx, customerID, userCode, domain.DeviceAuthorizationDenied)
+	return err
+}
+
+// Poll answers a device polling with its device code: with its tokens once
+// the customer approved it, and otherwise with ErrAuthorizationPending,
+// ErrSlowDown when it polls too fast, ErrAccessDenied, or
+// ErrDeviceCodeExpired. The tokens are only ever issued once.
+func (s *DeviceService) Poll(ctx context.Context, clientID, deviceCode string) (DeviceTokens, error) {
+	hash := hashDeviceToken(deviceCode)
+
+	a, err := s.store.DeviceAuthorizationByDeviceCode(ctx, hash)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return DeviceTokens{}, ErrInvalidGrant
+	case err != nil:
+		return DeviceTokens{}, fmt.Errorf("failed to find device authorization: %w", err)
+	case a.ClientID != clientID:
+		return DeviceTokens{}, ErrInvalidGrant
+	}
+
+	now := s.now().UTC()
+
+	if !now.Before(a.Expires) {
+		return DeviceTokens{}, ErrDeviceCodeExpired
+	}
+
+	interval := a.Interval
+	if !a.LastPolled.IsZero() && now.Before(a.LastPolled.Add(a.Interval)) {
+		interval += slowDownStep
+	}
+
+	err = s.store.PollDeviceAuthorization(ctx, hash, now, interval)
+	if err != nil {
+		return DeviceTokens{}, fmt.Errorf("failed to record device poll: %w", err)
+	}
+
+	if interval != a.Interval {
+		return DeviceTokens{}, ErrSlowDown
+	}
+
+	switch a.Status {
+	case domain.DeviceAuthorizationPending:
+		return DeviceTokens{}, ErrAuthorizationPending
+	case domain.DeviceAuthorizationDenied:
+		err = s.store.DeleteDeviceAuthorization(ctx, hash)
+		if err != nil && !errors.Is(err, ports.ErrNotFound) {
+			return DeviceTokens{}, fmt.Errorf("failed to delete device authorization: %w", err)
+		}
+
+		return DeviceTokens{}, ErrAccessDenied
+	case domain.DeviceAuthorizationApproved:
+	}
+
+	// Of two concurrent polls, only the one deleting the authorization gets
+	// the tokens.
+	err = s.store.DeleteDeviceAuthorization(ctx, hash)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return DeviceTokens{}, ErrInvalidGrant
+	case err != nil:
+		return DeviceTokens{}, fmt.Errorf("failed to delete device authorization: %w", err)
+	}
+
+	id, err := randomString(sessionIDBytes)
+	if err != nil {
+		return DeviceTokens{}, fmt.Errorf("failed to generate device id: %w", err)
+	}
+
+	tokens := s.newTokens(domain.Device{
+		ID:               id,
+		CustomerID:       a.CustomerID,
+		ClientID:         a.ClientID,
+		Name:             a.DeviceName,
+		Scopes:           a.Scopes,
+		RefreshTokenHash: nil,
+		AccessTokenHash:  nil,
+		AccessExpires:    time.Time{},
+		Created:          now,
+		LastSeen:         now,
+		Expires:          time.Time{},
+	})
+
+	err = s.store.CreateDevice(ctx, tokens.Device)
+	if err != nil {
+		return DeviceTokens{}, fmt.Errorf("failed to save device: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Refresh issues new tokens to a device from its refresh token, which is
+// then no longer valid.
+func (s *DeviceService) Refresh(ctx context.Context, clientID, refreshToken string) (DeviceTokens, error) {
+	oldHash := hashDeviceToken(refreshToken)
+
+	d, err := s.store.DeviceByRefreshToken(ctx, oldHash)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return DeviceTokens{}, ErrInvalidGrant
+	case err != nil:
+		return DeviceTokens{}, fmt.Errorf("failed to find device: %w", err)
+	case d.ClientID != clientID:
+		return DeviceTokens{}, ErrInvalidGrant
+	}
+
+	now := s.now().UTC()
+
+	if !now.Before(d.Expires) {
+		return DeviceTokens{}, ErrInvalidGrant
+	}
+
+	d.LastSeen = now
+	tokens := s.newTokens(d)
+
+	err = s.store.RotateDeviceTokens(ctx, oldHash, tokens.Device)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return DeviceTokens{}, ErrInvalidGrant
+	case err != nil:
+		return DeviceTokens{}, fmt.Errorf("failed to rotate device tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Authenticate returns the device an access token was issued to.
+func (s *DeviceService) Authenticate(ctx context.Context, accessToken string) (domain.Device, error) {
+	d, err := s.store.DeviceByAccessToken(ctx, hashDeviceToken(accessToken))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return domain.Device{}, ErrAccessTokenInvalid
+		}
+
+		return domain.Device{}, fmt.Errorf("failed to find device: %w", err)
+	}
+
+	if !s.now().Before(d.AccessExpires) {
+		return domain.Device{}, ErrAccessTokenExpired
+	}
+
+	return d, nil
+}
+
+// List returns the signed in devices of a customer, most recently used
+// first.
+func (s *DeviceService) List(ctx context.Context, customerID int) ([]domain.Device, error) {
+	return s.store.CustomerDevices(ctx, customerID, s.now().UTC())
+}
+
+// Revoke signs out the device with the given public ID, which must belong
+// to the customer. Its tokens stop working at once.
+func (s *DeviceService) Revoke(ctx context.Context, customerID int, id, ip string) error {
+	devices, err := s.List(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	i := slices.IndexFunc(devices, func(d domain.Device) bool { return d.ID == id })
+	if i < 0 {
+		return ErrDeviceNotFound
+	}
+
+	err = s.store.DeleteDevice(ctx, id)
+	if err != nil && !errors.Is(err, ports.ErrNotFound) {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+
+	return s.record(ctx, domain.AuditDeviceRevoked, customerID, ip, fmt.Sprintf("%q signed out", devices[i].Name))
+}
+
+// RevokeAll signs out every device of a customer, along with the devices
+// they approved that have not fetched their tokens yet, and returns how many
+// devices were signed out. Their tokens stop working at once.
+func (s *DeviceService) RevokeAll(ctx context.Context, customerID int, ip string) (int, error) {
+	n, err := s.store.DeleteCustomerDevices(ctx, customerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete devices: %w", err)
+	}
+
+	if n == 0 {
+		return 0, nil
+	}
+
+	return n, s.record(ctx, domain.AuditDeviceRevoked, customerID, ip, fmt.Sprintf("%d devices signed out", n))
+}
+
+// Sweep deletes expired device authorizations and devices, and returns how
+// many were deleted.
+func (s *DeviceService) Sweep(ctx context.Context) (int, error) {
+	now := s.now().UTC()
+
+	authorizations, err := s.store.DeleteExpiredDeviceAuthorizations(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	devices, err := s.store.DeleteExpiredDevices(ctx, now)
+
+	return authorizations + devices, err
+}
+
+// pending returns the authorization of a user code that still waits for a
+// decision.
+func (s *DeviceService) pending(ctx context.Context, userCode string) (domain.DeviceAuthorization, error) {
+	a, err := s.store.DeviceAuthorizationByUserCode(ctx, normalizeUserCode(userCode))
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return domain.DeviceAuthorization{}, ErrUserCodeInvalid
+	case err != nil:
+		return domain.DeviceAuthorization{}, fmt.Errorf("failed to find device authorization: %w", err)
+	case a.Status != domain.DeviceAuthorizationPending, !s.now().Before(a.Expires):
+		return domain.DeviceAuthorization{}, ErrUserCodeInvalid
+	}
+
+	return a, nil
+}
+
+func (s *DeviceService) decide(
+	ctx context.Context,
+	customerID int,
+	userCode string,
+	status domain.DeviceAuthorizationStatus,
+) (domain.DeviceAuthorization, error) {
+	a, err := s.pending(ctx, userCode)
+	if err != nil {
+		return domain.DeviceAuthorization{}, err
+	}
+
+	err = s.store.DecideDeviceAuthorization(ctx, a.UserCode, customerID, status)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return domain.DeviceAuthorization{}, ErrUserCodeInvalid
+	case err != nil:
+		return domain.DeviceAuthorization{}, fmt.Errorf("failed to decide device authorization: %w", err)
+	}
+
+	return a, nil
+}
+
+// newTokens draws new tokens for d, and returns them with d updated to
+// match.
+func (s *DeviceService) newTokens(d domain.Device) DeviceTokens {
+	accessToken := rand.Text()
+	refreshToken := rand.Text()
+
+	d.AccessTokenHash = hashDeviceToken(accessToken)
+	d.RefreshTokenHash = hashDeviceToken(refreshToken)
+	d.AccessExpires = d.LastSeen.Add(s.config.AccessTokenTTL)
+	d.Expires = d.LastSeen.Add(s.config.RefreshTokenTTL)
+
+	return DeviceTokens{
+		Device:        d,
+		AccessToken:   accessToken,
+		RefreshToken:  refreshToken,
+		AccessExpires: d.AccessExpires,
+	}
+}
+
+func (s *DeviceService) client(id string) (DeviceClient, bool) {
+	i := slices.IndexFunc(s.config.Clients, func(c DeviceClient) bool { return c.ID == id })
+	if i < 0 {
+		return DeviceClient{}, false
+	}
+
+	return s.config.Clients[i], true
+}
+
+func (s *DeviceService) record(
+	ctx context.Context,
+	t domain.AuditEventType,
+	customerID int,
+	ip, detail string,
+) error {
+	c, err := s.customers.CustomerByID(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	err = s.audit.RecordAudit(ctx, domain.AuditEvent{
+		Type:    t,
+		Subject: c.Email,
+		IP:      ip,
+		Time:    s.now().UTC(),
+		Detail:  detail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// grantedScopes returns the scopes of the space separated scope that the
+// client may ask for, or all of its scopes when scope is empty.
+func grantedScopes(client DeviceClient, scope string) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return slices.Clone(client.Scopes), nil
+	}
+
+	for _, s := range requested {
+		if !slices.Contains(client.Scopes, s) {
+			return nil, ErrInvalidScope
+		}
+	}
+
+	slices.Sort(requested)
+
+	return slices.Compact(requested), nil
+}
+
+func newUserCode() string {
+	b := make([]byte, userCodeLength)
+	for i := range b {
+		b[i] = userCodeAlphabet[randomIndex(len(userCodeAlphabet))]
+	}
+
+	return string(b)
+}
+
+// randomIndex returns a uniformly random index below n, which must be at
+// most 256.
+func randomIndex(n int) int {
+	// Bytes past the largest multiple of n are drawn again, so that no index
+	// is likelier than another.
+	limit := byte(256 - 256%n) //nolint:gosec // n is at most 256
+
+	var b [1]byte
+	for {
+		_, _ = rand.Read(b[:])
+		if limit == 0 || b[0] < limit {
+			return int(b[0]) % n
+		}
+	}
+}
+
+// formatUserCode splits a user code in two halves with a dash, which is
+// easier to read out and type.
+func formatUserCode(code string) string {
+	return code[:len(code)/2] + "-" + code[len(code)/2:]
+}
+
+// normalizeUserCode undoes formatUserCode and whatever case and spacing the
+// customer typed.
+func normalizeUserCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, strings.ToUpper(code))
+}
+
+// userCodeLink returns the verification page carrying the user code as its
+// "user_code" query parameter. The page must be a valid URL.
+func userCodeLink(page, userCode string) string {
+	u, _ := url.Parse(page)
+
+	q := u.Query()
+	q.Set("user_code", userCode)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func hashDeviceToken(token string) []byte {
+	h := sha256.Sum256([]byte(token))
+	return h[:]
+}
+
+type DeviceError string
+
+func (e DeviceError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrDeviceConfig         DeviceError = "device clients must be unique with scopes, durations positive and verification uri absolute"
+	ErrUnknownClient        DeviceError = "client is unknown"
+	ErrInvalidScope         DeviceError = "scope is not allowed for this client"
+	ErrDeviceNameTooLong    DeviceError = "device name is too long"
+	ErrUserCodeInvalid      DeviceError = "user code is invalid or expired"
+	ErrAuthorizationPending DeviceError = "authorization is pending"
+	ErrSlowDown             DeviceError = "polling too fast"
+	ErrAccessDenied         DeviceError = "authorization was denied"
+	ErrDeviceCodeExpired    DeviceError = "device code is expired"
+	ErrInvalidGrant         DeviceError = "grant is invalid"
+	ErrAccessTokenInvalid   DeviceError = "access token is invalid"
+	ErrAccessTokenExpired   DeviceError = "access token is expired"
+	ErrDeviceNotFound       DeviceError = "device not found"
+)
@@ -0,0 +1,308 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+type deviceTest struct {
+	*DeviceService
+	audit    *dal.MemoryAuditLog
+	customer domain.Customer
+	clock    time.Time
+}
+
+func (d *deviceTest) advance(dur time.Duration) {
+	d.clock = d.clock.Add(dur)
+}
+
+// signIn runs a whole device authorization and returns the tokens issued.
+func (d *deviceTest) signIn(t *testing.T) DeviceTokens {
+	t.Helper()
+
+	code, err := d.Authorize(t.Context(), "microwave", "", "")
+	assert.NoError(t, err)
+
+	err = d.Approve(t.Context(), d.customer.ID, code.UserCode, "10.0.0.1")
+	assert.NoError(t, err)
+
+	tokens, err := d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.NoError(t, err)
+
+	return tokens
+}
+
+func newDeviceTest(t *testing.T) *deviceTest {
+	t.Helper()
+
+	customers := dal.NewMemoryCustomers()
+
+	c, err := customers.CreateCustomer(t.Context(), domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          true,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	audit := dal.NewMemoryAuditLog()
+
+	svc, err := NewDeviceService(customers, dal.NewMemoryDevices(), audit, DeviceConfig{
+		Clients: []DeviceClient{
+			{ID: "microwave", Name: "Microwave", Scopes: []string{"licenses", "profile"}},
+		},
+		VerificationURI: "https://brokedaear.com/device",
+		CodeTTL:         10 * time.Minute,
+		Interval:        5 * time.Second,
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	dt := &deviceTest{
+		DeviceService: svc,
+		audit:         audit,
+		customer:      c,
+		clock:         time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	svc.now = func() time.Time { return dt.clock }
+
+	return dt
+}
+
+func TestDeviceService_Authorize(t *testing.T) {
+	d := newDeviceTest(t)
+
+	code, err := d.Authorize(t.Context(), "microwave", "profile", "Studio Mac")
+	assert.NoError(t, err)
+	assert.Equal(t, len(code.UserCode), userCodeLength+1)
+	assert.Equal(t, code.VerificationURI, "https://brokedaear.com/device")
+	assert.Equal(t, code.VerificationURIComplete, "https://brokedaear.com/device?user_code="+code.UserCode)
+	assert.Equal(t, code.Interval, 5*time.Second)
+
+	req, err := d.Request(t.Context(), strings.ToLower(strings.ReplaceAll(code.UserCode, "-", "")))
+	assert.NoError(t, err)
+	assert.Equal(t, req.ClientName, "Microwave")
+	assert.Equal(t, req.DeviceName, "Studio Mac")
+	assert.Equal(t, strings.Join(req.Scopes, " "), "profile")
+
+	_, err = d.Authorize(t.Context(), "unknown", "", "")
+	assert.Error(t, err, ErrUnknownClient)
+
+	_, err = d.Authorize(t.Context(), "microwave", "admin", "")
+	assert.Error(t, err, ErrInvalidScope)
+
+	_, err = d.Authorize(t.Context(), "microwave", "", strings.Repeat("x", maxDeviceNameLength+1))
+	assert.Error(t, err, ErrDeviceNameTooLong)
+}
+
+func TestDeviceService_Poll(t *testing.T) {
+	d := newDeviceTest(t)
+
+	code, err := d.Authorize(t.Context(), "microwave", "", "")
+	assert.NoError(t, err)
+
+	_, err = d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.Error(t, err, ErrAuthorizationPending)
+
+	// Polling faster than the interval slows the device down for good.
+	d.advance(time.Second)
+	_, err = d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.Error(t, err, ErrSlowDown)
+
+	d.advance(5 * time.Second)
+	_, err = d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.Error(t, err, ErrSlowDown)
+
+	d.advance(15 * time.Second)
+	_, err = d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.Error(t, err, ErrAuthorizationPending)
+
+	_, err = d.Poll(t.Context(), "other", code.DeviceCode)
+	assert.Error(t, err, ErrInvalidGrant)
+
+	err = d.Approve(t.Context(), d.customer.ID, code.UserCode, "10.0.0.1")
+	assert.NoError(t, err)
+
+	// The code cannot be decided twice.
+	err = d.Deny(t.Context(), d.customer.ID, code.UserCode)
+	assert.Error(t, err, ErrUserCodeInvalid)
+
+	d.advance(15 * time.Second)
+	tokens, err := d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.NoError(t, err)
+	assert.Equal(t, tokens.Device.CustomerID, d.customer.ID)
+	assert.Equal(t, tokens.Device.Name, "Microwave")
+	assert.Equal(t, strings.Join(tokens.Device.Scopes, " "), "licenses profile")
+	assert.True(t, tokens.AccessExpires.Equal(d.clock.Add(time.Hour)))
+
+	// The tokens are only issued once.
+	d.advance(15 * time.Second)
+	_, err = d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.Error(t, err, ErrInvalidGrant)
+
+	events := d.audit.Events()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, domain.AuditDeviceAuthorized)
+	assert.Equal(t, events[0].Subject, "jane@example.com")
+}
+
+func TestDeviceService_Denied(t *testing.T) {
+	d := newDeviceTest(t)
+
+	code, err := d.Authorize(t.Context(), "microwave", "", "")
+	assert.NoError(t, err)
+
+	err = d.Deny(t.Context(), d.customer.ID, code.UserCode)
+	assert.NoError(t, err)
+
+	_, err = d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.Error(t, err, ErrAccessDenied)
+
+	_, err = d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.Error(t, err, ErrInvalidGrant)
+}
+
+func TestDeviceService_Expired(t *testing.T) {
+	d := newDeviceTest(t)
+
+	code, err := d.Authorize(t.Context(), "microwave", "", "")
+	assert.NoError(t, err)
+
+	d.advance(10 * time.Minute)
+
+	err = d.Approve(t.Context(), d.customer.ID, code.UserCode, "10.0.0.1")
+	assert.Error(t, err, ErrUserCodeInvalid)
+
+	_, err = d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.Error(t, err, ErrDeviceCodeExpired)
+
+	n, err := d.Sweep(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+}
+
+func TestDeviceService_Tokens(t *testing.T) {
+	d := newDeviceTest(t)
+	tokens := d.signIn(t)
+
+	dev, err := d.Authenticate(t.Context(), tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, dev.ID, tokens.Device.ID)
+
+	_, err = d.Authenticate(t.Context(), tokens.RefreshToken)
+	assert.Error(t, err, ErrAccessTokenInvalid)
+
+	d.advance(time.Hour)
+
+	_, err = d.Authenticate(t.Context(), tokens.AccessToken)
+	assert.Error(t, err, ErrAccessTokenExpired)
+
+	_, err = d.Refresh(t.Context(), "other", tokens.RefreshToken)
+	assert.Error(t, err, ErrInvalidGrant)
+
+	refreshed, err := d.Refresh(t.Context(), "microwave", tokens.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, refreshed.Device.ID, tokens.Device.ID)
+
+	_, err = d.Authenticate(t.Context(), refreshed.AccessToken)
+	assert.NoError(t, err)
+
+	// Refresh tokens rotate.
+	_, err = d.Refresh(t.Context(), "microwave", tokens.RefreshToken)
+	assert.Error(t, err, ErrInvalidGrant)
+
+	// A device left unused signs out.
+	d.advance(30 * 24 * time.Hour)
+
+	_, err = d.Refresh(t.Context(), "microwave", refreshed.RefreshToken)
+	assert.Error(t, err, ErrInvalidGrant)
+}
+
+func TestDeviceService_Revoke(t *testing.T) {
+	d := newDeviceTest(t)
+	tokens := d.signIn(t)
+
+	devices, err := d.List(t.Context(), d.customer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(devices), 1)
+
+	err = d.Revoke(t.Context(), d.customer.ID+1, tokens.Device.ID, "10.0.0.1")
+	assert.Error(t, err, ErrDeviceNotFound)
+
+	err = d.Revoke(t.Context(), d.customer.ID, tokens.Device.ID, "10.0.0.1")
+	assert.NoError(t, err)
+
+	_, err = d.Authenticate(t.Context(), tokens.AccessToken)
+	assert.Error(t, err, ErrAccessTokenInvalid)
+
+	_, err = d.Refresh(t.Context(), "microwave", tokens.RefreshToken)
+	assert.Error(t, err, ErrInvalidGrant)
+
+	events := d.audit.Events()
+	assert.Equal(t, events[len(events)-1].Type, domain.AuditDeviceRevoked)
+}
+
+func TestDeviceService_RevokeAll(t *testing.T) {
+	d := newDeviceTest(t)
+	tokens := d.signIn(t)
+
+	// A device approved before the revocation gets no tokens after it.
+	code, err := d.Authorize(t.Context(), "microwave", "", "")
+	assert.NoError(t, err)
+
+	err = d.Approve(t.Context(), d.customer.ID, code.UserCode, "10.0.0.1")
+	assert.NoError(t, err)
+
+	n, err := d.RevokeAll(t.Context(), d.customer.ID, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+
+	_, err = d.Refresh(t.Context(), "microwave", tokens.RefreshToken)
+	assert.Error(t, err, ErrInvalidGrant)
+
+	_, err = d.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.Error(t, err, ErrInvalidGrant)
+
+	events := d.audit.Events()
+	assert.Equal(t, events[len(events)-1].Type, domain.AuditDeviceRevoked)
+
+	n, err = d.RevokeAll(t.Context(), d.customer.ID, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, n, 0)
+}
+
+func TestDeviceConfig_Validate(t *testing.T) {
+	valid := DeviceConfig{
+		Clients:         []DeviceClient{{ID: "microwave", Name: "Microwave", Scopes: []string{"licenses"}}},
+		VerificationURI: "https://brokedaear.com/device",
+		CodeTTL:         time.Minute,
+		Interval:        time.Second,
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour,
+	}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.Clients = []DeviceClient{valid.Clients[0], valid.Clients[0]}
+	assert.Error(t, invalid.Validate(), ErrDeviceConfig)
+
+	invalid = valid
+	invalid.RefreshTokenTTL = time.Minute
+	assert.Error(t, invalid.Validate(), ErrDeviceConfig)
+
+	invalid = valid
+	invalid.VerificationURI = "/device"
+	assert.Error(t, invalid.Validate(), ErrDeviceConfig)
+}
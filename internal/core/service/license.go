@@ -0,0 +1,603 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// LicenseConfig configures the licenses of plugins.
+type LicenseConfig struct {
+	// Issuer identifies this app in the iss claim of license tokens, such
+	// as "https://brokedaear.com".
+	Issuer string
+
+	// Validity is how long licenses are valid once issued, or zero for
+	// licenses that do not expire.
+	Validity time.Duration
+}
+
+func (c LicenseConfig) Validate() error {
+	if c.Issuer == "" || c.Validity < 0 {
+		return ErrLicenseConfig
+	}
+
+	return nil
+}
+
+func (c LicenseConfig) Value() any {
+	return c
+}
+
+// Expires returns when a license issued at issued expires, or zero when
+// licenses do not expire.
+func (c LicenseConfig) Expires(issued time.Time) time.Time {
+	if c.Validity <= 0 {
+		return time.Time{}
+	}
+
+	return issued.Add(c.Validity)
+}
+
+// LicenseClaims are what a license token tells a plugin, which verifies it
+// offline with the published keys.
+type LicenseClaims struct {
+	Key        string
+	OrderID    int
+	CustomerID int
+	ProductID  int
+	Edition    domain.LicenseEdition
+	Issued     time.Time
+
+	// Expires is zero for licenses that do not expire.
+	Expires time.Time
+}
+
+// Uses of the tokens signed with license keys, which share their keys and
+// most of their claims.
+const (
+	tokenUseLicense    = "license"
+	tokenUseActivation = "activation"
+)
+
+// licenseClaims is the payload of license tokens. Use tells them from
+// activation tokens. Times are seconds since the Unix epoch.
+type licenseClaims struct {
+	Issuer   string                `json:"iss"`
+	Subject  string                `json:"sub"`
+	Use      string                `json:"use"`
+	Key      string                `json:"lic"`
+	Order    int                   `json:"ord"`
+	Product  int                   `json:"prd"`
+	Edition  domain.LicenseEdition `json:"edition"`
+	IssuedAt int64                 `json:"iat"`
+	Expires  int64                 `json:"exp,omitempty"`
+}
+
+// SignedLicense is a license with its token. The token is empty for
+// licenses that are no longer valid.
+type SignedLicense struct {
+	domain.License
+
+	Token string
+}
+
+// LicenseService issues the licenses of the plugins customers buy, one per
+// unit of each plugin line of paid orders, and revokes them. Licenses are
+// handed to plugins as tokens signed with Ed25519 keys, which plugins verify
+// offline with the published keys; the key ID in each token tells which key
+// signed it, so that keys can be rotated without invalidating tokens signed
+// before.
+//
+// Activation tokens are signed by the same key ring, which the activation
+// service is given too. Their use claim is
+// "activation", and that of license tokens "license": plugins must require
+// an activation token to run, since only those are bound to the machine,
+// and reject license tokens in their place.
+type LicenseService struct {
+	licenses ports.LicenseStore
+	orders   *OrderService
+	audit    ports.AuditLog
+	ring     *jwt.KeyRing
+	config   LicenseConfig
+	now      func() time.Time
+}
+
+// NewLicenseKeyRing returns the key ring of license and activation tokens,
+// signing with keys[0] and verifying with every key. Without keys, a random
+// key signs, and tokens do not verify once the process exits.
+func NewLicenseKeyRing(keys ...jwt.Key) (*jwt.KeyRing, error) {
+	// Keys rotate by being replaced, and licenses do not expire, so
+	// retired keys verify forever.
+	return jwt.NewKeyRing(jwt.RingConfig{Rotation: 0, Retention: 0}, time.Now(), keys...)
+}
+
+// NewLicenseService returns a license service signing with ring, as
+// returned by NewLicenseKeyRing.
+func NewLicenseService(
+	licenses ports.LicenseStore,
+	orders *OrderService,
+	audit ports.AuditLog,
+	ring *jwt.KeyRing,
+	config LicenseConfig,
+) (*LicenseService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LicenseService{
+		licenses: licenses,
+		orders:   orders,
+		audit:    audit,
+		ring:     ring,
+		config:   config,
+		now:      time.Now,
+	}, nil
+}
+
+// IssueLicenses handles the events of completed checkouts by issuing the
+// licenses of the plugins of their order, once it is paid. Orders of
+// plugins alone are fulfilled by their licenses. Upgrades from a license
+// that another order spent first are revoked and refunded.
+func (s *LicenseService) IssueLicenses(ctx context.Context, e domain.PaymentEvent) error {
+	if e.Checkout == nil || e.Checkout.PaymentStatus == domain.PaymentUnpaid {
+		return nil
+	}
+
+	o, err := s.orders.OrderByCheckout(ctx, e.Checkout.ID)
+	if err != nil {
+		return err
+	}
+
+	if o.Status != domain.OrderPaid && o.Status != domain.OrderFulfilled {
+		return nil
+	}
+
+	digital, err := s.issue(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	if digital {
+		o, err = s.orders.Deliver(ctx, o.ID, "licenses issued")
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.refundDoubleSpends(ctx, o)
+}
+
+// issue issues the licenses of the plugins of o that have none, one for
+// every unit of each line, and reports whether o bought plugins alone.
+func (s *LicenseService) issue(ctx context.Context, o domain.Order) (bool, error) {
+	existing, err := s.licenses.OrderLicenses(ctx, o.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list order licenses: %w", err)
+	}
+
+	type seat struct{ line, seat int }
+
+	issued := make(map[seat]bool, len(existing))
+	for _, l := range existing {
+		issued[seat{l.Line, l.Seat}] = true
+	}
+
+	digital := true
+	now := s.now().UTC()
+
+	for i, line := range o.Lines {
+		if line.ProductType != domain.ProductPlugin {
+			digital = false
+			continue
+		}
+
+		for n := range line.Quantity {
+			if issued[seat{i, n}] {
+				continue
+			}
+
+			l := domain.License{
+				ID:           0,
+				Key:          domain.NewLicenseKey(),
+				OrderID:      o.ID,
+				Line:         i,
+				Seat:         n,
+				CustomerID:   o.CustomerID,
+				ProductID:    line.ProductID,
+				Version:      line.Version,
+				Edition:      domain.EditionStandard,
+				Issued:       now,
+				Expires:      s.config.Expires(now),
+				Revoked:      time.Time{},
+				RevokeReason: "",
+				UpgradedFrom: line.UpgradeFrom,
+			}
+
+			// A conflict is the license being issued meanwhile, by the same
+			// event processed twice.
+			_, err = s.licenses.CreateLicense(ctx, l)
+			if err != nil && !errors.Is(err, ports.ErrConflict) {
+				return false, fmt.Errorf("failed to create license: %w", err)
+			}
+		}
+	}
+
+	return digital, nil
+}
+
+// refundDoubleSpends revokes the licenses of the upgrade lines of o whose
+// license backs another upgrade issued first, such as by two checkouts
+// paid at once, records it in the audit trail, and refunds the lines. It
+// may run again for the same order: lines are revoked and refunded once,
+// as told by the history of the order, whatever else was refunded of it.
+func (s *LicenseService) refundDoubleSpends(ctx context.Context, o domain.Order) error {
+	issued, err := s.licenses.OrderLicenses(ctx, o.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list order licenses: %w", err)
+	}
+
+	licenses, err := s.licenses.CustomerLicenses(ctx, o.CustomerID)
+	if err != nil {
+		return fmt.Errorf("failed to list licenses: %w", err)
+	}
+
+	history, err := s.orders.History(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+
+	for i, line := range o.Lines {
+		if line.UpgradeFrom == 0 {
+			continue
+		}
+
+		ours := slices.DeleteFunc(slices.Clone(issued), func(l domain.License) bool { return l.Line != i })
+		if len(ours) == 0 || !spentBefore(licenses, ours[0]) {
+			continue
+		}
+
+		reason := fmt.Sprintf("license %d backs another upgrade", line.UpgradeFrom)
+
+		for _, l := range ours {
+			if !l.Revoked.IsZero() {
+				continue
+			}
+
+			err = s.audit.RecordAudit(ctx, domain.AuditEvent{
+				Type:    domain.AuditUpgradeDoubleSpent,
+				Subject: "order " + strconv.Itoa(o.ID),
+				IP:      "",
+				Time:    now,
+				Detail:  fmt.Sprintf("license %s revoked: %s", l.Key, reason),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to record audit event: %w", err)
+			}
+
+			err = s.licenses.RevokeLicense(ctx, l.ID, now, reason)
+			if err != nil {
+				return fmt.Errorf("failed to revoke license: %w", err)
+			}
+		}
+
+		err = s.refundLine(ctx, o.ID, i, line, history)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// refundLine refunds the line i of the order with id, unless history shows
+// it was refunded already. Lines are refunded at most what is left of the
+// order.
+func (s *LicenseService) refundLine(
+	ctx context.Context,
+	id, i int,
+	line domain.OrderLine,
+	history []domain.OrderTransition,
+) error {
+	reason := fmt.Sprintf("upgrade license of line %d spent by another order", i)
+
+	if slices.ContainsFunc(history, func(t domain.OrderTransition) bool {
+		return t.Actor == actorDelivery && t.Reason == reason
+	}) {
+		return nil
+	}
+
+	o, err := s.orders.Order(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	due, err := line.UnitPrice.Mul(int64(line.Quantity))
+	if err != nil {
+		return err
+	}
+
+	left, err := o.Total.Sub(o.Refunded)
+	if err != nil {
+		return err
+	}
+
+	due.Amount = min(due.Amount, left.Amount)
+	if due.Amount <= 0 {
+		return nil
+	}
+
+	_, err = s.orders.RefundUndelivered(ctx, id, due, reason)
+
+	return err
+}
+
+// spentBefore reports whether the license l was upgraded from backs a
+// license among licenses that is still valid, and was issued before l for
+// another order.
+func spentBefore(licenses []domain.License, l domain.License) bool {
+	return slices.ContainsFunc(licenses, func(other domain.License) bool {
+		return other.UpgradedFrom == l.UpgradedFrom && other.OrderID != l.OrderID &&
+			other.ID < l.ID && other.Revoked.IsZero()
+	})
+}
+
+// RevokeRefunded handles the events of refunded charges and lost disputes
+// by revoking the licenses of their order, once it was refunded in full.
+// Orders refunded in part keep their licenses.
+func (s *LicenseService) RevokeRefunded(ctx context.Context, e domain.PaymentEvent) error {
+	var paymentID string
+
+	switch {
+	case e.Charge != nil:
+		paymentID = e.Charge.PaymentID
+	case e.Dispute != nil:
+		paymentID = e.Dispute.PaymentID
+	default:
+		return nil
+	}
+
+	o, err := s.orders.OrderByPayment(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+
+	if o.Status != domain.OrderRefunded && o.Status != domain.OrderCanceled {
+		return nil
+	}
+
+	licenses, err := s.licenses.OrderLicenses(ctx, o.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list order licenses: %w", err)
+	}
+
+	for _, l := range licenses {
+		err = s.licenses.RevokeLicense(ctx, l.ID, s.now().UTC(), "order "+string(o.Status))
+		if err != nil {
+			return fmt.Errorf("failed to revoke license: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Lookup returns the license with key, as typed by a customer, with its
+// token when it is valid.
+func (s *LicenseService) Lookup(ctx context.Context, key string) (SignedLicense, error) {
+	l, err := s.License(ctx, key)
+	if err != nil {
+		return SignedLicense{}, err
+	}
+
+	return s.sign(l)
+}
+
+// License returns the license with key, as typed by a customer. Mistyped
+// keys fail with domain.ErrInvalidLicenseKey.
+func (s *LicenseService) License(ctx context.Context, key string) (domain.License, error) {
+	key, err := domain.ParseLicenseKey(key)
+	if err != nil {
+		return domain.License{}, err
+	}
+
+	l, err := s.licenses.LicenseByKey(ctx, key)
+	if errors.Is(err, ports.ErrNotFound) {
+		return domain.License{}, ErrLicenseNotFound
+	}
+
+	if err != nil {
+		return domain.License{}, fmt.Errorf("failed to find license: %w", err)
+	}
+
+	return l, nil
+}
+
+// maxLineage is the most licenses Lineage follows a license back through.
+const maxLineage = 100
+
+// Lineage returns the license with key, as typed by a customer, followed
+// by the licenses it was upgraded from, newest first.
+func (s *LicenseService) Lineage(ctx context.Context, key string) ([]domain.License, error) {
+	l, err := s.License(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := []domain.License{l}
+
+	// Licenses are upgraded from older ones, so the lineage cannot loop,
+	// but a corrupt one must not hang the request.
+	for l.UpgradedFrom != 0 && len(lineage) <= maxLineage {
+		l, err = s.licenses.License(ctx, l.UpgradedFrom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find license: %w", err)
+		}
+
+		lineage = append(lineage, l)
+	}
+
+	return lineage, nil
+}
+
+// CustomerLicenses returns the licenses of a customer, oldest first, with
+// the tokens of those that are valid.
+func (s *LicenseService) CustomerLicenses(ctx context.Context, customerID int) ([]SignedLicense, error) {
+	licenses, err := s.licenses.CustomerLicenses(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list licenses: %w", err)
+	}
+
+	signed := make([]SignedLicense, 0, len(licenses))
+
+	for _, l := range licenses {
+		sl, err := s.sign(l)
+		if err != nil {
+			return nil, err
+		}
+
+		signed = append(signed, sl)
+	}
+
+	return signed, nil
+}
+
+// OrderLicenses returns the licenses issued for an order, oldest first.
+func (s *LicenseService) OrderLicenses(ctx context.Context, orderID int) ([]domain.License, error) {
+	licenses, err := s.licenses.OrderLicenses(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order licenses: %w", err)
+	}
+
+	return licenses, nil
+}
+
+// Revoke revokes the license with key for a member of staff, and records
+// it in the audit trail. Tokens issued before keep verifying offline, so
+// plugins learn of the revocation when they next look their license up.
+func (s *LicenseService) Revoke(ctx context.Context, staffID int, key, reason, ip string) (domain.License, error) {
+	l, err := s.License(ctx, key)
+	if err != nil || !l.Revoked.IsZero() {
+		return l, err
+	}
+
+	now := s.now().UTC()
+
+	err = s.licenses.RevokeLicense(ctx, l.ID, now, reason)
+	if err != nil {
+		return domain.License{}, fmt.Errorf("failed to revoke license: %w", err)
+	}
+
+	err = s.audit.RecordAudit(ctx, domain.AuditEvent{
+		Type:    domain.AuditLicenseRevoked,
+		Subject: "license " + strconv.Itoa(l.ID),
+		IP:      ip,
+		Time:    now,
+		Detail:  fmt.Sprintf("revoked by %s: %s", staffActor(staffID), reason),
+	})
+	if err != nil {
+		return domain.License{}, fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	l.Revoked, l.RevokeReason = now, reason
+
+	return l, nil
+}
+
+// Verify returns the claims of a license token issued by this app. It
+// returns ErrLicenseTokenInvalid for forged or foreign tokens, and for
+// activation tokens. It does not check expiry, which plugins check against
+// their own clock.
+func (s *LicenseService) Verify(token string) (LicenseClaims, error) {
+	var claims licenseClaims
+
+	err := s.ring.Verify(token, &claims)
+	if err != nil || claims.Issuer != s.config.Issuer || claims.Use != tokenUseLicense {
+		return LicenseClaims{}, ErrLicenseTokenInvalid
+	}
+
+	customerID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return LicenseClaims{}, ErrLicenseTokenInvalid
+	}
+
+	c := LicenseClaims{
+		Key:        claims.Key,
+		OrderID:    claims.Order,
+		CustomerID: customerID,
+		ProductID:  claims.Product,
+		Edition:    claims.Edition,
+		Issued:     time.Unix(claims.IssuedAt, 0).UTC(),
+		Expires:    time.Time{},
+	}
+
+	if claims.Expires != 0 {
+		c.Expires = time.Unix(claims.Expires, 0).UTC()
+	}
+
+	return c, nil
+}
+
+// JWKS returns the public keys that verify license tokens, for plugins to
+// embed.
+func (s *LicenseService) JWKS() jwt.JWKS {
+	return s.ring.JWKS()
+}
+
+// sign returns l with its token when it is valid. Tokens are signed anew
+// each time, by the key signing then, and Ed25519 signatures are
+// deterministic: the same license gets the same token until the key
+// changes.
+func (s *LicenseService) sign(l domain.License) (SignedLicense, error) {
+	if !l.Valid(s.now()) {
+		return SignedLicense{License: l, Token: ""}, nil
+	}
+
+	claims := licenseClaims{
+		Issuer:   s.config.Issuer,
+		Subject:  strconv.Itoa(l.CustomerID),
+		Use:      tokenUseLicense,
+		Key:      l.Key,
+		Order:    l.OrderID,
+		Product:  l.ProductID,
+		Edition:  l.Edition,
+		IssuedAt: l.Issued.Unix(),
+		Expires:  0,
+	}
+
+	if !l.Expires.IsZero() {
+		claims.Expires = l.Expires.Unix()
+	}
+
+	token, err := s.ring.Sign(claims)
+	if err != nil {
+		return SignedLicense{}, fmt.Errorf("failed to sign license: %w", err)
+	}
+
+	return SignedLicense{License: l, Token: token}, nil
+}
+
+type LicenseError string
+
+func (e LicenseError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrLicenseConfig       LicenseError = "license issuer must be set and validity not negative"
+	ErrLicenseNotFound     LicenseError = "license not found"
+	ErrLicenseTokenInvalid LicenseError = "license token is invalid"
+)
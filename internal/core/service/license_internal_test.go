@@ -0,0 +1,362 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+type licenseTest struct {
+	*LicenseService
+	orders *orderTest
+	audit  *dal.MemoryAuditLog
+}
+
+func newLicenseTest(t *testing.T, config LicenseConfig, keys ...jwt.Key) *licenseTest {
+	t.Helper()
+
+	orders := newOrderTest(t)
+	audit := dal.NewMemoryAuditLog()
+
+	ring, err := NewLicenseKeyRing(keys...)
+	assert.NoError(t, err)
+
+	svc, err := NewLicenseService(dal.NewMemoryLicenses(), orders.OrderService, audit, ring, config)
+	assert.NoError(t, err)
+
+	svc.now = func() time.Time { return orders.clock }
+
+	return &licenseTest{LicenseService: svc, orders: orders, audit: audit}
+}
+
+func licenseConfig() LicenseConfig {
+	return LicenseConfig{Issuer: "https://brokedaear.com", Validity: 0}
+}
+
+// placePlugin places a pending order of customer 1 for the plugin with
+// productID alone, checked out with session.
+func (l *licenseTest) placePlugin(t *testing.T, session domain.CheckoutSession, productID int) domain.Order {
+	t.Helper()
+
+	return l.placePlugins(t, session, pluginLine(productID, 1, false))
+}
+
+// pluginLine returns an order line for quantity of the plugin with
+// productID, worth 49 dollars each.
+func pluginLine(productID, quantity int, gift bool) domain.OrderLine {
+	return domain.OrderLine{
+		ProductID:   productID,
+		ProductType: domain.ProductPlugin,
+		Name:        "Microwave",
+		Version:     "1.2.0",
+		UnitPrice:   dollars(4900),
+		Quantity:    quantity,
+		Gift:        gift,
+		UpgradeFrom: 0,
+	}
+}
+
+// placePlugins places a pending order of customer 1 for lines of plugins,
+// checked out with session.
+func (l *licenseTest) placePlugins(t *testing.T, session domain.CheckoutSession, lines ...domain.OrderLine) domain.Order {
+	t.Helper()
+
+	var total int64
+	for _, line := range lines {
+		total += line.UnitPrice.Amount * int64(line.Quantity)
+	}
+
+	order, err := l.orders.orders.CreateOrder(t.Context(), domain.Order{
+		ID:          0,
+		CustomerID:  1,
+		Status:      domain.OrderPending,
+		Lines:       lines,
+		Total:       dollars(total),
+		Refunded:    dollars(0),
+		CheckoutKey: "checkout-" + session.ID,
+		CheckoutID:  session.ID,
+		PaymentID:   "",
+		Created:     l.orders.clock,
+		Updated:     l.orders.clock,
+	}, domain.OrderTransition{
+		OrderID: 0,
+		From:    "",
+		To:      domain.OrderPending,
+		Actor:   "customer 1",
+		Reason:  "checked out",
+		Time:    l.orders.clock,
+	})
+	assert.NoError(t, err)
+
+	return order
+}
+
+func paidEvent(session domain.CheckoutSession) domain.PaymentEvent {
+	session.PaymentStatus = domain.PaymentPaid
+	session.PaymentID = "pi_" + session.ID
+
+	return checkoutEvent(domain.EventCheckoutCompleted, session)
+}
+
+func TestNewLicenseService(t *testing.T) {
+	for _, config := range []LicenseConfig{
+		{Issuer: "", Validity: 0},
+		{Issuer: "https://brokedaear.com", Validity: -time.Hour},
+	} {
+		_, err := NewLicenseService(dal.NewMemoryLicenses(), nil, dal.NewMemoryAuditLog(), nil, config)
+		assert.Error(t, err, ErrLicenseConfig)
+	}
+}
+
+func TestLicenseService_IssueLicenses(t *testing.T) {
+	svc := newLicenseTest(t, licenseConfig())
+	ctx := t.Context()
+
+	order, session := svc.orders.place(t, "cs_1")
+
+	// Nothing is issued before the order is paid.
+	assert.NoError(t, svc.IssueLicenses(ctx, checkoutEvent(domain.EventCheckoutCompleted, session)))
+
+	licenses, err := svc.OrderLicenses(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 0)
+
+	svc.orders.pay(t, session)
+	assert.NoError(t, svc.IssueLicenses(ctx, paidEvent(session)))
+	assert.NoError(t, svc.IssueLicenses(ctx, paidEvent(session)))
+
+	// Only the plugin gets a license, and the shirts leave the order to be
+	// fulfilled by staff.
+	licenses, err = svc.OrderLicenses(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 1)
+	assert.Equal(t, licenses[0].ProductID, order.Lines[0].ProductID)
+	assert.Equal(t, licenses[0].CustomerID, 1)
+	assert.Equal(t, licenses[0].Edition, domain.EditionStandard)
+	assert.True(t, licenses[0].Expires.IsZero())
+
+	got, err := svc.orders.Order(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderPaid)
+
+	// Orders of plugins alone are fulfilled by their licenses.
+	session.ID = "cs_2"
+	digital := svc.placePlugin(t, session, 7)
+	svc.orders.pay(t, session)
+	assert.NoError(t, svc.IssueLicenses(ctx, paidEvent(session)))
+
+	got, err = svc.orders.Order(ctx, digital.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderFulfilled)
+
+	history, err := svc.orders.History(ctx, digital.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, history[len(history)-1].Actor, actorDelivery)
+
+	signed, err := svc.CustomerLicenses(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(signed), 2)
+	assert.NotEqual(t, signed[1].Token, "")
+}
+
+func TestLicenseService_IssueGifts(t *testing.T) {
+	svc := newLicenseTest(t, licenseConfig())
+	ctx := t.Context()
+
+	_, session := svc.orders.place(t, "cs_1")
+	session.ID = "cs_2"
+
+	// Gifts of a plugin get a license for every copy, besides the license
+	// of the copy bought for the buyer.
+	order := svc.placePlugins(t, session, pluginLine(7, 1, false), pluginLine(7, 3, true))
+	svc.orders.pay(t, session)
+	assert.NoError(t, svc.IssueLicenses(ctx, paidEvent(session)))
+	assert.NoError(t, svc.IssueLicenses(ctx, paidEvent(session)))
+
+	licenses, err := svc.OrderLicenses(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 4)
+
+	keys := make(map[string]bool)
+
+	for i, l := range licenses {
+		assert.Equal(t, l.ProductID, 7)
+		assert.Equal(t, l.Line, min(i, 1))
+		assert.Equal(t, l.Seat, max(i-1, 0))
+		keys[l.Key] = true
+	}
+
+	assert.Equal(t, len(keys), 4)
+}
+
+func TestLicenseService_Lookup(t *testing.T) {
+	svc := newLicenseTest(t, LicenseConfig{Issuer: "https://brokedaear.com", Validity: 365 * 24 * time.Hour})
+	ctx := t.Context()
+
+	_, session := svc.orders.place(t, "cs_1")
+	svc.orders.pay(t, session)
+	assert.NoError(t, svc.IssueLicenses(ctx, paidEvent(session)))
+
+	licenses, err := svc.CustomerLicenses(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 1)
+
+	issued := licenses[0]
+	assert.True(t, issued.Expires.Equal(svc.orders.clock.Add(365*24*time.Hour)))
+
+	// Keys are found however customers type them.
+	typed := strings.ToLower(strings.ReplaceAll(issued.Key, "-", ""))
+
+	l, err := svc.Lookup(ctx, typed)
+	assert.NoError(t, err)
+	assert.Equal(t, l.Key, issued.Key)
+	assert.Equal(t, l.Token, issued.Token)
+
+	claims, err := svc.Verify(l.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, claims.Key, issued.Key)
+	assert.Equal(t, claims.CustomerID, 1)
+	assert.Equal(t, claims.OrderID, issued.OrderID)
+	assert.Equal(t, claims.ProductID, issued.ProductID)
+	assert.Equal(t, claims.Edition, domain.EditionStandard)
+	assert.True(t, claims.Issued.Equal(issued.Issued))
+	assert.True(t, claims.Expires.Equal(issued.Expires))
+
+	// Plugins verify tokens offline with the published keys.
+	var payload licenseClaims
+	assert.NoError(t, svc.JWKS().Verify(l.Token, &payload))
+
+	_, err = svc.Lookup(ctx, typed[:len(typed)-1])
+	assert.Error(t, err, domain.ErrInvalidLicenseKey)
+
+	_, err = svc.Lookup(ctx, domain.NewLicenseKey())
+	assert.Error(t, err, ErrLicenseNotFound)
+
+	// Expired licenses are found, without a token.
+	svc.orders.advance(366 * 24 * time.Hour)
+
+	l, err = svc.Lookup(ctx, issued.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, l.Token, "")
+}
+
+func TestLicenseService_Verify(t *testing.T) {
+	old, current := jwt.NewKey(), jwt.NewKey()
+
+	before := newLicenseTest(t, licenseConfig(), old)
+	after := newLicenseTest(t, licenseConfig(), current, old)
+	foreign := newLicenseTest(t, LicenseConfig{Issuer: "https://example.com", Validity: 0}, old)
+
+	sign := func(svc *licenseTest) string {
+		t.Helper()
+
+		_, session := svc.orders.place(t, "cs_1")
+		svc.orders.pay(t, session)
+		assert.NoError(t, svc.IssueLicenses(t.Context(), paidEvent(session)))
+
+		licenses, err := svc.CustomerLicenses(t.Context(), 1)
+		assert.NoError(t, err)
+
+		return licenses[0].Token
+	}
+
+	// Tokens signed before a rotation keep verifying.
+	_, err := after.Verify(sign(before))
+	assert.NoError(t, err)
+
+	_, err = before.Verify(sign(after))
+	assert.Error(t, err, ErrLicenseTokenInvalid)
+
+	_, err = after.Verify(sign(foreign))
+	assert.Error(t, err, ErrLicenseTokenInvalid)
+
+	_, err = after.Verify("not.a.token")
+	assert.Error(t, err, ErrLicenseTokenInvalid)
+}
+
+func TestLicenseService_Revoke(t *testing.T) {
+	svc := newLicenseTest(t, licenseConfig())
+	ctx := t.Context()
+
+	_, session := svc.orders.place(t, "cs_1")
+	svc.orders.pay(t, session)
+	assert.NoError(t, svc.IssueLicenses(ctx, paidEvent(session)))
+
+	session.ID = "cs_2"
+	svc.placePlugin(t, session, 7)
+	svc.orders.pay(t, session)
+	assert.NoError(t, svc.IssueLicenses(ctx, paidEvent(session)))
+
+	licenses, err := svc.CustomerLicenses(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(licenses), 2)
+
+	svc.orders.advance(time.Hour)
+
+	l, err := svc.Revoke(ctx, 5, licenses[0].Key, "shared online", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.True(t, l.Revoked.Equal(svc.orders.clock))
+	assert.Equal(t, l.RevokeReason, "shared online")
+
+	events := svc.audit.Events()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, domain.AuditLicenseRevoked)
+	assert.Equal(t, events[0].Detail, "revoked by staff 5: shared online")
+
+	// Revoking again keeps the first revocation.
+	_, err = svc.Revoke(ctx, 5, licenses[0].Key, "again", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, len(svc.audit.Events()), 1)
+
+	signed, err := svc.Lookup(ctx, licenses[0].Key)
+	assert.NoError(t, err)
+	assert.Equal(t, signed.Token, "")
+	assert.Equal(t, signed.RevokeReason, "shared online")
+
+	_, err = svc.Revoke(ctx, 5, domain.NewLicenseKey(), "", "10.0.0.1")
+	assert.Error(t, err, ErrLicenseNotFound)
+
+	// Refunding an order in part keeps its licenses, and in full revokes
+	// them.
+	refund := func(refunded int64, full bool) domain.PaymentEvent {
+		return domain.PaymentEvent{
+			ID:       "evt",
+			Type:     domain.EventChargeRefunded,
+			Created:  time.Time{},
+			Checkout: nil,
+			Charge: &domain.Charge{
+				ID:            "ch_2",
+				PaymentID:     "pi_cs_2",
+				Amount:        dollars(4900),
+				Refunded:      dollars(refunded),
+				FullyRefunded: full,
+			},
+			Dispute: nil,
+		}
+	}
+
+	assert.NoError(t, svc.orders.RefundOrder(ctx, refund(900, false)))
+	assert.NoError(t, svc.RevokeRefunded(ctx, refund(900, false)))
+
+	signed, err = svc.Lookup(ctx, licenses[1].Key)
+	assert.NoError(t, err)
+	assert.NotEqual(t, signed.Token, "")
+
+	assert.NoError(t, svc.orders.RefundOrder(ctx, refund(4900, true)))
+	assert.NoError(t, svc.RevokeRefunded(ctx, refund(4900, true)))
+
+	signed, err = svc.Lookup(ctx, licenses[1].Key)
+	assert.NoError(t, err)
+	assert.Equal(t, signed.Token, "")
+
+	assert.Equal(t, signed.RevokeReason, "order refunded")
+}
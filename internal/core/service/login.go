@@ -0,0 +1,586 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// LoginConfig configures the defenses of the login against password guessing
+// and credential stuffing. Each failed login delays the next attempt on the
+// same account and from the same IP address, doubling the delay every time,
+// until the threshold is reached and the account or address is locked out.
+type LoginConfig struct {
+	// AccountThreshold is the number of consecutive failures after which an
+	// account is locked.
+	AccountThreshold int
+
+	// IPThreshold is the number of consecutive failures after which an IP
+	// address is locked. It is usually higher than AccountThreshold, since
+	// many customers may share an address.
+	IPThreshold int
+
+	// BaseDelay is the delay after the first failure.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between two attempts.
+	MaxDelay time.Duration
+
+	// Lockout is how long a lock lasts. Failures older than Lockout are
+	// forgotten.
+	Lockout time.Duration
+}
+
+func (c LoginConfig) Validate() error {
+	if c.AccountThreshold <= 0 || c.IPThreshold <= 0 {
+		return ErrLoginConfig
+	}
+
+	if c.BaseDelay < 0 || c.MaxDelay < c.BaseDelay || c.Lockout <= 0 {
+		return ErrLoginConfig
+	}
+
+	return nil
+}
+
+func (c LoginConfig) Value() any {
+	return c
+}
+
+// Authenticator checks the credentials of customers.
+type Authenticator interface {
+	Authenticate(
+		ctx context.Context,
+		email domain.RegisteredCustomerEmail,
+		password domain.RegisteredCustomerPassword,
+	) (domain.Customer, error)
+}
+
+// LoginMetrics counts login outcomes. Failures carry a reason attribute.
+type LoginMetrics struct {
+	Succeeded otelmetric.Int64Counter
+	Failed    otelmetric.Int64Counter
+}
+
+// LoginService logs customers in, issuing them a session.
+type LoginService struct {
+	customers Authenticator
+	sessions  *SessionService
+	twoFactor *TwoFactorService
+	failures  ports.LoginFailures
+	audit     ports.AuditLog
+	metrics   LoginMetrics
+	config    LoginConfig
+	now       func() time.Time
+}
+
+func NewLoginService(
+	customers Authenticator,
+	sessions *SessionService,
+	twoFactor *TwoFactorService,
+	failures ports.LoginFailures,
+	audit ports.AuditLog,
+	metrics LoginMetrics,
+	config LoginConfig,
+) (*LoginService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginService{
+		customers: customers,
+		sessions:  sessions,
+		twoFactor: twoFactor,
+		failures:  failures,
+		audit:     audit,
+		metrics:   metrics,
+		config:    config,
+		now:       time.Now,
+	}, nil
+}
+
+// LoginRequest is an attempt to log in.
+type LoginRequest struct {
+	Email    domain.RegisteredCustomerEmail
+	Password domain.RegisteredCustomerPassword
+	Meta     SessionMeta
+
+	// SessionToken is the session the client already holds, if any. It is
+	// ended on login, so that the client never keeps a session token across
+	// a change of privileges.
+	SessionToken string
+}
+
+// LoginResult is a successful login. Customers with two-factor
+// authentication get a Challenge instead of a session, which they complete
+// with a code.
+type LoginResult struct {
+	Customer domain.Customer
+	Session  domain.Session
+	Token    string
+
+	Challenge        string
+	ChallengeExpires time.Time
+}
+
+// SecondFactorRequest completes a login challenge with a code.
+type SecondFactorRequest struct {
+	Challenge    string
+	Code         string
+	Meta         SessionMeta
+	SessionToken string
+}
+
+// Login checks the credentials of a customer and issues a session, or a
+// challenge when they enabled two-factor authentication. Attempts made too
+// soon after a failure, or on a locked account or IP address, are rejected
+// with a *RetryError without checking the credentials.
+func (s *LoginService) Login(ctx context.Context, req LoginRequest) (LoginResult, error) {
+	c, err := s.authenticate(ctx, req)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	enabled, err := s.twoFactor.Enabled(ctx, c.ID)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	if enabled {
+		challenge, expires := s.twoFactor.NewChallenge(c.ID)
+
+		return LoginResult{
+			Customer:         c,
+			Session:          domain.Session{},
+			Token:            "",
+			Challenge:        challenge,
+			ChallengeExpires: expires,
+		}, nil
+	}
+
+	return s.issue(ctx, c, req.Meta, req.SessionToken, false)
+}
+
+// CompleteLogin checks the code entered against a login challenge and
+// issues a stepped up session. Too many wrong codes are rejected with a
+// *RetryError.
+func (s *LoginService) CompleteLogin(ctx context.Context, req SecondFactorRequest) (LoginResult, error) {
+	c, err := s.twoFactor.CompleteChallenge(ctx, req.Challenge, req.Code, req.Meta.IP)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCode) {
+			s.fail(ctx, "invalid_code")
+		}
+
+		return LoginResult{}, err
+	}
+
+	return s.issue(ctx, c, req.Meta, req.SessionToken, true)
+}
+
+// Reauthenticate checks the password of a logged in customer before a
+// sensitive change, such as a new password. Failures count against the
+// account and the IP address as failed logins do, and attempts are throttled
+// and locked out the same way; no session is issued.
+func (s *LoginService) Reauthenticate(
+	ctx context.Context,
+	email domain.RegisteredCustomerEmail,
+	password domain.RegisteredCustomerPassword,
+	ip string,
+) (domain.Customer, error) {
+	return s.authenticate(ctx, LoginRequest{
+		Email:        email,
+		Password:     password,
+		Meta:         SessionMeta{UserAgent: "", IP: ip},
+		SessionToken: "",
+	})
+}
+
+// authenticate checks the credentials of req under the throttling and
+// lockouts of the account and the IP address.
+func (s *LoginService) authenticate(ctx context.Context, req LoginRequest) (domain.Customer, error) {
+	gates := s.gates(req)
+
+	err := s.admit(ctx, gates, req.Meta.IP)
+	if err == nil {
+		err = s.reserve(ctx, gates, req.Meta.IP)
+	}
+
+	if err != nil {
+		s.fail(ctx, reasonOf(err))
+		return domain.Customer{}, err
+	}
+
+	c, err := s.customers.Authenticate(ctx, req.Email, req.Password)
+	if err != nil {
+		if !errors.Is(err, domain.ErrInvalidCredentials) {
+			return domain.Customer{}, errors.Join(err, s.forgive(ctx, gates...))
+		}
+
+		s.fail(ctx, "invalid_credentials")
+
+		for _, g := range gates {
+			lockErr := s.lock(ctx, g, req.Meta.IP)
+			if lockErr != nil {
+				return domain.Customer{}, lockErr
+			}
+		}
+
+		return domain.Customer{}, domain.ErrInvalidCredentials
+	}
+
+	// Only the account is cleared on success. Clearing the IP address too
+	// would let an attacker holding one valid account reset the count of
+	// their credential stuffing, so only this attempt is taken back from it.
+	err = s.failures.ResetLoginFailures(ctx, gates[0].key)
+	if err != nil {
+		return domain.Customer{}, fmt.Errorf("failed to reset login failures: %w", err)
+	}
+
+	err = s.forgive(ctx, gates[1])
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	return c, nil
+}
+
+// issue gives the customer a session with a fresh token. A session the
+// client already held for the same customer is rotated; any other is ended
+// and a new one is created.
+func (s *LoginService) issue(
+	ctx context.Context,
+	c domain.Customer,
+	meta SessionMeta,
+	current string,
+	steppedUp bool,
+) (LoginResult, error) {
+	token, sess, err := s.rotate(ctx, c, current)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	if token == "" {
+		token, sess, err = s.sessions.Create(ctx, c.ID, meta)
+		if err != nil {
+			return LoginResult{}, err
+		}
+	}
+
+	if steppedUp {
+		sess.SteppedUp, err = s.sessions.StepUp(ctx, sess.ID)
+		if err != nil {
+			return LoginResult{}, err
+		}
+	}
+
+	s.metrics.Succeeded.Add(ctx, 1)
+
+	return LoginResult{
+		Customer:         c,
+		Session:          sess,
+		Token:            token,
+		Challenge:        "",
+		ChallengeExpires: time.Time{},
+	}, nil
+}
+
+// rotate rotates the current session of the client if it belongs to c,
+// and ends it otherwise. It returns an empty token when there was no
+// session to rotate.
+func (s *LoginService) rotate(
+	ctx context.Context,
+	c domain.Customer,
+	current string,
+) (string, domain.Session, error) {
+	if current == "" {
+		return "", domain.Session{}, nil
+	}
+
+	sess, err := s.sessions.Validate(ctx, current)
+
+	switch {
+	case errors.Is(err, ErrSessionInvalid), errors.Is(err, ErrSessionExpired):
+		return "", domain.Session{}, nil
+	case err != nil:
+		return "", domain.Session{}, err
+	case sess.CustomerID != c.ID:
+		return "", domain.Session{}, s.sessions.Logout(ctx, current)
+	default:
+		return s.sessions.Rotate(ctx, current)
+	}
+}
+
+// Sweep forgets failures old enough to no longer matter, and returns how
+// many keys were forgotten.
+func (s *LoginService) Sweep(ctx context.Context) (int, error) {
+	return s.failures.ForgetLoginFailures(ctx, s.now().Add(-s.config.Lockout))
+}
+
+// gate is the failure count of the account or the IP address of an
+// attempt. Seen and last are what admit read before the attempt, and
+// count and at what reserve recorded for it. Serial gates admit one attempt
+// at a time.
+type gate struct {
+	key       string
+	threshold int
+	serial    bool
+	lockErr   error
+	locked    domain.AuditEventType
+	unlocked  domain.AuditEventType
+
+	seen  int
+	last  time.Time
+	count int
+	at    time.Time
+}
+
+// gates returns the gates of the account and the IP address of req, in
+// that order. Failures on emails without an account are counted all the
+// same, so that lockouts do not tell which emails belong to customers. Only
+// the account is serial: many customers may log in from one address at the
+// same time.
+func (s *LoginService) gates(req LoginRequest) []*gate {
+	email, err := domain.NormalizeEmail(req.Email.String())
+	if err != nil {
+		email = strings.ToLower(strings.TrimSpace(req.Email.String()))
+	}
+
+	return []*gate{
+		{
+			key:       "account:" + email,
+			threshold: s.config.AccountThreshold,
+			serial:    true,
+			lockErr:   ErrAccountLocked,
+			locked:    domain.AuditAccountLocked,
+			unlocked:  domain.AuditAccountUnlocked,
+			seen:      0,
+			last:      time.Time{},
+			count:     0,
+			at:        time.Time{},
+		},
+		{
+			key:       "ip:" + req.Meta.IP,
+			threshold: s.config.IPThreshold,
+			serial:    false,
+			lockErr:   ErrIPLocked,
+			locked:    domain.AuditIPLocked,
+			unlocked:  domain.AuditIPUnlocked,
+			seen:      0,
+			last:      time.Time{},
+			count:     0,
+			at:        time.Time{},
+		},
+	}
+}
+
+// admit rejects the attempt when the account or the IP address is locked or
+// must wait longer since its last failure.
+func (s *LoginService) admit(ctx context.Context, gates []*gate, ip string) error {
+	now := s.now()
+
+	for _, g := range gates {
+		count, last, err := s.failures.LoginFailures(ctx, g.key)
+		if err != nil {
+			return fmt.Errorf("failed to read login failures: %w", err)
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		if now.Sub(last) >= s.config.Lockout {
+			err = s.forget(ctx, g.key, count >= g.threshold, g.unlocked, ip)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if count >= g.threshold {
+			return &RetryError{Err: g.lockErr, RetryAfter: last.Add(s.config.Lockout).Sub(now)}
+		}
+
+		wait := last.Add(s.delay(count)).Sub(now)
+		if wait > 0 {
+			return &RetryError{Err: ErrLoginThrottled, RetryAfter: wait}
+		}
+
+		g.seen, g.last = count, last
+	}
+
+	return nil
+}
+
+// reserve counts the attempt as a failure of the account and the IP address
+// before the password is checked, and decides on the counts the store
+// returns. Attempts made at the same time all pass admit before any of them
+// is counted. On a serial gate only the first to be counted goes on, and the
+// others are throttled or locked out as if they had come after it, keeping
+// their failure. Other gates only lock out the attempts counted past their
+// threshold.
+func (s *LoginService) reserve(ctx context.Context, gates []*gate, ip string) error {
+	now := s.now()
+
+	for _, g := range gates {
+		count, err := s.failures.RecordLoginFailure(ctx, g.key, now)
+		if err != nil {
+			return fmt.Errorf("failed to record login failure: %w", err)
+		}
+
+		g.count, g.at = count, now
+
+		if count == g.seen+1 || (!g.serial && count <= g.threshold) {
+			continue
+		}
+
+		err = s.lock(ctx, g, ip)
+		if err != nil {
+			return err
+		}
+
+		if count > g.threshold {
+			return &RetryError{Err: g.lockErr, RetryAfter: s.config.Lockout}
+		}
+
+		return &RetryError{Err: ErrLoginThrottled, RetryAfter: s.delay(count - 1)}
+	}
+
+	return nil
+}
+
+// forgive takes back the failures reserve counted against gates, for
+// attempts that turned out not to fail.
+func (s *LoginService) forgive(ctx context.Context, gates ...*gate) error {
+	for _, g := range gates {
+		if g.count == 0 {
+			continue
+		}
+
+		err := s.failures.ForgiveLoginFailure(ctx, g.key, g.at, g.last)
+		if err != nil {
+			return fmt.Errorf("failed to forgive login failure: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// forget resets the stale failures of key, auditing the end of its lock if
+// it was locked.
+func (s *LoginService) forget(
+	ctx context.Context,
+	key string,
+	wasLocked bool,
+	unlocked domain.AuditEventType,
+	ip string,
+) error {
+	err := s.failures.ResetLoginFailures(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+
+	if !wasLocked {
+		return nil
+	}
+
+	return s.record(ctx, unlocked, key, ip, "lockout expired")
+}
+
+// lock audits the lock of g when its failure was the one that reached the
+// threshold.
+func (s *LoginService) lock(ctx context.Context, g *gate, ip string) error {
+	if g.count != g.threshold {
+		return nil
+	}
+
+	return s.record(ctx, g.locked, g.key, ip, fmt.Sprintf("locked for %s after %d failed logins", s.config.Lockout, g.count))
+}
+
+func (s *LoginService) record(ctx context.Context, t domain.AuditEventType, key, ip, detail string) error {
+	_, subject, _ := strings.Cut(key, ":")
+
+	err := s.audit.RecordAudit(ctx, domain.AuditEvent{
+		Type:    t,
+		Subject: subject,
+		IP:      ip,
+		Time:    s.now().UTC(),
+		Detail:  detail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// delay returns how long to wait after the given number of consecutive
+// failures.
+func (s *LoginService) delay(failures int) time.Duration {
+	d := s.config.BaseDelay
+	for range failures - 1 {
+		d *= 2
+		if d >= s.config.MaxDelay {
+			return s.config.MaxDelay
+		}
+	}
+
+	return d
+}
+
+func (s *LoginService) fail(ctx context.Context, reason string) {
+	s.metrics.Failed.Add(ctx, 1, otelmetric.WithAttributes(attribute.String("reason", reason)))
+}
+
+func reasonOf(err error) string {
+	switch {
+	case errors.Is(err, ErrAccountLocked):
+		return "account_locked"
+	case errors.Is(err, ErrIPLocked):
+		return "ip_locked"
+	case errors.Is(err, ErrLoginThrottled):
+		return "throttled"
+	default:
+		return "error"
+	}
+}
+
+// RetryError is an attempt rejected until RetryAfter has passed.
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", e.Err, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+type LoginError string
+
+func (e LoginError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrLoginConfig    LoginError = "login thresholds and lockout must be positive and delays ordered"
+	ErrLoginThrottled LoginError = "too many failed logins"
+	ErrAccountLocked  LoginError = "account is temporarily locked"
+	ErrIPLocked       LoginError = "too many failed logins from this address"
+)
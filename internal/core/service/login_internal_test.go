@@ -0,0 +1,445 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/otp"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// fakeAuthenticator knows a single customer.
+type fakeAuthenticator struct {
+	email    domain.RegisteredCustomerEmail
+	password domain.RegisteredCustomerPassword
+}
+
+func (f fakeAuthenticator) Authenticate(
+	_ context.Context,
+	email domain.RegisteredCustomerEmail,
+	password domain.RegisteredCustomerPassword,
+) (domain.Customer, error) {
+	if !strings.EqualFold(string(email), string(f.email)) || password != f.password {
+		return domain.Customer{}, domain.ErrInvalidCredentials
+	}
+
+	return domain.Customer{ID: 1, Email: string(email), HashedPassword: nil, Created: time.Time{}, Verified: true, PaymentCustomerID: ""}, nil
+}
+
+type loginTest struct {
+	*LoginService
+	sessions  *SessionService
+	twoFactor *TwoFactorService
+	audit     *dal.MemoryAuditLog
+	clock     time.Time
+}
+
+func (l *loginTest) advance(d time.Duration) {
+	l.clock = l.clock.Add(d)
+}
+
+func (l *loginTest) login(t *testing.T, email, password, ip string) (LoginResult, error) {
+	t.Helper()
+
+	return l.Login(t.Context(), LoginRequest{
+		Email:        domain.RegisteredCustomerEmail(email),
+		Password:     domain.RegisteredCustomerPassword(password),
+		Meta:         SessionMeta{UserAgent: "test", IP: ip},
+		SessionToken: "",
+	})
+}
+
+func newLoginTest(t *testing.T) *loginTest {
+	t.Helper()
+
+	sessions, err := NewSessionService(dal.NewMemorySessions(), SessionConfig{
+		IdleTimeout:      time.Hour,
+		AbsoluteLifetime: 3 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	meter := noop.NewMeterProvider().Meter("test")
+	succeeded, err := meter.Int64Counter("succeeded")
+	assert.NoError(t, err)
+	failed, err := meter.Int64Counter("failed")
+	assert.NoError(t, err)
+
+	audit := dal.NewMemoryAuditLog()
+
+	customers := dal.NewMemoryCustomers()
+	_, err = customers.CreateCustomer(t.Context(), domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          true,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	twoFactor := newTwoFactorService(t, customers, dal.NewMemoryTwoFactor(), sessions, audit)
+
+	svc, err := NewLoginService(
+		fakeAuthenticator{email: "jane@example.com", password: "correct horse battery"},
+		sessions,
+		twoFactor,
+		dal.NewMemoryLoginFailures(),
+		audit,
+		LoginMetrics{Succeeded: succeeded, Failed: failed},
+		LoginConfig{
+			AccountThreshold: 3,
+			IPThreshold:      5,
+			BaseDelay:        time.Second,
+			MaxDelay:         4 * time.Second,
+			Lockout:          15 * time.Minute,
+		},
+	)
+	assert.NoError(t, err)
+
+	lt := &loginTest{
+		LoginService: svc,
+		sessions:     sessions,
+		twoFactor:    twoFactor,
+		audit:        audit,
+		clock:        time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	svc.now = func() time.Time { return lt.clock }
+	sessions.now = func() time.Time { return lt.clock }
+	twoFactor.now = func() time.Time { return lt.clock }
+
+	return lt
+}
+
+func retryAfter(t *testing.T, err error, want error) time.Duration {
+	t.Helper()
+
+	var retryErr *RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Error(t, err, want)
+
+	return retryErr.RetryAfter
+}
+
+func TestLoginService_IssuesSession(t *testing.T) {
+	l := newLoginTest(t)
+
+	res, err := l.login(t, "Jane@Example.com", "correct horse battery", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, res.Customer.ID, 1)
+	assert.Equal(t, res.Session.CustomerID, 1)
+
+	sess, err := l.sessions.Validate(t.Context(), res.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, sess.ID, res.Session.ID)
+}
+
+func TestLoginService_EndsPreviousSession(t *testing.T) {
+	l := newLoginTest(t)
+
+	old, err := l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	assert.NoError(t, err)
+
+	l.advance(time.Minute)
+
+	// A session of the same customer is rotated: its token changes, but
+	// not its absolute expiry.
+	res, err := l.Login(t.Context(), LoginRequest{
+		Email:        "jane@example.com",
+		Password:     "correct horse battery",
+		Meta:         SessionMeta{UserAgent: "test", IP: "10.0.0.1"},
+		SessionToken: old.Token,
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, res.Token, old.Token)
+	assert.True(t, res.Session.AbsoluteExpires.Equal(old.Session.AbsoluteExpires))
+
+	_, err = l.sessions.Validate(t.Context(), old.Token)
+	assert.Error(t, err, ErrSessionInvalid)
+
+	// A session of another customer is ended.
+	other, _, err := l.sessions.Create(t.Context(), 2, SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	res, err = l.Login(t.Context(), LoginRequest{
+		Email:        "jane@example.com",
+		Password:     "correct horse battery",
+		Meta:         SessionMeta{UserAgent: "test", IP: "10.0.0.1"},
+		SessionToken: other,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, res.Session.CustomerID, 1)
+	assert.True(t, res.Session.AbsoluteExpires.After(old.Session.AbsoluteExpires))
+
+	_, err = l.sessions.Validate(t.Context(), other)
+	assert.Error(t, err, ErrSessionInvalid)
+}
+
+func TestLoginService_TwoFactorChallenge(t *testing.T) {
+	l := newLoginTest(t)
+
+	e, err := l.twoFactor.BeginEnrollment(t.Context(), 1)
+	assert.NoError(t, err)
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(e.Secret)
+	assert.NoError(t, err)
+
+	_, sess, err := l.sessions.Create(t.Context(), 1, SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	p := otp.DefaultParams()
+
+	_, err = l.twoFactor.ConfirmEnrollment(t.Context(), sess, p.Code(secret, p.Counter(l.clock)), "10.0.0.1")
+	assert.NoError(t, err)
+
+	l.advance(p.Period)
+
+	// The password alone only earns a challenge.
+	res, err := l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, res.Token, "")
+	assert.True(t, res.Challenge != "")
+
+	_, err = l.CompleteLogin(t.Context(), SecondFactorRequest{
+		Challenge:    res.Challenge,
+		Code:         "000000",
+		Meta:         SessionMeta{UserAgent: "test", IP: "10.0.0.1"},
+		SessionToken: "",
+	})
+	assert.Error(t, err, ErrInvalidCode)
+
+	done, err := l.CompleteLogin(t.Context(), SecondFactorRequest{
+		Challenge:    res.Challenge,
+		Code:         p.Code(secret, p.Counter(l.clock)),
+		Meta:         SessionMeta{UserAgent: "test", IP: "10.0.0.1"},
+		SessionToken: "",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, done.Customer.ID, 1)
+
+	// The session starts stepped up.
+	sess, err = l.sessions.Validate(t.Context(), done.Token)
+	assert.NoError(t, err)
+	assert.NoError(t, l.twoFactor.RequireStepUp(t.Context(), sess))
+}
+
+func TestLoginService_ProgressiveDelay(t *testing.T) {
+	l := newLoginTest(t)
+
+	_, err := l.login(t, "jane@example.com", "wrong", "10.0.0.1")
+	assert.Error(t, err, domain.ErrInvalidCredentials)
+
+	// Retrying at once is throttled, even with the right password.
+	_, err = l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	assert.Equal(t, retryAfter(t, err, ErrLoginThrottled), time.Second)
+
+	l.advance(time.Second)
+	_, err = l.login(t, "jane@example.com", "wrong", "10.0.0.1")
+	assert.Error(t, err, domain.ErrInvalidCredentials)
+
+	// The delay doubles with each failure.
+	_, err = l.login(t, "jane@example.com", "wrong", "10.0.0.1")
+	assert.Equal(t, retryAfter(t, err, ErrLoginThrottled), 2*time.Second)
+
+	l.advance(2 * time.Second)
+	_, err = l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	assert.NoError(t, err)
+
+	// Success clears the account.
+	_, err = l.login(t, "jane@example.com", "wrong", "10.0.0.1")
+	assert.Error(t, err, domain.ErrInvalidCredentials)
+}
+
+func TestLoginService_AccountLockout(t *testing.T) {
+	l := newLoginTest(t)
+
+	for range 3 {
+		_, err := l.login(t, "jane@example.com", "wrong", "10.0.0.1")
+		assert.Error(t, err, domain.ErrInvalidCredentials)
+		l.advance(time.Minute)
+	}
+
+	events := l.audit.Events()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, domain.AuditAccountLocked)
+	assert.Equal(t, events[0].Subject, "jane@example.com")
+
+	// The lock holds from any address, with the right password too.
+	_, err := l.login(t, "jane@example.com", "correct horse battery", "10.0.0.2")
+	assert.Equal(t, retryAfter(t, err, ErrAccountLocked), 14*time.Minute)
+
+	l.advance(14 * time.Minute)
+	_, err = l.login(t, "jane@example.com", "correct horse battery", "10.0.0.2")
+	assert.NoError(t, err)
+
+	events = l.audit.Events()
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[1].Type, domain.AuditAccountUnlocked)
+}
+
+// racingFailures counts a failure of another attempt just before each one
+// it records on a key starting with prefix, as if that attempt had been
+// checked at the same time.
+type racingFailures struct {
+	*dal.MemoryLoginFailures
+	prefix string
+}
+
+func (r racingFailures) RecordLoginFailure(ctx context.Context, key string, at time.Time) (int, error) {
+	if !strings.HasPrefix(key, r.prefix) {
+		return r.MemoryLoginFailures.RecordLoginFailure(ctx, key, at)
+	}
+
+	_, err := r.MemoryLoginFailures.RecordLoginFailure(ctx, key, at)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.MemoryLoginFailures.RecordLoginFailure(ctx, key, at)
+}
+
+func TestLoginService_ConcurrentAttempts(t *testing.T) {
+	l := newLoginTest(t)
+	l.failures = racingFailures{dal.NewMemoryLoginFailures(), ""}
+
+	// Another attempt was counted first, so the password is not even
+	// checked.
+	_, err := l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	assert.Equal(t, retryAfter(t, err, ErrLoginThrottled), time.Second)
+
+	// Attempts counted past the threshold are locked out.
+	l.advance(time.Minute)
+	_, err = l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	assert.Equal(t, retryAfter(t, err, ErrAccountLocked), 15*time.Minute)
+}
+
+func TestLoginService_ConcurrentLoginsFromOneIP(t *testing.T) {
+	l := newLoginTest(t)
+	failures := dal.NewMemoryLoginFailures()
+	l.failures = racingFailures{failures, "ip:"}
+
+	// Another customer behind the same address logs in at the same time.
+	_, err := l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	assert.NoError(t, err)
+
+	// Only the attempt of the other customer is still counted, and the
+	// account was not charged a failure.
+	count, _, err := l.failures.LoginFailures(t.Context(), "ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, count, 1)
+
+	count, _, err = l.failures.LoginFailures(t.Context(), "account:jane@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, count, 0)
+
+	// Attempts counted past the threshold of the address are still locked
+	// out.
+	for range 4 {
+		_, err = failures.RecordLoginFailure(t.Context(), "ip:10.0.0.1", l.clock)
+		assert.NoError(t, err)
+	}
+
+	_, err = l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	assert.Equal(t, retryAfter(t, err, ErrIPLocked), 15*time.Minute)
+}
+
+func TestLoginService_SuccessLeavesIPCount(t *testing.T) {
+	l := newLoginTest(t)
+
+	_, err := l.login(t, "jane@example.com", "wrong", "10.0.0.1")
+	assert.Error(t, err, domain.ErrInvalidCredentials)
+
+	l.advance(time.Second)
+	_, err = l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	assert.NoError(t, err)
+
+	// The successful attempt counted against the address only while its
+	// password was checked.
+	count, last, err := l.failures.LoginFailures(t.Context(), "ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, count, 1)
+	assert.True(t, last.Equal(l.clock.Add(-time.Second)))
+}
+
+func TestLoginService_UnknownEmailsAreCounted(t *testing.T) {
+	l := newLoginTest(t)
+
+	for range 3 {
+		_, err := l.login(t, "nobody@example.com", "wrong", "10.0.0.1")
+		assert.Error(t, err, domain.ErrInvalidCredentials)
+		l.advance(time.Minute)
+	}
+
+	_, err := l.login(t, "nobody@example.com", "wrong", "10.0.0.2")
+	retryAfter(t, err, ErrAccountLocked)
+}
+
+func TestLoginService_IPLockout(t *testing.T) {
+	l := newLoginTest(t)
+
+	// Credential stuffing spreads failures over many accounts.
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"} {
+		_, err := l.login(t, email, "wrong", "10.0.0.1")
+		assert.Error(t, err, domain.ErrInvalidCredentials)
+		l.advance(time.Minute)
+	}
+
+	events := l.audit.Events()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, domain.AuditIPLocked)
+	assert.Equal(t, events[0].Subject, "10.0.0.1")
+
+	_, err := l.login(t, "jane@example.com", "correct horse battery", "10.0.0.1")
+	retryAfter(t, err, ErrIPLocked)
+
+	// Other addresses are not affected.
+	_, err = l.login(t, "jane@example.com", "correct horse battery", "10.0.0.2")
+	assert.NoError(t, err)
+}
+
+func TestLoginService_Sweep(t *testing.T) {
+	l := newLoginTest(t)
+
+	_, err := l.login(t, "jane@example.com", "wrong", "10.0.0.1")
+	assert.Error(t, err, domain.ErrInvalidCredentials)
+
+	n, err := l.Sweep(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, n, 0)
+
+	l.advance(16 * time.Minute)
+
+	n, err = l.Sweep(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, n, 2)
+}
+
+func TestLoginConfig_Validate(t *testing.T) {
+	valid := LoginConfig{
+		AccountThreshold: 1,
+		IPThreshold:      1,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Second,
+		Lockout:          time.Minute,
+	}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.MaxDelay = 0
+	assert.Error(t, invalid.Validate(), ErrLoginConfig)
+
+	invalid = valid
+	invalid.AccountThreshold = 0
+	assert.Error(t, invalid.Validate(), ErrLoginConfig)
+}
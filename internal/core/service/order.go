@@ -0,0 +1,681 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+const (
+	// actorPayments is the actor of the transitions made by the events of
+	// the payment provider.
+	actorPayments = "payments"
+
+	// actorDelivery is the actor of the transitions made once orders were
+	// delivered without staff.
+	actorDelivery = "delivery"
+)
+
+func customerActor(id int) string {
+	return fmt.Sprintf("customer %d", id)
+}
+
+func staffActor(id int) string {
+	return fmt.Sprintf("staff %d", id)
+}
+
+// OrderService keeps track of orders, from their checkout to their
+// fulfillment or refund. Orders are moved along their state machine by
+// the events of the payment provider and by staff, and every move is
+// recorded in their history.
+type OrderService struct {
+	orders   ports.OrderStore
+	payments ports.PaymentProvider
+	now      func() time.Time
+}
+
+func NewOrderService(orders ports.OrderStore, payments ports.PaymentProvider) *OrderService {
+	return &OrderService{
+		orders:   orders,
+		payments: payments,
+		now:      time.Now,
+	}
+}
+
+// Place records the pending order of the checkout of a customer with the
+// idempotency key, for the cart priced as cart, and returns it. Orders are
+// placed before their checkout session is opened, so that no session goes
+// without one; checkouts with the same key keep their order.
+func (s *OrderService) Place(
+	ctx context.Context,
+	customerID int,
+	key string,
+	cart domain.PricedCart,
+) (domain.Order, error) {
+	o, err := s.orders.OrderByCheckoutKey(ctx, key)
+	if err == nil {
+		return o, nil
+	}
+
+	if !errors.Is(err, ports.ErrNotFound) {
+		return domain.Order{}, fmt.Errorf("failed to find order: %w", err)
+	}
+
+	lines := make([]domain.OrderLine, 0, len(cart.Lines))
+	for _, l := range cart.Lines {
+		line := domain.OrderLine{
+			ProductID:   l.Product.ID,
+			ProductType: l.Product.Type,
+			Name:        l.Product.Name,
+			Version:     l.Product.Version,
+			UnitPrice:   l.UnitPrice,
+			Quantity:    l.Line.Quantity,
+			Gift:        l.Line.Gift,
+			UpgradeFrom: 0,
+		}
+
+		if l.Upgrade != nil {
+			line.UpgradeFrom = l.Upgrade.FromLicenseID
+		}
+
+		lines = append(lines, line)
+	}
+
+	now := s.now().UTC()
+	o = domain.Order{
+		ID:          0,
+		CustomerID:  customerID,
+		Status:      domain.OrderPending,
+		Lines:       lines,
+		Total:       cart.Total,
+		Refunded:    domain.Money{Amount: 0, Currency: cart.Total.Currency},
+		CheckoutKey: key,
+		CheckoutID:  "",
+		PaymentID:   "",
+		Created:     now,
+		Updated:     now,
+	}
+
+	o, err = s.orders.CreateOrder(ctx, o, domain.OrderTransition{
+		OrderID: 0,
+		From:    "",
+		To:      domain.OrderPending,
+		Actor:   customerActor(customerID),
+		Reason:  "checked out",
+		Time:    now,
+	})
+	if errors.Is(err, ports.ErrConflict) {
+		// The same checkout was placed at the same time.
+		return s.orders.OrderByCheckoutKey(ctx, key)
+	}
+
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	return o, nil
+}
+
+// AttachCheckout records session as the checkout session of o, once it is
+// opened, and returns o with it.
+func (s *OrderService) AttachCheckout(
+	ctx context.Context,
+	o domain.Order,
+	session domain.CheckoutSession,
+) (domain.Order, error) {
+	if o.CheckoutID == session.ID {
+		return o, nil
+	}
+
+	err := s.orders.SetOrderCheckout(ctx, o.ID, session.ID)
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("failed to attach checkout to order: %w", err)
+	}
+
+	o.CheckoutID = session.ID
+
+	return o, nil
+}
+
+// Order returns the order with id.
+func (s *OrderService) Order(ctx context.Context, id int) (domain.Order, error) {
+	o, err := s.orders.Order(ctx, id)
+	if errors.Is(err, ports.ErrNotFound) {
+		return domain.Order{}, ErrOrderNotFound
+	}
+
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("failed to find order: %w", err)
+	}
+
+	return o, nil
+}
+
+// OrderByCheckout returns the order of a checkout session.
+func (s *OrderService) OrderByCheckout(ctx context.Context, checkoutID string) (domain.Order, error) {
+	o, err := s.orders.OrderByCheckout(ctx, checkoutID)
+	if errors.Is(err, ports.ErrNotFound) {
+		return domain.Order{}, ErrOrderNotFound
+	}
+
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("failed to find order: %w", err)
+	}
+
+	return o, nil
+}
+
+// OrderByPayment returns the order of a payment.
+func (s *OrderService) OrderByPayment(ctx context.Context, paymentID string) (domain.Order, error) {
+	o, err := s.orders.OrderByPayment(ctx, paymentID)
+	if errors.Is(err, ports.ErrNotFound) {
+		return domain.Order{}, ErrOrderNotFound
+	}
+
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("failed to find order: %w", err)
+	}
+
+	return o, nil
+}
+
+// CustomerOrder returns the order with id of a customer. The orders of
+// other customers are not found.
+func (s *OrderService) CustomerOrder(ctx context.Context, customerID, id int) (domain.Order, error) {
+	o, err := s.Order(ctx, id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if o.CustomerID != customerID {
+		return domain.Order{}, ErrOrderNotFound
+	}
+
+	return o, nil
+}
+
+// CustomerOrders returns the orders of a customer, most recent first.
+func (s *OrderService) CustomerOrders(ctx context.Context, customerID int) ([]domain.Order, error) {
+	orders, err := s.orders.CustomerOrders(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// Orders returns up to limit orders with status, most recent first.
+func (s *OrderService) Orders(ctx context.Context, status domain.OrderStatus, limit int) ([]domain.Order, error) {
+	orders, err := s.orders.Orders(ctx, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// History returns the transitions of the order with id, oldest first.
+func (s *OrderService) History(ctx context.Context, id int) ([]domain.OrderTransition, error) {
+	transitions, err := s.orders.OrderTransitions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// Fulfill marks a paid order as fulfilled by a member of staff.
+func (s *OrderService) Fulfill(ctx context.Context, staffID, id int, reason string) (domain.Order, error) {
+	o, err := s.Order(ctx, id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	return s.transition(ctx, o, domain.OrderFulfilled, staffActor(staffID), reason)
+}
+
+// Deliver marks a paid order fulfilled once what it bought was delivered
+// without staff, such as the licenses of plugins. Orders that are not
+// paid are left as they are.
+func (s *OrderService) Deliver(ctx context.Context, id int, reason string) (domain.Order, error) {
+	o, err := s.Order(ctx, id)
+	if err != nil || o.Status != domain.OrderPaid {
+		return o, err
+	}
+
+	return s.transition(ctx, o, domain.OrderFulfilled, actorDelivery, reason)
+}
+
+// Cancel cancels an order that was not fulfilled, for a member of staff.
+// Paid orders are refunded what is left of their payment first, and held
+// orders all of it.
+func (s *OrderService) Cancel(ctx context.Context, staffID, id int, reason string) (domain.Order, error) {
+	o, err := s.Order(ctx, id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if !o.Status.CanTransition(domain.OrderCanceled) {
+		return domain.Order{}, &domain.TransitionError{From: o.Status, To: domain.OrderCanceled}
+	}
+
+	if o.Status == domain.OrderHeld {
+		err = s.refundHeld(ctx, o)
+	} else if o.Status != domain.OrderPending {
+		err = s.refund(ctx, &o, domain.Money{Amount: 0, Currency: o.Total.Currency})
+	}
+
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	return s.transition(ctx, o, domain.OrderCanceled, staffActor(staffID), reason)
+}
+
+// Supersede cancels the pending order o in favor of the order by, and
+// expires its checkout session so that it can no longer be paid. It
+// returns ErrOrderPaid when the session was completed meanwhile.
+func (s *OrderService) Supersede(ctx context.Context, o, by domain.Order) error {
+	if o.CheckoutID != "" {
+		session, err := s.payments.ExpireCheckoutSession(ctx, o.CheckoutID)
+		if err != nil {
+			return fmt.Errorf("failed to expire checkout session: %w", err)
+		}
+
+		if session.Status == domain.CheckoutComplete {
+			return ErrOrderPaid
+		}
+	}
+
+	_, err := s.transition(ctx, o, domain.OrderCanceled, customerActor(o.CustomerID),
+		fmt.Sprintf("superseded by order %d", by.ID))
+
+	return err
+}
+
+// Refund refunds amount of the payment of an order for a member of staff,
+// or what is left of it when amount is zero. Orders refunded in whole are
+// refunded, and the others partially refunded.
+func (s *OrderService) Refund(
+	ctx context.Context,
+	staffID, id int,
+	amount domain.Money,
+	reason string,
+) (domain.Order, error) {
+	return s.refundAs(ctx, staffActor(staffID), id, amount, reason)
+}
+
+// RefundUndelivered refunds amount of the payment of an order that could
+// not be delivered, without staff.
+func (s *OrderService) RefundUndelivered(
+	ctx context.Context,
+	id int,
+	amount domain.Money,
+	reason string,
+) (domain.Order, error) {
+	return s.refundAs(ctx, actorDelivery, id, amount, reason)
+}
+
+// refundAs refunds amount of the payment of an order for actor, like
+// Refund.
+func (s *OrderService) refundAs(
+	ctx context.Context,
+	actor string,
+	id int,
+	amount domain.Money,
+	reason string,
+) (domain.Order, error) {
+	o, err := s.Order(ctx, id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	to, err := s.refundStatus(o, amount)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if !o.Status.CanTransition(to) {
+		return domain.Order{}, &domain.TransitionError{From: o.Status, To: to}
+	}
+
+	err = s.refund(ctx, &o, amount)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	return s.transition(ctx, o, to, actor, reason)
+}
+
+// refundStatus returns the status of o once amount of it is refunded, or
+// what is left of it when amount is zero.
+func (s *OrderService) refundStatus(o domain.Order, amount domain.Money) (domain.OrderStatus, error) {
+	left, err := o.Total.Sub(o.Refunded)
+	if err != nil {
+		return "", err
+	}
+
+	if amount.IsZero() {
+		amount = left
+	}
+
+	if amount.Currency != o.Total.Currency || amount.Amount <= 0 || amount.Amount > left.Amount {
+		return "", ErrRefundAmount
+	}
+
+	if amount.Amount == left.Amount {
+		return domain.OrderRefunded, nil
+	}
+
+	return domain.OrderPartiallyRefunded, nil
+}
+
+// refund refunds amount of the payment of o, or what is left of it when
+// amount is zero, and adds it to the refunded amount of o.
+func (s *OrderService) refund(ctx context.Context, o *domain.Order, amount domain.Money) error {
+	left, err := o.Total.Sub(o.Refunded)
+	if err != nil {
+		return err
+	}
+
+	if amount.IsZero() {
+		amount = left
+	}
+
+	if amount.IsZero() {
+		return nil
+	}
+
+	refunded, err := o.Refunded.Add(amount)
+	if err != nil {
+		return err
+	}
+
+	// The key is the same for a refund retried before it is recorded, and
+	// differs for the next one.
+	_, err = s.payments.Refund(ctx, domain.RefundRequest{
+		PaymentID:      o.PaymentID,
+		Amount:         amount,
+		Reason:         domain.RefundRequestedByCustomer,
+		IdempotencyKey: fmt.Sprintf("refund-%d-%d", o.ID, refunded.Amount),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	o.Refunded = refunded
+
+	return nil
+}
+
+// refundHeld refunds the whole payment of a held order. What was paid is
+// not what the order is worth, so the refunded amount of the order is left
+// as it is.
+func (s *OrderService) refundHeld(ctx context.Context, o domain.Order) error {
+	_, err := s.payments.Refund(ctx, domain.RefundRequest{
+		PaymentID:      o.PaymentID,
+		Amount:         domain.Money{Amount: 0, Currency: o.Total.Currency},
+		Reason:         domain.RefundRequestedByCustomer,
+		IdempotencyKey: fmt.Sprintf("refund-%d-held", o.ID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	return nil
+}
+
+// PayOrder handles the events of completed checkouts by marking their
+// order paid, once the payment went through. Payments of another amount or
+// currency than the total of the order, such as when the prices of the
+// payment provider drifted from the catalog, hold the order for staff to
+// review instead.
+func (s *OrderService) PayOrder(ctx context.Context, e domain.PaymentEvent) error {
+	if e.Checkout == nil || e.Checkout.PaymentStatus == domain.PaymentUnpaid {
+		return nil
+	}
+
+	o, err := s.orders.OrderByCheckout(ctx, e.Checkout.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find order of checkout %s: %w", e.Checkout.ID, err)
+	}
+
+	if o.Status != domain.OrderPending {
+		return nil
+	}
+
+	o.PaymentID = e.Checkout.PaymentID
+
+	to, reason := domain.OrderPaid, string(e.Type)
+	if e.Checkout.Total != o.Total {
+		to = domain.OrderHeld
+		reason = fmt.Sprintf("%s: paid %s of %s", e.Type, e.Checkout.Total, o.Total)
+	}
+
+	_, err = s.transition(ctx, o, to, actorPayments, reason)
+
+	return err
+}
+
+// CancelOrder handles the events of expired checkouts, and of payments
+// that failed to go through, by canceling their order.
+func (s *OrderService) CancelOrder(ctx context.Context, e domain.PaymentEvent) error {
+	if e.Checkout == nil {
+		return nil
+	}
+
+	o, err := s.orders.OrderByCheckout(ctx, e.Checkout.ID)
+	if errors.Is(err, ports.ErrNotFound) {
+		// Sessions nobody checked out with have no order to cancel.
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to find order of checkout %s: %w", e.Checkout.ID, err)
+	}
+
+	if o.Status != domain.OrderPending {
+		return nil
+	}
+
+	_, err = s.transition(ctx, o, domain.OrderCanceled, actorPayments, string(e.Type))
+
+	return err
+}
+
+// RefundOrder handles the events of refunded charges by recording the
+// refund in their order. Refunds made from the order, which are recorded
+// already, are left alone. Disputed orders only record the amount, and
+// stay disputed until DisputeOrder closes the dispute.
+func (s *OrderService) RefundOrder(ctx context.Context, e domain.PaymentEvent) error {
+	if e.Charge == nil {
+		return nil
+	}
+
+	o, err := s.orders.OrderByPayment(ctx, e.Charge.PaymentID)
+	if err != nil {
+		return fmt.Errorf("failed to find order of payment %s: %w", e.Charge.PaymentID, err)
+	}
+
+	// What was paid for held orders is not their total, nor in its
+	// currency, so only their refund in whole is recorded. Canceled
+	// orders recorded their refund when they were canceled, and refunded
+	// ones have nothing left to refund, such as after a lost dispute.
+	switch {
+	case o.Status == domain.OrderCanceled, o.Status == domain.OrderRefunded:
+		return nil
+	case o.Status == domain.OrderHeld && !e.Charge.FullyRefunded:
+		return nil
+	}
+
+	if o.Status == domain.OrderHeld {
+		_, err = s.transition(ctx, o, domain.OrderRefunded, actorPayments, string(e.Type))
+		return err
+	}
+
+	refunded := e.Charge.Refunded
+	if o.Status == domain.OrderDisputed && refunded.Currency != o.Total.Currency {
+		// The order was held before the dispute.
+		if !e.Charge.FullyRefunded {
+			return nil
+		}
+
+		refunded = o.Total
+	}
+
+	more, err := refunded.Cmp(o.Refunded)
+	if err != nil || more <= 0 {
+		return err
+	}
+
+	to := domain.OrderPartiallyRefunded
+
+	switch {
+	case o.Status == domain.OrderDisputed:
+		to = domain.OrderDisputed
+	case e.Charge.FullyRefunded:
+		to = domain.OrderRefunded
+	}
+
+	o.Refunded = refunded
+	_, err = s.transition(ctx, o, to, actorPayments, string(e.Type))
+
+	return err
+}
+
+// DisputeOrder handles the events of disputes by marking their order
+// disputed, and moving it back out of it once the dispute is closed: to
+// refunded when it is lost, and when it is won to where it was, or to what
+// the refunds made during the dispute left of it.
+func (s *OrderService) DisputeOrder(ctx context.Context, e domain.PaymentEvent) error {
+	if e.Dispute == nil {
+		return nil
+	}
+
+	o, err := s.orders.OrderByPayment(ctx, e.Dispute.PaymentID)
+	if err != nil {
+		return fmt.Errorf("failed to find order of payment %s: %w", e.Dispute.PaymentID, err)
+	}
+
+	reason := fmt.Sprintf("%s: %s", e.Type, e.Dispute.Reason)
+
+	if e.Type != domain.EventDisputeClosed {
+		if o.Status == domain.OrderDisputed {
+			return nil
+		}
+
+		_, err = s.transition(ctx, o, domain.OrderDisputed, actorPayments, reason)
+
+		return err
+	}
+
+	if o.Status != domain.OrderDisputed {
+		return nil
+	}
+
+	to := domain.OrderRefunded
+	if e.Dispute.Status != domain.DisputeLost {
+		to, err = s.statusAfterDispute(ctx, o)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.transition(ctx, o, to, actorPayments, fmt.Sprintf("%s: %s", e.Type, e.Dispute.Status))
+
+	return err
+}
+
+// statusAfterDispute returns the status o goes back to when its dispute is
+// won: the one it was in when it was last disputed, unless it was refunded
+// since.
+func (s *OrderService) statusAfterDispute(ctx context.Context, o domain.Order) (domain.OrderStatus, error) {
+	history, err := s.History(ctx, o.ID)
+	if err != nil {
+		return "", err
+	}
+
+	refunded := false
+
+	for i := len(history) - 1; i >= 0; i-- {
+		t := history[i]
+
+		switch {
+		case t.To != domain.OrderDisputed:
+		case t.From == domain.OrderDisputed:
+			// A refund made during the dispute.
+			refunded = true
+		case refunded:
+			return refundedStatus(o)
+		default:
+			return t.From, nil
+		}
+	}
+
+	return domain.OrderPaid, nil
+}
+
+// refundedStatus returns refunded when all of the total of o was refunded,
+// and partially refunded otherwise.
+func refundedStatus(o domain.Order) (domain.OrderStatus, error) {
+	full, err := o.Refunded.Cmp(o.Total)
+	if err != nil {
+		return "", err
+	}
+
+	if full >= 0 {
+		return domain.OrderRefunded, nil
+	}
+
+	return domain.OrderPartiallyRefunded, nil
+}
+
+// transition moves o to status to and saves it, with the record of the
+// transition.
+func (s *OrderService) transition(
+	ctx context.Context,
+	o domain.Order,
+	to domain.OrderStatus,
+	actor, reason string,
+) (domain.Order, error) {
+	t, err := o.Transition(to, actor, reason, s.now().UTC())
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	err = s.orders.TransitionOrder(ctx, o, t)
+	if errors.Is(err, ports.ErrNotFound) {
+		return domain.Order{}, ErrOrderNotFound
+	}
+
+	if errors.Is(err, ports.ErrConflict) {
+		return domain.Order{}, ErrOrderChanged
+	}
+
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("failed to save order: %w", err)
+	}
+
+	return o, nil
+}
+
+type OrderError string
+
+func (e OrderError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrOrderNotFound OrderError = "order not found"
+	ErrOrderChanged  OrderError = "order changed meanwhile, try again"
+	ErrOrderPaid     OrderError = "order was paid meanwhile"
+	ErrRefundAmount  OrderError = "refund amount must be positive, in the currency of the order, and at most what is left"
+)
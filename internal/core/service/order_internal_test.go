@@ -0,0 +1,510 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+type orderTest struct {
+	*OrderService
+	payments *fakePayments
+	clock    time.Time
+}
+
+func (o *orderTest) advance(dur time.Duration) {
+	o.clock = o.clock.Add(dur)
+}
+
+func newOrderTest(t *testing.T) *orderTest {
+	t.Helper()
+
+	payments := &fakePayments{requests: nil, refunds: nil, expired: nil, customerKeys: nil, synced: 0, err: nil}
+	o := &orderTest{
+		OrderService: NewOrderService(dal.NewMemoryOrders(), payments),
+		payments:     payments,
+		clock:        time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	o.now = func() time.Time { return o.clock }
+
+	return o
+}
+
+func dollars(amount int64) domain.Money {
+	return domain.Money{Amount: amount, Currency: domain.CurrencyUSD}
+}
+
+// place places the order of a checkout session of customer 1 for a plugin
+// and two shirts, worth 147 dollars.
+func (o *orderTest) place(t *testing.T, sessionID string) (domain.Order, domain.CheckoutSession) {
+	t.Helper()
+
+	carts := newCartTest(t)
+	owner := CartOwner{CustomerID: 1, GuestToken: ""}
+
+	carts.plugin.Version = "1.2.0"
+	assert.NoError(t, carts.products.UpdateProduct(t.Context(), carts.plugin))
+
+	_, err := carts.AddLine(t.Context(), owner, cartLine(carts.plugin.ID, 1, false))
+	assert.NoError(t, err)
+
+	cart, err := carts.AddLine(t.Context(), owner, cartLine(carts.merch.ID, 2, true))
+	assert.NoError(t, err)
+
+	priced, err := carts.Price(t.Context(), cart, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+
+	session := domain.CheckoutSession{
+		ID:                sessionID,
+		URL:               "https://checkout.example.com/" + sessionID,
+		Reference:         cart.ID,
+		CustomerID:        1,
+		PaymentCustomerID: "cus_1",
+		Status:            domain.CheckoutOpen,
+		PaymentStatus:     domain.PaymentUnpaid,
+		PaymentID:         "",
+		Total:             priced.Total,
+		Expires:           time.Time{},
+	}
+
+	order, err := o.Place(t.Context(), 1, "checkout-"+sessionID, priced)
+	assert.NoError(t, err)
+
+	order, err = o.AttachCheckout(t.Context(), order, session)
+	assert.NoError(t, err)
+
+	return order, session
+}
+
+func checkoutEvent(t domain.PaymentEventType, session domain.CheckoutSession) domain.PaymentEvent {
+	return domain.PaymentEvent{ID: "evt", Type: t, Created: time.Time{}, Checkout: &session, Charge: nil, Dispute: nil}
+}
+
+// pay marks the order of session paid in full, with the payment pi_
+// followed by the ID of the session.
+func (o *orderTest) pay(t *testing.T, session domain.CheckoutSession) {
+	t.Helper()
+
+	order, err := o.OrderByCheckout(t.Context(), session.ID)
+	assert.NoError(t, err)
+
+	session.Total = order.Total
+	session.PaymentStatus = domain.PaymentPaid
+	session.PaymentID = "pi_" + session.ID
+
+	assert.NoError(t, o.PayOrder(t.Context(), checkoutEvent(domain.EventCheckoutCompleted, session)))
+}
+
+func TestOrderService_Place(t *testing.T) {
+	svc := newOrderTest(t)
+	ctx := t.Context()
+
+	order, session := svc.place(t, "cs_1")
+	assert.NotEqual(t, order.ID, 0)
+	assert.Equal(t, order.Status, domain.OrderPending)
+	assert.Equal(t, order.CheckoutID, "cs_1")
+	assert.Equal(t, len(order.Lines), 2)
+	assert.Equal(t, order.Lines[0].Name, "microwave")
+	assert.Equal(t, order.Lines[0].Version, "1.2.0")
+	assert.Equal(t, order.Lines[0].UnitPrice, dollars(4900))
+	assert.Equal(t, order.Lines[1].Quantity, 2)
+	assert.True(t, order.Lines[1].Gift)
+	assert.Equal(t, order.Total, dollars(14700))
+	assert.Equal(t, order.Refunded, dollars(0))
+
+	// Placing the same session again keeps its order.
+	again, _ := svc.place(t, session.ID)
+	assert.Equal(t, again.ID, order.ID)
+
+	history, err := svc.History(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(history), 1)
+	assert.Equal(t, history[0].From, "")
+	assert.Equal(t, history[0].To, domain.OrderPending)
+	assert.Equal(t, history[0].Actor, "customer 1")
+
+	orders, err := svc.CustomerOrders(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(orders), 1)
+
+	_, err = svc.CustomerOrder(ctx, 1, order.ID)
+	assert.NoError(t, err)
+
+	_, err = svc.CustomerOrder(ctx, 2, order.ID)
+	assert.Error(t, err, ErrOrderNotFound)
+
+	_, err = svc.Order(ctx, 1000)
+	assert.Error(t, err, ErrOrderNotFound)
+}
+
+// chargeEvent is a refund of the charge of checkout cs_1, having refunded
+// refunded cents of it so far.
+func chargeEvent(refunded int64, full bool) domain.PaymentEvent {
+	return domain.PaymentEvent{
+		ID:       "evt",
+		Type:     domain.EventChargeRefunded,
+		Created:  time.Time{},
+		Checkout: nil,
+		Charge: &domain.Charge{
+			ID:            "ch_1",
+			PaymentID:     "pi_cs_1",
+			Amount:        dollars(14700),
+			Refunded:      dollars(refunded),
+			FullyRefunded: full,
+		},
+		Dispute: nil,
+	}
+}
+
+// disputeEvent is a dispute of the charge of checkout cs_1.
+func disputeEvent(typ domain.PaymentEventType, status string) domain.PaymentEvent {
+	return domain.PaymentEvent{
+		ID:       "evt",
+		Type:     typ,
+		Created:  time.Time{},
+		Checkout: nil,
+		Charge:   nil,
+		Dispute: &domain.Dispute{
+			ID:        "dp_1",
+			ChargeID:  "ch_1",
+			PaymentID: "pi_cs_1",
+			Amount:    dollars(8900),
+			Reason:    "fraudulent",
+			Status:    status,
+		},
+	}
+}
+
+func TestOrderService_PaymentEvents(t *testing.T) {
+	svc := newOrderTest(t)
+	ctx := t.Context()
+
+	order, session := svc.place(t, "cs_1")
+
+	// Sessions still waiting for an asynchronous payment leave it pending.
+	assert.NoError(t, svc.PayOrder(ctx, checkoutEvent(domain.EventCheckoutCompleted, session)))
+
+	got, err := svc.Order(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderPending)
+
+	svc.advance(time.Minute)
+	svc.pay(t, session)
+	svc.pay(t, session)
+
+	got, err = svc.Order(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderPaid)
+	assert.Equal(t, got.PaymentID, "pi_cs_1")
+	assert.True(t, got.Updated.Equal(svc.clock))
+
+	// Paid orders are no longer canceled by the session expiring.
+	assert.NoError(t, svc.CancelOrder(ctx, checkoutEvent(domain.EventCheckoutExpired, session)))
+
+	assert.NoError(t, svc.RefundOrder(ctx, chargeEvent(1000, false)))
+	assert.NoError(t, svc.RefundOrder(ctx, chargeEvent(1000, false)))
+
+	got, err = svc.Order(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderPartiallyRefunded)
+	assert.Equal(t, got.Refunded, dollars(1000))
+
+	assert.NoError(t, svc.DisputeOrder(ctx, disputeEvent(domain.EventDisputeCreated, "needs_response")))
+	assert.NoError(t, svc.DisputeOrder(ctx, disputeEvent(domain.EventDisputeCreated, "needs_response")))
+
+	got, err = svc.Order(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderDisputed)
+
+	// Won disputes move the order back to where it was.
+	assert.NoError(t, svc.DisputeOrder(ctx, disputeEvent(domain.EventDisputeClosed, "won")))
+
+	got, err = svc.Order(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderPartiallyRefunded)
+
+	assert.NoError(t, svc.DisputeOrder(ctx, disputeEvent(domain.EventDisputeCreated, "needs_response")))
+	assert.NoError(t, svc.DisputeOrder(ctx, disputeEvent(domain.EventDisputeClosed, domain.DisputeLost)))
+
+	got, err = svc.Order(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderRefunded)
+
+	history, err := svc.History(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(history), 7)
+	assert.Equal(t, history[1].Actor, actorPayments)
+	assert.Equal(t, history[1].Reason, string(domain.EventCheckoutCompleted))
+
+	// Expired sessions cancel their pending order, and those of sessions
+	// nobody checked out with are ignored.
+	other, session := svc.place(t, "cs_2")
+	assert.NoError(t, svc.CancelOrder(ctx, checkoutEvent(domain.EventCheckoutExpired, session)))
+
+	got, err = svc.Order(ctx, other.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderCanceled)
+
+	session.ID = "cs_3"
+	assert.NoError(t, svc.CancelOrder(ctx, checkoutEvent(domain.EventCheckoutExpired, session)))
+
+	// Refunds of payments of no order are errors, to be retried.
+	event := chargeEvent(100, false)
+	event.Charge.PaymentID = "pi_2"
+	assert.Error(t, svc.RefundOrder(ctx, event), ports.ErrNotFound)
+}
+
+func TestOrderService_RefundsDuringDispute(t *testing.T) {
+	ctx := t.Context()
+
+	for _, tt := range []struct {
+		name     string
+		events   []domain.PaymentEvent
+		want     domain.OrderStatus
+		refunded domain.Money
+	}{
+		{
+			name: "refund then lost",
+			events: []domain.PaymentEvent{
+				disputeEvent(domain.EventDisputeCreated, "needs_response"),
+				chargeEvent(1000, false),
+				disputeEvent(domain.EventDisputeClosed, domain.DisputeLost),
+			},
+			want:     domain.OrderRefunded,
+			refunded: dollars(1000),
+		},
+		{
+			name: "lost then refund",
+			events: []domain.PaymentEvent{
+				disputeEvent(domain.EventDisputeCreated, "needs_response"),
+				disputeEvent(domain.EventDisputeClosed, domain.DisputeLost),
+				chargeEvent(1000, false),
+			},
+			want:     domain.OrderRefunded,
+			refunded: dollars(0),
+		},
+		{
+			name: "partial refund then won",
+			events: []domain.PaymentEvent{
+				disputeEvent(domain.EventDisputeCreated, "needs_response"),
+				chargeEvent(1000, false),
+				disputeEvent(domain.EventDisputeClosed, "won"),
+			},
+			want:     domain.OrderPartiallyRefunded,
+			refunded: dollars(1000),
+		},
+		{
+			name: "full refund then won",
+			events: []domain.PaymentEvent{
+				disputeEvent(domain.EventDisputeCreated, "needs_response"),
+				chargeEvent(14700, true),
+				disputeEvent(domain.EventDisputeClosed, "won"),
+			},
+			want:     domain.OrderRefunded,
+			refunded: dollars(14700),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newOrderTest(t)
+
+			order, session := svc.place(t, "cs_1")
+			svc.pay(t, session)
+
+			for i, e := range tt.events {
+				switch e.Type {
+				case domain.EventChargeRefunded:
+					assert.NoError(t, svc.RefundOrder(ctx, e))
+				default:
+					assert.NoError(t, svc.DisputeOrder(ctx, e))
+				}
+
+				// The order stays disputed until the dispute is closed.
+				if i == 1 && e.Type == domain.EventChargeRefunded {
+					got, err := svc.Order(ctx, order.ID)
+					assert.NoError(t, err)
+					assert.Equal(t, got.Status, domain.OrderDisputed)
+				}
+			}
+
+			got, err := svc.Order(ctx, order.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, got.Status, tt.want)
+			assert.Equal(t, got.Refunded, tt.refunded)
+		})
+	}
+}
+
+func TestOrderService_HoldsMismatchedPayments(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		total domain.Money
+	}{
+		{"underpaid", dollars(9900)},
+		{"wrong currency", domain.Money{Amount: 14700, Currency: domain.CurrencyEUR}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newOrderTest(t)
+			ctx := t.Context()
+
+			order, session := svc.place(t, "cs_1")
+
+			session.Total = tt.total
+			session.PaymentStatus = domain.PaymentPaid
+			session.PaymentID = "pi_cs_1"
+			assert.NoError(t, svc.PayOrder(ctx, checkoutEvent(domain.EventCheckoutCompleted, session)))
+
+			got, err := svc.Order(ctx, order.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, got.Status, domain.OrderHeld)
+			assert.Equal(t, got.PaymentID, "pi_cs_1")
+
+			history, err := svc.History(ctx, order.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, history[1].Reason, fmt.Sprintf("%s: paid %s of %s", domain.EventCheckoutCompleted, tt.total, order.Total))
+
+			// Held orders are neither delivered nor refunded in part.
+			got, err = svc.Deliver(ctx, order.ID, "licenses issued")
+			assert.NoError(t, err)
+			assert.Equal(t, got.Status, domain.OrderHeld)
+
+			_, err = svc.Refund(ctx, 5, order.ID, dollars(1000), "asked")
+			assert.Error(t, err, domain.ErrIllegalTransition)
+
+			// Canceling them refunds whatever was paid.
+			got, err = svc.Cancel(ctx, 5, order.ID, "paid the wrong amount")
+			assert.NoError(t, err)
+			assert.Equal(t, got.Status, domain.OrderCanceled)
+			assert.Equal(t, len(svc.payments.refunds), 1)
+			assert.Equal(t, svc.payments.refunds[0].PaymentID, "pi_cs_1")
+			assert.True(t, svc.payments.refunds[0].Amount.IsZero())
+
+			// The event of that refund changes nothing.
+			assert.NoError(t, svc.RefundOrder(ctx, domain.PaymentEvent{
+				ID:       "evt",
+				Type:     domain.EventChargeRefunded,
+				Created:  time.Time{},
+				Checkout: nil,
+				Charge: &domain.Charge{
+					ID:            "ch_1",
+					PaymentID:     "pi_cs_1",
+					Amount:        tt.total,
+					Refunded:      tt.total,
+					FullyRefunded: true,
+				},
+				Dispute: nil,
+			}))
+		})
+	}
+}
+
+func TestOrderService_StaffActions(t *testing.T) {
+	svc := newOrderTest(t)
+	ctx := t.Context()
+
+	order, session := svc.place(t, "cs_1")
+
+	_, err := svc.Fulfill(ctx, 5, order.ID, "shipped")
+	assert.Error(t, err, domain.ErrIllegalTransition)
+
+	_, err = svc.Refund(ctx, 5, order.ID, dollars(0), "asked")
+	assert.Error(t, err, domain.ErrIllegalTransition)
+	assert.Equal(t, len(svc.payments.refunds), 0)
+
+	svc.pay(t, session)
+
+	_, err = svc.Refund(ctx, 5, order.ID, dollars(-100), "asked")
+	assert.Error(t, err, ErrRefundAmount)
+
+	_, err = svc.Refund(ctx, 5, order.ID, dollars(20000), "asked")
+	assert.Error(t, err, ErrRefundAmount)
+
+	_, err = svc.Refund(ctx, 5, order.ID, domain.Money{Amount: 100, Currency: domain.CurrencyEUR}, "asked")
+	assert.Error(t, err, ErrRefundAmount)
+
+	got, err := svc.Refund(ctx, 5, order.ID, dollars(2500), "shirt too small")
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderPartiallyRefunded)
+	assert.Equal(t, got.Refunded, dollars(2500))
+	assert.Equal(t, svc.payments.refunds[0].PaymentID, "pi_cs_1")
+	assert.Equal(t, svc.payments.refunds[0].Amount, dollars(2500))
+
+	// The event of the refund made above changes nothing.
+	assert.NoError(t, svc.RefundOrder(ctx, domain.PaymentEvent{
+		ID:       "evt",
+		Type:     domain.EventChargeRefunded,
+		Created:  time.Time{},
+		Checkout: nil,
+		Charge: &domain.Charge{
+			ID:            "ch_1",
+			PaymentID:     "pi_cs_1",
+			Amount:        dollars(14700),
+			Refunded:      dollars(2500),
+			FullyRefunded: false,
+		},
+		Dispute: nil,
+	}))
+
+	// Failed refunds leave the order as it was.
+	svc.payments.err = ports.ErrPaymentUnavailable
+
+	_, err = svc.Refund(ctx, 5, order.ID, dollars(0), "asked")
+	assert.Error(t, err, ports.ErrPaymentUnavailable)
+
+	svc.payments.err = nil
+
+	got, err = svc.Refund(ctx, 5, order.ID, dollars(0), "asked")
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderRefunded)
+	assert.Equal(t, got.Refunded, dollars(14700))
+	assert.Equal(t, svc.payments.refunds[1].Amount, dollars(12200))
+	assert.NotEqual(t, svc.payments.refunds[1].IdempotencyKey, svc.payments.refunds[0].IdempotencyKey)
+
+	_, err = svc.Refund(ctx, 5, order.ID, dollars(0), "asked")
+	assert.Error(t, err, ErrRefundAmount)
+
+	history, err := svc.History(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, history[len(history)-1].Actor, "staff 5")
+	assert.Equal(t, history[len(history)-1].Reason, "asked")
+
+	// Canceling paid orders refunds them in full.
+	order, session = svc.place(t, "cs_2")
+	svc.pay(t, session)
+
+	got, err = svc.Cancel(ctx, 5, order.ID, "changed their mind")
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderCanceled)
+	assert.Equal(t, got.Refunded, dollars(14700))
+	assert.Equal(t, len(svc.payments.refunds), 3)
+
+	// Canceling pending orders refunds nothing, and fulfilled orders cannot
+	// be canceled.
+	order, session = svc.place(t, "cs_3")
+
+	got, err = svc.Cancel(ctx, 5, order.ID, "fraud")
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderCanceled)
+	assert.Equal(t, len(svc.payments.refunds), 3)
+
+	order, session = svc.place(t, "cs_4")
+	svc.pay(t, session)
+
+	got, err = svc.Fulfill(ctx, 5, order.ID, "shipped")
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderFulfilled)
+
+	_, err = svc.Cancel(ctx, 5, order.ID, "too late")
+	assert.Error(t, err, domain.ErrIllegalTransition)
+
+	_, err = svc.Fulfill(ctx, 5, 1000, "shipped")
+	assert.Error(t, err, ErrOrderNotFound)
+}
@@ -0,0 +1,355 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// PasswordResetConfig configures password resets.
+type PasswordResetConfig struct {
+	// TTL is how long a reset token can be used.
+	TTL time.Duration
+
+	// ResendInterval is the shortest time between two reset emails to the
+	// same customer. Requests made sooner are silently dropped.
+	ResendInterval time.Duration
+
+	// LinkURL is the page the reset email links to. The token is added to
+	// it as the "token" query parameter.
+	LinkURL string
+
+	// IPLimit and EmailLimit are how many reset requests an IP address and
+	// an email may make until none was made for RequestWindow. Requests
+	// beyond are silently dropped, before the email is looked up.
+	IPLimit       int
+	EmailLimit    int
+	RequestWindow time.Duration
+}
+
+func (c PasswordResetConfig) Validate() error {
+	if c.TTL <= 0 || c.ResendInterval < 0 || c.IPLimit <= 0 || c.EmailLimit <= 0 || c.RequestWindow <= 0 {
+		return ErrPasswordResetConfig
+	}
+
+	u, err := url.Parse(c.LinkURL)
+	if err != nil || !u.IsAbs() {
+		return ErrPasswordResetConfig
+	}
+
+	return nil
+}
+
+func (c PasswordResetConfig) Value() any {
+	return c
+}
+
+// Reauthenticator checks the password of a logged in customer, throttling
+// guesses as logins are.
+type Reauthenticator interface {
+	Reauthenticate(
+		ctx context.Context,
+		email domain.RegisteredCustomerEmail,
+		password domain.RegisteredCustomerPassword,
+		ip string,
+	) (domain.Customer, error)
+}
+
+// PasswordService lets customers change their password, or reset it when
+// they forgot it.
+type PasswordService struct {
+	customers ports.CustomerRepository
+	reauth    Reauthenticator
+	hasher    ports.PasswordHasher
+	breached  ports.BreachedPasswords
+	resets    ports.PasswordResetStore
+	requests  ports.RequestCounts
+	mailer    ports.Mailer
+	sessions  *SessionService
+	devices   *DeviceService
+	audit     ports.AuditLog
+	config    PasswordResetConfig
+	now       func() time.Time
+}
+
+func NewPasswordService(
+	customers ports.CustomerRepository,
+	reauth Reauthenticator,
+	hasher ports.PasswordHasher,
+	breached ports.BreachedPasswords,
+	resets ports.PasswordResetStore,
+	requests ports.RequestCounts,
+	mailer ports.Mailer,
+	sessions *SessionService,
+	devices *DeviceService,
+	audit ports.AuditLog,
+	config PasswordResetConfig,
+) (*PasswordService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasswordService{
+		customers: customers,
+		reauth:    reauth,
+		hasher:    hasher,
+		breached:  breached,
+		resets:    resets,
+		requests:  requests,
+		mailer:    mailer,
+		sessions:  sessions,
+		devices:   devices,
+		audit:     audit,
+		config:    config,
+		now:       time.Now,
+	}, nil
+}
+
+// AdmitReset counts a reset request for email from the IP address ip, and
+// tells whether it may be handled. Requests are counted by address and by
+// email in requests, and an address over its limit is not counted against
+// the email. It does not look the email up, so that it takes as long whether
+// or not the email belongs to a customer.
+func (s *PasswordService) AdmitReset(
+	ctx context.Context,
+	email domain.RegisteredCustomerEmail,
+	ip string,
+) (bool, error) {
+	normalized, err := domain.NormalizeEmail(email.String())
+	if err != nil {
+		return false, nil
+	}
+
+	for _, limit := range []struct {
+		key   string
+		limit int
+	}{
+		{key: "ip:" + ip, limit: s.config.IPLimit},
+		{key: "email:" + normalized, limit: s.config.EmailLimit},
+	} {
+		count, err := s.requests.CountRequest(ctx, limit.key, s.now(), s.config.RequestWindow)
+		if err != nil {
+			return false, fmt.Errorf("failed to count reset request: %w", err)
+		}
+
+		if count > limit.limit {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// SweepRequests forgets the reset requests made before the last
+// RequestWindow, and returns how many keys were forgotten.
+func (s *PasswordService) SweepRequests(ctx context.Context) (int, error) {
+	return s.requests.ForgetRequests(ctx, s.now().Add(-s.config.RequestWindow))
+}
+
+// RequestReset mails a password reset link to the customer with the given
+// email, replacing any link sent before. Emails that belong to no customer
+// are ignored without error, so that callers cannot tell them apart.
+func (s *PasswordService) RequestReset(ctx context.Context, email domain.RegisteredCustomerEmail) error {
+	normalized, err := domain.NormalizeEmail(email.String())
+	if err != nil {
+		return nil
+	}
+
+	c, err := s.customers.CustomerByEmail(ctx, normalized)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	now := s.now().UTC()
+
+	prev, err := s.resets.PasswordReset(ctx, c.ID)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("failed to find password reset: %w", err)
+	case now.Before(prev.Created.Add(s.config.ResendInterval)):
+		return nil
+	}
+
+	token := rand.Text()
+
+	err = s.resets.SavePasswordReset(ctx, domain.PasswordReset{
+		CustomerID: c.ID,
+		TokenHash:  hashResetToken(token),
+		Created:    now,
+		Expires:    now.Add(s.config.TTL),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save password reset: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, domain.Mail{
+		To:      c.Email,
+		Subject: "Reset your password",
+		Text: fmt.Sprintf(
+			"Someone asked to reset the password of your BROKE DA EAR account.\n\n"+
+				"Open the link below to choose a new password. It expires in %s.\n\n%s\n\n"+
+				"If it was not you, you can ignore this email: your password is unchanged.\n",
+			s.config.TTL,
+			tokenLink(s.config.LinkURL, token),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword replaces the password of the customer a reset token was
+// mailed to, and revokes all their sessions and signed in devices. The
+// token can only be used once.
+func (s *PasswordService) ResetPassword(
+	ctx context.Context,
+	token string,
+	password domain.NewCustomerPassword,
+	ip string,
+) error {
+	// The policy is checked first, so that a rejected password does not use
+	// up the token.
+	hash, err := hashNewPassword(ctx, s.hasher, s.breached, password)
+	if err != nil {
+		return err
+	}
+
+	r, err := s.resets.ConsumePasswordReset(ctx, hashResetToken(token))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return ErrResetInvalid
+		}
+
+		return fmt.Errorf("failed to consume password reset: %w", err)
+	}
+
+	if !s.now().Before(r.Expires) {
+		return ErrResetExpired
+	}
+
+	c, err := s.customers.CustomerByID(ctx, r.CustomerID)
+	if err != nil {
+		return fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	err = s.customers.UpdateCustomerPassword(ctx, c.ID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	_, err = s.sessions.RevokeAll(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.devices.RevokeAll(ctx, c.ID, ip)
+	if err != nil {
+		return err
+	}
+
+	return s.record(ctx, domain.AuditPasswordReset, c.Email, ip,
+		"password reset by email, all sessions and devices revoked")
+}
+
+// ChangePassword replaces the password of a logged in customer, after
+// checking their current password. Wrong current passwords are throttled
+// and locked out like failed logins, with a *RetryError. The other sessions
+// and the signed in devices of the customer are revoked; the current
+// session is kept, and must be rotated by the caller.
+func (s *PasswordService) ChangePassword(
+	ctx context.Context,
+	session domain.Session,
+	current domain.RegisteredCustomerPassword,
+	password domain.NewCustomerPassword,
+	ip string,
+) error {
+	c, err := s.customers.CustomerByID(ctx, session.CustomerID)
+	if err != nil {
+		return fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	err = current.Valid()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.reauth.Reauthenticate(ctx, domain.RegisteredCustomerEmail(c.Email), current, ip)
+	if err != nil {
+		return err
+	}
+
+	hash, err := hashNewPassword(ctx, s.hasher, s.breached, password)
+	if err != nil {
+		return err
+	}
+
+	err = s.customers.UpdateCustomerPassword(ctx, c.ID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	_, err = s.sessions.RevokeAll(ctx, c.ID, session.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.devices.RevokeAll(ctx, c.ID, ip)
+	if err != nil {
+		return err
+	}
+
+	return s.record(ctx, domain.AuditPasswordChanged, c.Email, ip,
+		"password changed, other sessions and devices revoked")
+}
+
+func (s *PasswordService) record(ctx context.Context, t domain.AuditEventType, subject, ip, detail string) error {
+	err := s.audit.RecordAudit(ctx, domain.AuditEvent{
+		Type:    t,
+		Subject: subject,
+		IP:      ip,
+		Time:    s.now().UTC(),
+		Detail:  detail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+func hashResetToken(token string) []byte {
+	h := sha256.Sum256([]byte(token))
+	return h[:]
+}
+
+type PasswordError string
+
+func (e PasswordError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrPasswordResetConfig PasswordError = "password reset ttl, request limits and window must be positive, " +
+		"and link url absolute"
+	ErrResetInvalid PasswordError = "password reset token is invalid"
+	ErrResetExpired PasswordError = "password reset token is expired"
+)
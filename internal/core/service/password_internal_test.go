@@ -0,0 +1,359 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/passwords"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+type passwordTest struct {
+	*PasswordService
+	customers *CustomerService
+	sessions  *SessionService
+	devices   *DeviceService
+	audit     *dal.MemoryAuditLog
+	outbox    *outbox
+	customer  domain.Customer
+	clock     time.Time
+}
+
+func (p *passwordTest) advance(d time.Duration) {
+	p.clock = p.clock.Add(d)
+}
+
+func (p *passwordTest) authenticates(t *testing.T, password string) bool {
+	t.Helper()
+
+	_, err := p.customers.Authenticate(t.Context(), "jane@example.com", domain.RegisteredCustomerPassword(password))
+
+	return err == nil
+}
+
+// signInDevice signs a plugin in to the account of the customer through the
+// device grant, and returns its tokens.
+func (p *passwordTest) signInDevice(t *testing.T) DeviceTokens {
+	t.Helper()
+
+	code, err := p.devices.Authorize(t.Context(), "microwave", "", "Studio Mac")
+	assert.NoError(t, err)
+
+	err = p.devices.Approve(t.Context(), p.customer.ID, code.UserCode, "10.0.0.1")
+	assert.NoError(t, err)
+
+	tokens, err := p.devices.Poll(t.Context(), "microwave", code.DeviceCode)
+	assert.NoError(t, err)
+
+	return tokens
+}
+
+func newPasswordTest(t *testing.T) *passwordTest {
+	t.Helper()
+
+	hasher, err := passwords.NewArgon2id(passwords.Argon2idParams{
+		Time:       1,
+		Memory:     64,
+		Threads:    1,
+		SaltLength: 16,
+		KeyLength:  32,
+	})
+	assert.NoError(t, err)
+
+	breached, err := passwords.ReadBreachedList(strings.NewReader("password1234\n"))
+	assert.NoError(t, err)
+
+	repo := dal.NewMemoryCustomers()
+	customers := NewCustomerService(repo, hasher, breached)
+
+	c, err := customers.SignUp(t.Context(), "jane@example.com", "correct horse battery")
+	assert.NoError(t, err)
+
+	sessions, err := NewSessionService(dal.NewMemorySessions(), SessionConfig{
+		IdleTimeout:      time.Hour,
+		AbsoluteLifetime: 3 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	out := &outbox{mails: nil}
+	audit := dal.NewMemoryAuditLog()
+
+	meter := noop.NewMeterProvider().Meter("test")
+	succeeded, err := meter.Int64Counter("succeeded")
+	assert.NoError(t, err)
+	failed, err := meter.Int64Counter("failed")
+	assert.NoError(t, err)
+
+	login, err := NewLoginService(
+		customers,
+		sessions,
+		newTwoFactorService(t, repo, dal.NewMemoryTwoFactor(), sessions, audit),
+		dal.NewMemoryLoginFailures(),
+		audit,
+		LoginMetrics{Succeeded: succeeded, Failed: failed},
+		LoginConfig{
+			AccountThreshold: 3,
+			IPThreshold:      5,
+			BaseDelay:        0,
+			MaxDelay:         0,
+			Lockout:          15 * time.Minute,
+		},
+	)
+	assert.NoError(t, err)
+
+	devices, err := NewDeviceService(repo, dal.NewMemoryDevices(), audit, DeviceConfig{
+		Clients:         []DeviceClient{{ID: "microwave", Name: "Microwave", Scopes: []string{"licenses"}}},
+		VerificationURI: "https://brokedaear.com/device",
+		CodeTTL:         10 * time.Minute,
+		Interval:        5 * time.Second,
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	svc, err := NewPasswordService(repo, login, hasher, breached, dal.NewMemoryPasswordResets(),
+		dal.NewMemoryRequestCounts(), out, sessions, devices, audit,
+		PasswordResetConfig{
+			TTL:            30 * time.Minute,
+			ResendInterval: time.Minute,
+			LinkURL:        "https://brokedaear.com/reset-password",
+			IPLimit:        3,
+			EmailLimit:     2,
+			RequestWindow:  time.Hour,
+		},
+	)
+	assert.NoError(t, err)
+
+	pt := &passwordTest{
+		PasswordService: svc,
+		customers:       customers,
+		sessions:        sessions,
+		devices:         devices,
+		audit:           audit,
+		outbox:          out,
+		customer:        c,
+		clock:           time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	svc.now = func() time.Time { return pt.clock }
+	sessions.now = func() time.Time { return pt.clock }
+	login.now = func() time.Time { return pt.clock }
+	devices.now = func() time.Time { return pt.clock }
+
+	return pt
+}
+
+func TestPasswordService_Reset(t *testing.T) {
+	p := newPasswordTest(t)
+
+	session, _, err := p.sessions.Create(t.Context(), p.customer.ID, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	err = p.RequestReset(t.Context(), "Jane@Example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, len(p.outbox.mails), 1)
+	assert.Equal(t, p.outbox.mails[0].To, "jane@example.com")
+
+	token := p.outbox.token(t)
+
+	// The password policy applies, and a rejected password keeps the token.
+	err = p.ResetPassword(t.Context(), token, "password1234", "10.0.0.1")
+	assert.Error(t, err, domain.ErrPasswordBreached)
+
+	err = p.ResetPassword(t.Context(), token, "short", "10.0.0.1")
+	assert.Error(t, err, domain.ErrPasswordTooShort)
+
+	err = p.ResetPassword(t.Context(), token, "a brand new passphrase", "10.0.0.1")
+	assert.NoError(t, err)
+
+	assert.True(t, p.authenticates(t, "a brand new passphrase"))
+	assert.False(t, p.authenticates(t, "correct horse battery"))
+
+	// Every session is revoked.
+	_, err = p.sessions.Validate(t.Context(), session)
+	assert.Error(t, err, ErrSessionInvalid)
+
+	// Tokens are single use.
+	err = p.ResetPassword(t.Context(), token, "yet another passphrase", "10.0.0.1")
+	assert.Error(t, err, ErrResetInvalid)
+
+	events := p.audit.Events()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, domain.AuditPasswordReset)
+	assert.Equal(t, events[0].Subject, "jane@example.com")
+	assert.Equal(t, events[0].IP, "10.0.0.1")
+}
+
+func TestPasswordService_ResetSignsOutDevices(t *testing.T) {
+	p := newPasswordTest(t)
+	tokens := p.signInDevice(t)
+
+	err := p.RequestReset(t.Context(), "jane@example.com")
+	assert.NoError(t, err)
+
+	err = p.ResetPassword(t.Context(), p.outbox.token(t), "a brand new passphrase", "10.0.0.1")
+	assert.NoError(t, err)
+
+	_, err = p.devices.Refresh(t.Context(), "microwave", tokens.RefreshToken)
+	assert.Error(t, err, ErrInvalidGrant)
+
+	_, err = p.devices.Authenticate(t.Context(), tokens.AccessToken)
+	assert.Error(t, err, ErrAccessTokenInvalid)
+}
+
+func TestPasswordService_ResetUnknownEmail(t *testing.T) {
+	p := newPasswordTest(t)
+
+	err := p.RequestReset(t.Context(), "nobody@example.com")
+	assert.NoError(t, err)
+
+	err = p.RequestReset(t.Context(), "not an email")
+	assert.NoError(t, err)
+
+	assert.Equal(t, len(p.outbox.mails), 0)
+}
+
+func TestPasswordService_ResetExpired(t *testing.T) {
+	p := newPasswordTest(t)
+
+	err := p.RequestReset(t.Context(), "jane@example.com")
+	assert.NoError(t, err)
+
+	p.advance(30 * time.Minute)
+
+	err = p.ResetPassword(t.Context(), p.outbox.token(t), "a brand new passphrase", "")
+	assert.Error(t, err, ErrResetExpired)
+	assert.True(t, p.authenticates(t, "correct horse battery"))
+}
+
+func TestPasswordService_ResetThrottled(t *testing.T) {
+	p := newPasswordTest(t)
+
+	err := p.RequestReset(t.Context(), "jane@example.com")
+	assert.NoError(t, err)
+
+	first := p.outbox.token(t)
+
+	err = p.RequestReset(t.Context(), "jane@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, len(p.outbox.mails), 1)
+
+	p.advance(time.Minute)
+
+	err = p.RequestReset(t.Context(), "jane@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, len(p.outbox.mails), 2)
+
+	// The new link replaces the previous one.
+	err = p.ResetPassword(t.Context(), first, "a brand new passphrase", "")
+	assert.Error(t, err, ErrResetInvalid)
+}
+
+func TestPasswordService_AdmitReset(t *testing.T) {
+	p := newPasswordTest(t)
+
+	admit := func(email domain.RegisteredCustomerEmail, ip string) bool {
+		admitted, err := p.AdmitReset(t.Context(), email, ip)
+		assert.NoError(t, err)
+
+		return admitted
+	}
+
+	assert.True(t, admit("jane@example.com", "10.0.0.1"))
+	assert.True(t, admit("Jane@Example.com", "10.0.0.1"))
+	assert.False(t, admit("jane@example.com", "10.0.0.2"))
+
+	// Once the address is over its limit, its requests are not counted
+	// against the email.
+	assert.True(t, admit("john@example.com", "10.0.0.1"))
+	assert.False(t, admit("john@example.com", "10.0.0.1"))
+	assert.True(t, admit("john@example.com", "10.0.0.3"))
+
+	assert.False(t, admit("not an email", "10.0.0.4"))
+
+	p.advance(time.Hour)
+
+	assert.True(t, admit("jane@example.com", "10.0.0.1"))
+
+	p.advance(time.Minute)
+
+	n, err := p.SweepRequests(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, n, 3)
+}
+
+func TestPasswordService_Change(t *testing.T) {
+	p := newPasswordTest(t)
+
+	_, current, err := p.sessions.Create(t.Context(), p.customer.ID, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	other, _, err := p.sessions.Create(t.Context(), p.customer.ID, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	tokens := p.signInDevice(t)
+
+	err = p.ChangePassword(t.Context(), current, "wrong password", "a brand new passphrase", "10.0.0.1")
+	assert.Error(t, err, domain.ErrInvalidCredentials)
+
+	err = p.ChangePassword(t.Context(), current, "correct horse battery", "password1234", "10.0.0.1")
+	assert.Error(t, err, domain.ErrPasswordBreached)
+
+	err = p.ChangePassword(t.Context(), current, "correct horse battery", "a brand new passphrase", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.True(t, p.authenticates(t, "a brand new passphrase"))
+
+	// The other sessions are revoked, the current one is kept.
+	_, err = p.sessions.Validate(t.Context(), other)
+	assert.Error(t, err, ErrSessionInvalid)
+
+	list, err := p.sessions.List(t.Context(), p.customer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].ID, current.ID)
+
+	// So are the signed in devices.
+	_, err = p.devices.Refresh(t.Context(), "microwave", tokens.RefreshToken)
+	assert.Error(t, err, ErrInvalidGrant)
+
+	events := p.audit.Events()
+	assert.Equal(t, len(events), 3)
+	assert.Equal(t, events[0].Type, domain.AuditDeviceAuthorized)
+	assert.Equal(t, events[1].Type, domain.AuditDeviceRevoked)
+	assert.Equal(t, events[2].Type, domain.AuditPasswordChanged)
+}
+
+func TestPasswordService_ChangeThrottled(t *testing.T) {
+	p := newPasswordTest(t)
+
+	_, current, err := p.sessions.Create(t.Context(), p.customer.ID, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	// Wrong current passwords count as failed logins, and lock the account
+	// out of both.
+	for range 3 {
+		err = p.ChangePassword(t.Context(), current, "wrong password", "a brand new passphrase", "10.0.0.1")
+		assert.Error(t, err, domain.ErrInvalidCredentials)
+	}
+
+	err = p.ChangePassword(t.Context(), current, "correct horse battery", "a brand new passphrase", "10.0.0.1")
+	assert.Error(t, err, ErrAccountLocked)
+	assert.True(t, p.authenticates(t, "correct horse battery"))
+
+	events := p.audit.Events()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, domain.AuditAccountLocked)
+
+	p.advance(15 * time.Minute)
+
+	err = p.ChangePassword(t.Context(), current, "correct horse battery", "a brand new passphrase", "10.0.0.1")
+	assert.NoError(t, err)
+}
@@ -4,34 +4,297 @@
 
 package service
 
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// sessionEncoding encodes session tokens and IDs so that they are safe in
+// cookies and URLs.
 //
-// import (
-// 	"crypto/rand"
-// 	"encoding/base32"
-// 	"errors"
-// 	"time"
-// )
-//
-// func NewSessionToken() string {
-// 	bytes := make([]byte, 15)
-// 	rand.Read(bytes)
-// 	sessionId := base32.StdEncoding.EncodeToString(bytes)
-//
-// 	return sessionId
-// }
-//
-// const sessionExpiresIn = 30 * 24 * time.Hour
-//
-// func validateSession(sessionId string) (*Session, error) {
-// 	session, ok := getSessionFromStorage(sessionId)
-// 	if !ok {
-// 		return nil, errors.New("invalid session id")
-// 	}
-// 	if time.Now().After(session.ExpiresAt) {
-// 		return nil, errors.New("expired session")
-// 	}
-// 	if time.Now().After(session.expiresAt.Sub(sessionExpiresIn / 2)) {
-// 		session.ExpiresAt = time.Now().Add(sessionExpiresIn)
-// 	}
-// 	return session, nil
-// }
+//nolint:gochecknoglobals // Stateless encoding.
+var sessionEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+const (
+	// sessionTokenBytes is the entropy of a session token.
+	sessionTokenBytes = 32
+
+	// sessionIDBytes is the entropy of a public session ID.
+	sessionIDBytes = 15
+)
+
+// NewSessionToken returns a random session token.
+func NewSessionToken() (string, error) {
+	return randomString(sessionTokenBytes)
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return sessionEncoding.EncodeToString(b), nil
+}
+
+// HashSessionToken returns the digest under which a session token is stored.
+// Tokens carry enough entropy that a fast digest cannot be reversed.
+func HashSessionToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// SessionConfig configures the lifetime of sessions.
+type SessionConfig struct {
+	// IdleTimeout is how long a session lasts without being used. Using a
+	// session in the second half of its idle timeout renews it.
+	IdleTimeout time.Duration
+
+	// AbsoluteLifetime is how long a session lasts no matter how often it is
+	// used.
+	AbsoluteLifetime time.Duration
+}
+
+func (c SessionConfig) Validate() error {
+	if c.IdleTimeout <= 0 || c.AbsoluteLifetime < c.IdleTimeout {
+		return ErrSessionConfig
+	}
+
+	return nil
+}
+
+func (c SessionConfig) Value() any {
+	return c
+}
+
+// SessionMeta describes the client a session is issued to.
+type SessionMeta struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionService issues, validates, and revokes sessions.
+type SessionService struct {
+	store  ports.SessionStore
+	config SessionConfig
+	now    func() time.Time
+}
+
+func NewSessionService(store ports.SessionStore, config SessionConfig) (*SessionService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionService{
+		store:  store,
+		config: config,
+		now:    time.Now,
+	}, nil
+}
+
+// Config returns the lifetime configuration of the sessions.
+func (s *SessionService) Config() SessionConfig {
+	return s.config
+}
+
+// Create issues a session to a customer. The returned token is only ever
+// known to the caller; the store keeps its digest.
+func (s *SessionService) Create(ctx context.Context, customerID int, meta SessionMeta) (string, domain.Session, error) {
+	now := s.now().UTC()
+
+	return s.issue(ctx, domain.Session{
+		ID:              "",
+		TokenHash:       nil,
+		CustomerID:      customerID,
+		Created:         now,
+		LastSeen:        now,
+		Expires:         now.Add(s.config.IdleTimeout),
+		AbsoluteExpires: now.Add(s.config.AbsoluteLifetime),
+		UserAgent:       meta.UserAgent,
+		IP:              meta.IP,
+		SteppedUp:       time.Time{},
+	})
+}
+
+// issue stores sess under a fresh ID and token.
+func (s *SessionService) issue(ctx context.Context, sess domain.Session) (string, domain.Session, error) {
+	token, err := NewSessionToken()
+	if err != nil {
+		return "", domain.Session{}, fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	sess.ID, err = randomString(sessionIDBytes)
+	if err != nil {
+		return "", domain.Session{}, fmt.Errorf("failed to generate session id: %w", err)
+	}
+
+	sess.TokenHash = HashSessionToken(token)
+
+	err = s.store.CreateSession(ctx, sess)
+	if err != nil {
+		return "", domain.Session{}, fmt.Errorf("failed to store session: %w", err)
+	}
+
+	return token, sess, nil
+}
+
+// Validate returns the session of a token. An expired session is deleted.
+// A session used in the second half of its idle timeout has its expiry
+// pushed back, but never past its absolute expiry.
+func (s *SessionService) Validate(ctx context.Context, token string) (domain.Session, error) {
+	sess, err := s.store.SessionByTokenHash(ctx, HashSessionToken(token))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return domain.Session{}, ErrSessionInvalid
+		}
+
+		return domain.Session{}, fmt.Errorf("failed to find session: %w", err)
+	}
+
+	now := s.now().UTC()
+
+	if !now.Before(sess.Expires) || !now.Before(sess.AbsoluteExpires) {
+		err = s.store.DeleteSession(ctx, sess.ID)
+		if err != nil && !errors.Is(err, ports.ErrNotFound) {
+			return domain.Session{}, fmt.Errorf("failed to delete expired session: %w", err)
+		}
+
+		return domain.Session{}, ErrSessionExpired
+	}
+
+	sess.LastSeen = now
+
+	if now.After(sess.Expires.Add(-s.config.IdleTimeout / 2)) {
+		sess.Expires = now.Add(s.config.IdleTimeout)
+		if sess.Expires.After(sess.AbsoluteExpires) {
+			sess.Expires = sess.AbsoluteExpires
+		}
+	}
+
+	err = s.store.TouchSession(ctx, sess.ID, sess.LastSeen, sess.Expires)
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return sess, nil
+}
+
+// Rotate replaces the token of a session with a fresh one, which must
+// happen whenever the privileges of the session change: on login, password
+// change and step-up. The session keeps its absolute expiry.
+func (s *SessionService) Rotate(ctx context.Context, token string) (string, domain.Session, error) {
+	sess, err := s.Validate(ctx, token)
+	if err != nil {
+		return "", domain.Session{}, err
+	}
+
+	oldID := sess.ID
+
+	newToken, rotated, err := s.issue(ctx, sess)
+	if err != nil {
+		return "", domain.Session{}, err
+	}
+
+	err = s.store.DeleteSession(ctx, oldID)
+	if err != nil && !errors.Is(err, ports.ErrNotFound) {
+		return "", domain.Session{}, fmt.Errorf("failed to delete rotated session: %w", err)
+	}
+
+	return newToken, rotated, nil
+}
+
+// List returns the active sessions of a customer, most recently used first.
+func (s *SessionService) List(ctx context.Context, customerID int) ([]domain.Session, error) {
+	return s.store.CustomerSessions(ctx, customerID, s.now().UTC())
+}
+
+// Revoke ends the session with the given public ID, which must belong to
+// the customer.
+func (s *SessionService) Revoke(ctx context.Context, customerID int, id string) error {
+	sessions, err := s.store.CustomerSessions(ctx, customerID, s.now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	for _, sess := range sessions {
+		if sess.ID != id {
+			continue
+		}
+
+		err = s.store.DeleteSession(ctx, id)
+		if err != nil && !errors.Is(err, ports.ErrNotFound) {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+
+		return nil
+	}
+
+	return ErrSessionNotFound
+}
+
+// RevokeAll ends every session of a customer but the ones listed in except,
+// and returns how many were ended.
+func (s *SessionService) RevokeAll(ctx context.Context, customerID int, except ...string) (int, error) {
+	return s.store.DeleteCustomerSessions(ctx, customerID, except...)
+}
+
+// Logout ends the session of a token.
+func (s *SessionService) Logout(ctx context.Context, token string) error {
+	sess, err := s.store.SessionByTokenHash(ctx, HashSessionToken(token))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to find session: %w", err)
+	}
+
+	err = s.store.DeleteSession(ctx, sess.ID)
+	if err != nil && !errors.Is(err, ports.ErrNotFound) {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// StepUp records that the customer of a session just proved their second
+// factor, and returns when.
+func (s *SessionService) StepUp(ctx context.Context, id string) (time.Time, error) {
+	now := s.now().UTC()
+
+	err := s.store.StepUpSession(ctx, id, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to step up session: %w", err)
+	}
+
+	return now, nil
+}
+
+// Sweep deletes expired sessions and returns how many were deleted.
+func (s *SessionService) Sweep(ctx context.Context) (int, error) {
+	return s.store.DeleteExpiredSessions(ctx, s.now().UTC())
+}
+
+type SessionError string
+
+func (e SessionError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrSessionConfig   SessionError = "session idle timeout must be positive and within the absolute lifetime"
+	ErrSessionInvalid  SessionError = "session is invalid"
+	ErrSessionExpired  SessionError = "session has expired"
+	ErrSessionNotFound SessionError = "session not found"
+)
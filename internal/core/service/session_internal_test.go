@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+)
+
+type sessionTest struct {
+	*SessionService
+	store *dal.MemorySessions
+	clock time.Time
+}
+
+func (s *sessionTest) advance(d time.Duration) {
+	s.clock = s.clock.Add(d)
+}
+
+func newSessionTest(t *testing.T) *sessionTest {
+	t.Helper()
+
+	store := dal.NewMemorySessions()
+
+	svc, err := NewSessionService(store, SessionConfig{
+		IdleTimeout:      time.Hour,
+		AbsoluteLifetime: 3 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	st := &sessionTest{
+		SessionService: svc,
+		store:          store,
+		clock:          time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	svc.now = func() time.Time { return st.clock }
+
+	return st
+}
+
+func TestSessionService_TokenIsStoredHashed(t *testing.T) {
+	s := newSessionTest(t)
+
+	token, sess, err := s.Create(t.Context(), 1, SessionMeta{UserAgent: "test", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	assert.False(t, bytes.Contains(sess.TokenHash, []byte(token)))
+	assert.True(t, bytes.Equal(sess.TokenHash, HashSessionToken(token)))
+	assert.NotEqual(t, sess.ID, token)
+}
+
+func TestSessionService_SlidingExpiry(t *testing.T) {
+	s := newSessionTest(t)
+
+	token, created, err := s.Create(t.Context(), 1, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	// Used in the first half of the idle timeout, the expiry stays.
+	s.advance(20 * time.Minute)
+	sess, err := s.Validate(t.Context(), token)
+	assert.NoError(t, err)
+	assert.True(t, sess.Expires.Equal(created.Expires))
+
+	// Used in the second half, the expiry is pushed back.
+	s.advance(20 * time.Minute)
+	sess, err = s.Validate(t.Context(), token)
+	assert.NoError(t, err)
+	assert.True(t, sess.Expires.Equal(s.clock.Add(time.Hour)))
+
+	// Left idle, the session expires and is deleted.
+	s.advance(time.Hour)
+	_, err = s.Validate(t.Context(), token)
+	assert.Error(t, err, ErrSessionExpired)
+
+	_, err = s.Validate(t.Context(), token)
+	assert.Error(t, err, ErrSessionInvalid)
+}
+
+func TestSessionService_AbsoluteLifetime(t *testing.T) {
+	s := newSessionTest(t)
+
+	token, created, err := s.Create(t.Context(), 1, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	for s.clock.Add(40 * time.Minute).Before(created.AbsoluteExpires) {
+		s.advance(40 * time.Minute)
+
+		sess, err := s.Validate(t.Context(), token)
+		assert.NoError(t, err)
+		assert.False(t, sess.Expires.After(created.AbsoluteExpires))
+	}
+
+	s.clock = created.AbsoluteExpires
+	_, err = s.Validate(t.Context(), token)
+	assert.Error(t, err, ErrSessionExpired)
+}
+
+func TestSessionService_Rotate(t *testing.T) {
+	s := newSessionTest(t)
+
+	token, created, err := s.Create(t.Context(), 1, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	rotated, sess, err := s.Rotate(t.Context(), token)
+	assert.NoError(t, err)
+	assert.NotEqual(t, rotated, token)
+	assert.NotEqual(t, sess.ID, created.ID)
+	assert.True(t, sess.AbsoluteExpires.Equal(created.AbsoluteExpires))
+
+	_, err = s.Validate(t.Context(), token)
+	assert.Error(t, err, ErrSessionInvalid)
+
+	_, err = s.Validate(t.Context(), rotated)
+	assert.NoError(t, err)
+}
+
+func TestSessionService_ListAndRevoke(t *testing.T) {
+	s := newSessionTest(t)
+
+	_, first, err := s.Create(t.Context(), 1, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	s.advance(time.Minute)
+	_, second, err := s.Create(t.Context(), 1, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	otherToken, other, err := s.Create(t.Context(), 2, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	list, err := s.List(t.Context(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[0].ID, second.ID)
+
+	// A customer cannot revoke the session of another.
+	err = s.Revoke(t.Context(), 1, other.ID)
+	assert.Error(t, err, ErrSessionNotFound)
+
+	_, err = s.Validate(t.Context(), otherToken)
+	assert.NoError(t, err)
+
+	err = s.Revoke(t.Context(), 1, first.ID)
+	assert.NoError(t, err)
+
+	n, err := s.RevokeAll(t.Context(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+}
+
+func TestSessionService_LogoutAndSweep(t *testing.T) {
+	s := newSessionTest(t)
+
+	token, _, err := s.Create(t.Context(), 1, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	err = s.Logout(t.Context(), token)
+	assert.NoError(t, err)
+
+	_, err = s.Validate(t.Context(), token)
+	assert.Error(t, err, ErrSessionInvalid)
+
+	_, _, err = s.Create(t.Context(), 1, SessionMeta{UserAgent: "", IP: ""})
+	assert.NoError(t, err)
+
+	s.advance(2 * time.Hour)
+	n, err := s.Sweep(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, n, 1)
+}
+
+func TestSessionConfig_Validate(t *testing.T) {
+	err := SessionConfig{IdleTimeout: 0, AbsoluteLifetime: time.Hour}.Validate()
+	assert.Error(t, err, ErrSessionConfig)
+
+	err = SessionConfig{IdleTimeout: 2 * time.Hour, AbsoluteLifetime: time.Hour}.Validate()
+	assert.Error(t, err, ErrSessionConfig)
+}
@@ -1,5 +0,0 @@
-// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
-//
-// SPDX-License-Identifier: Apache-2.0
-
-package service
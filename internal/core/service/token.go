@@ -0,0 +1,236 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// TokenConfig configures the JWT access tokens other services accept in
+// place of sessions.
+type TokenConfig struct {
+	// Issuer identifies this app in the iss claim, such as
+	// "https://brokedaear.com".
+	Issuer string
+
+	// Audience identifies the services tokens are meant for in the aud
+	// claim.
+	Audience string
+
+	// TTL is how long a token can be used. Tokens cannot be revoked, so it
+	// should be minutes.
+	TTL time.Duration
+
+	// Rotation is how long a signing key signs before the next one takes
+	// over. Zero disables rotation.
+	Rotation time.Duration
+}
+
+func (c TokenConfig) Validate() error {
+	if c.Issuer == "" || c.Audience == "" || c.TTL <= 0 || c.Rotation < 0 {
+		return ErrTokenConfig
+	}
+
+	return nil
+}
+
+func (c TokenConfig) Value() any {
+	return c
+}
+
+// CustomerClaims are what an access token tells about its customer.
+type CustomerClaims struct {
+	CustomerID int
+	Email      string
+	Verified   bool
+
+	// Scopes are the scopes granted to the device the token was issued to.
+	// Tokens issued to the website have none, and grant every scope.
+	Scopes []string
+
+	// ClientID is the plugin the token was issued to, or empty for the
+	// website.
+	ClientID string
+
+	// TokenID uniquely identifies the token, such as in logs.
+	TokenID string
+
+	Expires time.Time
+}
+
+// HasScope reports whether the token grants scope.
+func (c CustomerClaims) HasScope(scope string) bool {
+	return len(c.Scopes) == 0 || slices.Contains(c.Scopes, scope)
+}
+
+// AccessToken is a signed access token and what it claims.
+type AccessToken struct {
+	Token  string
+	Claims CustomerClaims
+}
+
+// accessClaims is the payload of access tokens.
+type accessClaims struct {
+	jwt.RegisteredClaims
+
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Scope         string `json:"scope,omitempty"`
+	ClientID      string `json:"client_id,omitempty"`
+}
+
+// TokenService issues short-lived JWT access tokens, signed with Ed25519
+// keys that rotate on schedule, and verifies them. Other services verify
+// them with the published key set alone, without a round trip to this app.
+type TokenService struct {
+	customers ports.CustomerRepository
+	ring      *jwt.KeyRing
+	config    TokenConfig
+	now       func() time.Time
+}
+
+// NewTokenService returns a token service signing with keys[0] and
+// verifying with every key, such as keys loaded from files. Without keys,
+// a random key signs, and tokens do not outlive the process.
+func NewTokenService(customers ports.CustomerRepository, config TokenConfig, keys ...jwt.Key) (*TokenService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	// Retired keys verify for as long as the tokens they signed may be
+	// used.
+	ring, err := jwt.NewKeyRing(
+		jwt.RingConfig{Rotation: config.Rotation, Retention: config.TTL + jwt.Leeway},
+		time.Now(),
+		keys...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenService{
+		customers: customers,
+		ring:      ring,
+		config:    config,
+		now:       time.Now,
+	}, nil
+}
+
+// Issue returns an access token for a customer. A plugin signed in through
+// the device authorization grant passes its client ID and scopes; the
+// website passes neither.
+func (s *TokenService) Issue(ctx context.Context, customerID int, clientID string, scopes []string) (AccessToken, error) {
+	c, err := s.customers.CustomerByID(ctx, customerID)
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	now := s.now()
+	expires := now.Add(s.config.TTL)
+
+	claims := accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.config.Issuer,
+			Subject:   strconv.Itoa(c.ID),
+			Audience:  s.config.Audience,
+			Expires:   expires.Unix(),
+			NotBefore: now.Unix(),
+			IssuedAt:  now.Unix(),
+			ID:        rand.Text(),
+		},
+		Email:         c.Email,
+		EmailVerified: c.Verified,
+		Scope:         strings.Join(scopes, " "),
+		ClientID:      clientID,
+	}
+
+	token, err := s.ring.Sign(claims)
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return AccessToken{
+		Token:  token,
+		Claims: customerClaims(claims),
+	}, nil
+}
+
+// Verify returns the claims of an access token issued by this app. It
+// returns ErrTokenInvalid for forged or foreign tokens, and
+// ErrTokenExpired for tokens past their expiry.
+func (s *TokenService) Verify(_ context.Context, token string) (CustomerClaims, error) {
+	var claims accessClaims
+
+	err := s.ring.Verify(token, &claims)
+	if err != nil {
+		return CustomerClaims{}, ErrTokenInvalid
+	}
+
+	err = claims.Validate(s.now(), s.config.Issuer, s.config.Audience)
+	if errors.Is(err, jwt.ErrExpired) {
+		return CustomerClaims{}, ErrTokenExpired
+	}
+
+	if err != nil {
+		return CustomerClaims{}, ErrTokenInvalid
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil || id <= 0 {
+		return CustomerClaims{}, ErrTokenInvalid
+	}
+
+	return customerClaims(claims), nil
+}
+
+// JWKS returns the public keys that verify access tokens, as published at
+// /.well-known/jwks.json.
+func (s *TokenService) JWKS() jwt.JWKS {
+	return s.ring.JWKS()
+}
+
+// Rotate rotates the signing keys when they are due. It is meant to be
+// called periodically, more often than the rotation interval.
+func (s *TokenService) Rotate(_ context.Context) error {
+	s.ring.Rotate(s.now())
+	return nil
+}
+
+func customerClaims(c accessClaims) CustomerClaims {
+	id, _ := strconv.Atoi(c.Subject)
+
+	return CustomerClaims{
+		CustomerID: id,
+		Email:      c.Email,
+		Verified:   c.EmailVerified,
+		Scopes:     strings.Fields(c.Scope),
+		ClientID:   c.ClientID,
+		TokenID:    c.ID,
+		Expires:    time.Unix(c.Expires, 0),
+	}
+}
+
+type TokenError string
+
+func (e TokenError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrTokenConfig  TokenError = "token issuer and audience must be set, ttl positive and rotation not negative"
+	ErrTokenInvalid TokenError = "access token is invalid"
+	ErrTokenExpired TokenError = "access token is expired"
+)
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/jwt"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+func newTokenConfig() TokenConfig {
+	return TokenConfig{
+		Issuer:   "https://brokedaear.com",
+		Audience: "brokedaear",
+		TTL:      5 * time.Minute,
+		Rotation: time.Hour,
+	}
+}
+
+func TestTokenService_IssueVerify(t *testing.T) {
+	customers := dal.NewMemoryCustomers()
+
+	c, err := customers.CreateCustomer(t.Context(), domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          true,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	svc, err := NewTokenService(customers, newTokenConfig())
+	assert.NoError(t, err)
+
+	// The key ring starts at the real time, so the fake clock does too.
+	clock := time.Now()
+	svc.now = func() time.Time { return clock }
+
+	website, err := svc.Issue(t.Context(), c.ID, "", nil)
+	assert.NoError(t, err)
+	assert.True(t, website.Claims.HasScope("licenses"))
+
+	plugin, err := svc.Issue(t.Context(), c.ID, "microwave", []string{"profile"})
+	assert.NoError(t, err)
+
+	claims, err := svc.Verify(t.Context(), plugin.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, claims.CustomerID, c.ID)
+	assert.Equal(t, claims.Email, "jane@example.com")
+	assert.True(t, claims.Verified)
+	assert.Equal(t, claims.ClientID, "microwave")
+	assert.True(t, claims.HasScope("profile"))
+	assert.False(t, claims.HasScope("licenses"))
+	assert.Equal(t, claims.TokenID, plugin.Claims.TokenID)
+
+	// Other services verify with the published keys alone.
+	var payload accessClaims
+
+	err = svc.JWKS().Verify(plugin.Token, &payload)
+	assert.NoError(t, err)
+	assert.NoError(t, payload.Validate(clock, "https://brokedaear.com", "brokedaear"))
+
+	_, err = svc.Issue(t.Context(), 404, "", nil)
+	assert.Error(t, err, ports.ErrNotFound)
+
+	_, err = svc.Verify(t.Context(), plugin.Token+"x")
+	assert.Error(t, err, ErrTokenInvalid)
+
+	// Tokens signed by other keys or for another audience are foreign.
+	other, err := NewTokenService(customers, newTokenConfig(), jwt.NewKey())
+	assert.NoError(t, err)
+
+	_, err = other.Verify(t.Context(), plugin.Token)
+	assert.Error(t, err, ErrTokenInvalid)
+
+	config := newTokenConfig()
+	config.Audience = "other"
+
+	key := jwt.NewKey()
+
+	foreign, err := NewTokenService(customers, config, key)
+	assert.NoError(t, err)
+
+	strict, err := NewTokenService(customers, newTokenConfig(), key)
+	assert.NoError(t, err)
+
+	token, err := foreign.Issue(t.Context(), c.ID, "", nil)
+	assert.NoError(t, err)
+
+	_, err = strict.Verify(t.Context(), token.Token)
+	assert.Error(t, err, ErrTokenInvalid)
+
+	clock = clock.Add(5*time.Minute + jwt.Leeway)
+
+	_, err = svc.Verify(t.Context(), plugin.Token)
+	assert.Error(t, err, ErrTokenExpired)
+}
+
+func TestTokenService_Rotate(t *testing.T) {
+	customers := dal.NewMemoryCustomers()
+
+	c, err := customers.CreateCustomer(t.Context(), domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          false,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	svc, err := NewTokenService(customers, newTokenConfig())
+	assert.NoError(t, err)
+
+	clock := time.Now()
+	svc.now = func() time.Time { return clock }
+
+	before, err := svc.Issue(t.Context(), c.ID, "", nil)
+	assert.NoError(t, err)
+
+	clock = clock.Add(time.Hour + time.Second)
+	assert.NoError(t, svc.Rotate(t.Context()))
+
+	after, err := svc.Issue(t.Context(), c.ID, "", nil)
+	assert.NoError(t, err)
+
+	// The keys changed, yet the older token still verifies with the keys
+	// retired by the rotation until it expires.
+	assert.Equal(t, len(svc.JWKS().Keys), 3)
+
+	_, err = svc.Verify(t.Context(), after.Token)
+	assert.NoError(t, err)
+
+	var payload accessClaims
+
+	assert.NoError(t, svc.JWKS().Verify(before.Token, &payload))
+
+	clock = clock.Add(10 * time.Minute)
+	assert.NoError(t, svc.Rotate(t.Context()))
+	assert.Error(t, svc.JWKS().Verify(before.Token, &payload), jwt.ErrUnknownKey)
+}
+
+func TestTokenConfig_Validate(t *testing.T) {
+	config := newTokenConfig()
+	assert.NoError(t, config.Validate())
+
+	config.TTL = 0
+	assert.Error(t, config.Validate(), ErrTokenConfig)
+
+	config = newTokenConfig()
+	config.Issuer = ""
+	assert.Error(t, config.Validate(), ErrTokenConfig)
+}
@@ -0,0 +1,264 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// TrialConfig configures the trials of plugins.
+type TrialConfig struct {
+	// Duration is how long trials last once started.
+	Duration time.Duration
+}
+
+func (c TrialConfig) Validate() error {
+	if c.Duration <= 0 {
+		return ErrTrialConfig
+	}
+
+	return nil
+}
+
+func (c TrialConfig) Value() any {
+	return c
+}
+
+// TrialMetrics counts the trials started and converted. Both carry a
+// product_id attribute, so that conversion rates are told by product.
+type TrialMetrics struct {
+	Started   otelmetric.Int64Counter
+	Converted otelmetric.Int64Counter
+}
+
+// TrialService lets customers try plugins before buying them. A trial is a
+// license of the trial edition that expires, started by a customer on a
+// machine and activated there. Only verified customers may start trials,
+// and each customer and each machine tries a plugin once. Buying the
+// plugin converts the trial into a license of the order, with the same key
+// and activations, so that plugins pick up the purchase on their next
+// refresh without being activated again. Converted trials expire like the
+// licenses issued by the license service, as configured by licenseConfig.
+type TrialService struct {
+	customers     ports.CustomerRepository
+	licenses      ports.LicenseStore
+	orders        *OrderService
+	activations   *ActivationService
+	products      ports.ProductRepository
+	metrics       TrialMetrics
+	licenseConfig LicenseConfig
+	config        TrialConfig
+	now           func() time.Time
+}
+
+func NewTrialService(
+	customers ports.CustomerRepository,
+	licenses ports.LicenseStore,
+	orders *OrderService,
+	activations *ActivationService,
+	products ports.ProductRepository,
+	metrics TrialMetrics,
+	licenseConfig LicenseConfig,
+	config TrialConfig,
+) (*TrialService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TrialService{
+		customers:     customers,
+		licenses:      licenses,
+		orders:        orders,
+		activations:   activations,
+		products:      products,
+		metrics:       metrics,
+		licenseConfig: licenseConfig,
+		config:        config,
+		now:           time.Now,
+	}, nil
+}
+
+// StartTrial starts a trial of a plugin for a customer, and activates it
+// on machine. It returns ErrCustomerUnverified when the customer has not
+// verified their email, ErrTrialUsed when the customer or the machine
+// tried the plugin before, and ErrLicenseOwned when the customer owns a
+// license of it.
+func (s *TrialService) StartTrial(
+	ctx context.Context,
+	customerID, productID int,
+	machine, name string,
+) (SignedActivation, error) {
+	err := domain.ValidateMachine(machine, name)
+	if err != nil {
+		return SignedActivation{}, err
+	}
+
+	c, err := s.customers.CustomerByID(ctx, customerID)
+	if err != nil {
+		return SignedActivation{}, fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	if !c.Verified {
+		return SignedActivation{}, ErrCustomerUnverified
+	}
+
+	p, err := s.products.ProductByID(ctx, productID)
+	if errors.Is(err, ports.ErrNotFound) || err == nil && !p.Published {
+		return SignedActivation{}, ErrProductNotFound
+	}
+
+	if err != nil {
+		return SignedActivation{}, fmt.Errorf("failed to find product: %w", err)
+	}
+
+	if p.Type != domain.ProductPlugin {
+		return SignedActivation{}, ErrTrialUnavailable
+	}
+
+	owned, err := s.licenses.CustomerLicenses(ctx, customerID)
+	if err != nil {
+		return SignedActivation{}, fmt.Errorf("failed to list licenses: %w", err)
+	}
+
+	now := s.now().UTC()
+
+	for _, l := range owned {
+		if l.ProductID == productID && !l.Trial() && l.Valid(now) {
+			return SignedActivation{}, ErrLicenseOwned
+		}
+	}
+
+	l, err := s.licenses.CreateTrial(ctx, domain.License{
+		ID:           0,
+		Key:          domain.NewLicenseKey(),
+		OrderID:      0,
+		Line:         0,
+		Seat:         0,
+		CustomerID:   customerID,
+		ProductID:    productID,
+		Version:      p.Version,
+		Edition:      domain.EditionTrial,
+		Issued:       now,
+		Expires:      now.Add(s.config.Duration),
+		Revoked:      time.Time{},
+		RevokeReason: "",
+		UpgradedFrom: 0,
+	}, machine)
+	if errors.Is(err, ports.ErrConflict) {
+		return SignedActivation{}, ErrTrialUsed
+	}
+
+	if err != nil {
+		return SignedActivation{}, fmt.Errorf("failed to create trial: %w", err)
+	}
+
+	// A trial that cannot be activated is abandoned, so that the customer
+	// may start it again rather than having spent it on no machine.
+	a, err := s.activations.Activate(ctx, l.Key, machine, name)
+	if err != nil {
+		abandonErr := s.licenses.AbandonTrial(ctx, l.ID, s.now().UTC(), "activation failed")
+		if abandonErr != nil {
+			return SignedActivation{}, errors.Join(err, fmt.Errorf("failed to abandon trial: %w", abandonErr))
+		}
+
+		return SignedActivation{}, err
+	}
+
+	s.metrics.Started.Add(ctx, 1, otelmetric.WithAttributes(attribute.Int("product_id", productID)))
+
+	return a, nil
+}
+
+// ConvertTrials handles the events of completed checkouts by converting the
+// trials of the plugins of their order, once it is paid, into licenses of
+// the order. It runs before LicenseService.IssueLicenses, which then issues
+// licenses for the plugins that were not tried. Gifts leave the trials of
+// the buyer as they are, and so do revoked trials.
+func (s *TrialService) ConvertTrials(ctx context.Context, e domain.PaymentEvent) error {
+	if e.Checkout == nil || e.Checkout.PaymentStatus == domain.PaymentUnpaid {
+		return nil
+	}
+
+	o, err := s.orders.OrderByCheckout(ctx, e.Checkout.ID)
+	if err != nil {
+		return err
+	}
+
+	if o.Status != domain.OrderPaid && o.Status != domain.OrderFulfilled {
+		return nil
+	}
+
+	licenses, err := s.licenses.CustomerLicenses(ctx, o.CustomerID)
+	if err != nil {
+		return fmt.Errorf("failed to list licenses: %w", err)
+	}
+
+	trials := make(map[int]domain.License)
+
+	for _, l := range licenses {
+		if l.Trial() && l.Revoked.IsZero() {
+			trials[l.ProductID] = l
+		}
+	}
+
+	now := s.now().UTC()
+
+	for i, line := range o.Lines {
+		l, ok := trials[line.ProductID]
+		if !ok || line.Gift || line.ProductType != domain.ProductPlugin {
+			continue
+		}
+
+		// The trial becomes the first license of the line.
+		l.OrderID = o.ID
+		l.Line = i
+		l.Seat = 0
+		l.Version = line.Version
+		l.Edition = domain.EditionStandard
+		l.UpgradedFrom = line.UpgradeFrom
+		l.Issued = now
+		l.Expires = s.licenseConfig.Expires(now)
+
+		// The trial was converted meanwhile, by the same event processed
+		// twice or by another order, or the line has its license already:
+		// either way, IssueLicenses makes sure it has one.
+		err = s.licenses.ConvertTrial(ctx, l)
+		if errors.Is(err, ports.ErrNotFound) || errors.Is(err, ports.ErrConflict) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to convert trial: %w", err)
+		}
+
+		delete(trials, line.ProductID)
+		s.metrics.Converted.Add(ctx, 1, otelmetric.WithAttributes(attribute.Int("product_id", line.ProductID)))
+	}
+
+	return nil
+}
+
+type TrialError string
+
+func (e TrialError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrTrialConfig      TrialError = "trial duration must be positive"
+	ErrTrialUnavailable TrialError = "only plugins can be tried"
+	ErrTrialUsed        TrialError = "this plugin was tried already on this account or machine"
+	ErrLicenseOwned     TrialError = "you own a license of this plugin already"
+)
@@ -0,0 +1,285 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+type trialTest struct {
+	*TrialService
+	activations *activationTest
+	reader      *sdkmetric.ManualReader
+}
+
+// newTrialTest returns a trial service over the plugins of newActivationTest:
+// customer 1 owns the first, and customer 2 the second.
+func newTrialTest(t *testing.T) *trialTest {
+	t.Helper()
+
+	activations := newActivationTest(t, activationConfig())
+
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	started, err := meter.Int64Counter("started")
+	assert.NoError(t, err)
+	converted, err := meter.Int64Counter("converted")
+	assert.NoError(t, err)
+
+	// Customers 1 to 3 verified their email, and customer 4 did not.
+	customers := dal.NewMemoryCustomers()
+
+	for i := 1; i <= 4; i++ {
+		_, err := customers.CreateCustomer(t.Context(), domain.Customer{
+			ID:                0,
+			Email:             fmt.Sprintf("customer%d@example.com", i),
+			HashedPassword:    nil,
+			Created:           time.Time{},
+			Verified:          i < 4,
+			PaymentCustomerID: "",
+		})
+		assert.NoError(t, err)
+	}
+
+	svc, err := NewTrialService(
+		customers,
+		activations.licenses.licenses,
+		activations.licenses.orders.OrderService,
+		activations.ActivationService,
+		activations.products,
+		TrialMetrics{Started: started, Converted: converted},
+		activations.licenses.config,
+		TrialConfig{Duration: 14 * 24 * time.Hour},
+	)
+	assert.NoError(t, err)
+
+	svc.now = func() time.Time { return activations.clock }
+
+	return &trialTest{TrialService: svc, activations: activations, reader: reader}
+}
+
+// count returns the sum of the counter named name.
+func (tt *trialTest) count(t *testing.T, name string) int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, tt.reader.Collect(t.Context(), &rm))
+
+	var sum int64
+
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			data, ok := m.Data.(metricdata.Sum[int64])
+			if m.Name != name || !ok {
+				continue
+			}
+
+			for _, point := range data.DataPoints {
+				sum += point.Value
+			}
+		}
+	}
+
+	return sum
+}
+
+func TestNewTrialService(t *testing.T) {
+	_, err := NewTrialService(nil, nil, nil, nil, nil, TrialMetrics{Started: nil, Converted: nil}, licenseConfig(),
+		TrialConfig{Duration: 0})
+	assert.Error(t, err, ErrTrialConfig)
+}
+
+func TestTrialService_StartTrial(t *testing.T) {
+	svc := newTrialTest(t)
+	ctx := t.Context()
+	clock := svc.activations.clock
+
+	const studio, laptop = "studio-fingerprint-1", "laptop-fingerprint-2"
+
+	oven := svc.activations.oven
+
+	trial, err := svc.StartTrial(ctx, 1, oven, studio, "Studio Mac")
+	assert.NoError(t, err)
+	assert.Equal(t, trial.CustomerID, 1)
+	assert.Equal(t, trial.ProductID, oven)
+	assert.Equal(t, svc.count(t, "started"), 1)
+
+	// The activation of a trial lasts no longer than the trial, which its
+	// signed license tells.
+	claims, err := svc.activations.Verify(trial.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, claims.Edition, domain.EditionTrial)
+
+	signed, err := svc.activations.licenses.Lookup(ctx, claims.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, signed.OrderID, 0)
+	assert.True(t, signed.Expires.Equal(clock.Add(14*24*time.Hour)))
+
+	license, err := svc.activations.licenses.Verify(signed.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, license.Edition, domain.EditionTrial)
+	assert.True(t, license.Expires.Equal(signed.Expires))
+
+	merch, err := svc.activations.products.CreateProduct(ctx, domain.Product{
+		ID:           0,
+		Type:         domain.ProductMerchandise,
+		Slug:         "shirt",
+		Name:         "Shirt",
+		Description:  "",
+		Version:      "",
+		MachineLimit: 0,
+		Media:        nil,
+		Published:    true,
+		Position:     0,
+		Prices:       nil,
+		PriceID:      "",
+		ProductID:    "",
+		Created:      clock,
+		Updated:      clock,
+	})
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name                  string
+		customerID, productID int
+		machine               string
+		want                  error
+	}{
+		{"customer tried", 1, oven, laptop, ErrTrialUsed},
+		{"machine tried", 3, oven, studio, ErrTrialUsed},
+		{"unverified", 4, oven, laptop, ErrCustomerUnverified},
+		{"owned", 2, oven, laptop, ErrLicenseOwned},
+		{"owned with limit", 1, svc.activations.microwave, laptop, ErrLicenseOwned},
+		{"unknown product", 1, 1000, laptop, ErrProductNotFound},
+		{"merch", 1, merch.ID, laptop, ErrTrialUnavailable},
+		{"invalid machine", 1, oven, "short", domain.ErrInvalidMachine},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.StartTrial(ctx, tt.customerID, tt.productID, tt.machine, "")
+			assert.Error(t, err, tt.want)
+		})
+	}
+
+	assert.Equal(t, svc.count(t, "started"), 1)
+
+	// Trials end with their license.
+	svc.activations.advance(15 * 24 * time.Hour)
+
+	_, err = svc.activations.Activate(ctx, claims.Key, studio, "Studio Mac")
+	assert.Error(t, err, ErrLicenseInactive)
+}
+
+// failingActivations fails to create activations with err.
+type failingActivations struct {
+	ports.ActivationStore
+
+	err error
+}
+
+func (f failingActivations) CreateActivation(context.Context, domain.Activation, int) (domain.Activation, error) {
+	return domain.Activation{}, f.err
+}
+
+func TestTrialService_StartTrialActivationFails(t *testing.T) {
+	svc := newTrialTest(t)
+	ctx := t.Context()
+	activations := svc.activations.ActivationService
+	store := activations.activations
+	failure := errors.New("database is down")
+
+	activations.activations = failingActivations{ActivationStore: store, err: failure}
+
+	_, err := svc.StartTrial(ctx, 1, svc.activations.oven, "studio-fingerprint-1", "Studio Mac")
+	assert.Error(t, err, failure)
+	assert.Equal(t, svc.count(t, "started"), 0)
+
+	// The trial was abandoned rather than spent, and starts once the
+	// machine can be activated.
+	licenses, err := svc.licenses.CustomerLicenses(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, licenses[len(licenses)-1].RevokeReason, "activation failed")
+
+	activations.activations = store
+
+	trial, err := svc.StartTrial(ctx, 1, svc.activations.oven, "studio-fingerprint-1", "Studio Mac")
+	assert.NoError(t, err)
+	assert.Equal(t, trial.ProductID, svc.activations.oven)
+	assert.Equal(t, svc.count(t, "started"), 1)
+}
+
+func TestTrialService_ConvertTrials(t *testing.T) {
+	svc := newTrialTest(t)
+	ctx := t.Context()
+	licenses := svc.activations.licenses
+	oven := svc.activations.oven
+
+	trial, err := svc.StartTrial(ctx, 1, oven, "studio-fingerprint-1", "Studio Mac")
+	assert.NoError(t, err)
+
+	session := domain.CheckoutSession{
+		ID:                "cs_1",
+		URL:               "https://checkout.example.com/cs_1",
+		Reference:         "",
+		CustomerID:        1,
+		PaymentCustomerID: "cus_1",
+		Status:            domain.CheckoutOpen,
+		PaymentStatus:     domain.PaymentUnpaid,
+		PaymentID:         "",
+		Total:             dollars(4900),
+		Expires:           time.Time{},
+	}
+	order := licenses.placePlugin(t, session, oven)
+
+	// Nothing is converted before the order is paid.
+	assert.NoError(t, svc.ConvertTrials(ctx, checkoutEvent(domain.EventCheckoutCompleted, session)))
+	assert.Equal(t, svc.count(t, "converted"), 0)
+
+	licenses.orders.pay(t, session)
+	assert.NoError(t, svc.ConvertTrials(ctx, paidEvent(session)))
+	assert.NoError(t, svc.ConvertTrials(ctx, paidEvent(session)))
+	assert.NoError(t, licenses.IssueLicenses(ctx, paidEvent(session)))
+	assert.Equal(t, svc.count(t, "converted"), 1)
+
+	// The trial became the license of the order, with the same key.
+	bought, err := licenses.OrderLicenses(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(bought), 1)
+	assert.Equal(t, bought[0].ID, trial.LicenseID)
+	assert.Equal(t, bought[0].Edition, domain.EditionStandard)
+	assert.True(t, bought[0].Expires.IsZero())
+
+	got, err := licenses.orders.Order(ctx, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderFulfilled)
+
+	// The machine stays activated, and its next refresh unlocks the
+	// plugin for good.
+	svc.activations.advance(15 * 24 * time.Hour)
+
+	refreshed, err := svc.activations.Activate(ctx, bought[0].Key, "studio-fingerprint-1", "Studio Mac")
+	assert.NoError(t, err)
+	assert.Equal(t, refreshed.ID, trial.ID)
+
+	claims, err := svc.activations.Verify(refreshed.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, claims.Edition, domain.EditionStandard)
+	assert.Equal(t, claims.Key, bought[0].Key)
+
+	_, err = svc.StartTrial(ctx, 1, oven, "laptop-fingerprint-2", "")
+	assert.Error(t, err, ErrLicenseOwned)
+}
@@ -0,0 +1,585 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/common/encryption"
+	"backend.brokedaear.com/internal/common/otp"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+const (
+	// RecoveryCodeCount is the number of recovery codes a customer is given.
+	RecoveryCodeCount = 10
+
+	// recoveryCodeLength is the number of random bytes of a recovery code,
+	// which encode to 16 base32 characters.
+	recoveryCodeLength = 10
+
+	// challengePayloadLength is the length of a sealed login challenge: the
+	// customer ID and the expiry.
+	challengePayloadLength = 8 + 8
+
+	// challengeAAD binds sealed login challenges to their purpose, so that
+	// no other sealed value can pass for one.
+	challengeAAD = "login challenge"
+)
+
+// TwoFactorConfig configures two-factor authentication.
+type TwoFactorConfig struct {
+	// EncryptionSecret encrypts the TOTP secrets at rest and the login
+	// challenges. It must be 32 bytes long, and be kept secret and stable:
+	// losing it disables the second factor of every customer.
+	EncryptionSecret []byte `redact:"true"`
+
+	// Issuer names the service in authenticator apps.
+	Issuer string
+
+	// Params are the parameters of the TOTP codes.
+	Params otp.Params
+
+	// ChallengeTTL is how long a customer has to enter their code after
+	// entering their password.
+	ChallengeTTL time.Duration
+
+	// StepUpTTL is how long a session stays stepped up after its customer
+	// last entered a code.
+	StepUpTTL time.Duration
+
+	// MaxAttempts is the number of consecutive wrong codes after which the
+	// second factor of a customer is locked.
+	MaxAttempts int
+
+	// Lockout is how long a lock lasts. Wrong codes older than Lockout are
+	// forgotten.
+	Lockout time.Duration
+}
+
+func (c TwoFactorConfig) Validate() error {
+	if len(c.EncryptionSecret) != encryption.KeyLength || c.Issuer == "" || strings.Contains(c.Issuer, ":") {
+		return ErrTwoFactorConfig
+	}
+
+	if c.Params.Validate() != nil {
+		return ErrTwoFactorConfig
+	}
+
+	if c.ChallengeTTL <= 0 || c.StepUpTTL <= 0 || c.MaxAttempts <= 0 || c.Lockout <= 0 {
+		return ErrTwoFactorConfig
+	}
+
+	return nil
+}
+
+func (c TwoFactorConfig) Value() any {
+	return c
+}
+
+// Enrollment is a TOTP secret waiting for its first code.
+type Enrollment struct {
+	// Secret is the base32 secret, for customers who type it into their
+	// authenticator app.
+	Secret string
+
+	// URI is the otpauth:// provisioning URI, shown as a QR code.
+	URI string
+}
+
+// TwoFactorService lets customers protect their account with a TOTP
+// authenticator app, and challenges them for a code at login and before
+// sensitive actions. Each customer also gets single-use recovery codes, for
+// when they lose their authenticator.
+type TwoFactorService struct {
+	customers ports.CustomerRepository
+	store     ports.TwoFactorStore
+	sessions  *SessionService
+	failures  ports.LoginFailures
+	audit     ports.AuditLog
+	aead      *encryption.AESGCM
+	config    TwoFactorConfig
+	now       func() time.Time
+}
+
+func NewTwoFactorService(
+	customers ports.CustomerRepository,
+	store ports.TwoFactorStore,
+	sessions *SessionService,
+	failures ports.LoginFailures,
+	audit ports.AuditLog,
+	config TwoFactorConfig,
+) (*TwoFactorService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := encryption.NewAESGCM(config.EncryptionSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorService{
+		customers: customers,
+		store:     store,
+		sessions:  sessions,
+		failures:  failures,
+		audit:     audit,
+		aead:      aead,
+		config:    config,
+		now:       time.Now,
+	}, nil
+}
+
+// Enabled reports whether a customer confirmed their TOTP enrollment.
+func (s *TwoFactorService) Enabled(ctx context.Context, customerID int) (bool, error) {
+	t, err := s.store.TOTP(ctx, customerID)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to find totp: %w", err)
+	}
+
+	return t.Confirmed, nil
+}
+
+// BeginEnrollment draws a new TOTP secret for a customer, replacing any
+// enrollment left unconfirmed. The second factor is only enabled once
+// ConfirmEnrollment proves the customer's app generates the right codes.
+func (s *TwoFactorService) BeginEnrollment(ctx context.Context, customerID int) (Enrollment, error) {
+	enabled, err := s.Enabled(ctx, customerID)
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	if enabled {
+		return Enrollment{}, ErrTwoFactorEnabled
+	}
+
+	c, err := s.customers.CustomerByID(ctx, customerID)
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	secret := otp.NewSecret()
+
+	err = s.store.SaveTOTP(ctx, domain.TOTP{
+		CustomerID:   customerID,
+		SealedSecret: s.aead.Seal(secret, secretAAD(customerID)),
+		Confirmed:    false,
+		LastCounter:  0,
+		Created:      s.now().UTC(),
+	})
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("failed to save totp: %w", err)
+	}
+
+	return Enrollment{
+		Secret: otp.EncodeSecret(secret),
+		URI:    s.config.Params.URI(s.config.Issuer, c.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables the second factor of the customer of a session
+// with a first code from their app, steps the session up, and returns the
+// recovery codes. They are only ever shown this once.
+func (s *TwoFactorService) ConfirmEnrollment(
+	ctx context.Context,
+	session domain.Session,
+	code, ip string,
+) ([]string, error) {
+	err := s.admit(ctx, session.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.store.TOTP(ctx, session.CustomerID)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return nil, ErrEnrollmentNotStarted
+	case err != nil:
+		return nil, fmt.Errorf("failed to find totp: %w", err)
+	case t.Confirmed:
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := s.aead.Open(t.SealedSecret, secretAAD(t.CustomerID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	counter, ok := s.config.Params.Verify(secret, code, s.now())
+	if !ok {
+		return nil, s.recordFailure(ctx, t.CustomerID)
+	}
+
+	t.Confirmed = true
+	t.LastCounter = counter
+
+	err = s.store.SaveTOTP(ctx, t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save totp: %w", err)
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, t.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.succeed(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, s.record(ctx, domain.AuditTwoFactorEnabled, t.CustomerID, ip, "totp enrolled")
+}
+
+// Disable turns the second factor of the customer of a session off, after
+// checking a code.
+func (s *TwoFactorService) Disable(ctx context.Context, session domain.Session, code, ip string) error {
+	err := s.verify(ctx, session.CustomerID, code, ip)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.DeleteTOTP(ctx, session.CustomerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete totp: %w", err)
+	}
+
+	return s.record(ctx, domain.AuditTwoFactorDisabled, session.CustomerID, ip, "totp removed")
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the customer of a
+// session, after checking a code, and returns the new ones.
+func (s *TwoFactorService) RegenerateRecoveryCodes(
+	ctx context.Context,
+	session domain.Session,
+	code, ip string,
+) ([]string, error) {
+	err := s.verify(ctx, session.CustomerID, code, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, session.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.succeed(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, s.record(ctx, domain.AuditRecoveryCodesRegenerated, session.CustomerID, ip, "previous codes revoked")
+}
+
+// StepUp checks a code from the customer of a session, and marks the
+// session as stepped up, which RequireStepUp then lets through for a while.
+func (s *TwoFactorService) StepUp(ctx context.Context, session domain.Session, code, ip string) error {
+	err := s.verify(ctx, session.CustomerID, code, ip)
+	if err != nil {
+		return err
+	}
+
+	return s.succeed(ctx, session)
+}
+
+// RequireStepUp returns ErrStepUpRequired when the customer of a session
+// enabled their second factor but did not enter a code recently. Customers
+// without a second factor are let through.
+func (s *TwoFactorService) RequireStepUp(ctx context.Context, session domain.Session) error {
+	enabled, err := s.Enabled(ctx, session.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	if enabled && !s.now().Before(session.SteppedUp.Add(s.config.StepUpTTL)) {
+		return ErrStepUpRequired
+	}
+
+	return nil
+}
+
+// NewChallenge returns the challenge of a customer who entered their
+// password and must now enter a code, and its expiry. The challenge is
+// sealed rather than stored: it carries the customer ID and expiry, which
+// the client can neither read nor forge.
+func (s *TwoFactorService) NewChallenge(customerID int) (string, time.Time) {
+	expires := s.now().Add(s.config.ChallengeTTL).UTC().Truncate(time.Second)
+
+	payload := make([]byte, 0, challengePayloadLength)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(customerID)) //nolint:gosec // IDs are positive
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expires.Unix()))
+
+	return base64.RawURLEncoding.EncodeToString(s.aead.Seal(payload, []byte(challengeAAD))), expires
+}
+
+// CompleteChallenge checks the code entered against a login challenge, and
+// returns the customer who may now be issued a session.
+func (s *TwoFactorService) CompleteChallenge(
+	ctx context.Context,
+	challenge, code, ip string,
+) (domain.Customer, error) {
+	customerID, err := s.openChallenge(challenge)
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	err = s.verify(ctx, customerID, code, ip)
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	c, err := s.customers.CustomerByID(ctx, customerID)
+	if err != nil {
+		return domain.Customer{}, fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *TwoFactorService) openChallenge(challenge string) (int, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(challenge)
+	if err != nil {
+		return 0, ErrChallengeInvalid
+	}
+
+	payload, err := s.aead.Open(sealed, []byte(challengeAAD))
+	if err != nil || len(payload) != challengePayloadLength {
+		return 0, ErrChallengeInvalid
+	}
+
+	customerID := int(binary.BigEndian.Uint64(payload[0:8]))                     //nolint:gosec // sealed by us
+	expires := time.Unix(int64(binary.BigEndian.Uint64(payload[8:16])), 0).UTC() //nolint:gosec // sealed by us
+
+	if !s.now().Before(expires) {
+		return 0, ErrChallengeExpired
+	}
+
+	return customerID, nil
+}
+
+// verify checks a TOTP or recovery code of a customer. A TOTP code is only
+// accepted once, and a recovery code is used up. Too many wrong codes lock
+// the second factor with a *RetryError.
+func (s *TwoFactorService) verify(ctx context.Context, customerID int, code, ip string) error {
+	err := s.admit(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	t, err := s.store.TOTP(ctx, customerID)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return ErrTwoFactorDisabled
+	case err != nil:
+		return fmt.Errorf("failed to find totp: %w", err)
+	case !t.Confirmed:
+		return ErrTwoFactorDisabled
+	}
+
+	secret, err := s.aead.Open(t.SealedSecret, secretAAD(customerID))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	if counter, ok := s.config.Params.Verify(secret, code, s.now()); ok {
+		err = s.store.AdvanceTOTPCounter(ctx, customerID, counter)
+		switch {
+		case errors.Is(err, ports.ErrConflict):
+			return s.recordFailure(ctx, customerID)
+		case err != nil:
+			return fmt.Errorf("failed to advance totp counter: %w", err)
+		}
+
+		return s.resetFailures(ctx, customerID)
+	}
+
+	left, err := s.store.ConsumeRecoveryCode(ctx, customerID, hashRecoveryCode(code))
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return s.recordFailure(ctx, customerID)
+	case err != nil:
+		return fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+
+	err = s.resetFailures(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	return s.record(ctx, domain.AuditRecoveryCodeUsed, customerID, ip, fmt.Sprintf("%d recovery codes left", left))
+}
+
+// admit rejects the attempt while the second factor of the customer is
+// locked.
+func (s *TwoFactorService) admit(ctx context.Context, customerID int) error {
+	key := failureKey(customerID)
+
+	count, last, err := s.failures.LoginFailures(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to read two-factor failures: %w", err)
+	}
+
+	now := s.now()
+
+	switch {
+	case count == 0:
+		return nil
+	case now.Sub(last) >= s.config.Lockout:
+		return s.resetFailures(ctx, customerID)
+	case count >= s.config.MaxAttempts:
+		return &RetryError{Err: ErrTwoFactorLocked, RetryAfter: last.Add(s.config.Lockout).Sub(now)}
+	default:
+		return nil
+	}
+}
+
+// recordFailure counts a wrong code and returns ErrInvalidCode.
+func (s *TwoFactorService) recordFailure(ctx context.Context, customerID int) error {
+	_, err := s.failures.RecordLoginFailure(ctx, failureKey(customerID), s.now())
+	if err != nil {
+		return fmt.Errorf("failed to record two-factor failure: %w", err)
+	}
+
+	return ErrInvalidCode
+}
+
+func (s *TwoFactorService) resetFailures(ctx context.Context, customerID int) error {
+	err := s.failures.ResetLoginFailures(ctx, failureKey(customerID))
+	if err != nil {
+		return fmt.Errorf("failed to reset two-factor failures: %w", err)
+	}
+
+	return nil
+}
+
+// succeed steps up a session whose customer just entered a valid code.
+func (s *TwoFactorService) succeed(ctx context.Context, session domain.Session) error {
+	if session.ID == "" {
+		return nil
+	}
+
+	_, err := s.sessions.StepUp(ctx, session.ID)
+
+	return err
+}
+
+// replaceRecoveryCodes draws new recovery codes for a customer, stores
+// their digests and returns them.
+func (s *TwoFactorService) replaceRecoveryCodes(ctx context.Context, customerID int) ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([][]byte, RecoveryCodeCount)
+
+	for i := range codes {
+		codes[i] = newRecoveryCode()
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	err := s.store.ReplaceRecoveryCodes(ctx, customerID, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (s *TwoFactorService) record(
+	ctx context.Context,
+	t domain.AuditEventType,
+	customerID int,
+	ip, detail string,
+) error {
+	subject := strconv.Itoa(customerID)
+
+	c, err := s.customers.CustomerByID(ctx, customerID)
+	if err == nil {
+		subject = c.Email
+	}
+
+	err = s.audit.RecordAudit(ctx, domain.AuditEvent{
+		Type:    t,
+		Subject: subject,
+		IP:      ip,
+		Time:    s.now().UTC(),
+		Detail:  detail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// newRecoveryCode returns a random recovery code, formatted as four groups
+// of four base32 characters, such as "ABCD-EFGH-IJKL-MNOP".
+func newRecoveryCode() string {
+	b := make([]byte, recoveryCodeLength)
+	_, _ = rand.Read(b)
+
+	s := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+
+	return s[0:4] + "-" + s[4:8] + "-" + s[8:12] + "-" + s[12:16]
+}
+
+// hashRecoveryCode returns the digest of a recovery code, ignoring case,
+// spaces and dashes, which customers type as they like.
+func hashRecoveryCode(code string) []byte {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, strings.ToUpper(code))
+
+	h := sha256.Sum256([]byte(normalized))
+
+	return h[:]
+}
+
+// secretAAD binds a sealed TOTP secret to its customer, so that a secret
+// copied to another row does not decrypt.
+func secretAAD(customerID int) []byte {
+	return []byte("totp:" + strconv.Itoa(customerID))
+}
+
+func failureKey(customerID int) string {
+	return "totp:" + strconv.Itoa(customerID)
+}
+
+type TwoFactorError string
+
+func (e TwoFactorError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrTwoFactorConfig      TwoFactorError = "two-factor key must be 32 bytes, issuer set, totp valid and durations and attempts positive"
+	ErrTwoFactorEnabled     TwoFactorError = "two-factor authentication is already enabled"
+	ErrTwoFactorDisabled    TwoFactorError = "two-factor authentication is not enabled"
+	ErrEnrollmentNotStarted TwoFactorError = "two-factor enrollment was not started"
+	ErrInvalidCode          TwoFactorError = "code is invalid"
+	ErrTwoFactorLocked      TwoFactorError = "too many invalid codes"
+	ErrStepUpRequired       TwoFactorError = "a two-factor code is required"
+	ErrChallengeInvalid     TwoFactorError = "login challenge is invalid"
+	ErrChallengeExpired     TwoFactorError = "login challenge is expired"
+)
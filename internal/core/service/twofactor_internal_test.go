@@ -0,0 +1,332 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/otp"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+type twoFactorTest struct {
+	*TwoFactorService
+	store    *dal.MemoryTwoFactor
+	sessions *SessionService
+	audit    *dal.MemoryAuditLog
+	session  domain.Session
+	clock    time.Time
+}
+
+func (f *twoFactorTest) advance(d time.Duration) {
+	f.clock = f.clock.Add(d)
+}
+
+// code returns the current TOTP code of secret.
+func (f *twoFactorTest) code(secret []byte) string {
+	p := otp.DefaultParams()
+	return p.Code(secret, p.Counter(f.clock))
+}
+
+// enroll enables the second factor of the customer, and returns their
+// secret and recovery codes.
+func (f *twoFactorTest) enroll(t *testing.T) ([]byte, []string) {
+	t.Helper()
+
+	e, err := f.BeginEnrollment(t.Context(), f.session.CustomerID)
+	assert.NoError(t, err)
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(e.Secret)
+	assert.NoError(t, err)
+
+	codes, err := f.ConfirmEnrollment(t.Context(), f.session, f.code(secret), "10.0.0.1")
+	assert.NoError(t, err)
+
+	// The enrollment code is used up.
+	f.advance(otp.DefaultParams().Period)
+
+	return secret, codes
+}
+
+func newTwoFactorService(
+	t *testing.T,
+	customers ports.CustomerRepository,
+	store ports.TwoFactorStore,
+	sessions *SessionService,
+	audit ports.AuditLog,
+) *TwoFactorService {
+	t.Helper()
+
+	svc, err := NewTwoFactorService(customers, store, sessions, dal.NewMemoryLoginFailures(), audit, TwoFactorConfig{
+		EncryptionSecret: []byte(strings.Repeat("k", 32)),
+		Issuer:           "BROKE DA EAR",
+		Params:           otp.DefaultParams(),
+		ChallengeTTL:     5 * time.Minute,
+		StepUpTTL:        10 * time.Minute,
+		MaxAttempts:      3,
+		Lockout:          15 * time.Minute,
+	})
+	assert.NoError(t, err)
+
+	return svc
+}
+
+func newTwoFactorTest(t *testing.T) *twoFactorTest {
+	t.Helper()
+
+	customers := dal.NewMemoryCustomers()
+
+	c, err := customers.CreateCustomer(t.Context(), domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          true,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	sessions, err := NewSessionService(dal.NewMemorySessions(), SessionConfig{
+		IdleTimeout:      time.Hour,
+		AbsoluteLifetime: 3 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	store := dal.NewMemoryTwoFactor()
+	audit := dal.NewMemoryAuditLog()
+
+	ft := &twoFactorTest{
+		TwoFactorService: newTwoFactorService(t, customers, store, sessions, audit),
+		store:            store,
+		sessions:         sessions,
+		audit:            audit,
+		session:          domain.Session{},
+		clock:            time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	ft.now = func() time.Time { return ft.clock }
+	sessions.now = func() time.Time { return ft.clock }
+
+	_, ft.session, err = sessions.Create(t.Context(), c.ID, SessionMeta{UserAgent: "test", IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	return ft
+}
+
+func TestTwoFactorService_Enrollment(t *testing.T) {
+	f := newTwoFactorTest(t)
+
+	e, err := f.BeginEnrollment(t.Context(), f.session.CustomerID)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(e.URI, "otpauth://totp/BROKE%20DA%20EAR:jane@example.com?"))
+
+	// The secret is encrypted at rest.
+	stored, err := f.store.TOTP(t.Context(), f.session.CustomerID)
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(string(stored.SealedSecret), e.Secret))
+
+	enabled, err := f.Enabled(t.Context(), f.session.CustomerID)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(e.Secret)
+	assert.NoError(t, err)
+
+	_, err = f.ConfirmEnrollment(t.Context(), f.session, "000000", "10.0.0.1")
+	assert.Error(t, err, ErrInvalidCode)
+
+	codes, err := f.ConfirmEnrollment(t.Context(), f.session, f.code(secret), "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, len(codes), RecoveryCodeCount)
+
+	enabled, err = f.Enabled(t.Context(), f.session.CustomerID)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	_, err = f.BeginEnrollment(t.Context(), f.session.CustomerID)
+	assert.Error(t, err, ErrTwoFactorEnabled)
+
+	events := f.audit.Events()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, domain.AuditTwoFactorEnabled)
+	assert.Equal(t, events[0].Subject, "jane@example.com")
+}
+
+func TestTwoFactorService_CodesAreSingleUse(t *testing.T) {
+	f := newTwoFactorTest(t)
+	secret, _ := f.enroll(t)
+
+	code := f.code(secret)
+
+	err := f.StepUp(t.Context(), f.session, code, "10.0.0.1")
+	assert.NoError(t, err)
+
+	err = f.StepUp(t.Context(), f.session, code, "10.0.0.1")
+	assert.Error(t, err, ErrInvalidCode)
+}
+
+func TestTwoFactorService_ClockSkew(t *testing.T) {
+	f := newTwoFactorTest(t)
+	secret, _ := f.enroll(t)
+
+	// A code of the previous period is still accepted.
+	code := f.code(secret)
+	f.advance(otp.DefaultParams().Period)
+
+	err := f.StepUp(t.Context(), f.session, code, "10.0.0.1")
+	assert.NoError(t, err)
+
+	// Older codes are not.
+	code = f.code(secret)
+	f.advance(2 * otp.DefaultParams().Period)
+
+	err = f.StepUp(t.Context(), f.session, code, "10.0.0.1")
+	assert.Error(t, err, ErrInvalidCode)
+}
+
+func TestTwoFactorService_RecoveryCodes(t *testing.T) {
+	f := newTwoFactorTest(t)
+	_, codes := f.enroll(t)
+
+	// Recovery codes are accepted in any case and without dashes, once.
+	code := strings.ToLower(strings.ReplaceAll(codes[0], "-", ""))
+
+	err := f.StepUp(t.Context(), f.session, code, "10.0.0.1")
+	assert.NoError(t, err)
+
+	err = f.StepUp(t.Context(), f.session, codes[0], "10.0.0.1")
+	assert.Error(t, err, ErrInvalidCode)
+
+	events := f.audit.Events()
+	assert.Equal(t, events[len(events)-1].Type, domain.AuditRecoveryCodeUsed)
+	assert.Equal(t, events[len(events)-1].Detail, "9 recovery codes left")
+
+	fresh, err := f.RegenerateRecoveryCodes(t.Context(), f.session, codes[1], "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, len(fresh), RecoveryCodeCount)
+
+	// The previous codes are revoked.
+	err = f.StepUp(t.Context(), f.session, codes[2], "10.0.0.1")
+	assert.Error(t, err, ErrInvalidCode)
+}
+
+func TestTwoFactorService_Lockout(t *testing.T) {
+	f := newTwoFactorTest(t)
+	secret, _ := f.enroll(t)
+
+	for range 3 {
+		err := f.StepUp(t.Context(), f.session, "000000", "10.0.0.1")
+		assert.Error(t, err, ErrInvalidCode)
+	}
+
+	err := f.StepUp(t.Context(), f.session, f.code(secret), "10.0.0.1")
+	assert.Equal(t, retryAfter(t, err, ErrTwoFactorLocked), 15*time.Minute)
+
+	f.advance(15 * time.Minute)
+
+	err = f.StepUp(t.Context(), f.session, f.code(secret), "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestTwoFactorService_StepUp(t *testing.T) {
+	f := newTwoFactorTest(t)
+
+	// Customers without a second factor are let through.
+	err := f.RequireStepUp(t.Context(), f.session)
+	assert.NoError(t, err)
+
+	secret, _ := f.enroll(t)
+
+	// Confirming the enrollment stepped the session up.
+	sessions, err := f.sessions.List(t.Context(), f.session.CustomerID)
+	assert.NoError(t, err)
+
+	sess := sessions[0]
+	assert.NoError(t, f.RequireStepUp(t.Context(), sess))
+
+	f.advance(10 * time.Minute)
+	assert.Error(t, f.RequireStepUp(t.Context(), sess), ErrStepUpRequired)
+
+	err = f.StepUp(t.Context(), sess, f.code(secret), "10.0.0.1")
+	assert.NoError(t, err)
+
+	sessions, err = f.sessions.List(t.Context(), f.session.CustomerID)
+	assert.NoError(t, err)
+	assert.NoError(t, f.RequireStepUp(t.Context(), sessions[0]))
+}
+
+func TestTwoFactorService_Disable(t *testing.T) {
+	f := newTwoFactorTest(t)
+	secret, codes := f.enroll(t)
+
+	err := f.Disable(t.Context(), f.session, "000000", "10.0.0.1")
+	assert.Error(t, err, ErrInvalidCode)
+
+	err = f.Disable(t.Context(), f.session, f.code(secret), "10.0.0.1")
+	assert.NoError(t, err)
+
+	enabled, err := f.Enabled(t.Context(), f.session.CustomerID)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	err = f.StepUp(t.Context(), f.session, codes[0], "10.0.0.1")
+	assert.Error(t, err, ErrTwoFactorDisabled)
+
+	events := f.audit.Events()
+	assert.Equal(t, events[len(events)-1].Type, domain.AuditTwoFactorDisabled)
+}
+
+func TestTwoFactorService_Challenge(t *testing.T) {
+	f := newTwoFactorTest(t)
+	secret, _ := f.enroll(t)
+
+	challenge, expires := f.NewChallenge(f.session.CustomerID)
+	assert.True(t, expires.Equal(f.clock.Add(5*time.Minute)))
+
+	_, err := f.CompleteChallenge(t.Context(), challenge+"x", f.code(secret), "10.0.0.1")
+	assert.Error(t, err, ErrChallengeInvalid)
+
+	_, err = f.CompleteChallenge(t.Context(), challenge, "000000", "10.0.0.1")
+	assert.Error(t, err, ErrInvalidCode)
+
+	c, err := f.CompleteChallenge(t.Context(), challenge, f.code(secret), "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, f.session.CustomerID)
+
+	f.advance(5 * time.Minute)
+
+	_, err = f.CompleteChallenge(t.Context(), challenge, f.code(secret), "10.0.0.1")
+	assert.Error(t, err, ErrChallengeExpired)
+}
+
+func TestTwoFactorConfig_Validate(t *testing.T) {
+	valid := TwoFactorConfig{
+		EncryptionSecret: []byte(strings.Repeat("k", 32)),
+		Issuer:           "BROKE DA EAR",
+		Params:           otp.DefaultParams(),
+		ChallengeTTL:     time.Minute,
+		StepUpTTL:        time.Minute,
+		MaxAttempts:      1,
+		Lockout:          time.Minute,
+	}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.EncryptionSecret = invalid.EncryptionSecret[:16]
+	assert.Error(t, invalid.Validate(), ErrTwoFactorConfig)
+
+	invalid = valid
+	invalid.Issuer = "BROKE:DA:EAR"
+	assert.Error(t, invalid.Validate(), ErrTwoFactorConfig)
+
+	invalid = valid
+	invalid.MaxAttempts = 0
+	assert.Error(t, invalid.Validate(), ErrTwoFactorConfig)
+}
@@ -0,0 +1,265 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// UpgradeOffer is the upgrade price of a plugin for a customer, who owns
+// the license From.
+type UpgradeOffer struct {
+	Product   domain.Product
+	From      domain.License
+	ListPrice domain.Money
+	Price     domain.Money
+}
+
+// UpgradeService prices plugins for the customers that own licenses of
+// other plugins, or of older versions of the same one, by the upgrade
+// rules staff declare. A license backs one upgrade: once a license was
+// upgraded from, it stays the customer's, but makes them eligible to no
+// other upgrade, unless the license upgraded to is revoked. While an
+// order upgrading from a license waits for its payment, the license backs
+// upgrades to the same product only. Checking out such an upgrade again
+// cancels the earlier pending order, and a license that still backs two
+// paid upgrades keeps the one issued first: the other is refunded.
+type UpgradeService struct {
+	rules    ports.UpgradeRuleStore
+	licenses ports.LicenseStore
+	orders   ports.OrderStore
+	products ports.ProductRepository
+	now      func() time.Time
+}
+
+func NewUpgradeService(
+	rules ports.UpgradeRuleStore,
+	licenses ports.LicenseStore,
+	orders ports.OrderStore,
+	products ports.ProductRepository,
+) *UpgradeService {
+	return &UpgradeService{
+		rules:    rules,
+		licenses: licenses,
+		orders:   orders,
+		products: products,
+		now:      time.Now,
+	}
+}
+
+// Rules returns every upgrade rule, by ID.
+func (s *UpgradeService) Rules(ctx context.Context) ([]domain.UpgradeRule, error) {
+	rules, err := s.rules.UpgradeRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upgrade rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// CreateRule creates the upgrade rule r, between plugins of the catalog.
+func (s *UpgradeService) CreateRule(ctx context.Context, r domain.UpgradeRule) (domain.UpgradeRule, error) {
+	err := domain.ValidateUpgradeRule(r)
+	if err != nil {
+		return domain.UpgradeRule{}, err
+	}
+
+	for _, id := range []int{r.FromProductID, r.ToProductID} {
+		p, err := s.products.ProductByID(ctx, id)
+		if errors.Is(err, ports.ErrNotFound) {
+			return domain.UpgradeRule{}, ErrProductNotFound
+		}
+
+		if err != nil {
+			return domain.UpgradeRule{}, fmt.Errorf("failed to find product: %w", err)
+		}
+
+		if p.Type != domain.ProductPlugin {
+			return domain.UpgradeRule{}, ErrUpgradeUnavailable
+		}
+	}
+
+	r.ID = 0
+	r.Created = s.now().UTC()
+
+	r, err = s.rules.CreateUpgradeRule(ctx, r)
+	if err != nil {
+		return domain.UpgradeRule{}, fmt.Errorf("failed to create upgrade rule: %w", err)
+	}
+
+	return r, nil
+}
+
+// DeleteRule deletes the upgrade rule with id. Orders placed at its price
+// keep it.
+func (s *UpgradeService) DeleteRule(ctx context.Context, id int) error {
+	err := s.rules.DeleteUpgradeRule(ctx, id)
+	if errors.Is(err, ports.ErrNotFound) {
+		return ErrUpgradeRuleNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete upgrade rule: %w", err)
+	}
+
+	return nil
+}
+
+// Apply returns c, priced for customerID, with the upgrade prices the
+// licenses of the customer make them eligible to. Guests, with no
+// licenses, get c as it is.
+func (s *UpgradeService) Apply(ctx context.Context, customerID int, c domain.PricedCart) (domain.PricedCart, error) {
+	if customerID == 0 {
+		return c, nil
+	}
+
+	rules, sources, err := s.eligibility(ctx, customerID)
+	if err != nil || len(rules) == 0 || len(sources) == 0 {
+		return c, err
+	}
+
+	return domain.ApplyUpgrades(c, rules, sources)
+}
+
+// Offers returns the upgrade prices of the published plugins in currency
+// the licenses of a customer make them eligible to, for customers in
+// region. Each offer stands alone: buying one may spend the license that
+// backs another.
+func (s *UpgradeService) Offers(
+	ctx context.Context,
+	customerID int,
+	currency domain.Currency,
+	region domain.Region,
+) ([]UpgradeOffer, error) {
+	err := currency.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	err = region.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	rules, sources, err := s.eligibility(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	offers := make([]UpgradeOffer, 0)
+	if len(rules) == 0 || len(sources) == 0 {
+		return offers, nil
+	}
+
+	products, err := s.products.Products(ctx, ports.ProductFilter{
+		Types:         []domain.ProductType{domain.ProductPlugin},
+		PublishedOnly: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	for _, p := range products {
+		list, ok := p.Price(currency, region)
+		if !ok {
+			continue
+		}
+
+		u, price, ok := domain.BestUpgrade(rules, sources, p.ID, list, region)
+		if !ok {
+			continue
+		}
+
+		i := slices.IndexFunc(sources, func(s domain.UpgradeSource) bool { return s.License.ID == u.FromLicenseID })
+		offers = append(offers, UpgradeOffer{Product: p, From: sources[i].License, ListPrice: list, Price: price})
+	}
+
+	return offers, nil
+}
+
+// eligibility returns the upgrade rules, and the licenses of a customer
+// that may back them: valid licenses bought, not trials, that were not
+// upgraded from already.
+func (s *UpgradeService) eligibility(
+	ctx context.Context,
+	customerID int,
+) ([]domain.UpgradeRule, []domain.UpgradeSource, error) {
+	rules, err := s.Rules(ctx)
+	if err != nil || len(rules) == 0 {
+		return nil, nil, err
+	}
+
+	licenses, err := s.licenses.CustomerLicenses(ctx, customerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list licenses: %w", err)
+	}
+
+	// Gifts are never upgrades, so the licenses upgraded to from the
+	// licenses of a customer are theirs as well.
+	spent := make(map[int]bool)
+
+	for _, l := range licenses {
+		if l.UpgradedFrom != 0 && l.Revoked.IsZero() {
+			spent[l.UpgradedFrom] = true
+		}
+	}
+
+	orders, err := s.orders.CustomerOrders(ctx, customerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	reserved := make(map[int]int)
+
+	for _, o := range orders {
+		for _, line := range o.Lines {
+			if line.UpgradeFrom == 0 {
+				continue
+			}
+
+			// Paid orders spend their licenses before the licenses they
+			// upgrade to are issued.
+			switch o.Status {
+			case domain.OrderPending:
+				reserved[line.UpgradeFrom] = line.ProductID
+			case domain.OrderCanceled, domain.OrderRefunded:
+				// The upgrade was called off, and its license is free.
+			default:
+				spent[line.UpgradeFrom] = true
+			}
+		}
+	}
+
+	now := s.now()
+	sources := make([]domain.UpgradeSource, 0, len(licenses))
+
+	for _, l := range licenses {
+		if l.Trial() || !l.Valid(now) || spent[l.ID] {
+			continue
+		}
+
+		sources = append(sources, domain.UpgradeSource{License: l, ReservedFor: reserved[l.ID]})
+	}
+
+	return rules, sources, nil
+}
+
+type UpgradeError string
+
+func (e UpgradeError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrUpgradeRuleNotFound UpgradeError = "upgrade rule not found"
+	ErrUpgradeUnavailable  UpgradeError = "upgrade rules only go from a plugin to a plugin"
+)
@@ -0,0 +1,443 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+type upgradeTest struct {
+	*UpgradeService
+	carts    *CartService
+	licenses *licenseTest
+
+	// microwave is the first Microwave, microwave2 its successor, oven
+	// another plugin and shirt a piece of merchandise, all at 49 dollars.
+	microwave, microwave2, oven, shirt domain.Product
+
+	// owned is the license of customer 1 of the first Microwave, version
+	// 1.2.0.
+	owned domain.License
+}
+
+// newUpgradeTest returns an upgrade service with two rules: owners of the
+// first Microwave upgrade to Microwave 2 for 29 dollars, and get 20% off
+// the oven.
+func newUpgradeTest(t *testing.T) *upgradeTest {
+	t.Helper()
+
+	ctx := t.Context()
+	licenses := newLicenseTest(t, licenseConfig())
+	products := dal.NewMemoryProducts()
+
+	add := func(slug, version string, typ domain.ProductType) domain.Product {
+		p, err := products.CreateProduct(ctx, domain.Product{
+			ID:           0,
+			Type:         typ,
+			Slug:         slug,
+			Name:         slug,
+			Description:  "",
+			Version:      version,
+			MachineLimit: 0,
+			Media:        nil,
+			Published:    true,
+			Position:     0,
+			Prices:       []domain.Price{{Region: domain.RegionDefault, Money: dollars(4900)}},
+			PriceID:      "price_" + slug,
+			ProductID:    "prod_" + slug,
+			Created:      time.Time{},
+			Updated:      time.Time{},
+		})
+		assert.NoError(t, err)
+
+		return p
+	}
+
+	svc := NewUpgradeService(dal.NewMemoryUpgradeRules(), licenses.licenses, licenses.orders.orders, products)
+	svc.now = func() time.Time { return licenses.orders.clock }
+
+	carts, err := NewCartService(dal.NewMemoryCarts(), products, svc, CartConfig{
+		Secret:      []byte(strings.Repeat("s", minCartSecretLength)),
+		GuestTTL:    7 * 24 * time.Hour,
+		CustomerTTL: 90 * 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	u := &upgradeTest{
+		UpgradeService: svc,
+		carts:          carts,
+		licenses:       licenses,
+		microwave:      add("microwave", "1.2.0", domain.ProductPlugin),
+		microwave2:     add("microwave-2", "2.0.0", domain.ProductPlugin),
+		oven:           add("oven", "1.0.0", domain.ProductPlugin),
+		shirt:          add("shirt", "", domain.ProductMerchandise),
+		owned:          domain.License{}, //nolint:exhaustruct // set below
+	}
+
+	u.owned = u.license(t, 1, u.microwave, domain.EditionStandard)
+
+	_, err = svc.CreateRule(ctx, u.rule(u.microwave.ID, "1.", u.microwave2.ID, dollars(2900), 0))
+	assert.NoError(t, err)
+
+	_, err = svc.CreateRule(ctx, u.rule(u.microwave.ID, "", u.oven.ID, domain.Money{}, 20))
+	assert.NoError(t, err)
+
+	return u
+}
+
+// license creates a license of customerID for p, of its current version.
+func (u *upgradeTest) license(t *testing.T, customerID int, p domain.Product, edition domain.LicenseEdition) domain.License {
+	t.Helper()
+
+	clock := u.licenses.orders.clock
+
+	l, err := u.licenses.licenses.CreateLicense(t.Context(), domain.License{
+		ID:           0,
+		Key:          domain.NewLicenseKey(),
+		OrderID:      100 + customerID,
+		Line:         0,
+		Seat:         0,
+		CustomerID:   customerID,
+		ProductID:    p.ID,
+		Version:      p.Version,
+		Edition:      edition,
+		Issued:       clock,
+		Expires:      time.Time{},
+		Revoked:      time.Time{},
+		RevokeReason: "",
+		UpgradedFrom: 0,
+	})
+	assert.NoError(t, err)
+
+	return l
+}
+
+func (u *upgradeTest) rule(from int, version string, to int, price domain.Money, percent int) domain.UpgradeRule {
+	var prices []domain.Price
+	if !price.IsZero() {
+		prices = []domain.Price{{Region: domain.RegionDefault, Money: price}}
+	}
+
+	return domain.UpgradeRule{
+		ID:            0,
+		FromProductID: from,
+		FromVersion:   version,
+		ToProductID:   to,
+		Prices:        prices,
+		PercentOff:    percent,
+		Created:       time.Time{},
+	}
+}
+
+// price prices a cart of customerID with a line of each of products.
+func (u *upgradeTest) price(t *testing.T, customerID int, products ...domain.Product) domain.PricedCart {
+	t.Helper()
+
+	c := domain.Cart{
+		ID:         "",
+		CustomerID: customerID,
+		Lines:      nil,
+		Created:    time.Time{},
+		Updated:    time.Time{},
+		Expires:    time.Time{},
+	}
+
+	for _, p := range products {
+		c.Lines = append(c.Lines, cartLine(p.ID, 1, false))
+	}
+
+	priced, err := u.carts.Price(t.Context(), c, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+
+	return priced
+}
+
+// place places the order of a checkout session of customer 1, with
+// sessionID, for priced.
+func (u *upgradeTest) place(t *testing.T, sessionID string, priced domain.PricedCart) (domain.Order, domain.CheckoutSession) {
+	t.Helper()
+
+	session := domain.CheckoutSession{
+		ID:                sessionID,
+		URL:               "https://checkout.example.com/" + sessionID,
+		Reference:         "",
+		CustomerID:        1,
+		PaymentCustomerID: "cus_1",
+		Status:            domain.CheckoutOpen,
+		PaymentStatus:     domain.PaymentUnpaid,
+		PaymentID:         "",
+		Total:             priced.Total,
+		Expires:           time.Time{},
+	}
+
+	order, err := u.licenses.orders.Place(t.Context(), 1, "checkout-"+sessionID, priced)
+	assert.NoError(t, err)
+
+	order, err = u.licenses.orders.AttachCheckout(t.Context(), order, session)
+	assert.NoError(t, err)
+
+	return order, session
+}
+
+func TestUpgradeService_Rules(t *testing.T) {
+	svc := newUpgradeTest(t)
+	ctx := t.Context()
+
+	rules, err := svc.Rules(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(rules), 2)
+	assert.True(t, rules[0].Created.Equal(svc.licenses.orders.clock))
+
+	for _, tt := range []struct {
+		name string
+		rule domain.UpgradeRule
+		want error
+	}{
+		{"unknown product", svc.rule(svc.microwave.ID, "", 1000, domain.Money{}, 20), ErrProductNotFound},
+		{"merchandise", svc.rule(svc.microwave.ID, "", svc.shirt.ID, domain.Money{}, 20), ErrUpgradeUnavailable},
+		{"no discount", svc.rule(svc.microwave.ID, "", svc.oven.ID, domain.Money{}, 0), domain.ErrInvalidUpgradeDiscount},
+		{"same product", svc.rule(svc.oven.ID, "", svc.oven.ID, domain.Money{}, 20), domain.ErrInvalidUpgradeProducts},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateRule(ctx, tt.rule)
+			assert.Error(t, err, tt.want)
+		})
+	}
+
+	assert.NoError(t, svc.DeleteRule(ctx, rules[1].ID))
+	assert.Error(t, svc.DeleteRule(ctx, rules[1].ID), ErrUpgradeRuleNotFound)
+
+	// Without the crossgrade rule, the oven is at its list price.
+	priced := svc.price(t, 1, svc.oven)
+	assert.Equal(t, priced.Total, dollars(4900))
+}
+
+func TestUpgradeService_Apply(t *testing.T) {
+	svc := newUpgradeTest(t)
+
+	// Guests and customers without licenses pay list prices.
+	for _, customerID := range []int{0, 2} {
+		priced := svc.price(t, customerID, svc.microwave2, svc.oven)
+		assert.Equal(t, priced.Total, dollars(9800))
+		assert.True(t, priced.Lines[0].Upgrade == nil)
+	}
+
+	// One license backs one line: the upgrade, listed first.
+	priced := svc.price(t, 1, svc.microwave2, svc.oven, svc.shirt)
+	assert.Equal(t, priced.Lines[0].UnitPrice, dollars(2900))
+	assert.Equal(t, *priced.Lines[0].Upgrade, domain.Upgrade{
+		RuleID:        1,
+		FromLicenseID: svc.owned.ID,
+		ListPrice:     dollars(4900),
+	})
+	assert.True(t, priced.Lines[1].Upgrade == nil)
+	assert.Equal(t, priced.Total, dollars(2900+4900+4900))
+
+	priced = svc.price(t, 1, svc.oven)
+	assert.Equal(t, priced.Lines[0].UnitPrice, dollars(3920))
+
+	// Trials and expired licenses make no one eligible.
+	svc.license(t, 3, svc.microwave, domain.EditionTrial)
+
+	priced = svc.price(t, 3, svc.microwave2)
+	assert.True(t, priced.Lines[0].Upgrade == nil)
+
+	_, err := svc.Offers(t.Context(), 1, "XXX", domain.RegionDefault)
+	assert.Error(t, err, domain.ErrInvalidCurrency)
+
+	offers, err := svc.Offers(t.Context(), 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+	assert.Equal(t, len(offers), 2)
+	assert.Equal(t, offers[0].Product.ID, svc.microwave2.ID)
+	assert.Equal(t, offers[0].From.ID, svc.owned.ID)
+	assert.Equal(t, offers[0].Price, dollars(2900))
+	assert.Equal(t, offers[1].ListPrice, dollars(4900))
+	assert.Equal(t, offers[1].Price, dollars(3920))
+}
+
+func TestUpgradeService_Stacking(t *testing.T) {
+	svc := newUpgradeTest(t)
+	ctx := t.Context()
+	licenses := svc.licenses
+
+	order, session := svc.place(t, "cs_1", svc.price(t, 1, svc.microwave2))
+	assert.Equal(t, order.Lines[0].UpgradeFrom, svc.owned.ID)
+	assert.Equal(t, order.Total, dollars(2900))
+
+	// While the upgrade waits for its payment, its license backs no
+	// crossgrade, but checking the upgrade out again keeps its price.
+	assert.True(t, svc.price(t, 1, svc.oven).Lines[0].Upgrade == nil)
+	assert.Equal(t, svc.price(t, 1, svc.microwave2).Total, dollars(2900))
+
+	// Canceling the upgrade frees its license.
+	_, err := licenses.orders.Cancel(ctx, 9, order.ID, "abandoned")
+	assert.NoError(t, err)
+	assert.Equal(t, svc.price(t, 1, svc.oven).Total, dollars(3920))
+
+	// Once paid, the upgrade is issued a license that records its lineage,
+	// and the license upgraded from backs nothing more.
+	_, session = svc.place(t, "cs_2", svc.price(t, 1, svc.microwave2))
+	licenses.orders.pay(t, session)
+	assert.True(t, svc.price(t, 1, svc.oven).Lines[0].Upgrade == nil)
+
+	assert.NoError(t, licenses.IssueLicenses(ctx, paidEvent(session)))
+
+	bought, err := licenses.CustomerLicenses(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(bought), 2)
+
+	upgraded := bought[1].License
+	assert.Equal(t, upgraded.ProductID, svc.microwave2.ID)
+	assert.Equal(t, upgraded.Version, "2.0.0")
+	assert.Equal(t, upgraded.UpgradedFrom, svc.owned.ID)
+
+	lineage, err := licenses.Lineage(ctx, upgraded.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, len(lineage), 2)
+	assert.Equal(t, lineage[0].ID, upgraded.ID)
+	assert.Equal(t, lineage[1].ID, svc.owned.ID)
+
+	// The license upgraded from stays valid.
+	assert.True(t, lineage[1].Valid(licenses.orders.clock))
+
+	assert.True(t, svc.price(t, 1, svc.oven).Lines[0].Upgrade == nil)
+
+	offers, err := svc.Offers(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+	assert.Equal(t, len(offers), 0)
+}
+
+func TestUpgradeService_OneOpenUpgrade(t *testing.T) {
+	svc := newUpgradeTest(t)
+	ctx := t.Context()
+	orders := svc.licenses.orders
+	customers := dal.NewMemoryCustomers()
+
+	_, err := customers.CreateCustomer(ctx, domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          true,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	checkout, err := NewCheckoutService(customers, svc.carts, orders.payments, orders.OrderService,
+		dal.NewMemoryAuditLog(), CheckoutConfig{
+			SuccessURL: "https://brokedaear.com/checkout/success?session={CHECKOUT_SESSION_ID}",
+			CancelURL:  "https://brokedaear.com/cart",
+		})
+	assert.NoError(t, err)
+
+	owner := CartOwner{CustomerID: 1, GuestToken: ""}
+
+	_, err = svc.carts.AddLine(ctx, owner, cartLine(svc.microwave2.ID, 1, false))
+	assert.NoError(t, err)
+
+	first, err := checkout.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+
+	// Checking the upgrade out in another cart cancels the first order and
+	// expires its session, so that the license backs a single payment.
+	_, err = svc.carts.AddLine(ctx, owner, cartLine(svc.oven.ID, 1, false))
+	assert.NoError(t, err)
+
+	second, err := checkout.Checkout(ctx, 1, domain.CurrencyUSD, domain.RegionDefault)
+	assert.NoError(t, err)
+	assert.NotEqual(t, second.ID, first.ID)
+	assert.Equal(t, len(orders.payments.expired), 1)
+	assert.Equal(t, orders.payments.expired[0], first.ID)
+
+	superseded, err := orders.OrderByCheckout(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, superseded.Status, domain.OrderCanceled)
+
+	open, err := orders.OrderByCheckout(ctx, second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, open.Status, domain.OrderPending)
+	assert.Equal(t, open.Lines[0].UpgradeFrom, svc.owned.ID)
+}
+
+func TestUpgradeService_DoubleSpend(t *testing.T) {
+	svc := newUpgradeTest(t)
+	ctx := t.Context()
+	licenses := svc.licenses
+
+	// Two upgrades from the same license paid at once, such as in sessions
+	// opened before either was superseded.
+	priced := svc.price(t, 1, svc.microwave2)
+	_, first := svc.place(t, "cs_1", priced)
+	second, session := svc.place(t, "cs_2", priced)
+
+	licenses.orders.pay(t, first)
+	licenses.orders.pay(t, session)
+
+	assert.NoError(t, licenses.IssueLicenses(ctx, paidEvent(first)))
+	assert.NoError(t, licenses.IssueLicenses(ctx, paidEvent(session)))
+	assert.NoError(t, licenses.IssueLicenses(ctx, paidEvent(session)))
+
+	// The upgrade issued second is revoked and refunded, once.
+	issued, err := licenses.OrderLicenses(ctx, second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(issued), 1)
+	assert.False(t, issued[0].Revoked.IsZero())
+
+	got, err := licenses.orders.Order(ctx, second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderRefunded)
+	assert.Equal(t, got.Refunded, dollars(2900))
+	assert.Equal(t, len(licenses.orders.payments.refunds), 1)
+
+	events := licenses.audit.Events()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, domain.AuditUpgradeDoubleSpent)
+
+	bought, err := licenses.CustomerLicenses(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(bought), 3)
+	assert.True(t, bought[1].Revoked.IsZero())
+}
+
+func TestUpgradeService_DoubleSpendAfterOtherRefund(t *testing.T) {
+	svc := newUpgradeTest(t)
+	ctx := t.Context()
+	licenses := svc.licenses
+
+	priced := svc.price(t, 1, svc.microwave2)
+	_, first := svc.place(t, "cs_1", priced)
+	second, session := svc.place(t, "cs_2", svc.price(t, 1, svc.microwave2, svc.shirt))
+
+	licenses.orders.pay(t, first)
+	licenses.orders.pay(t, session)
+
+	assert.NoError(t, licenses.IssueLicenses(ctx, paidEvent(first)))
+
+	_, err := licenses.issue(ctx, second)
+	assert.NoError(t, err)
+
+	// The shirt of the second order is refunded before its upgrade is
+	// found spent, which does not count as the refund of the upgrade.
+	got, err := licenses.orders.Refund(ctx, 5, second.ID, dollars(4900), "shirt out of stock")
+	assert.NoError(t, err)
+
+	assert.NoError(t, licenses.refundDoubleSpends(ctx, got))
+	assert.NoError(t, licenses.refundDoubleSpends(ctx, got))
+
+	got, err = licenses.orders.Order(ctx, second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, got.Status, domain.OrderRefunded)
+	assert.Equal(t, got.Refunded, dollars(4900+2900))
+
+	refunds := licenses.orders.payments.refunds
+	assert.Equal(t, len(refunds), 2)
+	assert.Equal(t, refunds[1].Amount, dollars(2900))
+}
@@ -0,0 +1,276 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+const (
+	// minVerificationSecretLength is the shortest accepted signing secret, the
+	// output size of SHA-256.
+	minVerificationSecretLength = 32
+
+	verificationNonceLength = 16
+
+	// verificationPayloadLength is the length of the signed part of a
+	// token: the customer ID, the expiry and the nonce.
+	verificationPayloadLength = 8 + 8 + verificationNonceLength
+)
+
+// VerificationConfig configures email verification.
+type VerificationConfig struct {
+	// Secret signs verification tokens. It must be at least 32 bytes long and
+	// be kept secret: anyone holding it can forge tokens.
+	Secret []byte `redact:"true"`
+
+	// TTL is how long a verification token can be used.
+	TTL time.Duration
+
+	// ResendInterval is the shortest time between two verification emails
+	// to the same customer.
+	ResendInterval time.Duration
+
+	// LinkURL is the page the verification email links to. The token is
+	// added to it as the "token" query parameter.
+	LinkURL string
+}
+
+func (c VerificationConfig) Validate() error {
+	if len(c.Secret) < minVerificationSecretLength || c.TTL <= 0 || c.ResendInterval < 0 {
+		return ErrVerificationConfig
+	}
+
+	u, err := url.Parse(c.LinkURL)
+	if err != nil || !u.IsAbs() {
+		return ErrVerificationConfig
+	}
+
+	return nil
+}
+
+func (c VerificationConfig) Value() any {
+	return c
+}
+
+// VerificationService verifies that customers own their email, by mailing
+// them a link with a signed, single-use and expiring token.
+type VerificationService struct {
+	customers     ports.CustomerRepository
+	verifications ports.VerificationStore
+	mailer        ports.Mailer
+	config        VerificationConfig
+	now           func() time.Time
+}
+
+func NewVerificationService(
+	customers ports.CustomerRepository,
+	verifications ports.VerificationStore,
+	mailer ports.Mailer,
+	config VerificationConfig,
+) (*VerificationService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &VerificationService{
+		customers:     customers,
+		verifications: verifications,
+		mailer:        mailer,
+		config:        config,
+		now:           time.Now,
+	}, nil
+}
+
+// Send mails a verification link to a customer, replacing any link sent
+// before. Sending again within the resend interval is rejected with a
+// *RetryError.
+func (s *VerificationService) Send(ctx context.Context, customerID int) error {
+	c, err := s.customers.CustomerByID(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	if c.Verified {
+		return ErrAlreadyVerified
+	}
+
+	now := s.now().UTC()
+
+	prev, err := s.verifications.Verification(ctx, customerID)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("failed to find verification: %w", err)
+	default:
+		wait := prev.Created.Add(s.config.ResendInterval).Sub(now)
+		if wait > 0 {
+			return &RetryError{Err: ErrVerificationThrottled, RetryAfter: wait}
+		}
+	}
+
+	nonce := make([]byte, verificationNonceLength)
+	_, _ = rand.Read(nonce)
+
+	expires := now.Add(s.config.TTL)
+
+	err = s.verifications.SaveVerification(ctx, domain.Verification{
+		CustomerID: customerID,
+		NonceHash:  hashNonce(nonce),
+		Created:    now,
+		Expires:    expires,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save verification: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, domain.Mail{
+		To:      c.Email,
+		Subject: "Verify your email",
+		Text: fmt.Sprintf(
+			"Welcome to BROKE DA EAR!\n\nOpen the link below to verify your email. It expires in %s.\n\n%s\n\n"+
+				"If you did not sign up, you can ignore this email.\n",
+			s.config.TTL,
+			tokenLink(s.config.LinkURL, s.sign(customerID, expires, nonce)),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// Verify marks the customer a token was issued to as verified, and returns
+// their ID. A token is rejected when it is forged, expired, or was already
+// used or replaced by a newer one.
+func (s *VerificationService) Verify(ctx context.Context, token string) (int, error) {
+	customerID, expires, nonce, err := s.parse(token)
+	if err != nil {
+		return 0, err
+	}
+
+	if !s.now().Before(expires) {
+		return 0, ErrVerificationExpired
+	}
+
+	_, err = s.verifications.ConsumeVerification(ctx, customerID, hashNonce(nonce))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return 0, ErrVerificationInvalid
+		}
+
+		return 0, fmt.Errorf("failed to consume verification: %w", err)
+	}
+
+	err = s.customers.MarkCustomerVerified(ctx, customerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark customer verified: %w", err)
+	}
+
+	return customerID, nil
+}
+
+// RequireVerified returns ErrCustomerUnverified unless the customer
+// verified their email.
+func (s *VerificationService) RequireVerified(ctx context.Context, customerID int) error {
+	c, err := s.customers.CustomerByID(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to find customer: %w", err)
+	}
+
+	if !c.Verified {
+		return ErrCustomerUnverified
+	}
+
+	return nil
+}
+
+// tokenLink returns the link to page carrying token as its "token" query
+// parameter. The page must be a valid URL.
+func tokenLink(page, token string) string {
+	u, _ := url.Parse(page)
+
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// sign returns a token made of the payload and its HMAC-SHA256, both
+// base64url encoded and separated by a dot.
+func (s *VerificationService) sign(customerID int, expires time.Time, nonce []byte) string {
+	payload := make([]byte, 0, verificationPayloadLength)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(customerID)) //nolint:gosec // IDs are positive
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expires.Unix()))
+	payload = append(payload, nonce...)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+func (s *VerificationService) parse(token string) (int, time.Time, []byte, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, time.Time{}, nil, ErrVerificationInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != verificationPayloadLength {
+		return 0, time.Time{}, nil, ErrVerificationInvalid
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.mac(payload)) {
+		return 0, time.Time{}, nil, ErrVerificationInvalid
+	}
+
+	customerID := int(binary.BigEndian.Uint64(payload[0:8]))                     //nolint:gosec // signed by us
+	expires := time.Unix(int64(binary.BigEndian.Uint64(payload[8:16])), 0).UTC() //nolint:gosec // signed by us
+
+	return customerID, expires, payload[16:], nil
+}
+
+func (s *VerificationService) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, s.config.Secret)
+	h.Write(payload)
+
+	return h.Sum(nil)
+}
+
+func hashNonce(nonce []byte) []byte {
+	h := sha256.Sum256(nonce)
+	return h[:]
+}
+
+type VerificationError string
+
+func (e VerificationError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrVerificationConfig    VerificationError = "verification secret must be at least 32 bytes, ttl positive and link url absolute"
+	ErrVerificationInvalid   VerificationError = "verification token is invalid"
+	ErrVerificationExpired   VerificationError = "verification token is expired"
+	ErrVerificationThrottled VerificationError = "verification email was sent recently"
+	ErrAlreadyVerified       VerificationError = "email is already verified"
+	ErrCustomerUnverified    VerificationError = "email must be verified first"
+)
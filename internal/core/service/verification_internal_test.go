@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+)
+
+// outbox is a mailer keeping the emails it is given.
+type outbox struct {
+	mails []domain.Mail
+}
+
+func (o *outbox) Send(_ context.Context, m domain.Mail) error {
+	o.mails = append(o.mails, m)
+	return nil
+}
+
+var linkPattern = regexp.MustCompile(`https://\S+`)
+
+// token returns the token of the last verification link mailed.
+func (o *outbox) token(t *testing.T) string {
+	t.Helper()
+
+	assert.True(t, len(o.mails) > 0)
+
+	u, err := url.Parse(linkPattern.FindString(o.mails[len(o.mails)-1].Text))
+	assert.NoError(t, err)
+
+	return u.Query().Get("token")
+}
+
+type verificationTest struct {
+	*VerificationService
+	customers *dal.MemoryCustomers
+	outbox    *outbox
+	customer  domain.Customer
+	clock     time.Time
+}
+
+func (v *verificationTest) advance(d time.Duration) {
+	v.clock = v.clock.Add(d)
+}
+
+func newVerificationTest(t *testing.T) *verificationTest {
+	t.Helper()
+
+	customers := dal.NewMemoryCustomers()
+	c, err := customers.CreateCustomer(t.Context(), domain.Customer{
+		ID:                0,
+		Email:             "jane@example.com",
+		HashedPassword:    nil,
+		Created:           time.Time{},
+		Verified:          false,
+		PaymentCustomerID: "",
+	})
+	assert.NoError(t, err)
+
+	out := &outbox{mails: nil}
+
+	svc, err := NewVerificationService(customers, dal.NewMemoryVerifications(), out, VerificationConfig{
+		Secret:         []byte(strings.Repeat("s", 32)),
+		TTL:            time.Hour,
+		ResendInterval: time.Minute,
+		LinkURL:        "https://brokedaear.com/verify",
+	})
+	assert.NoError(t, err)
+
+	vt := &verificationTest{
+		VerificationService: svc,
+		customers:           customers,
+		outbox:              out,
+		customer:            c,
+		clock:               time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	svc.now = func() time.Time { return vt.clock }
+
+	return vt
+}
+
+func TestVerificationService_Verify(t *testing.T) {
+	v := newVerificationTest(t)
+
+	err := v.RequireVerified(t.Context(), v.customer.ID)
+	assert.Error(t, err, ErrCustomerUnverified)
+
+	err = v.Send(t.Context(), v.customer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, v.outbox.mails[0].To, "jane@example.com")
+
+	token := v.outbox.token(t)
+
+	id, err := v.Verify(t.Context(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, id, v.customer.ID)
+
+	err = v.RequireVerified(t.Context(), v.customer.ID)
+	assert.NoError(t, err)
+
+	// Tokens are single use.
+	_, err = v.Verify(t.Context(), token)
+	assert.Error(t, err, ErrVerificationInvalid)
+
+	err = v.Send(t.Context(), v.customer.ID)
+	assert.Error(t, err, ErrAlreadyVerified)
+}
+
+func TestVerificationService_Expired(t *testing.T) {
+	v := newVerificationTest(t)
+
+	err := v.Send(t.Context(), v.customer.ID)
+	assert.NoError(t, err)
+
+	v.advance(time.Hour)
+
+	_, err = v.Verify(t.Context(), v.outbox.token(t))
+	assert.Error(t, err, ErrVerificationExpired)
+}
+
+func TestVerificationService_Forged(t *testing.T) {
+	v := newVerificationTest(t)
+
+	err := v.Send(t.Context(), v.customer.ID)
+	assert.NoError(t, err)
+
+	token := v.outbox.token(t)
+	payload, mac, _ := strings.Cut(token, ".")
+
+	for _, forged := range []string{
+		"",
+		"garbage",
+		payload,
+		payload + "." + strings.Repeat("A", len(mac)),
+		strings.Repeat("A", len(payload)) + "." + mac,
+	} {
+		_, err = v.Verify(t.Context(), forged)
+		assert.Error(t, err, ErrVerificationInvalid)
+	}
+
+	// A token signed with another secret is rejected too.
+	other := *v.VerificationService
+	other.config.Secret = []byte(strings.Repeat("o", 32))
+
+	_, err = v.Verify(t.Context(), other.sign(v.customer.ID, v.clock.Add(time.Hour), make([]byte, verificationNonceLength)))
+	assert.Error(t, err, ErrVerificationInvalid)
+}
+
+func TestVerificationService_ResendThrottled(t *testing.T) {
+	v := newVerificationTest(t)
+
+	err := v.Send(t.Context(), v.customer.ID)
+	assert.NoError(t, err)
+
+	first := v.outbox.token(t)
+
+	v.advance(30 * time.Second)
+
+	err = v.Send(t.Context(), v.customer.ID)
+
+	var retryErr *RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Error(t, err, ErrVerificationThrottled)
+	assert.Equal(t, retryErr.RetryAfter, 30*time.Second)
+
+	v.advance(30 * time.Second)
+
+	err = v.Send(t.Context(), v.customer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, len(v.outbox.mails), 2)
+
+	// The new link replaces the previous one.
+	_, err = v.Verify(t.Context(), first)
+	assert.Error(t, err, ErrVerificationInvalid)
+
+	_, err = v.Verify(t.Context(), v.outbox.token(t))
+	assert.NoError(t, err)
+}
+
+func TestVerificationConfig_Validate(t *testing.T) {
+	valid := VerificationConfig{
+		Secret:         []byte(strings.Repeat("s", 32)),
+		TTL:            time.Hour,
+		ResendInterval: 0,
+		LinkURL:        "https://brokedaear.com/verify",
+	}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.Secret = []byte("short")
+	assert.Error(t, invalid.Validate(), ErrVerificationConfig)
+
+	invalid = valid
+	invalid.LinkURL = "/verify"
+	assert.Error(t, invalid.Validate(), ErrVerificationConfig)
+}
@@ -0,0 +1,288 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// WebhookConfig configures the processing of the webhook events of the
+// payment provider.
+type WebhookConfig struct {
+	// MaxAttempts is how many times an event is dispatched before it is
+	// given up on as dead.
+	MaxAttempts int
+
+	// RetryDelay is the delay before the first retry of a failed event. It
+	// doubles with every retry, up to MaxRetryDelay.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// Lease is how long a claimed event is hidden from other dispatchers. It
+	// must be longer than dispatching a batch takes, or events may be
+	// dispatched twice at the same time.
+	Lease time.Duration
+
+	// BatchSize is how many events are claimed at once.
+	BatchSize int
+
+	// Retention is how long processed events are kept. It must be longer
+	// than the provider retries deliveries for, so that late deliveries are
+	// still recognized as duplicates.
+	Retention time.Duration
+}
+
+func (c WebhookConfig) Validate() error {
+	if c.MaxAttempts <= 0 || c.RetryDelay <= 0 || c.MaxRetryDelay < c.RetryDelay {
+		return ErrWebhookConfig
+	}
+
+	if c.Lease <= 0 || c.BatchSize <= 0 || c.Retention <= 0 {
+		return ErrWebhookConfig
+	}
+
+	return nil
+}
+
+func (c WebhookConfig) Value() any {
+	return c
+}
+
+// WebhookHandler processes an event of the payment provider. Handlers may
+// be run more than once for an event, after a failure of theirs or of
+// another handler of the event, and must be idempotent.
+type WebhookHandler func(ctx context.Context, e domain.PaymentEvent) error
+
+// WebhookService receives the webhook events of the payment provider and
+// dispatches them to their handlers. Received events are stored in an
+// inbox first, which makes every event processed once however many times
+// it is delivered, and lets failed events be retried with backoff. Events
+// that keep failing are set aside as dead, for staff to replay.
+type WebhookService struct {
+	inbox    ports.WebhookInbox
+	webhook  ports.PaymentWebhook
+	config   WebhookConfig
+	handlers map[domain.PaymentEventType][]WebhookHandler
+	now      func() time.Time
+}
+
+func NewWebhookService(
+	inbox ports.WebhookInbox,
+	webhook ports.PaymentWebhook,
+	config WebhookConfig,
+) (*WebhookService, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookService{
+		inbox:    inbox,
+		webhook:  webhook,
+		config:   config,
+		handlers: make(map[domain.PaymentEventType][]WebhookHandler),
+		now:      time.Now,
+	}, nil
+}
+
+// Handle registers h for the events of type t, after the handlers already
+// registered for it. Events without handlers are processed by doing
+// nothing. Handlers must be registered before events are dispatched.
+func (s *WebhookService) Handle(t domain.PaymentEventType, h WebhookHandler) {
+	s.handlers[t] = append(s.handlers[t], h)
+}
+
+// Receive verifies a webhook delivery and stores its event in the inbox,
+// to be dispatched. Deliveries of events received before are accepted and
+// ignored. Deliveries that are not signed by the provider fail with
+// ErrWebhookInvalid.
+func (s *WebhookService) Receive(ctx context.Context, payload []byte, signature string) (domain.WebhookEvent, error) {
+	e, err := s.webhook.VerifyEvent(payload, signature)
+	if err != nil {
+		return domain.WebhookEvent{}, fmt.Errorf("%w: %w", ErrWebhookInvalid, err)
+	}
+
+	now := s.now().UTC()
+	event := domain.WebhookEvent{
+		ID:          e.ID,
+		Type:        e.Type,
+		Payload:     payload,
+		Received:    now,
+		Status:      domain.WebhookPending,
+		Attempts:    0,
+		LastError:   "",
+		NextAttempt: now,
+		Processed:   time.Time{},
+	}
+
+	err = s.inbox.SaveWebhookEvent(ctx, event)
+	if errors.Is(err, ports.ErrConflict) {
+		return s.Event(ctx, e.ID)
+	}
+
+	if err != nil {
+		return domain.WebhookEvent{}, fmt.Errorf("failed to save webhook event: %w", err)
+	}
+
+	return event, nil
+}
+
+// Dispatch dispatches the events due, a batch at a time, and returns how
+// many were processed.
+func (s *WebhookService) Dispatch(ctx context.Context) (int, error) {
+	now := s.now().UTC()
+
+	events, err := s.inbox.ClaimWebhookEvents(ctx, now, now.Add(s.config.Lease), s.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook events: %w", err)
+	}
+
+	var (
+		processed int
+		errs      []error
+	)
+
+	for _, e := range events {
+		e = s.dispatch(ctx, e)
+
+		err = s.inbox.UpdateWebhookEvent(ctx, e)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to update webhook event %s: %w", e.ID, err))
+			continue
+		}
+
+		if e.Status == domain.WebhookProcessed {
+			processed++
+		}
+	}
+
+	return processed, errors.Join(errs...)
+}
+
+// dispatch runs the handlers of e, and returns e updated with the outcome.
+func (s *WebhookService) dispatch(ctx context.Context, e domain.WebhookEvent) domain.WebhookEvent {
+	event, err := s.webhook.ParseEvent(e.Payload)
+	if err == nil {
+		for _, h := range s.handlers[e.Type] {
+			err = h(ctx, event)
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	now := s.now().UTC()
+
+	if err == nil {
+		e.Status = domain.WebhookProcessed
+		e.Processed = now
+		e.LastError = ""
+
+		return e
+	}
+
+	e.Attempts++
+	e.LastError = err.Error()
+
+	if e.Attempts >= s.config.MaxAttempts {
+		e.Status = domain.WebhookDead
+		return e
+	}
+
+	e.NextAttempt = now.Add(s.backoff(e.Attempts))
+
+	return e
+}
+
+// backoff returns the delay before the retry following failed attempt
+// number attempts, counting from 1.
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	d := s.config.RetryDelay
+	for range attempts - 1 {
+		if d > s.config.MaxRetryDelay/2 {
+			return s.config.MaxRetryDelay
+		}
+
+		d *= 2
+	}
+
+	return d
+}
+
+// Event returns the event of the inbox with id.
+func (s *WebhookService) Event(ctx context.Context, id string) (domain.WebhookEvent, error) {
+	e, err := s.inbox.WebhookEvent(ctx, id)
+	if errors.Is(err, ports.ErrNotFound) {
+		return domain.WebhookEvent{}, ErrWebhookEventNotFound
+	}
+
+	if err != nil {
+		return domain.WebhookEvent{}, fmt.Errorf("failed to find webhook event: %w", err)
+	}
+
+	return e, nil
+}
+
+// Events returns up to limit events of the inbox with status, most
+// recently received first. The dead events are the dead-letter queue.
+func (s *WebhookService) Events(ctx context.Context, status domain.WebhookStatus, limit int) ([]domain.WebhookEvent, error) {
+	events, err := s.inbox.WebhookEvents(ctx, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Replay queues the event with id to be dispatched again right away, with
+// a fresh count of attempts. It is meant for dead events, once what made
+// them fail is fixed, but processed events can be replayed too.
+func (s *WebhookService) Replay(ctx context.Context, id string) (domain.WebhookEvent, error) {
+	e, err := s.Event(ctx, id)
+	if err != nil {
+		return domain.WebhookEvent{}, err
+	}
+
+	e.Status = domain.WebhookPending
+	e.Attempts = 0
+	e.LastError = ""
+	e.NextAttempt = s.now().UTC()
+	e.Processed = time.Time{}
+
+	err = s.inbox.UpdateWebhookEvent(ctx, e)
+	if errors.Is(err, ports.ErrNotFound) {
+		return domain.WebhookEvent{}, ErrWebhookEventNotFound
+	}
+
+	if err != nil {
+		return domain.WebhookEvent{}, fmt.Errorf("failed to update webhook event: %w", err)
+	}
+
+	return e, nil
+}
+
+// Sweep deletes the events processed longer ago than the retention, and
+// returns how many were deleted.
+func (s *WebhookService) Sweep(ctx context.Context) (int, error) {
+	return s.inbox.DeleteProcessedWebhookEvents(ctx, s.now().UTC().Add(-s.config.Retention))
+}
+
+type WebhookError string
+
+func (e WebhookError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrWebhookConfig        WebhookError = "webhook attempts, delays, lease, batch size and retention must be positive"
+	ErrWebhookInvalid       WebhookError = "webhook delivery is invalid"
+	ErrWebhookEventNotFound WebhookError = "webhook event not found"
+)
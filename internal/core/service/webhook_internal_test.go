@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2025 BROKE DA EAR LLC <https://brokedaear.com>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"backend.brokedaear.com/app/dal"
+	"backend.brokedaear.com/internal/common/tests/assert"
+	"backend.brokedaear.com/internal/core/domain"
+	"backend.brokedaear.com/internal/core/ports"
+)
+
+// fakeWebhook is a ports.PaymentWebhook accepting deliveries signed
+// "valid", of payloads holding only an event ID and type.
+type fakeWebhook struct{}
+
+func (fakeWebhook) VerifyEvent(payload []byte, signature string) (domain.PaymentEvent, error) {
+	if signature != "valid" {
+		return domain.PaymentEvent{}, ports.ErrWebhookSignature
+	}
+
+	return fakeWebhook{}.ParseEvent(payload)
+}
+
+func (fakeWebhook) ParseEvent(payload []byte) (domain.PaymentEvent, error) {
+	var e struct {
+		ID   string                  `json:"id"`
+		Type domain.PaymentEventType `json:"type"`
+	}
+
+	err := json.Unmarshal(payload, &e)
+	if err != nil {
+		return domain.PaymentEvent{}, err
+	}
+
+	return domain.PaymentEvent{
+		ID:       e.ID,
+		Type:     e.Type,
+		Created:  time.Time{},
+		Checkout: nil,
+		Charge:   nil,
+		Dispute:  nil,
+	}, nil
+}
+
+type webhookTest struct {
+	*WebhookService
+	clock time.Time
+}
+
+func (w *webhookTest) advance(dur time.Duration) {
+	w.clock = w.clock.Add(dur)
+}
+
+func newWebhookTest(t *testing.T) *webhookTest {
+	t.Helper()
+
+	svc, err := NewWebhookService(dal.NewMemoryWebhookInbox(), fakeWebhook{}, WebhookConfig{
+		MaxAttempts:   3,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: time.Hour,
+		Lease:         5 * time.Minute,
+		BatchSize:     10,
+		Retention:     24 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	w := &webhookTest{
+		WebhookService: svc,
+		clock:          time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	svc.now = func() time.Time { return w.clock }
+
+	return w
+}
+
+func TestWebhookService_Receive(t *testing.T) {
+	w := newWebhookTest(t)
+	ctx := t.Context()
+	payload := []byte(`{"id":"evt_1","type":"checkout.session.completed"}`)
+
+	_, err := w.Receive(ctx, payload, "forged")
+	assert.Error(t, err, ErrWebhookInvalid)
+	assert.Error(t, err, ports.ErrWebhookSignature)
+
+	e, err := w.Receive(ctx, payload, "valid")
+	assert.NoError(t, err)
+	assert.Equal(t, e.ID, "evt_1")
+	assert.Equal(t, e.Type, domain.EventCheckoutCompleted)
+	assert.Equal(t, e.Status, domain.WebhookPending)
+
+	// Deliveries of the same event are accepted once.
+	var handled int
+
+	w.Handle(domain.EventCheckoutCompleted, func(context.Context, domain.PaymentEvent) error {
+		handled++
+		return nil
+	})
+
+	processed, err := w.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, processed, 1)
+
+	e, err = w.Receive(ctx, payload, "valid")
+	assert.NoError(t, err)
+	assert.Equal(t, e.Status, domain.WebhookProcessed)
+
+	processed, err = w.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, processed, 0)
+	assert.Equal(t, handled, 1)
+}
+
+func TestWebhookService_Dispatch(t *testing.T) {
+	w := newWebhookTest(t)
+	ctx := t.Context()
+	failure := errors.New("database is down")
+
+	var calls []string
+
+	w.Handle(domain.EventChargeRefunded, func(_ context.Context, e domain.PaymentEvent) error {
+		calls = append(calls, "first "+e.ID)
+		return nil
+	})
+	w.Handle(domain.EventChargeRefunded, func(_ context.Context, e domain.PaymentEvent) error {
+		calls = append(calls, "second "+e.ID)
+		return failure
+	})
+
+	_, err := w.Receive(ctx, []byte(`{"id":"evt_1","type":"charge.refunded"}`), "valid")
+	assert.NoError(t, err)
+
+	// Events without handlers are processed by doing nothing.
+	_, err = w.Receive(ctx, []byte(`{"id":"evt_2","type":"customer.created"}`), "valid")
+	assert.NoError(t, err)
+
+	processed, err := w.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, processed, 1)
+	assert.True(t, slices.Equal(calls, []string{"first evt_1", "second evt_1"}))
+
+	e, err := w.Event(ctx, "evt_1")
+	assert.NoError(t, err)
+	assert.Equal(t, e.Status, domain.WebhookPending)
+	assert.Equal(t, e.Attempts, 1)
+	assert.Equal(t, e.LastError, failure.Error())
+	assert.Equal(t, e.NextAttempt, w.clock.Add(time.Minute))
+
+	// Failed events wait for their retry, with a doubling delay.
+	processed, err = w.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, processed, 0)
+	assert.Equal(t, len(calls), 2)
+
+	w.advance(time.Minute)
+
+	_, err = w.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(calls), 4)
+
+	e, err = w.Event(ctx, "evt_1")
+	assert.NoError(t, err)
+	assert.Equal(t, e.Attempts, 2)
+	assert.Equal(t, e.NextAttempt, w.clock.Add(2*time.Minute))
+
+	// Events failing every attempt are dead.
+	w.advance(2 * time.Minute)
+
+	_, err = w.Dispatch(ctx)
+	assert.NoError(t, err)
+
+	dead, err := w.Events(ctx, domain.WebhookDead, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(dead), 1)
+	assert.Equal(t, dead[0].ID, "evt_1")
+	assert.Equal(t, dead[0].Attempts, 3)
+
+	w.advance(time.Hour)
+
+	_, err = w.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(calls), 6)
+
+	// Replayed events are dispatched again, with fresh attempts.
+	_, err = w.Replay(ctx, "nope")
+	assert.Error(t, err, ErrWebhookEventNotFound)
+
+	failure = nil
+
+	e, err = w.Replay(ctx, "evt_1")
+	assert.NoError(t, err)
+	assert.Equal(t, e.Status, domain.WebhookPending)
+	assert.Equal(t, e.Attempts, 0)
+
+	processed, err = w.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, processed, 1)
+	assert.Equal(t, len(calls), 8)
+
+	dead, err = w.Events(ctx, domain.WebhookDead, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(dead), 0)
+}
+
+func TestWebhookService_Sweep(t *testing.T) {
+	w := newWebhookTest(t)
+	ctx := t.Context()
+
+	_, err := w.Receive(ctx, []byte(`{"id":"evt_1","type":"customer.created"}`), "valid")
+	assert.NoError(t, err)
+
+	_, err = w.Receive(ctx, []byte(`{"id":"evt_2","type":"customer.created"}`), "valid")
+	assert.NoError(t, err)
+
+	_, err = w.Dispatch(ctx)
+	assert.NoError(t, err)
+
+	w.advance(12 * time.Hour)
+
+	_, err = w.Receive(ctx, []byte(`{"id":"evt_3","type":"customer.created"}`), "valid")
+	assert.NoError(t, err)
+
+	_, err = w.Dispatch(ctx)
+	assert.NoError(t, err)
+
+	w.advance(13 * time.Hour)
+
+	n, err := w.Sweep(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, n, 2)
+
+	_, err = w.Event(ctx, "evt_1")
+	assert.Error(t, err, ErrWebhookEventNotFound)
+
+	_, err = w.Event(ctx, "evt_3")
+	assert.NoError(t, err)
+}
+
+func TestWebhookConfig_Validate(t *testing.T) {
+	config := WebhookConfig{
+		MaxAttempts:   3,
+		RetryDelay:    time.Hour,
+		MaxRetryDelay: time.Minute,
+		Lease:         time.Minute,
+		BatchSize:     10,
+		Retention:     time.Hour,
+	}
+	assert.Error(t, config.Validate(), ErrWebhookConfig)
+
+	config.MaxRetryDelay = config.RetryDelay
+	assert.NoError(t, config.Validate())
+
+	config.BatchSize = 0
+	assert.Error(t, config.Validate(), ErrWebhookConfig)
+}